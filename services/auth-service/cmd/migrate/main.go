@@ -1,20 +1,29 @@
 package main
 
 import (
+	"auth-service/internal/config"
 	"auth-service/internal/migrations"
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	sharedDB "shared/database"
 )
 
+// connectTimeout bounds the initial database ping
+const connectTimeout = 10 * time.Second
+
 // CLI commands
 const (
 	CmdStatus   = "status"
@@ -22,6 +31,12 @@ const (
 	CmdValidate = "validate"
 	CmdRollback = "rollback"
 	CmdCreate   = "create"
+	CmdVerify   = "verify"
+	CmdRepair   = "repair"
+	CmdSeed     = "seed"
+	CmdDiff     = "diff"
+	CmdHistory  = "history"
+	CmdLint     = "lint"
 	CmdHelp     = "help"
 )
 
@@ -31,6 +46,24 @@ var (
 	dryRun      = flag.Bool("dry-run", false, "Show what would be done without executing")
 	verbose     = flag.Bool("v", false, "Verbose output")
 	force       = flag.Bool("force", false, "Force operation (use with caution)")
+	yes         = flag.Bool("yes", false, "Skip the typed confirmation for migrate and rollback in production")
+	toVersion   = flag.String("to", "", "Target migration version for migrate/rollback")
+	output      = flag.String("output", OutputText, "Output format (text, json)")
+	execute     = flag.Bool("execute", false, "With --dry-run, execute migrations in a transaction that is rolled back")
+	lockTimeout = flag.Duration("lock-timeout", 5*time.Second, "Lock wait limit for --dry-run --execute")
+	since       = flag.String("since", "", "History: only migrations applied on or after this date (YYYY-MM-DD or RFC 3339)")
+	until       = flag.String("until", "", "History: only migrations applied before the end of this date (YYYY-MM-DD or RFC 3339)")
+	allEnvs     = flag.Bool("all-envs", false, "History: include every environment instead of --env")
+	strict      = flag.Bool("strict", false, "Lint: fail on warnings as well as errors")
+	template    = flag.String("template", "", "Create: generate SQL from a template (add_column, create_table, add_index, add_enum_value)")
+	table       = flag.String("table", "", "Create: table name used by --template")
+	column      = flag.String("column", "", "Create: column name used by --template (comma-separated for add_index)")
+	fixOutput   = flag.String("fix-output", "", "Validate: write a fix-up migration for the reported drift to this file or directory")
+	report      = flag.String("report", "", "Validate: report format (markdown, json, junit), written to --out or stdout")
+	reportOut   = flag.String("out", "", "Validate: write the --report to this file")
+	backup      = flag.Bool("backup", false, "Migrate: dump the database before applying pending migrations")
+	backupDir   = flag.String("backup-dir", "backups", "Migrate: directory for --backup artifacts")
+	backupCmd   = flag.String("backup-command", "", "Migrate: command run instead of pg_dump for --backup; must write $BACKUP_FILE")
 )
 
 func main() {
@@ -51,18 +84,69 @@ func main() {
 		return
 	}
 
-	// Initialize database connection
-	db, err := initDatabase()
+	if *output != OutputText && *output != OutputJSON {
+		log.Fatalf("❌ Unknown output format: %s (use text or json)", *output)
+	}
+	if *report != "" && !slices.Contains(migrations.ReportFormats, *report) {
+		log.Fatalf("❌ Unknown report format: %s (use %s)", *report, strings.Join(migrations.ReportFormats, ", "))
+	}
+	if *reportOut != "" && *report == "" {
+		log.Fatalf("❌ --out requires --report")
+	}
+	if (flagProvided("backup-dir") || flagProvided("backup-command")) && !*backup {
+		log.Fatalf("❌ --backup-dir and --backup-command require --backup")
+	}
+	if reportToStdout() && jsonOutput() {
+		log.Fatalf("❌ --report without --out writes to stdout and can't be combined with --output=json")
+	}
+	if command == CmdMigrate && jsonOutput() && productionConfirmationRequired() {
+		log.Fatalf("❌ --output=json can't prompt for confirmation; pass --yes to migrate production")
+	}
+	if jsonOutput() || reportToStdout() {
+		// Keep stdout clean for the JSON document or report; diagnostics go to stderr
+		log.SetOutput(os.Stderr)
+	}
+
+	// Initialize database connection from --config (overridable with DB_* variables)
+	dbConfig, err := loadDatabaseConfig()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+
+	// Lint only reads migration files, so it runs in CI without a database
+	if command == CmdLint {
+		handleLint(dbConfig.MigrationPath)
+		return
+	}
+
+	// Create only writes a new file
+	if command == CmdCreate {
+		handleCreate()
+		return
+	}
+
+	db, err := initDatabase(dbConfig)
 	if err != nil {
 		log.Fatalf("❌ Failed to connect to database: %v", err)
 	}
 
 	// Initialize migration manager
-	migrationsDir := "migrations"
-	migrationManager, err := migrations.NewMigrationManager(db, migrationsDir, *environment)
+	migrationManager, err := migrations.NewMigrationManager(db, dbConfig.MigrationPath, *environment)
 	if err != nil {
 		log.Fatalf("❌ Failed to initialize migration manager: %v", err)
 	}
+	if *backup && !*dryRun {
+		migrationManager.SetBackup(&migrations.Backup{
+			Host:     dbConfig.Host,
+			Port:     dbConfig.Port,
+			Database: dbConfig.Name,
+			User:     dbConfig.User,
+			Password: dbConfig.Password,
+			SSLMode:  dbConfig.SSLMode,
+			Dir:      *backupDir,
+			Command:  *backupCmd,
+		})
+	}
 
 	// Execute command
 	switch command {
@@ -74,8 +158,16 @@ func main() {
 		handleValidate(db)
 	case CmdRollback:
 		handleRollback(migrationManager)
-	case CmdCreate:
-		handleCreate()
+	case CmdVerify:
+		handleVerify(migrationManager)
+	case CmdRepair:
+		handleRepair(migrationManager)
+	case CmdSeed:
+		handleSeed(db)
+	case CmdDiff:
+		handleDiff(db)
+	case CmdHistory:
+		handleHistory(migrationManager)
 	default:
 		fmt.Printf("❌ Unknown command: %s\n", command)
 		printHelp()
@@ -83,35 +175,26 @@ func main() {
 	}
 }
 
-func initDatabase() (*gorm.DB, error) {
-	// Get database configuration from environment variables
-	host := getEnvOrDefault("DB_HOST", "localhost")
-	user := getEnvOrDefault("DB_USER", "postgres")
-	password := getEnvOrDefault("DB_PASSWORD", "")
-	dbname := getEnvOrDefault("DB_NAME", "auth_db")
-	port := getEnvOrDefault("DB_PORT", "5432")
-
+func initDatabase(dbConfig config.DatabaseConfig) (*gorm.DB, error) {
 	// Build connection string
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
-		host, user, password, dbname, port,
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
+		dbConfig.Host, dbConfig.User, dbConfig.Password, dbConfig.Name, dbConfig.Port, dbConfig.SSLMode,
 	)
 
 	// Configure GORM for migration operations
-	config := &gorm.Config{
-		Logger: logger.New(
-			log.New(os.Stdout, "\r\n", log.LstdFlags),
-			logger.Config{
-				SlowThreshold:             200 * time.Millisecond,
-				LogLevel:                  getLogLevel(),
-				IgnoreRecordNotFoundError: false,
-				Colorful:                  true,
-			},
-		),
+	gormConfig := &gorm.Config{
+		Logger: sharedDB.NewGormLogger(sharedDB.GormLoggerConfig{
+			Level:         getLogLevel(),
+			SlowThreshold: dbConfig.SlowQueryThreshold,
+			Logger: slog.New(slog.NewTextHandler(gormLogWriter(), &slog.HandlerOptions{
+				Level: slog.LevelDebug,
+			})),
+		}),
 		DisableAutomaticPing:   false,
 		DisableForeignKeyConstraintWhenMigrating: false, // Important: keep FK constraints
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), config)
+	db, err := gorm.Open(postgres.Open(dsn), gormConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
 	}
@@ -122,21 +205,98 @@ func initDatabase() (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
 	}
 
-	if err := sqlDB.Ping(); err != nil {
+	if dbConfig.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	}
+	if dbConfig.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(dbConfig.MaxIdleConns)
+	}
+	if dbConfig.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	return db, nil
 }
 
-func getLogLevel() logger.LogLevel {
+// loadDatabaseConfig reads the [database] section of the --config TOML file used by the service
+// DB_* environment variables override file values; when the default config file is absent the
+// CLI falls back to environment variables alone so it keeps working in bare containers
+func loadDatabaseConfig() (config.DatabaseConfig, error) {
+	dbConfig := config.DatabaseConfig{
+		Host:               "localhost",
+		Port:               "5432",
+		Name:               "auth_db",
+		User:               "postgres",
+		SSLMode:            "disable",
+		MigrationPath:      "migrations",
+		SlowQueryThreshold: 200 * time.Millisecond,
+	}
+
+	if _, err := os.Stat(*configPath); err == nil {
+		cfg, err := config.LoadFile(*configPath)
+		if err != nil {
+			return dbConfig, err
+		}
+		dbConfig = cfg.Database
+		log.Printf("📄 Loaded database settings from %s", *configPath)
+	} else if flagProvided("config") {
+		return dbConfig, fmt.Errorf("config file %s not found", *configPath)
+	} else {
+		log.Printf("⚠️  %s not found, using DB_* environment variables", *configPath)
+	}
+
+	dbConfig.Host = getEnvOrDefault("DB_HOST", dbConfig.Host)
+	dbConfig.Port = getEnvOrDefault("DB_PORT", dbConfig.Port)
+	dbConfig.Name = getEnvOrDefault("DB_NAME", dbConfig.Name)
+	dbConfig.User = getEnvOrDefault("DB_USER", dbConfig.User)
+	dbConfig.Password = getEnvOrDefault("DB_PASSWORD", dbConfig.Password)
+	dbConfig.SSLMode = getEnvOrDefault("DB_SSL_MODE", dbConfig.SSLMode)
+	if dbConfig.MigrationPath == "" {
+		dbConfig.MigrationPath = "migrations"
+	}
+
+	return dbConfig, nil
+}
+
+// flagProvided reports whether a flag was set explicitly on the command line
+func flagProvided(name string) bool {
+	provided := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			provided = true
+		}
+	})
+	return provided
+}
+
+// gormLogWriter keeps SQL logs off stdout when JSON output is requested
+func gormLogWriter() *os.File {
+	if jsonOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// getLogLevel logs every statement with -v, otherwise only slow statements and errors
+func getLogLevel() string {
 	if *verbose {
-		return logger.Info
+		return "debug"
 	}
-	return logger.Warn
+	return "warn"
 }
 
 func handleStatus(mgr *migrations.MigrationManager) {
+	if jsonOutput() {
+		printStatusJSON(mgr)
+		return
+	}
+
 	fmt.Println("🔍 Checking migration status...")
 	
 	status, err := mgr.GetMigrationStatus()
@@ -148,6 +308,21 @@ func handleStatus(mgr *migrations.MigrationManager) {
 	fmt.Printf("   Total migrations: %d\n", status.TotalMigrations)
 	fmt.Printf("   Applied: %d\n", status.AppliedMigrations)
 	fmt.Printf("   Pending: %d\n", status.PendingMigrations)
+	if status.LastAppliedAt != nil {
+		fmt.Printf("   Last applied: %s: %s at %s\n", status.LastAppliedVersion, status.LastAppliedName, status.LastAppliedAt.Format("2006-01-02 15:04:05"))
+	}
+	if status.SkippedMigrations > 0 {
+		fmt.Printf("   Skipped (other environments): %d\n", status.SkippedMigrations)
+
+		skipped, err := mgr.GetSkippedMigrations()
+		if err != nil {
+			log.Printf("Failed to get skipped migration details: %v", err)
+		} else {
+			for _, migration := range skipped {
+				fmt.Printf("   - %s: %s (environment: %s)\n", migration.Version, migration.Name, strings.Join(migration.Environments, ", "))
+			}
+		}
+	}
 	
 	if status.PendingMigrations > 0 {
 		fmt.Printf("\n⚠️  %d pending migrations need to be applied\n", status.PendingMigrations)
@@ -168,6 +343,16 @@ func handleStatus(mgr *migrations.MigrationManager) {
 }
 
 func handleMigrate(mgr *migrations.MigrationManager) {
+	if jsonOutput() {
+		printMigrateJSON(mgr)
+		return
+	}
+
+	if *toVersion != "" {
+		handleMigrateTo(mgr, *toVersion)
+		return
+	}
+
 	if *dryRun {
 		fmt.Println("🔍 DRY RUN: Showing what would be migrated...")
 		
@@ -181,6 +366,11 @@ func handleMigrate(mgr *migrations.MigrationManager) {
 			return
 		}
 
+		if *execute {
+			handleDryRunExecute(mgr, pending)
+			return
+		}
+
 		fmt.Printf("\nWould apply %d migrations:\n", len(pending))
 		for _, migration := range pending {
 			fmt.Printf("   - %s: %s\n", migration.Version, migration.Name)
@@ -189,6 +379,21 @@ func handleMigrate(mgr *migrations.MigrationManager) {
 		return
 	}
 
+	if productionConfirmationRequired() {
+		pending, err := mgr.GetPendingMigrations()
+		if err != nil {
+			log.Fatalf("❌ Failed to get pending migrations: %v", err)
+		}
+		if len(pending) == 0 {
+			fmt.Println("✅ No pending migrations to apply")
+			return
+		}
+		if !confirmProduction(pending) {
+			fmt.Println("❌ Migration cancelled")
+			os.Exit(1)
+		}
+	}
+
 	fmt.Println("🚀 Applying pending migrations...")
 	
 	results, err := mgr.ApplyMigrations()
@@ -208,9 +413,60 @@ func handleMigrate(mgr *migrations.MigrationManager) {
 			result.Migration.Name, 
 			float64(result.ExecutionTime.Nanoseconds())/1e6)
 	}
+	printBackupPath(results)
+}
+
+// handleDryRunExecute rehearses pending migrations in a rolled-back transaction and reports the outcome
+func handleDryRunExecute(mgr *migrations.MigrationManager, pending []*migrations.Migration) {
+	fmt.Printf("\n🧪 Executing %d migrations in a transaction that will be rolled back (lock timeout %s)...\n\n", len(pending), *lockTimeout)
+
+	results, err := mgr.DryRunMigrations(pending, *lockTimeout)
+	if err != nil {
+		log.Fatalf("❌ Dry run failed: %v", err)
+	}
+
+	var total time.Duration
+	failed := 0
+	for _, result := range results {
+		total += result.ExecutionTime
+		ms := float64(result.ExecutionTime.Nanoseconds()) / 1e6
+
+		switch {
+		case result.Skipped:
+			fmt.Printf("   ⏭️  %s: %s runs outside a transaction (-- no-transaction) and was not rehearsed\n", result.Migration.Version, result.Migration.Name)
+		case result.Success:
+			fmt.Printf("   ✅ %s: %s (~%.2fms)\n", result.Migration.Version, result.Migration.Name, ms)
+		case result.LockTimeout:
+			failed++
+			fmt.Printf("   🔒 %s: %s blocked waiting for a lock after %.2fms: %v\n", result.Migration.Version, result.Migration.Name, ms, result.Error)
+		default:
+			failed++
+			fmt.Printf("   ❌ %s: %s: %v\n", result.Migration.Version, result.Migration.Name, result.Error)
+		}
+		if len(result.ExclusiveLocks) > 0 {
+			fmt.Printf("      ⚠️  ACCESS EXCLUSIVE locks: %s\n", strings.Join(result.ExclusiveLocks, ", "))
+		}
+	}
+
+	fmt.Printf("\n⏱️  Estimated total duration: %.2fms\n", float64(total.Nanoseconds())/1e6)
+	fmt.Println("🔙 All changes were rolled back")
+
+	if failed > 0 {
+		fmt.Printf("❌ %d of %d migrations would fail\n", failed, len(results))
+		os.Exit(1)
+	}
 }
 
 func handleValidate(db *gorm.DB) {
+	if jsonOutput() {
+		printValidateJSON(db)
+		return
+	}
+	if reportToStdout() {
+		printValidationReport(db)
+		return
+	}
+
 	fmt.Println("🔍 Validating database schema...")
 	
 	validator, err := migrations.NewSchemaValidator(db)
@@ -251,6 +507,19 @@ func handleValidate(db *gorm.DB) {
 						mismatch.ColumnName, mismatch.ExpectedType, mismatch.ActualType)
 				}
 			}
+			for _, mismatch := range append(result.EnumMismatches, result.CheckMismatches...) {
+				fmt.Printf("   Allowed values of %s (%s):", mismatch.ColumnName, mismatch.Constraint)
+				if len(mismatch.MissingValues) > 0 {
+					fmt.Printf(" missing %s", strings.Join(mismatch.MissingValues, ", "))
+				}
+				if len(mismatch.ExtraValues) > 0 {
+					fmt.Printf(" extra %s", strings.Join(mismatch.ExtraValues, ", "))
+				}
+				fmt.Println()
+			}
+			for _, issue := range result.TriggerIssues {
+				fmt.Printf("   Trigger %s (%s()): %s\n", issue.TriggerName, issue.Function, issue.Issue)
+			}
 			if len(result.RecommendedActions) > 0 {
 				fmt.Println("   Recommendations:")
 				for _, action := range result.RecommendedActions {
@@ -262,6 +531,29 @@ func handleValidate(db *gorm.DB) {
 	
 	fmt.Println("=" + strings.Repeat("=", 40))
 	fmt.Printf("Summary: %d valid, %d invalid tables\n", validCount, invalidCount)
+
+	if *reportOut != "" {
+		if err := writeValidationReport(results); err != nil {
+			log.Fatalf("❌ Failed to write validation report: %v", err)
+		}
+		fmt.Printf("\n📄 Wrote %s validation report: %s\n", *report, *reportOut)
+	}
+
+	if *fixOutput != "" {
+		path, content, err := writeFixMigration(validator, results)
+		switch {
+		case err != nil:
+			log.Fatalf("❌ Failed to generate fix-up migration: %v", err)
+		case content == "":
+			fmt.Println("\n✅ No drift that a migration can fix, no fix-up migration written")
+		case *dryRun:
+			fmt.Printf("\n🔍 DRY RUN: Would create fix-up migration: %s\n\n", path)
+			fmt.Println(content)
+		default:
+			fmt.Printf("\n📝 Wrote fix-up migration: %s\n", path)
+			fmt.Println("⚠️  Review the generated SQL (and any TODO notes) before applying it")
+		}
+	}
 	
 	if invalidCount > 0 {
 		fmt.Printf("\n⚠️  %d tables have schema issues\n", invalidCount)
@@ -273,42 +565,550 @@ func handleValidate(db *gorm.DB) {
 	}
 }
 
+// printValidationReport prints the --report to stdout; the exit code is 1 when any table is invalid
+// A fix-up migration requested with --fix-output is still written and logged to stderr
+func printValidationReport(db *gorm.DB) {
+	validator, err := migrations.NewSchemaValidator(db)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize schema validator: %v", err)
+	}
+
+	results, err := validator.ValidateAllTables()
+	if err != nil {
+		log.Fatalf("❌ Schema validation failed: %v", err)
+	}
+
+	if *fixOutput != "" {
+		path, content, err := writeFixMigration(validator, results)
+		switch {
+		case err != nil:
+			log.Fatalf("❌ Failed to generate fix-up migration: %v", err)
+		case content != "" && !*dryRun:
+			log.Printf("📝 Wrote fix-up migration: %s", path)
+		}
+	}
+
+	content, err := migrations.RenderValidationReport(results, *report, time.Now())
+	if err != nil {
+		log.Fatalf("❌ Failed to render validation report: %v", err)
+	}
+	os.Stdout.Write(content)
+
+	for _, result := range results {
+		if !result.IsValid {
+			os.Exit(1)
+		}
+	}
+}
+
+func handleMigrateTo(mgr *migrations.MigrationManager, target string) {
+	pending, err := mgr.GetPendingMigrationsTo(target)
+	if err != nil {
+		log.Fatalf("❌ Failed to plan migration to %s: %v", target, err)
+	}
+
+	if len(pending) == 0 {
+		fmt.Printf("✅ Schema is already at or beyond version %s\n", target)
+		return
+	}
+
+	if *dryRun && *execute {
+		handleDryRunExecute(mgr, pending)
+		return
+	}
+
+	if *dryRun {
+		fmt.Printf("🔍 DRY RUN: Would apply %d migrations up to %s:\n", len(pending), target)
+		for _, migration := range pending {
+			fmt.Printf("   - %s: %s\n", migration.Version, migration.Name)
+		}
+		return
+	}
+
+	if productionConfirmationRequired() && !confirmProduction(pending) {
+		fmt.Println("❌ Migration cancelled")
+		os.Exit(1)
+	}
+
+	fmt.Printf("🚀 Migrating to version %s...\n", target)
+
+	results, err := mgr.MigrateTo(target)
+	if err != nil {
+		log.Fatalf("❌ Migration failed: %v", err)
+	}
+
+	fmt.Printf("\n🎉 Successfully applied %d migrations\n", len(results))
+	for _, result := range results {
+		fmt.Printf("   ✅ %s: %s (%.2fms)\n",
+			result.Migration.Version,
+			result.Migration.Name,
+			float64(result.ExecutionTime.Nanoseconds())/1e6)
+	}
+	printBackupPath(results)
+}
+
+// printBackupPath shows where the --backup artifact of a run was written
+func printBackupPath(results []*migrations.MigrationResult) {
+	if len(results) > 0 && results[0].BackupPath != "" {
+		fmt.Printf("\n💾 Pre-migration backup: %s\n", results[0].BackupPath)
+	}
+}
+
 func handleRollback(mgr *migrations.MigrationManager) {
-	fmt.Println("🔄 Rollback functionality not yet implemented")
-	fmt.Println("This is a planned feature for future versions")
-	
-	if !*force {
-		fmt.Println("\nFor now, manual rollback is required:")
-		fmt.Println("1. Review the DOWN migration SQL in the migration file")
-		fmt.Println("2. Execute the rollback SQL manually")
-		fmt.Println("3. Remove the migration record from schema_migrations table")
+	if *toVersion == "" {
+		fmt.Println("❌ Rollback requires a target version")
+		fmt.Println("Usage: migrate rollback --to <version>   (use --to 0 to roll back everything)")
+		os.Exit(1)
+	}
+
+	plan, err := mgr.GetRollbackPlan(*toVersion)
+	if err != nil {
+		log.Fatalf("❌ Failed to plan rollback to %s: %v", *toVersion, err)
+	}
+
+	if len(plan) == 0 {
+		fmt.Printf("✅ Nothing to roll back, schema is at or below version %s\n", *toVersion)
 		return
 	}
-	
-	// TODO: Implement rollback functionality
-	log.Fatal("❌ Rollback not implemented yet")
+
+	fmt.Printf("↩️  Rolling back %d migrations to version %s:\n", len(plan), *toVersion)
+	for _, migration := range plan {
+		fmt.Printf("   - %s: %s\n", migration.Version, migration.Name)
+		if *verbose && migration.IsGo() {
+			fmt.Printf("   (Go rollback function)\n\n")
+		} else if *verbose {
+			fmt.Printf("%s\n\n", migration.DownSQL)
+		}
+	}
+
+	if *dryRun {
+		fmt.Println("\n🔍 DRY RUN: No migrations were rolled back")
+		return
+	}
+
+	// Production asks for the environment name instead; --force alone doesn't skip it
+	if productionEnvironment() {
+		if !*yes && !confirm("\nRoll back PRODUCTION? Type 'production' to continue (--yes skips this): ", "production") {
+			fmt.Println("❌ Rollback cancelled")
+			os.Exit(1)
+		}
+	} else if !*force && !confirm(fmt.Sprintf("\nRoll back %s? Type 'rollback' to continue: ", *environment), "rollback") {
+		fmt.Println("❌ Rollback cancelled")
+		os.Exit(1)
+	}
+
+	results, err := mgr.RollbackTo(*toVersion)
+	if err != nil {
+		log.Fatalf("❌ Rollback failed: %v", err)
+	}
+
+	fmt.Printf("\n✅ Rolled back %d migrations\n", len(results))
+}
+
+func handleSeed(db *gorm.DB) {
+	seedManager, err := migrations.NewSeedManager(db, "seeds", *environment)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize seed manager: %v", err)
+	}
+
+	pending, err := seedManager.GetPendingSeeds(*force)
+	if err != nil {
+		log.Fatalf("❌ Failed to load seeds: %v", err)
+	}
+
+	if len(pending) == 0 {
+		fmt.Printf("✅ No pending seeds for %s environment\n", *environment)
+		return
+	}
+
+	if *dryRun {
+		fmt.Printf("🔍 DRY RUN: Would apply %d seeds for %s:\n", len(pending), *environment)
+		for _, seed := range pending {
+			fmt.Printf("   - %s\n", seed.Name)
+		}
+		return
+	}
+
+	fmt.Printf("🌱 Seeding %s environment...\n", *environment)
+
+	results, err := seedManager.ApplySeeds(*force)
+	if err != nil {
+		log.Fatalf("❌ Seeding failed: %v", err)
+	}
+
+	fmt.Printf("\n🎉 Successfully applied %d seeds\n", len(results))
+	for _, result := range results {
+		fmt.Printf("   ✅ %s (%.2fms)\n", result.Seed.Name, float64(result.ExecutionTime.Nanoseconds())/1e6)
+	}
+}
+
+func handleVerify(mgr *migrations.MigrationManager) {
+	fmt.Println("🔍 Verifying applied migration checksums...")
+
+	drifts, err := mgr.VerifyChecksums()
+	if err != nil {
+		log.Fatalf("❌ Checksum verification failed: %v", err)
+	}
+
+	if len(drifts) == 0 {
+		fmt.Println("\n✅ All applied migrations match their files")
+		return
+	}
+
+	printDrifts(drifts)
+	fmt.Println("\nApplied migrations must not be edited. Restore the original files, or run")
+	fmt.Println("'migrate repair' to accept the current files as the new baseline.")
+	os.Exit(1)
+}
+
+func handleRepair(mgr *migrations.MigrationManager) {
+	drifts, err := mgr.VerifyChecksums()
+	if err != nil {
+		log.Fatalf("❌ Checksum verification failed: %v", err)
+	}
+
+	if len(drifts) == 0 {
+		fmt.Println("✅ No checksum drift detected, nothing to repair")
+		return
+	}
+
+	printDrifts(drifts)
+
+	if *dryRun {
+		fmt.Println("\n🔍 DRY RUN: No checksums were changed")
+		return
+	}
+
+	if !*force && !confirm(fmt.Sprintf("\nRe-baseline checksums in %s? Type 'repair' to continue: ", *environment), "repair") {
+		fmt.Println("❌ Repair cancelled")
+		os.Exit(1)
+	}
+
+	repaired, err := mgr.RepairChecksums(drifts)
+	if err != nil {
+		log.Fatalf("❌ Repair failed: %v", err)
+	}
+
+	fmt.Printf("\n✅ Repaired %d migration checksums\n", repaired)
+}
+
+func printDrifts(drifts []*migrations.ChecksumDrift) {
+	fmt.Printf("\n⚠️  %d applied migrations have drifted:\n", len(drifts))
+	for _, drift := range drifts {
+		if drift.FileMissing {
+			fmt.Printf("   - %s: %s (file missing)\n", drift.Version, drift.Name)
+			continue
+		}
+		fmt.Printf("   - %s: %s\n", drift.Version, drift.Name)
+		fmt.Printf("       recorded: %s\n", drift.RecordedChecksum)
+		fmt.Printf("       current:  %s\n", drift.CurrentChecksum)
+	}
+}
+
+func handleHistory(mgr *migrations.MigrationManager) {
+	filter := migrations.HistoryFilter{}
+	if !*allEnvs {
+		filter.Environment = *environment
+	}
+
+	var err error
+	if filter.Since, err = parseHistoryTime(*since, false); err != nil {
+		log.Fatalf("❌ Invalid --since: %v", err)
+	}
+	if filter.Until, err = parseHistoryTime(*until, true); err != nil {
+		log.Fatalf("❌ Invalid --until: %v", err)
+	}
+
+	records, err := mgr.GetMigrationHistory(filter)
+	if err != nil {
+		if jsonOutput() {
+			exitJSONError(err)
+		}
+		log.Fatalf("❌ Failed to load migration history: %v", err)
+	}
+
+	if jsonOutput() {
+		printHistoryJSON(filter, records)
+		return
+	}
+
+	scope := *environment + " environment"
+	if *allEnvs {
+		scope = "all environments"
+	}
+	fmt.Printf("📜 Migration history for %s:\n\n", scope)
+
+	if len(records) == 0 {
+		fmt.Println("   No applied migrations match the filter")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "   VERSION\tNAME\tAPPLIED AT\tAPPLIED BY\tENVIRONMENT\tDURATION")
+	for _, record := range records {
+		fmt.Fprintf(w, "   %s\t%s\t%s\t%s\t%s\t%dms\n",
+			record.Version,
+			record.Name,
+			record.AppliedAt.UTC().Format(time.RFC3339),
+			record.AppliedBy,
+			record.Environment,
+			record.ExecutionTimeMs)
+	}
+	w.Flush()
+	fmt.Printf("\n%d migrations\n", len(records))
+}
+
+// parseHistoryTime parses a --since/--until value; a bare date used as an upper bound covers the whole day
+func parseHistoryTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or RFC 3339 timestamp", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// productionEnvironment reports whether the command targets the production schema
+func productionEnvironment() bool {
+	return *environment == "production"
+}
+
+// productionConfirmationRequired reports whether migrate and rollback must ask before changing the schema
+func productionConfirmationRequired() bool {
+	return productionEnvironment() && !*yes
+}
+
+// confirmProduction shows the migrations about to be applied and asks for the environment name
+func confirmProduction(pending []*migrations.Migration) bool {
+	fmt.Printf("⚠️  About to apply %d migrations to PRODUCTION:\n", len(pending))
+	for _, migration := range pending {
+		fmt.Printf("   - %s: %s\n", migration.Version, migration.Name)
+	}
+	return confirm("\nType 'production' to continue (--yes skips this): ", "production")
+}
+
+// confirm prompts the operator and returns true only if the expected answer is typed
+func confirm(prompt, expected string) bool {
+	fmt.Print(prompt)
+	reader := bufio.NewReader(os.Stdin)
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(answer) == expected
+}
+
+// handleDiff writes a draft migration with the additive DDL needed to match the GORM models
+// handleLint checks migration files for risky statements and exits non-zero when any error is found
+// The directory defaults to the configured migration path; `migrate lint <dir>` overrides it
+func handleLint(migrationsDir string) {
+	if flag.NArg() > 0 {
+		migrationsDir = flag.Arg(0)
+	}
+
+	files, err := migrations.LoadMigrationFiles(os.DirFS(migrationsDir), migrationsDir)
+	if err != nil {
+		if jsonOutput() {
+			exitJSONError(err)
+		}
+		log.Fatalf("❌ Failed to load migrations: %v", err)
+	}
+
+	findings := migrations.LintMigrations(files)
+	errorCount, warningCount := 0, 0
+	for _, finding := range findings {
+		if finding.Severity == migrations.LintError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+	failed := errorCount > 0 || (*strict && warningCount > 0)
+
+	if jsonOutput() {
+		printLintJSON(files, findings, errorCount, warningCount, failed)
+	} else {
+		fmt.Printf("🔎 Linting %d migrations in %s\n\n", len(files), migrationsDir)
+		for _, finding := range findings {
+			icon := "⚠️ "
+			if finding.Severity == migrations.LintError {
+				icon = "❌"
+			}
+			location := finding.File
+			switch {
+			case finding.Section == "down":
+				location += " (DOWN)"
+			case finding.Line > 0:
+				location = fmt.Sprintf("%s:%d", location, finding.Line)
+			}
+			fmt.Printf("%s %s [%s] %s\n", icon, location, finding.Rule, finding.Message)
+			if finding.Statement != "" {
+				fmt.Printf("      %s\n", finding.Statement)
+			}
+		}
+		if len(findings) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("📋 %d errors, %d warnings\n", errorCount, warningCount)
+		if len(findings) > 0 {
+			fmt.Println("💡 Suppress an intended finding with a \"-- lint:ignore <rule>\" comment above the statement or in the file header")
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func handleDiff(db *gorm.DB) {
+	name := flag.Arg(0)
+	if name == "" {
+		name = "schema_diff"
+	}
+
+	validator, err := migrations.NewSchemaValidator(db)
+	if err != nil {
+		log.Fatalf("❌ Failed to create schema validator: %v", err)
+	}
+
+	diffs, err := validator.GenerateDiff()
+	if err != nil {
+		log.Fatalf("❌ Failed to diff schema: %v", err)
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("✅ Database schema matches the GORM models, nothing to generate")
+		return
+	}
+
+	version := time.Now().Format("20060102150405")
+	path := filepath.Join("migrations", fmt.Sprintf("%s_%s.sql", version, name))
+	content := migrations.RenderDiffMigration(version, name, diffs)
+
+	for _, diff := range diffs {
+		fmt.Printf("📋 %s: %d statement(s)", diff.TableName, len(diff.Statements))
+		if len(diff.ManualReview) > 0 {
+			fmt.Printf(", %d item(s) need manual review", len(diff.ManualReview))
+		}
+		fmt.Println()
+	}
+
+	if *dryRun {
+		fmt.Printf("\n🔍 DRY RUN: Would create migration file: %s\n\n", path)
+		fmt.Println(content)
+		return
+	}
+
+	if err := os.MkdirAll("migrations", 0755); err != nil {
+		log.Fatalf("❌ Failed to create migrations directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		log.Fatalf("❌ Failed to write migration file: %v", err)
+	}
+
+	fmt.Printf("\n✅ Created draft migration: %s\n", path)
+	fmt.Println("⚠️  Review the generated SQL (and any TODO notes) before applying it")
+}
+
+// writeFixMigration renders the drift in results as a migration at --fix-output
+// A directory gets a timestamped <version>_fix_schema.sql; nothing is written when there is nothing to fix
+// or with --dry-run. It returns the path and the rendered migration ("" when there is nothing to fix)
+func writeFixMigration(validator *migrations.SchemaValidator, results []*migrations.SchemaValidationResult) (string, string, error) {
+	diffs, err := validator.GenerateFixDiffs(results)
+	if err != nil {
+		return "", "", err
+	}
+	if len(diffs) == 0 {
+		return "", "", nil
+	}
+
+	path := *fixOutput
+	if info, err := os.Stat(path); (err == nil && info.IsDir()) || strings.HasSuffix(path, string(os.PathSeparator)) {
+		path = filepath.Join(path, time.Now().Format("20060102150405")+"_fix_schema.sql")
+	}
+
+	version, name, found := strings.Cut(strings.TrimSuffix(filepath.Base(path), ".sql"), "_")
+	if !found || version == "" || name == "" || filepath.Ext(path) != ".sql" {
+		return "", "", fmt.Errorf("--fix-output file name must look like <version>_<name>.sql, got %s", filepath.Base(path))
+	}
+
+	content := migrations.RenderFixMigration(version, name, diffs)
+	if *dryRun {
+		return path, content, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write fix-up migration: %w", err)
+	}
+	return path, content, nil
+}
+
+// reportToStdout reports whether validate prints the --report instead of its usual output
+func reportToStdout() bool {
+	return *report != "" && *reportOut == ""
+}
+
+// writeValidationReport renders results in the --report format to --out
+func writeValidationReport(results []*migrations.SchemaValidationResult) error {
+	content, err := migrations.RenderValidationReport(results, *report, time.Now())
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(*reportOut); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	return os.WriteFile(*reportOut, content, 0644)
 }
 
 func handleCreate() {
-	if len(os.Args) < 3 {
+	name := flag.Arg(0)
+	if name == "" {
 		fmt.Println("❌ Migration name required")
-		fmt.Println("Usage: migrate create <migration_name>")
+		fmt.Println("Usage: migrate create [--template <name> --table <table> --column <column>] <migration_name>")
 		os.Exit(1)
 	}
 	
-	name := os.Args[2]
-	
 	// Generate migration file
 	version := time.Now().Format("20060102150405")
 	filename := fmt.Sprintf("%s_%s.sql", version, name)
 	filepath := filepath.Join("migrations", filename)
 	
-	template := generateMigrationTemplate(version, name)
+	content := generateMigrationTemplate(version, name)
+	if *template != "" {
+		var columns []string
+		for _, col := range strings.Split(*column, ",") {
+			if col = strings.TrimSpace(col); col != "" {
+				columns = append(columns, col)
+			}
+		}
+
+		var err error
+		content, err = migrations.RenderMigrationTemplate(*template, version, name, migrations.TemplateParams{
+			Table:   *table,
+			Columns: columns,
+		})
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
 	
 	if *dryRun {
 		fmt.Printf("🔍 DRY RUN: Would create migration file: %s\n", filepath)
 		fmt.Println("\nTemplate content:")
-		fmt.Println(template)
+		fmt.Println(content)
 		return
 	}
 	
@@ -318,7 +1118,7 @@ func handleCreate() {
 	}
 	
 	// Write migration file
-	if err := os.WriteFile(filepath, []byte(template), 0644); err != nil {
+	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
 		log.Fatalf("❌ Failed to create migration file: %v", err)
 	}
 	
@@ -383,23 +1183,62 @@ func printHelp() {
 	fmt.Println("  migrate   Apply pending migrations")
 	fmt.Println("  validate  Validate database schema consistency")
 	fmt.Println("  create    Create a new migration file")
-	fmt.Println("  rollback  Rollback last migration (planned)")
+	fmt.Println("  verify    Detect applied migrations whose files were modified")
+	fmt.Println("  repair    Re-baseline drifted checksums (requires confirmation)")
+	fmt.Println("  seed      Load seed data from seeds/<env>/ (new or changed seeds only)")
+	fmt.Println("  diff      Generate a draft migration from GORM model differences")
+	fmt.Println("  history   Show applied migrations with audit details (--since, --until, --all-envs)")
+	fmt.Println("  lint      Check migration files for risky statements; exits 1 on errors (no database needed)")
+	fmt.Println("  rollback  Roll back to a target version (requires --to)")
 	fmt.Println("  help      Show this help message")
 	fmt.Println()
 	fmt.Println("FLAGS:")
 	fmt.Println("  --env string       Environment (development, test, production) (default: development)")
-	fmt.Println("  --config string    Config file path (default: config/config.toml)")
+	fmt.Println("  --config string    Service TOML config for database settings; DB_* env vars override (default: config/config.toml)")
 	fmt.Println("  --dry-run          Show what would be done without executing")
 	fmt.Println("  --verbose, -v      Verbose output")
 	fmt.Println("  --force            Force operation (use with caution)")
+	fmt.Println("  --yes              Skip the typed confirmation for migrate/rollback in production (CI deploys)")
+	fmt.Println("  --output string    Output format: text or json (status, migrate, validate, history, lint)")
+	fmt.Println("  --execute          With --dry-run, run migrations in a rolled-back transaction")
+	fmt.Println("  --lock-timeout     Lock wait limit for --dry-run --execute (default: 5s)")
+	fmt.Println("  --to string        Target version for migrate/rollback (rollback --to 0 reverts all)")
+	fmt.Println("  --since string     History: applied on or after this date (YYYY-MM-DD or RFC 3339)")
+	fmt.Println("  --until string     History: applied up to the end of this date (YYYY-MM-DD or RFC 3339)")
+	fmt.Println("  --all-envs         History: include every environment instead of --env")
+	fmt.Println("  --strict           Lint: fail on warnings as well as errors")
+	fmt.Println("  --template string  Create: add_column, create_table, add_index or add_enum_value")
+	fmt.Println("  --table string     Create: table name filled into --template")
+	fmt.Println("  --column string    Create: column name(s) filled into --template (comma-separated for add_index)")
+	fmt.Println("  --fix-output path  Validate: write a fix-up migration (missing tables, columns, indexes, FKs) to this file or directory")
+	fmt.Println("  --report string    Validate: report format markdown, json or junit; printed to stdout unless --out is set")
+	fmt.Println("  --out path         Validate: write the --report to this file (e.g. for CI artifacts)")
+	fmt.Println("  --backup           Migrate: pg_dump the database before applying; aborts if the backup fails")
+	fmt.Println("  --backup-dir path  Migrate: directory for --backup artifacts (default: backups)")
+	fmt.Println("  --backup-command   Migrate: command run instead of pg_dump (PG* variables set; must write $BACKUP_FILE)")
 	fmt.Println()
 	fmt.Println("EXAMPLES:")
 	fmt.Println("  migrate status                              # Check migration status")
 	fmt.Println("  migrate migrate --dry-run                   # Preview pending migrations")
+	fmt.Println("  migrate migrate --dry-run --execute         # Rehearse pending migrations, then roll back")
 	fmt.Println("  migrate migrate                             # Apply pending migrations")
 	fmt.Println("  migrate validate --verbose                  # Detailed schema validation")
+	fmt.Println("  migrate validate --fix-output migrations/007_fix_schema.sql  # Generate SQL that closes the drift")
+	fmt.Println("  migrate validate --report=junit --out reports/schema.xml     # Gate CI on schema drift")
 	fmt.Println("  migrate create add_user_avatar_field        # Create new migration")
+	fmt.Println("  migrate create --template add_index --table sessions --column user_id,created_at index_sessions_by_user")
 	fmt.Println("  migrate status --env=production             # Check production status")
+	fmt.Println("  migrate migrate --env=production --yes      # Apply in production without the prompt (CI)")
+	fmt.Println("  migrate migrate --backup --backup-dir /var/backups/auth  # Dump the database first")
+	fmt.Println("  migrate migrate --to 20240601120000         # Apply migrations up to a version")
+	fmt.Println("  migrate rollback --to 002 --dry-run         # Preview rollback to a version")
+	fmt.Println("  migrate status --output=json                # Machine-readable status for CI")
+	fmt.Println("  migrate seed --env=development              # Load development seed data")
+	fmt.Println("  migrate verify                              # Check for checksum drift")
+	fmt.Println("  migrate diff --dry-run add_avatar_columns   # Preview DDL generated from models")
+	fmt.Println("  migrate repair --dry-run                    # Preview checksum repair")
+	fmt.Println("  migrate history --since 2025-01-01 --env=production  # Audit production changes")
+	fmt.Println("  migrate lint --strict                       # Fail CI on risky migration statements")
 	fmt.Println()
 	fmt.Println("MIGRATION-FIRST WORKFLOW:")
 	fmt.Println("  1. Create migration: migrate create <name>")
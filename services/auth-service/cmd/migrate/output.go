@@ -0,0 +1,386 @@
+package main
+
+import (
+	"auth-service/internal/migrations"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Output formats for the --output flag
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// migrationEntry is the machine-readable form of a migration file or record
+type migrationEntry struct {
+	Version         string   `json:"version"`
+	Name            string   `json:"name"`
+	Environments    []string `json:"environments,omitempty"`
+	NoTransaction   bool     `json:"no_transaction,omitempty"`
+	ExecutionTimeMs *int64   `json:"execution_time_ms,omitempty"`
+}
+
+// statusReport is emitted by `status --output=json`
+type statusReport struct {
+	Environment     string           `json:"environment"`
+	Total           int              `json:"total"`
+	Applied         int              `json:"applied"`
+	Pending         int              `json:"pending"`
+	Skipped         int              `json:"skipped"`
+	UpToDate        bool             `json:"up_to_date"`
+	LastApplied     *lastApplied     `json:"last_applied,omitempty"` // Most recently applied migration
+	AppliedVersions []migrationEntry `json:"applied_versions"`
+	PendingVersions []migrationEntry `json:"pending_versions"`
+	SkippedVersions []migrationEntry `json:"skipped_versions"`
+}
+
+// lastApplied identifies the most recently applied migration in `status --output=json`
+type lastApplied struct {
+	Version   string    `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// migrateReport is emitted by `migrate --output=json`
+type migrateReport struct {
+	Environment string           `json:"environment"`
+	DryRun      bool             `json:"dry_run"`
+	Target      string           `json:"target,omitempty"`
+	Applied     []migrationEntry `json:"applied"`
+	Pending     []migrationEntry `json:"pending"`
+	Rehearsal   []rehearsalEntry `json:"rehearsal,omitempty"`
+	Backup      string           `json:"backup,omitempty"` // Artifact path of the --backup taken before applying
+	Error       string           `json:"error,omitempty"`
+}
+
+// rehearsalEntry is the outcome of one migration executed by `migrate --dry-run --execute`
+type rehearsalEntry struct {
+	Version         string   `json:"version"`
+	Name            string   `json:"name"`
+	Success         bool     `json:"success"`
+	Skipped         bool     `json:"skipped,omitempty"`
+	Error           string   `json:"error,omitempty"`
+	LockTimeout     bool     `json:"lock_timeout"`
+	ExclusiveLocks  []string `json:"exclusive_locks,omitempty"`
+	ExecutionTimeMs int64    `json:"execution_time_ms"`
+}
+
+// lintReport is emitted by `lint --output=json`
+type lintReport struct {
+	Files    int         `json:"files"`
+	Errors   int         `json:"errors"`
+	Warnings int         `json:"warnings"`
+	Passed   bool        `json:"passed"`
+	Findings []lintEntry `json:"findings"`
+}
+
+// lintEntry is one finding reported by `lint --output=json`
+type lintEntry struct {
+	Version   string `json:"version"`
+	File      string `json:"file"`
+	Section   string `json:"section"`
+	Line      int    `json:"line,omitempty"`
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	Statement string `json:"statement,omitempty"`
+}
+
+// historyReport is emitted by `history --output=json`
+type historyReport struct {
+	Environment string         `json:"environment,omitempty"` // Empty with --all-envs
+	Since       *time.Time     `json:"since,omitempty"`
+	Until       *time.Time     `json:"until,omitempty"`
+	Count       int            `json:"count"`
+	Migrations  []historyEntry `json:"migrations"`
+}
+
+// historyEntry is one schema_migrations record
+type historyEntry struct {
+	Version         string    `json:"version"`
+	Name            string    `json:"name"`
+	Checksum        string    `json:"checksum"`
+	AppliedAt       time.Time `json:"applied_at"`
+	AppliedBy       string    `json:"applied_by"`
+	Environment     string    `json:"environment"`
+	ExecutionTimeMs int       `json:"execution_time_ms"`
+}
+
+// validateReport is emitted by `validate --output=json`
+type validateReport struct {
+	Valid        bool                                 `json:"valid"`
+	ValidCount   int                                  `json:"valid_count"`
+	InvalidCount int                                  `json:"invalid_count"`
+	Tables       []*migrations.SchemaValidationResult `json:"tables"`
+	FixOutput    string                               `json:"fix_output,omitempty"`    // Fix-up migration written by --fix-output
+	ReportOutput string                               `json:"report_output,omitempty"` // Report written by --report --out
+}
+
+// jsonOutput reports whether machine-readable output was requested
+func jsonOutput() bool {
+	return *output == OutputJSON
+}
+
+// printJSON writes v to stdout as indented JSON; diagnostics stay on stderr
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// exitJSONError prints an error object and exits with status 1
+func exitJSONError(err error) {
+	printJSON(map[string]string{"error": err.Error()})
+	os.Exit(1)
+}
+
+// toEntries converts migration files to output entries
+func toEntries(list []*migrations.Migration) []migrationEntry {
+	entries := make([]migrationEntry, 0, len(list))
+	for _, migration := range list {
+		entries = append(entries, migrationEntry{
+			Version:       migration.Version,
+			Name:          migration.Name,
+			Environments:  migration.Environments,
+			NoTransaction: migration.NoTransaction,
+		})
+	}
+	return entries
+}
+
+// resultEntries converts migration results to output entries with timings
+func resultEntries(results []*migrations.MigrationResult) []migrationEntry {
+	entries := make([]migrationEntry, 0, len(results))
+	for _, result := range results {
+		if !result.Success {
+			continue
+		}
+		ms := result.ExecutionTime.Milliseconds()
+		entries = append(entries, migrationEntry{
+			Version:         result.Migration.Version,
+			Name:            result.Migration.Name,
+			ExecutionTimeMs: &ms,
+		})
+	}
+	return entries
+}
+
+func printHistoryJSON(filter migrations.HistoryFilter, records []migrations.MigrationRecord) {
+	report := historyReport{
+		Environment: filter.Environment,
+		Count:       len(records),
+		Migrations:  make([]historyEntry, 0, len(records)),
+	}
+	if !filter.Since.IsZero() {
+		report.Since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		report.Until = &filter.Until
+	}
+
+	for _, record := range records {
+		report.Migrations = append(report.Migrations, historyEntry{
+			Version:         record.Version,
+			Name:            record.Name,
+			Checksum:        record.Checksum,
+			AppliedAt:       record.AppliedAt.UTC(),
+			AppliedBy:       record.AppliedBy,
+			Environment:     record.Environment,
+			ExecutionTimeMs: record.ExecutionTimeMs,
+		})
+	}
+	printJSON(report)
+}
+
+func printLintJSON(files []*migrations.Migration, findings []migrations.LintFinding, errorCount, warningCount int, failed bool) {
+	report := lintReport{
+		Files:    len(files),
+		Errors:   errorCount,
+		Warnings: warningCount,
+		Passed:   !failed,
+		Findings: make([]lintEntry, 0, len(findings)),
+	}
+	for _, finding := range findings {
+		report.Findings = append(report.Findings, lintEntry{
+			Version:   finding.Version,
+			File:      finding.File,
+			Section:   finding.Section,
+			Line:      finding.Line,
+			Rule:      string(finding.Rule),
+			Severity:  string(finding.Severity),
+			Message:   finding.Message,
+			Statement: finding.Statement,
+		})
+	}
+	printJSON(report)
+}
+
+func printStatusJSON(mgr *migrations.MigrationManager) {
+	records, err := mgr.GetAppliedMigrations()
+	if err != nil {
+		exitJSONError(err)
+	}
+
+	pending, err := mgr.GetPendingMigrations()
+	if err != nil {
+		exitJSONError(err)
+	}
+
+	skipped, err := mgr.GetSkippedMigrations()
+	if err != nil {
+		exitJSONError(err)
+	}
+
+	status, err := mgr.GetMigrationStatus()
+	if err != nil {
+		exitJSONError(err)
+	}
+
+	applied := make([]migrationEntry, 0, len(records))
+	for _, record := range records {
+		ms := int64(record.ExecutionTimeMs)
+		applied = append(applied, migrationEntry{Version: record.Version, Name: record.Name, ExecutionTimeMs: &ms})
+	}
+
+	var last *lastApplied
+	if status.LastAppliedAt != nil {
+		last = &lastApplied{Version: status.LastAppliedVersion, Name: status.LastAppliedName, AppliedAt: *status.LastAppliedAt}
+	}
+
+	printJSON(statusReport{
+		Environment:     *environment,
+		Total:           len(applied) + len(pending),
+		Applied:         len(applied),
+		Pending:         len(pending),
+		Skipped:         len(skipped),
+		UpToDate:        len(pending) == 0,
+		LastApplied:     last,
+		AppliedVersions: applied,
+		PendingVersions: toEntries(pending),
+		SkippedVersions: toEntries(skipped),
+	})
+}
+
+func printMigrateJSON(mgr *migrations.MigrationManager) {
+	report := migrateReport{
+		Environment: *environment,
+		DryRun:      *dryRun,
+		Target:      *toVersion,
+		Applied:     []migrationEntry{},
+	}
+
+	var pending []*migrations.Migration
+	var err error
+	if *toVersion != "" {
+		pending, err = mgr.GetPendingMigrationsTo(*toVersion)
+	} else {
+		pending, err = mgr.GetPendingMigrations()
+	}
+	if err != nil {
+		exitJSONError(err)
+	}
+	report.Pending = toEntries(pending)
+
+	if *dryRun && *execute && len(pending) > 0 {
+		results, err := mgr.DryRunMigrations(pending, *lockTimeout)
+		if err != nil {
+			report.Error = err.Error()
+		}
+		for _, result := range results {
+			entry := rehearsalEntry{
+				Version:         result.Migration.Version,
+				Name:            result.Migration.Name,
+				Success:         result.Success,
+				Skipped:         result.Skipped,
+				LockTimeout:     result.LockTimeout,
+				ExclusiveLocks:  result.ExclusiveLocks,
+				ExecutionTimeMs: result.ExecutionTime.Milliseconds(),
+			}
+			if result.Error != nil {
+				entry.Error = result.Error.Error()
+				if report.Error == "" {
+					report.Error = fmt.Sprintf("migration %s would fail", result.Migration.Version)
+				}
+			}
+			report.Rehearsal = append(report.Rehearsal, entry)
+		}
+		printJSON(report)
+		if report.Error != "" {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *dryRun || len(pending) == 0 {
+		printJSON(report)
+		return
+	}
+
+	var results []*migrations.MigrationResult
+	if *toVersion != "" {
+		results, err = mgr.MigrateTo(*toVersion)
+	} else {
+		results, err = mgr.ApplyMigrations()
+	}
+	report.Applied = resultEntries(results)
+	report.Pending = report.Pending[len(report.Applied):]
+	if len(results) > 0 {
+		report.Backup = results[0].BackupPath
+	}
+	if err != nil {
+		report.Error = err.Error()
+		printJSON(report)
+		os.Exit(1)
+	}
+
+	printJSON(report)
+}
+
+func printValidateJSON(db *gorm.DB) {
+	validator, err := migrations.NewSchemaValidator(db)
+	if err != nil {
+		exitJSONError(err)
+	}
+
+	results, err := validator.ValidateAllTables()
+	if err != nil {
+		exitJSONError(err)
+	}
+
+	report := validateReport{Tables: results}
+	for _, result := range results {
+		if result.IsValid {
+			report.ValidCount++
+		} else {
+			report.InvalidCount++
+		}
+	}
+	report.Valid = report.InvalidCount == 0
+
+	if *fixOutput != "" {
+		path, _, err := writeFixMigration(validator, results)
+		if err != nil {
+			exitJSONError(err)
+		}
+		if !*dryRun {
+			report.FixOutput = path
+		}
+	}
+
+	if *reportOut != "" {
+		if err := writeValidationReport(results); err != nil {
+			exitJSONError(err)
+		}
+		report.ReportOutput = *reportOut
+	}
+
+	printJSON(report)
+	if !report.Valid {
+		os.Exit(1)
+	}
+}
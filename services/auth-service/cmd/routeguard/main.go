@@ -0,0 +1,90 @@
+// Command routeguard prints the auth service's authorization matrix and exits non-zero when a route
+// lacks the middleware the access policy requires. Run it in CI:
+//
+//	go run ./cmd/routeguard
+//	go run ./cmd/routeguard --format markdown --out docs/authorization-matrix.md
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"auth-service/internal/config"
+	"auth-service/internal/container"
+	"auth-service/internal/routeguard"
+	"auth-service/internal/routes"
+	"auth-service/internal/status"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Report formats
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+var (
+	configPath = flag.String("config", "config/config.toml", "Config file path")
+	format     = flag.String("format", FormatText, "Report format (text, markdown, json)")
+	out        = flag.String("out", "", "Write the report to this file instead of stdout")
+)
+
+func main() {
+	flag.Parse()
+	gin.SetMode(gin.ReleaseMode)
+
+	cfg, err := config.LoadFile(*configPath)
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+
+	// Only the route table is needed: handlers are never called, so no database or Redis is opened
+	deps := &container.Container{
+		Config:     cfg,
+		StatusPage: status.NewPage(nil, nil, cfg),
+	}
+
+	inspected, err := routeguard.Inspect(func(router *gin.Engine) {
+		routes.Register(router, deps, cfg)
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to inspect routes: %v", err)
+	}
+	result := routeguard.DefaultPolicy.Check(inspected)
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("❌ Failed to create %s: %v", *out, err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case FormatText:
+		routeguard.WriteText(w, result)
+	case FormatMarkdown:
+		routeguard.WriteMarkdown(w, result)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			log.Fatalf("❌ Failed to encode report: %v", err)
+		}
+	default:
+		log.Fatalf("❌ Unknown format %q (available: text, markdown, json)", *format)
+	}
+
+	if violations := result.Violations(); violations > 0 {
+		fmt.Fprintf(os.Stderr, "❌ %d route policy violations\n", violations)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,47 @@
+package cachewarm
+
+import (
+	"context"
+
+	"auth-service/internal/config"
+
+	"gorm.io/gorm"
+)
+
+// Cache keys of the built-in targets; readers fetch them with CacheManager.Get
+const (
+	RoleDefinitionsKey = "warm:role_definitions"
+	ClientRegistryKey  = "warm:client_registry"
+)
+
+// RoleDefinitions caches the labels of the user_role enum, the roles permissions are granted to
+func RoleDefinitions(db *gorm.DB) Target {
+	return Target{
+		Name: "role_definitions",
+		Key:  RoleDefinitionsKey,
+		Load: func(ctx context.Context) (interface{}, error) {
+			var roles []string
+			err := db.WithContext(ctx).
+				Raw("SELECT unnest(enum_range(NULL::user_role))::text").
+				Scan(&roles).Error
+			return roles, err
+		},
+	}
+}
+
+// ClientRegistry caches the registered client IDs with the security policy each belongs to
+func ClientRegistry(policies []config.SecurityPolicyConfig) Target {
+	return Target{
+		Name: "client_registry",
+		Key:  ClientRegistryKey,
+		Load: func(ctx context.Context) (interface{}, error) {
+			clients := make(map[string]string)
+			for _, policy := range policies {
+				for _, clientID := range policy.ClientIDs {
+					clients[clientID] = policy.Name
+				}
+			}
+			return clients, nil
+		},
+	}
+}
@@ -0,0 +1,166 @@
+// Package cachewarm loads critical, rarely changing data from Postgres into Redis at startup
+// so the first requests after a deploy don't all miss the cache and hit the database at once
+package cachewarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+
+	"shared/cache"
+)
+
+// Target is one cache entry warmed at startup
+type Target struct {
+	Name string        // Metric label and log name, e.g. "role_definitions"
+	Key  string        // Cache key readers look the data up under
+	TTL  time.Duration // Zero uses cache_warming.ttl
+	Load func(ctx context.Context) (interface{}, error)
+}
+
+// targetStats holds the outcome of the latest warm of one target
+type targetStats struct {
+	successes uint64
+	failures  uint64
+	duration  float64
+}
+
+// Warmer warms every registered target through CacheManager.WarmCache, which skips keys that are already cached
+type Warmer struct {
+	cache   *cache.CacheManager
+	config  config.CacheWarmingConfig
+	targets []Target
+
+	mu    sync.Mutex
+	stats map[string]*targetStats
+	total float64 // Duration of the latest full warm in seconds
+}
+
+// NewWarmer creates a warmer that stores targets in cm
+func NewWarmer(cm *cache.CacheManager, cfg config.CacheWarmingConfig) *Warmer {
+	return &Warmer{cache: cm, config: cfg, stats: make(map[string]*targetStats)}
+}
+
+// Register adds targets to warm; call it before Start
+func (w *Warmer) Register(targets ...Target) {
+	w.targets = append(w.targets, targets...)
+}
+
+// Start warms the caches; with cache_warming.block_startup it returns once every target is warm (or
+// cache_warming.timeout elapses) and reports failures, otherwise it warms in the background and returns nil
+func (w *Warmer) Start(ctx context.Context) error {
+	if !w.config.Enabled || len(w.targets) == 0 {
+		return nil
+	}
+	if !w.config.BlockStartup {
+		go func() {
+			if err := w.Warm(ctx); err != nil {
+				log.Printf("⚠️  Cache warming incomplete: %v", err)
+			}
+		}()
+		return nil
+	}
+	return w.Warm(ctx)
+}
+
+// Warm loads every target concurrently and returns the failures joined
+func (w *Warmer) Warm(ctx context.Context) error {
+	if w.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.config.Timeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	errs := make([]error, len(w.targets))
+	var wg sync.WaitGroup
+	for i, target := range w.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = w.warm(ctx, target)
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(startTime)
+	w.mu.Lock()
+	w.total = elapsed.Seconds()
+	w.mu.Unlock()
+
+	err := errors.Join(errs...)
+	if err == nil {
+		log.Printf("🔥 Warmed %d caches in %s", len(w.targets), elapsed.Round(time.Millisecond))
+	}
+	return err
+}
+
+// warm loads one target and records its outcome
+func (w *Warmer) warm(ctx context.Context, target Target) error {
+	ttl := target.TTL
+	if ttl == 0 {
+		ttl = w.config.TTL
+	}
+
+	startTime := time.Now()
+	err := w.cache.WarmCache(ctx, target.Key, func() (interface{}, error) { return target.Load(ctx) }, ttl)
+	w.observe(target.Name, time.Since(startTime), err)
+	if err != nil {
+		return fmt.Errorf("%s: %w", target.Name, err)
+	}
+	return nil
+}
+
+// observe records the outcome and duration of warming one target
+func (w *Warmer) observe(name string, duration time.Duration, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats, exists := w.stats[name]
+	if !exists {
+		stats = &targetStats{}
+		w.stats[name] = stats
+	}
+	if err != nil {
+		stats.failures++
+	} else {
+		stats.successes++
+	}
+	stats.duration = duration.Seconds()
+}
+
+// WritePrometheus writes the warm outcomes and durations in the Prometheus text format
+func (w *Warmer) WritePrometheus(out io.Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	names := make([]string, 0, len(w.stats))
+	for name := range w.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(out, "# HELP auth_service_cache_warm_total Cache warm attempts by target and outcome")
+	fmt.Fprintln(out, "# TYPE auth_service_cache_warm_total counter")
+	for _, name := range names {
+		fmt.Fprintf(out, "auth_service_cache_warm_total{target=%q,result=\"success\"} %d\n", name, w.stats[name].successes)
+		fmt.Fprintf(out, "auth_service_cache_warm_total{target=%q,result=\"failure\"} %d\n", name, w.stats[name].failures)
+	}
+
+	fmt.Fprintln(out, "# HELP auth_service_cache_warm_duration_seconds Duration of the latest warm of each target")
+	fmt.Fprintln(out, "# TYPE auth_service_cache_warm_duration_seconds gauge")
+	for _, name := range names {
+		fmt.Fprintf(out, "auth_service_cache_warm_duration_seconds{target=%q} %g\n", name, w.stats[name].duration)
+	}
+
+	fmt.Fprintln(out, "# HELP auth_service_cache_warm_run_duration_seconds Duration of the latest warm of all targets")
+	fmt.Fprintln(out, "# TYPE auth_service_cache_warm_run_duration_seconds gauge")
+	fmt.Fprintf(out, "auth_service_cache_warm_run_duration_seconds %g\n", w.total)
+}
@@ -0,0 +1,250 @@
+// Package captcha challenges logins and sign-ups with a CAPTCHA once logins keep failing from the
+// caller's address or for the account. Failures are counted in Redis, shared by every replica, and tokens
+// are verified server-side by a Verifier; reCAPTCHA, hCaptcha and Turnstile are built in
+package captcha
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"auth-service/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrRequired means the caller must solve a challenge and send its token
+	ErrRequired = errors.New("captcha required")
+	// ErrInvalid means the provider refused the token: unsolved, expired, reused or for another site
+	ErrInvalid = errors.New("captcha verification failed")
+	// ErrUnavailable means the provider couldn't be asked; a required challenge can't be passed meanwhile
+	ErrUnavailable = errors.New("captcha verification unavailable")
+)
+
+// keyPrefix prefixes the Redis failure counters (captcha_failures:ip:<hash> and captcha_failures:account:<hash>)
+const keyPrefix = "captcha_failures:"
+
+// counterTimeout bounds the Redis calls made on every login
+const counterTimeout = 500 * time.Millisecond
+
+// siteverifyURLs are the providers' verification endpoints
+var siteverifyURLs = map[string]string{
+	config.CaptchaReCAPTCHA: "https://www.google.com/recaptcha/api/siteverify",
+	config.CaptchaHCaptcha:  "https://api.hcaptcha.com/siteverify",
+	config.CaptchaTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// Verifier asks a CAPTCHA provider whether a token is a solved challenge. Verify returns ErrInvalid when
+// the provider refuses the token and any other error when the provider couldn't answer
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// SiteVerifier verifies tokens with the siteverify protocol reCAPTCHA, hCaptcha and Turnstile share
+type SiteVerifier struct {
+	client    *http.Client
+	url       string
+	secret    string
+	hostnames []string
+	minScore  float64
+}
+
+// NewSiteVerifier creates the verifier of captcha.provider; a nil client uses one with captcha.timeout
+func NewSiteVerifier(cfg config.CaptchaConfig, client *http.Client) *SiteVerifier {
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+	verifyURL := cfg.VerifyURL
+	if verifyURL == "" {
+		verifyURL = siteverifyURLs[cfg.Provider]
+	}
+	return &SiteVerifier{client: client, url: verifyURL, secret: cfg.Secret, hostnames: cfg.Hostnames, minScore: cfg.MinScore}
+}
+
+// siteverifyResponse is the part of a siteverify answer the verifier checks
+type siteverifyResponse struct {
+	Success    bool     `json:"success"`
+	Hostname   string   `json:"hostname"`
+	Score      *float64 `json:"score"` // reCAPTCHA v3 and hCaptcha Enterprise only
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify posts the token to the provider and checks the answer's hostname and score
+func (v *SiteVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("siteverify request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("siteverify returned %s", resp.Status)
+	}
+	var answer siteverifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&answer); err != nil {
+		return fmt.Errorf("unreadable siteverify response: %w", err)
+	}
+
+	if !answer.Success {
+		return fmt.Errorf("%w: %s", ErrInvalid, strings.Join(answer.ErrorCodes, ", "))
+	}
+	if len(v.hostnames) > 0 && !slices.Contains(v.hostnames, answer.Hostname) {
+		return fmt.Errorf("%w: solved on %s", ErrInvalid, answer.Hostname)
+	}
+	if v.minScore > 0 && answer.Score != nil && *answer.Score < v.minScore {
+		return fmt.Errorf("%w: score %.2f", ErrInvalid, *answer.Score)
+	}
+	return nil
+}
+
+// Guard decides when a caller must solve a challenge and verifies the tokens sent
+// A nil Guard never challenges anyone
+type Guard struct {
+	redis    *redis.Client
+	verifier Verifier
+	config   config.CaptchaConfig
+
+	required, passed, failed, unavailable atomic.Int64
+}
+
+// NewGuard creates the guard; it returns nil when captcha.provider is off
+func NewGuard(client *redis.Client, verifier Verifier, cfg config.CaptchaConfig) *Guard {
+	if cfg.Provider == config.CaptchaOff {
+		return nil
+	}
+	return &Guard{redis: client, verifier: verifier, config: cfg}
+}
+
+// Check lets a login or sign-up from ip for email through unless either failed too often, in which
+// case token must be a solved challenge. Counters that can't be read don't challenge anyone
+func (g *Guard) Check(ctx context.Context, ip, email, token string) error {
+	if g == nil || !g.challenged(ctx, ip, email) {
+		return nil
+	}
+	return g.Challenge(ctx, ip, token)
+}
+
+// Challenge requires token to be a solved challenge whatever the failure counts, e.g. for callers from a
+// country in geoip.challenge_countries
+func (g *Guard) Challenge(ctx context.Context, ip, token string) error {
+	if g == nil {
+		return nil
+	}
+	if token == "" {
+		g.required.Add(1)
+		return ErrRequired
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.config.Timeout)
+	defer cancel()
+	err := g.verifier.Verify(ctx, token, ip)
+	switch {
+	case err == nil:
+		g.passed.Add(1)
+		return nil
+	case errors.Is(err, ErrInvalid):
+		g.failed.Add(1)
+		return err
+	default:
+		g.unavailable.Add(1)
+		log.Printf("⚠️  CAPTCHA verification failed: %v", err)
+		return ErrUnavailable
+	}
+}
+
+// challenged reports whether ip or email reached its failure threshold
+func (g *Guard) challenged(ctx context.Context, ip, email string) bool {
+	ctx, cancel := context.WithTimeout(ctx, counterTimeout)
+	defer cancel()
+	counts, err := g.redis.MGet(ctx, g.ipKey(ip), g.accountKey(email)).Result()
+	if err != nil {
+		log.Printf("⚠️  CAPTCHA failure counters unavailable: %v", err)
+		return false
+	}
+	return reached(counts[0], g.config.IPThreshold) || reached(counts[1], g.config.AccountThreshold)
+}
+
+// reached reports whether a counter read with MGET is at threshold; a zero threshold is never reached
+func reached(count interface{}, threshold int) bool {
+	value, ok := count.(string)
+	if !ok || threshold == 0 {
+		return false
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n >= threshold
+}
+
+// RecordFailure counts a failed login from ip for email; counters expire a window after their first failure
+func (g *Guard) RecordFailure(ctx context.Context, ip, email string) {
+	if g == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, counterTimeout)
+	defer cancel()
+	pipe := g.redis.TxPipeline()
+	for _, key := range []string{g.ipKey(ip), g.accountKey(email)} {
+		pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, g.config.Window)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️  Failed to count failed login for CAPTCHA: %v", err)
+	}
+}
+
+// Reset forgets the failures of email after its owner logged in; the address keeps its count
+func (g *Guard) Reset(ctx context.Context, email string) {
+	if g == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, counterTimeout)
+	defer cancel()
+	if err := g.redis.Del(ctx, g.accountKey(email)).Err(); err != nil {
+		log.Printf("⚠️  Failed to reset CAPTCHA failures: %v", err)
+	}
+}
+
+// ipKey and accountKey hash the address and email, which don't belong in Redis key names
+func (g *Guard) ipKey(ip string) string {
+	return keyPrefix + "ip:" + hash(ip)
+}
+
+func (g *Guard) accountKey(email string) string {
+	return keyPrefix + "account:" + hash(strings.ToLower(email))
+}
+
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// WritePrometheus writes this replica's challenge outcomes in the Prometheus text format
+func (g *Guard) WritePrometheus(w io.Writer) {
+	fmt.Fprintln(w, "# HELP auth_service_captcha_challenges_total Logins and sign-ups that had to solve a CAPTCHA, by outcome")
+	fmt.Fprintln(w, "# TYPE auth_service_captcha_challenges_total counter")
+	fmt.Fprintf(w, "auth_service_captcha_challenges_total{result=\"required\"} %d\n", g.required.Load())
+	fmt.Fprintf(w, "auth_service_captcha_challenges_total{result=\"passed\"} %d\n", g.passed.Load())
+	fmt.Fprintf(w, "auth_service_captcha_challenges_total{result=\"failed\"} %d\n", g.failed.Load())
+	fmt.Fprintf(w, "auth_service_captcha_challenges_total{result=\"unavailable\"} %d\n", g.unavailable.Load())
+}
@@ -1,10 +1,25 @@
 package config
 
 import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -21,11 +36,45 @@ type Config struct {
 	Logging       LoggingConfig    `toml:"logging"`
 	Metrics       MetricsConfig    `toml:"metrics"`
 	Tracing       TracingConfig    `toml:"tracing"`
+	RateLimiting  RateLimitingConfig `toml:"rate_limiting"`
 	Security      SecurityConfig   `toml:"security"`
 	Email         EmailConfig      `toml:"email"`
 	CORS          CORSConfig       `toml:"cors"`
 	Health        HealthConfig     `toml:"health"`
-	// OAuth2        OAuth2Config     `toml:"oauth2"` // Temporarily disabled for debugging
+	Discovery     DiscoveryConfig  `toml:"discovery"`
+	Telemetry     TelemetryConfig  `toml:"telemetry"`
+	TwoFactor     TwoFactorPolicyConfig `toml:"two_factor"`
+	Privacy       PrivacyConfig    `toml:"privacy"`
+	Registration  RegistrationConfig `toml:"registration"`
+	EmailDomains  EmailDomainsConfig `toml:"email_domains"`
+	BreachedPasswords BreachedPasswordsConfig `toml:"breached_passwords"`
+	Captcha       CaptchaConfig    `toml:"captcha"`
+	RoleGrants    RoleGrantConfig  `toml:"role_grants"`
+	SecurityPolicies []SecurityPolicyConfig `toml:"security_policies"`
+	CacheWarming  CacheWarmingConfig `toml:"cache_warming"`
+	TLSFingerprint TLSFingerprintConfig `toml:"tls_fingerprint"`
+	Honeypot      HoneypotConfig   `toml:"honeypot"`
+	Maintenance   MaintenanceConfig `toml:"maintenance"`
+	NotificationRetention NotificationRetentionConfig `toml:"notification_retention"`
+	ActivityArchive ActivityArchiveConfig `toml:"activity_archive"`
+	DeletedUserRetention DeletedUserRetentionConfig `toml:"deleted_user_retention"`
+	WebAuthn      WebAuthnConfig   `toml:"webauthn"`
+	WebPush       WebPushConfig    `toml:"web_push"`
+	AccessTokens  PersonalAccessTokenConfig `toml:"personal_access_tokens"`
+	ClientCredentials ClientCredentialsConfig `toml:"client_credentials"`
+	OAuth2        OAuth2Config     `toml:"oauth2"`
+	SessionReplication SessionReplicationConfig `toml:"session_replication"`
+	RevocationSnapshot RevocationSnapshotConfig `toml:"revocation_snapshot"`
+	ForwardAuth   ForwardAuthConfig `toml:"forward_auth"`
+	Deprecations  DeprecationsConfig `toml:"deprecations"`
+	DataEncryption DataEncryptionConfig `toml:"data_encryption"`
+	BulkExport    BulkExportConfig `toml:"bulk_export"`
+	LoginAlerts   LoginAlertsConfig `toml:"login_alerts"`
+	GeoIP         GeoIPConfig      `toml:"geoip"`
+	AuditLog      AuditLogConfig   `toml:"audit_log"`
+
+	// Source is the file the configuration was loaded from, reported in startup diagnostics
+	Source string `toml:"-"`
 }
 
 type ServerConfig struct {
@@ -35,6 +84,13 @@ type ServerConfig struct {
 	WriteTimeout    time.Duration `toml:"write_timeout"`
 	IdleTimeout     time.Duration `toml:"idle_timeout"`
 	ShutdownTimeout time.Duration `toml:"shutdown_timeout"`
+
+	// TrustedProxies lists the reverse proxies (addresses or CIDR ranges) whose client IP headers are
+	// believed; empty trusts none, and lockouts, rate limits and audit records use the peer address
+	TrustedProxies []string `toml:"trusted_proxies"`
+	// ClientIPHeaders are read in order when the request comes from a trusted proxy, e.g.
+	// "CF-Connecting-IP", "X-Forwarded-For" or "Forwarded"; list only headers your proxy overwrites
+	ClientIPHeaders []string `toml:"client_ip_headers"`
 }
 
 type DatabaseConfig struct {
@@ -48,8 +104,20 @@ type DatabaseConfig struct {
 	MaxIdleConns    int           `toml:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `toml:"conn_max_lifetime"`
 	MigrationPath   string        `toml:"migration_path"`
+	RunMigrations   bool          `toml:"run_migrations"`        // Apply embedded migrations at startup
+	MigrationEnv    string        `toml:"migration_environment"` // Environment recorded in schema_migrations
+	SlowQueryThreshold time.Duration `toml:"slow_query_threshold"` // Statements slower than this are logged as slow queries
+	// PendingMigrations decides what startup does when schema_migrations is behind the embedded
+	// migrations: "refuse" exits, "warn" starts with the migrations health check degraded
+	PendingMigrations string `toml:"pending_migrations"`
 }
 
+// Pending migration policies applied at startup
+const (
+	PendingMigrationsRefuse = "refuse"
+	PendingMigrationsWarn   = "warn"
+)
+
 type RedisConfig struct {
 	URL           string        `toml:"url"`
 	Password      string        `toml:"password"`
@@ -70,26 +138,252 @@ type JWTConfig struct {
 	Issuer        string `toml:"issuer"`
 	AccessExpiry  string `toml:"access_expiry"`
 	RefreshExpiry string `toml:"refresh_expiry"`
-	Algorithm     string `toml:"algorithm"`
+	Algorithm     string `toml:"algorithm"` // HS256 signs access tokens with access_secret; RS256 or EdDSA with signing_key_id
+
+	// SigningKeyID is the kid of the key in Keys that signs new access tokens when Algorithm is RS256 or
+	// EdDSA. Every key in Keys verifies tokens and is published at /.well-known/jwks.json, so rotating is:
+	// add the new key, deploy, switch signing_key_id, and remove the old key once access_expiry has passed.
+	// Refresh tokens are always HS256 with refresh_secret: only this service verifies them
+	SigningKeyID string   `toml:"signing_key_id"`
+	Keys         []JWTKey `toml:"keys"`
+
+	// RefreshMode "sliding" renews refresh_expiry on every rotation; "absolute" also ends the
+	// refresh token family refresh_max_lifetime after the login that started it
+	RefreshMode        string `toml:"refresh_mode"`
+	RefreshMaxLifetime string `toml:"refresh_max_lifetime"`
+
+	// RememberMeExpiry replaces refresh_expiry for logins that ask to be remembered (remember_me);
+	// empty turns the option off. Absolute mode still ends those logins at refresh_max_lifetime
+	RememberMeExpiry string `toml:"remember_me_expiry"`
+
+	// PermissionsClaim adds the user's effective permissions to access tokens as "perms", one
+	// space-separated string like an OAuth scope, so downstream services can check them offline
+	PermissionsClaim bool `toml:"permissions_claim"`
+
+	CustomClaims  CustomClaimsConfig  `toml:"custom_claims"`
+	RefreshCookie RefreshCookieConfig `toml:"refresh_cookie"`
+}
+
+// JWTKey is an asymmetric access token key of jwt.keys
+type JWTKey struct {
+	ID             string `toml:"kid"`
+	Algorithm      string `toml:"algorithm"`        // RS256 or EdDSA; defaults to jwt.algorithm
+	PrivateKeyFile string `toml:"private_key_file"` // PEM, PKCS#8 (or PKCS#1 for RSA)
+	PrivateKey     string `toml:"-"`                // Loaded from JWT_KEY_<KID>_PRIVATE_KEY or private_key_file
+}
+
+// Access token signing algorithms
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// minRSAKeyBits is the smallest RSA signing key accepted
+const minRSAKeyBits = 2048
+
+// jwtKeyEnv names the environment variable holding a signing key's PEM
+const jwtKeyEnv = "JWT_KEY_%s_PRIVATE_KEY"
+
+// jwtKeyID keeps kids usable in environment variable names and URLs
+var jwtKeyID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// jwtKeyEnvFor returns the environment variable holding the key's PEM
+func jwtKeyEnvFor(kid string) string {
+	return fmt.Sprintf(jwtKeyEnv, strings.ToUpper(strings.ReplaceAll(kid, "-", "_")))
+}
+
+// ParsePrivateKey decodes the key and checks it suits its algorithm: an RSA key of at least 2048 bits
+// for RS256, an Ed25519 key for EdDSA
+func (k JWTKey) ParsePrivateKey() (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(k.PrivateKey))
+	if block == nil {
+		return nil, errors.New("not PEM encoded")
+	}
+	var key interface{}
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k.Algorithm {
+	case JWTAlgorithmRS256:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok || rsaKey.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("not an RSA key of at least %d bits", minRSAKeyBits)
+		}
+		return rsaKey, nil
+	case JWTAlgorithmEdDSA:
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("not an Ed25519 key")
+		}
+		return edKey, nil
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", k.Algorithm)
+}
+
+// RefreshCookieConfig lets browser clients keep the refresh token in an HttpOnly cookie instead of
+// JavaScript-readable storage. Requests opt in with the X-Token-Transport: cookie header; refreshing
+// from the cookie then needs an allowed Origin, a Sec-Fetch-Site that isn't "none" and the CSRF token
+// from the CSRF cookie echoed in CSRFHeader (double submit). The CSRF token rotates on every refresh
+type RefreshCookieConfig struct {
+	Enabled       bool   `toml:"enabled"`
+	Name          string `toml:"name"`           // Refresh token cookie, HttpOnly
+	Path          string `toml:"path"`           // The browser only sends the refresh cookie to this path
+	Domain        string `toml:"domain"`         // Empty for a host-only cookie
+	SameSite      string `toml:"same_site"`      // strict, lax or none
+	AllowInsecure bool   `toml:"allow_insecure"` // Drops the Secure attribute; only for local development over HTTP
+	CSRFCookie    string `toml:"csrf_cookie"`    // Readable cookie holding the CSRF token
+	CSRFHeader    string `toml:"csrf_header"`    // Header the client echoes the CSRF token in; responses carry the new one in it too
+
+	// AllowedOrigins are first-party web apps on the same site as the auth service (Sec-Fetch-Site
+	// same-origin or same-site); registered clients add their own, cross-site ones in security_policies.origins
+	AllowedOrigins []string `toml:"allowed_origins"`
 }
 
+// Cookie SameSite modes for RefreshCookieConfig.SameSite
+const (
+	SameSiteStrict = "strict"
+	SameSiteLax    = "lax"
+	SameSiteNone   = "none" // Needed when the web app and auth service are on different sites
+)
+
+// CustomClaimsConfig limits what registered claims enrichers may add to access tokens
+type CustomClaimsConfig struct {
+	Allowed  []string `toml:"allowed"`   // Claim names enrichers may set; anything else is dropped
+	MaxBytes int      `toml:"max_bytes"` // Upper bound on the JSON size of all custom claims together
+}
+
+// reservedClaims are set by the auth service itself and can't be overridden by custom claims
+var reservedClaims = map[string]bool{
+	"user_id": true, "email": true, "username": true, "role": true, "roles": true, "type": true,
+	"session_id": true, "family_iat": true, "fid": true, "rm": true, "perms": true,
+	"iss": true, "sub": true, "aud": true, "exp": true, "nbf": true, "iat": true, "jti": true,
+}
+
+// IsReservedClaim reports whether name is a standard or auth-service claim
+func IsReservedClaim(name string) bool {
+	return reservedClaims[name]
+}
+
+// Refresh modes control whether rotating a refresh token can extend a login indefinitely
+const (
+	RefreshModeSliding  = "sliding"  // Each rotation grants a fresh refresh_expiry
+	RefreshModeAbsolute = "absolute" // Rotations never outlive refresh_max_lifetime from the original login
+)
+
 type OAuth2Config struct {
-	Google   OAuth2Provider `toml:"google"`
-	GitHub   OAuth2Provider `toml:"github"`
-	Facebook OAuth2Provider `toml:"facebook"`
+	Google   OAuth2Provider      `toml:"google"`
+	GitHub   OAuth2Provider      `toml:"github"`
+	Facebook OAuth2Provider      `toml:"facebook"`
+	Apple    AppleOAuth2Provider `toml:"apple"`
+	// OIDC holds any OpenID Connect providers, keyed by the name used in /oauth/{provider}
+	OIDC map[string]OIDCProvider `toml:"oidc"`
+
+	// FrontendRedirectURL, when set, makes the callback redirect there with a one-time code (or an
+	// error) instead of returning tokens; the frontend trades the code at /api/v1/auth/oauth/exchange
+	FrontendRedirectURL string        `toml:"frontend_redirect_url"`
+	CodeTTL             time.Duration `toml:"code_ttl"` // Lifetime of that one-time code
+	// StateTTL is how long a login started at /oauth/{provider} may take to come back to the callback
+	StateTTL time.Duration `toml:"state_ttl"`
+}
+
+// Enabled reports whether any OAuth2 provider is configured
+func (c OAuth2Config) Enabled() bool {
+	if c.Google.Enabled || c.GitHub.Enabled || c.Facebook.Enabled || c.Apple.Enabled {
+		return true
+	}
+	for _, provider := range c.OIDC {
+		if provider.Enabled {
+			return true
+		}
+	}
+	return false
 }
 
 type OAuth2Provider struct {
 	ClientID     string `toml:"client_id"`
-	ClientSecret string `toml:"client_secret"`
+	ClientSecret string `toml:"-"` // Loaded from OAUTH2_<PROVIDER>_CLIENT_SECRET; never belongs in the TOML file
 	RedirectURL  string `toml:"redirect_url"`
 	Enabled      bool   `toml:"enabled"`
 }
 
+// oauth2SecretEnv names the environment variable the secrets backend injects a provider's client secret through
+const oauth2SecretEnv = "OAUTH2_%s_CLIENT_SECRET"
+
+// AppleOAuth2Provider is Sign in with Apple. Apple has no static client secret: the service signs a
+// short-lived ES256 JWT with the team's private key for every code exchange
+type AppleOAuth2Provider struct {
+	ClientID       string `toml:"client_id"` // Services ID, e.g. com.example.web
+	TeamID         string `toml:"team_id"`
+	KeyID          string `toml:"key_id"`           // ID of the Sign in with Apple key
+	PrivateKey     string `toml:"-"`                // PEM of the key's .p8 file, from OAUTH2_APPLE_PRIVATE_KEY
+	PrivateKeyFile string `toml:"private_key_file"` // Read when OAUTH2_APPLE_PRIVATE_KEY is unset
+	RedirectURL    string `toml:"redirect_url"`
+	Enabled        bool   `toml:"enabled"`
+}
+
+// OIDCProvider is an OpenID Connect provider configured by its issuer: the endpoints come from the issuer's
+// discovery document and ID tokens are checked against its JWKS
+type OIDCProvider struct {
+	Issuer       string   `toml:"issuer"` // As stated in the discovery document, e.g. https://login.example.com
+	ClientID     string   `toml:"client_id"`
+	ClientSecret string   `toml:"-"` // Loaded from OAUTH2_OIDC_<NAME>_CLIENT_SECRET
+	RedirectURL  string   `toml:"redirect_url"`
+	Scopes       []string `toml:"scopes"` // Defaults to openid, email and profile
+	Enabled      bool     `toml:"enabled"`
+}
+
+// oidcSecretEnv names the environment variable holding an OIDC provider's client secret
+const oidcSecretEnv = "OAUTH2_OIDC_%s_CLIENT_SECRET"
+
+// oidcProviderName keeps OIDC provider names usable in URLs, environment variables and user_oauth_identities
+var oidcProviderName = regexp.MustCompile(`^[a-z][a-z0-9-]{0,49}$`)
+
+// reservedOAuthNames are the built-in providers and the other routes under /oauth
+var reservedOAuthNames = map[string]bool{"google": true, "github": true, "facebook": true, "apple": true, "exchange": true}
+
+// oidcSecretEnvFor returns the environment variable holding the named provider's client secret
+func oidcSecretEnvFor(name string) string {
+	return fmt.Sprintf(oidcSecretEnv, strings.ToUpper(strings.ReplaceAll(name, "-", "_")))
+}
+
+// appleKeyEnv names the environment variable the secrets backend injects the Apple private key through
+const appleKeyEnv = "OAUTH2_APPLE_PRIVATE_KEY"
+
+// ParsePrivateKey decodes the PKCS#8 P-256 key Apple issues as a .p8 file
+func (p AppleOAuth2Provider) ParsePrivateKey() (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(p.PrivateKey))
+	if block == nil {
+		return nil, errors.New("not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok || ecKey.Curve.Params().Name != "P-256" {
+		return nil, errors.New("not a P-256 key")
+	}
+	return ecKey, nil
+}
+
 type LoggingConfig struct {
 	Level  string `toml:"level"`
 	Format string `toml:"format"`
 	Output string `toml:"output"`
+
+	// Admins can change the level and turn on debug logging for one user or path through
+	// /api/v1/admin/logging; every change reverts by itself after at most MaxOverrideDuration
+	MaxOverrideDuration time.Duration `toml:"max_override_duration"`
+	SignalDebugDuration time.Duration `toml:"signal_debug_duration"` // How long SIGUSR1 switches a replica to debug
+	SyncInterval        time.Duration `toml:"sync_interval"`         // How often replicas pick up admin changes
 }
 
 type MetricsConfig struct {
@@ -105,9 +399,27 @@ type TracingConfig struct {
 	SampleRate     float64 `toml:"sample_rate"`
 }
 
-// Rate limiting is handled by Traefik Gateway - no service-level config needed
+// RateLimitingConfig decides where requests are rate limited. The limits themselves are the rateLimit
+// middlewares of the Traefik dynamic configuration, so the gateway and the service enforce the same ones
+type RateLimitingConfig struct {
+	// Mode is gateway (Traefik limits requests before they reach the service) or service (the service
+	// applies the gateway's limits itself with Redis-backed buckets, for runs without the gateway)
+	Mode string `toml:"mode"`
+	// GatewayConfig is the Traefik dynamic configuration file; relative paths are resolved from the
+	// directory of this configuration file
+	GatewayConfig string `toml:"gateway_config"`
+	// Routers are the Traefik routers to this service whose paths and rate limits are applied
+	Routers []string `toml:"routers"`
+}
+
+// Rate limiting modes
+const (
+	RateLimitingGateway = "gateway"
+	RateLimitingService = "service"
+)
 
 type SecurityConfig struct {
+	// BcryptCost is the work factor of new bcrypt hashes; bcrypt hashes of another cost are rehashed at login
 	BcryptCost              int           `toml:"bcrypt_cost"`
 	SessionTimeout          time.Duration `toml:"session_timeout"`
 	MaxSessionsPerUser      int           `toml:"max_sessions_per_user"`
@@ -115,8 +427,33 @@ type SecurityConfig struct {
 	PasswordRequireSpecial  bool          `toml:"password_require_special"`
 	PasswordRequireNumber   bool          `toml:"password_require_number"`
 	PasswordRequireUppercase bool         `toml:"password_require_uppercase"`
+	ResetTokenTTL           time.Duration `toml:"reset_token_ttl"`
+	InvitationTTL           time.Duration `toml:"invitation_ttl"` // How long an invited user's activation link stays valid
+	TokenBinding            string        `toml:"token_binding"` // none, user_agent, ip or strict
+	MaxLoginAttempts        int           `toml:"max_login_attempts"` // Failed passwords in a row before the account locks
+	LockoutDuration         time.Duration `toml:"lockout_duration"`   // How long a locked account refuses logins
+	// PasswordHash is the algorithm new password hashes use; hashes of the other algorithm or with other
+	// cost parameters still verify and are replaced at the next successful login
+	PasswordHash            string        `toml:"password_hash"`       // bcrypt or argon2id
+	Argon2Memory            int           `toml:"argon2_memory"`       // KiB
+	Argon2Iterations        int           `toml:"argon2_iterations"`
+	Argon2Parallelism       int           `toml:"argon2_parallelism"`
 }
 
+// Password hash algorithms
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// Token binding modes control how strictly one-time tokens are tied to the requesting device
+const (
+	TokenBindingNone      = "none"       // Any device may redeem the token
+	TokenBindingUserAgent = "user_agent" // Same user agent required (tolerates mobile IP changes)
+	TokenBindingIP        = "ip"         // Same IP address required
+	TokenBindingStrict    = "strict"     // Same IP address and user agent required
+)
+
 type EmailConfig struct {
 	SMTPHost    string `toml:"smtp_host"`
 	SMTPPort    int    `toml:"smtp_port"`
@@ -124,6 +461,7 @@ type EmailConfig struct {
 	Password    string `toml:"password"`
 	FromAddress string `toml:"from_address"`
 	FromName    string `toml:"from_name"`
+	LinkBaseURL string `toml:"link_base_url"` // Web app URL that links in emails point to, e.g. https://app.example.com
 }
 
 type CORSConfig struct {
@@ -140,6 +478,495 @@ type HealthConfig struct {
 	Timeout       time.Duration `toml:"timeout"`
 }
 
+// DiscoveryConfig controls background refresh of JWKS and OIDC discovery documents
+type DiscoveryConfig struct {
+	RefreshInterval time.Duration `toml:"refresh_interval"` // Normal refresh period (shortened by provider max-age)
+	RetryInterval   time.Duration `toml:"retry_interval"`   // First retry after a failed fetch, doubled up to RefreshInterval
+	MaxStale        time.Duration `toml:"max_stale"`        // How long the last good document is served while refreshes fail
+	Jitter          float64       `toml:"jitter"`           // Fraction of the interval randomised per refresh (0.1 = ±10%)
+}
+
+// TelemetryConfig controls anonymized login funnel analytics
+type TelemetryConfig struct {
+	Enabled    bool          `toml:"enabled"`
+	SampleRate float64       `toml:"sample_rate"` // Fraction of login attempts recorded (0 < rate <= 1)
+	Retention  time.Duration `toml:"retention"`   // How long hourly funnel buckets are kept
+}
+
+// TwoFactorPolicyConfig is the organization-wide two-factor authentication policy
+type TwoFactorPolicyConfig struct {
+	RequiredRoles []string `toml:"required_roles"` // Roles that must enable two-factor authentication, e.g. ["admin"]
+	// GracePeriod is how long a user in a required role may log in without two-factor authentication,
+	// counted from account creation or EnforcedFrom, whichever is later
+	GracePeriod  time.Duration `toml:"grace_period"`
+	EnforcedFrom time.Time     `toml:"enforced_from"` // When the policy took effect; zero counts from account creation only
+	// RequireApprovalToDisable stops users from turning two-factor authentication off themselves;
+	// an administrator has to disable it for them
+	RequireApprovalToDisable bool `toml:"require_approval_to_disable"`
+}
+
+// PrivacyConfig controls how client IP addresses are stored in login_attempts, sessions and user_activities
+type PrivacyConfig struct {
+	IPStorage string `toml:"ip_storage"` // full, truncate or hmac
+	// IPHashKeyFile is the path where the secrets backend mounts the HMAC key (e.g. /run/secrets/ip_hash_key)
+	// The IP_HASH_KEY environment variable takes precedence; the key never belongs in the TOML file
+	IPHashKeyFile string `toml:"ip_hash_key_file"`
+	IPHashKey     string `toml:"-"` // Loaded from IP_HASH_KEY or IPHashKeyFile
+}
+
+// RegistrationConfig controls how self-service sign-ups become active accounts
+type RegistrationConfig struct {
+	// Mode is open (accounts are active immediately) or approval (accounts wait in the admin review
+	// queue and can't log in until approved), e.g. for a closed beta
+	Mode string `toml:"mode"`
+}
+
+// EmailDomainsConfig checks the domain of registering addresses: disposable mailbox services are refused
+// and likely typos of a popular provider are returned with a did-you-mean suggestion. Admins replace the
+// lists at runtime through /api/v1/admin/email-domains
+type EmailDomainsConfig struct {
+	Enabled           bool              `toml:"enabled"`
+	DisposableDomains []string          `toml:"disposable_domains"` // Refused, with their subdomains; unset uses a built-in list
+	PopularDomains    []string          `toml:"popular_domains"`    // Typos are corrected to these; unset uses a built-in list
+	Typos             map[string]string `toml:"typos"`              // Misspellings edit distance misses, e.g. "gmail.co" = "gmail.com"
+	MaxTypoDistance   int               `toml:"max_typo_distance"`  // Edits (including swapped letters) a domain may be from a popular one
+	SyncInterval      time.Duration     `toml:"sync_interval"`      // How often replicas pick up lists changed through the admin endpoint
+}
+
+// Breached password modes: off, warn (accepted with a warning) or block (refused)
+const (
+	BreachedPasswordsOff   = "off"
+	BreachedPasswordsWarn  = "warn"
+	BreachedPasswordsBlock = "block"
+)
+
+// BreachedPasswordsConfig screens new passwords at registration and password change against the Have I
+// Been Pwned range API. Only the first 5 hex characters of the password's SHA-1 leave the service
+// (k-anonymity); when the API can't be reached the password is accepted
+type BreachedPasswordsConfig struct {
+	Mode      string        `toml:"mode"`
+	APIURL    string        `toml:"api_url"`    // Range endpoint; the hash prefix is appended
+	Timeout   time.Duration `toml:"timeout"`    // Per range request
+	CacheSize int           `toml:"cache_size"` // Hash prefixes whose ranges are kept in memory
+	CacheTTL  time.Duration `toml:"cache_ttl"`  // How long a cached range is used before it is fetched again
+}
+
+// CAPTCHA providers; all three verify tokens with the same siteverify protocol
+const (
+	CaptchaOff       = "off"
+	CaptchaReCAPTCHA = "recaptcha"
+	CaptchaHCaptcha  = "hcaptcha"
+	CaptchaTurnstile = "turnstile"
+)
+
+// CaptchaConfig makes logins and sign-ups solve a CAPTCHA once logins keep failing from the caller's
+// address or for the account. Failures are counted in Redis, so every replica challenges the same callers
+type CaptchaConfig struct {
+	Provider         string        `toml:"provider"`          // off, recaptcha, hcaptcha or turnstile
+	Secret           string        `toml:"-"`                 // Loaded from CAPTCHA_SECRET
+	VerifyURL        string        `toml:"verify_url"`        // Overrides the provider's siteverify endpoint
+	Hostnames        []string      `toml:"hostnames"`         // Sites a solved challenge may come from; empty accepts any
+	MinScore         float64       `toml:"min_score"`         // reCAPTCHA v3 and hCaptcha Enterprise scores below this fail
+	IPThreshold      int           `toml:"ip_threshold"`      // Failed logins from an address before it is challenged; 0 never
+	AccountThreshold int           `toml:"account_threshold"` // Failed logins for an email before it is challenged; 0 never
+	Window           time.Duration `toml:"window"`            // Failures are counted from the first one for this long
+	Timeout          time.Duration `toml:"timeout"`           // Per verification request
+}
+
+// captchaSecretEnv holds the secret key of the CAPTCHA provider
+const captchaSecretEnv = "CAPTCHA_SECRET"
+
+// GeoIP providers
+const (
+	GeoIPOff        = "off"
+	GeoIPMaxMindDB  = "maxmind_db"  // Local GeoIP2 or GeoLite2 City/Country database file
+	GeoIPMaxMindWeb = "maxmind_web" // GeoIP2 web service
+)
+
+// GeoIPConfig locates client addresses for login attempts and sessions, and refuses or challenges logins
+// and sign-ups by country
+type GeoIPConfig struct {
+	Provider           string        `toml:"provider"`            // off, maxmind_db or maxmind_web
+	DatabaseFile       string        `toml:"database_file"`       // .mmdb file of maxmind_db
+	AccountID          string        `toml:"account_id"`          // maxmind_web account
+	LicenseKey         string        `toml:"-"`                   // Loaded from GEOIP_LICENSE_KEY
+	URL                string        `toml:"url"`                 // Overrides the maxmind_web endpoint (City by default)
+	Timeout            time.Duration `toml:"timeout"`             // Per lookup
+	CacheTTL           time.Duration `toml:"cache_ttl"`           // How long maxmind_web answers are reused; 0 never
+	BlockedCountries   []string      `toml:"blocked_countries"`   // ISO 3166-1 alpha-2 codes whose logins and sign-ups are refused
+	ChallengeCountries []string      `toml:"challenge_countries"` // Codes whose logins and sign-ups always solve the CAPTCHA
+}
+
+// geoIPLicenseKeyEnv holds the license key of the MaxMind web service
+const geoIPLicenseKeyEnv = "GEOIP_LICENSE_KEY"
+
+// AuditLogConfig controls the hash chain of the audit log of admin operations and security events
+type AuditLogConfig struct {
+	// KeyFile is where the secrets backend mounts the HMAC key entries are chained with; the AUDIT_LOG_KEY
+	// environment variable takes precedence. Without a key entries are chained with plain SHA-256, which
+	// detects edits but not someone with database access rewriting the chain from the edit onwards
+	KeyFile string `toml:"key_file"`
+	Key     string `toml:"-"` // Loaded from AUDIT_LOG_KEY or KeyFile
+}
+
+// auditLogKeyEnv holds the HMAC key of the audit log chain
+const auditLogKeyEnv = "AUDIT_LOG_KEY"
+
+// minAuditLogKeyLength is the minimum audit log key size in bytes
+const minAuditLogKeyLength = 32
+
+// countryCode matches ISO 3166-1 alpha-2 country codes in either case
+var countryCode = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+// defaultDisposableDomains are widely used disposable mailbox services
+var defaultDisposableDomains = []string{
+	"10minutemail.com", "dispostable.com", "getnada.com", "guerrillamail.com", "mailinator.com", "maildrop.cc",
+	"sharklasers.com", "temp-mail.org", "tempmail.com", "throwawaymail.com", "trashmail.com", "yopmail.com",
+}
+
+// defaultPopularDomains are the most common mailbox providers
+var defaultPopularDomains = []string{
+	"gmail.com", "googlemail.com", "yahoo.com", "hotmail.com", "outlook.com", "live.com", "msn.com",
+	"icloud.com", "me.com", "aol.com", "proton.me", "protonmail.com", "gmx.com", "gmx.net", "mail.com",
+	"yandex.com", "comcast.net",
+}
+
+// RoleGrantConfig limits time-boxed role grants (just-in-time elevated access)
+type RoleGrantConfig struct {
+	MaxDuration time.Duration `toml:"max_duration"` // Longest grant an admin may issue, e.g. "8h" for an on-call shift
+	// ExpiryInterval is how often expired grants are ended and their holders' tokens revoked
+	// Access tokens never outlive a grant, so this only bounds how late the audit trail records the expiry
+	ExpiryInterval time.Duration `toml:"expiry_interval"`
+}
+
+// HoneypotConfig sets the response to a used honeypot account or canary API key
+type HoneypotConfig struct {
+	// BlockDuration is how long every request from the address that used a honeypot is refused; zero only alerts
+	BlockDuration time.Duration `toml:"block_duration"`
+	KeyPrefix     string        `toml:"key_prefix"` // Prefix of generated canary keys; make it look like the real keys an attacker hunts for
+}
+
+// MaintenanceConfig controls read-only maintenance mode, for running against a read replica during a primary failover
+// Token verification, refresh and reads keep working; writes get 503. Admins can also toggle the mode at runtime
+type MaintenanceConfig struct {
+	// ReadOnly starts the service read-only with a read-only database connection and no startup migrations
+	ReadOnly     bool          `toml:"read_only"`
+	Reason       string        `toml:"reason"`        // Shown to clients in the 503 message
+	SyncInterval time.Duration `toml:"sync_interval"` // How often replicas pick up a mode toggled through the admin endpoint
+}
+
+// SessionReplicationConfig replicates sessions between regions of an active-active deployment: session
+// creation, revocation and refresh token changes are published to a Redis stream shared by every region
+// and applied by each other region's replicator, so a login survives traffic shifting regions
+type SessionReplicationConfig struct {
+	Enabled       bool          `toml:"enabled"`
+	Region        string        `toml:"region"`         // This deployment's region; its own events are not applied again
+	RedisURL      string        `toml:"redis_url"`      // Cross-region Redis holding the stream
+	RedisPassword string        `toml:"-"`              // Loaded from SESSION_REPLICATION_REDIS_PASSWORD
+	Stream        string        `toml:"stream"`         // Stream key shared by every region
+	MaxLen        int64         `toml:"max_len"`        // Approximate number of events kept in the stream
+	BatchSize     int64         `toml:"batch_size"`     // Events read per round trip
+	BlockTimeout  time.Duration `toml:"block_timeout"`  // How long a read waits for new events
+	RevocationTTL time.Duration `toml:"revocation_ttl"` // How long revocations are remembered to reject late creates; at least the refresh token lifetime
+	MaxLag        time.Duration `toml:"max_lag"`        // Replication lag above which readiness reports session replication as degraded
+}
+
+// RevocationSnapshotConfig publishes the hashes of revoked refresh tokens at GET /revocations/refresh-tokens,
+// signed with the access token key, so edge gateways can reject them without calling the service
+type RevocationSnapshotConfig struct {
+	Enabled         bool          `toml:"enabled"`
+	RefreshInterval time.Duration `toml:"refresh_interval"` // How often the snapshot is rebuilt and signed again
+	MaxAge          time.Duration `toml:"max_age"`          // Lifetime of a signed snapshot; edges must fetch a newer one before it ends
+	Retention       time.Duration `toml:"retention"`        // How long a revoked token stays listed; at least the refresh token lifetime
+}
+
+// sessionReplicationPasswordEnv holds the password of the cross-region Redis
+const sessionReplicationPasswordEnv = "SESSION_REPLICATION_REDIS_PASSWORD"
+
+// NotificationRetentionConfig controls the background job that removes old read or expired notifications
+// Each removed notification is first counted in a monthly summary row per user
+type NotificationRetentionConfig struct {
+	Enabled   bool          `toml:"enabled"`
+	MaxAge    time.Duration `toml:"max_age"`    // Read or expired notifications older than this are removed; unread ones are kept
+	Mode      string        `toml:"mode"`       // archive (move to user_notifications_archive) or delete
+	Interval  time.Duration `toml:"interval"`   // How often the job runs
+	BatchSize int           `toml:"batch_size"` // Notifications removed per transaction
+}
+
+// ActivityArchiveConfig controls the background job that moves user activities older than max_age out of
+// Postgres into gzipped JSON Lines objects, one per user and batch, listed in user_activity_archives
+// The activity history API reads them back when a page reaches past the activities still in Postgres
+type ActivityArchiveConfig struct {
+	Enabled   bool                `toml:"enabled"`
+	MaxAge    time.Duration       `toml:"max_age"`    // Activities older than this are archived
+	Interval  time.Duration       `toml:"interval"`   // How often the job runs
+	BatchSize int                 `toml:"batch_size"` // Activities per archive object
+	Storage   ObjectStorageConfig `toml:"storage"`
+}
+
+// DeletedUserRetentionConfig controls the background job that purges accounts soft-deleted more than
+// retention_period ago. Their sessions, activities, notifications and other per-user rows are deleted;
+// the user row itself is deleted too or, in anonymize mode, kept with its personal data cleared
+type DeletedUserRetentionConfig struct {
+	Enabled         bool          `toml:"enabled"`
+	RetentionPeriod time.Duration `toml:"retention_period"` // Time between account deletion and purge, e.g. to undo mistakes
+	Mode            string        `toml:"mode"`             // anonymize (keep the row without personal data) or delete
+	Interval        time.Duration `toml:"interval"`         // How often the job runs
+	BatchSize       int           `toml:"batch_size"`       // Accounts looked up per query
+}
+
+// Object storage backends
+const (
+	ObjectStorageFilesystem = "filesystem"
+	ObjectStorageS3         = "s3"
+)
+
+// ObjectStorageConfig is where archive objects are kept: a directory, e.g. a mounted volume, or a bucket
+// of an S3-compatible service (AWS S3, MinIO, ...), addressed path-style
+type ObjectStorageConfig struct {
+	Backend         string        `toml:"backend"`       // filesystem or s3
+	Directory       string        `toml:"directory"`     // filesystem: root directory of the objects
+	Endpoint        string        `toml:"endpoint"`      // s3: service URL, e.g. "https://s3.eu-west-1.amazonaws.com"
+	Region          string        `toml:"region"`        // s3: signing region
+	Bucket          string        `toml:"bucket"`        // s3
+	Prefix          string        `toml:"prefix"`        // Prepended to every object key, e.g. "auth-service/"
+	AccessKeyID     string        `toml:"access_key_id"` // s3
+	SecretAccessKey string        `toml:"-"`             // s3: loaded from OBJECT_STORAGE_SECRET_ACCESS_KEY
+	Timeout         time.Duration `toml:"timeout"`       // s3: per request
+}
+
+// objectStorageSecretEnv holds the secret access key of the S3-compatible object storage
+const objectStorageSecretEnv = "OBJECT_STORAGE_SECRET_ACCESS_KEY"
+
+// WebAuthnConfig is the relying party passkeys are registered with; passkeys are disabled while RPID is empty
+type WebAuthnConfig struct {
+	RPID             string        `toml:"rp_id"`             // Domain passkeys are scoped to, e.g. "example.com"
+	RPName           string        `toml:"rp_name"`           // Shown by the browser and authenticator
+	Origins          []string      `toml:"origins"`           // Exact origins allowed to use passkeys, e.g. "https://app.example.com"
+	ChallengeTTL     time.Duration `toml:"challenge_ttl"`     // How long a registration or login ceremony may take
+	UserVerification string        `toml:"user_verification"` // required, preferred or discouraged (PIN or biometric on the authenticator)
+}
+
+// WebAuthn user verification requirements
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// WebPushConfig pushes notifications to the browsers users subscribed under /api/v1/auth/push-subscriptions
+// (Push API, RFC 8030). Pushes are signed with the VAPID key (RFC 8292) browsers subscribed with
+type WebPushConfig struct {
+	Enabled         bool          `toml:"enabled"`
+	Subject         string        `toml:"subject"`                    // Contact for push services, e.g. "mailto:ops@example.com"
+	VAPIDPrivateKey string        `toml:"-"`                          // base64url P-256 private key from WEB_PUSH_VAPID_PRIVATE_KEY
+	EndpointHosts   []string      `toml:"endpoint_hosts"`             // Push services subscriptions may use; a host also allows its subdomains
+	MaxPerUser      int           `toml:"max_subscriptions_per_user"` // Devices a user may subscribe
+	TTL             time.Duration `toml:"ttl"`                        // How long a push service keeps a push for an offline device
+	Timeout         time.Duration `toml:"timeout"`                    // Per push service request
+}
+
+// webPushKeyEnv names the environment variable the secrets backend injects the VAPID private key through
+const webPushKeyEnv = "WEB_PUSH_VAPID_PRIVATE_KEY"
+
+// defaultPushServiceHosts are the push services of Chrome, Firefox, Edge and Safari
+var defaultPushServiceHosts = []string{
+	"fcm.googleapis.com", "updates.push.services.mozilla.com", "notify.windows.com", "push.apple.com",
+}
+
+// ParseVAPIDKey decodes the private key as generated by web-push tools: the base64url P-256 scalar
+func (w WebPushConfig) ParseVAPIDKey() (*ecdsa.PrivateKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(w.VAPIDPrivateKey, "="))
+	if err != nil {
+		return nil, errors.New("not base64url encoded")
+	}
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	point := key.PublicKey().Bytes() // Uncompressed: 0x04 || X || Y
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point[1:33]),
+			Y:     new(big.Int).SetBytes(point[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}, nil
+}
+
+// PersonalAccessTokenConfig controls the long-lived tokens users mint for scripts and integrations
+// Bearer tokens starting with Prefix are looked up as personal access tokens instead of parsed as JWTs
+type PersonalAccessTokenConfig struct {
+	Prefix          string        `toml:"prefix"`           // Marks personal access tokens; must not overlap honeypot.key_prefix
+	MaxPerUser      int           `toml:"max_per_user"`     // Active tokens a user may hold
+	DefaultLifetime time.Duration `toml:"default_lifetime"` // Expiry of tokens created without expires_at
+	MaxLifetime     time.Duration `toml:"max_lifetime"`     // Latest expiry a user may choose; zero leaves it unlimited
+}
+
+// ClientCredentialsConfig controls the OAuth2 client-credentials grant at POST /oauth2/token, which issues
+// short-lived tokens to internal services registered under /api/v1/admin/oauth-clients
+type ClientCredentialsConfig struct {
+	Enabled       bool          `toml:"enabled"`
+	TokenLifetime time.Duration `toml:"token_lifetime"` // Client tokens can't be revoked, so keep them short-lived
+	Scopes        []string      `toml:"scopes"`         // Scopes admins may grant clients, e.g. "users:read"
+}
+
+// OAuthClientID is the form of client-credentials client IDs, e.g. billing-worker
+var OAuthClientID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{2,63}$`)
+
+// oauthScope keeps scopes free of the spaces that separate them in OAuth2 scope parameters
+var oauthScope = regexp.MustCompile(`^[a-z][a-z0-9_.:-]{0,63}$`)
+
+// maxClientTokenLifetime bounds client_credentials.token_lifetime
+const maxClientTokenLifetime = time.Hour
+
+// ForwardAuthConfig controls the headers /api/v1/verify returns for the gateway to copy onto requests
+// to downstream services. Without a matching mapping the default X-User-* headers are returned
+type ForwardAuthConfig struct {
+	// AllowedClaims are the claims mappings may emit; a mapping naming anything else fails validation,
+	// so e.g. email only reaches services once it is allowed here
+	AllowedClaims []string             `toml:"allowed_claims"`
+	Mappings      []ForwardAuthMapping `toml:"mappings"`
+}
+
+// ForwardAuthMapping replaces the default headers for one downstream service
+type ForwardAuthMapping struct {
+	// Audience is selected by ?audience= on the gateway's ForwardAuth address
+	Audience string `toml:"audience"`
+	// ClientIDs select the mapping for tokens issued to these registered clients when no audience is given
+	ClientIDs []string `toml:"client_ids"`
+	// Headers maps header names (X-...) to the claims they carry
+	Headers map[string]string `toml:"headers"`
+}
+
+// ForwardAuthClaims are the claims of a verified token that can be emitted as headers, besides the
+// custom claims allowed in jwt.custom_claims
+var ForwardAuthClaims = []string{"user_id", "email", "role", "roles", "permissions", "scopes", "client_id"}
+
+// forwardAuthHeader is an extension header name; X-Forwarded-* and X-Auth-Status are reserved
+var forwardAuthHeader = regexp.MustCompile(`^[Xx]-[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// DeprecationsConfig lists endpoints being retired. Their responses carry Deprecation, Sunset and Link
+// headers, and calls are counted per consumer (token client ID or user agent) for GET /api/v1/admin/deprecations
+type DeprecationsConfig struct {
+	Retention time.Duration        `toml:"retention"` // How long a consumer stays in the usage report after its last call
+	Endpoints []DeprecatedEndpoint `toml:"endpoints"`
+}
+
+// DeprecatedEndpoint is one route being retired
+type DeprecatedEndpoint struct {
+	Method     string    `toml:"method"`     // GET, POST, ...; empty for every method of the path
+	Path       string    `toml:"path"`       // Route as registered, e.g. /api/v1/auth/oauth/:provider
+	Deprecated time.Time `toml:"deprecated"` // When the endpoint was deprecated, sent in the Deprecation header
+	Sunset     time.Time `toml:"sunset"`     // When it will be removed, sent in the Sunset header; unset when not decided
+	Successor  string    `toml:"successor"`  // Path or URL replacing it, linked as rel="successor-version"
+	Link       string    `toml:"link"`       // Migration notes, linked as rel="deprecation"
+}
+
+// DataEncryptionConfig encrypts session Data and cached user blobs in Redis under a random key per user,
+// kept in user_data_keys wrapped by the master key. Deleting an account destroys its key, which leaves every
+// copy in Redis unreadable without having to find them
+type DataEncryptionConfig struct {
+	Enabled bool `toml:"enabled"`
+	// MasterKeyFile is the path where the secrets backend mounts the base64 master key (e.g. /run/secrets/data_master_key)
+	// The DATA_MASTER_KEY environment variable takes precedence; the key never belongs in the TOML file
+	MasterKeyFile string        `toml:"master_key_file"`
+	MasterKey     []byte        `toml:"-"`             // 32 bytes, decoded from DATA_MASTER_KEY or MasterKeyFile
+	KeyCacheTTL   time.Duration `toml:"key_cache_ttl"` // How long a replica keeps an unwrapped user key in memory
+}
+
+// BulkExportConfig controls GET /api/v1/admin/export/{resource}, which pages through users, sessions and
+// user activities as NDJSON for analytics and warehouse jobs. Pages are rate limited per admin in Redis
+type BulkExportConfig struct {
+	Enabled           bool  `toml:"enabled"`
+	DefaultPageSize   int   `toml:"default_page_size"`   // Rows per page when the limit parameter is omitted
+	MaxPageSize       int   `toml:"max_page_size"`       // Largest limit accepted; a page is held in memory before it is sent
+	RequestsPerMinute int64 `toml:"requests_per_minute"` // Pages an admin may fetch per minute on average
+	Burst             int64 `toml:"burst"`               // Pages an admin may fetch at once
+}
+
+// LoginAlertsConfig controls the "new sign-in to your account" alert a user gets when logging in from a
+// device and network none of their logins within lookback came from
+type LoginAlertsConfig struct {
+	Enabled  bool          `toml:"enabled"`
+	Email    bool          `toml:"email"`    // Email the alert as well as creating a notification
+	Lookback time.Duration `toml:"lookback"` // How long a device and network stay known after a login from them
+}
+
+// dataMasterKeyEnv names the environment variable the secrets backend may inject the data master key through
+const dataMasterKeyEnv = "DATA_MASTER_KEY"
+
+// dataMasterKeyLength is the size of the AES-256 master key in bytes
+const dataMasterKeyLength = 32
+
+// SecurityPolicyConfig overrides token lifetimes and login security for a tenant, identified by the
+// users' email domains, or a registered client, identified by the client_id it sends at login
+// Zero values keep the global setting; overrides may only be stricter than the global settings
+type SecurityPolicyConfig struct {
+	Name               string        `toml:"name"`
+	EmailDomains       []string      `toml:"email_domains"`         // Tenant: users whose email is in one of these domains
+	ClientIDs          []string      `toml:"client_ids"`            // Registered clients, e.g. an enterprise customer's portal
+	AccessExpiry       string        `toml:"access_expiry"`         // At most jwt.access_expiry
+	RefreshExpiry      string        `toml:"refresh_expiry"`        // At most jwt.refresh_expiry
+	MaxSessionsPerUser int           `toml:"max_sessions_per_user"` // At most security.max_sessions_per_user
+	RequireTwoFactor   bool          `toml:"require_two_factor"`    // Every user, not only two_factor.required_roles
+	MaxLoginAttempts   int           `toml:"max_login_attempts"`    // At most security.max_login_attempts
+	LockoutDuration    time.Duration `toml:"lockout_duration"`      // At least security.lockout_duration
+	Origins            []string      `toml:"origins"`               // Web origins of the clients, allowed to refresh from the refresh cookie
+}
+
+// CacheWarmingConfig controls loading critical data from Postgres into Redis at startup
+type CacheWarmingConfig struct {
+	Enabled bool `toml:"enabled"`
+	// BlockStartup keeps the server from accepting requests until every cache is warm or Timeout elapses;
+	// otherwise caches are warmed in the background while the server starts
+	BlockStartup bool          `toml:"block_startup"`
+	Timeout      time.Duration `toml:"timeout"` // Bound on the whole warm-up
+	TTL          time.Duration `toml:"ttl"`     // Lifetime of warmed entries
+}
+
+// TLSFingerprintConfig names the headers a TLS-terminating proxy forwards JA3/JA4 client fingerprints in
+// Only set them when the proxy overwrites these headers on every request; clients could otherwise send their own
+type TLSFingerprintConfig struct {
+	JA3Header string `toml:"ja3_header"` // e.g. "X-JA3-Fingerprint"
+	JA4Header string `toml:"ja4_header"` // e.g. "X-JA4-Fingerprint"; preferred for binding, stable across Chrome's extension shuffling
+	// BindRefreshTokens rejects refreshes from a different TLS client than the one that logged in
+	// Requests without a fingerprint are let through so a proxy that stops forwarding doesn't log everyone out
+	BindRefreshTokens bool `toml:"bind_refresh_tokens"`
+}
+
+// Notification retention modes
+const (
+	NotificationRetentionArchive = "archive"
+	NotificationRetentionDelete  = "delete"
+)
+
+// Deleted user retention modes
+const (
+	DeletedUserRetentionAnonymize = "anonymize"
+	DeletedUserRetentionDelete    = "delete"
+)
+
+// Registration modes
+const (
+	RegistrationOpen     = "open"
+	RegistrationApproval = "approval"
+)
+
+// IP storage modes
+const (
+	IPStorageFull     = "full"     // Store the address as received
+	IPStorageTruncate = "truncate" // Zero the host part: IPv4 to /24, IPv6 to /48
+	IPStorageHMAC     = "hmac"     // Store only a keyed hash; ip_address columns stay NULL
+)
+
+// ipHashKeyEnv names the environment variable the secrets backend may inject the IP hash key through
+const ipHashKeyEnv = "IP_HASH_KEY"
+
+// minIPHashKeyLength is the minimum IP hash key size in bytes
+const minIPHashKeyLength = 32
+
 // Load reads and parses environment-specific TOML configuration file with comprehensive fallback logic
 //
 // Purpose: Centralized configuration loading with environment-based file selection and .env integration
@@ -162,16 +989,41 @@ type HealthConfig struct {
 //   - Server: HTTP server settings (host, port, timeouts)
 //   - Database: PostgreSQL connection and pool configuration
 //   - Redis: Cache connection settings and pool configuration
-//   - JWT: Token secrets, expiration times, signing algorithm (HS256)
+//   - JWT: Token secrets, expiration times, signing algorithm (HS256), refresh token cookie
 //   - Security: bcrypt cost, session limits, password policies
 //   - CORS: Cross-origin policies for web client integration
-//   - OAuth2: External provider credentials (Google, GitHub, Facebook)
-//   - Logging: Log level, format, output destination
+//   - OAuth2: External provider credentials (Google, GitHub, Facebook, Apple) and the frontend redirect
+//   - Logging: Log level, format, output destination, runtime level overrides
 //   - Metrics: Prometheus configuration
 //   - Tracing: Jaeger distributed tracing settings
-//   - RateLimiting: Login attempt limits and lockout policies
+//   - RateLimiting: Whether Traefik or the service applies the gateway's rate limits
 //   - Email: SMTP configuration for notifications
 //   - Health: Health check intervals and timeouts
+//   - Discovery: JWKS/OIDC document refresh, retry and staleness limits
+//   - Telemetry: Login funnel sampling and retention
+//   - EmailDomains: Disposable domains refused and typo suggestions at registration
+//   - BreachedPasswords: Have I Been Pwned screening of new passwords and its range cache
+//   - TwoFactor: Roles required to enroll in two-factor authentication and the enrollment grace period
+//   - SecurityPolicies: Stricter token lifetimes, session limits, 2FA and lockout per tenant or client
+//   - CacheWarming: Startup warm-up of critical Redis caches, blocking or in the background
+//   - TLSFingerprint: Proxy headers carrying JA3/JA4 fingerprints and refresh token binding
+//   - Honeypot: IP block duration and key prefix for honeypot accounts and canary API keys
+//   - Maintenance: Read-only mode for running against a read replica
+//   - NotificationRetention: Age, mode and schedule of the notification archiving job
+//   - ActivityArchive: Age and schedule of moving user activities to object storage, and the storage
+//   - DeletedUserRetention: Retention period, mode and schedule of purging soft-deleted accounts
+//   - WebAuthn: Relying party and origins for passkey registration and login
+//   - AccessTokens: Prefix, per-user limit and lifetimes of personal access tokens
+//   - ClientCredentials: Lifetime and grantable scopes of service tokens from POST /oauth2/token
+//   - SessionReplication: Cross-region session stream for active-active deployments
+//   - RevocationSnapshot: Signed list of revoked refresh tokens polled by edge gateways
+//   - ForwardAuth: Claims /api/v1/verify emits as headers, per downstream audience or client
+//   - Deprecations: Endpoints answered with Deprecation and Sunset headers, and how long their usage is kept
+//   - DataEncryption: Per-user keys sealing session Data and cached users, wrapped by a master key
+//   - Captcha: Provider and failed login thresholds after which logins and sign-ups are challenged
+//   - BulkExport: Page sizes and per-admin rate limit of the NDJSON export for analytics pipelines
+//   - LoginAlerts: Notification and email on logins from an unseen device and network
+//   - GeoIP: Location of login attempts and sessions, and the countries refused or challenged
 // File Resolution Strategy:
 //   1. Service-specific config directory (config/)
 //   2. Current working directory config
@@ -231,21 +1083,36 @@ func Load(environment string) (*Config, error) {
 	
 	// Return error if no configuration file is found
 	if configPath == "" {
-		return nil, fmt.Errorf("could not find %s configuration file in any of the expected locations", configFileName)
+		return nil, fmt.Errorf("could not find %s configuration file in any of the expected locations (%s)",
+			configFileName, strings.Join(configPaths, ", "))
 	}
 	
+	// Steps 6-9: Parse, apply defaults and validate
+	return LoadFile(configPath)
+}
+
+// LoadFile parses a specific TOML configuration file, applies defaults and validates it
+// Used by tools such as the migrate CLI that are pointed at a config file explicitly
+func LoadFile(configPath string) (*Config, error) {
 	// Step 6: Parse TOML configuration file into Config struct
 	var config Config
 	if _, err := toml.DecodeFile(configPath, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
 	}
 	
+	config.Source = configPath
+
 	// Step 7: Apply default values for missing fields
 	setDefaults(&config)
 	
 	// Step 8: Expand environment variables in configuration (${VAR:default} patterns)
 	// Temporarily disabled for debugging
 	// expandEnvironmentVariables(&config)
+
+	// Secrets are read from the environment or files mounted by the secrets backend, never from TOML
+	if err := loadSecrets(&config); err != nil {
+		return nil, err
+	}
 	
 	// Step 9: Validate configuration
 	if err := validate(&config); err != nil {
@@ -255,6 +1122,84 @@ func Load(environment string) (*Config, error) {
 	return &config, nil
 }
 
+// loadSecrets reads secrets that are kept out of the configuration file
+// The IP hash key file is only read when hmac IP storage needs it
+func loadSecrets(cfg *Config) error {
+	cfg.OAuth2.Google.ClientSecret = os.Getenv(fmt.Sprintf(oauth2SecretEnv, "GOOGLE"))
+	cfg.OAuth2.GitHub.ClientSecret = os.Getenv(fmt.Sprintf(oauth2SecretEnv, "GITHUB"))
+	cfg.OAuth2.Facebook.ClientSecret = os.Getenv(fmt.Sprintf(oauth2SecretEnv, "FACEBOOK"))
+	cfg.SessionReplication.RedisPassword = os.Getenv(sessionReplicationPasswordEnv)
+	for i, key := range cfg.JWT.Keys {
+		if value := os.Getenv(jwtKeyEnvFor(key.ID)); value != "" {
+			cfg.JWT.Keys[i].PrivateKey = value
+		} else if key.PrivateKeyFile != "" {
+			data, err := os.ReadFile(key.PrivateKeyFile)
+			if err != nil {
+				return fmt.Errorf("failed to read jwt.keys %s private key: %w", key.ID, err)
+			}
+			cfg.JWT.Keys[i].PrivateKey = string(data)
+		}
+	}
+	for name, provider := range cfg.OAuth2.OIDC {
+		provider.ClientSecret = os.Getenv(oidcSecretEnvFor(name))
+		cfg.OAuth2.OIDC[name] = provider
+	}
+	cfg.WebPush.VAPIDPrivateKey = os.Getenv(webPushKeyEnv)
+	cfg.Captcha.Secret = os.Getenv(captchaSecretEnv)
+	cfg.GeoIP.LicenseKey = os.Getenv(geoIPLicenseKeyEnv)
+	if key := os.Getenv(auditLogKeyEnv); key != "" {
+		cfg.AuditLog.Key = key
+	} else if cfg.AuditLog.KeyFile != "" {
+		key, err := os.ReadFile(cfg.AuditLog.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read audit log key: %w", err)
+		}
+		cfg.AuditLog.Key = strings.TrimSpace(string(key))
+	}
+	cfg.ActivityArchive.Storage.SecretAccessKey = os.Getenv(objectStorageSecretEnv)
+	if key := os.Getenv(appleKeyEnv); key != "" {
+		cfg.OAuth2.Apple.PrivateKey = key
+	} else if cfg.OAuth2.Apple.Enabled && cfg.OAuth2.Apple.PrivateKeyFile != "" {
+		key, err := os.ReadFile(cfg.OAuth2.Apple.PrivateKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read Apple private key: %w", err)
+		}
+		cfg.OAuth2.Apple.PrivateKey = string(key)
+	}
+
+	if cfg.DataEncryption.Enabled {
+		key := os.Getenv(dataMasterKeyEnv)
+		if key == "" && cfg.DataEncryption.MasterKeyFile != "" {
+			data, err := os.ReadFile(cfg.DataEncryption.MasterKeyFile)
+			if err != nil {
+				return fmt.Errorf("failed to read data master key: %w", err)
+			}
+			key = strings.TrimSpace(string(data))
+		}
+		if key != "" {
+			decoded, err := base64.StdEncoding.DecodeString(key)
+			if err != nil {
+				return fmt.Errorf("data master key is not valid base64: %w", err)
+			}
+			cfg.DataEncryption.MasterKey = decoded
+		}
+	}
+
+	if cfg.Privacy.IPStorage != IPStorageHMAC {
+		return nil
+	}
+	if key := os.Getenv(ipHashKeyEnv); key != "" {
+		cfg.Privacy.IPHashKey = key
+	} else if cfg.Privacy.IPHashKeyFile != "" {
+		key, err := os.ReadFile(cfg.Privacy.IPHashKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read IP hash key: %w", err)
+		}
+		cfg.Privacy.IPHashKey = strings.TrimSpace(string(key))
+	}
+	return nil
+}
+
 // expandEnvironmentVariables recursively expands environment variables in configuration strings
 //
 // Purpose: Replaces ${ENV_VAR} or ${ENV_VAR:default_value} patterns with actual environment variable values
@@ -387,6 +1332,9 @@ func setDefaults(cfg *Config) {
 	if cfg.Server.ShutdownTimeout == 0 {
 		cfg.Server.ShutdownTimeout = 30 * time.Second
 	}
+	if len(cfg.Server.ClientIPHeaders) == 0 {
+		cfg.Server.ClientIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+	}
 
 	// Database defaults
 	if cfg.Database.SSLMode == "" {
@@ -417,12 +1365,52 @@ func setDefaults(cfg *Config) {
 	if cfg.JWT.Algorithm == "" {
 		cfg.JWT.Algorithm = "HS256"
 	}
+	for i := range cfg.JWT.Keys {
+		if cfg.JWT.Keys[i].Algorithm == "" {
+			cfg.JWT.Keys[i].Algorithm = cfg.JWT.Algorithm
+		}
+	}
 	if cfg.JWT.AccessExpiry == "" {
 		cfg.JWT.AccessExpiry = "15m"
 	}
 	if cfg.JWT.RefreshExpiry == "" {
 		cfg.JWT.RefreshExpiry = "168h" // 7 days
 	}
+	if cfg.JWT.RefreshMode == "" {
+		cfg.JWT.RefreshMode = RefreshModeSliding
+	}
+	if cfg.JWT.RefreshMaxLifetime == "" {
+		cfg.JWT.RefreshMaxLifetime = "720h" // 30 days
+	}
+	if cfg.JWT.CustomClaims.MaxBytes == 0 {
+		cfg.JWT.CustomClaims.MaxBytes = 1024
+	}
+	if cfg.JWT.RefreshCookie.Name == "" {
+		cfg.JWT.RefreshCookie.Name = "refresh_token"
+	}
+	if cfg.JWT.RefreshCookie.Path == "" {
+		cfg.JWT.RefreshCookie.Path = "/api/v1/auth/refresh"
+	}
+	if cfg.JWT.RefreshCookie.SameSite == "" {
+		cfg.JWT.RefreshCookie.SameSite = SameSiteStrict
+	}
+	if cfg.JWT.RefreshCookie.CSRFCookie == "" {
+		cfg.JWT.RefreshCookie.CSRFCookie = "csrf_token"
+	}
+	if cfg.JWT.RefreshCookie.CSRFHeader == "" {
+		cfg.JWT.RefreshCookie.CSRFHeader = "X-CSRF-Token"
+	}
+
+	// Rate limiting defaults: the limits come from the gateway's routers to this service
+	if cfg.RateLimiting.Mode == "" {
+		cfg.RateLimiting.Mode = RateLimitingGateway
+	}
+	if cfg.RateLimiting.GatewayConfig == "" {
+		cfg.RateLimiting.GatewayConfig = "../../../config/traefik/dynamic/dynamic.toml"
+	}
+	if cfg.RateLimiting.Routers == nil {
+		cfg.RateLimiting.Routers = []string{"auth-service", "auth-health"}
+	}
 
 	// Security defaults
 	if cfg.Security.BcryptCost == 0 {
@@ -434,31 +1422,357 @@ func setDefaults(cfg *Config) {
 	if cfg.Security.PasswordMinLength == 0 {
 		cfg.Security.PasswordMinLength = 8
 	}
-}
+	if cfg.Security.ResetTokenTTL == 0 {
+		cfg.Security.ResetTokenTTL = time.Hour
+	}
+	if cfg.Security.InvitationTTL == 0 {
+		cfg.Security.InvitationTTL = 72 * time.Hour
+	}
+	if cfg.Security.TokenBinding == "" {
+		cfg.Security.TokenBinding = TokenBindingUserAgent
+	}
+	if cfg.Security.MaxLoginAttempts == 0 {
+		cfg.Security.MaxLoginAttempts = 5
+	}
+	if cfg.Security.LockoutDuration == 0 {
+		cfg.Security.LockoutDuration = 15 * time.Minute
+	}
+	if cfg.Security.PasswordHash == "" {
+		cfg.Security.PasswordHash = PasswordHashBcrypt
+	}
+	// Argon2id defaults are the second recommended option of RFC 9106 for memory-constrained servers
+	if cfg.Security.Argon2Memory == 0 {
+		cfg.Security.Argon2Memory = 64 * 1024
+	}
+	if cfg.Security.Argon2Iterations == 0 {
+		cfg.Security.Argon2Iterations = 3
+	}
+	if cfg.Security.Argon2Parallelism == 0 {
+		cfg.Security.Argon2Parallelism = 4
+	}
 
-// loadEnvFile loads the appropriate .env file based on environment
-func loadEnvFile(environment string) error {
-	// Determine .env file based on environment
-	var envFile string
-	if environment == "local" {
-		envFile = ".env.local"
-	} else {
-		envFile = ".env"
+	// Privacy defaults
+	if cfg.Privacy.IPStorage == "" {
+		cfg.Privacy.IPStorage = IPStorageFull
 	}
 
-	// Try to find .env file in various locations
-	envPaths := []string{
-		envFile,                    // current directory
-		"../" + envFile,            // parent directory
-		"../../" + envFile,         // grandparent directory
-		"../../../" + envFile,      // great-grandparent (for nested service structure)
+	// Registration defaults
+	if cfg.Registration.Mode == "" {
+		cfg.Registration.Mode = RegistrationOpen
 	}
 
-	var envPath string
-	for _, path := range envPaths {
-		if _, err := os.Stat(path); err == nil {
-			envPath = path
-			break
+	// Email domain defaults
+	if cfg.EmailDomains.DisposableDomains == nil {
+		cfg.EmailDomains.DisposableDomains = defaultDisposableDomains
+	}
+	if cfg.EmailDomains.PopularDomains == nil {
+		cfg.EmailDomains.PopularDomains = defaultPopularDomains
+	}
+	if cfg.EmailDomains.MaxTypoDistance == 0 {
+		cfg.EmailDomains.MaxTypoDistance = 1
+	}
+	if cfg.EmailDomains.SyncInterval == 0 {
+		cfg.EmailDomains.SyncInterval = 30 * time.Second
+	}
+
+	// Breached password defaults
+	if cfg.BreachedPasswords.Mode == "" {
+		cfg.BreachedPasswords.Mode = BreachedPasswordsOff
+	}
+	if cfg.BreachedPasswords.APIURL == "" {
+		cfg.BreachedPasswords.APIURL = "https://api.pwnedpasswords.com/range/"
+	}
+	if cfg.BreachedPasswords.Timeout == 0 {
+		cfg.BreachedPasswords.Timeout = 2 * time.Second
+	}
+	if cfg.BreachedPasswords.CacheSize == 0 {
+		cfg.BreachedPasswords.CacheSize = 100
+	}
+	if cfg.BreachedPasswords.CacheTTL == 0 {
+		cfg.BreachedPasswords.CacheTTL = 24 * time.Hour
+	}
+
+	// CAPTCHA defaults
+	if cfg.Captcha.Provider == "" {
+		cfg.Captcha.Provider = CaptchaOff
+	}
+	if cfg.Captcha.Window == 0 {
+		cfg.Captcha.Window = time.Hour
+	}
+	if cfg.Captcha.Timeout == 0 {
+		cfg.Captcha.Timeout = 5 * time.Second
+	}
+
+	// Role grant defaults
+	if cfg.RoleGrants.MaxDuration == 0 {
+		cfg.RoleGrants.MaxDuration = 8 * time.Hour
+	}
+	if cfg.RoleGrants.ExpiryInterval == 0 {
+		cfg.RoleGrants.ExpiryInterval = time.Minute
+	}
+	if cfg.Honeypot.KeyPrefix == "" {
+		cfg.Honeypot.KeyPrefix = "ak_live_"
+	}
+	if cfg.Maintenance.SyncInterval == 0 {
+		cfg.Maintenance.SyncInterval = 5 * time.Second
+	}
+
+	// Forward auth defaults: identifiers only; email and custom claims must be allowed explicitly
+	if cfg.ForwardAuth.AllowedClaims == nil {
+		cfg.ForwardAuth.AllowedClaims = []string{"user_id", "role", "roles", "permissions", "scopes", "client_id"}
+	}
+
+	if cfg.Deprecations.Retention == 0 {
+		cfg.Deprecations.Retention = 90 * 24 * time.Hour
+	}
+
+	if cfg.DataEncryption.KeyCacheTTL == 0 {
+		cfg.DataEncryption.KeyCacheTTL = time.Minute
+	}
+
+	// Bulk export defaults
+	if cfg.BulkExport.DefaultPageSize == 0 {
+		cfg.BulkExport.DefaultPageSize = 1000
+	}
+	if cfg.BulkExport.MaxPageSize == 0 {
+		cfg.BulkExport.MaxPageSize = 10000
+	}
+	if cfg.BulkExport.RequestsPerMinute == 0 {
+		cfg.BulkExport.RequestsPerMinute = 30
+	}
+	if cfg.BulkExport.Burst == 0 {
+		cfg.BulkExport.Burst = 5
+	}
+
+	// Login alert defaults
+	if cfg.LoginAlerts.Lookback == 0 {
+		cfg.LoginAlerts.Lookback = 90 * 24 * time.Hour
+	}
+
+	// GeoIP defaults
+	if cfg.GeoIP.Provider == "" {
+		cfg.GeoIP.Provider = GeoIPOff
+	}
+	if cfg.GeoIP.Timeout == 0 {
+		cfg.GeoIP.Timeout = 2 * time.Second
+	}
+	if cfg.GeoIP.CacheTTL == 0 {
+		cfg.GeoIP.CacheTTL = 24 * time.Hour
+	}
+
+	// Session replication defaults
+	if cfg.SessionReplication.Stream == "" {
+		cfg.SessionReplication.Stream = "session_replication"
+	}
+	if cfg.SessionReplication.MaxLen == 0 {
+		cfg.SessionReplication.MaxLen = 1000000
+	}
+	if cfg.SessionReplication.BatchSize == 0 {
+		cfg.SessionReplication.BatchSize = 100
+	}
+	if cfg.SessionReplication.BlockTimeout == 0 {
+		cfg.SessionReplication.BlockTimeout = 5 * time.Second
+	}
+	if cfg.SessionReplication.RevocationTTL == 0 {
+		cfg.SessionReplication.RevocationTTL = 30 * 24 * time.Hour
+	}
+	if cfg.SessionReplication.MaxLag == 0 {
+		cfg.SessionReplication.MaxLag = time.Minute
+	}
+
+	// Revocation snapshot defaults
+	if cfg.RevocationSnapshot.RefreshInterval == 0 {
+		cfg.RevocationSnapshot.RefreshInterval = 30 * time.Second
+	}
+	if cfg.RevocationSnapshot.MaxAge == 0 {
+		cfg.RevocationSnapshot.MaxAge = 5 * time.Minute
+	}
+	if cfg.RevocationSnapshot.Retention == 0 {
+		cfg.RevocationSnapshot.Retention = 30 * 24 * time.Hour
+	}
+
+	// Notification retention defaults
+	if cfg.NotificationRetention.MaxAge == 0 {
+		cfg.NotificationRetention.MaxAge = 90 * 24 * time.Hour
+	}
+	if cfg.NotificationRetention.Mode == "" {
+		cfg.NotificationRetention.Mode = NotificationRetentionArchive
+	}
+	if cfg.NotificationRetention.Interval == 0 {
+		cfg.NotificationRetention.Interval = time.Hour
+	}
+	if cfg.NotificationRetention.BatchSize == 0 {
+		cfg.NotificationRetention.BatchSize = 1000
+	}
+
+	// Deleted user retention defaults
+	if cfg.DeletedUserRetention.RetentionPeriod == 0 {
+		cfg.DeletedUserRetention.RetentionPeriod = 30 * 24 * time.Hour
+	}
+	if cfg.DeletedUserRetention.Mode == "" {
+		cfg.DeletedUserRetention.Mode = DeletedUserRetentionAnonymize
+	}
+	if cfg.DeletedUserRetention.Interval == 0 {
+		cfg.DeletedUserRetention.Interval = time.Hour
+	}
+	if cfg.DeletedUserRetention.BatchSize == 0 {
+		cfg.DeletedUserRetention.BatchSize = 100
+	}
+
+	// Activity archive defaults
+	if cfg.ActivityArchive.MaxAge == 0 {
+		cfg.ActivityArchive.MaxAge = 180 * 24 * time.Hour
+	}
+	if cfg.ActivityArchive.Interval == 0 {
+		cfg.ActivityArchive.Interval = time.Hour
+	}
+	if cfg.ActivityArchive.BatchSize == 0 {
+		cfg.ActivityArchive.BatchSize = 1000
+	}
+	if cfg.ActivityArchive.Storage.Backend == "" {
+		cfg.ActivityArchive.Storage.Backend = ObjectStorageFilesystem
+	}
+	if cfg.ActivityArchive.Storage.Region == "" {
+		cfg.ActivityArchive.Storage.Region = "us-east-1"
+	}
+	if cfg.ActivityArchive.Storage.Timeout == 0 {
+		cfg.ActivityArchive.Storage.Timeout = 30 * time.Second
+	}
+
+	// WebAuthn defaults
+	if cfg.WebAuthn.RPName == "" {
+		cfg.WebAuthn.RPName = "Auth Service"
+	}
+	if cfg.WebAuthn.ChallengeTTL == 0 {
+		cfg.WebAuthn.ChallengeTTL = 5 * time.Minute
+	}
+	if cfg.WebAuthn.UserVerification == "" {
+		cfg.WebAuthn.UserVerification = UserVerificationPreferred
+	}
+
+	// Web Push defaults
+	if len(cfg.WebPush.EndpointHosts) == 0 {
+		cfg.WebPush.EndpointHosts = defaultPushServiceHosts
+	}
+	if cfg.WebPush.MaxPerUser == 0 {
+		cfg.WebPush.MaxPerUser = 10
+	}
+	if cfg.WebPush.TTL == 0 {
+		cfg.WebPush.TTL = 24 * time.Hour
+	}
+	if cfg.WebPush.Timeout == 0 {
+		cfg.WebPush.Timeout = 10 * time.Second
+	}
+
+	// Logging defaults
+	if cfg.Logging.MaxOverrideDuration == 0 {
+		cfg.Logging.MaxOverrideDuration = time.Hour
+	}
+	if cfg.Logging.SignalDebugDuration == 0 {
+		cfg.Logging.SignalDebugDuration = 15 * time.Minute
+	}
+	if cfg.Logging.SyncInterval == 0 {
+		cfg.Logging.SyncInterval = 5 * time.Second
+	}
+
+	// OAuth2 defaults
+	if cfg.OAuth2.CodeTTL == 0 {
+		cfg.OAuth2.CodeTTL = time.Minute
+	}
+	if cfg.OAuth2.StateTTL == 0 {
+		cfg.OAuth2.StateTTL = 10 * time.Minute
+	}
+	for name, provider := range cfg.OAuth2.OIDC {
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"openid", "email", "profile"}
+			cfg.OAuth2.OIDC[name] = provider
+		}
+	}
+
+	// Personal access token defaults
+	if cfg.AccessTokens.Prefix == "" {
+		cfg.AccessTokens.Prefix = "pat_"
+	}
+	if cfg.AccessTokens.MaxPerUser == 0 {
+		cfg.AccessTokens.MaxPerUser = 20
+	}
+	if cfg.AccessTokens.DefaultLifetime == 0 {
+		cfg.AccessTokens.DefaultLifetime = 30 * 24 * time.Hour
+	}
+	if cfg.ClientCredentials.TokenLifetime == 0 {
+		cfg.ClientCredentials.TokenLifetime = 5 * time.Minute
+	}
+
+	// Cache warming defaults
+	if cfg.CacheWarming.Timeout == 0 {
+		cfg.CacheWarming.Timeout = 10 * time.Second
+	}
+	if cfg.CacheWarming.TTL == 0 {
+		cfg.CacheWarming.TTL = 10 * time.Minute
+	}
+
+	// Database defaults
+	if cfg.Database.MigrationEnv == "" {
+		cfg.Database.MigrationEnv = "production"
+	}
+	if cfg.Database.SlowQueryThreshold == 0 {
+		cfg.Database.SlowQueryThreshold = 200 * time.Millisecond
+	}
+	if cfg.Database.PendingMigrations == "" {
+		cfg.Database.PendingMigrations = PendingMigrationsWarn
+	}
+
+	// Discovery defaults
+	if cfg.Discovery.RefreshInterval == 0 {
+		cfg.Discovery.RefreshInterval = time.Hour
+	}
+	if cfg.Discovery.RetryInterval == 0 {
+		cfg.Discovery.RetryInterval = 30 * time.Second
+	}
+	if cfg.Discovery.MaxStale == 0 {
+		cfg.Discovery.MaxStale = 24 * time.Hour
+	}
+	if cfg.Discovery.Jitter == 0 {
+		cfg.Discovery.Jitter = 0.1
+	}
+
+	// Telemetry defaults
+	if cfg.Telemetry.SampleRate == 0 {
+		cfg.Telemetry.SampleRate = 1.0
+	}
+	if cfg.Telemetry.Retention == 0 {
+		cfg.Telemetry.Retention = 30 * 24 * time.Hour
+	}
+
+	// Two-factor policy defaults
+	if cfg.TwoFactor.GracePeriod == 0 {
+		cfg.TwoFactor.GracePeriod = 7 * 24 * time.Hour
+	}
+}
+
+// loadEnvFile loads the appropriate .env file based on environment
+func loadEnvFile(environment string) error {
+	// Determine .env file based on environment
+	var envFile string
+	if environment == "local" {
+		envFile = ".env.local"
+	} else {
+		envFile = ".env"
+	}
+
+	// Try to find .env file in various locations
+	envPaths := []string{
+		envFile,                    // current directory
+		"../" + envFile,            // parent directory
+		"../../" + envFile,         // grandparent directory
+		"../../../" + envFile,      // great-grandparent (for nested service structure)
+	}
+
+	var envPath string
+	for _, path := range envPaths {
+		if _, err := os.Stat(path); err == nil {
+			envPath = path
+			break
 		}
 	}
 
@@ -526,8 +1840,82 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("database user is required")
 	}
 
-	if cfg.JWT.AccessSecret == "" {
-		return fmt.Errorf("JWT access secret is required")
+	switch cfg.Database.PendingMigrations {
+	case PendingMigrationsRefuse, PendingMigrationsWarn:
+	default:
+		return fmt.Errorf("database.pending_migrations must be refuse or warn: %s", cfg.Database.PendingMigrations)
+	}
+
+	switch cfg.JWT.Algorithm {
+	case JWTAlgorithmHS256:
+		if cfg.JWT.AccessSecret == "" {
+			return fmt.Errorf("JWT access secret is required")
+		}
+	case JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+		if err := validateJWTKeys(cfg.JWT); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("jwt.algorithm must be HS256, RS256 or EdDSA: %s", cfg.JWT.Algorithm)
+	}
+	if cfg.JWT.RefreshSecret == "" {
+		return fmt.Errorf("JWT refresh secret is required")
+	}
+
+	switch cfg.JWT.RefreshMode {
+	case RefreshModeSliding, RefreshModeAbsolute:
+	default:
+		return fmt.Errorf("invalid JWT refresh mode: %s", cfg.JWT.RefreshMode)
+	}
+
+	if maxLifetime, err := time.ParseDuration(cfg.JWT.RefreshMaxLifetime); err != nil || maxLifetime <= 0 {
+		return fmt.Errorf("JWT refresh max lifetime must be a positive duration: %s", cfg.JWT.RefreshMaxLifetime)
+	}
+
+	if cfg.JWT.RememberMeExpiry != "" {
+		rememberMe, err := time.ParseDuration(cfg.JWT.RememberMeExpiry)
+		if err != nil {
+			return fmt.Errorf("JWT remember me expiry must be a duration: %s", cfg.JWT.RememberMeExpiry)
+		}
+		if refreshExpiry, err := time.ParseDuration(cfg.JWT.RefreshExpiry); err == nil && rememberMe <= refreshExpiry {
+			return fmt.Errorf("JWT remember me expiry must be longer than refresh_expiry (%s)", cfg.JWT.RefreshExpiry)
+		}
+	}
+
+	for _, name := range cfg.JWT.CustomClaims.Allowed {
+		if IsReservedClaim(name) {
+			return fmt.Errorf("custom claim %q is reserved and can't be allowed", name)
+		}
+	}
+	if cfg.JWT.CustomClaims.MaxBytes < 0 {
+		return fmt.Errorf("custom claims max_bytes must not be negative")
+	}
+
+	switch cfg.JWT.RefreshCookie.SameSite {
+	case SameSiteStrict, SameSiteLax, SameSiteNone:
+	default:
+		return fmt.Errorf("invalid jwt.refresh_cookie same_site: %s", cfg.JWT.RefreshCookie.SameSite)
+	}
+	if cfg.JWT.RefreshCookie.SameSite == SameSiteNone && cfg.JWT.RefreshCookie.AllowInsecure {
+		return fmt.Errorf("jwt.refresh_cookie same_site = \"none\" requires secure cookies; unset allow_insecure")
+	}
+	if !strings.HasPrefix(cfg.JWT.RefreshCookie.Path, "/") {
+		return fmt.Errorf("jwt.refresh_cookie.path must start with /")
+	}
+	for _, origin := range cfg.JWT.RefreshCookie.AllowedOrigins {
+		if !IsWebOrigin(origin) {
+			return fmt.Errorf("jwt.refresh_cookie.allowed_origins: %q is not an origin like https://app.example.com", origin)
+		}
+	}
+
+	switch cfg.RateLimiting.Mode {
+	case RateLimitingGateway:
+	case RateLimitingService:
+		if len(cfg.RateLimiting.Routers) == 0 {
+			return fmt.Errorf("rate_limiting.routers must name the gateway routers to this service in service mode")
+		}
+	default:
+		return fmt.Errorf("rate_limiting.mode must be gateway or service: %s", cfg.RateLimiting.Mode)
 	}
 
 	// Validate security settings
@@ -538,7 +1926,599 @@ func validate(cfg *Config) error {
 	if cfg.Security.PasswordMinLength < 6 {
 		return fmt.Errorf("password minimum length must be at least 6")
 	}
+	if cfg.Security.PasswordMinLength > 72 {
+		return fmt.Errorf("password minimum length must be at most 72, the most bcrypt hashes")
+	}
+
+	switch cfg.Security.PasswordHash {
+	case PasswordHashBcrypt, PasswordHashArgon2id:
+	default:
+		return fmt.Errorf("security.password_hash must be bcrypt or argon2id: %s", cfg.Security.PasswordHash)
+	}
+	if cfg.Security.Argon2Iterations < 1 || cfg.Security.Argon2Parallelism < 1 || cfg.Security.Argon2Parallelism > 255 {
+		return fmt.Errorf("security.argon2_iterations must be at least 1 and argon2_parallelism between 1 and 255")
+	}
+	if cfg.Security.Argon2Memory < 8*cfg.Security.Argon2Parallelism {
+		return fmt.Errorf("security.argon2_memory must be at least 8 KiB per argon2_parallelism")
+	}
+
+	switch cfg.Security.TokenBinding {
+	case TokenBindingNone, TokenBindingUserAgent, TokenBindingIP, TokenBindingStrict:
+	default:
+		return fmt.Errorf("invalid token binding mode: %s", cfg.Security.TokenBinding)
+	}
+
+	if cfg.Security.MaxLoginAttempts < 1 || cfg.Security.LockoutDuration < 0 {
+		return fmt.Errorf("max login attempts must be at least 1 and lockout duration must not be negative")
+	}
+
+	if err := validateSecurityPolicies(cfg); err != nil {
+		return err
+	}
+
+	if err := validateForwardAuth(cfg); err != nil {
+		return err
+	}
+
+	switch cfg.Privacy.IPStorage {
+	case IPStorageFull, IPStorageTruncate:
+	case IPStorageHMAC:
+		if len(cfg.Privacy.IPHashKey) < minIPHashKeyLength {
+			return fmt.Errorf("hmac IP storage requires a key of at least %d bytes in %s or privacy.ip_hash_key_file", minIPHashKeyLength, ipHashKeyEnv)
+		}
+	default:
+		return fmt.Errorf("invalid IP storage mode: %s", cfg.Privacy.IPStorage)
+	}
+
+	switch cfg.Registration.Mode {
+	case RegistrationOpen, RegistrationApproval:
+	default:
+		return fmt.Errorf("invalid registration mode: %s", cfg.Registration.Mode)
+	}
+	if cfg.EmailDomains.MaxTypoDistance < 0 || cfg.EmailDomains.MaxTypoDistance > 2 {
+		return fmt.Errorf("email_domains.max_typo_distance must be between 0 and 2")
+	}
+	if cfg.EmailDomains.SyncInterval < 0 {
+		return fmt.Errorf("email_domains.sync_interval must not be negative")
+	}
 
+	switch cfg.BreachedPasswords.Mode {
+	case BreachedPasswordsOff, BreachedPasswordsWarn, BreachedPasswordsBlock:
+	default:
+		return fmt.Errorf("breached_passwords.mode must be off, warn or block: %s", cfg.BreachedPasswords.Mode)
+	}
+	if cfg.BreachedPasswords.Timeout < 0 || cfg.BreachedPasswords.CacheSize < 0 || cfg.BreachedPasswords.CacheTTL < 0 {
+		return fmt.Errorf("breached_passwords timeout, cache_size and cache_ttl must not be negative")
+	}
+
+	if err := validateCaptcha(cfg.Captcha); err != nil {
+		return err
+	}
+	if err := validateGeoIP(cfg.GeoIP); err != nil {
+		return err
+	}
+	if len(cfg.GeoIP.ChallengeCountries) > 0 && cfg.Captcha.Provider == CaptchaOff {
+		return fmt.Errorf("geoip.challenge_countries needs a captcha.provider")
+	}
+	if cfg.AuditLog.Key != "" && len(cfg.AuditLog.Key) < minAuditLogKeyLength {
+		return fmt.Errorf("the audit log key in %s or audit_log.key_file must be at least %d bytes", auditLogKeyEnv, minAuditLogKeyLength)
+	}
+
+	if cfg.RoleGrants.MaxDuration < 0 || cfg.RoleGrants.ExpiryInterval < 0 {
+		return fmt.Errorf("role grant max_duration and expiry_interval must be positive")
+	}
+
+	if cfg.Maintenance.SyncInterval < 0 {
+		return fmt.Errorf("maintenance.sync_interval must not be negative")
+	}
+
+	if replication := cfg.SessionReplication; replication.Enabled {
+		if replication.Region == "" || replication.RedisURL == "" {
+			return fmt.Errorf("session_replication needs region and redis_url when enabled")
+		}
+		if replication.MaxLen < 0 || replication.BatchSize < 0 || replication.BlockTimeout < 0 || replication.MaxLag < 0 {
+			return fmt.Errorf("session_replication max_len, batch_size, block_timeout and max_lag must not be negative")
+		}
+		if refreshExpiry, err := time.ParseDuration(cfg.JWT.RefreshExpiry); err == nil && replication.RevocationTTL < refreshExpiry {
+			return fmt.Errorf("session_replication.revocation_ttl must be at least jwt.refresh_expiry (%s)", cfg.JWT.RefreshExpiry)
+		}
+		if rememberMe, err := time.ParseDuration(cfg.JWT.RememberMeExpiry); err == nil && replication.RevocationTTL < rememberMe {
+			return fmt.Errorf("session_replication.revocation_ttl must be at least jwt.remember_me_expiry (%s)", cfg.JWT.RememberMeExpiry)
+		}
+	}
+
+	if snapshot := cfg.RevocationSnapshot; snapshot.Enabled {
+		if snapshot.RefreshInterval < time.Second {
+			return fmt.Errorf("revocation_snapshot.refresh_interval must be at least 1s")
+		}
+		if snapshot.MaxAge <= snapshot.RefreshInterval {
+			return fmt.Errorf("revocation_snapshot.max_age must be longer than refresh_interval")
+		}
+		if refreshExpiry, err := time.ParseDuration(cfg.JWT.RefreshExpiry); err == nil && snapshot.Retention < refreshExpiry {
+			return fmt.Errorf("revocation_snapshot.retention must be at least jwt.refresh_expiry (%s)", cfg.JWT.RefreshExpiry)
+		}
+		if rememberMe, err := time.ParseDuration(cfg.JWT.RememberMeExpiry); err == nil && snapshot.Retention < rememberMe {
+			return fmt.Errorf("revocation_snapshot.retention must be at least jwt.remember_me_expiry (%s)", cfg.JWT.RememberMeExpiry)
+		}
+	}
+
+	if cfg.Logging.MaxOverrideDuration < 0 || cfg.Logging.SignalDebugDuration < 0 || cfg.Logging.SyncInterval < 0 {
+		return fmt.Errorf("logging max_override_duration, signal_debug_duration and sync_interval must not be negative")
+	}
+
+	switch cfg.NotificationRetention.Mode {
+	case NotificationRetentionArchive, NotificationRetentionDelete:
+	default:
+		return fmt.Errorf("invalid notification retention mode: %s", cfg.NotificationRetention.Mode)
+	}
+	if cfg.NotificationRetention.MaxAge < 0 || cfg.NotificationRetention.Interval < 0 || cfg.NotificationRetention.BatchSize < 0 {
+		return fmt.Errorf("notification_retention max_age, interval and batch_size must be positive")
+	}
+
+	switch cfg.DeletedUserRetention.Mode {
+	case DeletedUserRetentionAnonymize, DeletedUserRetentionDelete:
+	default:
+		return fmt.Errorf("invalid deleted user retention mode: %s", cfg.DeletedUserRetention.Mode)
+	}
+	if cfg.DeletedUserRetention.RetentionPeriod < 0 || cfg.DeletedUserRetention.Interval < 0 || cfg.DeletedUserRetention.BatchSize < 0 {
+		return fmt.Errorf("deleted_user_retention retention_period, interval and batch_size must be positive")
+	}
+
+	if archive := cfg.ActivityArchive; archive.Enabled {
+		if archive.MaxAge <= 0 || archive.Interval <= 0 || archive.BatchSize <= 0 {
+			return fmt.Errorf("activity_archive max_age, interval and batch_size must be positive")
+		}
+		switch storage := archive.Storage; storage.Backend {
+		case ObjectStorageFilesystem:
+			if storage.Directory == "" {
+				return fmt.Errorf("activity_archive.storage.directory is required for the filesystem backend")
+			}
+		case ObjectStorageS3:
+			if storage.Endpoint == "" || storage.Bucket == "" || storage.AccessKeyID == "" || storage.SecretAccessKey == "" {
+				return fmt.Errorf("activity_archive.storage needs endpoint, bucket, access_key_id and the %s environment variable for the s3 backend",
+					objectStorageSecretEnv)
+			}
+		default:
+			return fmt.Errorf("activity_archive.storage.backend must be filesystem or s3: %s", storage.Backend)
+		}
+	}
+
+	switch cfg.WebAuthn.UserVerification {
+	case UserVerificationRequired, UserVerificationPreferred, UserVerificationDiscouraged:
+	default:
+		return fmt.Errorf("invalid webauthn user_verification: %s", cfg.WebAuthn.UserVerification)
+	}
+	if cfg.WebAuthn.RPID != "" && len(cfg.WebAuthn.Origins) == 0 {
+		return fmt.Errorf("webauthn.origins must list the origins allowed to use passkeys for %s", cfg.WebAuthn.RPID)
+	}
+	if cfg.WebAuthn.ChallengeTTL < 0 {
+		return fmt.Errorf("webauthn.challenge_ttl must be positive")
+	}
+
+	if push := cfg.WebPush; push.Enabled {
+		if !strings.HasPrefix(push.Subject, "mailto:") && !strings.HasPrefix(push.Subject, "https://") {
+			return fmt.Errorf("web_push.subject must be a mailto: or https: contact for push services")
+		}
+		if push.VAPIDPrivateKey == "" {
+			return fmt.Errorf("web_push needs the %s environment variable when enabled", webPushKeyEnv)
+		}
+		if _, err := push.ParseVAPIDKey(); err != nil {
+			return fmt.Errorf("web_push VAPID key must be a base64url P-256 private key: %v", err)
+		}
+		if push.MaxPerUser < 0 || push.TTL < 0 || push.Timeout < 0 {
+			return fmt.Errorf("web_push max_subscriptions_per_user, ttl and timeout must not be negative")
+		}
+	}
+
+	for name, provider := range map[string]OAuth2Provider{"google": cfg.OAuth2.Google, "github": cfg.OAuth2.GitHub, "facebook": cfg.OAuth2.Facebook} {
+		if provider.Enabled && (provider.ClientID == "" || provider.ClientSecret == "" || provider.RedirectURL == "") {
+			return fmt.Errorf("oauth2.%s needs client_id, redirect_url and the %s environment variable when enabled",
+				name, fmt.Sprintf(oauth2SecretEnv, strings.ToUpper(name)))
+		}
+	}
+	if apple := cfg.OAuth2.Apple; apple.Enabled {
+		if apple.ClientID == "" || apple.TeamID == "" || apple.KeyID == "" || apple.RedirectURL == "" {
+			return fmt.Errorf("oauth2.apple needs client_id, team_id, key_id and redirect_url when enabled")
+		}
+		if apple.PrivateKey == "" {
+			return fmt.Errorf("oauth2.apple needs the %s environment variable or private_key_file when enabled", appleKeyEnv)
+		}
+		if _, err := apple.ParsePrivateKey(); err != nil {
+			return fmt.Errorf("oauth2.apple private key must be the PKCS#8 P-256 key from Apple: %v", err)
+		}
+	}
+	for name, provider := range cfg.OAuth2.OIDC {
+		if !oidcProviderName.MatchString(name) || reservedOAuthNames[name] {
+			return fmt.Errorf("oauth2.oidc.%s: names must be lowercase letters, digits and dashes, and not a built-in provider", name)
+		}
+		if !provider.Enabled {
+			continue
+		}
+		if provider.ClientID == "" || provider.ClientSecret == "" || provider.RedirectURL == "" {
+			return fmt.Errorf("oauth2.oidc.%s needs client_id, redirect_url and the %s environment variable when enabled",
+				name, oidcSecretEnvFor(name))
+		}
+		if u, err := url.Parse(provider.Issuer); err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("oauth2.oidc.%s.issuer must be an https URL: %s", name, provider.Issuer)
+		}
+		if !slices.Contains(provider.Scopes, "openid") {
+			return fmt.Errorf("oauth2.oidc.%s.scopes must include openid", name)
+		}
+	}
+	if cfg.OAuth2.FrontendRedirectURL != "" {
+		if u, err := url.Parse(cfg.OAuth2.FrontendRedirectURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("oauth2.frontend_redirect_url must be an absolute URL: %s", cfg.OAuth2.FrontendRedirectURL)
+		}
+	}
+	if cfg.OAuth2.CodeTTL < 0 {
+		return fmt.Errorf("oauth2.code_ttl must be positive")
+	}
+	if cfg.OAuth2.StateTTL < 0 {
+		return fmt.Errorf("oauth2.state_ttl must be positive")
+	}
+
+	if len(cfg.AccessTokens.Prefix) > 12 {
+		return fmt.Errorf("personal_access_tokens.prefix must be at most 12 characters")
+	}
+	if strings.HasPrefix(cfg.AccessTokens.Prefix, cfg.Honeypot.KeyPrefix) || strings.HasPrefix(cfg.Honeypot.KeyPrefix, cfg.AccessTokens.Prefix) {
+		return fmt.Errorf("personal_access_tokens.prefix and honeypot.key_prefix must not overlap")
+	}
+	if cfg.AccessTokens.MaxPerUser < 0 || cfg.AccessTokens.DefaultLifetime < 0 || cfg.AccessTokens.MaxLifetime < 0 {
+		return fmt.Errorf("personal_access_tokens max_per_user, default_lifetime and max_lifetime must not be negative")
+	}
+	if cfg.AccessTokens.MaxLifetime > 0 && cfg.AccessTokens.DefaultLifetime > cfg.AccessTokens.MaxLifetime {
+		return fmt.Errorf("personal_access_tokens.default_lifetime must not exceed max_lifetime")
+	}
+
+	if err := validateClientCredentials(cfg.ClientCredentials); err != nil {
+		return err
+	}
+
+	if err := validateDeprecations(cfg.Deprecations); err != nil {
+		return err
+	}
+
+	if cfg.DataEncryption.Enabled && len(cfg.DataEncryption.MasterKey) != dataMasterKeyLength {
+		return fmt.Errorf("data_encryption requires a base64 key of %d bytes in %s or data_encryption.master_key_file", dataMasterKeyLength, dataMasterKeyEnv)
+	}
+	if cfg.DataEncryption.KeyCacheTTL < 0 {
+		return fmt.Errorf("data_encryption.key_cache_ttl must not be negative")
+	}
+
+	if cfg.BulkExport.DefaultPageSize < 1 || cfg.BulkExport.DefaultPageSize > cfg.BulkExport.MaxPageSize {
+		return fmt.Errorf("bulk_export.default_page_size must be between 1 and max_page_size")
+	}
+	if cfg.BulkExport.RequestsPerMinute < 0 || cfg.BulkExport.Burst < 0 {
+		return fmt.Errorf("bulk_export requests_per_minute and burst must not be negative")
+	}
+	if cfg.LoginAlerts.Lookback < 0 {
+		return fmt.Errorf("login_alerts.lookback must not be negative")
+	}
+
+	if cfg.Honeypot.BlockDuration < 0 {
+		return fmt.Errorf("honeypot.block_duration must not be negative")
+	}
+	if len(cfg.Honeypot.KeyPrefix) > 12 {
+		return fmt.Errorf("honeypot.key_prefix must be at most 12 characters")
+	}
+
+	if cfg.TLSFingerprint.BindRefreshTokens && cfg.TLSFingerprint.JA3Header == "" && cfg.TLSFingerprint.JA4Header == "" {
+		return fmt.Errorf("tls_fingerprint.bind_refresh_tokens requires ja3_header or ja4_header")
+	}
+
+	if cfg.CacheWarming.Timeout < 0 || cfg.CacheWarming.TTL < 0 {
+		return fmt.Errorf("cache warming timeout and ttl must not be negative")
+	}
+
+	if cfg.Telemetry.SampleRate <= 0 || cfg.Telemetry.SampleRate > 1 {
+		return fmt.Errorf("telemetry sample rate must be greater than 0 and at most 1")
+	}
+
+	for _, role := range cfg.TwoFactor.RequiredRoles {
+		switch role {
+		case "user", "admin", "moderator":
+		default:
+			return fmt.Errorf("invalid two-factor required role: %s", role)
+		}
+	}
+	if cfg.TwoFactor.GracePeriod < 0 {
+		return fmt.Errorf("two-factor grace period must not be negative")
+	}
+
+	return nil
+}
+
+// validateSecurityPolicies rejects tenant and client overrides that are malformed or looser than the global settings
+// validateJWTKeys checks the asymmetric key set: unique kids, keys that parse for their algorithm and
+// a signing key among them
+func validateJWTKeys(cfg JWTConfig) error {
+	seen := make(map[string]bool, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		if !jwtKeyID.MatchString(key.ID) {
+			return fmt.Errorf("jwt.keys kid must be lowercase letters, digits and dashes: %q", key.ID)
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("jwt.keys kid %s is listed twice", key.ID)
+		}
+		seen[key.ID] = true
+		if key.PrivateKey == "" {
+			return fmt.Errorf("jwt.keys %s needs %s or private_key_file", key.ID, jwtKeyEnvFor(key.ID))
+		}
+		if _, err := key.ParsePrivateKey(); err != nil {
+			return fmt.Errorf("invalid jwt.keys %s private key: %w", key.ID, err)
+		}
+	}
+	if !seen[cfg.SigningKeyID] {
+		return fmt.Errorf("jwt.signing_key_id must be the kid of one of jwt.keys when jwt.algorithm is %s", cfg.Algorithm)
+	}
+	return nil
+}
+
+func validateSecurityPolicies(cfg *Config) error {
+	names := make(map[string]bool, len(cfg.SecurityPolicies))
+	for _, policy := range cfg.SecurityPolicies {
+		if policy.Name == "" {
+			return fmt.Errorf("security policy name is required")
+		}
+		if names[policy.Name] {
+			return fmt.Errorf("duplicate security policy: %s", policy.Name)
+		}
+		names[policy.Name] = true
+
+		if len(policy.EmailDomains) == 0 && len(policy.ClientIDs) == 0 {
+			return fmt.Errorf("security policy %s must list email_domains or client_ids", policy.Name)
+		}
+		if err := validatePolicyExpiry(policy.AccessExpiry, cfg.JWT.AccessExpiry); err != nil {
+			return fmt.Errorf("security policy %s: access_expiry %w", policy.Name, err)
+		}
+		if err := validatePolicyExpiry(policy.RefreshExpiry, cfg.JWT.RefreshExpiry); err != nil {
+			return fmt.Errorf("security policy %s: refresh_expiry %w", policy.Name, err)
+		}
+		if policy.MaxSessionsPerUser < 0 || policy.MaxSessionsPerUser > cfg.Security.MaxSessionsPerUser {
+			return fmt.Errorf("security policy %s: max_sessions_per_user must be between 0 and %d", policy.Name, cfg.Security.MaxSessionsPerUser)
+		}
+		if policy.MaxLoginAttempts < 0 || policy.MaxLoginAttempts > cfg.Security.MaxLoginAttempts {
+			return fmt.Errorf("security policy %s: max_login_attempts must be between 0 and %d", policy.Name, cfg.Security.MaxLoginAttempts)
+		}
+		if policy.LockoutDuration != 0 && policy.LockoutDuration < cfg.Security.LockoutDuration {
+			return fmt.Errorf("security policy %s: lockout_duration must be at least %s", policy.Name, cfg.Security.LockoutDuration)
+		}
+		if len(policy.Origins) > 0 && len(policy.ClientIDs) == 0 {
+			return fmt.Errorf("security policy %s: origins belong to registered clients and need client_ids", policy.Name)
+		}
+		for _, origin := range policy.Origins {
+			if !IsWebOrigin(origin) {
+				return fmt.Errorf("security policy %s: %q is not an origin like https://app.example.com", policy.Name, origin)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDeprecations checks that each endpoint is listed once with a route path, a deprecation date
+// before its sunset and absolute links
+func validateDeprecations(cfg DeprecationsConfig) error {
+	if cfg.Retention < 0 {
+		return fmt.Errorf("deprecations.retention must not be negative")
+	}
+	seen := make(map[string]bool, len(cfg.Endpoints))
+	for _, endpoint := range cfg.Endpoints {
+		name := strings.TrimSpace(endpoint.Method + " " + endpoint.Path)
+		if !strings.HasPrefix(endpoint.Path, "/") {
+			return fmt.Errorf("deprecated endpoint %s: path must be a route path starting with /", name)
+		}
+		method := strings.ToUpper(endpoint.Method)
+		switch method {
+		case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("deprecated endpoint %s: unknown method %s", name, endpoint.Method)
+		}
+		if seen[method+" "+endpoint.Path] {
+			return fmt.Errorf("deprecated endpoint %s is listed twice", name)
+		}
+		seen[method+" "+endpoint.Path] = true
+
+		if endpoint.Deprecated.IsZero() {
+			return fmt.Errorf("deprecated endpoint %s needs a deprecated date", name)
+		}
+		if !endpoint.Sunset.IsZero() && !endpoint.Sunset.After(endpoint.Deprecated) {
+			return fmt.Errorf("deprecated endpoint %s: sunset must be after the deprecated date", name)
+		}
+		if endpoint.Successor != "" && !strings.HasPrefix(endpoint.Successor, "/") && !isHTTPURL(endpoint.Successor) {
+			return fmt.Errorf("deprecated endpoint %s: successor must be a path or an http(s) URL", name)
+		}
+		if endpoint.Link != "" && !isHTTPURL(endpoint.Link) {
+			return fmt.Errorf("deprecated endpoint %s: link must be an http(s) URL", name)
+		}
+	}
+	return nil
+}
+
+// validateCaptcha checks the provider, its secret and the thresholds
+func validateCaptcha(cfg CaptchaConfig) error {
+	switch cfg.Provider {
+	case CaptchaOff:
+		return nil
+	case CaptchaReCAPTCHA, CaptchaHCaptcha, CaptchaTurnstile:
+	default:
+		return fmt.Errorf("captcha.provider must be off, recaptcha, hcaptcha or turnstile: %s", cfg.Provider)
+	}
+	if cfg.Secret == "" {
+		return fmt.Errorf("captcha.provider %s requires the secret key in %s", cfg.Provider, captchaSecretEnv)
+	}
+	if cfg.VerifyURL != "" && !isHTTPURL(cfg.VerifyURL) {
+		return fmt.Errorf("captcha.verify_url must be an http(s) URL")
+	}
+	if cfg.MinScore < 0 || cfg.MinScore > 1 {
+		return fmt.Errorf("captcha.min_score must be between 0 and 1")
+	}
+	if cfg.IPThreshold < 0 || cfg.AccountThreshold < 0 {
+		return fmt.Errorf("captcha ip_threshold and account_threshold must not be negative")
+	}
+	if cfg.IPThreshold == 0 && cfg.AccountThreshold == 0 {
+		return fmt.Errorf("captcha.provider %s needs ip_threshold or account_threshold to challenge anyone", cfg.Provider)
+	}
+	if cfg.Window <= 0 || cfg.Timeout <= 0 {
+		return fmt.Errorf("captcha window and timeout must be positive")
+	}
+	return nil
+}
+
+// validateGeoIP checks the provider, what it needs and the country codes of the rules
+func validateGeoIP(cfg GeoIPConfig) error {
+	switch cfg.Provider {
+	case GeoIPOff:
+		if len(cfg.BlockedCountries) > 0 || len(cfg.ChallengeCountries) > 0 {
+			return fmt.Errorf("geoip country rules need a geoip.provider")
+		}
+		return nil
+	case GeoIPMaxMindDB:
+		if cfg.DatabaseFile == "" {
+			return fmt.Errorf("geoip.provider %s requires database_file", cfg.Provider)
+		}
+	case GeoIPMaxMindWeb:
+		if cfg.AccountID == "" || cfg.LicenseKey == "" {
+			return fmt.Errorf("geoip.provider %s requires account_id and the license key in %s", cfg.Provider, geoIPLicenseKeyEnv)
+		}
+		if cfg.URL != "" && !isHTTPURL(cfg.URL) {
+			return fmt.Errorf("geoip.url must be an http(s) URL")
+		}
+	default:
+		return fmt.Errorf("geoip.provider must be off, maxmind_db or maxmind_web: %s", cfg.Provider)
+	}
+	if cfg.Timeout <= 0 || cfg.CacheTTL < 0 {
+		return fmt.Errorf("geoip.timeout must be positive and cache_ttl not negative")
+	}
+	for _, code := range append(slices.Clone(cfg.BlockedCountries), cfg.ChallengeCountries...) {
+		if !countryCode.MatchString(code) {
+			return fmt.Errorf("geoip country rules take ISO 3166-1 alpha-2 codes such as DE: %q", code)
+		}
+	}
+	return nil
+}
+
+// isHTTPURL reports whether raw is an absolute http or https URL
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// validateClientCredentials checks the token lifetime and that scopes are unique, space-free names
+func validateClientCredentials(cfg ClientCredentialsConfig) error {
+	if cfg.TokenLifetime <= 0 || cfg.TokenLifetime > maxClientTokenLifetime {
+		return fmt.Errorf("client_credentials.token_lifetime must be positive and at most %s", maxClientTokenLifetime)
+	}
+	if cfg.Enabled && len(cfg.Scopes) == 0 {
+		return fmt.Errorf("client_credentials.scopes must list at least one scope when enabled")
+	}
+	seen := make(map[string]bool, len(cfg.Scopes))
+	for _, scope := range cfg.Scopes {
+		if !oauthScope.MatchString(scope) {
+			return fmt.Errorf("client_credentials.scopes: %q must be lowercase letters, digits and _ . : -", scope)
+		}
+		if seen[scope] {
+			return fmt.Errorf("client_credentials.scopes: %s is listed twice", scope)
+		}
+		seen[scope] = true
+	}
+	return nil
+}
+
+// validateForwardAuth checks that mappings only emit allowed claims, under unreserved header names, and
+// select registered clients
+func validateForwardAuth(cfg *Config) error {
+	known := make(map[string]bool)
+	for _, claim := range ForwardAuthClaims {
+		known[claim] = true
+	}
+	for _, claim := range cfg.JWT.CustomClaims.Allowed {
+		known[claim] = true
+	}
+	allowed := make(map[string]bool, len(cfg.ForwardAuth.AllowedClaims))
+	for _, claim := range cfg.ForwardAuth.AllowedClaims {
+		if !known[claim] {
+			return fmt.Errorf("forward_auth.allowed_claims: %s is not a token claim or allowed custom claim", claim)
+		}
+		allowed[claim] = true
+	}
+
+	registered := make(map[string]bool)
+	for _, policy := range cfg.SecurityPolicies {
+		for _, clientID := range policy.ClientIDs {
+			registered[clientID] = true
+		}
+	}
+
+	audiences := make(map[string]bool)
+	clients := make(map[string]bool)
+	for i, mapping := range cfg.ForwardAuth.Mappings {
+		name := mapping.Audience
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if mapping.Audience == "" && len(mapping.ClientIDs) == 0 {
+			return fmt.Errorf("forward_auth mapping %s needs an audience or client_ids", name)
+		}
+		if mapping.Audience != "" {
+			if audiences[mapping.Audience] {
+				return fmt.Errorf("forward_auth audience %s is mapped twice", mapping.Audience)
+			}
+			audiences[mapping.Audience] = true
+		}
+		for _, clientID := range mapping.ClientIDs {
+			// Client-credentials clients are registered in the database, so only their form is checked
+			if !registered[clientID] && !(cfg.ClientCredentials.Enabled && OAuthClientID.MatchString(clientID)) {
+				return fmt.Errorf("forward_auth mapping %s: client %s is not in any security policy's client_ids", name, clientID)
+			}
+			if clients[clientID] {
+				return fmt.Errorf("forward_auth client %s is mapped twice", clientID)
+			}
+			clients[clientID] = true
+		}
+
+		if len(mapping.Headers) == 0 {
+			return fmt.Errorf("forward_auth mapping %s has no headers", name)
+		}
+		headers := make(map[string]bool, len(mapping.Headers))
+		for header, claim := range mapping.Headers {
+			canonical := http.CanonicalHeaderKey(header)
+			if !forwardAuthHeader.MatchString(header) || canonical == "X-Auth-Status" || strings.HasPrefix(canonical, "X-Forwarded-") {
+				return fmt.Errorf("forward_auth mapping %s: %q must be an X- header other than X-Auth-Status and X-Forwarded-*", name, header)
+			}
+			if headers[canonical] {
+				return fmt.Errorf("forward_auth mapping %s: header %s is listed twice", name, canonical)
+			}
+			headers[canonical] = true
+			if !allowed[claim] {
+				return fmt.Errorf("forward_auth mapping %s: claim %s is not in forward_auth.allowed_claims", name, claim)
+			}
+		}
+	}
+	return nil
+}
+
+// IsWebOrigin reports whether s is a browser origin: an http(s) scheme and host, with an optional port
+// and nothing else, as sent in the Origin header
+func IsWebOrigin(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	return u.Path == "" && u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
+
+// validatePolicyExpiry checks that an overriding token expiry parses and doesn't exceed the global one
+func validatePolicyExpiry(override, global string) error {
+	if override == "" {
+		return nil
+	}
+	expiry, err := time.ParseDuration(override)
+	if err != nil || expiry <= 0 {
+		return fmt.Errorf("must be a positive duration")
+	}
+	if limit, err := time.ParseDuration(global); err == nil && expiry > limit {
+		return fmt.Errorf("must not exceed the global %s", global)
+	}
 	return nil
 }
 
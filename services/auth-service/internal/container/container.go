@@ -0,0 +1,942 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"auth-service/internal/cachewarm"
+	"auth-service/internal/captcha"
+	"auth-service/internal/config"
+	"auth-service/internal/database"
+	"auth-service/internal/datakeys"
+	"auth-service/internal/deprecation"
+	"auth-service/internal/discovery"
+	"auth-service/internal/emaildomains"
+	"auth-service/internal/export"
+	"auth-service/internal/geoip"
+	"auth-service/internal/handlers"
+	"auth-service/internal/instrumentation"
+	"auth-service/internal/logging"
+	"auth-service/internal/mail"
+	"auth-service/internal/maintenance"
+	"auth-service/internal/migrations"
+	"auth-service/internal/objectstore"
+	"auth-service/internal/privacy"
+	"auth-service/internal/pwnedpasswords"
+	"auth-service/internal/ratelimit"
+	"auth-service/internal/replication"
+	"auth-service/internal/repositories"
+	"auth-service/internal/services"
+	"auth-service/internal/status"
+	"auth-service/internal/telemetry"
+	"auth-service/internal/webpush"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"shared/cache"
+	sharedDB "shared/database"
+	"shared/events"
+	"shared/health"
+	sharedMiddleware "shared/middleware"
+)
+
+// slowCallThreshold is the latency above which instrumented calls are logged
+const slowCallThreshold = 500 * time.Millisecond
+
+// contextKey is the gin context key under which the container is stored for each request
+const contextKey = "container"
+
+// Container owns the auth service dependency graph
+// Every dependency can be supplied up front via an Option (e.g. a mock repository in tests);
+// anything left nil is built from configuration when New runs
+type Container struct {
+	Config *config.Config
+	DB     *gorm.DB
+	Redis  *redis.Client
+
+	// EventBus publishes system events (e.g. dependency health transitions) to other services
+	EventBus *events.EventBus
+
+	UserRepository         repositories.UserRepository
+	SessionRepository      repositories.SessionRepository
+	OneTimeTokenRepository repositories.OneTimeTokenRepository
+
+	// Mailer sends transactional email such as invitations
+	Mailer mail.Mailer
+	// WebPush pushes notifications to subscribed browsers; nil unless web_push is enabled
+	WebPush *webpush.Pusher
+
+	// ActivityStore holds archived user activities; nil unless activity_archive is enabled
+	ActivityStore objectstore.Store
+
+	// DataKeys seals Cache's user entries under per-user keys shredded with the account; nil unless
+	// data_encryption is enabled
+	DataKeys *datakeys.Keyring
+
+	// ClaimsEnrichers add deployment-specific claims to access tokens (see jwt.custom_claims)
+	ClaimsEnrichers []services.ClaimsEnricher
+
+	JWTService    services.JWTService
+	SigningKeys   *services.SigningKeys // Signs and verifies access tokens; published by JWKSHandler
+	AuthService   services.AuthService
+	OAuth2Service services.OAuth2Service
+
+	// ClientIPs finds the caller's address behind the trusted proxies of server.trusted_proxies
+	ClientIPs *sharedMiddleware.ClientIPResolver
+
+	// RateLimiter applies the gateway's rate limits when rate_limiting.mode is service, nil otherwise
+	RateLimiter *ratelimit.Limiter
+
+	// CaptchaVerifier checks CAPTCHA tokens with captcha.provider; Captcha decides who has to send one
+	// Both are nil when captcha.provider is off
+	CaptchaVerifier captcha.Verifier
+	Captcha         *captcha.Guard
+
+	// GeoIPResolver finds where client addresses are with geoip.provider; GeoIP locates logins and sessions
+	// with it and applies the country rules. Both are nil when geoip.provider is off
+	GeoIPResolver geoip.Resolver
+	GeoIP         *geoip.Locator
+
+	// Exporter pages through users, sessions and activities for GET /api/v1/admin/export/{resource} and
+	// ExportLimiter limits each admin's pages; both are nil when bulk_export is disabled
+	Exporter      *export.Exporter
+	ExportLimiter *ratelimit.Limiter
+
+	// Metrics collects service layer method metrics for the /metrics endpoint
+	Metrics *instrumentation.Metrics
+	// MigrationMetrics records migrations applied at startup; nil unless metrics are enabled and database.run_migrations is set
+	MigrationMetrics *migrations.Metrics
+	// SchemaStatus lists the embedded migrations the database hadn't applied at startup; nil without embedded migrations
+	SchemaStatus *migrations.SchemaStatus
+	// Observer receives every instrumented call (metrics, logging and tracing)
+	Observer instrumentation.Observer
+	// LoginFunnel records sampled login funnel analytics; nil when telemetry is disabled
+	LoginFunnel *telemetry.LoginFunnel
+	// Deprecations adds Deprecation and Sunset headers to the endpoints of [deprecations] and counts
+	// their callers; nil when no endpoint is deprecated
+	Deprecations *deprecation.Registry
+	// HoneypotAlerts counts uses of honeypot accounts and canary API keys for alerting
+	HoneypotAlerts *services.HoneypotAlerts
+
+	StatusPage *status.Page
+
+	// Maintenance is the read-only maintenance mode; start it with Maintenance.Start to follow admin changes
+	Maintenance *maintenance.Mode
+
+	// EmailDomains flags disposable and mistyped email domains at registration; start it with
+	// EmailDomains.Start to follow admin changes
+	EmailDomains *emaildomains.Checker
+
+	// BreachedPasswords screens new passwords against Have I Been Pwned unless breached_passwords.mode is off
+	BreachedPasswords *pwnedpasswords.Checker
+
+	// LogLevels is the runtime log level and debug targets; LogControl changes them on every replica
+	// through the admin endpoint; start it with LogControl.Start to follow admin changes
+	LogLevels  *logging.Levels
+	LogControl *logging.Control
+
+	// RoleGrantExpirer ends expired role grants; start it with RoleGrantExpirer.Start
+	RoleGrantExpirer *services.RoleGrantExpirer
+
+	// NotificationRetention summarizes and archives old notifications; start it with NotificationRetention.Start
+	NotificationRetention *services.NotificationRetention
+
+	// ActivityArchive moves old user activities to ActivityStore; nil unless activity_archive is enabled.
+	// Start it with ActivityArchive.Start
+	ActivityArchive *services.ActivityArchive
+
+	// DeletedUserRetention purges accounts soft-deleted past the retention period, archived activities in
+	// ActivityStore included; start it with DeletedUserRetention.Start
+	DeletedUserRetention *services.DeletedUserRetention
+
+	// Discovery caches provider JWKS and OIDC discovery documents; start it with Discovery.Start
+	Discovery *discovery.Fetcher
+
+	// SessionReplicator exchanges session changes with the other regions when session_replication is
+	// enabled, nil otherwise; start it with SessionReplicator.Start
+	SessionReplicator *replication.Replicator
+
+	// RefreshRevocations records revoked refresh tokens for the revocation snapshot; nil unless
+	// revocation_snapshot is enabled. RevocationSnapshots signs the list edges poll; start it with
+	// RevocationSnapshots.Start
+	RefreshRevocations  *repositories.RefreshRevocationLog
+	RevocationSnapshots *services.RevocationSnapshots
+
+	// Cache is the Redis cache shared by the services; CacheWarmer fills it at startup with CacheWarmer.Start
+	Cache       *cache.CacheManager
+	CacheWarmer *cachewarm.Warmer
+
+	AuthHandler         *handlers.AuthHandler
+	AdminHandler        *handlers.AdminHandler
+	StatusHandler       *handlers.StatusHandler
+	TelemetryHandler    *handlers.TelemetryHandler
+	JWKSHandler         *handlers.JWKSHandler
+	SchemaHandler       *handlers.SchemaHandler
+	MaintenanceHandler  *handlers.MaintenanceHandler
+	LoggingHandler      *handlers.LoggingHandler
+	RevocationHandler   *handlers.RevocationHandler
+	EmailDomainsHandler *handlers.EmailDomainsHandler
+	DeprecationHandler  *handlers.DeprecationHandler
+	ExportHandler       *handlers.ExportHandler
+
+	// migrationsFS holds the SQL migrations applied at startup when database.run_migrations is set
+	migrationsFS fs.FS
+
+	// StartupReport records how New initialized each dependency, for diagnostics
+	StartupReport *StartupReport
+
+	// closers release resources the container opened itself, in reverse order
+	closers []func() error
+}
+
+// Option overrides a dependency before the container builds the rest of the graph
+type Option func(*Container)
+
+// WithDB uses an existing database connection instead of connecting from configuration
+func WithDB(db *gorm.DB) Option {
+	return func(c *Container) { c.DB = db }
+}
+
+// WithRedis uses an existing Redis client instead of connecting from configuration
+func WithRedis(client *redis.Client) Option {
+	return func(c *Container) { c.Redis = client }
+}
+
+// WithEventBus publishes system events on an existing bus
+func WithEventBus(bus *events.EventBus) Option {
+	return func(c *Container) { c.EventBus = bus }
+}
+
+// WithMigrations supplies the migration files (usually embedded in the binary) applied at startup
+func WithMigrations(fsys fs.FS) Option {
+	return func(c *Container) { c.migrationsFS = fsys }
+}
+
+// WithUserRepository replaces the GORM-backed user repository
+func WithUserRepository(repo repositories.UserRepository) Option {
+	return func(c *Container) { c.UserRepository = repo }
+}
+
+// WithSessionRepository replaces the database/Redis-backed session repository
+func WithSessionRepository(repo repositories.SessionRepository) Option {
+	return func(c *Container) { c.SessionRepository = repo }
+}
+
+// WithJWTService replaces the default HS256 token service
+func WithJWTService(svc services.JWTService) Option {
+	return func(c *Container) { c.JWTService = svc }
+}
+
+// WithObserver replaces the default metrics, logging and tracing observers
+func WithObserver(observer instrumentation.Observer) Option {
+	return func(c *Container) { c.Observer = observer }
+}
+
+// WithClaimsEnricher registers an enricher for custom access token claims; may be given more than once
+func WithClaimsEnricher(enricher services.ClaimsEnricher) Option {
+	return func(c *Container) { c.ClaimsEnrichers = append(c.ClaimsEnrichers, enricher) }
+}
+
+// WithMailer replaces the transactional email sender (e.g. to capture messages in tests)
+func WithMailer(mailer mail.Mailer) Option {
+	return func(c *Container) { c.Mailer = mailer }
+}
+
+// WithWebPush replaces the Web Push sender (e.g. one whose HTTP client reaches a fake push service)
+func WithWebPush(pusher *webpush.Pusher) Option {
+	return func(c *Container) { c.WebPush = pusher }
+}
+
+// WithActivityStore replaces the object storage of archived activities (e.g. a directory in tests)
+func WithActivityStore(store objectstore.Store) Option {
+	return func(c *Container) { c.ActivityStore = store }
+}
+
+// WithDataKeys replaces the per-user data keyring (e.g. one with a fixed master key in tests)
+func WithDataKeys(keyring *datakeys.Keyring) Option {
+	return func(c *Container) { c.DataKeys = keyring }
+}
+
+// WithCaptchaVerifier replaces the siteverify client of captcha.provider (e.g. another provider, or one
+// accepting fixed tokens in tests)
+func WithCaptchaVerifier(verifier captcha.Verifier) Option {
+	return func(c *Container) { c.CaptchaVerifier = verifier }
+}
+
+// WithGeoIPResolver replaces the resolver of geoip.provider (e.g. another GeoIP source, or fixed
+// locations in tests); geoip's country rules still apply
+func WithGeoIPResolver(resolver geoip.Resolver) Option {
+	return func(c *Container) { c.GeoIPResolver = resolver }
+}
+
+// WithAuthService replaces the default authentication service
+func WithAuthService(svc services.AuthService) Option {
+	return func(c *Container) { c.AuthService = svc }
+}
+
+// WithOAuth2Service enables OAuth2 login with the given service
+func WithOAuth2Service(svc services.OAuth2Service) Option {
+	return func(c *Container) { c.OAuth2Service = svc }
+}
+
+// New builds the dependency graph in order: infrastructure, repositories, services, handlers
+// Each step is timed and the outcome logged as a StartupReport; a failed step stops startup with a
+// *StartupError. Infrastructure opened here is released by Close; injected infrastructure is left to the caller
+func New(ctx context.Context, cfg *config.Config, opts ...Option) (*Container, error) {
+	c := &Container{Config: cfg}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.LogLevels == nil {
+		c.LogLevels = logging.NewLevels(c.Config.Logging) // SQL logging follows runtime level changes
+	}
+
+	report, err := runStartup(ctx, cfg.Source, c.startupSteps())
+	c.StartupReport = report
+	report.Log()
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// startupSteps declares the order New initializes dependencies in
+// Database and Redis connections back off and retry on their own; of the rest only the schema check,
+// a read, is retried. Migrations are never retried since a half-applied one needs a person to look at it
+func (c *Container) startupSteps() []startupStep {
+	infallible := func(provide func()) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			provide()
+			return "", nil
+		}
+	}
+	return []startupStep{
+		{name: "database", run: c.provideDatabase},
+		{name: "migrations", run: c.applyMigrations},
+		{name: "schema check", retries: 2, run: c.checkSchema},
+		{name: "redis", run: c.provideRedis},
+		{name: "event bus", run: c.provideEventBus},
+		{name: "instrumentation", run: infallible(c.provideInstrumentation)},
+		{name: "discovery", run: infallible(c.provideDiscovery)},
+		{name: "cache", run: infallible(c.provideCache)},
+		{name: "maintenance", run: infallible(c.provideMaintenance)},
+		{name: "logging", run: infallible(c.provideLogging)},
+		{name: "revocation log", run: c.provideRevocationLog},
+		{name: "replication", run: c.provideReplication},
+		{name: "repositories", run: infallible(c.provideRepositories)},
+		{name: "data encryption", run: c.provideDataKeys},
+		{name: "signing keys", run: c.provideSigningKeys},
+		{name: "web push", run: c.provideWebPush},
+		{name: "object storage", run: c.provideObjectStorage},
+		{name: "captcha", run: c.provideCaptcha},
+		{name: "geoip", run: c.provideGeoIP},
+		{name: "bulk export", run: c.provideExporter},
+		{name: "services", run: infallible(c.provideServices)},
+		{name: "trusted proxies", run: c.provideClientIPs},
+		{name: "rate limiting", run: c.provideRateLimiter},
+		{name: "handlers", run: infallible(c.provideHandlers)},
+	}
+}
+
+// provideDatabase connects to PostgreSQL unless a connection was injected
+func (c *Container) provideDatabase(ctx context.Context) (string, error) {
+	if c.DB != nil {
+		return "injected", nil
+	}
+
+	dbConfig := sharedDB.ConnectionConfig{
+		Host:            c.Config.Database.Host,
+		Port:            c.Config.Database.Port,
+		Name:            c.Config.Database.Name,
+		User:            c.Config.Database.User,
+		Password:        c.Config.Database.Password,
+		SSLMode:         c.Config.Database.SSLMode,
+		MaxOpenConns:    c.Config.Database.MaxOpenConns,
+		MaxIdleConns:    c.Config.Database.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(c.Config.Database.ConnMaxLifetime) * time.Second,
+		Timezone:        "UTC",
+		Logger:          database.NewGormLogger(c.Config.Logging, c.Config.Database.SlowQueryThreshold, c.LogLevels),
+		ReadOnly:        c.Config.Maintenance.ReadOnly,
+	}
+	target := fmt.Sprintf("%s:%s/%s", dbConfig.Host, dbConfig.Port, dbConfig.Name)
+	db, err := sharedDB.ConnectWithRetry(ctx, dbConfig, sharedDB.DefaultRetryConfig())
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to database %s: %w", target, err)
+	}
+	c.DB = db
+	c.closers = append(c.closers, func() error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+
+	// Verify the migrated schema is reachable before anything depends on it
+	if err := database.Migrate(db); err != nil {
+		return "", fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return target, nil
+}
+
+// applyMigrations applies the embedded migrations when database.run_migrations is set
+func (c *Container) applyMigrations(ctx context.Context) (string, error) {
+	switch {
+	case !c.Config.Database.RunMigrations || c.migrationsFS == nil:
+		return "not enabled", nil
+	case c.Config.Maintenance.ReadOnly:
+		log.Println("⚠️  Skipping startup migrations: maintenance.read_only is set")
+		return "skipped, maintenance.read_only is set", nil
+	}
+
+	if c.MigrationMetrics == nil && c.Config.Metrics.Enabled {
+		c.MigrationMetrics = migrations.NewMetrics()
+	}
+	results, err := migrations.Apply(ctx, c.DB, c.migrationsFS, c.Config.Database.MigrationEnv, c.MigrationMetrics)
+	if err != nil {
+		return "", fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	log.Printf("✅ Database schema up to date (%d migrations applied at startup)", len(results))
+	return fmt.Sprintf("%d applied", len(results)), nil
+}
+
+// provideRedis connects to Redis unless a client was injected
+func (c *Container) provideRedis(ctx context.Context) (string, error) {
+	if c.Redis != nil {
+		return "injected", nil
+	}
+
+	// The URL may carry a password, so only the host is reported
+	target := c.Config.Redis.URL
+	if parsed, err := url.Parse(target); err == nil {
+		target = parsed.Host
+	}
+	client, err := database.ConnectRedis(ctx, c.Config.Redis)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", target, err)
+	}
+	c.Redis = client
+	c.closers = append(c.closers, client.Close)
+	return target, nil
+}
+
+// provideEventBus publishes system events over Redis unless a bus was injected
+func (c *Container) provideEventBus(context.Context) (string, error) {
+	if c.EventBus != nil {
+		return "injected", nil
+	}
+	c.EventBus = events.NewEventBus(c.Redis, "auth-service")
+	c.closers = append(c.closers, c.EventBus.Close)
+	return "", nil
+}
+
+// checkSchema applies database.pending_migrations when the database is behind the embedded migrations
+// During read-only maintenance migrations can't run, so the service starts and warns either way
+func (c *Container) checkSchema(ctx context.Context) (string, error) {
+	if c.SchemaStatus != nil {
+		return "injected", nil
+	}
+	if c.migrationsFS == nil {
+		return "no embedded migrations", nil
+	}
+	schema, err := migrations.CheckSchema(ctx, c.DB, c.migrationsFS, c.Config.Database.MigrationEnv)
+	if err != nil {
+		return "", fmt.Errorf("failed to check for pending migrations: %w", err)
+	}
+	c.SchemaStatus = schema
+	if schema.PendingCount() == 0 {
+		return "up to date", nil
+	}
+
+	if c.Config.Database.PendingMigrations == config.PendingMigrationsRefuse && !c.Config.Maintenance.ReadOnly {
+		return "", fmt.Errorf("database schema is behind this build (%s); run migrate up first or set database.pending_migrations = \"warn\"",
+			schema.Summary())
+	}
+	log.Printf("🚨 Database schema is behind this build (%s); readiness reports the migrations check as degraded",
+		schema.Summary())
+	return "behind: " + schema.Summary(), nil
+}
+
+// provideInstrumentation builds the observers that decorate repositories and services
+func (c *Container) provideInstrumentation() {
+	if c.Metrics == nil {
+		c.Metrics = instrumentation.NewMetrics()
+	}
+	if c.LoginFunnel == nil {
+		c.LoginFunnel = telemetry.NewLoginFunnel(c.Redis, c.Config.Telemetry)
+	}
+	if c.Deprecations == nil {
+		c.Deprecations = deprecation.NewRegistry(c.Redis, c.Config.Deprecations)
+	}
+	if c.Observer != nil {
+		return
+	}
+
+	observers := instrumentation.Observers{instrumentation.NewLogObserver(slowCallThreshold)}
+	if c.Config.Metrics.Enabled {
+		observers = append(observers, c.Metrics)
+	}
+	if c.Config.Tracing.Enabled {
+		observers = append(observers, instrumentation.NewTraceObserver(c.Config.Tracing.ServiceName, c.Config.Tracing.SampleRate))
+	}
+	if c.Config.Metrics.Enabled && c.Config.Tracing.Enabled {
+		// Latency buckets link to traces the tracer sampled, shown as exemplars in Grafana
+		c.Metrics.EnableExemplars(c.Config.Tracing.SampleRate)
+	}
+	c.Observer = observers
+}
+
+// provideDiscovery builds the background cache for provider key sets and discovery documents
+func (c *Container) provideDiscovery() {
+	if c.Discovery == nil {
+		c.Discovery = discovery.NewFetcher(nil, c.Config.Discovery)
+	}
+}
+
+// provideCache builds the Redis cache and registers the entries warmed at startup
+func (c *Container) provideCache() {
+	if c.Cache == nil {
+		c.Cache = cache.NewCacheManager(c.Redis, c.EventBus, cache.Config{DefaultTTL: c.Config.CacheWarming.TTL})
+	}
+	if c.CacheWarmer == nil {
+		c.CacheWarmer = cachewarm.NewWarmer(c.Cache, c.Config.CacheWarming)
+		c.CacheWarmer.Register(
+			cachewarm.RoleDefinitions(c.DB),
+			cachewarm.ClientRegistry(c.Config.SecurityPolicies),
+		)
+	}
+}
+
+// provideMaintenance builds the read-only maintenance mode shared by the middleware and admin endpoint,
+// and the email domain lists admins can replace the same way
+func (c *Container) provideMaintenance() {
+	if c.Maintenance == nil {
+		c.Maintenance = maintenance.NewMode(c.Redis, c.Config.Maintenance)
+	}
+	if c.EmailDomains == nil {
+		c.EmailDomains = emaildomains.NewChecker(c.Redis, c.Config.EmailDomains)
+	}
+}
+
+// provideLogging builds the admin control over the runtime log level
+func (c *Container) provideLogging() {
+	if c.LogControl == nil {
+		c.LogControl = logging.NewControl(c.Redis, c.LogLevels, c.Config.Logging)
+	}
+}
+
+// provideRevocationLog builds the log of revoked refresh tokens when revocation_snapshot is enabled
+func (c *Container) provideRevocationLog(context.Context) (string, error) {
+	if c.RefreshRevocations != nil {
+		return "injected", nil
+	}
+	if !c.Config.RevocationSnapshot.Enabled {
+		return "not enabled", nil
+	}
+	c.RefreshRevocations = repositories.NewRefreshRevocationLog(c.Redis, c.Config.RevocationSnapshot.Retention)
+	return fmt.Sprintf("retention %s", c.Config.RevocationSnapshot.Retention), nil
+}
+
+// provideReplication builds the cross-region session replicator when session_replication is enabled
+func (c *Container) provideReplication(context.Context) (string, error) {
+	replicationConfig := c.Config.SessionReplication
+	if c.SessionReplicator != nil {
+		return "injected", nil
+	}
+	if !replicationConfig.Enabled {
+		return "not enabled", nil
+	}
+
+	applier := repositories.NewSessionEventApplier(c.DB, c.Redis, replicationConfig.RevocationTTL)
+	if c.RefreshRevocations != nil {
+		applier = c.RefreshRevocations.Applier(applier) // Revocations made in other regions are listed here too
+	}
+	replicator, err := replication.New(replicationConfig, applier)
+	if err != nil {
+		return "", err
+	}
+	c.SessionReplicator = replicator
+	c.closers = append(c.closers, replicator.Close)
+	return "", nil
+}
+
+// provideRepositories builds the data access layer
+func (c *Container) provideRepositories() {
+	if c.UserRepository == nil {
+		c.UserRepository = repositories.NewInstrumentedUserRepository(repositories.NewUserRepository(c.DB), c.Observer)
+	}
+	if c.SessionRepository == nil && (c.SessionReplicator != nil || c.RefreshRevocations != nil) {
+		// Revocations are recorded before replication queues the event for the other regions
+		var publishers repositories.SessionEventPublishers
+		revocationTTL := c.Config.RevocationSnapshot.Retention
+		if c.RefreshRevocations != nil {
+			publishers = append(publishers, c.RefreshRevocations)
+		}
+		if c.SessionReplicator != nil {
+			publishers = append(publishers, c.SessionReplicator)
+			revocationTTL = c.Config.SessionReplication.RevocationTTL
+		}
+		c.SessionRepository = repositories.NewReplicatedSessionRepository(c.DB, c.Redis, publishers, revocationTTL)
+	}
+	if c.SessionRepository == nil {
+		c.SessionRepository = repositories.NewSessionRepository(c.DB, c.Redis)
+	}
+	if c.OneTimeTokenRepository == nil {
+		c.OneTimeTokenRepository = repositories.NewOneTimeTokenRepository(c.Redis)
+	}
+}
+
+// provideDataKeys builds the per-user data keyring when data_encryption is enabled and seals the cache's
+// user entries with it
+func (c *Container) provideDataKeys(context.Context) (string, error) {
+	status := "injected"
+	if c.DataKeys == nil {
+		keyring, err := datakeys.NewKeyring(c.UserRepository, c.Config.DataEncryption)
+		if err != nil {
+			return "", fmt.Errorf("data_encryption: %w", err)
+		}
+		if keyring == nil {
+			return "not enabled", nil
+		}
+		c.DataKeys = keyring
+		status = "master key " + keyring.MasterKeyID()
+	}
+	if c.Cache != nil {
+		c.Cache.WithDataSealer(c.DataKeys)
+	}
+	return status, nil
+}
+
+// provideSigningKeys builds the access token key set shared by the JWT service, middleware and JWKS
+func (c *Container) provideSigningKeys(context.Context) (string, error) {
+	if c.SigningKeys != nil {
+		return "injected", nil
+	}
+	keys, err := services.NewSigningKeys(c.Config.JWT)
+	if err != nil {
+		return "", err
+	}
+	c.SigningKeys = keys
+	return "", nil
+}
+
+// provideClientIPs parses server.trusted_proxies; a malformed entry stops startup rather than trusting no one silently
+func (c *Container) provideClientIPs(context.Context) (string, error) {
+	if c.ClientIPs != nil {
+		return "injected", nil
+	}
+	resolver, err := sharedMiddleware.NewClientIPResolver(c.Config.Server.TrustedProxies, c.Config.Server.ClientIPHeaders)
+	if err != nil {
+		return "", fmt.Errorf("server.trusted_proxies: %w", err)
+	}
+	c.ClientIPs = resolver
+	if len(c.Config.Server.TrustedProxies) == 0 {
+		return "none; using peer addresses", nil
+	}
+	return fmt.Sprintf("%d trusted, headers %v", len(c.Config.Server.TrustedProxies), c.Config.Server.ClientIPHeaders), nil
+}
+
+// provideRateLimiter reads the gateway's rate limits when the service enforces them itself; a file that
+// can't be read stops startup rather than serving without limits
+func (c *Container) provideRateLimiter(context.Context) (string, error) {
+	if c.RateLimiter != nil {
+		return "injected", nil
+	}
+	if c.Config.RateLimiting.Mode != config.RateLimitingService {
+		return "handled by the gateway", nil
+	}
+	if c.Redis == nil {
+		return "", fmt.Errorf("rate_limiting.mode = %q needs Redis", config.RateLimitingService)
+	}
+
+	path := c.Config.RateLimiting.GatewayConfig
+	if !filepath.IsAbs(path) && c.Config.Source != "" {
+		path = filepath.Join(filepath.Dir(c.Config.Source), path)
+	}
+	rules, err := ratelimit.LoadRules(path, c.Config.RateLimiting.Routers)
+	if err != nil {
+		return "", fmt.Errorf("rate_limiting: %w", err)
+	}
+	c.RateLimiter = ratelimit.NewLimiter(c.Redis, rules)
+
+	var limits []string
+	for _, rule := range rules {
+		for _, limit := range rule.Limits {
+			limits = append(limits, fmt.Sprintf("%s/%s %d per %s burst %d", rule.Router, limit.Name, limit.Average, limit.Period, limit.Burst))
+		}
+	}
+	return "service: " + strings.Join(limits, ", "), nil
+}
+
+// provideWebPush loads the VAPID key push notifications are signed with, when web_push is enabled
+func (c *Container) provideWebPush(context.Context) (string, error) {
+	if c.WebPush != nil {
+		return "injected", nil
+	}
+	pusher, err := webpush.New(c.Config.WebPush, nil)
+	if err != nil {
+		return "", fmt.Errorf("web_push: %w", err)
+	}
+	if pusher == nil {
+		return "not enabled", nil
+	}
+	c.WebPush = pusher
+	return fmt.Sprintf("push services %v", c.Config.WebPush.EndpointHosts), nil
+}
+
+// provideObjectStorage opens the storage of archived user activities, when activity_archive is enabled
+func (c *Container) provideObjectStorage(context.Context) (string, error) {
+	if c.ActivityStore != nil {
+		return "injected", nil
+	}
+	if !c.Config.ActivityArchive.Enabled {
+		return "not enabled", nil
+	}
+	store, err := objectstore.New(c.Config.ActivityArchive.Storage, nil)
+	if err != nil {
+		return "", fmt.Errorf("activity_archive.storage: %w", err)
+	}
+	c.ActivityStore = store
+	return c.Config.ActivityArchive.Storage.Backend, nil
+}
+
+// provideCaptcha builds the CAPTCHA challenge of logins and sign-ups when captcha.provider is set
+func (c *Container) provideCaptcha(context.Context) (string, error) {
+	if c.Captcha != nil {
+		return "injected", nil
+	}
+	cfg := c.Config.Captcha
+	if cfg.Provider == config.CaptchaOff {
+		return "not enabled", nil
+	}
+	if c.Redis == nil {
+		return "", fmt.Errorf("captcha.provider = %q needs Redis to count failed logins", cfg.Provider)
+	}
+	if c.CaptchaVerifier == nil {
+		c.CaptchaVerifier = captcha.NewSiteVerifier(cfg, nil)
+	}
+	c.Captcha = captcha.NewGuard(c.Redis, c.CaptchaVerifier, cfg)
+	return fmt.Sprintf("%s after %d failures per address, %d per account within %s", cfg.Provider, cfg.IPThreshold, cfg.AccountThreshold, cfg.Window), nil
+}
+
+// provideGeoIP builds the locator of logins and sessions when geoip.provider is set or a resolver was
+// injected; a database file that can't be read fails startup
+func (c *Container) provideGeoIP(context.Context) (string, error) {
+	if c.GeoIP != nil {
+		return "injected", nil
+	}
+	cfg := c.Config.GeoIP
+	source := cfg.Provider
+	if c.GeoIPResolver != nil {
+		source = "injected resolver"
+	} else {
+		resolver, err := geoip.NewResolver(cfg)
+		if err != nil {
+			return "", err
+		}
+		if resolver == nil {
+			return "not enabled", nil
+		}
+		if db, ok := resolver.(*geoip.Database); ok {
+			source = fmt.Sprintf("%s (%s)", cfg.Provider, db.Type())
+		}
+		c.GeoIPResolver = resolver
+	}
+	c.GeoIP = geoip.NewLocator(c.GeoIPResolver, cfg)
+	return fmt.Sprintf("%s, %d blocked and %d challenged countries", source, len(cfg.BlockedCountries), len(cfg.ChallengeCountries)), nil
+}
+
+// provideExporter builds the admin bulk export and its per-admin rate limit when bulk_export is enabled
+func (c *Container) provideExporter(context.Context) (string, error) {
+	if c.Exporter != nil {
+		return "injected", nil
+	}
+	cfg := c.Config.BulkExport
+	if !cfg.Enabled {
+		return "not enabled", nil
+	}
+	if c.Redis == nil {
+		return "", fmt.Errorf("bulk_export needs Redis to rate limit exports")
+	}
+	c.Exporter = export.NewExporter(c.DB, cfg)
+	c.ExportLimiter = ratelimit.NewLimiter(c.Redis, []ratelimit.Rule{{
+		Router:   "bulk-export",
+		Prefixes: []string{"/api/v1/admin/export/"},
+		Limits:   []ratelimit.Limit{{Name: "bulk-export", Average: cfg.RequestsPerMinute, Burst: cfg.Burst, Period: time.Minute}},
+	}})
+	return fmt.Sprintf("pages of %d up to %d rows, %d per minute burst %d per admin", cfg.DefaultPageSize, cfg.MaxPageSize, cfg.RequestsPerMinute, cfg.Burst), nil
+}
+
+// provideServices builds the business logic layer
+// OAuth2Service stays nil, disabling OAuth login, unless injected or a provider is enabled in oauth2
+func (c *Container) provideServices() {
+	if c.JWTService == nil {
+		c.JWTService = services.NewInstrumentedJWTService(services.NewJWTServiceWithKeys(c.Config.JWT, c.SigningKeys, c.ClaimsEnrichers...), c.Observer)
+	}
+	if c.Mailer == nil {
+		c.Mailer = mail.NewMailer(c.Config.Email)
+	}
+	if c.HoneypotAlerts == nil {
+		c.HoneypotAlerts = services.NewHoneypotAlerts()
+	}
+	if c.OAuth2Service == nil && c.Config.OAuth2.Enabled() {
+		c.OAuth2Service = services.NewOAuth2Service(c.Config.OAuth2, repositories.NewOAuthStateRepository(c.Redis), c.Discovery)
+	}
+	if c.BreachedPasswords == nil {
+		c.BreachedPasswords = pwnedpasswords.NewChecker(c.Config.BreachedPasswords, nil)
+	}
+	if c.ActivityArchive == nil && c.ActivityStore != nil {
+		c.ActivityArchive = services.NewActivityArchive(c.UserRepository, c.ActivityStore, c.Config.ActivityArchive, c.Maintenance)
+	}
+	if c.AuthService == nil {
+		authService := services.NewAuthServiceWithDeps(services.AuthServiceDeps{
+			UserRepo:          c.UserRepository,
+			SessionRepo:       c.SessionRepository,
+			TokenRepo:         c.OneTimeTokenRepository,
+			JWTService:        c.JWTService,
+			Security:          c.Config.Security,
+			Funnel:            c.LoginFunnel,
+			TwoFactor:         c.Config.TwoFactor,
+			Mailer:            c.Mailer,
+			LinkBaseURL:       c.Config.Email.LinkBaseURL,
+			IPPrivacy:         privacy.NewIPAnonymizer(c.Config.Privacy),
+			Registration:      c.Config.Registration,
+			RoleGrants:        c.Config.RoleGrants,
+			Policies:          services.NewSecurityPolicyResolver(c.Config),
+			TLSFingerprint:    c.Config.TLSFingerprint,
+			Honeypot:          c.Config.Honeypot,
+			HoneypotAlerts:    c.HoneypotAlerts,
+			WebAuthn:          c.Config.WebAuthn,
+			WebPush:           c.WebPush,
+			WebPushConfig:     c.Config.WebPush,
+			AccessTokens:      c.Config.AccessTokens,
+			Maintenance:       c.Maintenance,
+			EmailDomains:      c.EmailDomains,
+			BreachedPasswords: c.BreachedPasswords,
+			ActivityArchive:   c.ActivityArchive,
+			DataKeys:          c.DataKeys,
+			Captcha:           c.Captcha,
+			GeoIP:             c.GeoIP,
+			AuditLog:          c.Config.AuditLog,
+			LoginAlerts:       c.Config.LoginAlerts,
+			OAuth2:            c.Config.OAuth2,
+			RefreshCookie:     c.Config.JWT.RefreshCookie,
+			ClientCredentials: c.Config.ClientCredentials,
+			Events:            c.EventBus,
+		})
+		c.AuthService = services.NewInstrumentedAuthService(authService, c.Observer)
+	}
+	if c.RoleGrantExpirer == nil {
+		c.RoleGrantExpirer = services.NewRoleGrantExpirer(c.AuthService, c.Config.RoleGrants.ExpiryInterval)
+	}
+	if c.NotificationRetention == nil {
+		c.NotificationRetention = services.NewNotificationRetention(c.UserRepository, c.Config.NotificationRetention, c.Maintenance)
+	}
+	if c.DeletedUserRetention == nil {
+		c.DeletedUserRetention = services.NewDeletedUserRetention(c.UserRepository, c.ActivityStore, c.AuthService, c.Config.DeletedUserRetention, c.Maintenance)
+	}
+	if c.RevocationSnapshots == nil {
+		c.RevocationSnapshots = services.NewRevocationSnapshots(c.RefreshRevocations, c.SigningKeys, c.Config.RevocationSnapshot, c.Config.JWT.Issuer)
+	}
+}
+
+// provideHandlers builds the HTTP layer
+func (c *Container) provideHandlers() {
+	if c.AuthHandler == nil {
+		c.AuthHandler = handlers.NewAuthHandler(c.AuthService, c.OAuth2Service).
+			WithRefreshCookie(c.Config.JWT.RefreshCookie).
+			WithForwardAuthHeaders(c.Config.ForwardAuth)
+	}
+	if c.AdminHandler == nil {
+		c.AdminHandler = handlers.NewAdminHandler(c.AuthService)
+	}
+	if c.StatusPage == nil {
+		c.StatusPage = status.NewPage(c.DB, c.Redis, c.Config)
+		if c.SchemaStatus != nil {
+			c.StatusPage.AddCheck("migrations", c.SchemaStatus.HealthCheck())
+		}
+		if c.SessionReplicator != nil {
+			c.StatusPage.AddCheck("session_replication", c.SessionReplicator.HealthCheck())
+		}
+		c.StatusPage.OnTransition(health.EventPublisher(c.EventBus))
+		if c.Cache != nil {
+			// In-process, since the bus events travel over the Redis whose outage they report
+			c.StatusPage.OnTransition(c.Cache.HealthListener())
+		}
+	}
+	if c.StatusHandler == nil {
+		c.StatusHandler = handlers.NewStatusHandler(c.StatusPage)
+	}
+	if c.TelemetryHandler == nil {
+		c.TelemetryHandler = handlers.NewTelemetryHandler(c.LoginFunnel)
+	}
+	if c.JWKSHandler == nil {
+		c.JWKSHandler = handlers.NewJWKSHandler(c.SigningKeys)
+	}
+	if c.SchemaHandler == nil {
+		var validator *migrations.SchemaValidator
+		if c.DB != nil {
+			var err error
+			if validator, err = migrations.NewSchemaValidator(c.DB); err != nil {
+				log.Printf("⚠️  Schema validation endpoint disabled: %v", err)
+			}
+		}
+		c.SchemaHandler = handlers.NewSchemaHandler(validator)
+	}
+	if c.MaintenanceHandler == nil {
+		c.MaintenanceHandler = handlers.NewMaintenanceHandler(c.Maintenance)
+	}
+	if c.LoggingHandler == nil {
+		c.LoggingHandler = handlers.NewLoggingHandler(c.LogControl)
+	}
+	if c.RevocationHandler == nil {
+		c.RevocationHandler = handlers.NewRevocationHandler(c.RevocationSnapshots)
+	}
+	if c.EmailDomainsHandler == nil {
+		c.EmailDomainsHandler = handlers.NewEmailDomainsHandler(c.EmailDomains)
+	}
+	if c.DeprecationHandler == nil {
+		c.DeprecationHandler = handlers.NewDeprecationHandler(c.Deprecations)
+	}
+	if c.ExportHandler == nil {
+		c.ExportHandler = handlers.NewExportHandler(c.Exporter, c.ExportLimiter)
+	}
+}
+
+// Close releases every resource the container opened, in reverse order of creation
+func (c *Container) Close() error {
+	var firstErr error
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		if err := c.closers[i](); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.closers = nil
+	return firstErr
+}
+
+// Inject makes the container available to handlers and middleware for the lifetime of each request
+func (c *Container) Inject() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(contextKey, c)
+		ctx.Next()
+	}
+}
+
+// FromContext returns the container stored by Inject
+func FromContext(ctx *gin.Context) (*Container, bool) {
+	value, exists := ctx.Get(contextKey)
+	if !exists {
+		return nil, false
+	}
+	c, ok := value.(*Container)
+	return c, ok
+}
@@ -0,0 +1,149 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// startupRetryInterval is the wait before a startup step's first retry; it doubles with every attempt
+const startupRetryInterval = time.Second
+
+// StepStatus is the outcome of one startup step
+type StepStatus string
+
+const (
+	StepOK      StepStatus = "ok"
+	StepFailed  StepStatus = "failed"
+	StepSkipped StepStatus = "skipped" // Not run because an earlier step failed
+)
+
+// startupStep is one stage of New, run in declared order
+// Only steps that are safe to repeat (connection checks, reads) set retries
+type startupStep struct {
+	name    string
+	retries int
+	run     func(ctx context.Context) (detail string, err error)
+}
+
+// StepResult records how one startup step went
+type StepResult struct {
+	Name     string        `json:"name"`
+	Status   StepStatus    `json:"status"`
+	Attempts int           `json:"attempts,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Detail   string        `json:"detail,omitempty"` // What the step did, e.g. "injected", or why it failed
+}
+
+// StartupReport summarizes how the container came up: which config file was used, and for every step
+// whether it ran, how long it took and what it connected to
+type StartupReport struct {
+	ConfigSource string        `json:"config_source"`
+	Steps        []StepResult  `json:"steps"`
+	Duration     time.Duration `json:"duration"`
+}
+
+// Failed returns the step that stopped startup, or nil when every step succeeded
+func (r *StartupReport) Failed() *StepResult {
+	for i := range r.Steps {
+		if r.Steps[i].Status == StepFailed {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Log prints the report as one line per step, so a failed boot shows what had already connected
+func (r *StartupReport) Log() {
+	outcome := "✅ Startup complete"
+	if failed := r.Failed(); failed != nil {
+		outcome = fmt.Sprintf("❌ Startup failed at %s", failed.Name)
+	}
+	source := r.ConfigSource
+	if source == "" {
+		source = "(not loaded from a file)"
+	}
+	log.Printf("%s in %s; config: %s", outcome, r.Duration.Round(time.Millisecond), source)
+
+	for _, step := range r.Steps {
+		duration := step.Duration.Round(time.Millisecond).String()
+		if step.Status == StepSkipped {
+			duration = "-"
+		}
+		line := fmt.Sprintf("   %-8s %-20s %8s", step.Status, step.Name, duration)
+		if step.Attempts > 1 {
+			line += fmt.Sprintf("  (%d attempts)", step.Attempts)
+		}
+		if step.Detail != "" {
+			line += "  " + step.Detail
+		}
+		log.Print(line)
+	}
+}
+
+// StartupError is returned by New when a startup step fails; Report covers every step, including
+// those that never ran
+type StartupError struct {
+	Step   string
+	Err    error
+	Report *StartupReport
+}
+
+func (e *StartupError) Error() string {
+	return fmt.Sprintf("startup step %s failed: %v", e.Step, e.Err)
+}
+
+func (e *StartupError) Unwrap() error {
+	return e.Err
+}
+
+// runStartup runs steps in order, stopping at the first failure, and returns the report
+// Retried steps wait startupRetryInterval, doubling each time, unless ctx is cancelled first
+func runStartup(ctx context.Context, configSource string, steps []startupStep) (*StartupReport, error) {
+	report := &StartupReport{ConfigSource: configSource, Steps: make([]StepResult, 0, len(steps))}
+	started := time.Now()
+	defer func() { report.Duration = time.Since(started) }()
+
+	for i, step := range steps {
+		result := StepResult{Name: step.name}
+		stepStarted := time.Now()
+		interval := startupRetryInterval
+
+		var err error
+		for attempt := 0; attempt <= step.retries; attempt++ {
+			if attempt > 0 {
+				log.Printf("🔄 Retrying startup step %s in %s: %v", step.name, interval, err)
+				select {
+				case <-ctx.Done():
+					err = fmt.Errorf("%w (cancelled while retrying: %v)", err, ctx.Err())
+				case <-time.After(interval):
+				}
+				if ctx.Err() != nil {
+					break
+				}
+				interval *= 2
+			}
+			result.Attempts = attempt + 1
+			result.Detail, err = step.run(ctx)
+			if err == nil {
+				break
+			}
+		}
+		result.Duration = time.Since(stepStarted)
+
+		if err != nil {
+			result.Status = StepFailed
+			result.Detail = err.Error()
+			report.Steps = append(report.Steps, result)
+			for _, skipped := range steps[i+1:] {
+				report.Steps = append(report.Steps, StepResult{Name: skipped.name, Status: StepSkipped})
+			}
+			return report, &StartupError{Step: step.name, Err: err, Report: report}
+		}
+
+		result.Status = StepOK
+		report.Steps = append(report.Steps, result)
+	}
+	return report, nil
+}
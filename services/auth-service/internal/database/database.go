@@ -153,6 +153,7 @@ func createIndexes(db *gorm.DB) error {
 //
 // Purpose: Creates Redis client connection for token blacklisting and caching with enhanced reliability
 // Parameters:
+//   - ctx (context.Context): Cancels connection attempts, which are also bounded to 2 minutes
 //   - cfg (config.RedisConfig): Redis configuration containing connection parameters
 // Redis Usage in Auth Service:
 //   - JWT Token Blacklisting: Immediately invalidate revoked tokens
@@ -167,12 +168,13 @@ func createIndexes(db *gorm.DB) error {
 //   - Retry Logic: Exponential backoff with configurable parameters
 // Returns:
 //   - *redis.Client: Configured Redis client for caching operations
+//   - error: Connection failure after all retries, for the caller to report
 // Error Handling: Enhanced error handling with retry logic instead of panic
 // Performance: Connection pooling enables 1000+ concurrent Redis operations
 // Security: Supports password authentication and database isolation
 // Reliability: Automatic retry with exponential backoff for transient failures
 // Usage: Called once during application initialization for global Redis client
-func ConnectRedis(cfg config.RedisConfig) *redis.Client {
+func ConnectRedis(ctx context.Context, cfg config.RedisConfig) (*redis.Client, error) {
 	// Convert auth-service config to shared database config
 	sharedConfig := database.RedisConfig{
 		URL:          cfg.URL,
@@ -192,7 +194,7 @@ func ConnectRedis(cfg config.RedisConfig) *redis.Client {
 	retryConfig := database.DefaultRetryConfig()
 	
 	// Create context with overall timeout for connection establishment
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 	
 	log.Println("🔄 Establishing Redis connection with retry logic...")
@@ -200,9 +202,9 @@ func ConnectRedis(cfg config.RedisConfig) *redis.Client {
 	// Use shared Redis connection with retry logic
 	client, err := database.ConnectRedisWithRetry(ctx, sharedConfig, retryConfig)
 	if err != nil {
-		log.Fatalf("Failed to connect to Redis after retries: %v", err)
+		return nil, fmt.Errorf("failed to connect to Redis after retries: %w", err)
 	}
 
 	log.Println("✅ Redis connected successfully with retry logic")
-	return client
+	return client, nil
 }
\ No newline at end of file
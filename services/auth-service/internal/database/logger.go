@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/logging"
+
+	"gorm.io/gorm/logger"
+	"shared/database"
+)
+
+// NewGormLogger builds the structured GORM logger from the logging configuration
+// SQL is written through log/slog in the configured format; statements slower than slowThreshold are warnings.
+// The logger follows levels, so a runtime level change (e.g. to debug) applies to SQL logging at once
+func NewGormLogger(cfg config.LoggingConfig, slowThreshold time.Duration, levels *logging.Levels) logger.Interface {
+	base := database.NewGormLogger(database.GormLoggerConfig{
+		Level:                     cfg.Level,
+		SlowThreshold:             slowThreshold,
+		IgnoreRecordNotFoundError: true, // Lookups of missing users/sessions are expected, not errors
+		Logger:                    newStructuredLogger(cfg, levels),
+	})
+
+	l := &leveledGormLogger{
+		base:       base,
+		levels:     levels,
+		configured: logging.ParseLevel(cfg.Level),
+		modes:      make(map[slog.Level]logger.Interface),
+	}
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		l.modes[level] = base.LogMode(database.ParseGormLogLevel(logging.LevelName(level)))
+	}
+	return l
+}
+
+// newStructuredLogger creates a slog logger honouring the format (json or text) and output settings
+// at the runtime level
+func newStructuredLogger(cfg config.LoggingConfig, levels *logging.Levels) *slog.Logger {
+	var out io.Writer = os.Stdout
+	if strings.EqualFold(cfg.Output, "stderr") {
+		out = os.Stderr
+	}
+
+	opts := &slog.HandlerOptions{Level: levels}
+	if strings.EqualFold(cfg.Format, "json") {
+		return slog.New(slog.NewJSONHandler(out, opts))
+	}
+	return slog.New(slog.NewTextHandler(out, opts))
+}
+
+// leveledGormLogger switches between copies of the GORM logger as the runtime log level changes
+type leveledGormLogger struct {
+	base       *database.GormLogger
+	levels     *logging.Levels
+	configured slog.Level
+	modes      map[slog.Level]logger.Interface
+}
+
+// current is the logger for the runtime level; at the configured level it is the base logger, which
+// also honours levels the runtime doesn't know, such as "silent"
+func (l *leveledGormLogger) current() logger.Interface {
+	level := l.levels.Level()
+	if level == l.configured {
+		return l.base
+	}
+	if mode, ok := l.modes[level]; ok {
+		return mode
+	}
+	return l.base
+}
+
+// LogMode returns a logger fixed at the given GORM level (used by db.Debug())
+func (l *leveledGormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return l.base.LogMode(level)
+}
+
+func (l *leveledGormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.current().Info(ctx, msg, args...)
+}
+
+func (l *leveledGormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.current().Warn(ctx, msg, args...)
+}
+
+func (l *leveledGormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.current().Error(ctx, msg, args...)
+}
+
+func (l *leveledGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	l.current().Trace(ctx, begin, fc, err)
+}
+
+// ParamsFilter keeps secret parameters out of the logged SQL
+func (l *leveledGormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return l.base.ParamsFilter(ctx, sql, params...)
+}
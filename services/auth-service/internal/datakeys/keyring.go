@@ -0,0 +1,203 @@
+// Package datakeys keeps the per-user keys session Data and cached users are sealed with in Redis. Each
+// key is random, stored in user_data_keys wrapped by the data master key, and deleted with the account,
+// after which nothing it sealed can be read again, wherever copies of it are left
+package datakeys
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+	"shared/sealing"
+)
+
+// keySize is the size of a user's AES-256 key in bytes
+const keySize = 32
+
+// maxCachedKeys bounds the unwrapped keys kept in memory; the cache is emptied when it fills up
+const maxCachedKeys = 10000
+
+// Store persists the wrapped keys; implemented by repositories.UserRepository
+type Store interface {
+	GetDataKey(userID uuid.UUID) (*models.UserDataKey, error)
+	CreateDataKey(key *models.UserDataKey) (*models.UserDataKey, error)
+}
+
+// cachedKey is an unwrapped user key, kept until expires so each seal doesn't read user_data_keys
+type cachedKey struct {
+	aead    cipher.AEAD
+	expires time.Time
+}
+
+// Keyring seals and opens user data with the user's key. Keys are shredded by deleting the account, which
+// deletes its user_data_keys row; other replicas may keep a shredded key cached for up to key_cache_ttl
+type Keyring struct {
+	store       Store
+	master      cipher.AEAD
+	masterKeyID string
+	cacheTTL    time.Duration
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedKey
+}
+
+var _ sealing.Sealer = (*Keyring)(nil)
+
+// NewKeyring builds the keyring for the validated configuration; it returns nil when data_encryption is off
+func NewKeyring(store Store, cfg config.DataEncryptionConfig) (*Keyring, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	master, err := newAEAD(cfg.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("data master key: %w", err)
+	}
+	fingerprint := sha256.Sum256(cfg.MasterKey)
+	return &Keyring{
+		store:       store,
+		master:      master,
+		masterKeyID: hex.EncodeToString(fingerprint[:8]),
+		cacheTTL:    cfg.KeyCacheTTL,
+		cache:       make(map[uuid.UUID]cachedKey),
+	}, nil
+}
+
+// MasterKeyID returns the fingerprint of the master key, stored with every key it wraps
+func (k *Keyring) MasterKeyID() string {
+	return k.masterKeyID
+}
+
+// Seal encrypts plaintext under the user's key, creating the key on first use. Deleted users have no key
+// and can't be given one, so sealing for them returns sealing.ErrShredded
+func (k *Keyring) Seal(ctx context.Context, userID string, plaintext []byte) ([]byte, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID %q: %w", userID, err)
+	}
+	aead, err := k.key(id, true)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, plaintext, id[:])
+}
+
+// Open decrypts what Seal returned for the user; it returns sealing.ErrShredded once the key is deleted
+func (k *Keyring) Open(ctx context.Context, userID string, ciphertext []byte) ([]byte, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID %q: %w", userID, err)
+	}
+	aead, err := k.key(id, false)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ciphertext, id[:])
+}
+
+// Evict drops the user's key from this replica's cache once it was shredded, i.e. deleted with the account
+func (k *Keyring) Evict(userID uuid.UUID) {
+	k.mu.Lock()
+	delete(k.cache, userID)
+	k.mu.Unlock()
+}
+
+// key returns the user's unwrapped key from the cache or user_data_keys, creating it when create is set
+func (k *Keyring) key(userID uuid.UUID, create bool) (cipher.AEAD, error) {
+	now := time.Now()
+	k.mu.Lock()
+	cached, ok := k.cache[userID]
+	k.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.aead, nil
+	}
+
+	stored, err := k.store.GetDataKey(userID)
+	if errors.Is(err, repositories.ErrDataKeyNotFound) && create {
+		stored, err = k.create(userID)
+	}
+	if errors.Is(err, repositories.ErrDataKeyNotFound) {
+		return nil, sealing.ErrShredded
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data key of user %s: %w", userID, err)
+	}
+
+	aead, err := k.unwrap(stored)
+	if err != nil {
+		return nil, err
+	}
+	k.mu.Lock()
+	if len(k.cache) >= maxCachedKeys {
+		clear(k.cache)
+	}
+	k.cache[userID] = cachedKey{aead: aead, expires: now.Add(k.cacheTTL)}
+	k.mu.Unlock()
+	return aead, nil
+}
+
+// create stores a new random key for the user and returns the one the user ends up with
+func (k *Keyring) create(userID uuid.UUID) (*models.UserDataKey, error) {
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err := seal(k.master, raw, userID[:])
+	if err != nil {
+		return nil, err
+	}
+	return k.store.CreateDataKey(&models.UserDataKey{
+		UserID:      userID,
+		WrappedKey:  wrapped,
+		MasterKeyID: k.masterKeyID,
+	})
+}
+
+// unwrap decrypts a stored key with the master key; the user ID binds the key to its row
+func (k *Keyring) unwrap(stored *models.UserDataKey) (cipher.AEAD, error) {
+	if stored.MasterKeyID != k.masterKeyID {
+		return nil, fmt.Errorf("data key of user %s is wrapped by master key %s, not the configured %s", stored.UserID, stored.MasterKeyID, k.masterKeyID)
+	}
+	raw, err := open(k.master, stored.WrappedKey, stored.UserID[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key of user %s: %w", stored.UserID, err)
+	}
+	return newAEAD(raw)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, returned in front of the ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts what seal returned
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
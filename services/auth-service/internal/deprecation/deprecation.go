@@ -0,0 +1,337 @@
+// Package deprecation marks endpoints that are being retired, e.g. after a move from /users/* to
+// /auth/*. Responses of the endpoints listed in [deprecations] carry Deprecation, Sunset and Link
+// headers, and calls are counted per consumer so the paths can be removed once nobody uses them
+package deprecation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	sharedMiddleware "shared/middleware"
+)
+
+const (
+	// usageKeyPrefix prefixes the per-endpoint Redis hashes of call counts by consumer
+	// (deprecations:usage:GET /api/v1/auth/profile)
+	usageKeyPrefix = "deprecations:usage:"
+	// lastSeenKeyPrefix prefixes the per-endpoint Redis hashes of each consumer's last call (Unix seconds)
+	lastSeenKeyPrefix = "deprecations:last_seen:"
+	// writeTimeout bounds each Redis write so usage tracking never stalls a request
+	writeTimeout = 500 * time.Millisecond
+	// maxUserAgentLength truncates user agents used as consumer names
+	maxUserAgentLength = 128
+	// maxLoggedConsumers bounds the consumers remembered for logging only their first call
+	maxLoggedConsumers = 10000
+)
+
+// Endpoint is a deprecated route and what its callers are told
+type Endpoint struct {
+	Method     string     `json:"method,omitempty"` // Empty for every method of the path
+	Path       string     `json:"path"`
+	Deprecated time.Time  `json:"deprecated"`
+	Sunset     *time.Time `json:"sunset,omitempty"`
+	Successor  string     `json:"successor,omitempty"`
+	Link       string     `json:"link,omitempty"`
+}
+
+// methods is the endpoint's method, or * for every method
+func (e Endpoint) methods() string {
+	if e.Method == "" {
+		return "*"
+	}
+	return e.Method
+}
+
+// key names the endpoint in Redis keys and logs
+func (e Endpoint) key() string {
+	return e.methods() + " " + e.Path
+}
+
+// Registry matches requests against the deprecated endpoints, sets their headers and records usage
+type Registry struct {
+	redis     *redis.Client
+	retention time.Duration
+	endpoints map[string]*entry // By key
+	ordered   []*entry
+
+	mu     sync.Mutex
+	calls  map[string]uint64 // This replica's calls by endpoint key
+	logged map[string]bool   // Endpoint key and consumer pairs already logged by this replica
+}
+
+type entry struct {
+	Endpoint
+	headers map[string]string
+}
+
+// NewRegistry builds the registry of cfg; it returns nil when no endpoint is deprecated
+// Without Redis, headers are still sent and first calls logged, but usage isn't counted for the report
+func NewRegistry(client *redis.Client, cfg config.DeprecationsConfig) *Registry {
+	if len(cfg.Endpoints) == 0 {
+		return nil
+	}
+
+	r := &Registry{
+		redis:     client,
+		retention: cfg.Retention,
+		endpoints: make(map[string]*entry, len(cfg.Endpoints)),
+		calls:     make(map[string]uint64, len(cfg.Endpoints)),
+		logged:    make(map[string]bool),
+	}
+	for _, endpoint := range cfg.Endpoints {
+		e := &entry{Endpoint: Endpoint{
+			Method:     strings.ToUpper(endpoint.Method),
+			Path:       endpoint.Path,
+			Deprecated: endpoint.Deprecated.UTC(),
+			Successor:  endpoint.Successor,
+			Link:       endpoint.Link,
+		}}
+		if !endpoint.Sunset.IsZero() {
+			sunset := endpoint.Sunset.UTC()
+			e.Sunset = &sunset
+		}
+		e.headers = headers(e.Endpoint)
+		r.endpoints[e.key()] = e
+		r.ordered = append(r.ordered, e)
+	}
+	return r
+}
+
+// headers are the response headers of a deprecated endpoint: Deprecation as a structured date
+// (RFC 9745), Sunset as an HTTP date (RFC 8594) and links to the successor and the migration notes
+func headers(e Endpoint) map[string]string {
+	h := map[string]string{"Deprecation": "@" + strconv.FormatInt(e.Deprecated.Unix(), 10)}
+	if e.Sunset != nil {
+		h["Sunset"] = e.Sunset.Format(http.TimeFormat)
+	}
+	var links []string
+	if e.Successor != "" {
+		links = append(links, fmt.Sprintf("<%s>; rel=\"successor-version\"", e.Successor))
+	}
+	if e.Link != "" {
+		links = append(links, fmt.Sprintf("<%s>; rel=\"deprecation\"; type=\"text/html\"", e.Link))
+	}
+	if len(links) > 0 {
+		h["Link"] = strings.Join(links, ", ")
+	}
+	return h
+}
+
+// Middleware adds the headers to responses of deprecated routes, before the handlers run so that
+// error responses carry them too, and records the call once the consumer is known
+func (r *Registry) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		e := r.match(c.Request.Method, c.FullPath())
+		if e == nil {
+			c.Next()
+			return
+		}
+
+		for name, value := range e.headers {
+			c.Header(name, value)
+		}
+		c.Next()
+		r.record(e, consumer(c))
+	}
+}
+
+// match finds the endpoint of a route; fullPath is the route pattern, empty for unmatched requests
+func (r *Registry) match(method, fullPath string) *entry {
+	if fullPath == "" {
+		return nil
+	}
+	if e, ok := r.endpoints[method+" "+fullPath]; ok {
+		return e
+	}
+	return r.endpoints["* "+fullPath]
+}
+
+// consumer names the caller: the client ID of its token when it has one, its user agent otherwise
+// The token is only known once the authentication middleware ran
+func consumer(c *gin.Context) string {
+	if claims := sharedMiddleware.GetClaimsFromContext(c); claims != nil && claims.ClientID != "" {
+		return "client:" + claims.ClientID
+	}
+	userAgent := strings.TrimSpace(c.Request.UserAgent())
+	if userAgent == "" {
+		return "ua:unknown"
+	}
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return "ua:" + userAgent
+}
+
+// record counts a call in Redis and logs the first call of each consumer seen by this replica
+func (r *Registry) record(e *entry, consumer string) {
+	key := e.key()
+
+	r.mu.Lock()
+	r.calls[key]++
+	first := !r.logged[key+"\n"+consumer]
+	if first {
+		if len(r.logged) >= maxLoggedConsumers {
+			clear(r.logged)
+		}
+		r.logged[key+"\n"+consumer] = true
+	}
+	r.mu.Unlock()
+
+	if first {
+		sunset := "no sunset date"
+		if e.Sunset != nil {
+			sunset = "sunset " + e.Sunset.Format(time.DateOnly)
+		}
+		log.Printf("⚠️  Deprecated endpoint %s called by %s (%s)", key, consumer, sunset)
+	}
+	if r.redis == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	pipe := r.redis.Pipeline()
+	pipe.HIncrBy(ctx, usageKeyPrefix+key, consumer, 1)
+	pipe.HSet(ctx, lastSeenKeyPrefix+key, consumer, time.Now().Unix())
+	if r.retention > 0 {
+		pipe.Expire(ctx, usageKeyPrefix+key, r.retention)
+		pipe.Expire(ctx, lastSeenKeyPrefix+key, r.retention)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️  Failed to record use of deprecated endpoint %s: %v", key, err)
+	}
+}
+
+// Unregistered returns the deprecated endpoints that match none of routes, which are most likely typos
+func (r *Registry) Unregistered(routes gin.RoutesInfo) []string {
+	if r == nil {
+		return nil
+	}
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		registered[route.Method+" "+route.Path] = true
+		registered["* "+route.Path] = true
+	}
+	var unmatched []string
+	for _, e := range r.ordered {
+		if !registered[e.key()] {
+			unmatched = append(unmatched, e.key())
+		}
+	}
+	return unmatched
+}
+
+// ConsumerUsage is one consumer's calls to a deprecated endpoint
+type ConsumerUsage struct {
+	Consumer string    `json:"consumer"`
+	Calls    int64     `json:"calls"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// EndpointUsage is a deprecated endpoint and who still calls it, most active consumer first
+type EndpointUsage struct {
+	Endpoint
+	Calls     int64           `json:"calls"`
+	Consumers []ConsumerUsage `json:"consumers"`
+}
+
+// Report is the usage of every deprecated endpoint across replicas, in the order they are configured
+type Report struct {
+	// Retention is how long a consumer is listed after its last call
+	Retention string          `json:"retention,omitempty"`
+	Tracked   bool            `json:"tracked"` // False without Redis: calls were logged but not counted
+	Endpoints []EndpointUsage `json:"endpoints"`
+}
+
+// Report reads the usage counts of every deprecated endpoint; a nil registry reports none
+func (r *Registry) Report(ctx context.Context) (*Report, error) {
+	report := &Report{Endpoints: []EndpointUsage{}}
+	if r == nil {
+		return report, nil
+	}
+	report.Tracked = r.redis != nil
+	if r.retention > 0 {
+		report.Retention = r.retention.String()
+	}
+
+	usage := make([]*redis.MapStringStringCmd, len(r.ordered))
+	lastSeen := make([]*redis.MapStringStringCmd, len(r.ordered))
+	if r.redis != nil {
+		pipe := r.redis.Pipeline()
+		for i, e := range r.ordered {
+			usage[i] = pipe.HGetAll(ctx, usageKeyPrefix+e.key())
+			lastSeen[i] = pipe.HGetAll(ctx, lastSeenKeyPrefix+e.key())
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to read deprecated endpoint usage: %w", err)
+		}
+	}
+
+	var cutoff time.Time
+	if r.retention > 0 {
+		cutoff = time.Now().Add(-r.retention)
+	}
+	for i, e := range r.ordered {
+		endpoint := EndpointUsage{Endpoint: e.Endpoint, Consumers: []ConsumerUsage{}}
+		if r.redis != nil {
+			seen := lastSeen[i].Val()
+			for name, value := range usage[i].Val() {
+				calls, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					continue
+				}
+				consumer := ConsumerUsage{Consumer: name, Calls: calls}
+				if unix, err := strconv.ParseInt(seen[name], 10, 64); err == nil {
+					consumer.LastSeen = time.Unix(unix, 0).UTC()
+				}
+				if consumer.LastSeen.Before(cutoff) {
+					continue // The hashes outlive consumers that stopped calling while others still call
+				}
+				endpoint.Calls += calls
+				endpoint.Consumers = append(endpoint.Consumers, consumer)
+			}
+			sort.Slice(endpoint.Consumers, func(a, b int) bool {
+				if endpoint.Consumers[a].Calls != endpoint.Consumers[b].Calls {
+					return endpoint.Consumers[a].Calls > endpoint.Consumers[b].Calls
+				}
+				return endpoint.Consumers[a].Consumer < endpoint.Consumers[b].Consumer
+			})
+		}
+		report.Endpoints = append(report.Endpoints, endpoint)
+	}
+	return report, nil
+}
+
+// WritePrometheus writes this replica's calls to each deprecated endpoint in the Prometheus text format
+func (r *Registry) WritePrometheus(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP auth_service_deprecated_endpoint_calls_total Calls to endpoints listed in [deprecations]")
+	fmt.Fprintln(w, "# TYPE auth_service_deprecated_endpoint_calls_total counter")
+	for _, e := range r.ordered {
+		fmt.Fprintf(w, "auth_service_deprecated_endpoint_calls_total{method=%q,path=%q} %d\n",
+			e.methods(), e.Path, r.calls[e.key()])
+	}
+	fmt.Fprintln(w, "# HELP auth_service_deprecated_endpoint_sunset_timestamp_seconds When a deprecated endpoint will be removed")
+	fmt.Fprintln(w, "# TYPE auth_service_deprecated_endpoint_sunset_timestamp_seconds gauge")
+	for _, e := range r.ordered {
+		if e.Sunset != nil {
+			fmt.Fprintf(w, "auth_service_deprecated_endpoint_sunset_timestamp_seconds{method=%q,path=%q} %d\n",
+				e.methods(), e.Path, e.Sunset.Unix())
+		}
+	}
+}
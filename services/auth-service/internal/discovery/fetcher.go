@@ -0,0 +1,426 @@
+package discovery
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+)
+
+var (
+	ErrDocumentNotRegistered = errors.New("discovery document not registered")
+	ErrDocumentNotReady      = errors.New("discovery document not fetched yet")
+	ErrDocumentStale         = errors.New("discovery document is stale")
+)
+
+// maxDocumentSize bounds provider responses
+const maxDocumentSize = 1 << 20
+
+// ParseFunc converts a raw provider response into the cached value
+type ParseFunc func(body []byte) (interface{}, error)
+
+// document is one cached provider document
+type document struct {
+	name  string
+	url   string
+	parse ParseFunc
+
+	mu        sync.RWMutex
+	value     interface{}
+	fetchedAt time.Time
+	lastErr   error
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// Status describes a cached document for diagnostics
+type Status struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	FetchedAt time.Time `json:"fetched_at,omitempty"`
+	Stale     bool      `json:"stale"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Fetcher keeps JWKS and OIDC discovery documents fresh in the background
+// Lookups never perform HTTP calls: they return the last good document (stale-if-error)
+// until it is older than MaxStale. Refreshes are jittered so replicas don't hit providers together,
+// and failures are retried with exponential backoff.
+type Fetcher struct {
+	client *http.Client
+	cfg    config.DiscoveryConfig
+
+	mu        sync.RWMutex
+	documents map[string]*document
+	ctx       context.Context
+}
+
+// NewFetcher creates a fetcher; documents are fetched once Start is called
+func NewFetcher(client *http.Client, cfg config.DiscoveryConfig) *Fetcher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Fetcher{
+		client:    client,
+		cfg:       cfg,
+		documents: make(map[string]*document),
+	}
+}
+
+// Register adds a document; if the fetcher is already running its refresh loop starts immediately
+// Registering a name twice keeps the first registration
+func (f *Fetcher) Register(name, url string, parse ParseFunc) {
+	doc := &document{name: name, url: url, parse: parse, ready: make(chan struct{})}
+
+	f.mu.Lock()
+	if _, exists := f.documents[name]; exists {
+		f.mu.Unlock()
+		return
+	}
+	f.documents[name] = doc
+	ctx := f.ctx
+	f.mu.Unlock()
+
+	if ctx != nil {
+		go f.refreshLoop(ctx, doc)
+	}
+}
+
+// RegisterJWKS registers a JSON Web Key Set endpoint
+func (f *Fetcher) RegisterJWKS(name, url string) {
+	f.Register(name, url, ParseJWKS)
+}
+
+// RegisterOIDC registers an OpenID Connect discovery document (.well-known/openid-configuration)
+func (f *Fetcher) RegisterOIDC(name, issuer string) {
+	f.Register(name, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", ParseOIDCConfiguration)
+}
+
+// Start launches a refresh loop per document until ctx is cancelled
+func (f *Fetcher) Start(ctx context.Context) {
+	f.mu.Lock()
+	f.ctx = ctx
+	docs := make([]*document, 0, len(f.documents))
+	for _, doc := range f.documents {
+		docs = append(docs, doc)
+	}
+	f.mu.Unlock()
+
+	for _, doc := range docs {
+		go f.refreshLoop(ctx, doc)
+	}
+}
+
+// WaitReady blocks until every registered document has been fetched once or ctx expires
+// Intended for startup only; request paths should call Get
+func (f *Fetcher) WaitReady(ctx context.Context) error {
+	f.mu.RLock()
+	docs := make([]*document, 0, len(f.documents))
+	for _, doc := range f.documents {
+		docs = append(docs, doc)
+	}
+	f.mu.RUnlock()
+
+	for _, doc := range docs {
+		select {
+		case <-doc.ready:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %s: %w", doc.name, ctx.Err())
+		}
+	}
+	return nil
+}
+
+// Get returns the cached document without blocking on the network
+func (f *Fetcher) Get(name string) (interface{}, error) {
+	f.mu.RLock()
+	doc, exists := f.documents[name]
+	f.mu.RUnlock()
+	if !exists {
+		return nil, ErrDocumentNotRegistered
+	}
+
+	doc.mu.RLock()
+	defer doc.mu.RUnlock()
+
+	if doc.value == nil {
+		if doc.lastErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDocumentNotReady, doc.lastErr)
+		}
+		return nil, ErrDocumentNotReady
+	}
+	if f.cfg.MaxStale > 0 && time.Since(doc.fetchedAt) > f.cfg.MaxStale {
+		return nil, fmt.Errorf("%w: last fetched %s ago", ErrDocumentStale, time.Since(doc.fetchedAt).Round(time.Second))
+	}
+	return doc.value, nil
+}
+
+// JWKS returns a cached key set
+func (f *Fetcher) JWKS(name string) (*JWKS, error) {
+	value, err := f.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	jwks, ok := value.(*JWKS)
+	if !ok {
+		return nil, fmt.Errorf("discovery document %s is not a JWKS", name)
+	}
+	return jwks, nil
+}
+
+// OIDC returns a cached OpenID Connect discovery document
+func (f *Fetcher) OIDC(name string) (*OIDCConfiguration, error) {
+	value, err := f.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	oidc, ok := value.(*OIDCConfiguration)
+	if !ok {
+		return nil, fmt.Errorf("discovery document %s is not an OIDC configuration", name)
+	}
+	return oidc, nil
+}
+
+// Statuses reports the freshness of every registered document
+func (f *Fetcher) Statuses() []Status {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(f.documents))
+	for _, doc := range f.documents {
+		doc.mu.RLock()
+		status := Status{
+			Name:      doc.name,
+			URL:       doc.url,
+			FetchedAt: doc.fetchedAt,
+			Stale:     doc.value == nil || (f.cfg.MaxStale > 0 && time.Since(doc.fetchedAt) > f.cfg.MaxStale),
+		}
+		if doc.lastErr != nil {
+			status.LastError = doc.lastErr.Error()
+		}
+		doc.mu.RUnlock()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// refreshLoop fetches a document, then refreshes it on a jittered interval with backoff on failure
+func (f *Fetcher) refreshLoop(ctx context.Context, doc *document) {
+	retryDelay := f.cfg.RetryInterval
+
+	for {
+		maxAge, err := f.fetch(ctx, doc)
+
+		var wait time.Duration
+		if err != nil {
+			log.Printf("⚠️ Failed to refresh %s from %s (retrying in %s): %v", doc.name, doc.url, retryDelay, err)
+			wait = retryDelay
+			retryDelay *= 2
+			if retryDelay > f.cfg.RefreshInterval {
+				retryDelay = f.cfg.RefreshInterval
+			}
+		} else {
+			retryDelay = f.cfg.RetryInterval
+			wait = f.cfg.RefreshInterval
+			// Honour a shorter provider max-age, e.g. during key rotation
+			if maxAge > 0 && maxAge < wait {
+				wait = maxAge
+			}
+		}
+
+		timer := time.NewTimer(jitter(wait, f.cfg.Jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// fetch downloads and parses a document, keeping the previous value on failure
+func (f *Fetcher) fetch(ctx context.Context, doc *document) (time.Duration, error) {
+	value, maxAge, err := f.download(ctx, doc)
+
+	doc.mu.Lock()
+	doc.lastErr = err
+	if err == nil {
+		doc.value = value
+		doc.fetchedAt = time.Now()
+	}
+	doc.mu.Unlock()
+
+	if err == nil {
+		doc.readyOnce.Do(func() { close(doc.ready) })
+	}
+	return maxAge, err
+}
+
+func (f *Fetcher) download(ctx context.Context, doc *document) (interface{}, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, doc.url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, err := doc.parse(body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid document: %w", err)
+	}
+	return value, cacheMaxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// cacheMaxAge extracts max-age from a Cache-Control header
+func cacheMaxAge(header string) time.Duration {
+	for _, directive := range strings.Split(header, ",") {
+		directive = strings.TrimSpace(directive)
+		if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+			if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return 0
+}
+
+// jitter spreads d by ±fraction
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+	delta := (rand.Float64()*2 - 1) * fraction * float64(d)
+	return d + time.Duration(delta)
+}
+
+// JSONWebKey is a single key from a JWKS document
+type JSONWebKey struct {
+	Kid string   `json:"kid"`
+	Kty string   `json:"kty"`
+	Alg string   `json:"alg,omitempty"`
+	Use string   `json:"use,omitempty"`
+	N   string   `json:"n,omitempty"`
+	E   string   `json:"e,omitempty"`
+	Crv string   `json:"crv,omitempty"`
+	X   string   `json:"x,omitempty"`
+	Y   string   `json:"y,omitempty"`
+	X5c []string `json:"x5c,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Key returns the key with the given kid
+func (j *JWKS) Key(kid string) (*JSONWebKey, bool) {
+	for i := range j.Keys {
+		if j.Keys[i].Kid == kid {
+			return &j.Keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// PublicKey decodes an RSA or EC (P-256, P-384, P-521) key for signature verification
+func (k *JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("invalid EC coordinates")
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// OIDCConfiguration is the subset of an OpenID Connect discovery document the service uses
+type OIDCConfiguration struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserinfoEndpoint      string   `json:"userinfo_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	ScopesSupported       []string `json:"scopes_supported,omitempty"`
+}
+
+// ParseJWKS parses a JWKS document; an empty key set is rejected so a bad response can't wipe cached keys
+func ParseJWKS(body []byte) (interface{}, error) {
+	var jwks JWKS
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return nil, err
+	}
+	if len(jwks.Keys) == 0 {
+		return nil, errors.New("key set is empty")
+	}
+	return &jwks, nil
+}
+
+// ParseOIDCConfiguration parses an OpenID Connect discovery document
+func ParseOIDCConfiguration(body []byte) (interface{}, error) {
+	var oidc OIDCConfiguration
+	if err := json.Unmarshal(body, &oidc); err != nil {
+		return nil, err
+	}
+	if oidc.Issuer == "" || oidc.AuthorizationEndpoint == "" {
+		return nil, errors.New("issuer and authorization_endpoint are required")
+	}
+	return &oidc, nil
+}
@@ -0,0 +1,278 @@
+// Package emaildomains flags email addresses at registration: domains of disposable mailbox services, and
+// domains that look like a typo of a common provider (gamil.com for gmail.com). The lists come from
+// [email_domains] and can be replaced at runtime through the admin endpoint on every replica
+package emaildomains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// listsKey holds the lists set through the admin endpoint, shared by every replica
+const listsKey = "email_domains:lists"
+
+// Sources of the current lists
+const (
+	SourceConfig = "config" // [email_domains] lists, or the built-in ones
+	SourceAdmin  = "admin"  // Replaced through the admin endpoint
+)
+
+// Lists are the domains addresses are checked against
+type Lists struct {
+	Disposable []string          `json:"disposable_domains"`
+	Popular    []string          `json:"popular_domains"` // Typos are corrected to these
+	Typos      map[string]string `json:"typos"`           // Known misspellings and their correction
+	Source     string            `json:"source"`
+	UpdatedAt  *time.Time        `json:"updated_at,omitempty"`
+	UpdatedBy  string            `json:"updated_by,omitempty"` // Admin user ID for SourceAdmin
+}
+
+// Result is what Check found about an address
+type Result struct {
+	Disposable bool
+	Suggestion string // Corrected address when the domain looks like a typo
+}
+
+// Checker checks addresses against the current lists; admin changes are stored in Redis and picked up
+// by every replica within email_domains.sync_interval
+type Checker struct {
+	redis  *redis.Client
+	config config.EmailDomainsConfig
+
+	mu         sync.RWMutex
+	lists      Lists
+	disposable map[string]bool
+}
+
+// NewChecker creates the checker with the lists of cfg until Start loads those set by admins
+func NewChecker(client *redis.Client, cfg config.EmailDomainsConfig) *Checker {
+	c := &Checker{redis: client, config: cfg}
+	c.apply(c.configLists())
+	return c
+}
+
+// Check reports whether the domain of email is disposable or a likely typo; a nil or disabled checker
+// finds nothing. Subdomains of a disposable domain are disposable too
+func (c *Checker) Check(email string) Result {
+	if c == nil || !c.config.Enabled {
+		return Result{}
+	}
+	local, domain, ok := splitAddress(email)
+	if !ok {
+		return Result{}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for parent := domain; parent != ""; {
+		if c.disposable[parent] {
+			return Result{Disposable: true}
+		}
+		_, parent, _ = strings.Cut(parent, ".")
+	}
+	if suggestion := c.correct(domain); suggestion != "" {
+		return Result{Suggestion: local + "@" + suggestion}
+	}
+	return Result{}
+}
+
+// correct returns the popular domain that domain is a likely typo of, or ""
+func (c *Checker) correct(domain string) string {
+	if corrected, ok := c.lists.Typos[domain]; ok {
+		return corrected
+	}
+	best, bestDistance := "", c.config.MaxTypoDistance+1
+	for _, popular := range c.lists.Popular {
+		if popular == domain {
+			return ""
+		}
+		if distance := editDistance(domain, popular); distance < bestDistance {
+			best, bestDistance = popular, distance
+		}
+	}
+	return best
+}
+
+// Current returns the lists in use
+func (c *Checker) Current() Lists {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lists
+}
+
+// Set replaces the lists on every replica
+func (c *Checker) Set(ctx context.Context, lists Lists, changedBy string) (Lists, error) {
+	now := time.Now()
+	lists = normalize(lists)
+	lists.Source, lists.UpdatedAt, lists.UpdatedBy = SourceAdmin, &now, changedBy
+
+	data, err := json.Marshal(lists)
+	if err != nil {
+		return c.Current(), err
+	}
+	if err := c.redis.Set(ctx, listsKey, data, 0).Err(); err != nil {
+		return c.Current(), fmt.Errorf("failed to store email domain lists: %w", err)
+	}
+	c.apply(lists)
+	return lists, nil
+}
+
+// Reset returns every replica to the lists of [email_domains]
+func (c *Checker) Reset(ctx context.Context) (Lists, error) {
+	if err := c.redis.Del(ctx, listsKey).Err(); err != nil {
+		return c.Current(), fmt.Errorf("failed to remove email domain lists: %w", err)
+	}
+	lists := c.configLists()
+	c.apply(lists)
+	return lists, nil
+}
+
+// Start loads the lists set by admins and then re-reads them every email_domains.sync_interval until ctx
+// is cancelled. When Redis is unavailable the last known lists are kept
+func (c *Checker) Start(ctx context.Context) {
+	if !c.config.Enabled {
+		return
+	}
+
+	c.sync(ctx)
+	go func() {
+		ticker := time.NewTicker(c.config.SyncInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.sync(ctx)
+			}
+		}
+	}()
+}
+
+// sync adopts the lists stored in Redis, or the configured ones when none are
+func (c *Checker) sync(ctx context.Context) {
+	data, err := c.redis.Get(ctx, listsKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		if c.Current().Source != SourceConfig {
+			c.apply(c.configLists())
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to read email domain lists: %v", err)
+		return
+	}
+
+	var lists Lists
+	if err := json.Unmarshal(data, &lists); err != nil {
+		log.Printf("⚠️  Ignoring malformed email domain lists: %v", err)
+		return
+	}
+	c.apply(lists)
+}
+
+func (c *Checker) apply(lists Lists) {
+	disposable := make(map[string]bool, len(lists.Disposable))
+	for _, domain := range lists.Disposable {
+		disposable[domain] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lists = lists
+	c.disposable = disposable
+}
+
+func (c *Checker) configLists() Lists {
+	lists := normalize(Lists{
+		Disposable: c.config.DisposableDomains,
+		Popular:    c.config.PopularDomains,
+		Typos:      c.config.Typos,
+	})
+	lists.Source = SourceConfig
+	return lists
+}
+
+// normalize lowercases the domains and drops duplicates, sorting the disposable list
+func normalize(lists Lists) Lists {
+	seen := make(map[string]bool)
+	unique := func(domains []string) []string {
+		clear(seen)
+		out := make([]string, 0, len(domains))
+		for _, domain := range domains {
+			domain = normalizeDomain(domain)
+			if domain != "" && !seen[domain] {
+				seen[domain] = true
+				out = append(out, domain)
+			}
+		}
+		return out
+	}
+
+	typos := make(map[string]string, len(lists.Typos))
+	for typo, corrected := range lists.Typos {
+		if typo, corrected = normalizeDomain(typo), normalizeDomain(corrected); typo != "" && corrected != "" {
+			typos[typo] = corrected
+		}
+	}
+	disposable := unique(lists.Disposable)
+	sort.Strings(disposable)
+	return Lists{Disposable: disposable, Popular: unique(lists.Popular), Typos: typos}
+}
+
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// splitAddress returns the local part and the normalized domain of email
+func splitAddress(email string) (string, string, bool) {
+	address, err := mail.ParseAddress(email)
+	if err != nil {
+		return "", "", false
+	}
+	at := strings.LastIndexByte(address.Address, '@')
+	if at < 0 {
+		return "", "", false
+	}
+	return address.Address[:at], normalizeDomain(address.Address[at+1:]), true
+}
+
+// editDistance is the optimal string alignment distance: insertions, deletions, substitutions and
+// swaps of adjacent characters (the usual typing mistakes) each count 1
+func editDistance(a, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,231 @@
+// Package feeds renders account activity for external readers: RSS 2.0, Atom 1.0 and iCalendar
+package feeds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Feed kinds served at /api/v1/auth/feeds/:token/
+const (
+	KindActivities    = "activities"
+	KindNotifications = "notifications"
+	KindSecurity      = "security"
+)
+
+// Content types of the rendered formats
+const (
+	ContentTypeRSS  = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom = "application/atom+xml; charset=utf-8"
+	ContentTypeICal = "text/calendar; charset=utf-8"
+)
+
+// Feed is a format-independent list of entries, newest first
+type Feed struct {
+	ID          string // Stable URN identifying the feed (Atom id, iCalendar PRODID suffix)
+	Title       string
+	Description string
+	Link        string // Web page the feed belongs to; optional
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item is one feed entry
+type Item struct {
+	ID        string // Stable URN, e.g. urn:uuid:<activity id>
+	Title     string
+	Summary   string
+	Link      string // Optional
+	Category  string
+	Published time.Time
+}
+
+// RSS renders the feed as RSS 2.0
+func RSS(feed *Feed) ([]byte, error) {
+	type guid struct {
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	}
+	type item struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link,omitempty"`
+		Description string `xml:"description,omitempty"`
+		Category    string `xml:"category,omitempty"`
+		GUID        guid   `xml:"guid"`
+		PubDate     string `xml:"pubDate"`
+	}
+	type channel struct {
+		Title         string `xml:"title"`
+		Link          string `xml:"link"`
+		Description   string `xml:"description"`
+		LastBuildDate string `xml:"lastBuildDate"`
+		Items         []item `xml:"item"`
+	}
+	type rss struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel channel  `xml:"channel"`
+	}
+
+	doc := rss{Version: "2.0", Channel: channel{
+		Title:         feed.Title,
+		Link:          feed.Link,
+		Description:   feed.Description,
+		LastBuildDate: feed.Updated.UTC().Format(time.RFC1123Z),
+	}}
+	for _, it := range feed.Items {
+		doc.Channel.Items = append(doc.Channel.Items, item{
+			Title:       it.Title,
+			Link:        it.Link,
+			Description: it.Summary,
+			Category:    it.Category,
+			GUID:        guid{Value: it.ID},
+			PubDate:     it.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return marshalXML(doc)
+}
+
+// Atom renders the feed as Atom 1.0
+func Atom(feed *Feed) ([]byte, error) {
+	type link struct {
+		Href string `xml:"href,attr"`
+	}
+	type category struct {
+		Term string `xml:"term,attr"`
+	}
+	type entry struct {
+		ID        string    `xml:"id"`
+		Title     string    `xml:"title"`
+		Updated   string    `xml:"updated"`
+		Published string    `xml:"published"`
+		Summary   string    `xml:"summary,omitempty"`
+		Link      *link     `xml:"link,omitempty"`
+		Category  *category `xml:"category,omitempty"`
+	}
+	type author struct {
+		Name string `xml:"name"`
+	}
+	type atom struct {
+		XMLName  xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID       string   `xml:"id"`
+		Title    string   `xml:"title"`
+		Subtitle string   `xml:"subtitle,omitempty"`
+		Updated  string   `xml:"updated"`
+		Link     *link    `xml:"link,omitempty"`
+		Author   author   `xml:"author"`
+		Entries  []entry  `xml:"entry"`
+	}
+
+	doc := atom{
+		ID:       feed.ID,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  feed.Updated.UTC().Format(time.RFC3339),
+		Author:   author{Name: "Auth Service"},
+	}
+	if feed.Link != "" {
+		doc.Link = &link{Href: feed.Link}
+	}
+	for _, it := range feed.Items {
+		e := entry{
+			ID:        it.ID,
+			Title:     it.Title,
+			Updated:   it.Published.UTC().Format(time.RFC3339),
+			Published: it.Published.UTC().Format(time.RFC3339),
+			Summary:   it.Summary,
+		}
+		if it.Link != "" {
+			e.Link = &link{Href: it.Link}
+		}
+		if it.Category != "" {
+			e.Category = &category{Term: it.Category}
+		}
+		doc.Entries = append(doc.Entries, e)
+	}
+	return marshalXML(doc)
+}
+
+// ICal renders the feed as an iCalendar (RFC 5545) with one event per item
+// Each event carries a display alarm so calendar apps surface it as a reminder
+func ICal(feed *Feed) []byte {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldICalLine(line))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//auth-service//" + escapeICal(feed.ID) + "//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeICal(feed.Title))
+	for _, it := range feed.Items {
+		start := it.Published.UTC()
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + escapeICal(it.ID))
+		writeLine("DTSTAMP:" + start.Format(icalTimeFormat))
+		writeLine("DTSTART:" + start.Format(icalTimeFormat))
+		writeLine("DTEND:" + start.Add(15*time.Minute).Format(icalTimeFormat))
+		writeLine("SUMMARY:" + escapeICal(it.Title))
+		if it.Summary != "" {
+			writeLine("DESCRIPTION:" + escapeICal(it.Summary))
+		}
+		if it.Category != "" {
+			writeLine("CATEGORIES:" + escapeICal(it.Category))
+		}
+		if it.Link != "" {
+			writeLine("URL:" + it.Link)
+		}
+		writeLine("BEGIN:VALARM")
+		writeLine("ACTION:DISPLAY")
+		writeLine("TRIGGER:PT0M")
+		writeLine("DESCRIPTION:" + escapeICal(it.Title))
+		writeLine("END:VALARM")
+		writeLine("END:VEVENT")
+	}
+	writeLine("END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// icalTimeFormat is the UTC DATE-TIME form of RFC 5545
+const icalTimeFormat = "20060102T150405Z"
+
+// icalLineLimit is the maximum line length in octets before folding (RFC 5545 section 3.1)
+const icalLineLimit = 75
+
+// escapeICal escapes TEXT values (RFC 5545 section 3.3.11)
+func escapeICal(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// foldICalLine splits lines longer than 75 octets without breaking UTF-8 sequences
+func foldICalLine(line string) string {
+	if len(line) <= icalLineLimit {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > icalLineLimit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+func marshalXML(doc interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render feed: %w", err)
+	}
+	return append([]byte(xml.Header), body...), nil
+}
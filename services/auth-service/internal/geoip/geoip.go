@@ -0,0 +1,168 @@
+// Package geoip resolves client addresses to a country and city, recorded with login attempts and
+// sessions and checked by the country rules of logins and sign-ups. A MaxMind DB file and the MaxMind
+// GeoIP2 web service are built in; other sources plug in as a Resolver
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"auth-service/internal/config"
+)
+
+// Location is where an address is, as far as the resolver knows; fields it doesn't know are empty
+type Location struct {
+	CountryCode string `json:"country_code,omitempty"` // ISO 3166-1 alpha-2, e.g. DE
+	Country     string `json:"country,omitempty"`      // English name, e.g. Germany
+	City        string `json:"city,omitempty"`         // English name, e.g. Berlin
+}
+
+// String describes the location for people, e.g. "Berlin, Germany"; empty when it is unknown
+func (l Location) String() string {
+	country := l.Country
+	if country == "" {
+		country = l.CountryCode
+	}
+	switch {
+	case l.City != "" && country != "":
+		return l.City + ", " + country
+	default:
+		return l.City + country
+	}
+}
+
+// Resolver finds the location of an address. Lookup returns nil without an error when the address
+// isn't known, e.g. private and reserved addresses
+type Resolver interface {
+	Lookup(ctx context.Context, ip net.IP) (*Location, error)
+}
+
+// NewResolver opens the resolver of geoip.provider; it returns nil when the provider is off
+func NewResolver(cfg config.GeoIPConfig) (Resolver, error) {
+	switch cfg.Provider {
+	case config.GeoIPMaxMindDB:
+		db, err := OpenDatabase(cfg.DatabaseFile)
+		if err != nil {
+			return nil, fmt.Errorf("geoip.database_file: %w", err)
+		}
+		return db, nil
+	case config.GeoIPMaxMindWeb:
+		return NewWebService(cfg, nil), nil
+	default:
+		return nil, nil
+	}
+}
+
+// locationOf reads a GeoIP2 City or Country record, from the database or the web service
+// The registered country stands in when the address has no country of its own (e.g. anycast)
+func locationOf(record map[string]interface{}) *Location {
+	country, _ := record["country"].(map[string]interface{})
+	if country == nil {
+		country, _ = record["registered_country"].(map[string]interface{})
+	}
+	city, _ := record["city"].(map[string]interface{})
+
+	location := &Location{City: englishName(city)}
+	if country != nil {
+		location.CountryCode, _ = country["iso_code"].(string)
+		location.Country = englishName(country)
+	}
+	return location
+}
+
+func englishName(place map[string]interface{}) string {
+	names, _ := place["names"].(map[string]interface{})
+	name, _ := names["en"].(string)
+	return name
+}
+
+// Locator locates clients with a Resolver and applies the country rules of geoip. A nil Locator knows
+// no locations and applies no rules
+type Locator struct {
+	resolver   Resolver
+	timeout    time.Duration
+	blocked    []string
+	challenged []string
+
+	found, unknown, failed, refused atomic.Int64
+}
+
+// NewLocator creates the locator; it returns nil without a resolver
+func NewLocator(resolver Resolver, cfg config.GeoIPConfig) *Locator {
+	if resolver == nil {
+		return nil
+	}
+	return &Locator{
+		resolver:   resolver,
+		timeout:    cfg.Timeout,
+		blocked:    upper(cfg.BlockedCountries),
+		challenged: upper(cfg.ChallengeCountries),
+	}
+}
+
+// Locate returns the location of the address ip; it is empty when the address is unknown or the
+// resolver failed, which is logged rather than failing the login
+func (l *Locator) Locate(ctx context.Context, ip string) Location {
+	parsed := net.ParseIP(ip)
+	if l == nil || parsed == nil {
+		return Location{}
+	}
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	location, err := l.resolver.Lookup(ctx, parsed)
+	switch {
+	case err != nil:
+		l.failed.Add(1)
+		log.Printf("⚠️  GeoIP lookup failed: %v", err)
+		return Location{}
+	case location == nil:
+		l.unknown.Add(1)
+		return Location{}
+	default:
+		l.found.Add(1)
+		return *location
+	}
+}
+
+// Blocked reports whether logins and sign-ups from location are refused by geoip.blocked_countries, and
+// counts the refusal
+func (l *Locator) Blocked(location Location) bool {
+	if l == nil || location.CountryCode == "" || !slices.Contains(l.blocked, location.CountryCode) {
+		return false
+	}
+	l.refused.Add(1)
+	return true
+}
+
+// Challenged reports whether logins and sign-ups from location must always solve a CAPTCHA
+func (l *Locator) Challenged(location Location) bool {
+	return l != nil && location.CountryCode != "" && slices.Contains(l.challenged, location.CountryCode)
+}
+
+// WritePrometheus writes this replica's lookup outcomes and refusals in the Prometheus text format
+func (l *Locator) WritePrometheus(w io.Writer) {
+	fmt.Fprintln(w, "# HELP auth_service_geoip_lookups_total Client address lookups, by outcome")
+	fmt.Fprintln(w, "# TYPE auth_service_geoip_lookups_total counter")
+	fmt.Fprintf(w, "auth_service_geoip_lookups_total{result=\"found\"} %d\n", l.found.Load())
+	fmt.Fprintf(w, "auth_service_geoip_lookups_total{result=\"unknown\"} %d\n", l.unknown.Load())
+	fmt.Fprintf(w, "auth_service_geoip_lookups_total{result=\"error\"} %d\n", l.failed.Load())
+	fmt.Fprintln(w, "# HELP auth_service_geoip_blocked_total Logins and sign-ups refused by geoip.blocked_countries")
+	fmt.Fprintln(w, "# TYPE auth_service_geoip_blocked_total counter")
+	fmt.Fprintf(w, "auth_service_geoip_blocked_total %d\n", l.refused.Load())
+}
+
+func upper(codes []string) []string {
+	out := make([]string, 0, len(codes))
+	for _, code := range codes {
+		out = append(out, strings.ToUpper(code))
+	}
+	return out
+}
@@ -0,0 +1,331 @@
+package geoip
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+// metadataMarker precedes the metadata map at the end of a MaxMind DB file
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// dataSectionSeparator is the size of the zeroes between the search tree and the data section
+const dataSectionSeparator = 16
+
+// maxDecodeDepth bounds nested maps and arrays, so a corrupt file can't exhaust the stack
+const maxDecodeDepth = 32
+
+// Data section field types (MaxMind DB format 2.0)
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// Database resolves addresses with a MaxMind DB file (GeoIP2 or GeoLite2 City or Country), read into
+// memory when opened. Replacing the file takes a restart
+type Database struct {
+	tree       []byte
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint // Node of ::/96, where IPv4 addresses start in an IPv6 tree
+	kind       string
+}
+
+// OpenDatabase reads and checks a MaxMind DB file
+func OpenDatabase(path string) (*Database, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseDatabase(raw)
+}
+
+func parseDatabase(raw []byte) (*Database, error) {
+	marker := bytes.LastIndex(raw, metadataMarker)
+	if marker < 0 {
+		return nil, errors.New("not a MaxMind DB file: metadata marker missing")
+	}
+	decoded, _, err := (&decoder{data: raw[marker+len(metadataMarker):]}).decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unreadable MaxMind DB metadata: %w", err)
+	}
+	metadata, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("unreadable MaxMind DB metadata: not a map")
+	}
+
+	db := &Database{
+		nodeCount:  uint(metadataUint(metadata, "node_count")),
+		recordSize: uint(metadataUint(metadata, "record_size")),
+		ipVersion:  uint(metadataUint(metadata, "ip_version")),
+	}
+	db.kind, _ = metadata["database_type"].(string)
+	if db.recordSize != 24 && db.recordSize != 28 && db.recordSize != 32 {
+		return nil, fmt.Errorf("unsupported MaxMind DB record size %d", db.recordSize)
+	}
+	if db.ipVersion != 4 && db.ipVersion != 6 {
+		return nil, fmt.Errorf("unsupported MaxMind DB IP version %d", db.ipVersion)
+	}
+	treeSize := db.nodeCount * db.recordSize / 4
+	if treeSize+dataSectionSeparator > uint(marker) {
+		return nil, errors.New("corrupt MaxMind DB: search tree larger than the file")
+	}
+	db.tree = raw[:treeSize]
+	db.data = raw[treeSize+dataSectionSeparator : marker]
+
+	if db.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < db.nodeCount; i++ {
+			node = db.record(node, 0)
+		}
+		db.ipv4Start = node
+	}
+	return db, nil
+}
+
+// Type returns the database_type of the file, e.g. GeoLite2-City
+func (db *Database) Type() string {
+	return db.kind
+}
+
+// Lookup finds the location of ip; it returns nil when the database has no entry for it
+func (db *Database) Lookup(_ context.Context, ip net.IP) (*Location, error) {
+	record, err := db.lookup(ip)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	return locationOf(record), nil
+}
+
+// lookup walks the search tree along the bits of ip and decodes the record it ends at
+func (db *Database) lookup(ip net.IP) (map[string]interface{}, error) {
+	node := uint(0)
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+		node = db.ipv4Start
+	} else if db.ipVersion == 4 {
+		return nil, nil // An IPv4 database knows no IPv6 address
+	}
+
+	for i := 0; i < len(ip)*8 && node < db.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-uint(i%8))) & 1
+		node = db.record(node, bit)
+	}
+	switch {
+	case node == db.nodeCount:
+		return nil, nil
+	case node < db.nodeCount:
+		return nil, errors.New("corrupt MaxMind DB: search tree deeper than the address")
+	}
+
+	offset := node - db.nodeCount - dataSectionSeparator
+	if offset >= uint(len(db.data)) {
+		return nil, errors.New("corrupt MaxMind DB: record outside the data section")
+	}
+	value, _, err := (&decoder{data: db.data}).decode(offset, 0)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt MaxMind DB record: %w", err)
+	}
+	record, _ := value.(map[string]interface{})
+	return record, nil
+}
+
+// record reads the left (bit 0) or right (bit 1) record of a search tree node
+func (db *Database) record(node, bit uint) uint {
+	switch db.recordSize {
+	case 24:
+		b := db.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := db.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(db.tree[node*8+bit*4:]))
+	}
+}
+
+// decoder reads values of a MaxMind DB data section; pointers are offsets into data
+type decoder struct {
+	data []byte
+}
+
+// decode reads the value at offset and returns it with the offset after it
+func (d *decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, errors.New("values nested too deeply")
+	}
+	kind, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if kind == typePointer {
+		target, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target, depth+1)
+		return value, next, err
+	}
+
+	switch kind {
+	case typeMap:
+		values := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var key, value interface{}
+			if key, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			values[name] = value
+		}
+		return values, offset, nil
+	case typeArray:
+		values := make([]interface{}, 0, min(size, 1024))
+		for i := uint(0); i < size; i++ {
+			var value interface{}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, value)
+		}
+		return values, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(d.data)) {
+		return nil, 0, errors.New("value runs past the end of the data")
+	}
+	raw, next := d.data[offset:offset+size], offset+size
+	switch kind {
+	case typeString:
+		return string(raw), next, nil
+	case typeBytes, typeUint128:
+		return raw, next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errors.New("double is not 8 bytes")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errors.New("float is not 4 bytes")
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), next, nil
+	case typeUint16, typeUint32, typeUint64, typeInt32:
+		if size > 8 {
+			return nil, 0, errors.New("integer wider than 8 bytes")
+		}
+		var n uint64
+		for _, b := range raw {
+			n = n<<8 | uint64(b)
+		}
+		if kind == typeInt32 {
+			return int64(int32(uint32(n))), next, nil
+		}
+		return n, next, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported data type %d", kind)
+	}
+}
+
+// control reads the control byte(s) at offset: the value's type and its size, or the pointer size bits
+func (d *decoder) control(offset uint) (kind, size, next uint, err error) {
+	if offset >= uint(len(d.data)) {
+		return 0, 0, 0, errors.New("offset outside the data")
+	}
+	ctrl := d.data[offset]
+	offset++
+	kind = uint(ctrl >> 5)
+	if kind == typeExtended {
+		if offset >= uint(len(d.data)) {
+			return 0, 0, 0, errors.New("extended type runs past the end of the data")
+		}
+		kind = 7 + uint(d.data[offset])
+		offset++
+	}
+	if kind == typePointer {
+		return kind, uint(ctrl & 0x1f), offset, nil
+	}
+
+	size = uint(ctrl & 0x1f)
+	if size < 29 {
+		return kind, size, offset, nil
+	}
+	extra := size - 28
+	if offset+extra > uint(len(d.data)) {
+		return 0, 0, 0, errors.New("size runs past the end of the data")
+	}
+	var n uint
+	for _, b := range d.data[offset : offset+extra] {
+		n = n<<8 | uint(b)
+	}
+	switch extra {
+	case 1:
+		size = 29 + n
+	case 2:
+		size = 285 + n
+	default:
+		size = 65821 + n
+	}
+	return kind, size, offset + extra, nil
+}
+
+// pointer resolves a pointer whose size bits are bits; it returns the target and the offset after it
+func (d *decoder) pointer(bits, offset uint) (target, next uint, err error) {
+	length := (bits>>3)&0x3 + 1
+	if offset+length > uint(len(d.data)) {
+		return 0, 0, errors.New("pointer runs past the end of the data")
+	}
+	var n uint
+	for _, b := range d.data[offset : offset+length] {
+		n = n<<8 | uint(b)
+	}
+	switch length {
+	case 1:
+		target = (bits&0x7)<<8 | n
+	case 2:
+		target = ((bits&0x7)<<16 | n) + 2048
+	case 3:
+		target = ((bits&0x7)<<24 | n) + 526336
+	default:
+		target = n
+	}
+	return target, offset + length, nil
+}
+
+func metadataUint(metadata map[string]interface{}, key string) uint64 {
+	n, _ := metadata[key].(uint64)
+	return n
+}
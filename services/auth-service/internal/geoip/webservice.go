@@ -0,0 +1,125 @@
+package geoip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+)
+
+// defaultWebServiceURL is the GeoIP2 City web service; the address is appended to it
+const defaultWebServiceURL = "https://geoip.maxmind.com/geoip/v2.1/city"
+
+// maxCachedAnswers bounds the web service answers kept in memory; the cache is emptied when it fills up
+const maxCachedAnswers = 10000
+
+// notFoundCodes are web service errors meaning the address has no location
+var notFoundCodes = []string{"IP_ADDRESS_NOT_FOUND", "IP_ADDRESS_RESERVED"}
+
+// cachedAnswer is a web service answer, kept until expires so repeated logins don't pay for lookups
+type cachedAnswer struct {
+	location *Location
+	expires  time.Time
+}
+
+// WebService resolves addresses with the MaxMind GeoIP2 web service (City, Country or Insights)
+type WebService struct {
+	client     *http.Client
+	url        string
+	accountID  string
+	licenseKey string
+	cacheTTL   time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedAnswer
+}
+
+// NewWebService creates the web service resolver of geoip; a nil client uses one with geoip.timeout
+func NewWebService(cfg config.GeoIPConfig, client *http.Client) *WebService {
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+	endpoint := cfg.URL
+	if endpoint == "" {
+		endpoint = defaultWebServiceURL
+	}
+	return &WebService{
+		client:     client,
+		url:        strings.TrimRight(endpoint, "/"),
+		accountID:  cfg.AccountID,
+		licenseKey: cfg.LicenseKey,
+		cacheTTL:   cfg.CacheTTL,
+		cache:      make(map[string]cachedAnswer),
+	}
+}
+
+// webServiceError is the body of a web service error response
+type webServiceError struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// Lookup asks the web service for the location of ip, or answers from the cache
+func (w *WebService) Lookup(ctx context.Context, ip net.IP) (*Location, error) {
+	key := ip.String()
+	now := time.Now()
+	w.mu.Lock()
+	cached, ok := w.cache[key]
+	w.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.location, nil
+	}
+
+	location, err := w.fetch(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if w.cacheTTL > 0 {
+		w.mu.Lock()
+		if len(w.cache) >= maxCachedAnswers {
+			clear(w.cache)
+		}
+		w.cache[key] = cachedAnswer{location: location, expires: now.Add(w.cacheTTL)}
+		w.mu.Unlock()
+	}
+	return location, nil
+}
+
+func (w *WebService) fetch(ctx context.Context, ip string) (*Location, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.url+"/"+ip, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(w.accountID, w.licenseKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("geoip web service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body := io.LimitReader(resp.Body, 1<<20)
+
+	if resp.StatusCode != http.StatusOK {
+		var answer webServiceError
+		json.NewDecoder(body).Decode(&answer)
+		if slices.Contains(notFoundCodes, answer.Code) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("geoip web service returned %s: %s %s", resp.Status, answer.Code, answer.Error)
+	}
+
+	var record map[string]interface{}
+	if err := json.NewDecoder(body).Decode(&record); err != nil {
+		return nil, fmt.Errorf("unreadable geoip web service response: %w", err)
+	}
+	return locationOf(record), nil
+}
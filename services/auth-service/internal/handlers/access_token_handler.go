@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"auth-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreatePersonalAccessToken - Personal Access Token API
+// @Summary Create a personal access token
+// @Description Mints a bearer token for scripts and integrations; the token is shown only in this response
+// @Tags Personal Access Tokens
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/auth/tokens [post]
+func (h *AuthHandler) CreatePersonalAccessToken(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	response, err := h.authService.CreatePersonalAccessToken(userID, &req, clientInfo(c))
+	if err != nil {
+		localMiddleware.WriteError(c, accessTokenErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to create personal access token",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Personal access token created; copy it now, it won't be shown again",
+		Data:    response,
+	})
+}
+
+// ListPersonalAccessTokens - Personal Access Token API
+// @Summary List the user's personal access tokens, newest first
+// @Description Revoked tokens are omitted; expired ones are listed until revoked
+// @Tags Personal Access Tokens
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/tokens [get]
+func (h *AuthHandler) ListPersonalAccessTokens(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.authService.ListPersonalAccessTokens(userID)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list personal access tokens",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Personal access tokens retrieved",
+		Data:    tokens,
+	})
+}
+
+// RevokePersonalAccessToken - Personal Access Token API
+// @Summary Revoke a personal access token
+// @Tags Personal Access Tokens
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/tokens/{tokenId} [delete]
+func (h *AuthHandler) RevokePersonalAccessToken(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	tokenID, err := uuid.Parse(c.Param("tokenId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid token ID",
+			Message: "Token ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.authService.RevokePersonalAccessToken(userID, tokenID, clientInfo(c)); err != nil {
+		localMiddleware.WriteError(c, accessTokenErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to revoke personal access token",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Personal access token revoked",
+	})
+}
+
+// accessTokenErrorStatus maps personal access token service errors to HTTP statuses
+func accessTokenErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAccessTokensDisabled), errors.Is(err, repositories.ErrAccessTokenNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAccessTokenAdminScope):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAccessTokenLimit):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrAccessTokenExpiry):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
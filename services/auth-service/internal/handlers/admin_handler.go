@@ -0,0 +1,768 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultSessionSearchLimit is the page size when the limit query parameter is omitted
+const defaultSessionSearchLimit = 100
+
+// AdminHandler serves cross-user administrative operations (admin role required)
+type AdminHandler struct {
+	authService services.AuthService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(authService services.AuthService) *AdminHandler {
+	return &AdminHandler{authService: authService}
+}
+
+// SearchSessions - Admin Session Search API
+// @Summary Search sessions across all users
+// @Description Filter by user, IP address or CIDR range, user agent substring and creation window
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/sessions [get]
+func (h *AdminHandler) SearchSessions(c *gin.Context) {
+	var req models.AdminSessionSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	filter, err := parseSessionFilter(req.SessionFilterRequest)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+	filter.IncludeRevoked = req.IncludeRevoked
+
+	if req.Limit == 0 {
+		req.Limit = defaultSessionSearchLimit
+	}
+
+	sessions, total, err := h.authService.SearchSessions(filter, req.Limit, req.Offset)
+	if err != nil {
+		localMiddleware.WriteError(c, sessionFilterErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to search sessions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AdminSessionSearchResponse{
+		Sessions: sessions,
+		Total:    total,
+		Limit:    req.Limit,
+		Offset:   req.Offset,
+	})
+}
+
+// RevokeSessions - Admin Bulk Session Revocation API
+// @Summary Revoke every active session matching the filters
+// @Description Marks sessions revoked, deletes their refresh tokens and blacklists their access tokens
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/sessions/revoke [post]
+func (h *AdminHandler) RevokeSessions(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminRevokeSessionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	filter, err := parseSessionFilter(req.SessionFilterRequest)
+	if err == nil && filter.IsEmpty() {
+		err = errors.New("at least one session filter is required")
+	}
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.authService.RevokeSessions(adminID, filter, req.Reason, req.DryRun)
+	if err != nil {
+		localMiddleware.WriteError(c, sessionFilterErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to revoke sessions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message := "Sessions revoked"
+	if req.DryRun {
+		message = "Dry run: no sessions were revoked"
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: message,
+		Data:    resp,
+	})
+}
+
+// TwoFactorCompliance - Admin Two-Factor Compliance API
+// @Summary Report two-factor enrollment of the roles the policy applies to
+// @Description Counts enrolled, pending and overdue users per required role and lists non-compliant users
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/two-factor/compliance [get]
+func (h *AdminHandler) TwoFactorCompliance(c *gin.Context) {
+	report, err := h.authService.TwoFactorCompliance()
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to build two-factor compliance report",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// DisableTwoFactor - Admin Two-Factor Disable API
+// @Summary Disable two-factor authentication for a user
+// @Description Administrator approval path for users who may not disable two-factor authentication themselves
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/users/{userId}/two-factor/disable [post]
+func (h *AdminHandler) DisableTwoFactor(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req models.AdminDisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(adminID, userID, req.Reason); err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not enabled") {
+			statusCode = http.StatusConflict
+		}
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
+			Error:   "Failed to disable two-factor authentication",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+// InviteUser - Admin Invite User API
+// @Summary Create an account without a password and email an activation link
+// @Description The account stays inactive until the invitee sets a password with the emailed one-time link
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/users/invite [post]
+func (h *AdminHandler) InviteUser(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminInviteUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	resp, err := h.authService.InviteUser(adminID, &req)
+	if err != nil {
+		localMiddleware.WriteError(c, invitationErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to invite user",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: invitationMessage(resp),
+		Data:    resp,
+	})
+}
+
+// ResendInvitation - Admin Resend Invitation API
+// @Summary Email a new activation link to an invited user
+// @Description Links sent earlier stop working; use this when an invitation expired or was lost
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/users/{userId}/invite/resend [post]
+func (h *AdminHandler) ResendInvitation(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	resp, err := h.authService.ResendInvitation(adminID, userID)
+	if err != nil {
+		localMiddleware.WriteError(c, invitationErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to resend invitation",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: invitationMessage(resp),
+		Data:    resp,
+	})
+}
+
+// invitationErrorStatus maps invitation errors to HTTP statuses
+func invitationErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "already exists"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "no pending invitation"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "not configured"):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func invitationMessage(resp *models.AdminInvitationResponse) string {
+	if !resp.EmailSent {
+		return "Invitation created but the email could not be sent; resend it once email delivery works"
+	}
+	return "Invitation sent"
+}
+
+// ListPendingRegistrations - Admin Registration Queue API
+// @Summary List sign-ups awaiting approval
+// @Description Only populated when registration.mode is "approval"; oldest sign-ups come first
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/registrations [get]
+func (h *AdminHandler) ListPendingRegistrations(c *gin.Context) {
+	var req models.AdminRegistrationQueueRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultSessionSearchLimit
+	}
+
+	resp, err := h.authService.ListPendingRegistrations(req.Limit, req.Offset)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list pending registrations",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ApproveRegistration - Admin Approve Registration API
+// @Summary Activate a sign-up waiting in the approval queue
+// @Description The applicant is emailed that they can now sign in
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/registrations/{userId}/approve [post]
+func (h *AdminHandler) ApproveRegistration(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	resp, err := h.authService.ApproveRegistration(adminID, userID)
+	if err != nil {
+		localMiddleware.WriteError(c, registrationErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to approve registration",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: registrationReviewMessage(resp),
+		Data:    resp,
+	})
+}
+
+// RejectRegistration - Admin Reject Registration API
+// @Summary Reject a sign-up waiting in the approval queue
+// @Description The account stays inactive; the optional reason is included in the email to the applicant
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/registrations/{userId}/reject [post]
+func (h *AdminHandler) RejectRegistration(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	// The body is optional; rejecting without a reason sends none
+	var req models.AdminRejectRegistrationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			localMiddleware.WriteBindingError(c, err)
+			return
+		}
+	}
+
+	resp, err := h.authService.RejectRegistration(adminID, userID, strings.TrimSpace(req.Reason))
+	if err != nil {
+		localMiddleware.WriteError(c, registrationErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to reject registration",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: registrationReviewMessage(resp),
+		Data:    resp,
+	})
+}
+
+// registrationErrorStatus maps registration review errors to HTTP statuses
+func registrationErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "no pending registration") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func registrationReviewMessage(resp *models.AdminRegistrationReviewResponse) string {
+	message := "Registration " + resp.Status
+	if !resp.EmailSent {
+		message += " but the applicant could not be notified by email"
+	}
+	return message
+}
+
+// GrantRole - Admin Role Grant API
+// @Summary Give a user an extra role for a bounded time (just-in-time elevated access)
+// @Description The user's next login or token refresh carries the role; tokens never outlive the grant
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/users/{userId}/role-grants [post]
+func (h *AdminHandler) GrantRole(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req models.AdminGrantRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	grant, err := h.authService.GrantRole(adminID, userID, &req)
+	if err != nil {
+		localMiddleware.WriteError(c, roleGrantErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to grant role",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Role granted until " + grant.ExpiresAt.UTC().Format(time.RFC3339),
+		Data:    grant,
+	})
+}
+
+// ListRoleGrants - Admin Role Grant Listing API
+// @Summary List role grants, newest first
+// @Description Filter by user and to grants currently in effect; ended grants form the audit trail
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/role-grants [get]
+func (h *AdminHandler) ListRoleGrants(c *gin.Context) {
+	var req models.AdminRoleGrantListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	userID := uuid.Nil
+	if req.UserID != "" {
+		var err error
+		if userID, err = uuid.Parse(req.UserID); err != nil {
+			localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid user ID",
+				Message: "User ID must be a valid UUID",
+			})
+			return
+		}
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultSessionSearchLimit
+	}
+
+	resp, err := h.authService.ListRoleGrants(userID, req.Active, req.Limit, req.Offset)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list role grants",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeRoleGrant - Admin Role Grant Revocation API
+// @Summary End a role grant before it expires
+// @Description Access tokens issued before the revocation are rejected; the holder has to refresh them
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/role-grants/{grantId}/revoke [post]
+func (h *AdminHandler) RevokeRoleGrant(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	grantID, err := uuid.Parse(c.Param("grantId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid grant ID",
+			Message: "Grant ID must be a valid UUID",
+		})
+		return
+	}
+
+	// The body is optional; revoking without a reason records none
+	var req models.AdminRevokeRoleGrantRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			localMiddleware.WriteBindingError(c, err)
+			return
+		}
+	}
+
+	grant, err := h.authService.RevokeRoleGrant(adminID, grantID, strings.TrimSpace(req.Reason))
+	if err != nil {
+		localMiddleware.WriteError(c, roleGrantErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to revoke role grant",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Role grant revoked",
+		Data:    grant,
+	})
+}
+
+// CreateHoneypot - Admin Honeypot API
+// @Summary Plant a honeypot account or generate a canary API key
+// @Description Any later login as the account or use of the key raises a high-severity alert and blocks the caller's IP; the key is shown only once
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/honeypots [post]
+func (h *AdminHandler) CreateHoneypot(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminCreateHoneypotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	resp, err := h.authService.CreateHoneypot(adminID, &req)
+	if err != nil {
+		localMiddleware.WriteError(c, honeypotErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to create honeypot",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Honeypot created",
+		Data:    resp,
+	})
+}
+
+// ListHoneypots - Admin Honeypot Listing API
+// @Summary List honeypots, most recently triggered first
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/honeypots [get]
+func (h *AdminHandler) ListHoneypots(c *gin.Context) {
+	var req models.AdminHoneypotListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	honeypots, err := h.authService.ListHoneypots(req.IncludeDisabled)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list honeypots",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Honeypots retrieved successfully",
+		Data:    honeypots,
+	})
+}
+
+// DisableHoneypot - Admin Honeypot Removal API
+// @Summary Stop a honeypot from alerting
+// @Description The honeypot and its triggers are kept as the audit trail
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/honeypots/{honeypotId} [delete]
+func (h *AdminHandler) DisableHoneypot(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	honeypotID, ok := honeypotIDParam(c)
+	if !ok {
+		return
+	}
+
+	honeypot, err := h.authService.DisableHoneypot(adminID, honeypotID)
+	if err != nil {
+		localMiddleware.WriteError(c, honeypotErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to disable honeypot",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Honeypot disabled",
+		Data:    honeypot,
+	})
+}
+
+// ListHoneypotTriggers - Admin Honeypot Audit API
+// @Summary List the uses of a honeypot, newest first
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/honeypots/{honeypotId}/triggers [get]
+func (h *AdminHandler) ListHoneypotTriggers(c *gin.Context) {
+	honeypotID, ok := honeypotIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminHoneypotTriggerListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSessionSearchLimit
+	}
+
+	resp, err := h.authService.ListHoneypotTriggers(honeypotID, req.Limit, req.Offset)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list honeypot triggers",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Honeypot triggers retrieved successfully",
+		Data:    resp,
+	})
+}
+
+// honeypotIDParam parses the honeypotId path parameter, responding 400 when it is malformed
+func honeypotIDParam(c *gin.Context) (uuid.UUID, bool) {
+	honeypotID, err := uuid.Parse(c.Param("honeypotId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid honeypot ID",
+			Message: "Honeypot ID must be a valid UUID",
+		})
+		return uuid.Nil, false
+	}
+	return honeypotID, true
+}
+
+// honeypotErrorStatus maps honeypot errors to HTTP statuses
+func honeypotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrHoneypotEmailInUse), errors.Is(err, services.ErrHoneypotExists):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "no active honeypot"):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// roleGrantErrorStatus maps role grant errors to HTTP statuses
+func roleGrantErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidGrantDuration):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrSelfRoleGrant):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrRoleAlreadyHeld):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "not found"), strings.Contains(err.Error(), "no active role grant"):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// sessionFilterErrorStatus reports filters the configured IP storage can't answer as client errors
+func sessionFilterErrorStatus(err error) int {
+	if errors.Is(err, services.ErrIPRangeUnavailable) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// parseSessionFilter validates the raw filter parameters shared by search and revoke
+func parseSessionFilter(req models.SessionFilterRequest) (models.SessionFilter, error) {
+	var filter models.SessionFilter
+
+	if req.UserID != "" {
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return filter, fmt.Errorf("user_id must be a UUID")
+		}
+		filter.UserID = &userID
+	}
+
+	if ip := strings.TrimSpace(req.IP); ip != "" {
+		if _, _, err := net.ParseCIDR(ip); err != nil && net.ParseIP(ip) == nil {
+			return filter, fmt.Errorf("ip must be an IP address or CIDR range")
+		}
+		filter.IPRange = ip
+	}
+
+	filter.UserAgent = strings.TrimSpace(req.UserAgent)
+
+	for _, bound := range []struct {
+		name  string
+		value string
+		dest  **time.Time
+	}{
+		{"created_after", req.CreatedAfter, &filter.CreatedAfter},
+		{"created_before", req.CreatedBefore, &filter.CreatedBefore},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", bound.name)
+		}
+		*bound.dest = &t
+	}
+
+	return filter, nil
+}
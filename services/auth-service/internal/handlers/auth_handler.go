@@ -1,23 +1,40 @@
 package handlers
 
 import (
+	"auth-service/internal/captcha"
+	"auth-service/internal/config"
+	"auth-service/internal/feeds"
+	localMiddleware "auth-service/internal/middleware"
 	"auth-service/internal/models"
+	"auth-service/internal/repositories"
 	"auth-service/internal/services"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	sharedMiddleware "shared/middleware"
+	"shared/requestid"
+)
+
+// Feed formats served by AuthHandler.Feed
+const (
+	FeedFormatRSS  = "rss"
+	FeedFormatAtom = "atom"
+	FeedFormatICal = "ics"
 )
 
 // AuthHandler handles HTTP authentication requests with comprehensive business logic integration
 type AuthHandler struct {
-	authService   services.AuthService   // Business logic for authentication operations
-	oauth2Service services.OAuth2Service // OAuth2 integration for external providers
+	authService   services.AuthService       // Business logic for authentication operations
+	oauth2Service services.OAuth2Service     // OAuth2 integration for external providers
+	refreshCookie config.RefreshCookieConfig // Cookie transport of refresh tokens for browser clients
+	forwardAuth   *forwardAuthHeaders        // Per-audience headers returned to the gateway by VerifyToken
 }
 
 // NewAuthHandler creates AuthHandler instance with configured service dependencies
@@ -85,81 +102,124 @@ func NewAuthHandler(authService services.AuthService, oauth2Service services.OAu
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request",
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	response, err := h.authService.Register(&req, clientInfo(c))
+	if writeCaptchaError(c, "Registration failed", err) {
+		return
+	}
+	if writePasswordPolicyError(c, "Registration failed", "password", err) {
+		return
+	}
+	var domainErr *services.EmailDomainError
+	if errors.As(err, &domainErr) {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Registration failed",
 			Message: err.Error(),
+			Hints:   domainErr.Hints,
 		})
 		return
 	}
-
-	response, err := h.authService.Register(&req)
 	if err != nil {
 		statusCode := http.StatusBadRequest
 		if strings.Contains(err.Error(), "already exists") {
 			statusCode = http.StatusConflict
+		} else if errors.Is(err, services.ErrCountryBlocked) {
+			statusCode = http.StatusForbidden
 		}
 		
-		c.JSON(statusCode, models.ErrorResponse{
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
 			Error:   "Registration failed",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, response)
+	if response.ApprovalStatus == models.ApprovalPending {
+		c.JSON(http.StatusAccepted, models.SuccessResponse{
+			Message: "Registration received and awaiting approval",
+			Data:    response.User,
+		})
+		return
+	}
+
+	h.writeAuthResponse(c, http.StatusCreated, response)
 }
 
 // Login handles user login
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request",
-			Message: err.Error(),
-		})
+		localMiddleware.WriteBindingError(c, err)
 		return
 	}
 
-	ipAddress := c.ClientIP()
-	userAgent := c.GetHeader("User-Agent")
-
-	response, err := h.authService.Login(&req, ipAddress, userAgent)
+	response, err := h.authService.Login(&req, clientInfo(c))
+	if writeCaptchaError(c, "Login failed", err) {
+		return
+	}
 	if err != nil {
 		statusCode := http.StatusUnauthorized
-		if strings.Contains(err.Error(), "locked") {
+		if errors.Is(err, services.ErrUnknownClient) {
+			statusCode = http.StatusBadRequest
+		} else if strings.Contains(err.Error(), "locked") {
 			statusCode = http.StatusTooManyRequests
+		} else if strings.Contains(err.Error(), "two-factor enrollment overdue") {
+			statusCode = http.StatusForbidden
+		} else if errors.Is(err, services.ErrRegistrationPendingApproval) || errors.Is(err, services.ErrRegistrationRejected) {
+			statusCode = http.StatusForbidden
+		} else if errors.Is(err, services.ErrCountryBlocked) {
+			statusCode = http.StatusForbidden
 		}
 		
-		c.JSON(statusCode, models.ErrorResponse{
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
 			Error:   "Login failed",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	h.writeAuthResponse(c, http.StatusOK, response)
 }
 
 // RefreshToken handles token refresh
+// With cookie transport the refresh token comes from the refresh cookie, guarded by the CSRF token and
+// the request's origin, and the rotated token and a new CSRF token are set as cookies again
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var req models.RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request",
-			Message: err.Error(),
-		})
+	fromCookie := h.wantsRefreshCookie(c)
+	if fromCookie {
+		if !h.bindRefreshCookie(c, &req) {
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
 		return
 	}
 
-	response, err := h.authService.RefreshToken(&req)
+	response, err := h.authService.RefreshToken(&req, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		statusCode := http.StatusUnauthorized
+		if errors.Is(err, services.ErrRefreshOriginNotAllowed) {
+			statusCode = http.StatusForbidden
+		} else if fromCookie {
+			h.clearRefreshCookies(c) // The token is no good; stop the browser sending it
+		}
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
 			Error:   "Token refresh failed",
 			Message: err.Error(),
 		})
 		return
 	}
 
+	if fromCookie {
+		if !h.setRefreshCookies(c, response.RefreshToken) {
+			return
+		}
+		response.RefreshToken = ""
+	}
 	c.JSON(http.StatusOK, response)
 }
 
@@ -176,22 +236,42 @@ func (h *AuthHandler) VerifyToken(c *gin.Context) {
 		}
 	}
 	
+	// Personal access tokens may come as API keys instead; those are never parsed as JWTs
+	apiKey := false
+	if req.Token == "" {
+		req.Token = c.GetHeader(sharedMiddleware.APIKeyHeader)
+		apiKey = req.Token != ""
+	}
+	
 	// If no token in header, try JSON body
 	if req.Token == "" {
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
 				Error:   "Invalid request",
-				Message: "Token required in Authorization header or request body",
+				Message: "Token required in Authorization or X-API-Key header or request body",
 			})
 			return
 		}
 	}
 
-	response, err := h.authService.VerifyToken(req.Token)
+	// Canary API keys are rejected like any unknown token after raising the alert
+	if h.authService.CheckCanaryKey(req.Token, clientInfo(c)) {
+		c.Header("X-Auth-Status", "invalid")
+		localMiddleware.WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Invalid token",
+		})
+		return
+	}
+
+	verify := h.authService.VerifyToken
+	if apiKey {
+		verify = h.authService.VerifyAPIKey
+	}
+	response, err := verify(req.Token, clientInfo(c))
 	if err != nil {
 		// For ForwardAuth: return 401 for invalid tokens (not 500)
 		c.Header("X-Auth-Status", "failed")
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
 			Error:   "Token verification failed",
 			Message: err.Error(),
 		})
@@ -201,90 +281,157 @@ func (h *AuthHandler) VerifyToken(c *gin.Context) {
 	// For ForwardAuth: return appropriate status based on token validity
 	if !response.Valid {
 		c.Header("X-Auth-Status", "invalid")
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
 			Error: "Invalid token",
 		})
 		return
 	}
 
-	// For ForwardAuth, set response headers for downstream services
-	c.Header("X-User-ID", response.UserID)
-	c.Header("X-User-Role", string(response.Role))
-	c.Header("X-User-Email", response.Email)
+	// For ForwardAuth, set response headers for downstream services: the audience's or client's
+	// forward_auth mapping, otherwise the default X-User-* headers
+	mapping, ok := h.forwardAuth.mapping(c, response)
+	if !ok {
+		c.Header("X-Auth-Status", "invalid")
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Unknown audience",
+			Message: "No forward_auth mapping for audience " + c.Query(ForwardAuthAudienceParam),
+		})
+		return
+	}
+	if mapping != nil {
+		writeForwardAuthHeaders(c, mapping, response)
+	} else if response.UserID == "" {
+		// Client tokens act for a service, not a user
+		c.Header("X-Client-ID", response.ClientID)
+	} else {
+		c.Header("X-User-ID", response.UserID)
+		c.Header("X-User-Role", string(response.Role))
+		if len(response.Roles) > 0 {
+			c.Header("X-User-Roles", strings.Join(response.Roles, ","))
+		}
+		if len(response.Permissions) > 0 {
+			c.Header("X-User-Permissions", strings.Join(response.Permissions, ","))
+		}
+		c.Header("X-User-Email", response.Email)
+	}
 	c.Header("X-Auth-Status", "authenticated")
 
-	// For ForwardAuth, return 200 OK (Traefik needs 200 to proceed)
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"valid":  true,
 		"user_id": response.UserID,
 		"email":   response.Email,
 		"role":    response.Role,
-	})
+		"roles":   response.Roles,
+	}
+	if len(response.Permissions) > 0 {
+		body["permissions"] = response.Permissions
+	}
+	if response.ClientID != "" {
+		body["client_id"] = response.ClientID
+	}
+	// Personal access tokens and client tokens only cover their scopes; downstream services enforce them
+	if len(response.Scopes) > 0 {
+		if mapping == nil && response.UserID == "" {
+			c.Header("X-Client-Scopes", strings.Join(response.Scopes, ","))
+		} else if mapping == nil {
+			c.Header("X-User-Scopes", strings.Join(response.Scopes, ","))
+		}
+		body["scopes"] = response.Scopes
+	}
+
+	// For ForwardAuth, return 200 OK (Traefik needs 200 to proceed)
+	c.JSON(http.StatusOK, body)
 }
 
-// Logout handles user logout
+// Logout ends the session of the presented token; the user's other devices stay signed in
 func (h *AuthHandler) Logout(c *gin.Context) {
-	token, exists := c.Get("token")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Token required",
-		})
+	userID, token, ok := h.logoutCaller(c)
+	if !ok {
 		return
 	}
 
-	// Get user ID from token verification
-	verifyResponse, err := h.authService.VerifyToken(token.(string))
-	if err != nil || !verifyResponse.Valid {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Invalid token",
+	if err := h.authService.Logout(userID, token); err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Logout failed",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	userID, err := uuid.Parse(verifyResponse.UserID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
-		})
+	if h.refreshCookie.Enabled {
+		h.clearRefreshCookies(c)
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Logged out successfully",
+	})
+}
+
+// LogoutAll - Session Management API
+// @Summary Log out of every device
+// @Description Revokes all of the user's sessions, including the current one, and blacklists this access token
+// @Tags Sessions
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, token, ok := h.logoutCaller(c)
+	if !ok {
 		return
 	}
 
-	if err := h.authService.Logout(userID, token.(string)); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+	revoked, err := h.authService.LogoutAll(userID, token)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Logout failed",
 			Message: err.Error(),
 		})
 		return
 	}
 
+	if h.refreshCookie.Enabled {
+		h.clearRefreshCookies(c)
+	}
 	c.JSON(http.StatusOK, models.SuccessResponse{
-		Message: "Logged out successfully",
+		Message: "Logged out of all devices",
+		Data:    models.RevokedSessionsResponse{Revoked: revoked},
 	})
 }
 
-// GetProfile returns user profile
-func (h *AuthHandler) GetProfile(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
+// logoutCaller returns the caller and their token, rejecting tokens that were already revoked
+func (h *AuthHandler) logoutCaller(c *gin.Context) (uuid.UUID, string, bool) {
+	token := c.GetString("token")
+	if token == "" {
+		localMiddleware.WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Token required",
 		})
-		return
+		return uuid.Nil, "", false
 	}
 
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
+	userID, ok := requireUserID(c)
+	if !ok {
+		return uuid.Nil, "", false
+	}
+
+	verifyResponse, err := h.authService.VerifyToken(token, clientInfo(c))
+	if err != nil || !verifyResponse.Valid {
+		localMiddleware.WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Invalid token",
 		})
+		return uuid.Nil, "", false
+	}
+	return userID, token, true
+}
+
+// GetProfile returns user profile
+func (h *AuthHandler) GetProfile(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
 	profile, err := h.authService.GetProfile(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusNotFound, models.ErrorResponse{
 			Error:   "Profile not found",
 			Message: err.Error(),
 		})
@@ -294,31 +441,45 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, profile)
 }
 
-// UpdateProfile updates user profile
-func (h *AuthHandler) UpdateProfile(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
+// GetBootstrap - Dashboard Bootstrap API
+// @Summary Get the profile, preferences and unread notification count in one request
+// @Description Replaces separate profile, preferences and notification calls on page load; preferences is null until saved
+// @Tags Authentication
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/bootstrap [get]
+func (h *AuthHandler) GetBootstrap(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	resp, err := h.authService.GetBootstrap(userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrProfileNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
+			Error:   "Failed to load dashboard data",
+			Message: err.Error(),
 		})
 		return
 	}
 
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateProfile updates user profile
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
 	var req models.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request",
-			Message: err.Error(),
-		})
+		localMiddleware.WriteBindingError(c, err)
 		return
 	}
 
@@ -329,7 +490,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 			statusCode = http.StatusConflict
 		}
 		
-		c.JSON(statusCode, models.ErrorResponse{
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
 			Error:   "Profile update failed",
 			Message: err.Error(),
 		})
@@ -341,48 +502,39 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 // ChangePassword handles password change
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
-		})
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
 	var req models.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request",
-			Message: err.Error(),
-		})
+		localMiddleware.WriteBindingError(c, err)
 		return
 	}
 
-	if err := h.authService.ChangePassword(userID, &req); err != nil {
+	warnings, err := h.authService.ChangePassword(userID, &req)
+	if writePasswordPolicyError(c, "Password change failed", "new_password", err) {
+		return
+	}
+	if err != nil {
 		statusCode := http.StatusBadRequest
 		if strings.Contains(err.Error(), "invalid current password") {
 			statusCode = http.StatusUnauthorized
 		}
 		
-		c.JSON(statusCode, models.ErrorResponse{
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
 			Error:   "Password change failed",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.SuccessResponse{
-		Message: "Password changed successfully",
-	})
+	response := models.SuccessResponse{Message: "Password changed successfully"}
+	if len(warnings) > 0 {
+		response.Data = gin.H{"warnings": warnings}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // ForgotPassword - Forgot Password API
@@ -395,15 +547,12 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var req models.ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request",
-			Message: err.Error(),
-		})
+		localMiddleware.WriteBindingError(c, err)
 		return
 	}
 
-	if err := h.authService.ForgotPassword(&req); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+	if err := h.authService.ForgotPassword(&req, clientInfo(c)); err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to process request",
 			Message: err.Error(),
 		})
@@ -426,15 +575,16 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var req models.ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request",
-			Message: err.Error(),
-		})
+		localMiddleware.WriteBindingError(c, err)
 		return
 	}
 
-	if err := h.authService.ResetPassword(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+	err := h.authService.ResetPassword(&req, clientInfo(c))
+	if writePasswordPolicyError(c, "Password reset failed", "password", err) {
+		return
+	}
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Password reset failed",
 			Message: err.Error(),
 		})
@@ -446,27 +596,54 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	})
 }
 
-// DeleteAccount handles account deletion
-func (h *AuthHandler) DeleteAccount(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
+// AcceptInvitation - Accept Invitation API
+// @Summary Activate an invited account
+// @Description Set the password (and optionally enable two-factor authentication) with the token from the invitation email
+// @Tags Password Recovery
+// @Accept json
+// @Produce json
+// @Router /api/v1/auth/invitations/accept [post]
+func (h *AuthHandler) AcceptInvitation(c *gin.Context) {
+	var req models.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	user, err := h.authService.AcceptInvitation(&req, clientInfo(c))
+	if writePasswordPolicyError(c, "Invitation acceptance failed", "password", err) {
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
+		statusCode := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "superseded"):
+			statusCode = http.StatusGone
+		case strings.Contains(err.Error(), "not configured"):
+			statusCode = http.StatusServiceUnavailable
+		}
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
+			Error:   "Invitation acceptance failed",
+			Message: err.Error(),
 		})
 		return
 	}
 
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Account activated, you can now sign in",
+		Data:    user,
+	})
+}
+
+// DeleteAccount handles account deletion
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
 	if err := h.authService.DeleteAccount(userID); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Account deletion failed",
 			Message: err.Error(),
 		})
@@ -484,27 +661,27 @@ func (h *AuthHandler) DeleteAccount(c *gin.Context) {
 // @Summary Start OAuth2 authentication
 // @Description Redirect to OAuth2 provider for authentication
 // @Tags OAuth2
-// @Param provider path string true "OAuth provider (google, github, facebook)"
+// @Param provider path string true "OAuth provider (google, github, facebook, apple or a configured OIDC provider)"
 // @Produce json
 // @Router /api/v1/auth/oauth/{provider} [get]
 func (h *AuthHandler) OAuthLogin(c *gin.Context) {
+	if !h.oauthEnabled(c) {
+		return
+	}
 	provider := c.Param("provider")
-	
-	// Generate state parameter for CSRF protection
-	state, err := generateState()
+
+	// The state, PKCE verifier and the hash of the browser's nonce cookie are stored in Redis until the callback
+	nonce, err := h.setOAuthNonce(c, provider)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: "Failed to generate state",
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "OAuth login failed",
+			Message: "Failed to generate state",
 		})
 		return
 	}
-
-	// Store state in session/cache for validation (simplified)
-	// In production, you would store this in Redis with expiration
-
-	authURL, err := h.oauth2Service.GetAuthURL(provider, state)
+	authURL, state, err := h.oauth2Service.GetAuthURL(provider, uuid.Nil, nonce, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		localMiddleware.WriteError(c, oauthErrorStatus(err), models.ErrorResponse{
 			Error:   "OAuth login failed",
 			Message: err.Error(),
 		})
@@ -519,46 +696,279 @@ func (h *AuthHandler) OAuthLogin(c *gin.Context) {
 
 // OAuthCallback - OAuth2 Callback API
 // @Summary Handle OAuth2 callback
-// @Description Process OAuth2 provider callback and create session
+// @Description Logs in with the provider account, linking or creating the account; with oauth2.frontend_redirect_url set, redirects there with a one-time code instead of tokens. Apple posts the parameters as a form
 // @Tags OAuth2
-// @Param provider path string true "OAuth provider (google, github, facebook)"
+// @Param provider path string true "OAuth provider (google, github, facebook, apple or a configured OIDC provider)"
 // @Param code query string true "Authorization code"
 // @Param state query string true "State parameter"
 // @Produce json
 // @Router /api/v1/auth/oauth/{provider}/callback [get]
+// @Router /api/v1/auth/oauth/{provider}/callback [post]
 func (h *AuthHandler) OAuthCallback(c *gin.Context) {
+	if !h.oauthEnabled(c) {
+		return
+	}
 	provider := c.Param("provider")
-	code := c.Query("code")
-	state := c.Query("state")
+	code := callbackParam(c, "code")
+	state := callbackParam(c, "state")
 
 	if code == "" || state == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Missing code or state parameter",
-		})
+		h.oauthFailure(c, http.StatusBadRequest, "Missing code or state parameter", "")
+		return
+	}
+
+	// Checks the state was issued to this browser, by its nonce cookie, for this provider and not used
+	// yet, then gets the user info from the provider
+	nonce := h.takeOAuthNonce(c, provider)
+	oauthUser, linkUserID, err := h.oauth2Service.HandleCallback(provider, code, state, nonce, clientInfo(c))
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, services.ErrOAuthProviderUnavailable) {
+			statusCode = http.StatusServiceUnavailable
+		}
+		h.oauthFailure(c, statusCode, "OAuth callback failed", err.Error())
 		return
 	}
+	// Apple sends the name only here, and only on the user's first authorization
+	if provider == models.OAuthProviderApple && oauthUser.Username == "" {
+		oauthUser.Username = services.AppleUserName(c.PostForm("user"))
+	}
+
+	// The flow was started by a signed-in user from LinkOAuth
+	if linkUserID != uuid.Nil {
+		h.finishOAuthLink(c, linkUserID, oauthUser)
+		return
+	}
+
+	// The frontend gets a one-time code rather than tokens in its URL
+	if redirectURL := h.oauth2Service.FrontendRedirectURL(); redirectURL != "" {
+		loginCode, err := h.authService.IssueOAuthLoginCode(oauthUser, clientInfo(c))
+		if err != nil {
+			h.oauthLoginFailure(c, err)
+			return
+		}
+		c.Redirect(http.StatusFound, withQuery(redirectURL, url.Values{"code": {loginCode}}))
+		return
+	}
+
+	response, err := h.authService.OAuthLogin(oauthUser, clientInfo(c))
+	if err != nil {
+		h.oauthLoginFailure(c, err)
+		return
+	}
+
+	h.writeAuthResponse(c, http.StatusOK, response)
+}
 
-	// Validate state parameter (simplified)
-	// In production, you would validate against stored state
+// LinkOAuth - OAuth2 Account Linking API
+// @Summary Start linking an OAuth2 provider to the signed-in account
+// @Description Returns the provider's authorization URL; its callback links the provider account instead of logging in, then redirects to oauth2.frontend_redirect_url with linked={provider} when one is set
+// @Tags OAuth2
+// @Security Bearer
+// @Param provider path string true "OAuth provider (google, github, facebook, apple or a configured OIDC provider)"
+// @Produce json
+// @Router /api/v1/auth/oauth/{provider}/link [post]
+func (h *AuthHandler) LinkOAuth(c *gin.Context) {
+	if !h.oauthEnabled(c) {
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
 
-	// Get user info from OAuth provider
-	oauthUser, err := h.oauth2Service.HandleCallback(provider, code, state)
+	nonce, err := h.setOAuthNonce(c, c.Param("provider"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "OAuth linking failed",
+			Message: "Failed to generate state",
+		})
+		return
+	}
+	authURL, state, err := h.oauth2Service.GetAuthURL(c.Param("provider"), userID, nonce, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "OAuth callback failed",
+		localMiddleware.WriteError(c, oauthErrorStatus(err), models.ErrorResponse{
+			Error:   "OAuth linking failed",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	// TODO: Handle OAuth user (create account or login existing user)
-	// This is a simplified response
 	c.JSON(http.StatusOK, gin.H{
-		"message": "OAuth login successful",
-		"user":    oauthUser,
+		"auth_url": authURL,
+		"state":    state,
 	})
 }
 
+// UnlinkOAuth - OAuth2 Account Linking API
+// @Summary Unlink an OAuth2 provider from the signed-in account
+// @Description Refused with 409 when the provider is the account's only way to log in (no password, passkey or other provider)
+// @Tags OAuth2
+// @Security Bearer
+// @Param provider path string true "OAuth provider (google, github, facebook, apple or a configured OIDC provider)"
+// @Produce json
+// @Router /api/v1/auth/oauth/{provider}/unlink [delete]
+func (h *AuthHandler) UnlinkOAuth(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	provider := c.Param("provider")
+	if err := h.authService.UnlinkOAuthAccount(userID, provider, clientInfo(c)); err != nil {
+		localMiddleware.WriteError(c, oauthErrorStatus(err), models.ErrorResponse{
+			Error:   "OAuth unlinking failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: provider + " login unlinked",
+	})
+}
+
+// finishOAuthLink ends a linking callback, redirecting to the frontend with linked={provider} when one
+// is configured
+func (h *AuthHandler) finishOAuthLink(c *gin.Context, userID uuid.UUID, oauthUser *models.OAuth2UserInfo) {
+	if err := h.authService.LinkOAuthAccount(userID, oauthUser, clientInfo(c)); err != nil {
+		status := oauthErrorStatus(err)
+		message := err.Error()
+		if status >= http.StatusInternalServerError {
+			message = "failed to link the provider account"
+		}
+		h.oauthFailure(c, status, "OAuth linking failed", message)
+		return
+	}
+
+	if redirectURL := h.oauth2Service.FrontendRedirectURL(); redirectURL != "" {
+		c.Redirect(http.StatusFound, withQuery(redirectURL, url.Values{"linked": {oauthUser.Provider}}))
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: oauthUser.Provider + " login linked",
+	})
+}
+
+// ExchangeOAuthCode - OAuth2 Login API
+// @Summary Exchange an OAuth login code for tokens
+// @Description Redeems the code the callback redirected with, from the same device within oauth2.code_ttl, for the same token pair as a password login
+// @Tags OAuth2
+// @Accept json
+// @Produce json
+// @Router /api/v1/auth/oauth/exchange [post]
+func (h *AuthHandler) ExchangeOAuthCode(c *gin.Context) {
+	var req models.OAuthCodeExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	response, err := h.authService.ExchangeOAuthLoginCode(&req, clientInfo(c))
+	if err != nil {
+		localMiddleware.WriteError(c, oauthErrorStatus(err), models.ErrorResponse{
+			Error:   "Login failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.writeAuthResponse(c, http.StatusOK, response)
+}
+
+// callbackParam reads a callback parameter from the posted form (Apple's form_post) or the query string
+func callbackParam(c *gin.Context, key string) string {
+	if value, ok := c.GetPostForm(key); ok {
+		return value
+	}
+	return c.Query(key)
+}
+
+// oauthEnabled writes a 404 and returns false when no OAuth provider is configured
+func (h *AuthHandler) oauthEnabled(c *gin.Context) bool {
+	if h.oauth2Service == nil {
+		localMiddleware.WriteError(c, http.StatusNotFound, models.ErrorResponse{
+			Error: "OAuth login is not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// oauthLoginFailure reports an error from logging in with the provider identity; server-side failures
+// are not described to the browser
+func (h *AuthHandler) oauthLoginFailure(c *gin.Context, err error) {
+	status := oauthErrorStatus(err)
+	message := err.Error()
+	if status >= http.StatusInternalServerError {
+		message = services.ErrOAuthLoginFailed.Error()
+	}
+	h.oauthFailure(c, status, "OAuth login failed", message)
+}
+
+// oauthFailure ends the callback with an error: a redirect to the frontend with error and
+// error_description parameters when one is configured, a JSON error otherwise
+func (h *AuthHandler) oauthFailure(c *gin.Context, status int, title, message string) {
+	if redirectURL := h.oauth2Service.FrontendRedirectURL(); redirectURL != "" {
+		code := "access_denied"
+		if status >= http.StatusInternalServerError {
+			code = "server_error"
+		}
+		description := title
+		if message != "" {
+			description = message
+		}
+		c.Redirect(http.StatusFound, withQuery(redirectURL, url.Values{"error": {code}, "error_description": {description}}))
+		return
+	}
+	localMiddleware.WriteError(c, status, models.ErrorResponse{
+		Error:   title,
+		Message: message,
+	})
+}
+
+// withQuery adds params to a URL, keeping any query it already has
+func withQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// oauthErrorStatus maps OAuth login and linking service errors to HTTP statuses
+func oauthErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrOAuthCodesDisabled), errors.Is(err, repositories.ErrOAuthNotLinked):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrOAuthLoginCode), errors.Is(err, services.ErrOAuthLoginFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrOAuthEmailUnverified), errors.Is(err, services.ErrTwoFactorEnrollmentOverdue),
+		errors.Is(err, services.ErrRegistrationPendingApproval), errors.Is(err, services.ErrRegistrationRejected),
+		errors.Is(err, services.ErrCountryBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, repositories.ErrOAuthIdentityConflict), errors.Is(err, repositories.ErrOAuthIdentityInUse),
+		errors.Is(err, repositories.ErrLastLoginMethod):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrUnknownClient), errors.Is(err, services.ErrUnsupportedOAuthProvider):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrOAuthProviderUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	switch err.Error() {
+	case "account is temporarily locked":
+		return http.StatusTooManyRequests
+	case "account is inactive":
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
 // GetMe handles HTTP GET requests for basic auth user information
 //
 // Endpoint: GET /api/v1/auth/me  
@@ -567,26 +977,14 @@ func (h *AuthHandler) OAuthCallback(c *gin.Context) {
 // Response: Basic user data (id, email, username, is_active, email_verified)
 // Note: This endpoint only returns auth-related data, not profile information
 func (h *AuthHandler) GetMe(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
-		})
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
 	user, err := h.authService.GetProfile(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusNotFound, models.ErrorResponse{
 			Error: "User not found",
 		})
 		return
@@ -608,29 +1006,95 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 
 // Helper functions
 
-
-// GetUserPreferences handles user preferences retrieval
-func (h *AuthHandler) GetUserPreferences(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+// requireUserID returns the authenticated user's ID parsed by the RequireUserID middleware
+// Writes a 401 response and returns false when the route is not protected by it
+func requireUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, ok := localMiddleware.GetUserUUID(c)
+	if !ok {
+		localMiddleware.WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
 			Error: "Authentication required",
 		})
-		return
+		return uuid.Nil, false
 	}
+	return userID, true
+}
 
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
+// writePasswordPolicyError reports a password the policy rejected, listing each broken rule under field
+// Returns false, writing nothing, for other errors
+func writePasswordPolicyError(c *gin.Context, title, field string, err error) bool {
+	var policyErr *services.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	params := make([]localMiddleware.InvalidParam, 0, len(policyErr.Violations))
+	for _, violation := range policyErr.Violations {
+		params = append(params, localMiddleware.InvalidParam{Name: field, Reason: violation.Message})
+	}
+	localMiddleware.WriteInvalidParams(c, models.ErrorResponse{
+		Error:   title,
+		Message: err.Error(),
+	}, params)
+	return true
+}
+
+// Validation hint codes of logins and sign-ups refused for their CAPTCHA token
+const (
+	HintCaptchaRequired = "captcha_required"
+	HintCaptchaInvalid  = "captcha_invalid"
+)
+
+// writeCaptchaError writes a missing or refused CAPTCHA token as 403 with a hint on captcha_token, and
+// an unreachable CAPTCHA provider as 503; it reports whether err was one of them
+func writeCaptchaError(c *gin.Context, title string, err error) bool {
+	hint := models.ValidationHint{Field: "captcha_token"}
+	switch {
+	case errors.Is(err, captcha.ErrRequired):
+		hint.Code = HintCaptchaRequired
+		hint.Message = "solve the CAPTCHA and send its token"
+	case errors.Is(err, captcha.ErrInvalid):
+		hint.Code = HintCaptchaInvalid
+		hint.Message = "the CAPTCHA was not solved or has expired; solve a new one"
+	case errors.Is(err, captcha.ErrUnavailable):
+		localMiddleware.WriteError(c, http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   title,
+			Message: err.Error(),
 		})
+		return true
+	default:
+		return false
+	}
+	localMiddleware.WriteError(c, http.StatusForbidden, models.ErrorResponse{
+		Error:   title,
+		Message: hint.Message,
+		Hints:   []models.ValidationHint{hint},
+	})
+	return true
+}
+
+// clientInfo collects the caller's address, user agent, request and trace IDs and TLS fingerprints for audit records,
+// sessions and metric exemplars
+func clientInfo(c *gin.Context) models.ClientInfo {
+	ja3, ja4 := localMiddleware.GetTLSFingerprints(c)
+	return models.ClientInfo{
+		IPAddress: sharedMiddleware.ResolveClientIP(c),
+		UserAgent: c.GetHeader("User-Agent"),
+		RequestID: c.GetString(requestid.ContextKey),
+		TraceID:   c.GetString(requestid.TraceContextKey),
+		JA3:       ja3,
+		JA4:       ja4,
+	}
+}
+
+// GetUserPreferences handles user preferences retrieval
+func (h *AuthHandler) GetUserPreferences(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
 	preferences, err := h.authService.GetUserPreferences(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusNotFound, models.ErrorResponse{
 			Error:   "Preferences not found",
 			Message: err.Error(),
 		})
@@ -649,29 +1113,14 @@ func (h *AuthHandler) GetUserPreferences(c *gin.Context) {
 // @Produce json
 // @Router /api/v1/auth/preferences [put]
 func (h *AuthHandler) UpdateUserPreferences(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
-		})
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
 	var req models.UpdatePreferencesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request",
-			Message: err.Error(),
-		})
+		localMiddleware.WriteBindingError(c, err)
 		return
 	}
 
@@ -687,7 +1136,11 @@ func (h *AuthHandler) UpdateUserPreferences(c *gin.Context) {
 
 	preferences, err := h.authService.UpdateUserPreferences(userID, serviceReq)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		statusCode := http.StatusBadRequest
+		if strings.Contains(err.Error(), "requires administrator approval") {
+			statusCode = http.StatusForbidden
+		}
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
 			Error:   "Failed to update preferences",
 			Message: err.Error(),
 		})
@@ -706,35 +1159,20 @@ func (h *AuthHandler) UpdateUserPreferences(c *gin.Context) {
 // @Produce json
 // @Router /api/v1/auth/preferences [post]
 func (h *AuthHandler) CreateUserPreferences(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
-		})
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
 	var req models.CreatePreferencesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request",
-			Message: err.Error(),
-		})
+		localMiddleware.WriteBindingError(c, err)
 		return
 	}
 
 	preferences, err := h.authService.CreateUserPreferences(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Failed to create preferences",
 			Message: err.Error(),
 		})
@@ -744,6 +1182,42 @@ func (h *AuthHandler) CreateUserPreferences(c *gin.Context) {
 	c.JSON(http.StatusCreated, preferences)
 }
 
+// GetLoginHistory - Login History API
+// @Summary Get the user's login history
+// @Description Paginated login attempts on the account, newest first, with the country and city geoip located them in
+// @Tags Sessions
+// @Security Bearer
+// @Produce json
+// @Param limit query int false "Page size (default 50, max 1000)"
+// @Param offset query int false "Attempts to skip"
+// @Router /api/v1/auth/login-history [get]
+func (h *AuthHandler) GetLoginHistory(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.LoginHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+
+	history, err := h.authService.GetLoginHistory(userID, req.Limit, req.Offset)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get login history",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
 // GetUserActivities - Get User Activities API
 // @Summary Get user activity history
 // @Description Retrieve paginated list of user activities
@@ -752,20 +1226,8 @@ func (h *AuthHandler) CreateUserPreferences(c *gin.Context) {
 // @Produce json
 // @Router /api/v1/auth/activities [get]
 func (h *AuthHandler) GetUserActivities(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
-		})
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
@@ -787,7 +1249,7 @@ func (h *AuthHandler) GetUserActivities(c *gin.Context) {
 
 	activities, err := h.authService.GetUserActivities(userID, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to get activities",
 			Message: err.Error(),
 		})
@@ -805,26 +1267,14 @@ func (h *AuthHandler) GetUserActivities(c *gin.Context) {
 // @Produce json
 // @Router /api/v1/auth/notifications [get]
 func (h *AuthHandler) GetUserNotifications(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
-		})
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
 	notifications, err := h.authService.GetUserNotifications(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to get notifications",
 			Message: err.Error(),
 		})
@@ -842,27 +1292,15 @@ func (h *AuthHandler) GetUserNotifications(c *gin.Context) {
 // @Produce json
 // @Router /api/v1/auth/notifications/{id}/read [put]
 func (h *AuthHandler) MarkNotificationAsRead(c *gin.Context) {
-	userIDStr := sharedMiddleware.GetUserIDFromContext(c)
-	if userIDStr == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid user ID",
-			Message: "User ID must be a valid UUID",
-		})
+	userID, ok := requireUserID(c)
+	if !ok {
 		return
 	}
 
 	notificationIDStr := c.Param("notificationId")
 	notificationID, err := uuid.Parse(notificationIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid notification ID",
 			Message: "Notification ID must be a valid UUID",
 		})
@@ -870,7 +1308,7 @@ func (h *AuthHandler) MarkNotificationAsRead(c *gin.Context) {
 	}
 
 	if err := h.authService.MarkNotificationAsRead(userID, notificationID); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to mark notification as read",
 			Message: err.Error(),
 		})
@@ -882,6 +1320,110 @@ func (h *AuthHandler) MarkNotificationAsRead(c *gin.Context) {
 	})
 }
 
+// CreateFeedToken - Issue Feed Token API
+// @Summary Issue a personal feed token
+// @Description Create or rotate the token that unlocks the user's RSS/Atom and iCal feeds; previous feed URLs stop working
+// @Tags Feeds
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/feeds/token [post]
+func (h *AuthHandler) CreateFeedToken(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.authService.CreateFeedToken(userID)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to issue feed token",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Feed token issued, previous feed URLs no longer work",
+		Data:    response,
+	})
+}
+
+// RevokeFeedToken - Revoke Feed Token API
+// @Summary Revoke the personal feed token
+// @Description Invalidate all feed URLs of the user
+// @Tags Feeds
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/feeds/token [delete]
+func (h *AuthHandler) RevokeFeedToken(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeFeedToken(userID); err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to revoke feed token",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Feed token revoked",
+	})
+}
+
+// Feed - Subscription Feed API
+// @Summary Get an activity, notification or security feed
+// @Description Serve a feed for readers and calendar apps, authenticated by the personal token in the URL
+// @Tags Feeds
+// @Param token path string true "Personal feed token"
+// @Produce xml
+// @Router /api/v1/auth/feeds/{token}/{feed} [get]
+func (h *AuthHandler) Feed(kind, format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		feed, err := h.authService.GetFeed(c.Param("token"), kind)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			if errors.Is(err, services.ErrInvalidFeedToken) || errors.Is(err, services.ErrUnknownFeed) {
+				statusCode = http.StatusNotFound
+			}
+			localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
+				Error:   "Feed unavailable",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		var body []byte
+		var contentType string
+		switch format {
+		case FeedFormatAtom:
+			body, err = feeds.Atom(feed)
+			contentType = feeds.ContentTypeAtom
+		case FeedFormatICal:
+			body = feeds.ICal(feed)
+			contentType = feeds.ContentTypeICal
+		default:
+			body, err = feeds.RSS(feed)
+			contentType = feeds.ContentTypeRSS
+		}
+		if err != nil {
+			localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Feed unavailable",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		// The token is a credential: keep the URL out of shared caches and outgoing Referer headers
+		c.Header("Cache-Control", "private, max-age=300")
+		c.Header("Referrer-Policy", "no-referrer")
+		c.Data(http.StatusOK, contentType, body)
+	}
+}
+
 func generateState() (string, error) {
 	bytes := make([]byte, 16)
 	if _, err := rand.Read(bytes); err != nil {
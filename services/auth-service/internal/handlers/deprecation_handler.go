@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-service/internal/deprecation"
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecationHandler reports who still calls the endpoints listed in [deprecations]
+type DeprecationHandler struct {
+	registry *deprecation.Registry
+}
+
+// NewDeprecationHandler creates a new deprecation handler; registry is nil when no endpoint is deprecated
+func NewDeprecationHandler(registry *deprecation.Registry) *DeprecationHandler {
+	return &DeprecationHandler{registry: registry}
+}
+
+// GetDeprecations - Admin Deprecations API
+// @Summary List deprecated endpoints with their sunset dates and calls per consumer across replicas
+// @Description Consumers are the token's client_id, or the user agent for other callers; an endpoint without consumers can be removed
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/deprecations [get]
+func (h *DeprecationHandler) GetDeprecations(c *gin.Context) {
+	report, err := h.registry.Report(c.Request.Context())
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to load deprecation report",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Deprecation report retrieved",
+		Data:    report,
+	})
+}
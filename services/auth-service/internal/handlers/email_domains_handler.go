@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"auth-service/internal/emaildomains"
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailDomainsHandler lets administrators inspect and replace the email domain lists registration checks
+type EmailDomainsHandler struct {
+	checker *emaildomains.Checker
+}
+
+// NewEmailDomainsHandler creates a new email domains handler
+func NewEmailDomainsHandler(checker *emaildomains.Checker) *EmailDomainsHandler {
+	return &EmailDomainsHandler{checker: checker}
+}
+
+// GetEmailDomains - Admin Email Domains API
+// @Summary Show the disposable and popular domains and known typos registration checks addresses against
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/email-domains [get]
+func (h *EmailDomainsHandler) GetEmailDomains(c *gin.Context) {
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Email domain lists retrieved",
+		Data:    h.checker.Current(),
+	})
+}
+
+// SetEmailDomains - Admin Email Domains API
+// @Summary Replace the email domain lists on every replica
+// @Description Replicas pick the lists up within email_domains.sync_interval; they are kept across restarts until reset
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/email-domains [put]
+func (h *EmailDomainsHandler) SetEmailDomains(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminEmailDomainsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	lists, err := h.checker.Set(c.Request.Context(), emaildomains.Lists{
+		Disposable: req.DisposableDomains,
+		Popular:    req.PopularDomains,
+		Typos:      req.Typos,
+	}, adminID.String())
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to replace email domain lists",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	log.Printf("📧 Admin %s replaced the email domain lists: %d disposable, %d popular, %d typos",
+		adminID, len(lists.Disposable), len(lists.Popular), len(lists.Typos))
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Email domain lists replaced",
+		Data:    lists,
+	})
+}
+
+// ResetEmailDomains - Admin Email Domains API
+// @Summary Return every replica to the email domain lists of the config
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/email-domains [delete]
+func (h *EmailDomainsHandler) ResetEmailDomains(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	lists, err := h.checker.Reset(c.Request.Context())
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to reset email domain lists",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	log.Printf("📧 Admin %s reset the email domain lists to the config", adminID)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Email domain lists reset to the config",
+		Data:    lists,
+	})
+}
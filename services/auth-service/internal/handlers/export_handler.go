@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-service/internal/export"
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFlushEvery is how many NDJSON lines are written between flushes to the client
+const exportFlushEvery = 500
+
+// ExportHandler streams bulk exports to analytics pipelines (admin only)
+type ExportHandler struct {
+	exporter *export.Exporter
+	limiter  *ratelimit.Limiter
+}
+
+// NewExportHandler creates a new export handler; exporter is nil when bulk_export is disabled and
+// limiter nil when exports aren't rate limited
+func NewExportHandler(exporter *export.Exporter, limiter *ratelimit.Limiter) *ExportHandler {
+	return &ExportHandler{exporter: exporter, limiter: limiter}
+}
+
+// Export - Admin Bulk Export API
+// @Summary Stream a page of users, sessions or activities as NDJSON, one JSON object per line
+// @Description Query: fields (comma-separated), since and until (RFC 3339; since inclusive, until exclusive), cursor, limit. The next page's cursor is in X-Next-Cursor and Link rel="next", absent after the last page
+// @Tags Admin
+// @Security Bearer
+// @Produce application/x-ndjson
+// @Router /api/v1/admin/export/{resource} [get]
+func (h *ExportHandler) Export(c *gin.Context) {
+	if h.exporter == nil {
+		localMiddleware.WriteError(c, http.StatusNotFound, models.ErrorResponse{
+			Error:   "Bulk export disabled",
+			Message: "bulk_export is not enabled",
+		})
+		return
+	}
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	// Limited per administrator rather than per address, since pipelines often share egress addresses
+	if h.limiter != nil {
+		decision, err := h.limiter.Allow(c.Request.Context(), c.Request.URL.Path, adminID.String())
+		if err != nil {
+			log.Printf("⚠️  Bulk export rate limit not checked: %v", err)
+		} else if localMiddleware.WriteRateLimit(c, decision) {
+			return
+		}
+	}
+
+	query, err := parseExportQuery(c)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	page, err := h.exporter.Page(c.Request.Context(), query)
+	if err != nil {
+		localMiddleware.WriteError(c, exportErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to export " + query.Resource,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if page.NextCursor != "" {
+		next := *c.Request.URL
+		params := next.Query()
+		params.Set("cursor", page.NextCursor)
+		next.RawQuery = params.Encode()
+		c.Header("X-Next-Cursor", page.NextCursor)
+		c.Header("Link", "<"+next.RequestURI()+`>; rel="next"`)
+	}
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	for i, row := range page.Rows {
+		c.Writer.Write(row)
+		c.Writer.Write([]byte{'\n'})
+		if (i+1)%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+	}
+}
+
+// parseExportQuery reads the export query parameters; the exporter checks fields and limit bounds
+func parseExportQuery(c *gin.Context) (export.Query, error) {
+	query := export.Query{Resource: c.Param("resource"), Cursor: c.Query("cursor")}
+
+	if raw := c.Query("fields"); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				query.Fields = append(query.Fields, field)
+			}
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return query, errors.New("limit must be a positive integer")
+		}
+		query.Limit = limit
+	}
+	for _, bound := range []struct {
+		name string
+		dest *time.Time
+	}{
+		{"since", &query.Since},
+		{"until", &query.Until},
+	} {
+		raw := c.Query(bound.name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return query, errors.New(bound.name + " must be an RFC 3339 timestamp")
+		}
+		*bound.dest = t
+	}
+
+	return query, nil
+}
+
+// exportErrorStatus maps export errors to HTTP statuses
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, export.ErrUnknownResource):
+		return http.StatusNotFound
+	case errors.Is(err, export.ErrInvalidQuery):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
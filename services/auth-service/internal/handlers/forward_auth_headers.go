@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForwardAuthAudienceParam selects a forward_auth mapping; set it on the gateway's ForwardAuth address
+const ForwardAuthAudienceParam = "audience"
+
+// forwardAuthHeaders picks the headers /verify returns for each downstream service
+type forwardAuthHeaders struct {
+	byAudience map[string]map[string]string // Canonical header name → claim
+	byClient   map[string]map[string]string
+}
+
+// WithForwardAuthHeaders makes /verify emit the claims of forward_auth mappings instead of the default
+// X-User-* headers for the audiences and clients they select
+func (h *AuthHandler) WithForwardAuthHeaders(cfg config.ForwardAuthConfig) *AuthHandler {
+	headers := &forwardAuthHeaders{
+		byAudience: make(map[string]map[string]string),
+		byClient:   make(map[string]map[string]string),
+	}
+	for _, mapping := range cfg.Mappings {
+		canonical := make(map[string]string, len(mapping.Headers))
+		for header, claim := range mapping.Headers {
+			canonical[http.CanonicalHeaderKey(header)] = claim
+		}
+		if mapping.Audience != "" {
+			headers.byAudience[mapping.Audience] = canonical
+		}
+		for _, clientID := range mapping.ClientIDs {
+			headers.byClient[clientID] = canonical
+		}
+	}
+	h.forwardAuth = headers
+	return h
+}
+
+// mapping returns the headers for the request's audience, else the token's client; nil means the
+// defaults. An audience without a mapping is a gateway misconfiguration and fails closed
+func (f *forwardAuthHeaders) mapping(c *gin.Context, response *models.VerifyTokenResponse) (map[string]string, bool) {
+	if f == nil {
+		return nil, true
+	}
+	if audience := c.Query(ForwardAuthAudienceParam); audience != "" {
+		headers, ok := f.byAudience[audience]
+		if !ok {
+			log.Printf("⚠️  ForwardAuth request for unknown audience %q; add a forward_auth mapping", audience)
+		}
+		return headers, ok
+	}
+	return f.byClient[response.ClientID], true
+}
+
+// writeForwardAuthHeaders sets the mapped claims as headers; claims the token doesn't carry are left out
+func writeForwardAuthHeaders(c *gin.Context, headers map[string]string, response *models.VerifyTokenResponse) {
+	for header, claim := range headers {
+		if value, ok := forwardAuthClaim(response, claim); ok {
+			c.Header(header, value)
+		}
+	}
+}
+
+// forwardAuthClaim renders a claim as a header value: lists comma-separated, objects as JSON
+func forwardAuthClaim(response *models.VerifyTokenResponse, claim string) (string, bool) {
+	var value interface{}
+	switch claim {
+	case "user_id":
+		value = response.UserID
+	case "email":
+		value = response.Email
+	case "role":
+		value = string(response.Role)
+	case "roles":
+		value = response.Roles
+	case "permissions":
+		value = response.Permissions
+	case "scopes":
+		value = response.Scopes
+	case "client_id":
+		value = response.ClientID
+	default:
+		value = response.Claims[claim]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, v != ""
+	case []string:
+		return strings.Join(v, ","), len(v) > 0
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ","), len(parts) > 0
+	case bool, float64, int64:
+		return fmt.Sprint(v), true
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge lets verifiers cache the key set; a new key is published before it signs anything, so a
+// rotation only has to wait this long between adding the key and switching jwt.signing_key_id
+const jwksMaxAge = "public, max-age=300"
+
+// JWKSHandler publishes the access token verification keys
+type JWKSHandler struct {
+	keys *services.SigningKeys
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(keys *services.SigningKeys) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS - JSON Web Key Set
+// @Summary Access token verification keys
+// @Description Public keys of jwt.keys by kid, for services verifying RS256/EdDSA access tokens locally; empty with HS256
+// @Tags Discovery
+// @Produce json
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", jwksMaxAge)
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"auth-service/internal/logging"
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoggingHandler lets administrators change the log level and debug specific users or paths at runtime
+type LoggingHandler struct {
+	control *logging.Control
+}
+
+// NewLoggingHandler creates a new logging handler
+func NewLoggingHandler(control *logging.Control) *LoggingHandler {
+	return &LoggingHandler{control: control}
+}
+
+// GetLogging - Admin Logging API
+// @Summary Show the effective log level, overrides and debug targets
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/logging [get]
+func (h *LoggingHandler) GetLogging(c *gin.Context) {
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Logging state retrieved",
+		Data:    h.control.Current(),
+	})
+}
+
+// SetLogLevel - Admin Logging API
+// @Summary Override the log level on every replica for a bounded time
+// @Description The configured level comes back when duration (at most logging.max_override_duration) has passed
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/logging/level [put]
+func (h *LoggingHandler) SetLogLevel(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminLogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+	duration, ok := bindOverrideDuration(c, req.Duration)
+	if !ok {
+		return
+	}
+
+	state, err := h.control.SetLevel(c.Request.Context(), req.Level, duration, adminID.String())
+	if err != nil {
+		writeLoggingError(c, "Failed to change log level", err)
+		return
+	}
+
+	log.Printf("🔧 Admin %s set the log level to %s for %s", adminID, req.Level, duration)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Log level changed",
+		Data:    state,
+	})
+}
+
+// ResetLogLevel - Admin Logging API
+// @Summary End the log level override early
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/logging/level [delete]
+func (h *LoggingHandler) ResetLogLevel(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.control.ResetLevel(c.Request.Context())
+	if err != nil {
+		writeLoggingError(c, "Failed to reset log level", err)
+		return
+	}
+
+	log.Printf("🔧 Admin %s reset the log level to %s", adminID, state.Level)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Log level reset",
+		Data:    state,
+	})
+}
+
+// AddDebugTarget - Admin Logging API
+// @Summary Log the requests of one user, under one path, or both in detail for a bounded time
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/logging/debug-targets [post]
+func (h *LoggingHandler) AddDebugTarget(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminDebugTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+	duration, ok := bindOverrideDuration(c, req.Duration)
+	if !ok {
+		return
+	}
+
+	target, err := h.control.AddDebugTarget(c.Request.Context(), req.UserID, req.Path, duration, adminID.String())
+	if err != nil {
+		writeLoggingError(c, "Failed to add debug target", err)
+		return
+	}
+
+	log.Printf("🔧 Admin %s added debug target %s (user_id=%q path=%q) until %s",
+		adminID, target.ID, target.UserID, target.PathPrefix, target.ExpiresAt.UTC().Format(time.RFC3339))
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Debug target added",
+		Data:    target,
+	})
+}
+
+// RemoveDebugTarget - Admin Logging API
+// @Summary End a debug target early
+// @Tags Admin
+// @Security Bearer
+// @Param targetId path string true "Debug target ID"
+// @Produce json
+// @Router /api/v1/admin/logging/debug-targets/{targetId} [delete]
+func (h *LoggingHandler) RemoveDebugTarget(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.control.RemoveDebugTarget(c.Request.Context(), c.Param("targetId")); err != nil {
+		writeLoggingError(c, "Failed to remove debug target", err)
+		return
+	}
+
+	log.Printf("🔧 Admin %s removed debug target %s", adminID, c.Param("targetId"))
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Debug target removed",
+	})
+}
+
+// bindOverrideDuration parses a request's duration, writing a 400 when it isn't a Go duration
+func bindOverrideDuration(c *gin.Context, value string) (time.Duration, bool) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: "duration must be a duration such as \"15m\"",
+		})
+		return 0, false
+	}
+	return duration, true
+}
+
+// writeLoggingError maps logging control errors to statuses
+func writeLoggingError(c *gin.Context, title string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, logging.ErrDebugTargetNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, logging.ErrInvalidLevel), errors.Is(err, logging.ErrInvalidDuration), errors.Is(err, logging.ErrInvalidDebugTarget):
+		status = http.StatusBadRequest
+	}
+	localMiddleware.WriteError(c, status, models.ErrorResponse{
+		Error:   title,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"auth-service/internal/maintenance"
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceHandler lets administrators inspect and toggle read-only maintenance mode
+type MaintenanceHandler struct {
+	mode *maintenance.Mode
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(mode *maintenance.Mode) *MaintenanceHandler {
+	return &MaintenanceHandler{mode: mode}
+}
+
+// GetMaintenance - Admin Maintenance Mode API
+// @Summary Show whether the service is in read-only maintenance mode
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/maintenance [get]
+func (h *MaintenanceHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Maintenance mode retrieved",
+		Data:    h.mode.Current(),
+	})
+}
+
+// SetMaintenance - Admin Maintenance Mode API
+// @Summary Enter or leave read-only maintenance mode on every replica
+// @Description Use while the service runs against a read replica: token verification, refresh and reads keep
+// @Description working and other writes get 503. Not available when maintenance.read_only is set in the config
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/maintenance [put]
+func (h *MaintenanceHandler) SetMaintenance(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	state, err := h.mode.Set(c.Request.Context(), req.ReadOnly, req.Reason, adminID.String())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, maintenance.ErrForcedByConfig) {
+			status = http.StatusConflict
+		}
+		localMiddleware.WriteError(c, status, models.ErrorResponse{
+			Error:   "Failed to change maintenance mode",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message := "Read-only maintenance mode disabled"
+	if state.ReadOnly {
+		message = "Read-only maintenance mode enabled"
+	}
+	log.Printf("🔧 Admin %s: %s (%s)", adminID, message, req.Reason)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: message,
+		Data:    state,
+	})
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"auth-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ClientCredentialsToken - OAuth2 Token API
+// @Summary Issue a service token through the client-credentials grant
+// @Description Form-encoded per RFC 6749 section 4.4; the client authenticates with HTTP Basic or client_id and client_secret in the body
+// @Tags OAuth2
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Router /oauth2/token [post]
+func (h *AuthHandler) ClientCredentialsToken(c *gin.Context) {
+	// Token responses must never be cached (RFC 6749 section 5.1)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req models.ClientCredentialsTokenRequest
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+		writeOAuth2Error(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	clientID, clientSecret, basicAuth := c.Request.BasicAuth()
+	if basicAuth {
+		if req.ClientID != "" || req.ClientSecret != "" {
+			writeOAuth2Error(c, http.StatusBadRequest, "invalid_request", "use only one client authentication method")
+			return
+		}
+		// Basic credentials are form-encoded before being base64-encoded (RFC 6749 section 2.3.1)
+		var idErr, secretErr error
+		req.ClientID, idErr = url.QueryUnescape(clientID)
+		req.ClientSecret, secretErr = url.QueryUnescape(clientSecret)
+		if idErr != nil || secretErr != nil {
+			writeOAuth2Error(c, http.StatusBadRequest, "invalid_request", "malformed client credentials")
+			return
+		}
+	}
+	if req.GrantType == "" || req.ClientID == "" {
+		writeOAuth2Error(c, http.StatusBadRequest, "invalid_request", "grant_type and client_id are required")
+		return
+	}
+
+	response, err := h.authService.IssueClientCredentialsToken(&req, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrClientCredentialsDisabled), errors.Is(err, services.ErrUnsupportedGrantType):
+			writeOAuth2Error(c, http.StatusBadRequest, "unsupported_grant_type", err.Error())
+		case errors.Is(err, services.ErrInvalidClient):
+			if basicAuth {
+				c.Header("WWW-Authenticate", `Basic realm="oauth2"`)
+			}
+			writeOAuth2Error(c, http.StatusUnauthorized, "invalid_client", err.Error())
+		case errors.Is(err, services.ErrInvalidScope):
+			writeOAuth2Error(c, http.StatusBadRequest, "invalid_scope", err.Error())
+		default:
+			log.Printf("❌ Client credentials token for %s failed: %v", req.ClientID, err)
+			writeOAuth2Error(c, http.StatusInternalServerError, "server_error", "")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// writeOAuth2Error sends an error in the RFC 6749 section 5.2 format token clients expect
+func writeOAuth2Error(c *gin.Context, status int, code, description string) {
+	c.JSON(status, models.OAuth2ErrorResponse{Error: code, ErrorDescription: description})
+}
+
+// CreateOAuthClient - Admin OAuth Client API
+// @Summary Register a service for the client-credentials grant
+// @Description The client secret is shown only in this response
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/oauth-clients [post]
+func (h *AdminHandler) CreateOAuthClient(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminCreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	resp, err := h.authService.CreateOAuthClient(adminID, &req)
+	if err != nil {
+		localMiddleware.WriteError(c, oauthClientErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to register OAuth client",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "OAuth client registered; copy the secret now, it won't be shown again",
+		Data:    resp,
+	})
+}
+
+// ListOAuthClients - Admin OAuth Client Listing API
+// @Summary List the registered OAuth clients
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/oauth-clients [get]
+func (h *AdminHandler) ListOAuthClients(c *gin.Context) {
+	clients, err := h.authService.ListOAuthClients()
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list OAuth clients",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "OAuth clients retrieved successfully",
+		Data:    clients,
+	})
+}
+
+// GetOAuthClient - Admin OAuth Client API
+// @Summary Get a registered OAuth client
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/oauth-clients/{clientId} [get]
+func (h *AdminHandler) GetOAuthClient(c *gin.Context) {
+	client, err := h.authService.GetOAuthClient(c.Param("clientId"))
+	if err != nil {
+		localMiddleware.WriteError(c, oauthClientErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to get OAuth client",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "OAuth client retrieved successfully",
+		Data:    client,
+	})
+}
+
+// UpdateOAuthClient - Admin OAuth Client API
+// @Summary Rename an OAuth client or replace its scopes
+// @Description Tokens already issued keep their scopes until they expire
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/oauth-clients/{clientId} [patch]
+func (h *AdminHandler) UpdateOAuthClient(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminUpdateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	before, _ := h.authService.GetOAuthClient(c.Param("clientId"))
+	client, err := h.authService.UpdateOAuthClient(adminID, c.Param("clientId"), &req)
+	if err != nil {
+		localMiddleware.WriteError(c, oauthClientErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to update OAuth client",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	localMiddleware.SetAuditChange(c, "client", c.Param("clientId"), before, client)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "OAuth client updated",
+		Data:    client,
+	})
+}
+
+// RotateOAuthClientSecret - Admin OAuth Client API
+// @Summary Replace an OAuth client's secret
+// @Description The old secret stops working immediately; the new one is shown only in this response
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/oauth-clients/{clientId}/secret [post]
+func (h *AdminHandler) RotateOAuthClientSecret(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.authService.RotateOAuthClientSecret(adminID, c.Param("clientId"))
+	if err != nil {
+		localMiddleware.WriteError(c, oauthClientErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to rotate OAuth client secret",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "OAuth client secret rotated; copy it now, it won't be shown again",
+		Data:    resp,
+	})
+}
+
+// DeleteOAuthClient - Admin OAuth Client API
+// @Summary Delete an OAuth client
+// @Description The client gets no new tokens and /api/v1/verify rejects the ones it holds
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/oauth-clients/{clientId} [delete]
+func (h *AdminHandler) DeleteOAuthClient(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	before, _ := h.authService.GetOAuthClient(c.Param("clientId"))
+	if err := h.authService.DeleteOAuthClient(adminID, c.Param("clientId")); err != nil {
+		localMiddleware.WriteError(c, oauthClientErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to delete OAuth client",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	localMiddleware.SetAuditChange(c, "client", c.Param("clientId"), before, nil)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "OAuth client deleted",
+	})
+}
+
+// oauthClientErrorStatus maps OAuth client registry errors to HTTP statuses
+func oauthClientErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrOAuthClientID), errors.Is(err, services.ErrOAuthClientScope):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrClientCredentialsDisabled), errors.Is(err, repositories.ErrOAuthClientNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrOAuthClientExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oauthNonceCookie holds the random nonce binding an OAuth flow to the browser that started it. The state
+// in the provider's URL can be passed to someone else; the cookie stays in the starting browser
+const oauthNonceCookie = "oauth_nonce"
+
+// oauthNonceCookiePath limits the cookie to the OAuth endpoints, the callback included
+const oauthNonceCookiePath = "/api/v1/auth/oauth"
+
+// setOAuthNonce stores a new nonce in the browser's nonce cookie and returns it. Apple posts its callback
+// cross-site, which only SameSite=None cookies survive; every other provider redirects back with a GET
+func (h *AuthHandler) setOAuthNonce(c *gin.Context, provider string) (string, error) {
+	nonce, err := generateState()
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(c.Writer, h.oauthNonceCookieFor(provider, nonce, 0))
+	return nonce, nil
+}
+
+// takeOAuthNonce returns the nonce cookie, "" when there is none, and expires it; a nonce serves one callback
+func (h *AuthHandler) takeOAuthNonce(c *gin.Context, provider string) string {
+	nonce, err := c.Cookie(oauthNonceCookie)
+	if err != nil {
+		return ""
+	}
+	http.SetCookie(c.Writer, h.oauthNonceCookieFor(provider, "", -1))
+	return nonce
+}
+
+// oauthNonceCookieFor builds the HttpOnly nonce cookie; maxAge 0 makes it last for the browser session
+func (h *AuthHandler) oauthNonceCookieFor(provider, value string, maxAge int) *http.Cookie {
+	sameSite := http.SameSiteLaxMode
+	if provider == models.OAuthProviderApple {
+		sameSite = http.SameSiteNoneMode
+	}
+	return &http.Cookie{
+		Name:     oauthNonceCookie,
+		Value:    value,
+		Path:     oauthNonceCookiePath,
+		MaxAge:   maxAge,
+		Secure:   !h.refreshCookie.AllowInsecure || sameSite == http.SameSiteNoneMode,
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"auth-service/internal/services"
+	"auth-service/internal/webpush"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListPushSubscriptions - Push Notification API
+// @Summary List the browsers subscribed to the user's notifications
+// @Description Also returns vapid_public_key, the applicationServerKey to pass to pushManager.subscribe
+// @Tags Notifications
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/push-subscriptions [get]
+func (h *AuthHandler) ListPushSubscriptions(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.authService.ListPushSubscriptions(userID)
+	if err != nil {
+		localMiddleware.WriteError(c, pushErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to list push subscriptions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Push subscriptions retrieved",
+		Data:    subscriptions,
+	})
+}
+
+// SubscribePush - Push Notification API
+// @Summary Subscribe this browser to the user's notifications
+// @Description Takes PushSubscription.toJSON(); subscribing the same endpoint again replaces its keys
+// @Tags Notifications
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/auth/push-subscriptions [post]
+func (h *AuthHandler) SubscribePush(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.PushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	subscription, err := h.authService.SubscribePush(userID, &req, clientInfo(c))
+	if err != nil {
+		localMiddleware.WriteError(c, pushErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to subscribe to push notifications",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Subscribed to push notifications",
+		Data:    subscription,
+	})
+}
+
+// UnsubscribePush - Push Notification API
+// @Summary Stop pushing notifications to a browser
+// @Tags Notifications
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/push-subscriptions/{subscriptionId} [delete]
+func (h *AuthHandler) UnsubscribePush(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	subscriptionID, err := uuid.Parse(c.Param("subscriptionId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid subscription ID",
+			Message: "Subscription ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.authService.UnsubscribePush(userID, subscriptionID); err != nil {
+		localMiddleware.WriteError(c, pushErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to unsubscribe from push notifications",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Unsubscribed from push notifications",
+	})
+}
+
+// pushErrorStatus maps push subscription errors to HTTP statuses
+func pushErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrWebPushDisabled), errors.Is(err, repositories.ErrPushSubscriptionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrTooManyPushSubscriptions):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrPushEndpointNotAllowed), errors.Is(err, webpush.ErrInvalidSubscription):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"auth-service/internal/config"
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Browser clients opt in to refresh token cookies per request with this header
+const (
+	TokenTransportHeader = "X-Token-Transport"
+	TokenTransportCookie = "cookie"
+)
+
+// WithRefreshCookie enables the refresh token cookie transport described by cfg
+func (h *AuthHandler) WithRefreshCookie(cfg config.RefreshCookieConfig) *AuthHandler {
+	h.refreshCookie = cfg
+	return h
+}
+
+// wantsRefreshCookie reports whether the request asked for the refresh token in a cookie
+func (h *AuthHandler) wantsRefreshCookie(c *gin.Context) bool {
+	return h.refreshCookie.Enabled && strings.EqualFold(c.GetHeader(TokenTransportHeader), TokenTransportCookie)
+}
+
+// writeAuthResponse writes a token pair, moving the refresh token into the refresh cookie when the
+// client asked for cookie transport
+func (h *AuthHandler) writeAuthResponse(c *gin.Context, status int, response *models.AuthResponse) {
+	if response.RefreshToken != "" && h.wantsRefreshCookie(c) {
+		if !h.setRefreshCookies(c, response.RefreshToken) {
+			return
+		}
+		response.RefreshToken = ""
+	}
+	c.JSON(status, response)
+}
+
+// setRefreshCookies stores the refresh token in its HttpOnly cookie and issues a new CSRF token, in a
+// cookie the web app can read and in the CSRF response header. It writes the error response on failure
+func (h *AuthHandler) setRefreshCookies(c *gin.Context, refreshToken string) bool {
+	csrfToken, err := generateState()
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Message: "Failed to generate CSRF token",
+		})
+		return false
+	}
+
+	maxAge := refreshTokenMaxAge(refreshToken)
+	http.SetCookie(c.Writer, h.refreshCookieFor(h.refreshCookie.Name, refreshToken, h.refreshCookie.Path, maxAge, true))
+	http.SetCookie(c.Writer, h.refreshCookieFor(h.refreshCookie.CSRFCookie, csrfToken, "/", maxAge, false))
+	c.Header(h.refreshCookie.CSRFHeader, csrfToken)
+	return true
+}
+
+// clearRefreshCookies expires the refresh and CSRF cookies
+func (h *AuthHandler) clearRefreshCookies(c *gin.Context) {
+	http.SetCookie(c.Writer, h.refreshCookieFor(h.refreshCookie.Name, "", h.refreshCookie.Path, -1, true))
+	http.SetCookie(c.Writer, h.refreshCookieFor(h.refreshCookie.CSRFCookie, "", "/", -1, false))
+}
+
+// refreshCookieFor builds a cookie with the configured domain, SameSite mode and Secure flag
+func (h *AuthHandler) refreshCookieFor(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.refreshCookie.Domain,
+		MaxAge:   maxAge,
+		Secure:   !h.refreshCookie.AllowInsecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSiteMode(h.refreshCookie.SameSite),
+	}
+}
+
+// bindRefreshCookie fills req from the refresh cookie after checking the CSRF header matches the CSRF
+// cookie, and records the browser context the service checks the origin against. It writes the error
+// response and returns false when the request can't be used
+func (h *AuthHandler) bindRefreshCookie(c *gin.Context, req *models.RefreshTokenRequest) bool {
+	refreshToken, err := c.Cookie(h.refreshCookie.Name)
+	if err != nil || refreshToken == "" {
+		localMiddleware.WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Token refresh failed",
+			Message: "Refresh token cookie is missing",
+		})
+		return false
+	}
+
+	csrfCookie, _ := c.Cookie(h.refreshCookie.CSRFCookie)
+	csrfHeader := c.GetHeader(h.refreshCookie.CSRFHeader)
+	if csrfCookie == "" || subtle.ConstantTimeCompare([]byte(csrfCookie), []byte(csrfHeader)) != 1 {
+		localMiddleware.WriteError(c, http.StatusForbidden, models.ErrorResponse{
+			Error:   "Token refresh failed",
+			Message: "CSRF token mismatch",
+		})
+		return false
+	}
+
+	req.RefreshToken = refreshToken
+	req.Browser = &models.BrowserContext{
+		Origin:    c.GetHeader("Origin"),
+		FetchSite: c.GetHeader("Sec-Fetch-Site"),
+	}
+	return true
+}
+
+// refreshTokenMaxAge is the seconds until the refresh token expires, so the cookie goes with it;
+// 0 (a session cookie) if the expiry can't be read. The service has already signed or verified the token
+func refreshTokenMaxAge(refreshToken string) int {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(refreshToken, claims); err != nil {
+		return 0
+	}
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return 0
+	}
+	if remaining := int(time.Until(expiresAt.Time).Seconds()); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// sameSiteMode maps the configured SameSite mode to its cookie attribute
+func sameSiteMode(mode string) http.SameSite {
+	switch mode {
+	case config.SameSiteLax:
+		return http.SameSiteLaxMode
+	case config.SameSiteNone:
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RevocationHandler publishes the refresh token revocation snapshot to edge gateways
+type RevocationHandler struct {
+	snapshots *services.RevocationSnapshots
+}
+
+// NewRevocationHandler creates a new revocation snapshot handler
+func NewRevocationHandler(snapshots *services.RevocationSnapshots) *RevocationHandler {
+	return &RevocationHandler{snapshots: snapshots}
+}
+
+// GetRefreshRevocations - Refresh token revocation snapshot
+// @Summary Signed list of revoked refresh token hashes for edge gateways
+// @Description A JWT signed with the access token key (see /.well-known/jwks.json) listing the SHA-256 hex hashes of revoked refresh tokens with their expiry. With since, only what was revoked after that version
+// @Tags Discovery
+// @Produce application/jwt
+// @Param since query int false "Version the edge already holds"
+// @Router /revocations/refresh-tokens [get]
+func (h *RevocationHandler) GetRefreshRevocations(c *gin.Context) {
+	var since int64
+	if value := c.Query("since"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid version",
+				Message: "since must be a version returned in X-Revocation-Version",
+			})
+			return
+		}
+		since = parsed
+	}
+
+	snapshot, err := h.snapshots.Snapshot(since)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrRevocationSnapshotDisabled):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrRevocationSnapshotNotReady):
+			status = http.StatusServiceUnavailable
+		}
+		localMiddleware.WriteError(c, status, models.ErrorResponse{
+			Error:   "Revocation snapshot unavailable",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("X-Revocation-Version", strconv.FormatInt(snapshot.Version, 10))
+	// Caches may serve it until the next rebuild; every rebuild is signed with a later exp
+	maxAge := max(time.Until(snapshot.FreshUntil), 0)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	c.Data(http.StatusOK, "application/jwt", []byte(snapshot.Token))
+}
@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"auth-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListRoles - Admin Role API
+// @Summary List the roles and their permissions
+// @Description System roles mirror users.role; custom roles are assigned to users next to it
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/roles [get]
+func (h *AdminHandler) ListRoles(c *gin.Context) {
+	roles, err := h.authService.ListRoles()
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list roles",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Roles retrieved successfully",
+		Data:    roles,
+	})
+}
+
+// GetRole - Admin Role API
+// @Summary Get a role and its permissions
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/roles/{roleId} [get]
+func (h *AdminHandler) GetRole(c *gin.Context) {
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+
+	role, err := h.authService.GetRole(roleID)
+	if err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to get role",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Role retrieved successfully",
+		Data:    role,
+	})
+}
+
+// CreateRole - Admin Role API
+// @Summary Create a custom role
+// @Description Permissions are given by name, e.g. users:read, and must exist
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/roles [post]
+func (h *AdminHandler) CreateRole(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminCreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	role, err := h.authService.CreateRole(adminID, &req)
+	if err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to create role",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	localMiddleware.SetAuditChange(c, "role", role.ID.String(), nil, role)
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Role created",
+		Data:    role,
+	})
+}
+
+// UpdateRole - Admin Role API
+// @Summary Change a role's display name, description or priority
+// @Description Names can't change; system roles can be described too
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/roles/{roleId} [patch]
+func (h *AdminHandler) UpdateRole(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminUpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	before, _ := h.authService.GetRole(roleID) // For the audit log; a missing role fails the update below
+	role, err := h.authService.UpdateRole(adminID, roleID, &req)
+	if err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to update role",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	localMiddleware.SetAuditChange(c, "role", roleID.String(), before, role)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Role updated",
+		Data:    role,
+	})
+}
+
+// DeleteRole - Admin Role API
+// @Summary Delete a custom role
+// @Description Users who held it lose it and their tokens are revoked; system roles can't be deleted
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/roles/{roleId} [delete]
+func (h *AdminHandler) DeleteRole(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+
+	before, _ := h.authService.GetRole(roleID)
+	if err := h.authService.DeleteRole(adminID, roleID); err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to delete role",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	localMiddleware.SetAuditChange(c, "role", roleID.String(), before, nil)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Role deleted",
+	})
+}
+
+// SetRolePermissions - Admin Role API
+// @Summary Replace a role's permissions
+// @Description An empty list removes them all
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/roles/{roleId}/permissions [put]
+func (h *AdminHandler) SetRolePermissions(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminSetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	before, _ := h.authService.GetRole(roleID)
+	role, err := h.authService.SetRolePermissions(adminID, roleID, &req)
+	if err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to set role permissions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	localMiddleware.SetAuditChange(c, "role", roleID.String(), before, role)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Role permissions replaced",
+		Data:    role,
+	})
+}
+
+// ListPermissions - Admin Permission API
+// @Summary List the permissions roles can be given
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/permissions [get]
+func (h *AdminHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.authService.ListPermissions()
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list permissions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Permissions retrieved successfully",
+		Data:    permissions,
+	})
+}
+
+// CreatePermission - Admin Permission API
+// @Summary Create the permission resource:action
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/permissions [post]
+func (h *AdminHandler) CreatePermission(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminCreatePermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	permission, err := h.authService.CreatePermission(adminID, &req)
+	if err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to create permission",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	localMiddleware.SetAuditChange(c, "permission", permission.ID.String(), nil, permission)
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Permission created",
+		Data:    permission,
+	})
+}
+
+// DeletePermission - Admin Permission API
+// @Summary Delete a permission
+// @Description Every role that had it loses it
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/permissions/{permissionId} [delete]
+func (h *AdminHandler) DeletePermission(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	permissionID, err := uuid.Parse(c.Param("permissionId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid permission ID",
+			Message: "Permission ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.authService.DeletePermission(adminID, permissionID); err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to delete permission",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Permission deleted",
+	})
+}
+
+// GetUserRoles - Admin User Role API
+// @Summary List a user's roles and the permissions they give
+// @Description The system role of users.role, roles from active grants and assigned custom roles
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/users/{userId}/roles [get]
+func (h *AdminHandler) GetUserRoles(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.authService.GetUserRoles(userID)
+	if err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to get user roles",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// AssignRole - Admin User Role API
+// @Summary Assign a custom role to a user
+// @Description The user's next login or token refresh carries it; system roles follow users.role instead
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/users/{userId}/roles [post]
+func (h *AdminHandler) AssignRole(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminAssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	assignment, err := h.authService.AssignRole(adminID, userID, &req)
+	if err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to assign role",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	localMiddleware.SetAuditChange(c, "user", userID.String(), nil, assignment)
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Role assigned",
+		Data:    assignment,
+	})
+}
+
+// UnassignRole - Admin User Role API
+// @Summary Take a custom role away from a user
+// @Description Tokens the user was issued while holding it stop working
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/users/{userId}/roles/{roleId} [delete]
+func (h *AdminHandler) UnassignRole(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	roleID, ok := roleIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.UnassignRole(adminID, userID, roleID); err != nil {
+		localMiddleware.WriteError(c, roleErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to take role away",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Role taken away",
+	})
+}
+
+// roleIDParam parses the roleId path parameter, responding 400 when it is malformed
+func roleIDParam(c *gin.Context) (uuid.UUID, bool) {
+	roleID, err := uuid.Parse(c.Param("roleId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid role ID",
+			Message: "Role ID must be a valid UUID",
+		})
+		return uuid.Nil, false
+	}
+	return roleID, true
+}
+
+// userIDParam parses the userId path parameter, responding 400 when it is malformed
+func userIDParam(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// roleErrorStatus maps role and permission errors to HTTP statuses
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrRoleName), errors.Is(err, services.ErrPermissionName),
+		errors.Is(err, services.ErrUnknownPermission):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrSystemRole):
+		return http.StatusForbidden
+	case errors.Is(err, repositories.ErrRoleNotFound), errors.Is(err, repositories.ErrPermissionNotFound),
+		errors.Is(err, repositories.ErrRoleAssignmentNotFound), err.Error() == "user not found":
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrRoleExists), errors.Is(err, services.ErrPermissionExists),
+		errors.Is(err, repositories.ErrRoleAlreadyAssigned):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
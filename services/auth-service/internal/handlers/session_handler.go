@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListSessions - Session Management API
+// @Summary List the user's signed-in devices, most recently used first
+// @Description IP address, user agent, creation and last use of every active session; current marks this one
+// @Tags Sessions
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.authService.ListUserSessions(userID, c.GetString("token"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list sessions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Sessions retrieved",
+		Data:    sessions,
+	})
+}
+
+// RevokeSession - Session Management API
+// @Summary Sign out one of the user's devices
+// @Description Deletes the session's refresh token and blacklists its access token; revoking the current session logs this device out
+// @Tags Sessions
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/sessions/{sessionId} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid session ID",
+			Message: "Session ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.authService.RevokeUserSession(userID, sessionID, clientInfo(c)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			status = http.StatusNotFound
+		}
+		localMiddleware.WriteError(c, status, models.ErrorResponse{
+			Error:   "Failed to revoke session",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Session revoked",
+	})
+}
+
+// RevokeOtherSessions - Session Management API
+// @Summary Sign out every other device
+// @Description Revokes all of the user's sessions except the one making the request
+// @Tags Sessions
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/sessions/revoke-others [post]
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.authService.RevokeOtherSessions(userID, c.GetString("token"), clientInfo(c))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to revoke sessions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Other sessions revoked",
+		Data:    models.RevokedSessionsResponse{Revoked: revoked},
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/status"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusHandler serves the public status page and its admin incident controls
+type StatusHandler struct {
+	page *status.Page
+}
+
+// NewStatusHandler creates a new status handler
+func NewStatusHandler(page *status.Page) *StatusHandler {
+	return &StatusHandler{page: page}
+}
+
+// GetStatus - Public Status Page API
+// @Summary Service status
+// @Description Component health, uptime percentages and active incidents (cached)
+// @Tags Status
+// @Produce json
+// @Router /status [get]
+func (h *StatusHandler) GetStatus(c *gin.Context) {
+	h.page.Handler()(c)
+}
+
+// CreateIncident declares an incident on the status page (admin only)
+func (h *StatusHandler) CreateIncident(c *gin.Context) {
+	var incident status.Incident
+	if err := c.ShouldBindJSON(&incident); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	if err := h.page.CreateIncident(c.Request.Context(), &incident); err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to create incident",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Incident created",
+		Data:    incident,
+	})
+}
+
+// ResolveIncident removes an incident from the status page (admin only)
+func (h *StatusHandler) ResolveIncident(c *gin.Context) {
+	err := h.page.ResolveIncident(c.Request.Context(), c.Param("incidentId"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, status.ErrIncidentNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
+			Error:   "Failed to resolve incident",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Incident resolved",
+	})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/telemetry"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultFunnelHours is the report window when the hours query parameter is omitted
+const defaultFunnelHours = 24
+
+// TelemetryHandler serves aggregated product analytics to administrators
+type TelemetryHandler struct {
+	funnel *telemetry.LoginFunnel
+}
+
+// NewTelemetryHandler creates a new telemetry handler; funnel may be nil when telemetry is disabled
+func NewTelemetryHandler(funnel *telemetry.LoginFunnel) *TelemetryHandler {
+	return &TelemetryHandler{funnel: funnel}
+}
+
+// GetLoginFunnel returns hourly login funnel counts, conversion and drop-off (admin only)
+// Query: hours (default 24, capped at the telemetry retention)
+func (h *TelemetryHandler) GetLoginFunnel(c *gin.Context) {
+	if h.funnel == nil {
+		localMiddleware.WriteError(c, http.StatusNotFound, models.ErrorResponse{
+			Error:   "Telemetry disabled",
+			Message: "login funnel telemetry is not enabled",
+		})
+		return
+	}
+
+	hours := defaultFunnelHours
+	if raw := c.Query("hours"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request",
+				Message: "hours must be a positive integer",
+			})
+			return
+		}
+		hours = parsed
+	}
+
+	report, err := h.funnel.Report(c.Request.Context(), hours)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to load login funnel",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Login funnel retrieved",
+		Data:    report,
+	})
+}
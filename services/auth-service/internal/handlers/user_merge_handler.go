@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"auth-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MergeUser - Admin Account Merge API
+// @Summary Merge a duplicate account into this one
+// @Description Moves the duplicate's sessions, activities, notifications, preferences, passkeys and linked identities here and soft-deletes it. The merge can't be undone
+// @Tags Admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/admin/users/{userId}/merge [post]
+func (h *AdminHandler) MergeUser(c *gin.Context) {
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req models.AdminMergeUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	resp, err := h.authService.MergeUsers(adminID, targetID, &req, clientInfo(c))
+	if err != nil {
+		localMiddleware.WriteError(c, userMergeErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to merge accounts",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Accounts merged",
+		Data:    resp,
+	})
+}
+
+// ListUserMerges - Admin Account Merge Audit API
+// @Summary List the merges into or out of an account, newest first
+// @Tags Admin
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/admin/users/{userId}/merges [get]
+func (h *AdminHandler) ListUserMerges(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	merges, err := h.authService.ListUserMerges(userID)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list account merges",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Account merges retrieved successfully",
+		Data:    merges,
+	})
+}
+
+// userMergeErrorStatus maps account merge errors to HTTP statuses
+func userMergeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrMergeSameUser):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrMergeOwnAccount):
+		return http.StatusForbidden
+	case errors.Is(err, repositories.ErrMergeUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrMergeTargetInactive), errors.Is(err, repositories.ErrMergeIdentityConflict):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
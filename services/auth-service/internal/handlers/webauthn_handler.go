@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"auth-service/internal/services"
+	"auth-service/internal/webauthn"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BeginPasskeyRegistration - Passkey Registration API
+// @Summary Start registering a passkey
+// @Description Returns options for navigator.credentials.create; the challenge expires after webauthn.challenge_ttl
+// @Tags Passkeys
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/webauthn/register/begin [post]
+func (h *AuthHandler) BeginPasskeyRegistration(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	options, err := h.authService.BeginPasskeyRegistration(userID)
+	if err != nil {
+		localMiddleware.WriteError(c, passkeyErrorStatus(err), models.ErrorResponse{
+			Error:   "Passkey registration failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Passkey registration started",
+		Data:    gin.H{"publicKey": options},
+	})
+}
+
+// FinishPasskeyRegistration - Passkey Registration API
+// @Summary Register a passkey
+// @Description Verifies the navigator.credentials.create response (serialized with toJSON) and stores the passkey
+// @Tags Passkeys
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/auth/webauthn/register/finish [post]
+func (h *AuthHandler) FinishPasskeyRegistration(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.PasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	credential, err := h.authService.FinishPasskeyRegistration(userID, &req, clientInfo(c))
+	if err != nil {
+		localMiddleware.WriteError(c, passkeyErrorStatus(err), models.ErrorResponse{
+			Error:   "Passkey registration failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Passkey registered",
+		Data:    credential,
+	})
+}
+
+// ListPasskeys - Passkey Management API
+// @Summary List the user's passkeys, most recently used first
+// @Tags Passkeys
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/webauthn/credentials [get]
+func (h *AuthHandler) ListPasskeys(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := h.authService.ListPasskeys(userID)
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list passkeys",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Passkeys retrieved",
+		Data:    credentials,
+	})
+}
+
+// RenamePasskey - Passkey Management API
+// @Summary Rename a passkey
+// @Tags Passkeys
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Router /api/v1/auth/webauthn/credentials/{passkeyId} [patch]
+func (h *AuthHandler) RenamePasskey(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	passkeyID, ok := passkeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	credential, err := h.authService.RenamePasskey(userID, passkeyID, req.Name)
+	if err != nil {
+		localMiddleware.WriteError(c, passkeyErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to rename passkey",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Passkey renamed",
+		Data:    credential,
+	})
+}
+
+// DeletePasskey - Passkey Management API
+// @Summary Remove a passkey
+// @Description The passkey can no longer be used to log in; sessions it started are not ended
+// @Tags Passkeys
+// @Security Bearer
+// @Produce json
+// @Router /api/v1/auth/webauthn/credentials/{passkeyId} [delete]
+func (h *AuthHandler) DeletePasskey(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	passkeyID, ok := passkeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.DeletePasskey(userID, passkeyID, clientInfo(c)); err != nil {
+		localMiddleware.WriteError(c, passkeyErrorStatus(err), models.ErrorResponse{
+			Error:   "Failed to remove passkey",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Passkey removed",
+	})
+}
+
+// BeginPasskeyLogin - Passkey Login API
+// @Summary Start a passwordless login
+// @Description Returns options for navigator.credentials.get; without an email any discoverable passkey may be used
+// @Tags Passkeys
+// @Accept json
+// @Produce json
+// @Router /api/v1/auth/webauthn/login/begin [post]
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	var req models.PasskeyLoginBeginRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			localMiddleware.WriteBindingError(c, err)
+			return
+		}
+	}
+
+	options, err := h.authService.BeginPasskeyLogin(&req)
+	if err != nil {
+		localMiddleware.WriteError(c, passkeyErrorStatus(err), models.ErrorResponse{
+			Error:   "Login failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Passkey login started",
+		Data:    gin.H{"publicKey": options},
+	})
+}
+
+// FinishPasskeyLogin - Passkey Login API
+// @Summary Log in with a passkey
+// @Description Verifies the navigator.credentials.get response and returns the same token pair as a password login
+// @Tags Passkeys
+// @Accept json
+// @Produce json
+// @Router /api/v1/auth/webauthn/login/finish [post]
+func (h *AuthHandler) FinishPasskeyLogin(c *gin.Context) {
+	var req models.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		localMiddleware.WriteBindingError(c, err)
+		return
+	}
+
+	response, err := h.authService.FinishPasskeyLogin(&req, clientInfo(c))
+	if err != nil {
+		statusCode := passkeyErrorStatus(err)
+		if errors.Is(err, services.ErrPasskeyChallenge) || errors.Is(err, webauthn.ErrInvalidResponse) {
+			statusCode = http.StatusUnauthorized
+		}
+		localMiddleware.WriteError(c, statusCode, models.ErrorResponse{
+			Error:   "Login failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.writeAuthResponse(c, http.StatusOK, response)
+}
+
+// passkeyIDParam parses the passkeyId path parameter, writing a 400 when it isn't a UUID
+func passkeyIDParam(c *gin.Context) (uuid.UUID, bool) {
+	passkeyID, err := uuid.Parse(c.Param("passkeyId"))
+	if err != nil {
+		localMiddleware.WriteError(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid passkey ID",
+			Message: "Passkey ID must be a valid UUID",
+		})
+		return uuid.Nil, false
+	}
+	return passkeyID, true
+}
+
+// passkeyErrorStatus maps passkey service errors to HTTP statuses
+func passkeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPasskeysDisabled), errors.Is(err, repositories.ErrPasskeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPasskeyAlreadyEnrolled):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrPasskeyLoginFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrTwoFactorEnrollmentOverdue), errors.Is(err, services.ErrCountryBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrUnknownClient), errors.Is(err, services.ErrPasskeyChallenge),
+		errors.Is(err, webauthn.ErrInvalidResponse), errors.Is(err, webauthn.ErrChallengeMismatch),
+		errors.Is(err, webauthn.ErrOriginNotAllowed), errors.Is(err, webauthn.ErrRPIDMismatch),
+		errors.Is(err, webauthn.ErrUserNotPresent), errors.Is(err, webauthn.ErrUserNotVerified):
+		return http.StatusBadRequest
+	}
+	switch err.Error() {
+	case "account is temporarily locked":
+		return http.StatusTooManyRequests
+	case "account is inactive":
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,181 @@
+package instrumentation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the histogram upper bounds in seconds for method latency
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Collector writes metrics in the Prometheus text exposition format. Any type implementing it, such as the
+// background jobs' and middlewares' metrics, can be passed to the /metrics endpoint as a collector
+type Collector interface {
+	WritePrometheus(w io.Writer)
+}
+
+// OpenMetricsCollector is implemented by collectors with more to say in the OpenMetrics format,
+// such as exemplars; the /metrics endpoint uses it when the scraper accepts OpenMetrics
+type OpenMetricsCollector interface {
+	Collector
+	WriteOpenMetrics(w io.Writer)
+}
+
+// methodKey identifies a single instrumented method
+type methodKey struct {
+	component string
+	method    string
+}
+
+// methodStats holds the counters for one method
+type methodStats struct {
+	calls   uint64
+	errors  uint64
+	sum     float64
+	buckets []uint64
+	// exemplars holds the latest sampled trace per bucket, the last one for +Inf
+	exemplars []*exemplar
+}
+
+// exemplar links a latency observation to the trace it was recorded in
+type exemplar struct {
+	traceID string
+	value   float64
+	at      time.Time
+}
+
+// Metrics aggregates per-method call counts, error counts and latency histograms
+type Metrics struct {
+	mu      sync.Mutex
+	methods map[methodKey]*methodStats
+	// exemplarSampleRate is the trace sample rate; calls in sampled traces become exemplars. 0 disables them
+	exemplarSampleRate float64
+}
+
+// NewMetrics creates an empty metrics registry
+func NewMetrics() *Metrics {
+	return &Metrics{methods: make(map[methodKey]*methodStats)}
+}
+
+// EnableExemplars attaches the trace IDs of traced calls to the latency histogram, for traces sampled
+// at sampleRate so every exemplar points at a trace the tracer recorded
+func (m *Metrics) EnableExemplars(sampleRate float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exemplarSampleRate = sampleRate
+}
+
+// ObserveCall records the outcome and latency of a call
+func (m *Metrics) ObserveCall(component, method string, duration time.Duration, err error) {
+	m.observe(component, method, duration, err, "")
+}
+
+// ObserveTracedCall records a call like ObserveCall and, when its trace is sampled, keeps it as the
+// exemplar of its latency bucket
+func (m *Metrics) ObserveTracedCall(component, method string, duration time.Duration, err error, traceID string) {
+	m.observe(component, method, duration, err, traceID)
+}
+
+// observe records a call; a non-empty traceID may become an exemplar
+func (m *Metrics) observe(component, method string, duration time.Duration, err error, traceID string) {
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := methodKey{component: component, method: method}
+	stats, exists := m.methods[key]
+	if !exists {
+		stats = &methodStats{
+			buckets:   make([]uint64, len(latencyBuckets)),
+			exemplars: make([]*exemplar, len(latencyBuckets)+1),
+		}
+		m.methods[key] = stats
+	}
+
+	stats.calls++
+	stats.sum += seconds
+	if err != nil {
+		stats.errors++
+	}
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+		}
+	}
+
+	if traceID != "" && Sampled(traceID, m.exemplarSampleRate) {
+		// An exemplar belongs to the smallest bucket containing the observation
+		bucket := sort.SearchFloat64s(latencyBuckets, seconds)
+		stats.exemplars[bucket] = &exemplar{traceID: traceID, value: seconds, at: time.Now()}
+	}
+}
+
+// WritePrometheus writes all method metrics in the Prometheus text format
+func (m *Metrics) WritePrometheus(w io.Writer) {
+	m.write(w, false)
+}
+
+// WriteOpenMetrics writes all method metrics in the OpenMetrics format, with the latency exemplars
+func (m *Metrics) WriteOpenMetrics(w io.Writer) {
+	m.write(w, true)
+}
+
+// write writes the method metrics; exemplars are only valid in the OpenMetrics format
+func (m *Metrics) write(w io.Writer, exemplars bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]methodKey, 0, len(m.methods))
+	for key := range m.methods {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].component != keys[j].component {
+			return keys[i].component < keys[j].component
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	fmt.Fprintln(w, "# HELP auth_service_method_calls_total Total number of service layer method calls")
+	fmt.Fprintln(w, "# TYPE auth_service_method_calls_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(w, "auth_service_method_calls_total{%s} %d\n", labels(key), m.methods[key].calls)
+	}
+
+	fmt.Fprintln(w, "# HELP auth_service_method_errors_total Total number of service layer method calls that returned an error")
+	fmt.Fprintln(w, "# TYPE auth_service_method_errors_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(w, "auth_service_method_errors_total{%s} %d\n", labels(key), m.methods[key].errors)
+	}
+
+	fmt.Fprintln(w, "# HELP auth_service_method_duration_seconds Service layer method latency")
+	fmt.Fprintln(w, "# TYPE auth_service_method_duration_seconds histogram")
+	for _, key := range keys {
+		stats := m.methods[key]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(w, "auth_service_method_duration_seconds_bucket{%s,le=\"%g\"} %d%s\n", labels(key), bound, stats.buckets[i],
+				exemplarSuffix(stats.exemplars[i], exemplars))
+		}
+		fmt.Fprintf(w, "auth_service_method_duration_seconds_bucket{%s,le=\"+Inf\"} %d%s\n", labels(key), stats.calls,
+			exemplarSuffix(stats.exemplars[len(latencyBuckets)], exemplars))
+		fmt.Fprintf(w, "auth_service_method_duration_seconds_sum{%s} %g\n", labels(key), stats.sum)
+		fmt.Fprintf(w, "auth_service_method_duration_seconds_count{%s} %d\n", labels(key), stats.calls)
+	}
+}
+
+// exemplarSuffix renders a bucket's exemplar in the OpenMetrics syntax, or nothing
+func exemplarSuffix(e *exemplar, enabled bool) string {
+	if !enabled || e == nil {
+		return ""
+	}
+	return fmt.Sprintf(" # {trace_id=%q} %g %.3f", e.traceID, e.value, float64(e.at.UnixMilli())/1000)
+}
+
+// labels renders the label set identifying a method
+func labels(key methodKey) string {
+	return fmt.Sprintf("component=%q,method=%q", key.component, key.method)
+}
@@ -0,0 +1,129 @@
+package instrumentation
+
+import (
+	"log"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// Observer receives one event per instrumented method call
+// Implementations must be safe for concurrent use and must not block the caller
+type Observer interface {
+	ObserveCall(component, method string, duration time.Duration, err error)
+}
+
+// TracedObserver is implemented by observers that can link a call to the trace it ran in
+type TracedObserver interface {
+	Observer
+	ObserveTracedCall(component, method string, duration time.Duration, err error, traceID string)
+}
+
+// ObserveTraced reports a call with its trace ID to observers that take one, and without it to the rest
+func ObserveTraced(observer Observer, component, method string, duration time.Duration, err error, traceID string) {
+	if observer == nil {
+		return
+	}
+	if traced, ok := observer.(TracedObserver); ok && traceID != "" {
+		traced.ObserveTracedCall(component, method, duration, err, traceID)
+		return
+	}
+	observer.ObserveCall(component, method, duration, err)
+}
+
+// Sampled reports whether the trace with this W3C trace ID is sampled at rate (0.0 - 1.0)
+// The decision depends only on the trace ID, so spans and metric exemplars agree on which traces exist
+func Sampled(traceID string, rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	if rate >= 1 {
+		return true
+	}
+	if len(traceID) != 32 {
+		return rand.Float64() < rate
+	}
+	// The low 8 bytes of a trace ID are random, as in OpenTelemetry's trace ID ratio sampler
+	low, err := strconv.ParseUint(traceID[16:], 16, 64)
+	if err != nil {
+		return rand.Float64() < rate
+	}
+	return low>>1 < uint64(rate*(1<<63))
+}
+
+// Observers fans a call out to several observers
+type Observers []Observer
+
+// ObserveCall forwards the call to every non-nil observer
+func (o Observers) ObserveCall(component, method string, duration time.Duration, err error) {
+	for _, observer := range o {
+		if observer != nil {
+			observer.ObserveCall(component, method, duration, err)
+		}
+	}
+}
+
+// ObserveTracedCall forwards the call and its trace ID to every non-nil observer
+func (o Observers) ObserveTracedCall(component, method string, duration time.Duration, err error, traceID string) {
+	for _, observer := range o {
+		ObserveTraced(observer, component, method, duration, err, traceID)
+	}
+}
+
+// LogObserver logs failed calls and calls slower than SlowThreshold
+type LogObserver struct {
+	SlowThreshold time.Duration
+}
+
+// NewLogObserver creates a log observer; a zero threshold disables slow-call logging
+func NewLogObserver(slowThreshold time.Duration) *LogObserver {
+	return &LogObserver{SlowThreshold: slowThreshold}
+}
+
+// ObserveCall logs errors and slow calls
+func (l *LogObserver) ObserveCall(component, method string, duration time.Duration, err error) {
+	if err != nil {
+		log.Printf("⚠️  %s.%s failed after %s: %v", component, method, duration, err)
+		return
+	}
+	if l.SlowThreshold > 0 && duration >= l.SlowThreshold {
+		log.Printf("🐢 %s.%s took %s (threshold %s)", component, method, duration, l.SlowThreshold)
+	}
+}
+
+// TraceObserver emits a span record per sampled call
+// Spans are written to the log until a tracing exporter is wired in
+type TraceObserver struct {
+	ServiceName string
+	SampleRate  float64
+}
+
+// NewTraceObserver creates a trace observer sampling the given fraction of calls (0.0 - 1.0)
+func NewTraceObserver(serviceName string, sampleRate float64) *TraceObserver {
+	return &TraceObserver{ServiceName: serviceName, SampleRate: sampleRate}
+}
+
+// ObserveCall records a span for sampled calls
+func (t *TraceObserver) ObserveCall(component, method string, duration time.Duration, err error) {
+	if t.SampleRate <= 0 || (t.SampleRate < 1 && rand.Float64() >= t.SampleRate) {
+		return
+	}
+
+	log.Printf("🔭 span service=%s name=%s.%s duration=%s status=%s", t.ServiceName, component, method, duration, spanStatus(err))
+}
+
+// ObserveTracedCall records a span in the caller's trace when the trace is sampled
+func (t *TraceObserver) ObserveTracedCall(component, method string, duration time.Duration, err error, traceID string) {
+	if !Sampled(traceID, t.SampleRate) {
+		return
+	}
+	log.Printf("🔭 span service=%s trace_id=%s name=%s.%s duration=%s status=%s", t.ServiceName, traceID, component, method, duration, spanStatus(err))
+}
+
+// spanStatus is the status recorded for a span
+func spanStatus(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
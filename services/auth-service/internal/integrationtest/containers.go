@@ -0,0 +1,96 @@
+//go:build integration
+
+package integrationtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Images are pinned to the versions in docker-compose.yml so tests run against what is deployed
+const (
+	postgresImage = "postgres:15-alpine"
+	redisImage    = "redis:7-alpine"
+)
+
+// Credentials of the throwaway database
+const (
+	postgresUser     = "auth_test"
+	postgresPassword = "auth_test"
+	postgresDB       = "auth_test"
+)
+
+// startupTimeout bounds pulling and starting each container
+const startupTimeout = 2 * time.Minute
+
+// endpoint is a started container and the host port its service is mapped to
+type endpoint struct {
+	container testcontainers.Container
+	host      string
+	port      string
+}
+
+// startPostgres starts PostgreSQL in UTC, with fsync off since the data is thrown away
+func startPostgres(ctx context.Context) (*endpoint, error) {
+	return start(ctx, testcontainers.ContainerRequest{
+		Image:        postgresImage,
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_USER":     postgresUser,
+			"POSTGRES_PASSWORD": postgresPassword,
+			"POSTGRES_DB":       postgresDB,
+			"TZ":                "UTC",
+			"PGTZ":              "UTC",
+		},
+		Cmd: []string{"postgres", "-c", "fsync=off", "-c", "full_page_writes=off"},
+		// The server restarts once after the init scripts, so wait for the second ready line
+		WaitingFor: wait.ForAll(
+			wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+			wait.ForListeningPort("5432/tcp"),
+		).WithDeadline(startupTimeout),
+	}, "5432/tcp")
+}
+
+// startRedis starts Redis without persistence
+func startRedis(ctx context.Context) (*endpoint, error) {
+	return start(ctx, testcontainers.ContainerRequest{
+		Image:        redisImage,
+		ExposedPorts: []string{"6379/tcp"},
+		Cmd:          []string{"redis-server", "--save", "", "--appendonly", "no"},
+		WaitingFor: wait.ForAll(
+			wait.ForLog("Ready to accept connections"),
+			wait.ForListeningPort("6379/tcp"),
+		).WithDeadline(startupTimeout),
+	}, "6379/tcp")
+}
+
+func start(ctx context.Context, request testcontainers.ContainerRequest, port string) (*endpoint, error) {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: request,
+		Started:          true,
+	})
+	if err != nil {
+		if container != nil {
+			container.Terminate(context.Background())
+		}
+		return nil, fmt.Errorf("failed to start %s: %w", request.Image, err)
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		container.Terminate(context.Background())
+		return nil, fmt.Errorf("failed to get %s host: %w", request.Image, err)
+	}
+	mapped, err := container.MappedPort(ctx, nat.Port(port))
+	if err != nil {
+		container.Terminate(context.Background())
+		return nil, fmt.Errorf("failed to get %s port: %w", request.Image, err)
+	}
+
+	return &endpoint{container: container, host: host, port: mapped.Port()}, nil
+}
@@ -0,0 +1,21 @@
+//go:build integration
+
+// Package integrationtest runs repository and end-to-end handler tests against real PostgreSQL and
+// Redis containers started with testcontainers-go. The schema comes from migrations/ applied through
+// MigrationManager and the fixed accounts from seeds/test, so every test starts from the same state.
+//
+// A test package starts the containers once in TestMain and resets them per test:
+//
+//	func TestMain(m *testing.M) { integrationtest.Main(m) }
+//
+//	func TestLogin(t *testing.T) {
+//		env := integrationtest.Setup(t)
+//		router := env.Router(t)
+//		token := integrationtest.Login(t, router, integrationtest.UserEmail, integrationtest.FixturePassword)
+//		...
+//	}
+//
+// The package and its tests build only with the integration tag and need a Docker daemon:
+//
+//	go test ./... -tags=integration
+package integrationtest
@@ -0,0 +1,269 @@
+//go:build integration
+
+package integrationtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/container"
+	"auth-service/internal/database"
+	"auth-service/internal/migrations"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	sharedDB "shared/database"
+)
+
+// environment selects the test migrations and seeds/test
+const environment = "test"
+
+// Fixed accounts seeded from seeds/test/001_test_users.sql, one per role
+const (
+	AdminEmail      = "admin@test.local"
+	ModeratorEmail  = "moderator@test.local"
+	UserEmail       = "user@test.local"
+	FixturePassword = "TestUser123!"
+)
+
+// preservedTables keep their rows across Reset: the migration and seed history
+var preservedTables = map[string]bool{"schema_migrations": true, "schema_seeds": true}
+
+// Env is a migrated and seeded PostgreSQL plus a Redis, shared by the tests of a package
+type Env struct {
+	// Config is config/config-local.toml pointed at the containers, with fixed JWT secrets
+	Config *config.Config
+	DB     *gorm.DB
+	Redis  *redis.Client
+
+	root     string // auth-service module directory holding migrations/ and seeds/
+	postgres *endpoint
+	redis    *endpoint
+}
+
+// shared is the environment started by Main
+var shared *Env
+
+// Main starts the environment, runs the package's tests and stops it; call it from TestMain
+func Main(m *testing.M) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	env, err := Start(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("🚨 Failed to start integration test environment: %v", err)
+		os.Exit(1)
+	}
+
+	shared = env
+	code := m.Run()
+	if err := env.Close(); err != nil {
+		log.Printf("⚠️  Failed to stop integration test environment: %v", err)
+	}
+	os.Exit(code)
+}
+
+// Setup resets the environment started by Main to the migrated, seeded state and returns it
+func Setup(tb testing.TB) *Env {
+	tb.Helper()
+	if shared == nil {
+		tb.Fatal("integrationtest: call integrationtest.Main from the package's TestMain")
+	}
+	shared.Reset(tb)
+	return shared
+}
+
+// Start runs PostgreSQL and Redis containers, applies every migration and the test seeds
+func Start(ctx context.Context) (env *Env, err error) {
+	root, err := moduleRoot()
+	if err != nil {
+		return nil, err
+	}
+	env = &Env{root: root}
+	defer func() {
+		if err != nil {
+			env.Close()
+		}
+	}()
+
+	if env.postgres, err = startPostgres(ctx); err != nil {
+		return nil, err
+	}
+	if env.redis, err = startRedis(ctx); err != nil {
+		return nil, err
+	}
+	if env.Config, err = env.config(); err != nil {
+		return nil, err
+	}
+
+	env.DB, err = sharedDB.ConnectWithRetry(ctx, sharedDB.ConnectionConfig{
+		Host:            env.Config.Database.Host,
+		Port:            env.Config.Database.Port,
+		Name:            env.Config.Database.Name,
+		User:            env.Config.Database.User,
+		Password:        env.Config.Database.Password,
+		SSLMode:         env.Config.Database.SSLMode,
+		MaxOpenConns:    env.Config.Database.MaxOpenConns,
+		MaxIdleConns:    env.Config.Database.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(env.Config.Database.ConnMaxLifetime) * time.Second,
+		Timezone:        "UTC",
+	}, sharedDB.DefaultRetryConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to test database: %w", err)
+	}
+	if env.Redis, err = database.ConnectRedis(ctx, env.Config.Redis); err != nil {
+		return nil, fmt.Errorf("failed to connect to test Redis: %w", err)
+	}
+
+	if err = env.migrate(); err != nil {
+		return nil, err
+	}
+	if err = env.seed(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Close disconnects and removes the containers
+func (e *Env) Close() error {
+	var errs []error
+	if e.Redis != nil {
+		errs = append(errs, e.Redis.Close())
+	}
+	if e.DB != nil {
+		if sqlDB, err := e.DB.DB(); err == nil {
+			errs = append(errs, sqlDB.Close())
+		}
+	}
+	for _, endpoint := range []*endpoint{e.redis, e.postgres} {
+		if endpoint != nil {
+			errs = append(errs, endpoint.container.Terminate(context.Background()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reset empties every table but the migration and seed history, flushes Redis and re-applies the
+// seeds, so a test sees only the fixtures whatever ran before it
+func (e *Env) Reset(tb testing.TB) {
+	tb.Helper()
+
+	var tables []string
+	if err := e.DB.Raw("SELECT tablename FROM pg_tables WHERE schemaname = 'public' ORDER BY tablename").
+		Scan(&tables).Error; err != nil {
+		tb.Fatalf("integrationtest: failed to list tables: %v", err)
+	}
+	var truncate []string
+	for _, table := range tables {
+		if !preservedTables[table] {
+			truncate = append(truncate, fmt.Sprintf("%q", table))
+		}
+	}
+	if len(truncate) > 0 {
+		if err := e.DB.Exec("TRUNCATE " + strings.Join(truncate, ", ") + " RESTART IDENTITY CASCADE").Error; err != nil {
+			tb.Fatalf("integrationtest: failed to truncate tables: %v", err)
+		}
+	}
+
+	if err := e.Redis.FlushDB(context.Background()).Err(); err != nil {
+		tb.Fatalf("integrationtest: failed to flush Redis: %v", err)
+	}
+	if err := e.seed(); err != nil {
+		tb.Fatalf("integrationtest: %v", err)
+	}
+}
+
+// Container builds the service's dependency graph on the environment's database and Redis
+// opts replace individual dependencies, e.g. container.WithMailer; it is closed when the test ends
+func (e *Env) Container(tb testing.TB, opts ...container.Option) *container.Container {
+	tb.Helper()
+
+	opts = append([]container.Option{container.WithDB(e.DB), container.WithRedis(e.Redis)}, opts...)
+	deps, err := container.New(context.Background(), e.Config, opts...)
+	if err != nil {
+		tb.Fatalf("integrationtest: failed to build container: %v", err)
+	}
+	tb.Cleanup(func() {
+		if err := deps.Close(); err != nil {
+			tb.Logf("integrationtest: failed to close container: %v", err)
+		}
+	})
+	return deps
+}
+
+// config loads config/config-local.toml and points it at the containers
+// The file's ${VAR:default} values aren't expanded, so everything a test depends on is set here
+func (e *Env) config() (*config.Config, error) {
+	cfg, err := config.LoadFile(filepath.Join(e.root, "config", "config-local.toml"))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Database.Host = e.postgres.host
+	cfg.Database.Port = e.postgres.port
+	cfg.Database.Name = postgresDB
+	cfg.Database.User = postgresUser
+	cfg.Database.Password = postgresPassword
+	cfg.Database.SSLMode = "disable"
+	cfg.Database.RunMigrations = false // Applied once by Start
+	cfg.Database.MigrationEnv = environment
+
+	cfg.Redis.URL = fmt.Sprintf("redis://%s:%s/0", e.redis.host, e.redis.port)
+	cfg.Redis.Password = ""
+
+	cfg.JWT.AccessSecret = "integration-test-access-secret-0123456789"
+	cfg.JWT.RefreshSecret = "integration-test-refresh-secret-0123456789"
+	cfg.JWT.Issuer = "auth-service"
+	cfg.JWT.Algorithm = "HS256"
+	return cfg, nil
+}
+
+// migrate applies migrations/ through MigrationManager, as `migrate migrate --env=test` would
+func (e *Env) migrate() error {
+	manager, err := migrations.NewMigrationManager(e.DB, filepath.Join(e.root, "migrations"), environment)
+	if err != nil {
+		return fmt.Errorf("failed to create migration manager: %w", err)
+	}
+	if _, err := manager.ApplyMigrations(); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// seed applies seeds/test; forced so Reset restores fixtures a test changed or removed
+func (e *Env) seed() error {
+	manager, err := migrations.NewSeedManager(e.DB, filepath.Join(e.root, "seeds"), environment)
+	if err != nil {
+		return fmt.Errorf("failed to create seed manager: %w", err)
+	}
+	if _, err := manager.ApplySeeds(true); err != nil {
+		return fmt.Errorf("failed to apply seeds: %w", err)
+	}
+	return nil
+}
+
+// moduleRoot finds the auth-service module from this file, so tests work from any package directory
+func moduleRoot() (string, error) {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return "", errors.New("integrationtest: cannot locate the auth-service module")
+	}
+	root := filepath.Join(filepath.Dir(file), "..", "..")
+	if _, err := os.Stat(filepath.Join(root, "go.mod")); err != nil {
+		return "", fmt.Errorf("integrationtest: %s is not the auth-service module: %w", root, err)
+	}
+	return root, nil
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
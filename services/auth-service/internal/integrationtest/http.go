@@ -0,0 +1,73 @@
+//go:build integration
+
+package integrationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"auth-service/internal/container"
+	"auth-service/internal/models"
+	"auth-service/internal/routes"
+)
+
+// Router builds the service's full router, middleware included, on the environment
+func (e *Env) Router(tb testing.TB, opts ...container.Option) http.Handler {
+	tb.Helper()
+	return routes.NewRouter(e.Container(tb, opts...), e.Config)
+}
+
+// Request sends a request to handler; body, unless nil, is sent as JSON and token as a bearer token
+func Request(tb testing.TB, handler http.Handler, method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	tb.Helper()
+
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			tb.Fatalf("integrationtest: failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// DecodeJSON decodes a response body into v, failing the test unless the status is want
+func DecodeJSON(tb testing.TB, recorder *httptest.ResponseRecorder, want int, v interface{}) {
+	tb.Helper()
+
+	if recorder.Code != want {
+		tb.Fatalf("integrationtest: got status %d, want %d: %s", recorder.Code, want, recorder.Body.String())
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), v); err != nil {
+		tb.Fatalf("integrationtest: failed to decode response: %v: %s", err, recorder.Body.String())
+	}
+}
+
+// Login signs in through POST /api/v1/auth/login and returns the tokens
+func Login(tb testing.TB, handler http.Handler, email, password string) *models.AuthResponse {
+	tb.Helper()
+
+	recorder := Request(tb, handler, http.MethodPost, "/api/v1/auth/login",
+		models.LoginRequest{Email: email, Password: password}, "")
+	var response models.AuthResponse
+	DecodeJSON(tb, recorder, http.StatusOK, &response)
+	if response.AccessToken == "" {
+		tb.Fatalf("integrationtest: login of %s returned no access token: %s", email, recorder.Body.String())
+	}
+	return &response
+}
@@ -0,0 +1,243 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// overridesKey holds the overrides set through the admin endpoint, shared by every replica
+// It expires with the last of them, so nothing outlives logging.max_override_duration
+const overridesKey = "logging:overrides"
+
+var (
+	ErrInvalidLevel        = errors.New("level must be debug, info, warn or error")
+	ErrInvalidDuration     = errors.New("duration must be positive and at most logging.max_override_duration")
+	ErrInvalidDebugTarget  = errors.New("a debug target needs a user_id, a path starting with /, or both")
+	ErrDebugTargetNotFound = errors.New("debug target not found")
+	errOverridesContended  = errors.New("logging overrides changed concurrently; try again")
+)
+
+// overrides is the Redis payload
+type overrides struct {
+	Level   *LevelOverride `json:"level,omitempty"`
+	Targets []DebugTarget  `json:"targets,omitempty"`
+}
+
+// prune drops what has expired and returns when the rest expires (zero when nothing is left)
+func (o *overrides) prune(now time.Time) time.Time {
+	var last time.Time
+	if o.Level != nil {
+		if now.Before(o.Level.ExpiresAt) {
+			last = o.Level.ExpiresAt
+		} else {
+			o.Level = nil
+		}
+	}
+	targets := o.Targets[:0]
+	for _, target := range o.Targets {
+		if now.Before(target.ExpiresAt) {
+			targets = append(targets, target)
+			if target.ExpiresAt.After(last) {
+				last = target.ExpiresAt
+			}
+		}
+	}
+	o.Targets = targets
+	return last
+}
+
+// Control changes the log level and debug targets on every replica; changes are stored in Redis and
+// picked up within logging.sync_interval
+type Control struct {
+	redis  *redis.Client
+	levels *Levels
+	config config.LoggingConfig
+}
+
+// NewControl creates the admin control over levels
+func NewControl(client *redis.Client, levels *Levels, cfg config.LoggingConfig) *Control {
+	return &Control{redis: client, levels: levels, config: cfg}
+}
+
+// Current returns the state in effect on this replica
+func (c *Control) Current() State {
+	return c.levels.Current()
+}
+
+// SetLevel overrides logging.level with level for duration
+func (c *Control) SetLevel(ctx context.Context, level string, duration time.Duration, changedBy string) (State, error) {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if !ValidLevel(level) {
+		return c.Current(), ErrInvalidLevel
+	}
+	if err := c.checkDuration(duration); err != nil {
+		return c.Current(), err
+	}
+
+	err := c.update(ctx, func(o *overrides) error {
+		o.Level = &LevelOverride{Level: level, ExpiresAt: time.Now().Add(duration), ChangedBy: changedBy}
+		return nil
+	})
+	return c.Current(), err
+}
+
+// ResetLevel ends the level override early
+func (c *Control) ResetLevel(ctx context.Context) (State, error) {
+	err := c.update(ctx, func(o *overrides) error {
+		o.Level = nil
+		return nil
+	})
+	return c.Current(), err
+}
+
+// AddDebugTarget logs the requests of userID, under pathPrefix, or both in detail for duration
+func (c *Control) AddDebugTarget(ctx context.Context, userID, pathPrefix string, duration time.Duration, createdBy string) (*DebugTarget, error) {
+	userID, pathPrefix = strings.TrimSpace(userID), strings.TrimSpace(pathPrefix)
+	if (userID == "" && pathPrefix == "") || (pathPrefix != "" && !strings.HasPrefix(pathPrefix, "/")) {
+		return nil, ErrInvalidDebugTarget
+	}
+	if err := c.checkDuration(duration); err != nil {
+		return nil, err
+	}
+
+	target := DebugTarget{
+		ID:         models.NewID().String(),
+		UserID:     userID,
+		PathPrefix: pathPrefix,
+		ExpiresAt:  time.Now().Add(duration),
+		CreatedBy:  createdBy,
+	}
+	err := c.update(ctx, func(o *overrides) error {
+		o.Targets = append(o.Targets, target)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// RemoveDebugTarget ends a debug target early
+func (c *Control) RemoveDebugTarget(ctx context.Context, id string) error {
+	return c.update(ctx, func(o *overrides) error {
+		for i, target := range o.Targets {
+			if target.ID == id {
+				o.Targets = append(o.Targets[:i], o.Targets[i+1:]...)
+				return nil
+			}
+		}
+		return ErrDebugTargetNotFound
+	})
+}
+
+// Start loads the stored overrides and then re-reads them every logging.sync_interval until ctx is cancelled
+// When Redis is unavailable the last known overrides are kept; they still expire on time
+func (c *Control) Start(ctx context.Context) {
+	c.sync(ctx)
+	go func() {
+		ticker := time.NewTicker(c.config.SyncInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.sync(ctx)
+			}
+		}
+	}()
+}
+
+// checkDuration bounds an override by logging.max_override_duration
+func (c *Control) checkDuration(duration time.Duration) error {
+	if duration <= 0 || duration > c.config.MaxOverrideDuration {
+		return fmt.Errorf("%w (%s)", ErrInvalidDuration, c.config.MaxOverrideDuration)
+	}
+	return nil
+}
+
+// update applies change to the stored overrides in a transaction, so concurrent admin changes
+// aren't lost, and applies the result on this replica straight away
+func (c *Control) update(ctx context.Context, change func(*overrides) error) error {
+	var result overrides
+	txn := func(tx *redis.Tx) error {
+		current, err := readOverrides(tx.Get(ctx, overridesKey))
+		if err != nil {
+			return err
+		}
+		if err := change(current); err != nil {
+			return err
+		}
+		expiresAt := current.prune(time.Now())
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if expiresAt.IsZero() {
+				pipe.Del(ctx, overridesKey)
+				return nil
+			}
+			data, err := json.Marshal(current)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, overridesKey, data, time.Until(expiresAt))
+			return nil
+		})
+		result = *current
+		return err
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		err := c.redis.Watch(ctx, txn, overridesKey)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, ErrDebugTargetNotFound) {
+				return err
+			}
+			return fmt.Errorf("failed to store logging overrides: %w", err)
+		}
+		c.levels.apply(result.Level, result.Targets)
+		return nil
+	}
+	return errOverridesContended
+}
+
+// sync adopts the overrides stored in Redis
+func (c *Control) sync(ctx context.Context) {
+	stored, err := readOverrides(c.redis.Get(ctx, overridesKey))
+	if err != nil {
+		log.Printf("⚠️  Failed to read logging overrides: %v", err)
+		return
+	}
+	stored.prune(time.Now())
+	c.levels.apply(stored.Level, stored.Targets)
+}
+
+// readOverrides decodes the stored overrides; none are stored when the key is missing
+func readOverrides(cmd *redis.StringCmd) (*overrides, error) {
+	data, err := cmd.Bytes()
+	if errors.Is(err, redis.Nil) {
+		return &overrides{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored overrides
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("malformed logging overrides: %w", err)
+	}
+	return &stored, nil
+}
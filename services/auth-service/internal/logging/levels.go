@@ -0,0 +1,206 @@
+// Package logging holds the runtime log level and targeted debug logging. Admins change them through
+// /api/v1/admin/logging (shared by every replica through Redis) and operators with SIGUSR1 (one replica);
+// every change expires by itself, so production debugging needs no restart and nothing is left behind
+package logging
+
+import (
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+)
+
+// Level names accepted for overrides
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// LevelOverride replaces logging.level until ExpiresAt
+type LevelOverride struct {
+	Level     string    `json:"level"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ChangedBy string    `json:"changed_by,omitempty"` // Admin user ID
+}
+
+// DebugTarget logs the requests of one user, under one path prefix, or both, in detail until ExpiresAt
+type DebugTarget struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id,omitempty"`
+	PathPrefix string    `json:"path_prefix,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedBy  string    `json:"created_by,omitempty"` // Admin user ID
+}
+
+// matches reports whether the target covers a request by userID to path
+func (t DebugTarget) matches(userID, path string) bool {
+	if t.UserID != "" && t.UserID != userID {
+		return false
+	}
+	return t.PathPrefix == "" || strings.HasPrefix(path, t.PathPrefix)
+}
+
+// State is the logging setup in effect on this replica
+type State struct {
+	Level            string         `json:"level"`            // Effective level
+	ConfiguredLevel  string         `json:"configured_level"` // logging.level, in effect again once overrides expire
+	Override         *LevelOverride `json:"override,omitempty"`
+	SignalDebugUntil *time.Time     `json:"signal_debug_until,omitempty"` // Debug on this replica only, toggled by SIGUSR1
+	DebugTargets     []DebugTarget  `json:"debug_targets"`
+}
+
+// Levels is the effective log level of this replica; it implements slog.Leveler so loggers follow
+// changes immediately. Expired overrides and targets are ignored as soon as they expire
+type Levels struct {
+	configured     slog.Level
+	signalDuration time.Duration
+
+	mu          sync.RWMutex
+	override    *LevelOverride
+	targets     []DebugTarget
+	signalUntil time.Time
+}
+
+// NewLevels starts at logging.level
+func NewLevels(cfg config.LoggingConfig) *Levels {
+	return &Levels{configured: ParseLevel(cfg.Level), signalDuration: cfg.SignalDebugDuration}
+}
+
+// Level returns the effective level: debug while SIGUSR1 debugging is on, else an unexpired admin
+// override, else logging.level. A nil Levels is at info
+func (l *Levels) Level() slog.Level {
+	if l == nil {
+		return slog.LevelInfo
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.levelLocked(time.Now())
+}
+
+func (l *Levels) levelLocked(now time.Time) slog.Level {
+	if now.Before(l.signalUntil) {
+		return slog.LevelDebug
+	}
+	if l.override != nil && now.Before(l.override.ExpiresAt) {
+		return ParseLevel(l.override.Level)
+	}
+	return l.configured
+}
+
+// Debugging reports whether a request by userID (empty when anonymous) to path is logged in detail:
+// always at debug level, otherwise when an unexpired debug target matches
+func (l *Levels) Debugging(userID, path string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	if l.levelLocked(now) <= slog.LevelDebug {
+		return true
+	}
+	for _, target := range l.targets {
+		if now.Before(target.ExpiresAt) && target.matches(userID, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Current returns the state in effect, leaving out what has expired
+func (l *Levels) Current() State {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	state := State{
+		Level:           LevelName(l.levelLocked(now)),
+		ConfiguredLevel: LevelName(l.configured),
+		DebugTargets:    []DebugTarget{},
+	}
+	if l.override != nil && now.Before(l.override.ExpiresAt) {
+		override := *l.override
+		state.Override = &override
+	}
+	if now.Before(l.signalUntil) {
+		until := l.signalUntil
+		state.SignalDebugUntil = &until
+	}
+	for _, target := range l.targets {
+		if now.Before(target.ExpiresAt) {
+			state.DebugTargets = append(state.DebugTargets, target)
+		}
+	}
+	return state
+}
+
+// ToggleSignalDebug switches this replica to debug for logging.signal_debug_duration, or back if it already is
+func (l *Levels) ToggleSignalDebug() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(l.signalUntil) {
+		l.signalUntil = time.Time{}
+		log.Printf("🔧 SIGUSR1: debug logging off, back to %s", LevelName(l.levelLocked(now)))
+		return
+	}
+	l.signalUntil = now.Add(l.signalDuration)
+	log.Printf("🔧 SIGUSR1: debug logging on until %s", l.signalUntil.UTC().Format(time.RFC3339))
+}
+
+// apply adopts the overrides admins set, logging level changes
+func (l *Levels) apply(override *LevelOverride, targets []DebugTarget) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	before := l.levelLocked(now)
+	l.override, l.targets = override, targets
+	if after := l.levelLocked(now); after != before {
+		log.Printf("🔧 Log level changed from %s to %s", LevelName(before), LevelName(after))
+	}
+}
+
+// ParseLevel maps a level name to a slog level; unknown names are info, as for logging.level
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug", "trace":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LevelName is the name of a slog level as used in the config and the admin API
+func LevelName(level slog.Level) string {
+	switch {
+	case level <= slog.LevelDebug:
+		return LevelDebug
+	case level >= slog.LevelError:
+		return LevelError
+	case level >= slog.LevelWarn:
+		return LevelWarn
+	default:
+		return LevelInfo
+	}
+}
+
+// ValidLevel reports whether level may be used as an override
+func ValidLevel(level string) bool {
+	switch level {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError:
+		return true
+	}
+	return false
+}
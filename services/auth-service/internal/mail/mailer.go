@@ -0,0 +1,79 @@
+// Package mail sends transactional email (invitations, password resets) through the configured SMTP server
+package mail
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-service/internal/config"
+)
+
+// Message is a plain-text email to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional email
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// NewMailer returns an SMTP mailer, or a mailer that only logs when no SMTP host is configured
+func NewMailer(cfg config.EmailConfig) Mailer {
+	if cfg.SMTPHost == "" {
+		log.Println("⚠️  No SMTP host configured, emails will be logged instead of sent")
+		return logMailer{}
+	}
+	return &smtpMailer{cfg: cfg}
+}
+
+type smtpMailer struct {
+	cfg config.EmailConfig
+}
+
+// Send delivers msg with STARTTLS when the server offers it and PLAIN auth when a username is set
+func (m *smtpMailer) Send(msg Message) error {
+	addr := net.JoinHostPort(m.cfg.SMTPHost, strconv.Itoa(m.cfg.SMTPPort))
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.SMTPHost)
+	}
+
+	if err := smtp.SendMail(addr, auth, m.cfg.FromAddress, []string{msg.To}, m.render(msg)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// render builds the RFC 5322 message
+func (m *smtpMailer) render(msg Message) []byte {
+	from := (&mail.Address{Name: m.cfg.FromName, Address: m.cfg.FromAddress}).String()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// logMailer writes emails to the log when SMTP isn't configured (local development)
+// Bodies contain one-time links, so production configs must set smtp_host
+type logMailer struct{}
+
+func (logMailer) Send(msg Message) error {
+	log.Printf("📧 Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
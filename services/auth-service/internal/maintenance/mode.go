@@ -0,0 +1,172 @@
+// Package maintenance holds the read-only maintenance mode, used while the service runs against a
+// read replica (e.g. during a primary failover). Reads keep working; writes are refused with 503
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// stateKey holds the mode set through the admin endpoint, shared by every replica
+const stateKey = "maintenance:state"
+
+// Sources of the current mode
+const (
+	SourceConfig = "config" // maintenance.read_only; the database connection itself is read-only
+	SourceAdmin  = "admin"  // Toggled through the admin endpoint
+)
+
+// ErrForcedByConfig means maintenance.read_only is set, so read-only mode can't be turned off at runtime
+var ErrForcedByConfig = errors.New("read-only mode is set by maintenance.read_only; change the config and restart to leave it")
+
+// State is the current maintenance mode
+type State struct {
+	ReadOnly  bool       `json:"read_only"`
+	Reason    string     `json:"reason,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	Since     *time.Time `json:"since,omitempty"`
+	ChangedBy string     `json:"changed_by,omitempty"` // Admin user ID for SourceAdmin
+}
+
+// Mode tracks whether the service is read-only; admin changes are stored in Redis and picked up by
+// every replica within maintenance.sync_interval
+type Mode struct {
+	redis  *redis.Client
+	config config.MaintenanceConfig
+
+	mu    sync.RWMutex
+	state State
+}
+
+// NewMode creates the mode, read-only from the start when maintenance.read_only is set
+func NewMode(client *redis.Client, cfg config.MaintenanceConfig) *Mode {
+	m := &Mode{redis: client, config: cfg}
+	if cfg.ReadOnly {
+		now := time.Now()
+		m.state = State{ReadOnly: true, Reason: cfg.Reason, Source: SourceConfig, Since: &now}
+	}
+	return m
+}
+
+// ReadOnly reports whether writes are currently refused; a nil mode is never read-only
+func (m *Mode) ReadOnly() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.ReadOnly
+}
+
+// Current returns the current state; a nil mode is never read-only
+func (m *Mode) Current() State {
+	if m == nil {
+		return State{}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Set enters or leaves read-only mode on every replica
+func (m *Mode) Set(ctx context.Context, readOnly bool, reason, changedBy string) (State, error) {
+	if m.config.ReadOnly {
+		return m.Current(), ErrForcedByConfig
+	}
+
+	state := State{}
+	if readOnly {
+		now := time.Now()
+		state = State{ReadOnly: true, Reason: reason, Source: SourceAdmin, Since: &now, ChangedBy: changedBy}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return m.Current(), err
+	}
+	if err := m.redis.Set(ctx, stateKey, data, 0).Err(); err != nil {
+		return m.Current(), fmt.Errorf("failed to store maintenance mode: %w", err)
+	}
+
+	m.apply(state)
+	return state, nil
+}
+
+// Start loads the stored mode and then re-reads it every maintenance.sync_interval until ctx is cancelled
+// When Redis is unavailable the last known mode is kept
+func (m *Mode) Start(ctx context.Context) {
+	if m.config.ReadOnly {
+		log.Printf("🔧 Read-only maintenance mode enabled by config: %s", m.config.Reason)
+		return
+	}
+
+	m.sync(ctx)
+	go func() {
+		ticker := time.NewTicker(m.config.SyncInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.sync(ctx)
+			}
+		}
+	}()
+}
+
+// sync adopts the mode stored in Redis
+func (m *Mode) sync(ctx context.Context) {
+	data, err := m.redis.Get(ctx, stateKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		m.apply(State{})
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to read maintenance mode: %v", err)
+		return
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Printf("⚠️  Ignoring malformed maintenance mode: %v", err)
+		return
+	}
+	m.apply(state)
+}
+
+// apply switches to state, logging transitions
+func (m *Mode) apply(state State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if state.ReadOnly != m.state.ReadOnly {
+		if state.ReadOnly {
+			log.Printf("🔧 Entering read-only maintenance mode: %s", state.Reason)
+		} else {
+			log.Println("✅ Leaving read-only maintenance mode")
+		}
+	}
+	m.state = state
+}
+
+// WritePrometheus exports whether the service is read-only in the Prometheus text format
+func (m *Mode) WritePrometheus(w io.Writer) {
+	value := 0
+	if m.ReadOnly() {
+		value = 1
+	}
+	fmt.Fprintln(w, "# HELP auth_service_read_only Whether the service refuses writes for read-only maintenance")
+	fmt.Fprintln(w, "# TYPE auth_service_read_only gauge")
+	fmt.Fprintf(w, "auth_service_read_only %d\n", value)
+}
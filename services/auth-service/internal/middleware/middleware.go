@@ -2,13 +2,21 @@ package middleware
 
 import (
 	"auth-service/internal/config"
+	"auth-service/internal/instrumentation"
+	"auth-service/internal/models"
+	"auth-service/internal/ratelimit"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	sharedMiddleware "shared/middleware"
+	"shared/requestid"
 )
 
 // CORS middleware with configuration support
@@ -71,19 +79,35 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+// feedTokenPattern matches the personal token in feed URLs, which must not reach the access log
+var feedTokenPattern = regexp.MustCompile(`/feeds/[^/]+/`)
+
 // Logger middleware
+// Each access log line carries the request ID so it can be matched with responses and events
 func Logger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return ""
+		requestID, _ := param.Keys[requestid.ContextKey].(string)
+		return fmt.Sprintf("[GIN] %s | %3d | %13v | %15s | %-7s %q | request_id=%s%s\n",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Method,
+			feedTokenPattern.ReplaceAllString(param.Path, "/feeds/[redacted]/"),
+			requestID,
+			param.ErrorMessage,
+		)
 	})
 }
 
 // Recovery middleware
 func Recovery() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		log.Printf("Panic recovered: %v", recovered)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
+		requestID := c.GetString(requestid.ContextKey)
+		log.Printf("🚨 Panic recovered (request_id=%s): %v", requestID, recovered)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:     "Internal server error",
+			RequestID: requestID,
 		})
 	})
 }
@@ -105,8 +129,181 @@ func Recovery() gin.HandlerFunc {
 // - IsAuthenticated(c): Check if user is authenticated
 // - HasRole(c, role): Check if user has specific role
 
+// userUUIDKey is the context key holding the parsed uuid.UUID of the authenticated user
+const userUUIDKey = "user_uuid"
+
+// RequireRole allows the request only if the authenticated user holds one of the roles
+// Must run after shared JWT AuthRequired(); responds 403 otherwise
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !sharedMiddleware.HasAnyRole(c, roles...) {
+			WriteError(c, http.StatusForbidden, models.ErrorResponse{
+				Error:   "Insufficient permissions",
+				Message: "This endpoint requires one of the roles: " + strings.Join(roles, ", "),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireUserID parses the authenticated user's ID once and stores it as uuid.UUID in the context
+// Must run after shared JWT AuthRequired(). Responds 401 when no user is present and
+// 401 when the token carries a malformed user ID, so every protected handler behaves the same
+func RequireUserID() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		userIDStr := sharedMiddleware.GetUserIDFromContext(c)
+		if userIDStr == "" {
+			WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
+				Error: "Authentication required",
+			})
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Invalid user ID",
+				Message: "User ID must be a valid UUID",
+			})
+			return
+		}
+
+		c.Set(userUUIDKey, userID)
+		c.Next()
+	})
+}
+
+// GetUserUUID returns the user ID stored by RequireUserID
+func GetUserUUID(c *gin.Context) (uuid.UUID, bool) {
+	value, exists := c.Get(userUUIDKey)
+	if !exists {
+		return uuid.Nil, false
+	}
+	userID, ok := value.(uuid.UUID)
+	return userID, ok
+}
+
+// TokenVersionSource returns a user's current token version (implemented by the auth service)
+type TokenVersionSource interface {
+	TokenVersion(userID uuid.UUID) (int, error)
+}
+
+// RequireCurrentToken rejects access tokens issued before the user's token version was bumped,
+// e.g. tokens carrying a role grant that was revoked since. Must run after RequireUserID()
+// The lookup costs a query per request, so it guards privileged routes only
+func RequireCurrentToken(source TokenVersionSource) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		userID, _ := GetUserUUID(c)
+		version, err := source.TokenVersion(userID)
+		if err != nil {
+			WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
+				Error: "Authentication required",
+			})
+			return
+		}
+
+		var tokenVersion int64
+		if value, exists := c.Get("claims"); exists {
+			if claims, ok := value.(*sharedMiddleware.JWTClaims); ok {
+				tokenVersion = claims.TokenVersion
+			}
+		}
+		if tokenVersion < int64(version) {
+			WriteError(c, http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Token revoked",
+				Message: "Your roles changed since this token was issued; refresh it or log in again",
+			})
+			return
+		}
+		c.Next()
+	})
+}
+
+// RequireTokenScope limits personal access tokens by method: reads need the read scope, anything else
+// the write scope. Tokens from a login are not limited. Must run after shared JWT AuthRequired()
+func RequireTokenScope() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		scope := models.TokenScopeWrite
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scope = models.TokenScopeRead
+		}
+
+		if claims := sharedMiddleware.GetClaimsFromContext(c); claims != nil && !claims.HasScopes(scope) {
+			WriteError(c, http.StatusForbidden, models.ErrorResponse{
+				Error:   "Insufficient scope",
+				Message: "This request requires a personal access token with the " + scope + " scope",
+			})
+			return
+		}
+		c.Next()
+	})
+}
+
+// RejectPersonalAccessTokens keeps personal access tokens away from routes that manage credentials,
+// such as creating more tokens or changing the password, so a leaked token can't take over the account
+// Must run after shared JWT AuthRequired()
+func RejectPersonalAccessTokens() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if claims := sharedMiddleware.GetClaimsFromContext(c); claims != nil && claims.IsPersonalAccessToken() {
+			WriteError(c, http.StatusForbidden, models.ErrorResponse{
+				Error:   "Login required",
+				Message: "Personal access tokens cannot be used here; sign in instead",
+			})
+			return
+		}
+		c.Next()
+	})
+}
+
+// IPBlockSource reports addresses blocked after using a honeypot (implemented by the auth service)
+type IPBlockSource interface {
+	IsIPBlocked(ipAddress string) (bool, error)
+}
+
+// BlockedIPs refuses every request from an address that used a honeypot account or canary key
+// The lookup fails open: when Redis is unavailable requests are served as usual
+func BlockedIPs(source IPBlockSource) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		blocked, err := source.IsIPBlocked(sharedMiddleware.ResolveClientIP(c))
+		if err != nil {
+			log.Printf("⚠️  IP block check failed: %v", err)
+		}
+		if blocked {
+			WriteError(c, http.StatusForbidden, models.ErrorResponse{
+				Error: "Access denied",
+			})
+			return
+		}
+		c.Next()
+	})
+}
+
+// CanaryKeySource recognizes planted canary API keys (implemented by the auth service)
+type CanaryKeySource interface {
+	CheckCanaryKey(key string, client models.ClientInfo) bool
+}
+
+// CanaryKeys returns the hook the JWT middleware runs on presented credentials, so a canary key used
+// against any protected route raises the alert and blocks the caller's address
+func CanaryKeys(source CanaryKeySource) sharedMiddleware.CanaryKeyChecker {
+	return func(c *gin.Context, credential string) bool {
+		return source.CheckCanaryKey(credential, models.ClientInfo{
+			IPAddress: sharedMiddleware.ResolveClientIP(c),
+			UserAgent: c.GetHeader("User-Agent"),
+			RequestID: c.GetString(requestid.ContextKey),
+		})
+	}
+}
+
+// openMetricsContentType is served to scrapers that accept OpenMetrics, which carries exemplars
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
 // PrometheusHandler returns a simple metrics endpoint
-func PrometheusHandler() gin.HandlerFunc {
+// Collectors (e.g. service layer method metrics) are appended after the built-in metrics. Scrapers that
+// accept OpenMetrics get that format instead, with exemplars linking latency buckets to traces
+func PrometheusHandler(collectors ...instrumentation.Collector) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
 		// This is a simplified metrics endpoint
 		// In production, you would use the Prometheus client library
@@ -127,36 +324,81 @@ auth_service_request_duration_seconds_count 0
 # TYPE auth_service_active_sessions gauge
 auth_service_active_sessions 0
 `
-		c.Header("Content-Type", "text/plain")
-		c.String(http.StatusOK, metrics)
-	})
-}
+		openMetrics := strings.Contains(c.GetHeader("Accept"), "application/openmetrics-text")
 
-// RateLimit middleware (simplified version)
-func RateLimit() gin.HandlerFunc {
-	// This is a simplified rate limiter
-	// In production, you would use a proper rate limiting library
-	return gin.HandlerFunc(func(c *gin.Context) {
-		// Allow all requests for now
-		c.Next()
+		var buf strings.Builder
+		buf.WriteString(metrics)
+		for _, collector := range collectors {
+			buf.WriteString("\n")
+			if om, ok := collector.(instrumentation.OpenMetricsCollector); ok && openMetrics {
+				om.WriteOpenMetrics(&buf)
+			} else {
+				collector.WritePrometheus(&buf)
+			}
+		}
+
+		if !openMetrics {
+			c.Header("Content-Type", "text/plain")
+			c.String(http.StatusOK, buf.String())
+			return
+		}
+
+		// OpenMetrics allows no blank lines and must end with an EOF marker
+		var out strings.Builder
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if line != "" {
+				out.WriteString(line)
+				out.WriteString("\n")
+			}
+		}
+		out.WriteString("# EOF\n")
+		c.Data(http.StatusOK, openMetricsContentType, []byte(out.String()))
 	})
 }
 
-// RequestID middleware
-func RequestID() gin.HandlerFunc {
+// RateLimit refuses requests beyond the gateway's rate limits with 429 when the service enforces them
+// itself (rate_limiting.mode = "service"). Requests are let through when Redis can't be reached
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			requestID = generateRequestID()
+		decision, err := limiter.Allow(c.Request.Context(), c.Request.URL.Path, sharedMiddleware.ResolveClientIP(c))
+		if err != nil {
+			log.Printf("⚠️  Rate limit not checked: %v", err)
+		} else if WriteRateLimit(c, decision) {
+			return
 		}
-		
-		c.Set("request_id", requestID)
-		c.Header("X-Request-ID", requestID)
 		c.Next()
 	})
 }
 
-// generateRequestID generates a simple request ID
-func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "000"
+// WriteRateLimit sets the RateLimit-* headers for a limiter decision, like the shared RateLimitWithOptions
+// does, and when the request was refused aborts it with 429 and a problem+json body. It reports whether
+// the request was refused; a nil decision, for paths without limits, sets nothing
+func WriteRateLimit(c *gin.Context, decision *ratelimit.Decision) bool {
+	if decision == nil {
+		return false
+	}
+	resetSeconds := max(1, int(math.Ceil(decision.Reset.Seconds())))
+	c.Header("RateLimit-Limit", strconv.FormatInt(decision.Limit.Burst, 10))
+	c.Header("RateLimit-Remaining", strconv.FormatInt(decision.Remaining, 10))
+	c.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+	c.Header("RateLimit-Policy", decision.Policy())
+	if decision.Allowed {
+		return false
+	}
+
+	retryAfter := max(1, int(math.Ceil(decision.RetryAfter.Seconds())))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	sharedMiddleware.AbortWithProblem(c, &sharedMiddleware.ProblemDetails{
+		Type:   problemTypeBase + "rate-limit-exceeded",
+		Title:  "Rate limit exceeded",
+		Status: http.StatusTooManyRequests,
+		Detail: fmt.Sprintf("Too many requests, retry after %d seconds", retryAfter),
+		Extensions: map[string]interface{}{
+			"retry_after": retryAfter,
+			"limit":       decision.Limit.Burst,
+			"window":      decision.Limit.WindowSeconds(),
+			"policy":      decision.Limit.Name,
+		},
+	})
+	return true
 }
\ No newline at end of file
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	sharedMiddleware "shared/middleware"
+	"shared/requestid"
+)
+
+// problemTypeBase prefixes the per-error-code problem type URIs (resolved against the service URL)
+const problemTypeBase = "/problems/"
+
+// InvalidParam describes a single field validation failure in a problem response
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// WantsProblemJSON reports whether the client negotiated RFC 7807 error responses
+// Clients that don't ask for application/problem+json keep receiving the legacy ErrorResponse
+func WantsProblemJSON(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), sharedMiddleware.ProblemContentType)
+}
+
+// WriteError sends an error in the representation the client negotiated
+func WriteError(c *gin.Context, status int, errResp models.ErrorResponse) {
+	writeError(c, status, errResp, nil)
+}
+
+// WriteBindingError reports a request binding failure, listing invalid fields for problem+json clients
+func WriteBindingError(c *gin.Context, err error) {
+	var extensions map[string]interface{}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		params := make([]InvalidParam, 0, len(validationErrors))
+		for _, fieldErr := range validationErrors {
+			params = append(params, InvalidParam{
+				Name:   fieldErr.Field(),
+				Reason: validationReason(fieldErr),
+			})
+		}
+		extensions = map[string]interface{}{"invalid_params": params}
+	}
+
+	writeError(c, http.StatusBadRequest, models.ErrorResponse{
+		Error:   "Invalid request",
+		Message: err.Error(),
+	}, extensions)
+}
+
+// WriteInvalidParams reports a request rejected for the given fields as 400, listing them as
+// invalid_params for problem+json clients like binding failures
+func WriteInvalidParams(c *gin.Context, errResp models.ErrorResponse, params []InvalidParam) {
+	writeError(c, http.StatusBadRequest, errResp, map[string]interface{}{"invalid_params": params})
+}
+
+func writeError(c *gin.Context, status int, errResp models.ErrorResponse, extensions map[string]interface{}) {
+	if errResp.RequestID == "" {
+		errResp.RequestID = c.GetString(requestid.ContextKey)
+	}
+	if !WantsProblemJSON(c) {
+		c.AbortWithStatusJSON(status, errResp)
+		return
+	}
+
+	code := problemCode(errResp.Error)
+	if extensions == nil {
+		extensions = make(map[string]interface{}, 1)
+	}
+	extensions["code"] = code
+	if errResp.RequestID != "" {
+		extensions["request_id"] = errResp.RequestID
+	}
+	if len(errResp.Hints) > 0 {
+		extensions["hints"] = errResp.Hints
+	}
+
+	sharedMiddleware.AbortWithProblem(c, &sharedMiddleware.ProblemDetails{
+		Type:       problemTypeBase + code,
+		Title:      errResp.Error,
+		Status:     status,
+		Detail:     errResp.Message,
+		Extensions: extensions,
+	})
+}
+
+// problemCode turns an error title into a stable slug ("Invalid request" -> "invalid-request")
+func problemCode(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// validationReason renders a human readable reason for a failed validation tag
+func validationReason(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fieldErr.Param() + " characters"
+	case "max":
+		return "must be at most " + fieldErr.Param() + " characters"
+	case "oneof":
+		return "must be one of: " + fieldErr.Param()
+	default:
+		return "failed " + fieldErr.Tag() + " validation"
+	}
+}
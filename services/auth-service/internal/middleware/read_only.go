@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+
+	"auth-service/internal/maintenance"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readOnlyRetryAfter is the Retry-After (seconds) sent with writes refused during maintenance
+const readOnlyRetryAfter = "30"
+
+// readOnlyWritableRoutes keep working in read-only mode: they only touch Redis (token verification,
+// refresh rotation and runtime logging changes), only read the database (client-credentials tokens) or
+// leave the mode itself
+var readOnlyWritableRoutes = map[string]bool{
+	"/api/v1/verify":                                true,
+	"/oauth2/token":                                 true,
+	"/api/v1/auth/refresh":                          true,
+	"/api/v1/admin/maintenance":                     true,
+	"/api/v1/admin/logging/level":                   true,
+	"/api/v1/admin/logging/debug-targets":           true,
+	"/api/v1/admin/logging/debug-targets/:targetId": true,
+}
+
+// ReadOnlyMode refuses writes with 503 while the service is in read-only maintenance mode
+// Reads (GET, HEAD, OPTIONS), token verification and refresh are served as usual
+func ReadOnlyMode(mode *maintenance.Mode) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if !mode.ReadOnly() || isReadRequest(c.Request.Method) || readOnlyWritableRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		message := mode.Current().Reason
+		if message == "" {
+			message = "The service is read-only during maintenance; try again shortly"
+		}
+		c.Header("X-Maintenance-Mode", "read-only")
+		c.Header("Retry-After", readOnlyRetryAfter)
+		WriteError(c, http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "Read-only maintenance",
+			Message: message,
+		})
+	})
+}
+
+// isReadRequest reports whether method never changes state
+func isReadRequest(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
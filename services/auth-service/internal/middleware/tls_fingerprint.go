@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"auth-service/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys holding the TLS client fingerprints forwarded by the terminating proxy
+const (
+	ja3Key = "tls_ja3"
+	ja4Key = "tls_ja4"
+)
+
+// maxFingerprintLength bounds forwarded fingerprints; a JA3 hash is 32 chars and a JA4 string 36
+const maxFingerprintLength = 128
+
+// TLSFingerprint copies the JA3/JA4 fingerprints from the configured proxy headers into the context
+// Missing, empty or oversized headers are treated as absent; the request always continues
+func TLSFingerprint(cfg *config.TLSFingerprintConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value := fingerprintHeader(c, cfg.JA3Header); value != "" {
+			c.Set(ja3Key, value)
+		}
+		if value := fingerprintHeader(c, cfg.JA4Header); value != "" {
+			c.Set(ja4Key, value)
+		}
+		c.Next()
+	}
+}
+
+// GetTLSFingerprints returns the JA3 and JA4 fingerprints stored by TLSFingerprint, empty when absent
+func GetTLSFingerprints(c *gin.Context) (ja3, ja4 string) {
+	return c.GetString(ja3Key), c.GetString(ja4Key)
+}
+
+// fingerprintHeader reads one fingerprint header, ignoring it when unconfigured or implausibly long
+func fingerprintHeader(c *gin.Context, name string) string {
+	if name == "" {
+		return ""
+	}
+	value := c.GetHeader(name)
+	if len(value) > maxFingerprintLength {
+		return ""
+	}
+	return value
+}
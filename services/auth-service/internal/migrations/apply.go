@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockID is the PostgreSQL advisory lock key held while migrations run at startup
+const migrationLockID int64 = 0x6d696772617465 // "migrate"
+
+// Apply runs every pending migration from fsys against db, for services that migrate themselves at startup
+// fsys is typically an embed.FS holding migrations/*.sql. Replicas starting together are serialised with
+// a PostgreSQL advisory lock, so only the first one applies migrations and the rest find nothing pending.
+// metrics is optional and records the run and every migration it applies.
+func Apply(ctx context.Context, db *gorm.DB, fsys fs.FS, environment string, metrics *Metrics) (results []*MigrationResult, err error) {
+	defer func() { metrics.ObserveRun(err) }()
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB from gorm.DB: %w", err)
+	}
+
+	// Advisory locks belong to a session, so hold one dedicated connection for the lock
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve connection for migration lock: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			log.Printf("⚠️ Failed to release migration lock: %v", err)
+		}
+	}()
+
+	manager, err := NewMigrationManagerFS(db.WithContext(ctx), fsys, environment)
+	if err != nil {
+		return nil, err
+	}
+	manager.SetMetrics(metrics)
+
+	// Refuse to run on top of migrations that were edited after being applied
+	drifts, err := manager.VerifyChecksums()
+	if err != nil {
+		return nil, err
+	}
+	for _, drift := range drifts {
+		if drift.FileMissing {
+			log.Printf("⚠️ Applied migration %s_%s is not in the embedded migrations", drift.Version, drift.Name)
+			continue
+		}
+		return nil, fmt.Errorf("migration %s_%s was modified after being applied (run migrate verify)", drift.Version, drift.Name)
+	}
+
+	return manager.ApplyMigrations()
+}
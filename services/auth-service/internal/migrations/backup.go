@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Backup dumps the database before migrations are applied so a failed or harmful migration can be recovered from
+// Without Command it runs pg_dump in custom format; a custom Command runs through `sh -c` with the
+// connection in the standard PG* environment variables and must write the artifact to $BACKUP_FILE
+type Backup struct {
+	Host     string
+	Port     string
+	Database string
+	User     string
+	Password string
+	SSLMode  string
+
+	Dir     string // Directory the artifact is written to; created if missing
+	Command string // Optional replacement for pg_dump, e.g. a wrapper that also uploads the dump
+}
+
+// Run writes a backup taken before applying the migration at version and returns the artifact path
+func (b *Backup) Run(ctx context.Context, environment, version string) (string, error) {
+	if err := os.MkdirAll(b.Dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	name := fmt.Sprintf("%s_%s_%s_before_%s.dump", b.Database, environment, time.Now().UTC().Format("20060102150405"), version)
+	path := filepath.Join(b.Dir, name)
+	// A leftover file from a run in the same second must not pass for this backup
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	var cmd *exec.Cmd
+	if b.Command != "" {
+		cmd = exec.CommandContext(ctx, "sh", "-c", b.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "pg_dump", "--format=custom", "--no-password", "--file", path)
+	}
+	cmd.Env = append(os.Environ(),
+		"PGHOST="+b.Host,
+		"PGPORT="+b.Port,
+		"PGDATABASE="+b.Database,
+		"PGUSER="+b.User,
+		"PGPASSWORD="+b.Password,
+		"PGSSLMODE="+b.SSLMode,
+		"BACKUP_FILE="+path,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	log.Printf("💾 Backing up %s before migration %s...", b.Database, version)
+	startTime := time.Now()
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("backup command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("backup command did not write %s: %w", path, err)
+	}
+
+	log.Printf("💾 Backup written to %s (%d bytes, %s)", path, info.Size(), time.Since(startTime).Round(time.Millisecond))
+	return path, nil
+}
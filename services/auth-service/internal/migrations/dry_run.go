@@ -0,0 +1,121 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lockTimeoutSQLState is PostgreSQL's lock_not_available error code
+const lockTimeoutSQLState = "55P03"
+
+// DryRunResult is the outcome of rehearsing one migration inside a rolled-back transaction
+type DryRunResult struct {
+	Migration      *Migration
+	Success        bool
+	Error          error
+	Skipped        bool          // The migration runs outside a transaction ("-- no-transaction") and can't be rehearsed
+	LockTimeout    bool          // The migration waited longer than the lock timeout for a lock
+	ExclusiveLocks []string      // Relations the migration locked with ACCESS EXCLUSIVE
+	ExecutionTime  time.Duration // Estimated duration against the current data
+}
+
+// DryRunMigrations executes the given migrations in order inside a single transaction and rolls it back
+// Each migration runs under its own savepoint, so later migrations see earlier ones and a failure
+// doesn't prevent the rest from being rehearsed. Nothing is committed.
+func (m *MigrationManager) DryRunMigrations(pending []*Migration, lockTimeout time.Duration) ([]*DryRunResult, error) {
+	tx, err := m.sqlDB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin dry-run transaction: %w", err)
+	}
+	// Always roll back: a dry run must never change the schema
+	defer tx.Rollback()
+
+	if lockTimeout > 0 {
+		if _, err := tx.Exec(fmt.Sprintf("SET LOCAL lock_timeout = '%dms'", lockTimeout.Milliseconds())); err != nil {
+			return nil, fmt.Errorf("failed to set lock timeout: %w", err)
+		}
+	}
+
+	// Locks accumulate across savepoints; only report the ones each migration adds
+	held := make(map[string]bool)
+
+	var results []*DryRunResult
+	for i, migration := range pending {
+		if migration.NoTransaction {
+			results = append(results, &DryRunResult{Migration: migration, Skipped: true})
+			continue
+		}
+
+		savepoint := fmt.Sprintf("dry_run_%d", i)
+		if _, err := tx.Exec("SAVEPOINT " + savepoint); err != nil {
+			return results, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		result := &DryRunResult{Migration: migration}
+		startTime := time.Now()
+		var execErr error
+		if migration.IsGo() {
+			execErr = migration.Up(m.gormTx(tx))
+		} else {
+			execErr = execStatements(execFunc(tx), migration.UpSQL, upSQLFirstLine(migration))
+		}
+		result.ExecutionTime = time.Since(startTime)
+
+		if execErr != nil {
+			result.Error = execErr
+			result.LockTimeout = strings.Contains(execErr.Error(), lockTimeoutSQLState)
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT " + savepoint); err != nil {
+				return append(results, result), fmt.Errorf("failed to roll back savepoint: %w", err)
+			}
+		} else {
+			result.Success = true
+			locks, err := exclusiveLocks(tx)
+			if err != nil {
+				log.Printf("⚠️  Failed to inspect locks for %s: %v", migration.Version, err)
+			}
+			for relation := range locks {
+				if !held[relation] {
+					held[relation] = true
+					result.ExclusiveLocks = append(result.ExclusiveLocks, relation)
+				}
+			}
+			sort.Strings(result.ExclusiveLocks)
+			if _, err := tx.Exec("RELEASE SAVEPOINT " + savepoint); err != nil {
+				return append(results, result), fmt.Errorf("failed to release savepoint: %w", err)
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	log.Printf("🔙 Dry run complete, rolled back %d migrations", len(results))
+	return results, nil
+}
+
+// exclusiveLocks lists relations this transaction holds ACCESS EXCLUSIVE locks on
+func exclusiveLocks(tx *sql.Tx) (map[string]bool, error) {
+	rows, err := tx.Query(`
+		SELECT c.relname
+		FROM pg_locks l
+		JOIN pg_class c ON c.oid = l.relation
+		WHERE l.pid = pg_backend_pid() AND l.mode = 'AccessExclusiveLock'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locks := make(map[string]bool)
+	for rows.Next() {
+		var relation string
+		if err := rows.Scan(&relation); err != nil {
+			return nil, err
+		}
+		locks[relation] = true
+	}
+	return locks, rows.Err()
+}
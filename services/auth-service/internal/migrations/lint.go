@@ -0,0 +1,222 @@
+package migrations
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// LintRule identifies a migration lint check
+type LintRule string
+
+// Lint rules; a statement or file opts out with a "-- lint:ignore <rule>[, <rule>]" comment
+const (
+	LintDestructive   LintRule = "destructive"    // DROP TABLE, DROP COLUMN and TRUNCATE lose data
+	LintMissingDown   LintRule = "missing-down"   // The migration can't be rolled back
+	LintNonIdempotent LintRule = "non-idempotent" // CREATE/ADD without IF NOT EXISTS or OR REPLACE
+	LintTransaction   LintRule = "transaction"    // The statement can't run inside the transaction wrapper
+)
+
+// LintSeverity is "error" (fails the lint) or "warning"
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"
+	LintWarning LintSeverity = "warning"
+)
+
+// LintFinding is one problem found in a migration file
+type LintFinding struct {
+	Version   string
+	File      string
+	Section   string // "up" or "down"
+	Line      int    // File line of the statement; 0 for file-level findings and DOWN sections
+	Rule      LintRule
+	Severity  LintSeverity
+	Message   string
+	Statement string // Shortened statement text
+}
+
+var (
+	// lintIgnorePattern matches "-- lint:ignore destructive, non-idempotent"
+	lintIgnorePattern = regexp.MustCompile(`(?im)^\s*--\s*lint:ignore\s+(.+)$`)
+
+	dropTablePattern     = regexp.MustCompile(`^DROP\s+TABLE\b`)
+	truncatePattern      = regexp.MustCompile(`^TRUNCATE\b`)
+	alterTablePattern    = regexp.MustCompile(`^ALTER\s+TABLE\b`)
+	alterTableDropTarget = regexp.MustCompile(`\bDROP\s+(?:IF\s+EXISTS\s+)?("?\w+"?)`)
+	addColumnPattern     = regexp.MustCompile(`\bADD\s+COLUMN\s+(IF\s+NOT\s+EXISTS\s+)?`)
+
+	createIfNotExistsPattern = regexp.MustCompile(`^CREATE\s+(?:UNIQUE\s+)?(?:TEMP(?:ORARY)?\s+|UNLOGGED\s+)?(TABLE|INDEX|SCHEMA|SEQUENCE|EXTENSION|MATERIALIZED\s+VIEW)\s+(?:CONCURRENTLY\s+)?(IF\s+NOT\s+EXISTS\b)?`)
+	createOrReplacePattern   = regexp.MustCompile(`^CREATE\s+(OR\s+REPLACE\s+)?(?:CONSTRAINT\s+)?(FUNCTION|PROCEDURE|VIEW|TRIGGER)\b`)
+	createTypePattern        = regexp.MustCompile(`^CREATE\s+TYPE\b`)
+
+	concurrentlyPattern     = regexp.MustCompile(`^(CREATE\s+(UNIQUE\s+)?INDEX|DROP\s+INDEX|REINDEX)\b.*\bCONCURRENTLY\b`)
+	nonTransactionalPattern = regexp.MustCompile(`^(VACUUM|CREATE\s+DATABASE|DROP\s+DATABASE|ALTER\s+SYSTEM|CREATE\s+TABLESPACE|DROP\s+TABLESPACE)\b`)
+	enumAddValuePattern     = regexp.MustCompile(`^ALTER\s+TYPE\b.*\bADD\s+VALUE\b`)
+)
+
+// alterTableDropKeeps lists ALTER TABLE ... DROP targets that don't remove a column
+var alterTableDropKeeps = map[string]bool{"CONSTRAINT": true, "DEFAULT": true, "NOT": true, "IDENTITY": true, "EXPRESSION": true}
+
+// LoadMigrationFiles parses the migration files in fsys without a database connection (used by lint)
+func LoadMigrationFiles(fsys fs.FS, migrationsDir string) ([]*Migration, error) {
+	m := &MigrationManager{migrationsFS: fsys, migrationsDir: migrationsDir}
+	return m.loadMigrationFiles()
+}
+
+// LintMigrations checks SQL migrations for destructive operations, missing DOWN sections,
+// non-idempotent CREATE statements and statements that can't run in the transaction wrapper
+// Go migrations are skipped.
+func LintMigrations(migrations []*Migration) []LintFinding {
+	var findings []LintFinding
+	for _, migration := range migrations {
+		if !migration.IsGo() {
+			findings = append(findings, lintMigration(migration)...)
+		}
+	}
+	return findings
+}
+
+func lintMigration(migration *Migration) []LintFinding {
+	var findings []LintFinding
+	fileIgnores := lintIgnores(headerComments(migration.Content))
+
+	add := func(section string, stmt *Statement, rule LintRule, severity LintSeverity, message string) {
+		if fileIgnores[rule] || (stmt != nil && lintIgnores(stmt.SQL)[rule]) {
+			return
+		}
+		finding := LintFinding{
+			Version:  migration.Version,
+			File:     migration.FilePath,
+			Section:  section,
+			Rule:     rule,
+			Severity: severity,
+			Message:  message,
+		}
+		if stmt != nil {
+			finding.Statement = snippet(stmt.SQL)
+			if section == "up" {
+				finding.Line = stmt.StartLine
+			}
+		}
+		findings = append(findings, finding)
+	}
+
+	if migration.DownSQL == "" {
+		add("up", nil, LintMissingDown, LintError, "no DOWN MIGRATION section: the migration can't be rolled back")
+	}
+
+	for _, stmt := range SplitStatements(migration.UpSQL, upSQLFirstLine(migration)) {
+		if isTransactionControl(stmt) {
+			continue
+		}
+		text := normalizeStatement(stmt.SQL)
+
+		if message := destructiveOperation(text); message != "" {
+			add("up", &stmt, LintDestructive, LintError, message)
+		}
+		if message := nonIdempotentCreate(text); message != "" {
+			add("up", &stmt, LintNonIdempotent, LintWarning, message)
+		}
+		if !migration.NoTransaction {
+			if message, severity := transactionIncompatibility(text); message != "" {
+				add("up", &stmt, LintTransaction, severity, message)
+			}
+		}
+	}
+
+	if !migration.NoTransaction {
+		for _, stmt := range SplitStatements(migration.DownSQL, 1) {
+			if message, severity := transactionIncompatibility(normalizeStatement(stmt.SQL)); message != "" {
+				add("down", &stmt, LintTransaction, severity, message)
+			}
+		}
+	}
+
+	return findings
+}
+
+// destructiveOperation describes a statement that permanently removes data
+func destructiveOperation(text string) string {
+	switch {
+	case dropTablePattern.MatchString(text):
+		return "DROP TABLE permanently deletes the table and its data"
+	case truncatePattern.MatchString(text):
+		return "TRUNCATE permanently deletes every row"
+	case alterTablePattern.MatchString(text):
+		for _, match := range alterTableDropTarget.FindAllStringSubmatch(text, -1) {
+			if !alterTableDropKeeps[strings.Trim(match[1], `"`)] {
+				return "DROP COLUMN permanently deletes the column and its data"
+			}
+		}
+	}
+	return ""
+}
+
+// nonIdempotentCreate describes a CREATE or ADD COLUMN that fails when the migration is re-run
+func nonIdempotentCreate(text string) string {
+	if match := createIfNotExistsPattern.FindStringSubmatch(text); match != nil && match[2] == "" {
+		object := strings.Join(strings.Fields(match[1]), " ")
+		return fmt.Sprintf("CREATE %s without IF NOT EXISTS fails if the %s already exists", object, strings.ToLower(object))
+	}
+	if match := createOrReplacePattern.FindStringSubmatch(text); match != nil && match[1] == "" {
+		return fmt.Sprintf("CREATE %s without OR REPLACE fails if the %s already exists", match[2], strings.ToLower(match[2]))
+	}
+	if createTypePattern.MatchString(text) {
+		return "CREATE TYPE fails if the type already exists; wrap it in a DO block that checks pg_type"
+	}
+	if alterTablePattern.MatchString(text) {
+		for _, match := range addColumnPattern.FindAllStringSubmatch(text, -1) {
+			if match[1] == "" {
+				return "ADD COLUMN without IF NOT EXISTS fails if the column already exists"
+			}
+		}
+	}
+	return ""
+}
+
+// transactionIncompatibility describes a statement that can't (or shouldn't) run inside a transaction
+func transactionIncompatibility(text string) (string, LintSeverity) {
+	switch {
+	case concurrentlyPattern.MatchString(text):
+		return "CONCURRENTLY can't run inside a transaction; add a \"-- no-transaction\" header line", LintError
+	case nonTransactionalPattern.MatchString(text):
+		return "statement can't run inside a transaction block; add a \"-- no-transaction\" header line", LintError
+	case enumAddValuePattern.MatchString(text):
+		return "a new enum value can't be used until the transaction commits; use it in a later migration", LintWarning
+	}
+	return "", ""
+}
+
+// normalizeStatement upper-cases a statement and collapses comments and whitespace for pattern matching
+func normalizeStatement(sqlText string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(stripComments(sqlText)), " "))
+}
+
+// headerComments returns the comment block at the top of a migration file
+func headerComments(content string) string {
+	var header []string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+			break
+		}
+		header = append(header, line)
+	}
+	return strings.Join(header, "\n")
+}
+
+// lintIgnores collects the rules named by "-- lint:ignore" comments in text
+func lintIgnores(text string) map[LintRule]bool {
+	ignores := make(map[LintRule]bool)
+	for _, match := range lintIgnorePattern.FindAllStringSubmatch(text, -1) {
+		for _, rule := range strings.Split(match[1], ",") {
+			if rule = strings.TrimSpace(rule); rule != "" {
+				ignores[LintRule(strings.ToLower(rule))] = true
+			}
+		}
+	}
+	return ignores
+}
@@ -0,0 +1,148 @@
+package migrations
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// migrationBuckets are the histogram upper bounds in seconds for migration execution time
+// Migrations range from instant DDL to index builds and backfills that take minutes
+var migrationBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+// Run outcomes reported by auth_service_migration_runs_total
+const (
+	RunSuccess = "success"
+	RunFailure = "failure"
+)
+
+// migrationKey identifies one migration in metric labels
+type migrationKey struct {
+	version string
+	name    string
+}
+
+// migrationStats holds the counters for one migration
+type migrationStats struct {
+	applied  uint64
+	failures uint64
+	sum      float64
+	count    uint64
+	buckets  []uint64
+}
+
+// Metrics aggregates migration runs, applied and failed migrations and execution time per migration
+// A nil *Metrics records nothing and writes nothing
+type Metrics struct {
+	mu         sync.Mutex
+	runs       map[string]uint64
+	migrations map[migrationKey]*migrationStats
+}
+
+// NewMetrics creates an empty migration metrics registry
+func NewMetrics() *Metrics {
+	return &Metrics{
+		runs:       make(map[string]uint64),
+		migrations: make(map[migrationKey]*migrationStats),
+	}
+}
+
+// ObserveRun records the outcome of a whole migration run (e.g. Apply at startup)
+func (m *Metrics) ObserveRun(err error) {
+	if m == nil {
+		return
+	}
+
+	outcome := RunSuccess
+	if err != nil {
+		outcome = RunFailure
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[outcome]++
+}
+
+// ObserveMigration records one applied or failed migration and how long it ran
+func (m *Metrics) ObserveMigration(migration *Migration, duration time.Duration, success bool) {
+	if m == nil {
+		return
+	}
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := migrationKey{version: migration.Version, name: migration.Name}
+	stats, exists := m.migrations[key]
+	if !exists {
+		stats = &migrationStats{buckets: make([]uint64, len(migrationBuckets))}
+		m.migrations[key] = stats
+	}
+
+	if success {
+		stats.applied++
+	} else {
+		stats.failures++
+	}
+	stats.count++
+	stats.sum += seconds
+	for i, bound := range migrationBuckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+		}
+	}
+}
+
+// WritePrometheus writes all migration metrics in the Prometheus text format
+func (m *Metrics) WritePrometheus(w io.Writer) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]migrationKey, 0, len(m.migrations))
+	var applied uint64
+	for key, stats := range m.migrations {
+		keys = append(keys, key)
+		applied += stats.applied
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].version < keys[j].version })
+
+	fmt.Fprintln(w, "# HELP auth_service_migration_runs_total Total number of migration runs by outcome")
+	fmt.Fprintln(w, "# TYPE auth_service_migration_runs_total counter")
+	for _, outcome := range []string{RunSuccess, RunFailure} {
+		fmt.Fprintf(w, "auth_service_migration_runs_total{result=%q} %d\n", outcome, m.runs[outcome])
+	}
+
+	fmt.Fprintln(w, "# HELP auth_service_migrations_applied_total Total number of migrations applied")
+	fmt.Fprintln(w, "# TYPE auth_service_migrations_applied_total counter")
+	fmt.Fprintf(w, "auth_service_migrations_applied_total %d\n", applied)
+
+	fmt.Fprintln(w, "# HELP auth_service_migration_failures_total Total number of failed migrations")
+	fmt.Fprintln(w, "# TYPE auth_service_migration_failures_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(w, "auth_service_migration_failures_total{%s} %d\n", migrationLabels(key), m.migrations[key].failures)
+	}
+
+	fmt.Fprintln(w, "# HELP auth_service_migration_duration_seconds Migration execution time")
+	fmt.Fprintln(w, "# TYPE auth_service_migration_duration_seconds histogram")
+	for _, key := range keys {
+		stats := m.migrations[key]
+		for i, bound := range migrationBuckets {
+			fmt.Fprintf(w, "auth_service_migration_duration_seconds_bucket{%s,le=\"%g\"} %d\n", migrationLabels(key), bound, stats.buckets[i])
+		}
+		fmt.Fprintf(w, "auth_service_migration_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", migrationLabels(key), stats.count)
+		fmt.Fprintf(w, "auth_service_migration_duration_seconds_sum{%s} %g\n", migrationLabels(key), stats.sum)
+		fmt.Fprintf(w, "auth_service_migration_duration_seconds_count{%s} %d\n", migrationLabels(key), stats.count)
+	}
+}
+
+// migrationLabels renders the label set identifying a migration
+func migrationLabels(key migrationKey) string {
+	return fmt.Sprintf("version=%q,name=%q", key.version, key.name)
+}
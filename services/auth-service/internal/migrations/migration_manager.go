@@ -1,8 +1,10 @@
 package migrations
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
@@ -21,7 +23,10 @@ type MigrationManager struct {
 	db          *gorm.DB
 	sqlDB       *sql.DB
 	migrationsDir string
+	migrationsFS  fs.FS // Source of migration files: the directory on disk or an embedded FS
 	environment   string
+	metrics       *Metrics // Optional; records applied and failed migrations
+	backup        *Backup  // Optional; dumps the database before pending migrations are applied
 }
 
 // MigrationRecord tracks applied migrations in the database
@@ -50,6 +55,28 @@ type Migration struct {
 	Checksum  string
 	UpSQL     string
 	DownSQL   string
+	Up        GoMigrationFunc // Set for Go migrations instead of UpSQL
+	Down      GoMigrationFunc // Optional rollback for Go migrations
+	// Environments lists where the migration runs ("-- Environment: test, development"); empty means ALL
+	Environments []string
+	// NoTransaction runs the statements outside a transaction ("-- no-transaction"), e.g. for CREATE INDEX CONCURRENTLY
+	NoTransaction bool
+}
+
+// knownEnvironments are the environment names accepted by --env; other header values are warned about
+var knownEnvironments = []string{"development", "test", "staging", "production"}
+
+// AppliesTo reports whether the migration runs in the given environment
+func (mig *Migration) AppliesTo(environment string) bool {
+	if len(mig.Environments) == 0 {
+		return true
+	}
+	for _, env := range mig.Environments {
+		if strings.EqualFold(env, environment) {
+			return true
+		}
+	}
+	return false
 }
 
 // MigrationResult contains the result of migration execution
@@ -59,10 +86,21 @@ type MigrationResult struct {
 	Error         error
 	ExecutionTime time.Duration
 	RollbackSQL   string
+	BackupPath    string // Artifact of the backup taken before the run, shared by every result of the run
 }
 
 // NewMigrationManager creates a new migration manager
 func NewMigrationManager(db *gorm.DB, migrationsDir, environment string) (*MigrationManager, error) {
+	return newMigrationManager(db, os.DirFS(migrationsDir), migrationsDir, environment)
+}
+
+// NewMigrationManagerFS creates a migration manager that reads migration files from fsys (e.g. an embed.FS)
+// Every *.sql file in fsys is loaded, including those in subdirectories such as migrations/
+func NewMigrationManagerFS(db *gorm.DB, fsys fs.FS, environment string) (*MigrationManager, error) {
+	return newMigrationManager(db, fsys, "embedded migrations", environment)
+}
+
+func newMigrationManager(db *gorm.DB, fsys fs.FS, migrationsDir, environment string) (*MigrationManager, error) {
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sql.DB from gorm.DB: %w", err)
@@ -72,6 +110,7 @@ func NewMigrationManager(db *gorm.DB, migrationsDir, environment string) (*Migra
 		db:            db,
 		sqlDB:         sqlDB,
 		migrationsDir: migrationsDir,
+		migrationsFS:  fsys,
 		environment:   environment,
 	}
 
@@ -83,6 +122,28 @@ func NewMigrationManager(db *gorm.DB, migrationsDir, environment string) (*Migra
 	return manager, nil
 }
 
+// SetMetrics records every migration applied by this manager in metrics
+func (m *MigrationManager) SetMetrics(metrics *Metrics) {
+	m.metrics = metrics
+}
+
+// SetBackup takes a backup before every run that has pending migrations; a failed backup aborts the run
+func (m *MigrationManager) SetBackup(backup *Backup) {
+	m.backup = backup
+}
+
+// runBackup takes the configured pre-migration backup; without one it returns an empty path
+func (m *MigrationManager) runBackup(pending []*Migration) (string, error) {
+	if m.backup == nil {
+		return "", nil
+	}
+	path, err := m.backup.Run(context.Background(), m.environment, pending[0].Version)
+	if err != nil {
+		return "", fmt.Errorf("pre-migration backup failed, no migrations were applied: %w", err)
+	}
+	return path, nil
+}
+
 // ensureMigrationsTable creates the migration tracking table if it doesn't exist
 func (m *MigrationManager) ensureMigrationsTable() error {
 	createTableSQL := `
@@ -124,10 +185,10 @@ func (m *MigrationManager) GetPendingMigrations() ([]*Migration, error) {
 		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
 	}
 
-	// Filter out already applied migrations
+	// Filter out already applied migrations and those scoped to other environments
 	var pending []*Migration
 	for _, migration := range allMigrations {
-		if !appliedVersions[migration.Version] {
+		if !appliedVersions[migration.Version] && migration.AppliesTo(m.environment) {
 			pending = append(pending, migration)
 		}
 	}
@@ -135,11 +196,27 @@ func (m *MigrationManager) GetPendingMigrations() ([]*Migration, error) {
 	return pending, nil
 }
 
+// GetSkippedMigrations returns migrations whose Environment header excludes this environment
+func (m *MigrationManager) GetSkippedMigrations() ([]*Migration, error) {
+	allMigrations, err := m.loadMigrationFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migration files: %w", err)
+	}
+
+	var skipped []*Migration
+	for _, migration := range allMigrations {
+		if !migration.AppliesTo(m.environment) {
+			skipped = append(skipped, migration)
+		}
+	}
+	return skipped, nil
+}
+
 // loadMigrationFiles loads and parses all migration files from the directory
 func (m *MigrationManager) loadMigrationFiles() ([]*Migration, error) {
 	var migrations []*Migration
 
-	err := filepath.WalkDir(m.migrationsDir, func(path string, d fs.DirEntry, err error) error {
+	err := fs.WalkDir(m.migrationsFS, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -172,6 +249,16 @@ func (m *MigrationManager) loadMigrationFiles() ([]*Migration, error) {
 		return nil, err
 	}
 
+	// Interleave registered Go migrations with the SQL files by version
+	for _, goMigration := range registeredGoMigrations() {
+		for _, migration := range migrations {
+			if compareVersions(migration.Version, goMigration.Version) == 0 {
+				return nil, fmt.Errorf("Go migration %s_%s conflicts with %s", goMigration.Version, goMigration.Name, migration.FilePath)
+			}
+		}
+		migrations = append(migrations, goMigration)
+	}
+
 	// Sort migrations by version
 	sort.Slice(migrations, func(i, j int) bool {
 		vi, _ := strconv.Atoi(migrations[i].Version)
@@ -183,11 +270,12 @@ func (m *MigrationManager) loadMigrationFiles() ([]*Migration, error) {
 }
 
 // parseMigrationFile parses a single migration file
-func (m *MigrationManager) parseMigrationFile(filePath, version, name string) (*Migration, error) {
-	content, err := os.ReadFile(filePath)
+func (m *MigrationManager) parseMigrationFile(path, version, name string) (*Migration, error) {
+	content, err := fs.ReadFile(m.migrationsFS, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read migration file: %w", err)
 	}
+	filePath := filepath.Join(m.migrationsDir, path)
 
 	contentStr := string(content)
 	checksum := m.calculateChecksum(contentStr)
@@ -196,17 +284,58 @@ func (m *MigrationManager) parseMigrationFile(filePath, version, name string) (*
 	upSQL, downSQL := m.splitMigrationContent(contentStr)
 
 	return &Migration{
-		Version:  version,
-		Name:     name,
-		FilePath: filePath,
-		Content:  contentStr,
-		Checksum: checksum,
-		UpSQL:    upSQL,
-		DownSQL:  downSQL,
+		Version:      version,
+		Name:         name,
+		FilePath:     filePath,
+		Content:      contentStr,
+		Checksum:     checksum,
+		UpSQL:        upSQL,
+		DownSQL:      downSQL,
+		Environments: parseEnvironments(path, contentStr),
+		NoTransaction: hasHeaderDirective(contentStr, noTransactionDirective),
 	}, nil
 }
 
+// parseEnvironments reads the "-- Environment:" directive from the comment header at the top of a file
+// "ALL" (or no directive) returns nil so the migration runs everywhere
+func parseEnvironments(path, content string) []string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "--") {
+			break // The header ends at the first statement
+		}
+
+		directive := strings.TrimSpace(strings.TrimPrefix(trimmed, "--"))
+		key, value, found := strings.Cut(directive, ":")
+		if !found || !strings.EqualFold(strings.TrimSpace(key), "Environment") {
+			continue
+		}
+
+		var environments []string
+		for _, env := range strings.Split(value, ",") {
+			env = strings.ToLower(strings.TrimSpace(env))
+			if env == "" {
+				continue
+			}
+			if env == "all" {
+				return nil
+			}
+			if !contains(knownEnvironments, env) {
+				log.Printf("⚠️  Migration %s targets unknown environment %q", path, env)
+			}
+			environments = append(environments, env)
+		}
+		return environments
+	}
+	return nil
+}
+
 // splitMigrationContent splits migration content into UP and DOWN sections
+// The DOWN section is usually written as commented-out SQL ("-- To rollback this migration, run:");
+// when it contains no executable lines it is uncommented so rollbacks can run it
 func (m *MigrationManager) splitMigrationContent(content string) (upSQL, downSQL string) {
 	lines := strings.Split(content, "\n")
 	var upLines, downLines []string
@@ -214,8 +343,12 @@ func (m *MigrationManager) splitMigrationContent(content string) (upSQL, downSQL
 
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "-- DOWN MIGRATION") || strings.HasPrefix(trimmed, "-- ROLLBACK") {
+		if strings.HasPrefix(trimmed, "--") && (strings.Contains(trimmed, "DOWN MIGRATION") || strings.HasPrefix(trimmed, "-- ROLLBACK")) {
 			inDownSection = true
+			// Drop the separator line written just above the DOWN heading
+			if n := len(upLines); n > 0 && isSeparatorComment(upLines[n-1]) {
+				upLines = upLines[:n-1]
+			}
 			continue
 		}
 
@@ -227,10 +360,78 @@ func (m *MigrationManager) splitMigrationContent(content string) (upSQL, downSQL
 	}
 
 	upSQL = strings.TrimSpace(strings.Join(upLines, "\n"))
-	downSQL = strings.TrimSpace(strings.Join(downLines, "\n"))
+	downSQL = strings.TrimSpace(strings.Join(uncommentDownSection(downLines), "\n"))
 	return
 }
 
+// uncommentDownSection turns a fully commented DOWN section into executable SQL
+// Sections that already contain executable statements are returned unchanged
+func uncommentDownSection(lines []string) []string {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+			return lines
+		}
+	}
+
+	var sqlLines []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if isSeparatorComment(trimmed) || strings.HasPrefix(trimmed, "-- To rollback") {
+			continue
+		}
+		trimmed = strings.TrimPrefix(trimmed, "--")
+		sqlLines = append(sqlLines, strings.TrimPrefix(trimmed, " "))
+	}
+	return sqlLines
+}
+
+// isSeparatorComment reports whether a line is a "-- =====" banner
+func isSeparatorComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "-- ===")
+}
+
+// GetAppliedMigrations returns the tracking records for this environment ordered by version
+func (m *MigrationManager) GetAppliedMigrations() ([]MigrationRecord, error) {
+	var records []MigrationRecord
+	if err := m.db.Where("environment = ?", m.environment).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query migration records: %w", err)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return compareVersions(records[i].Version, records[j].Version) < 0
+	})
+	return records, nil
+}
+
+// HistoryFilter selects schema_migrations records for GetMigrationHistory
+type HistoryFilter struct {
+	Environment string    // Empty selects every environment
+	Since       time.Time // Inclusive lower bound on applied_at; zero means unbounded
+	Until       time.Time // Exclusive upper bound on applied_at; zero means unbounded
+}
+
+// GetMigrationHistory returns the tracking records matching filter in the order they were applied
+func (m *MigrationManager) GetMigrationHistory(filter HistoryFilter) ([]MigrationRecord, error) {
+	query := m.db.Model(&MigrationRecord{})
+	if filter.Environment != "" {
+		query = query.Where("environment = ?", filter.Environment)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("applied_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("applied_at < ?", filter.Until)
+	}
+
+	var records []MigrationRecord
+	if err := query.Order("applied_at").Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query migration history: %w", err)
+	}
+	return records, nil
+}
+
 // getAppliedVersions returns a map of applied migration versions
 func (m *MigrationManager) getAppliedVersions() (map[string]bool, error) {
 	var records []MigrationRecord
@@ -259,11 +460,17 @@ func (m *MigrationManager) ApplyMigrations() ([]*MigrationResult, error) {
 		return nil, nil
 	}
 
+	backupPath, err := m.runBackup(pending)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Printf("🚀 Applying %d pending migrations...", len(pending))
 
 	var results []*MigrationResult
 	for _, migration := range pending {
 		result := m.applyMigration(migration)
+		result.BackupPath = backupPath
 		results = append(results, result)
 
 		if !result.Success {
@@ -287,6 +494,11 @@ func (m *MigrationManager) applyMigration(migration *Migration) *MigrationResult
 		Migration: migration,
 		Success:   false,
 	}
+	defer func() { m.metrics.ObserveMigration(migration, time.Since(startTime), result.Success) }()
+
+	if migration.NoTransaction {
+		return m.applyWithoutTransaction(migration, result, startTime)
+	}
 
 	// Begin transaction
 	tx, err := m.sqlDB.Begin()
@@ -296,14 +508,67 @@ func (m *MigrationManager) applyMigration(migration *Migration) *MigrationResult
 	}
 	defer tx.Rollback()
 
-	// Execute migration SQL
-	if _, err := tx.Exec(migration.UpSQL); err != nil {
-		result.Error = fmt.Errorf("failed to execute migration SQL: %w", err)
+	// Execute migration SQL (or Go function)
+	if err := m.runUp(tx, migration); err != nil {
+		result.Error = fmt.Errorf("failed to execute migration: %w", err)
 		return result
 	}
 
 	// Record migration in tracking table
 	executionTime := time.Since(startTime)
+	if err := m.recordMigration(tx, migration, executionTime); err != nil {
+		result.Error = fmt.Errorf("failed to record migration: %w", err)
+		return result
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		result.Error = fmt.Errorf("failed to commit migration: %w", err)
+		return result
+	}
+
+	result.Success = true
+	result.ExecutionTime = executionTime
+	result.RollbackSQL = migration.DownSQL
+	return result
+}
+
+// applyWithoutTransaction runs a "-- no-transaction" migration statement by statement in autocommit mode
+// Statements that succeeded before a failure stay applied, so such migrations should be idempotent
+// (CREATE INDEX CONCURRENTLY IF NOT EXISTS) and are only recorded once every statement has run
+func (m *MigrationManager) applyWithoutTransaction(migration *Migration, result *MigrationResult, startTime time.Time) *MigrationResult {
+	if err := execStatements(execFunc(m.sqlDB), migration.UpSQL, upSQLFirstLine(migration)); err != nil {
+		result.Error = fmt.Errorf("failed to execute migration outside a transaction: %w", err)
+		return result
+	}
+
+	executionTime := time.Since(startTime)
+	if err := m.recordMigration(m.sqlDB, migration, executionTime); err != nil {
+		result.Error = fmt.Errorf("failed to record migration: %w", err)
+		return result
+	}
+
+	result.Success = true
+	result.ExecutionTime = executionTime
+	result.RollbackSQL = migration.DownSQL
+	return result
+}
+
+// sqlExecer is satisfied by both *sql.DB and *sql.Tx
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// execFunc adapts db for execStatements
+func execFunc(db sqlExecer) func(query string) error {
+	return func(query string) error {
+		_, err := db.Exec(query)
+		return err
+	}
+}
+
+// recordMigration inserts the tracking row for an applied migration
+func (m *MigrationManager) recordMigration(db sqlExecer, migration *Migration, executionTime time.Duration) error {
 	record := MigrationRecord{
 		Version:         migration.Version,
 		Name:            migration.Name,
@@ -318,24 +583,210 @@ func (m *MigrationManager) applyMigration(migration *Migration) *MigrationResult
 		INSERT INTO schema_migrations (version, name, checksum, applied_at, applied_by, environment, execution_time_ms)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
-	if _, err := tx.Exec(insertSQL, record.Version, record.Name, record.Checksum, 
-		record.AppliedAt, record.AppliedBy, record.Environment, record.ExecutionTimeMs); err != nil {
-		result.Error = fmt.Errorf("failed to record migration: %w", err)
+	_, err := db.Exec(insertSQL, record.Version, record.Name, record.Checksum,
+		record.AppliedAt, record.AppliedBy, record.Environment, record.ExecutionTimeMs)
+	return err
+}
+
+// GetPendingMigrationsTo returns pending migrations up to and including the target version
+func (m *MigrationManager) GetPendingMigrationsTo(target string) ([]*Migration, error) {
+	if err := m.ensureVersionExists(target); err != nil {
+		return nil, err
+	}
+
+	pending, err := m.GetPendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []*Migration
+	for _, migration := range pending {
+		if compareVersions(migration.Version, target) <= 0 {
+			selected = append(selected, migration)
+		}
+	}
+	return selected, nil
+}
+
+// MigrateTo applies pending migrations up to and including the target version
+func (m *MigrationManager) MigrateTo(target string) ([]*MigrationResult, error) {
+	pending, err := m.GetPendingMigrationsTo(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		log.Printf("✅ Schema is already at or beyond version %s", target)
+		return nil, nil
+	}
+
+	backupPath, err := m.runBackup(pending)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🚀 Applying %d migrations up to version %s...", len(pending), target)
+
+	var results []*MigrationResult
+	for _, migration := range pending {
+		result := m.applyMigration(migration)
+		result.BackupPath = backupPath
+		results = append(results, result)
+
+		if !result.Success {
+			return results, fmt.Errorf("migration %s failed: %w", migration.Version, result.Error)
+		}
+	}
+
+	return results, nil
+}
+
+// GetRollbackPlan returns applied migrations newer than the target version, newest first
+// Target "0" rolls back every applied migration
+func (m *MigrationManager) GetRollbackPlan(target string) ([]*Migration, error) {
+	if target != "0" {
+		if err := m.ensureVersionExists(target); err != nil {
+			return nil, err
+		}
+	}
+
+	allMigrations, err := m.loadMigrationFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migration files: %w", err)
+	}
+
+	appliedVersions, err := m.getAppliedVersions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	var plan []*Migration
+	for i := len(allMigrations) - 1; i >= 0; i-- {
+		migration := allMigrations[i]
+		if appliedVersions[migration.Version] && compareVersions(migration.Version, target) > 0 {
+			plan = append(plan, migration)
+		}
+	}
+	return plan, nil
+}
+
+// RollbackTo reverts applied migrations newer than the target version using their DOWN sections
+// Stops at the first migration without DOWN SQL or with a failing rollback
+func (m *MigrationManager) RollbackTo(target string) ([]*MigrationResult, error) {
+	plan, err := m.GetRollbackPlan(target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse up front rather than leaving the schema half rolled back
+	for _, migration := range plan {
+		if !migration.HasDown() {
+			return nil, fmt.Errorf("migration %s has no DOWN section, cannot roll back past it", migration.Version)
+		}
+	}
+
+	var results []*MigrationResult
+	for _, migration := range plan {
+		result := m.rollbackMigration(migration)
+		results = append(results, result)
+
+		if !result.Success {
+			return results, fmt.Errorf("rollback of %s failed: %w", migration.Version, result.Error)
+		}
+
+		log.Printf("↩️  Rolled back migration %s: %s (%.2fms)",
+			migration.Version, migration.Name, float64(result.ExecutionTime.Nanoseconds())/1e6)
+	}
+
+	return results, nil
+}
+
+// rollbackMigration runs a migration's DOWN SQL and removes its tracking record
+func (m *MigrationManager) rollbackMigration(migration *Migration) *MigrationResult {
+	startTime := time.Now()
+
+	result := &MigrationResult{
+		Migration:   migration,
+		Success:     false,
+		RollbackSQL: migration.DownSQL,
+	}
+
+	if migration.NoTransaction {
+		if err := execStatements(execFunc(m.sqlDB), migration.DownSQL, 1); err != nil {
+			result.Error = fmt.Errorf("failed to execute rollback outside a transaction: %w", err)
+			return result
+		}
+		deleteSQL := `DELETE FROM schema_migrations WHERE version = $1 AND environment = $2`
+		if _, err := m.sqlDB.Exec(deleteSQL, migration.Version, m.environment); err != nil {
+			result.Error = fmt.Errorf("failed to remove migration record: %w", err)
+			return result
+		}
+		result.Success = true
+		result.ExecutionTime = time.Since(startTime)
+		return result
+	}
+
+	tx, err := m.sqlDB.Begin()
+	if err != nil {
+		result.Error = fmt.Errorf("failed to begin transaction: %w", err)
+		return result
+	}
+	defer tx.Rollback()
+
+	if err := m.runDown(tx, migration); err != nil {
+		result.Error = fmt.Errorf("failed to execute rollback: %w", err)
+		return result
+	}
+
+	deleteSQL := `DELETE FROM schema_migrations WHERE version = $1 AND environment = $2`
+	if _, err := tx.Exec(deleteSQL, migration.Version, m.environment); err != nil {
+		result.Error = fmt.Errorf("failed to remove migration record: %w", err)
 		return result
 	}
 
-	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		result.Error = fmt.Errorf("failed to commit migration: %w", err)
+		result.Error = fmt.Errorf("failed to commit rollback: %w", err)
 		return result
 	}
 
 	result.Success = true
-	result.ExecutionTime = executionTime
-	result.RollbackSQL = migration.DownSQL
+	result.ExecutionTime = time.Since(startTime)
 	return result
 }
 
+// ensureVersionExists rejects target versions that don't match a migration file or Go migration
+func (m *MigrationManager) ensureVersionExists(version string) error {
+	if _, err := strconv.ParseInt(version, 10, 64); err != nil {
+		return fmt.Errorf("invalid migration version %q", version)
+	}
+
+	allMigrations, err := m.loadMigrationFiles()
+	if err != nil {
+		return fmt.Errorf("failed to load migration files: %w", err)
+	}
+
+	for _, migration := range allMigrations {
+		if compareVersions(migration.Version, version) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("migration version %s not found in %s", version, m.migrationsDir)
+}
+
+// compareVersions compares numeric migration versions ("001" equals "1")
+func compareVersions(a, b string) int {
+	va, _ := strconv.ParseInt(a, 10, 64)
+	vb, _ := strconv.ParseInt(b, 10, 64)
+	switch {
+	case va < vb:
+		return -1
+	case va > vb:
+		return 1
+	default:
+		return 0
+	}
+}
+
 // ValidateSchema validates current database schema against expected schema
 func (m *MigrationManager) ValidateSchema() error {
 	// This would implement schema validation logic
@@ -351,6 +802,11 @@ func (m *MigrationManager) ValidateSchema() error {
 	requiredTables := []string{
 		"users", "sessions", "login_attempts", 
 		"user_preferences", "user_activities", "user_notifications",
+		"role_grants", "password_resets", "honeypots", "honeypot_triggers",
+		"user_notification_summaries", "user_notifications_archive", "webauthn_credentials",
+		"personal_access_tokens", "user_oauth_identities", "oauth_clients", "user_merges", "push_subscriptions",
+		"user_activity_archives", "user_data_keys",
+		"roles", "permissions", "role_permissions", "user_roles", "audit_log",
 		"schema_migrations",
 	}
 
@@ -400,24 +856,136 @@ func (m *MigrationManager) GetMigrationStatus() (*MigrationStatus, error) {
 		return nil, err
 	}
 
-	applied := len(all) - len(pending)
+	skipped := 0
+	for _, migration := range all {
+		if !migration.AppliesTo(m.environment) {
+			skipped++
+		}
+	}
+	total := len(all) - skipped
+	applied := total - len(pending)
 
-	return &MigrationStatus{
-		TotalMigrations:   len(all),
+	status := &MigrationStatus{
+		TotalMigrations:   total,
 		AppliedMigrations: applied,
 		PendingMigrations: len(pending),
+		SkippedMigrations: skipped,
 		Environment:       m.environment,
-		LastAppliedAt:     time.Now(), // This should query the actual last migration
-	}, nil
+	}
+
+	last, err := m.getLastAppliedMigration()
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		status.LastAppliedVersion = last.Version
+		status.LastAppliedName = last.Name
+		status.LastAppliedAt = &last.AppliedAt
+	}
+	return status, nil
+}
+
+// getLastAppliedMigration returns the most recently applied migration of this environment, or nil
+func (m *MigrationManager) getLastAppliedMigration() (*MigrationRecord, error) {
+	var record MigrationRecord
+	err := m.db.Where("environment = ?", m.environment).Order("applied_at DESC").Order("id DESC").First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query last applied migration: %w", err)
+	}
+	return &record, nil
 }
 
 // MigrationStatus represents current migration status
+// The LastApplied fields describe the most recently applied migration and are empty before the first one
 type MigrationStatus struct {
-	TotalMigrations   int       `json:"total_migrations"`
-	AppliedMigrations int       `json:"applied_migrations"`
-	PendingMigrations int       `json:"pending_migrations"`
-	Environment       string    `json:"environment"`
-	LastAppliedAt     time.Time `json:"last_applied_at"`
+	TotalMigrations    int        `json:"total_migrations"`
+	AppliedMigrations  int        `json:"applied_migrations"`
+	PendingMigrations  int        `json:"pending_migrations"`
+	SkippedMigrations  int        `json:"skipped_migrations"` // Scoped to other environments by their Environment header
+	Environment        string     `json:"environment"`
+	LastAppliedVersion string     `json:"last_applied_version,omitempty"`
+	LastAppliedName    string     `json:"last_applied_name,omitempty"`
+	LastAppliedAt      *time.Time `json:"last_applied_at,omitempty"`
+}
+
+// ChecksumDrift describes an applied migration whose file no longer matches the recorded checksum
+type ChecksumDrift struct {
+	Version          string `json:"version"`
+	Name             string `json:"name"`
+	RecordedChecksum string `json:"recorded_checksum"`
+	CurrentChecksum  string `json:"current_checksum,omitempty"`
+	FileMissing      bool   `json:"file_missing"`
+}
+
+// VerifyChecksums compares on-disk migration files with the checksums stored in schema_migrations
+// Returns one entry per applied migration that was modified or deleted after being applied
+func (m *MigrationManager) VerifyChecksums() ([]*ChecksumDrift, error) {
+	all, err := m.loadMigrationFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migration files: %w", err)
+	}
+
+	files := make(map[string]*Migration, len(all))
+	for _, migration := range all {
+		files[migration.Version] = migration
+	}
+
+	var records []MigrationRecord
+	if err := m.db.Where("environment = ?", m.environment).Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query migration records: %w", err)
+	}
+
+	var drifts []*ChecksumDrift
+	for _, record := range records {
+		migration, exists := files[record.Version]
+		if !exists {
+			drifts = append(drifts, &ChecksumDrift{
+				Version:          record.Version,
+				Name:             record.Name,
+				RecordedChecksum: record.Checksum,
+				FileMissing:      true,
+			})
+			continue
+		}
+
+		if migration.Checksum != record.Checksum {
+			drifts = append(drifts, &ChecksumDrift{
+				Version:          record.Version,
+				Name:             record.Name,
+				RecordedChecksum: record.Checksum,
+				CurrentChecksum:  migration.Checksum,
+			})
+		}
+	}
+
+	return drifts, nil
+}
+
+// RepairChecksums re-baselines recorded checksums to match the current migration files
+// Only drifted records with a file on disk are updated; missing files must be resolved manually
+func (m *MigrationManager) RepairChecksums(drifts []*ChecksumDrift) (int, error) {
+	repaired := 0
+	for _, drift := range drifts {
+		if drift.FileMissing {
+			log.Printf("⚠️  Skipping %s: migration file is missing, cannot re-baseline", drift.Version)
+			continue
+		}
+
+		result := m.db.Model(&MigrationRecord{}).
+			Where("version = ? AND environment = ?", drift.Version, m.environment).
+			Update("checksum", drift.CurrentChecksum)
+		if result.Error != nil {
+			return repaired, fmt.Errorf("failed to repair checksum for %s: %w", drift.Version, result.Error)
+		}
+
+		repaired += int(result.RowsAffected)
+		log.Printf("🔧 Re-baselined checksum for %s: %s", drift.Version, drift.Name)
+	}
+
+	return repaired, nil
 }
 
 // calculateChecksum calculates SHA-256 checksum of content
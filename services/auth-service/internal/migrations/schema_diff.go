@@ -0,0 +1,315 @@
+package migrations
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SchemaDiff lists the DDL needed to bring one table in line with its GORM model
+// Only additive changes are generated; type mismatches and extra columns are reported for manual review
+type SchemaDiff struct {
+	TableName    string   `json:"table_name"`
+	CreateTable  bool     `json:"create_table"`
+	Statements   []string `json:"statements"`
+	Rollback     []string `json:"rollback"`
+	ManualReview []string `json:"manual_review,omitempty"`
+}
+
+// HasChanges reports whether the diff contains anything to write
+func (d *SchemaDiff) HasChanges() bool {
+	return len(d.Statements) > 0 || len(d.ManualReview) > 0
+}
+
+// GenerateDiff compares every managed model with the live database
+func (sv *SchemaValidator) GenerateDiff() ([]*SchemaDiff, error) {
+	modelTables := managedModels()
+
+	tableNames := make([]string, 0, len(modelTables))
+	for tableName := range modelTables {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	var diffs []*SchemaDiff
+	for _, tableName := range tableNames {
+		diff, err := sv.DiffTable(tableName, modelTables[tableName])
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff table %s: %w", tableName, err)
+		}
+		if diff.HasChanges() {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs, nil
+}
+
+// DiffTable generates CREATE TABLE / ADD COLUMN / CREATE INDEX statements for a single model
+func (sv *SchemaValidator) DiffTable(tableName string, model interface{}) (*SchemaDiff, error) {
+	stmt := &gorm.Statement{DB: sv.db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, fmt.Errorf("failed to parse model: %w", err)
+	}
+
+	diff := &SchemaDiff{TableName: tableName}
+
+	exists, err := sv.tableExists(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if table exists: %w", err)
+	}
+
+	if !exists {
+		diff.CreateTable = true
+		diff.Statements = append(diff.Statements, sv.createTableSQL(tableName, stmt.Schema))
+		for _, index := range stmt.Schema.ParseIndexes() {
+			diff.Statements = append(diff.Statements, createIndexSQL(tableName, index))
+		}
+		diff.Rollback = append(diff.Rollback, fmt.Sprintf("DROP TABLE IF EXISTS %s;", tableName))
+		return diff, nil
+	}
+
+	dbColumns, err := sv.getDatabaseColumns(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database columns: %w", err)
+	}
+
+	modelColumns := make(map[string]bool)
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		modelColumns[field.DBName] = true
+
+		dbCol, exists := dbColumns[field.DBName]
+		if !exists {
+			diff.Statements = append(diff.Statements,
+				fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s;", tableName, field.DBName, sv.columnDefinition(field)))
+			diff.Rollback = append(diff.Rollback,
+				fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;", tableName, field.DBName))
+			continue
+		}
+
+		if expected := sv.mapGoTypeToDBType(field); expected != "unknown" && !sv.typesMatch(expected, dbCol.DataType) {
+			diff.ManualReview = append(diff.ManualReview,
+				fmt.Sprintf("column %s is %s in the database but the model expects %s", field.DBName, dbCol.DataType, expected))
+		}
+	}
+
+	for colName := range dbColumns {
+		if !modelColumns[colName] && !sv.isSystemColumn(colName) {
+			diff.ManualReview = append(diff.ManualReview,
+				fmt.Sprintf("column %s exists in the database but not in the model", colName))
+		}
+	}
+	sort.Strings(diff.ManualReview)
+
+	dbIndexes, err := sv.getDatabaseIndexes(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database indexes: %w", err)
+	}
+	for _, index := range stmt.Schema.ParseIndexes() {
+		if sv.indexExists(index.Name, dbIndexes) {
+			continue
+		}
+		diff.Statements = append(diff.Statements, createIndexSQL(tableName, index))
+		diff.Rollback = append(diff.Rollback, fmt.Sprintf("DROP INDEX IF EXISTS %s;", index.Name))
+	}
+
+	return diff, nil
+}
+
+// foreignKeyPattern parses the "column -> table(column)" form of expected foreign keys
+var foreignKeyPattern = regexp.MustCompile(`^(\w+) -> (\w+)\((\w+)\)$`)
+
+// fixForeignKeyOnDelete matches 001_initial_schema.sql: rows owned by a user are removed with the user
+const fixForeignKeyOnDelete = "CASCADE"
+
+// GenerateFixDiffs turns validation results into the DDL that closes the reported drift
+// Tables that failed validation are diffed against their models (missing tables, columns and indexes),
+// missing foreign keys are added, broken triggers are recreated (with their function when it is missing),
+// and problems that need a decision are left for manual review
+func (sv *SchemaValidator) GenerateFixDiffs(results []*SchemaValidationResult) ([]*SchemaDiff, error) {
+	modelTables := managedModels()
+
+	sorted := append([]*SchemaValidationResult(nil), results...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].TableName < sorted[j].TableName })
+
+	createdFunctions := make(map[string]bool)
+	var diffs []*SchemaDiff
+	for _, result := range sorted {
+		model, managed := modelTables[result.TableName]
+		if !managed || (result.IsValid && len(result.MissingIndexes) == 0) {
+			continue
+		}
+
+		diff, err := sv.DiffTable(result.TableName, model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff table %s: %w", result.TableName, err)
+		}
+
+		expectedFK := sv.getExpectedForeignKeys(result.TableName)
+		for _, issue := range result.ConstraintIssues {
+			match := foreignKeyPattern.FindStringSubmatch(expectedFK[issue.ConstraintName])
+			if issue.Issue != "Missing foreign key constraint" || match == nil {
+				diff.ManualReview = append(diff.ManualReview, fmt.Sprintf("%s: %s", issue.ConstraintName, issue.Issue))
+				continue
+			}
+			diff.Statements = append(diff.Statements,
+				fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s) ON DELETE %s;",
+					result.TableName, issue.ConstraintName, match[1], match[2], match[3], fixForeignKeyOnDelete))
+			diff.Rollback = append(diff.Rollback,
+				fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", result.TableName, issue.ConstraintName))
+		}
+
+		for _, issue := range result.TriggerIssues {
+			definition, known := triggerFunctionDefinitions[issue.Function]
+			if !known {
+				diff.ManualReview = append(diff.ManualReview, fmt.Sprintf("%s: %s", issue.TriggerName, issue.Issue))
+				continue
+			}
+			// The function is created once, before the first trigger that executes it
+			if issue.MissingFunction && !createdFunctions[issue.Function] {
+				createdFunctions[issue.Function] = true
+				diff.Statements = append(diff.Statements, definition)
+				diff.Rollback = append(diff.Rollback, fmt.Sprintf("DROP FUNCTION IF EXISTS %s();", issue.Function))
+			}
+			diff.Statements = append(diff.Statements,
+				fmt.Sprintf("CREATE OR REPLACE TRIGGER %s BEFORE UPDATE ON %s FOR EACH ROW EXECUTE FUNCTION %s();",
+					issue.TriggerName, result.TableName, issue.Function))
+			diff.Rollback = append(diff.Rollback,
+				fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", issue.TriggerName, result.TableName))
+		}
+
+		for _, mismatch := range append(result.EnumMismatches, result.CheckMismatches...) {
+			if len(mismatch.MissingValues) > 0 {
+				diff.ManualReview = append(diff.ManualReview, fmt.Sprintf("%s (%s) rejects %s",
+					mismatch.Constraint, mismatch.ColumnName, strings.Join(mismatch.MissingValues, ", ")))
+			}
+		}
+
+		if diff.HasChanges() {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs, nil
+}
+
+// columnDefinition renders the column type, nullability and default the way GORM's migrator would
+func (sv *SchemaValidator) columnDefinition(field *schema.Field) string {
+	expr := sv.db.Migrator().FullDataTypeOf(field)
+	if len(expr.Vars) > 0 {
+		return sv.db.Dialector.Explain(expr.SQL, expr.Vars...)
+	}
+	return expr.SQL
+}
+
+// createTableSQL renders a CREATE TABLE statement for a model that has no table yet
+func (sv *SchemaValidator) createTableSQL(tableName string, s *schema.Schema) string {
+	var lines []string
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("    %s %s", field.DBName, sv.columnDefinition(field)))
+	}
+
+	if len(s.PrimaryFields) > 0 {
+		primaryKeys := make([]string, 0, len(s.PrimaryFields))
+		for _, field := range s.PrimaryFields {
+			primaryKeys = append(primaryKeys, field.DBName)
+		}
+		lines = append(lines, fmt.Sprintf("    PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n);", tableName, strings.Join(lines, ",\n"))
+}
+
+// createIndexSQL renders a CREATE INDEX statement from a parsed GORM index
+func createIndexSQL(tableName string, index *schema.Index) string {
+	columns := make([]string, 0, len(index.Fields))
+	for _, option := range index.Fields {
+		if option.Expression != "" {
+			columns = append(columns, option.Expression)
+		} else if option.Field != nil {
+			columns = append(columns, option.DBName)
+		}
+	}
+
+	unique := ""
+	if strings.EqualFold(index.Class, "UNIQUE") {
+		unique = "UNIQUE "
+	}
+
+	using := ""
+	if index.Type != "" {
+		using = fmt.Sprintf(" USING %s", index.Type)
+	}
+
+	where := ""
+	if index.Where != "" {
+		where = fmt.Sprintf(" WHERE %s", index.Where)
+	}
+
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s%s (%s)%s;",
+		unique, index.Name, tableName, using, strings.Join(columns, ", "), where)
+}
+
+// RenderDiffMigration renders diffs as a draft migration file in the standard layout
+func RenderDiffMigration(version, name string, diffs []*SchemaDiff) string {
+	return renderDiffMigration(version, name, "migrate diff", diffs)
+}
+
+// RenderFixMigration renders the diffs of GenerateFixDiffs as a fix-up migration to review
+func RenderFixMigration(version, name string, diffs []*SchemaDiff) string {
+	return renderDiffMigration(version, name, "migrate validate --fix-output", diffs)
+}
+
+func renderDiffMigration(version, name, generator string, diffs []*SchemaDiff) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "-- ==========================================\n")
+	fmt.Fprintf(&b, "-- Migration: %s_%s.sql\n", version, name)
+	fmt.Fprintf(&b, "-- Purpose: %s (generated by %s - review before applying)\n", name, generator)
+	fmt.Fprintf(&b, "-- Author: Migration System\n")
+	fmt.Fprintf(&b, "-- Date: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "-- Environment: ALL\n")
+	fmt.Fprintf(&b, "-- ==========================================\n\n")
+
+	fmt.Fprintf(&b, "-- 🔄 FORWARD MIGRATION (UP)\n")
+	fmt.Fprintf(&b, "BEGIN;\n")
+	for _, diff := range diffs {
+		if len(diff.Statements) == 0 && len(diff.ManualReview) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n-- %s\n", diff.TableName)
+		for _, note := range diff.ManualReview {
+			fmt.Fprintf(&b, "-- TODO (manual review): %s\n", note)
+		}
+		for _, statement := range diff.Statements {
+			fmt.Fprintf(&b, "%s\n", statement)
+		}
+	}
+	fmt.Fprintf(&b, "\nCOMMIT;\n\n")
+
+	fmt.Fprintf(&b, "-- ==========================================\n")
+	fmt.Fprintf(&b, "-- 🔙 DOWN MIGRATION (ROLLBACK)\n")
+	fmt.Fprintf(&b, "-- ==========================================\n")
+	fmt.Fprintf(&b, "-- To rollback this migration, run:\n")
+	fmt.Fprintf(&b, "-- \n")
+	fmt.Fprintf(&b, "-- BEGIN;\n")
+	for i := len(diffs) - 1; i >= 0; i-- {
+		for j := len(diffs[i].Rollback) - 1; j >= 0; j-- {
+			fmt.Fprintf(&b, "-- %s\n", diffs[i].Rollback[j])
+		}
+	}
+	fmt.Fprintf(&b, "-- COMMIT;\n")
+
+	return b.String()
+}
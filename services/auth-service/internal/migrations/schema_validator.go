@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"regexp"
 	"strings"
 	"time"
 
@@ -29,9 +30,62 @@ type SchemaValidationResult struct {
 	MissingIndexes    []string                 `json:"missing_indexes"`
 	ExtraIndexes      []string                 `json:"extra_indexes"`
 	ConstraintIssues  []ConstraintIssue        `json:"constraint_issues"`
+	EnumMismatches    []AllowedValuesMismatch  `json:"enum_mismatches"`
+	CheckMismatches   []AllowedValuesMismatch  `json:"check_mismatches"`
+	TriggerIssues     []TriggerIssue           `json:"trigger_issues"`
 	RecommendedActions []string                `json:"recommended_actions"`
 }
 
+// TriggerIssue reports a required trigger that is missing or doesn't do what the application relies on,
+// or whose trigger function doesn't exist
+type TriggerIssue struct {
+	TriggerName     string `json:"trigger_name"`
+	Function        string `json:"function"`
+	Issue           string `json:"issue"`
+	MissingFunction bool   `json:"missing_function,omitempty"` // The trigger function itself has to be created
+}
+
+// expectedTrigger is a BEFORE UPDATE ... FOR EACH ROW trigger a table must have
+type expectedTrigger struct {
+	Name     string
+	Function string
+}
+
+// updatedAtFunction is the trigger function from 001_initial_schema.sql that keeps updated_at current
+// for writes that bypass GORM (raw SQL, psql, other services)
+const updatedAtFunction = "update_updated_at_column"
+
+// triggerFunctionDefinitions holds the definition of each trigger function the schema depends on, used
+// to generate fix SQL when one is missing
+var triggerFunctionDefinitions = map[string]string{
+	updatedAtFunction: `CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ language 'plpgsql';`,
+}
+
+// AllowedValuesMismatch reports a column whose database-enforced values (enum labels or a CHECK
+// constraint's IN list) differ from the values the application writes
+type AllowedValuesMismatch struct {
+	ColumnName    string   `json:"column_name"`
+	Constraint    string   `json:"constraint"`               // Enum type or CHECK constraint name
+	MissingValues []string `json:"missing_values,omitempty"` // Used by the application but rejected by the database
+	ExtraValues   []string `json:"extra_values,omitempty"`   // Accepted by the database but unknown to the application
+}
+
+// expectedAllowedValues is the enum type or CHECK constraint expected on a column and its values
+type expectedAllowedValues struct {
+	Column     string
+	Constraint string
+	Values     []string
+}
+
+// checkValuePattern extracts the quoted literals from a CHECK constraint definition
+var checkValuePattern = regexp.MustCompile(`'((?:[^']|'')*)'`)
+
 // ColumnTypeMismatch represents a column type mismatch
 type ColumnTypeMismatch struct {
 	ColumnName   string `json:"column_name"`
@@ -78,16 +132,40 @@ func NewSchemaValidator(db *gorm.DB) (*SchemaValidator, error) {
 	}, nil
 }
 
+// managedModels maps each migration-managed table to its GORM model
+func managedModels() map[string]interface{} {
+	return map[string]interface{}{
+		"users":                       &models.User{},
+		"sessions":                    &models.Session{},
+		"login_attempts":              &models.LoginAttempt{},
+		"user_preferences":            &models.UserPreference{},
+		"user_activities":             &models.UserActivity{},
+		"user_notifications":          &models.UserNotification{},
+		"role_grants":                 &models.RoleGrant{},
+		"password_resets":             &models.PasswordReset{},
+		"honeypots":                   &models.Honeypot{},
+		"honeypot_triggers":           &models.HoneypotTrigger{},
+		"user_notification_summaries": &models.NotificationSummary{},
+		"user_notifications_archive":  &models.ArchivedNotification{},
+		"webauthn_credentials":        &models.WebAuthnCredential{},
+		"personal_access_tokens":      &models.PersonalAccessToken{},
+		"user_oauth_identities":       &models.OAuthIdentity{},
+		"oauth_clients":               &models.OAuthClient{},
+		"user_merges":                 &models.UserMerge{},
+		"push_subscriptions":          &models.PushSubscription{},
+		"user_activity_archives":      &models.UserActivityArchive{},
+		"user_data_keys":              &models.UserDataKey{},
+		"roles":                       &models.Role{},
+		"permissions":                 &models.Permission{},
+		"role_permissions":            &models.RolePermission{},
+		"user_roles":                  &models.UserRoleAssignment{},
+		"audit_log":                   &models.AuditLogEntry{},
+	}
+}
+
 // ValidateAllTables validates all model tables against database schema
 func (sv *SchemaValidator) ValidateAllTables() ([]*SchemaValidationResult, error) {
-	modelTables := map[string]interface{}{
-		"users":              &models.User{},
-		"sessions":           &models.Session{},
-		"login_attempts":     &models.LoginAttempt{},
-		"user_preferences":   &models.UserPreference{},
-		"user_activities":    &models.UserActivity{},
-		"user_notifications": &models.UserNotification{},
-	}
+	modelTables := managedModels()
 
 	var results []*SchemaValidationResult
 
@@ -147,6 +225,13 @@ func (sv *SchemaValidator) ValidateTable(tableName string, model interface{}) (*
 	// Validate constraints
 	sv.validateConstraints(result, tableName)
 
+	// Validate enum labels and CHECK constraint values
+	sv.validateEnums(result, tableName)
+	sv.validateCheckConstraints(result, tableName)
+
+	// Validate triggers and the functions they execute
+	sv.validateTriggers(result, tableName, model)
+
 	// Generate recommendations
 	sv.generateRecommendations(result)
 
@@ -505,11 +590,337 @@ func (sv *SchemaValidator) getExpectedForeignKeys(tableName string) map[string]s
 		expectedFK["user_activities_user_id_fkey"] = "user_id -> users(id)"
 	case "user_notifications":
 		expectedFK["user_notifications_user_id_fkey"] = "user_id -> users(id)"
+	case "role_grants":
+		expectedFK["role_grants_user_id_fkey"] = "user_id -> users(id)"
+	case "password_resets":
+		expectedFK["password_resets_user_id_fkey"] = "user_id -> users(id)"
+	case "honeypot_triggers":
+		expectedFK["honeypot_triggers_honeypot_id_fkey"] = "honeypot_id -> honeypots(id)"
+	case "user_notification_summaries":
+		expectedFK["user_notification_summaries_user_id_fkey"] = "user_id -> users(id)"
+	case "user_notifications_archive":
+		expectedFK["user_notifications_archive_user_id_fkey"] = "user_id -> users(id)"
+	case "webauthn_credentials":
+		expectedFK["webauthn_credentials_user_id_fkey"] = "user_id -> users(id)"
+	case "personal_access_tokens":
+		expectedFK["personal_access_tokens_user_id_fkey"] = "user_id -> users(id)"
+	case "user_oauth_identities":
+		expectedFK["user_oauth_identities_user_id_fkey"] = "user_id -> users(id)"
+	case "push_subscriptions":
+		expectedFK["push_subscriptions_user_id_fkey"] = "user_id -> users(id)"
+	case "user_activity_archives":
+		expectedFK["user_activity_archives_user_id_fkey"] = "user_id -> users(id)"
+	case "user_data_keys":
+		expectedFK["user_data_keys_user_id_fkey"] = "user_id -> users(id)"
 	}
 	
 	return expectedFK
 }
 
+// getExpectedEnums returns the PostgreSQL enum types a table's columns must use
+func (sv *SchemaValidator) getExpectedEnums(tableName string) []expectedAllowedValues {
+	switch tableName {
+	case "users":
+		return []expectedAllowedValues{{
+			Column:     "role",
+			Constraint: "user_role",
+			Values:     []string{string(models.RoleUser), string(models.RoleAdmin), string(models.RoleModerator)},
+		}}
+	case "role_grants":
+		return []expectedAllowedValues{{
+			Column:     "role",
+			Constraint: "user_role",
+			Values:     []string{string(models.RoleUser), string(models.RoleAdmin), string(models.RoleModerator)},
+		}}
+	}
+	return nil
+}
+
+// getExpectedCheckConstraints returns the CHECK (column IN (...)) constraints expected on a table
+func (sv *SchemaValidator) getExpectedCheckConstraints(tableName string) []expectedAllowedValues {
+	switch tableName {
+	case "users":
+		return []expectedAllowedValues{
+			{Column: "approval_status", Constraint: "check_valid_approval_status", Values: []string{models.ApprovalPending, models.ApprovalApproved, models.ApprovalRejected}},
+		}
+	case "user_preferences":
+		return []expectedAllowedValues{
+			{Column: "theme", Constraint: "check_valid_theme", Values: []string{"light", "dark", "auto"}},
+			{Column: "privacy_level", Constraint: "check_valid_privacy_level", Values: []string{"private", "normal", "public"}},
+		}
+	case "user_notifications":
+		return []expectedAllowedValues{
+			{Column: "type", Constraint: "check_notification_type", Values: []string{"info", "warning", "error", "success", "promotion", "reminder", "system"}},
+		}
+	case "role_grants":
+		return []expectedAllowedValues{
+			{Column: "end_reason", Constraint: "check_valid_grant_end_reason", Values: []string{models.GrantEndExpired, models.GrantEndRevoked}},
+		}
+	case "honeypots":
+		return []expectedAllowedValues{
+			{Column: "kind", Constraint: "check_valid_honeypot_kind", Values: []string{models.HoneypotAccount, models.HoneypotAPIKey}},
+		}
+	}
+	return nil
+}
+
+// getExpectedTriggers returns the triggers a table must have: every table with an updated_at column
+// gets update_<table>_updated_at, as in 001_initial_schema.sql, and user_merges rejects changes
+func (sv *SchemaValidator) getExpectedTriggers(tableName string, model interface{}) []expectedTrigger {
+	if tableName == "user_merges" {
+		return []expectedTrigger{{Name: "prevent_user_merges_changes", Function: "prevent_user_merge_changes"}}
+	}
+
+	stmt := &gorm.Statement{DB: sv.db}
+	if err := stmt.Parse(model); err != nil {
+		return nil
+	}
+	if stmt.Schema.LookUpField("updated_at") == nil {
+		return nil
+	}
+	return []expectedTrigger{{Name: fmt.Sprintf("update_%s_updated_at", tableName), Function: updatedAtFunction}}
+}
+
+// validateTriggers checks each expected trigger exists, is enabled, fires BEFORE UPDATE FOR EACH ROW and
+// executes a trigger function that exists
+func (sv *SchemaValidator) validateTriggers(result *SchemaValidationResult, tableName string, model interface{}) {
+	expected := sv.getExpectedTriggers(tableName, model)
+	if len(expected) == 0 {
+		return
+	}
+
+	triggers, err := sv.getDatabaseTriggers(tableName)
+	if err != nil {
+		log.Printf("Warning: Failed to get triggers for %s: %v", tableName, err)
+		return
+	}
+
+	for _, trigger := range expected {
+		functionExists, err := sv.triggerFunctionExists(trigger.Function)
+		if err != nil {
+			log.Printf("Warning: Failed to look up function %s: %v", trigger.Function, err)
+			continue
+		}
+
+		issue := TriggerIssue{TriggerName: trigger.Name, Function: trigger.Function, MissingFunction: !functionExists}
+		actual, exists := triggers[trigger.Name]
+		switch {
+		case !exists:
+			issue.Issue = "Missing trigger"
+		case actual.Function != trigger.Function:
+			issue.Issue = fmt.Sprintf("Trigger executes %s() instead of %s()", actual.Function, trigger.Function)
+		case !actual.Before || !actual.OnUpdate || !actual.ForEachRow:
+			issue.Issue = "Trigger must fire BEFORE UPDATE FOR EACH ROW"
+		case !actual.Enabled:
+			issue.Issue = "Trigger is disabled"
+		case !functionExists:
+			issue.Issue = fmt.Sprintf("Trigger function %s() is missing", trigger.Function)
+		default:
+			continue
+		}
+		result.TriggerIssues = append(result.TriggerIssues, issue)
+		result.IsValid = false
+	}
+}
+
+// DatabaseTrigger represents a user-defined trigger on a table
+type DatabaseTrigger struct {
+	Name       string
+	Function   string
+	Before     bool
+	OnUpdate   bool
+	ForEachRow bool
+	Enabled    bool
+}
+
+// getDatabaseTriggers retrieves the non-internal triggers on a table, keyed by name
+// tgtype bits: 1 = FOR EACH ROW, 2 = BEFORE, 16 = UPDATE
+func (sv *SchemaValidator) getDatabaseTriggers(tableName string) (map[string]*DatabaseTrigger, error) {
+	query := `
+		SELECT
+			t.tgname,
+			p.proname,
+			(t.tgtype & 2) <> 0 AS before,
+			(t.tgtype & 16) <> 0 AS on_update,
+			(t.tgtype & 1) <> 0 AS for_each_row,
+			t.tgenabled <> 'D' AS enabled
+		FROM pg_trigger t
+			JOIN pg_class c ON c.oid = t.tgrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			JOIN pg_proc p ON p.oid = t.tgfoid
+		WHERE n.nspname = 'public' AND c.relname = $1 AND NOT t.tgisinternal
+	`
+
+	rows, err := sv.sqlDB.Query(query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query triggers: %w", err)
+	}
+	defer rows.Close()
+
+	triggers := make(map[string]*DatabaseTrigger)
+	for rows.Next() {
+		trigger := &DatabaseTrigger{}
+		if err := rows.Scan(&trigger.Name, &trigger.Function, &trigger.Before, &trigger.OnUpdate,
+			&trigger.ForEachRow, &trigger.Enabled); err != nil {
+			return nil, fmt.Errorf("failed to scan trigger: %w", err)
+		}
+		triggers[trigger.Name] = trigger
+	}
+	return triggers, rows.Err()
+}
+
+// triggerFunctionExists reports whether a function returning trigger exists in the public schema
+func (sv *SchemaValidator) triggerFunctionExists(name string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_proc p
+				JOIN pg_namespace n ON n.oid = p.pronamespace
+			WHERE n.nspname = 'public' AND p.proname = $1 AND p.prorettype = 'trigger'::regtype
+		)
+	`
+
+	var exists bool
+	err := sv.sqlDB.QueryRow(query, name).Scan(&exists)
+	return exists, err
+}
+
+// validateEnums compares the labels of each expected enum type with the application's values
+func (sv *SchemaValidator) validateEnums(result *SchemaValidationResult, tableName string) {
+	for _, expected := range sv.getExpectedEnums(tableName) {
+		typeName, labels, err := sv.getColumnEnumLabels(tableName, expected.Column)
+		if err != nil {
+			log.Printf("Warning: Failed to get enum labels for %s.%s: %v", tableName, expected.Column, err)
+			continue
+		}
+
+		if typeName != expected.Constraint {
+			issue := fmt.Sprintf("Column %s should use enum type %s", expected.Column, expected.Constraint)
+			if typeName != "" {
+				issue = fmt.Sprintf("%s, found %s", issue, typeName)
+			}
+			result.ConstraintIssues = append(result.ConstraintIssues, ConstraintIssue{
+				ConstraintName: expected.Constraint,
+				Issue:          issue,
+				Severity:       "error",
+			})
+			result.IsValid = false
+			continue
+		}
+
+		if mismatch, ok := compareAllowedValues(expected, labels); !ok {
+			result.EnumMismatches = append(result.EnumMismatches, mismatch)
+			if len(mismatch.MissingValues) > 0 {
+				result.IsValid = false
+			}
+		}
+	}
+}
+
+// validateCheckConstraints compares the IN lists of expected CHECK constraints with the application's values
+func (sv *SchemaValidator) validateCheckConstraints(result *SchemaValidationResult, tableName string) {
+	for _, expected := range sv.getExpectedCheckConstraints(tableName) {
+		definition, err := sv.getCheckConstraintDefinition(tableName, expected.Constraint)
+		if err != nil {
+			log.Printf("Warning: Failed to get CHECK constraint %s on %s: %v", expected.Constraint, tableName, err)
+			continue
+		}
+
+		if definition == "" {
+			result.ConstraintIssues = append(result.ConstraintIssues, ConstraintIssue{
+				ConstraintName: expected.Constraint,
+				Issue:          fmt.Sprintf("Missing CHECK constraint on %s", expected.Column),
+				Severity:       "error",
+			})
+			result.IsValid = false
+			continue
+		}
+
+		var values []string
+		for _, match := range checkValuePattern.FindAllStringSubmatch(definition, -1) {
+			values = append(values, strings.ReplaceAll(match[1], "''", "'"))
+		}
+
+		if mismatch, ok := compareAllowedValues(expected, values); !ok {
+			result.CheckMismatches = append(result.CheckMismatches, mismatch)
+			if len(mismatch.MissingValues) > 0 {
+				result.IsValid = false
+			}
+		}
+	}
+}
+
+// getColumnEnumLabels returns the enum type of a column and its labels in sort order
+// The type name is empty when the column doesn't exist or isn't an enum
+func (sv *SchemaValidator) getColumnEnumLabels(tableName, columnName string) (string, []string, error) {
+	query := `
+		SELECT t.typname, e.enumlabel
+		FROM information_schema.columns c
+			JOIN pg_type t ON t.typname = c.udt_name AND t.typtype = 'e'
+			JOIN pg_enum e ON e.enumtypid = t.oid
+		WHERE c.table_schema = 'public' AND c.table_name = $1 AND c.column_name = $2
+		ORDER BY e.enumsortorder
+	`
+
+	rows, err := sv.sqlDB.Query(query, tableName, columnName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to query enum labels: %w", err)
+	}
+	defer rows.Close()
+
+	var typeName string
+	var labels []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&typeName, &label); err != nil {
+			return "", nil, fmt.Errorf("failed to scan enum label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+	return typeName, labels, rows.Err()
+}
+
+// getCheckConstraintDefinition returns the definition of a CHECK constraint, or "" when it doesn't exist
+func (sv *SchemaValidator) getCheckConstraintDefinition(tableName, constraintName string) (string, error) {
+	query := `
+		SELECT pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+			JOIN pg_class rel ON rel.oid = con.conrelid
+			JOIN pg_namespace nsp ON nsp.oid = rel.relnamespace
+		WHERE nsp.nspname = 'public' AND rel.relname = $1 AND con.conname = $2 AND con.contype = 'c'
+	`
+
+	var definition string
+	err := sv.sqlDB.QueryRow(query, tableName, constraintName).Scan(&definition)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return definition, err
+}
+
+// compareAllowedValues diffs the values the database accepts against the expected ones
+func compareAllowedValues(expected expectedAllowedValues, actual []string) (AllowedValuesMismatch, bool) {
+	mismatch := AllowedValuesMismatch{ColumnName: expected.Column, Constraint: expected.Constraint}
+
+	accepted := make(map[string]bool, len(actual))
+	for _, value := range actual {
+		accepted[value] = true
+	}
+	known := make(map[string]bool, len(expected.Values))
+	for _, value := range expected.Values {
+		known[value] = true
+		if !accepted[value] {
+			mismatch.MissingValues = append(mismatch.MissingValues, value)
+		}
+	}
+	for _, value := range actual {
+		if !known[value] {
+			mismatch.ExtraValues = append(mismatch.ExtraValues, value)
+		}
+	}
+
+	return mismatch, len(mismatch.MissingValues) == 0 && len(mismatch.ExtraValues) == 0
+}
+
 // generateRecommendations generates actionable recommendations
 func (sv *SchemaValidator) generateRecommendations(result *SchemaValidationResult) {
 	if len(result.MissingColumns) > 0 {
@@ -533,7 +944,29 @@ func (sv *SchemaValidator) generateRecommendations(result *SchemaValidationResul
 		result.RecommendedActions = append(result.RecommendedActions,
 			"Create missing indexes for better performance")
 	}
+
+	for _, mismatch := range append(result.EnumMismatches, result.CheckMismatches...) {
+		if len(mismatch.MissingValues) > 0 {
+			result.RecommendedActions = append(result.RecommendedActions,
+				fmt.Sprintf("Add %s to %s (%s) with a migration; writes of these values fail",
+					strings.Join(mismatch.MissingValues, ", "), mismatch.Constraint, mismatch.ColumnName))
+		}
+		if len(mismatch.ExtraValues) > 0 {
+			result.RecommendedActions = append(result.RecommendedActions,
+				fmt.Sprintf("Handle or remove %s accepted by %s (%s) but unknown to the application",
+					strings.Join(mismatch.ExtraValues, ", "), mismatch.Constraint, mismatch.ColumnName))
+		}
+	}
 	
+	if len(result.TriggerIssues) > 0 {
+		names := make([]string, 0, len(result.TriggerIssues))
+		for _, issue := range result.TriggerIssues {
+			names = append(names, issue.TriggerName)
+		}
+		result.RecommendedActions = append(result.RecommendedActions,
+			fmt.Sprintf("Recreate triggers with a migration (see validate --fix-output): %s", strings.Join(names, ", ")))
+	}
+
 	if len(result.ConstraintIssues) > 0 {
 		errorCount := 0
 		for _, issue := range result.ConstraintIssues {
@@ -548,62 +981,17 @@ func (sv *SchemaValidator) generateRecommendations(result *SchemaValidationResul
 	}
 }
 
-// GenerateValidationReport generates a comprehensive validation report
+// GenerateValidationReport generates a comprehensive validation report in Markdown
+// RenderValidationReport renders already computed results in other formats
 func (sv *SchemaValidator) GenerateValidationReport() (string, error) {
 	results, err := sv.ValidateAllTables()
 	if err != nil {
 		return "", fmt.Errorf("failed to validate tables: %w", err)
 	}
 
-	var report strings.Builder
-	report.WriteString("# Database Schema Validation Report\n\n")
-	report.WriteString(fmt.Sprintf("Generated at: %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
-
-	validCount := 0
-	for _, result := range results {
-		if result.IsValid {
-			validCount++
-		}
-	}
-
-	report.WriteString(fmt.Sprintf("## Summary\n"))
-	report.WriteString(fmt.Sprintf("- Total tables: %d\n", len(results)))
-	report.WriteString(fmt.Sprintf("- Valid tables: %d\n", validCount))
-	report.WriteString(fmt.Sprintf("- Invalid tables: %d\n\n", len(results)-validCount))
-
-	for _, result := range results {
-		status := "✅ VALID"
-		if !result.IsValid {
-			status = "❌ INVALID"
-		}
-		
-		report.WriteString(fmt.Sprintf("## Table: %s %s\n\n", result.TableName, status))
-		
-		if len(result.MissingColumns) > 0 {
-			report.WriteString("**Missing Columns:**\n")
-			for _, col := range result.MissingColumns {
-				report.WriteString(fmt.Sprintf("- %s\n", col))
-			}
-			report.WriteString("\n")
-		}
-		
-		if len(result.TypeMismatches) > 0 {
-			report.WriteString("**Type Mismatches:**\n")
-			for _, mismatch := range result.TypeMismatches {
-				report.WriteString(fmt.Sprintf("- %s: expected %s, got %s\n", 
-					mismatch.ColumnName, mismatch.ExpectedType, mismatch.ActualType))
-			}
-			report.WriteString("\n")
-		}
-		
-		if len(result.RecommendedActions) > 0 {
-			report.WriteString("**Recommended Actions:**\n")
-			for _, action := range result.RecommendedActions {
-				report.WriteString(fmt.Sprintf("- %s\n", action))
-			}
-			report.WriteString("\n")
-		}
+	report, err := RenderValidationReport(results, ReportFormatMarkdown, time.Now())
+	if err != nil {
+		return "", err
 	}
-
-	return report.String(), nil
+	return string(report), nil
 }
\ No newline at end of file
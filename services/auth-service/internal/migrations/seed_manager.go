@@ -0,0 +1,230 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SeedRecord represents an applied seed in the seed history table
+type SeedRecord struct {
+	ID              int       `gorm:"primaryKey;autoIncrement"`
+	Environment     string    `gorm:"not null;size:50"`
+	Name            string    `gorm:"not null;size:255"`
+	Checksum        string    `gorm:"not null;size:64"`
+	AppliedAt       time.Time `gorm:"not null"`
+	ExecutionTimeMs int       `gorm:"not null"`
+}
+
+// TableName overrides the table name used by this model
+func (SeedRecord) TableName() string {
+	return "schema_seeds"
+}
+
+// Seed represents a single seed file for one environment
+type Seed struct {
+	Name     string
+	FilePath string
+	SQL      string
+	Checksum string
+}
+
+// SeedResult contains the result of running a seed
+type SeedResult struct {
+	Seed          *Seed
+	Success       bool
+	Error         error
+	ExecutionTime time.Duration
+}
+
+// SeedManager loads environment-specific seed data from seeds/<environment>/*.sql
+// Seeds must be idempotent (INSERT ... ON CONFLICT DO NOTHING etc.): a seed runs when it is new
+// or its file changed since it was last applied
+type SeedManager struct {
+	db          *gorm.DB
+	sqlDB       *sql.DB
+	seedsDir    string
+	environment string
+}
+
+// NewSeedManager creates a seed manager and ensures the seed history table exists
+func NewSeedManager(db *gorm.DB, seedsDir, environment string) (*SeedManager, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB from gorm.DB: %w", err)
+	}
+
+	manager := &SeedManager{
+		db:          db,
+		sqlDB:       sqlDB,
+		seedsDir:    seedsDir,
+		environment: environment,
+	}
+
+	if err := manager.ensureSeedsTable(); err != nil {
+		return nil, fmt.Errorf("failed to create seeds table: %w", err)
+	}
+
+	return manager, nil
+}
+
+// ensureSeedsTable creates the seed history table if it doesn't exist
+func (s *SeedManager) ensureSeedsTable() error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS schema_seeds (
+		id SERIAL PRIMARY KEY,
+		environment VARCHAR(50) NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		execution_time_ms INTEGER NOT NULL,
+		UNIQUE (environment, name)
+	);
+	`
+
+	if _, err := s.sqlDB.Exec(createTableSQL); err != nil {
+		return fmt.Errorf("failed to create schema_seeds table: %w", err)
+	}
+	return nil
+}
+
+// GetPendingSeeds returns seeds that are new or changed since they were last applied
+// With force every seed for the environment is returned
+func (s *SeedManager) GetPendingSeeds(force bool) ([]*Seed, error) {
+	seeds, err := s.loadSeedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []SeedRecord
+	if err := s.db.Where("environment = ?", s.environment).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query seed history: %w", err)
+	}
+
+	applied := make(map[string]string, len(records))
+	for _, record := range records {
+		applied[record.Name] = record.Checksum
+	}
+
+	var pending []*Seed
+	for _, seed := range seeds {
+		if force || applied[seed.Name] != seed.Checksum {
+			pending = append(pending, seed)
+		}
+	}
+	return pending, nil
+}
+
+// ApplySeeds runs pending seeds in file name order, each in its own transaction
+func (s *SeedManager) ApplySeeds(force bool) ([]*SeedResult, error) {
+	pending, err := s.GetPendingSeeds(force)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*SeedResult
+	for _, seed := range pending {
+		result := s.applySeed(seed)
+		results = append(results, result)
+
+		if !result.Success {
+			return results, fmt.Errorf("seed %s failed: %w", seed.Name, result.Error)
+		}
+
+		log.Printf("🌱 Applied seed %s (%.2fms)", seed.Name, float64(result.ExecutionTime.Nanoseconds())/1e6)
+	}
+
+	return results, nil
+}
+
+// applySeed executes a seed and upserts its history record
+func (s *SeedManager) applySeed(seed *Seed) *SeedResult {
+	startTime := time.Now()
+	result := &SeedResult{Seed: seed}
+
+	tx, err := s.sqlDB.Begin()
+	if err != nil {
+		result.Error = fmt.Errorf("failed to begin transaction: %w", err)
+		return result
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(seed.SQL); err != nil {
+		result.Error = fmt.Errorf("failed to execute seed SQL: %w", err)
+		return result
+	}
+
+	executionTime := time.Since(startTime)
+	upsertSQL := `
+		INSERT INTO schema_seeds (environment, name, checksum, applied_at, execution_time_ms)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (environment, name)
+		DO UPDATE SET checksum = EXCLUDED.checksum, applied_at = EXCLUDED.applied_at, execution_time_ms = EXCLUDED.execution_time_ms
+	`
+	if _, err := tx.Exec(upsertSQL, s.environment, seed.Name, seed.Checksum, time.Now(), int(executionTime.Milliseconds())); err != nil {
+		result.Error = fmt.Errorf("failed to record seed: %w", err)
+		return result
+	}
+
+	if err := tx.Commit(); err != nil {
+		result.Error = fmt.Errorf("failed to commit seed: %w", err)
+		return result
+	}
+
+	result.Success = true
+	result.ExecutionTime = executionTime
+	return result
+}
+
+// loadSeedFiles reads seeds/<environment>/*.sql sorted by file name
+// A missing environment directory simply means there is nothing to seed
+func (s *SeedManager) loadSeedFiles() ([]*Seed, error) {
+	dir := filepath.Join(s.seedsDir, s.environment)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read seeds directory %s: %w", dir, err)
+	}
+
+	var seeds []*Seed
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seed file %s: %w", path, err)
+		}
+
+		seeds = append(seeds, &Seed{
+			Name:     strings.TrimSuffix(entry.Name(), ".sql"),
+			FilePath: path,
+			SQL:      string(content),
+			Checksum: s.calculateChecksum(string(content)),
+		})
+	}
+
+	sort.Slice(seeds, func(i, j int) bool {
+		return seeds[i].Name < seeds[j].Name
+	})
+	return seeds, nil
+}
+
+// calculateChecksum calculates SHA-256 checksum of content
+func (s *SeedManager) calculateChecksum(content string) string {
+	h := sha256.Sum256([]byte(content))
+	return fmt.Sprintf("%x", h)
+}
@@ -0,0 +1,255 @@
+package migrations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// noTransactionDirective in a migration's comment header runs it outside a transaction,
+// which statements such as CREATE INDEX CONCURRENTLY require
+const noTransactionDirective = "no-transaction"
+
+// statementSnippetLength caps how much of a failing statement is quoted in errors
+const statementSnippetLength = 200
+
+// transactionControlStatement matches a statement that only begins or ends a transaction
+var transactionControlStatement = regexp.MustCompile(`(?i)^(BEGIN|COMMIT|END|START\s+TRANSACTION)(\s+(WORK|TRANSACTION))?$`)
+
+// Statement is one SQL statement of a migration with its position in the migration file
+type Statement struct {
+	SQL       string
+	StartLine int // 1-based line of the first character of the statement
+	EndLine   int
+}
+
+// StatementError reports which statement of a migration failed
+type StatementError struct {
+	Index     int // 1-based
+	Total     int
+	StartLine int
+	EndLine   int
+	SQL       string
+	Err       error
+}
+
+func (e *StatementError) Error() string {
+	lines := fmt.Sprintf("line %d", e.StartLine)
+	if e.EndLine > e.StartLine {
+		lines = fmt.Sprintf("lines %d-%d", e.StartLine, e.EndLine)
+	}
+	return fmt.Sprintf("statement %d of %d (%s) failed: %v\n    %s", e.Index, e.Total, lines, e.Err, snippet(e.SQL))
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// SplitStatements splits SQL text on top-level semicolons
+// Semicolons inside quoted strings, quoted identifiers, dollar-quoted bodies and comments don't
+// end a statement. Statements that are empty or only contain comments are dropped.
+// firstLine is the line number of the first line of sqlText within its file.
+func SplitStatements(sqlText string, firstLine int) []Statement {
+	var statements []Statement
+
+	line := firstLine
+	start, startLine := 0, line
+	flush := func(end int) {
+		raw := sqlText[start:end]
+		if text := strings.TrimSpace(raw); hasCode(text) {
+			// Report the line the statement's code starts on, not the blank lines and comments before it
+			leading := raw[:len(raw)-len(strings.TrimLeft(raw, " \t\r\n"))]
+			first := startLine + strings.Count(leading, "\n")
+			lines := strings.Split(text, "\n")
+			offset := 0
+			for offset < len(lines) && !hasCode(lines[offset]) {
+				offset++
+			}
+			statements = append(statements, Statement{
+				SQL:       text,
+				StartLine: first + offset,
+				EndLine:   first + len(lines) - 1,
+			})
+		}
+	}
+
+	for i := 0; i < len(sqlText); i++ {
+		switch c := sqlText[i]; {
+		case c == '\n':
+			line++
+
+		case c == '-' && strings.HasPrefix(sqlText[i:], "--"):
+			for i < len(sqlText) && sqlText[i] != '\n' {
+				i++
+			}
+			if i < len(sqlText) {
+				line++
+			}
+
+		case c == '/' && strings.HasPrefix(sqlText[i:], "/*"):
+			// PostgreSQL block comments nest
+			depth := 0
+			for ; i < len(sqlText); i++ {
+				switch {
+				case strings.HasPrefix(sqlText[i:], "/*"):
+					depth++
+					i++
+				case strings.HasPrefix(sqlText[i:], "*/"):
+					depth--
+					i++
+				case sqlText[i] == '\n':
+					line++
+				}
+				if depth == 0 {
+					break
+				}
+			}
+
+		case c == '\'' || c == '"':
+			// E'...' strings allow backslash escapes; doubled quotes escape in both forms
+			escapes := c == '\'' && i > 0 && (sqlText[i-1] == 'E' || sqlText[i-1] == 'e')
+			for i++; i < len(sqlText); i++ {
+				if sqlText[i] == '\n' {
+					line++
+				}
+				if escapes && sqlText[i] == '\\' {
+					i++
+					continue
+				}
+				if sqlText[i] == c {
+					if i+1 < len(sqlText) && sqlText[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+
+		case c == '$':
+			tag, ok := dollarQuoteTag(sqlText[i:])
+			if !ok || (i > 0 && isIdentifierChar(sqlText[i-1])) {
+				continue
+			}
+			end := strings.Index(sqlText[i+len(tag):], tag)
+			if end < 0 {
+				end = len(sqlText) - i - len(tag)
+			}
+			body := sqlText[i : i+len(tag)+end]
+			line += strings.Count(body, "\n")
+			i += len(body) + len(tag) - 1
+
+		case c == ';':
+			flush(i)
+			start, startLine = i+1, line
+		}
+	}
+	if start < len(sqlText) {
+		flush(len(sqlText))
+	}
+
+	return statements
+}
+
+// dollarQuoteTag returns the opening $tag$ at the start of s
+func dollarQuoteTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '$' {
+			return s[:i+1], true
+		}
+		if !isIdentifierChar(s[i]) || (i == 1 && s[i] >= '0' && s[i] <= '9') {
+			return "", false // $1 is a parameter, not a quote
+		}
+	}
+	return "", false
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// hasCode reports whether a statement contains anything besides comments
+func hasCode(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+			return true
+		}
+	}
+	return false
+}
+
+// stripComments drops whole-line comments so a statement can be inspected by its keywords
+func stripComments(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+			lines = append(lines, trimmed)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// isTransactionControl reports whether a statement is a BEGIN/COMMIT wrapper written in the file
+// The manager controls the transaction itself, so these are skipped
+func isTransactionControl(stmt Statement) bool {
+	return transactionControlStatement.MatchString(stripComments(stmt.SQL))
+}
+
+// snippet shortens a statement to one line for error messages
+func snippet(sqlText string) string {
+	text := strings.Join(strings.Fields(stripComments(sqlText)), " ")
+	if len(text) > statementSnippetLength {
+		text = text[:statementSnippetLength] + "..."
+	}
+	return text
+}
+
+// hasHeaderDirective reports whether the comment header at the top of a file contains "-- <directive>"
+func hasHeaderDirective(content, directive string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "--") {
+			return false // The header ends at the first statement
+		}
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(trimmed, "--")), directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// execStatements runs each statement of sqlText in order on exec, skipping transaction control
+// A failure is returned as a *StatementError naming the statement and its lines
+func execStatements(exec func(query string) error, sqlText string, firstLine int) error {
+	var statements []Statement
+	for _, stmt := range SplitStatements(sqlText, firstLine) {
+		if !isTransactionControl(stmt) {
+			statements = append(statements, stmt)
+		}
+	}
+
+	for i, stmt := range statements {
+		if err := exec(stmt.SQL); err != nil {
+			return &StatementError{
+				Index:     i + 1,
+				Total:     len(statements),
+				StartLine: stmt.StartLine,
+				EndLine:   stmt.EndLine,
+				SQL:       stmt.SQL,
+				Err:       err,
+			}
+		}
+	}
+	return nil
+}
+
+// upSQLFirstLine returns the file line on which the UP section of a migration starts
+func upSQLFirstLine(migration *Migration) int {
+	if idx := strings.Index(migration.Content, migration.UpSQL); idx >= 0 && migration.UpSQL != "" {
+		return 1 + strings.Count(migration.Content[:idx], "\n")
+	}
+	return 1
+}
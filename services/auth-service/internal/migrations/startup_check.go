@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"shared/health"
+)
+
+// SchemaStatus compares the migrations embedded in the binary with schema_migrations at startup, so a
+// service running against a schema that is behind it says so. A nil *SchemaStatus has nothing pending
+type SchemaStatus struct {
+	Pending   []string // "<version>_<name>" of migrations not applied in this environment, in order
+	CheckedAt time.Time
+}
+
+// CheckSchema lists the migrations from fsys that this environment hasn't applied
+func CheckSchema(ctx context.Context, db *gorm.DB, fsys fs.FS, environment string) (*SchemaStatus, error) {
+	manager, err := NewMigrationManagerFS(db.WithContext(ctx), fsys, environment)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := manager.GetPendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SchemaStatus{Pending: []string{}, CheckedAt: time.Now()}
+	for _, migration := range pending {
+		status.Pending = append(status.Pending, migration.Version+"_"+migration.Name)
+	}
+	return status, nil
+}
+
+// PendingCount is the number of migrations not applied
+func (s *SchemaStatus) PendingCount() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.Pending)
+}
+
+// Summary describes the pending migrations for logs and errors
+func (s *SchemaStatus) Summary() string {
+	return fmt.Sprintf("%d pending migrations: %s", s.PendingCount(), strings.Join(s.Pending, ", "))
+}
+
+// HealthCheck reports the schema as degraded while migrations are pending; it reflects the startup
+// check, so it clears once a replica restarts after the migrations are applied
+func (s *SchemaStatus) HealthCheck() health.Check {
+	return func(ctx context.Context) health.CheckResult {
+		metadata := map[string]interface{}{
+			"pending_migrations": s.PendingCount(),
+			"checked_at":         s.CheckedAt,
+		}
+		if s.PendingCount() == 0 {
+			return health.CheckResult{Status: health.StatusHealthy, Metadata: metadata}
+		}
+		metadata["pending"] = s.Pending
+		return health.CheckResult{
+			Status:   health.StatusDegraded,
+			Message:  "database schema is behind this build",
+			Metadata: metadata,
+		}
+	}
+}
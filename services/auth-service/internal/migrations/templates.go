@@ -0,0 +1,221 @@
+package migrations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Templates for `migrate create --template`
+const (
+	TemplateAddColumn    = "add_column"
+	TemplateCreateTable  = "create_table"
+	TemplateAddIndex     = "add_index"
+	TemplateAddEnumValue = "add_enum_value"
+)
+
+// MigrationTemplates lists the template names RenderMigrationTemplate accepts
+var MigrationTemplates = []string{TemplateAddColumn, TemplateCreateTable, TemplateAddIndex, TemplateAddEnumValue}
+
+// Placeholder identifiers used when TemplateParams leaves a name empty
+const (
+	placeholderTable  = "table_name"
+	placeholderColumn = "column_name"
+)
+
+var identifierPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// TemplateParams names the objects a template touches; empty fields keep placeholder names to edit
+type TemplateParams struct {
+	Table   string
+	Columns []string // add_index indexes every column in order; add_column uses the first
+}
+
+// migrationTemplate is the SQL a template renders into the standard migration layout
+type migrationTemplate struct {
+	purpose       string
+	noTransaction bool     // Rendered with a "-- no-transaction" header and no BEGIN/COMMIT
+	up            []string // Lines of the UP section between BEGIN and COMMIT
+	down          []string // Lines of the DOWN section, commented out when rendered
+}
+
+// RenderMigrationTemplate renders a ready-to-edit migration with forward and rollback SQL
+func RenderMigrationTemplate(template, version, name string, params TemplateParams) (string, error) {
+	if params.Table != "" && !identifierPattern.MatchString(params.Table) {
+		return "", fmt.Errorf("invalid table name %q: use lower-case letters, digits and underscores", params.Table)
+	}
+	for _, column := range params.Columns {
+		if !identifierPattern.MatchString(column) {
+			return "", fmt.Errorf("invalid column name %q: use lower-case letters, digits and underscores", column)
+		}
+	}
+
+	var tmpl migrationTemplate
+	switch template {
+	case TemplateAddColumn:
+		tmpl = addColumnTemplate(params)
+	case TemplateCreateTable:
+		tmpl = createTableTemplate(name, params)
+	case TemplateAddIndex:
+		tmpl = addIndexTemplate(params)
+	case TemplateAddEnumValue:
+		tmpl = addEnumValueTemplate(params)
+	default:
+		return "", fmt.Errorf("unknown template %q (available: %s)", template, strings.Join(MigrationTemplates, ", "))
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "-- ==========================================\n")
+	fmt.Fprintf(&b, "-- Migration: %s_%s.sql\n", version, name)
+	fmt.Fprintf(&b, "-- Purpose: %s (%s)\n", name, tmpl.purpose)
+	fmt.Fprintf(&b, "-- Author: Migration System\n")
+	fmt.Fprintf(&b, "-- Date: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "-- Environment: ALL\n")
+	if tmpl.noTransaction {
+		fmt.Fprintf(&b, "-- %s\n", noTransactionDirective)
+	}
+	fmt.Fprintf(&b, "-- ==========================================\n\n")
+
+	fmt.Fprintf(&b, "-- 🔄 FORWARD MIGRATION (UP)\n")
+	if !tmpl.noTransaction {
+		fmt.Fprintf(&b, "BEGIN;\n\n")
+	}
+	for _, line := range tmpl.up {
+		fmt.Fprintf(&b, "%s\n", line)
+	}
+	if !tmpl.noTransaction {
+		fmt.Fprintf(&b, "\nCOMMIT;\n")
+	}
+	fmt.Fprintf(&b, "\n")
+
+	fmt.Fprintf(&b, "-- ==========================================\n")
+	fmt.Fprintf(&b, "-- 🔙 DOWN MIGRATION (ROLLBACK)\n")
+	fmt.Fprintf(&b, "-- ==========================================\n")
+	fmt.Fprintf(&b, "-- To rollback this migration, run:\n")
+	fmt.Fprintf(&b, "-- \n")
+	if !tmpl.noTransaction {
+		fmt.Fprintf(&b, "-- BEGIN;\n")
+	}
+	for _, line := range tmpl.down {
+		fmt.Fprintf(&b, "-- %s\n", line)
+	}
+	if !tmpl.noTransaction {
+		fmt.Fprintf(&b, "-- COMMIT;\n")
+	}
+
+	return b.String(), nil
+}
+
+func addColumnTemplate(params TemplateParams) migrationTemplate {
+	table := orPlaceholder(params.Table, placeholderTable)
+	column := placeholderColumn
+	if len(params.Columns) > 0 {
+		column = params.Columns[0]
+	}
+
+	return migrationTemplate{
+		purpose: "add column",
+		up: []string{
+			"-- Nullable so existing rows stay valid; backfill, then SET NOT NULL in a later migration if required",
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s VARCHAR(255);", table, column),
+		},
+		down: []string{
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;", table, column),
+		},
+	}
+}
+
+// createTableTemplate defaults the table name to the migration name without "create_" and "_table"
+func createTableTemplate(name string, params TemplateParams) migrationTemplate {
+	table := params.Table
+	if table == "" {
+		table = strings.TrimSuffix(strings.TrimPrefix(name, "create_"), "_table")
+		if !identifierPattern.MatchString(table) {
+			table = placeholderTable
+		}
+	}
+
+	return migrationTemplate{
+		purpose: "create table",
+		up: []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (", table),
+			"    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),",
+			"    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Remove if rows aren't owned by a user",
+			"",
+			"    -- Add columns here",
+			"",
+			"    created_at TIMESTAMP NOT NULL DEFAULT NOW(),",
+			"    updated_at TIMESTAMP NOT NULL DEFAULT NOW()",
+			");",
+			"",
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_user_id ON %s(user_id);", table, table),
+			"",
+			"-- update_updated_at_column() is defined in 001_initial_schema.sql",
+			fmt.Sprintf("CREATE OR REPLACE TRIGGER update_%s_updated_at", table),
+			fmt.Sprintf("    BEFORE UPDATE ON %s", table),
+			"    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();",
+		},
+		down: []string{
+			fmt.Sprintf("DROP TABLE IF EXISTS %s;", table),
+		},
+	}
+}
+
+// addIndexTemplate builds the index concurrently so writes aren't blocked, which requires running
+// outside a transaction
+func addIndexTemplate(params TemplateParams) migrationTemplate {
+	table := orPlaceholder(params.Table, placeholderTable)
+	columns := params.Columns
+	if len(columns) == 0 {
+		columns = []string{placeholderColumn}
+	}
+	index := fmt.Sprintf("idx_%s_%s", table, strings.Join(columns, "_"))
+
+	return migrationTemplate{
+		purpose:       "add index",
+		noTransaction: true,
+		up: []string{
+			"-- CONCURRENTLY avoids locking writes; a failed build leaves an INVALID index to drop before retrying",
+			fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s (%s);", index, table, strings.Join(columns, ", ")),
+		},
+		down: []string{
+			fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s;", index),
+		},
+	}
+}
+
+// addEnumValueTemplate adds a value with IF NOT EXISTS; PostgreSQL can't drop enum values, so the
+// rollback recreates the type without it
+func addEnumValueTemplate(params TemplateParams) migrationTemplate {
+	table := orPlaceholder(params.Table, placeholderTable)
+	column := placeholderColumn
+	if len(params.Columns) > 0 {
+		column = params.Columns[0]
+	}
+
+	return migrationTemplate{
+		purpose: "add enum value",
+		up: []string{
+			"-- The new value can't be used until this migration commits; use it in a later migration",
+			"-- lint:ignore transaction",
+			"ALTER TYPE enum_type ADD VALUE IF NOT EXISTS 'new_value';",
+		},
+		down: []string{
+			"-- PostgreSQL can't drop an enum value: recreate the type without it",
+			"-- Rows still set to 'new_value' must be updated first",
+			"ALTER TYPE enum_type RENAME TO enum_type_old;",
+			"CREATE TYPE enum_type AS ENUM ('existing_value');",
+			fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE enum_type USING %s::text::enum_type;", table, column, column),
+			"DROP TYPE enum_type_old;",
+		},
+	}
+}
+
+func orPlaceholder(value, placeholder string) string {
+	if value == "" {
+		return placeholder
+	}
+	return value
+}
@@ -0,0 +1,241 @@
+package migrations
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Validation report formats
+const (
+	ReportFormatMarkdown = "markdown"
+	ReportFormatJSON     = "json"
+	ReportFormatJUnit    = "junit"
+)
+
+// ReportFormats lists the supported validation report formats
+var ReportFormats = []string{ReportFormatMarkdown, ReportFormatJSON, ReportFormatJUnit}
+
+// junitSuiteName names the test suite of JUnit reports; every table is one test case
+const junitSuiteName = "schema-validation"
+
+// ValidationReport is the JSON validation report, also served by GET /api/v1/admin/schema/validate
+type ValidationReport struct {
+	GeneratedAt  time.Time                 `json:"generated_at"`
+	Valid        bool                      `json:"valid"`
+	ValidCount   int                       `json:"valid_count"`
+	InvalidCount int                       `json:"invalid_count"`
+	Tables       []*SchemaValidationResult `json:"tables"`
+}
+
+// NewValidationReport summarizes validation results, with tables sorted by name
+func NewValidationReport(results []*SchemaValidationResult, generatedAt time.Time) *ValidationReport {
+	report := &ValidationReport{GeneratedAt: generatedAt, Tables: sortResults(results)}
+	for _, result := range results {
+		if result.IsValid {
+			report.ValidCount++
+		} else {
+			report.InvalidCount++
+		}
+	}
+	report.Valid = report.InvalidCount == 0
+	return report
+}
+
+// RenderValidationReport renders validation results as a Markdown, JSON or JUnit XML report
+// Tables are sorted by name so reports of the same schema compare cleanly
+func RenderValidationReport(results []*SchemaValidationResult, format string, generatedAt time.Time) ([]byte, error) {
+	sorted := sortResults(results)
+	switch format {
+	case ReportFormatMarkdown:
+		return []byte(renderMarkdownReport(sorted, generatedAt)), nil
+	case ReportFormatJSON:
+		body, err := json.MarshalIndent(NewValidationReport(sorted, generatedAt), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode validation report: %w", err)
+		}
+		return append(body, '\n'), nil
+	case ReportFormatJUnit:
+		return renderJUnitReport(sorted, generatedAt)
+	default:
+		return nil, fmt.Errorf("unknown report format %q (available: %s)", format, strings.Join(ReportFormats, ", "))
+	}
+}
+
+// sortResults returns a copy of results sorted by table name
+func sortResults(results []*SchemaValidationResult) []*SchemaValidationResult {
+	sorted := make([]*SchemaValidationResult, len(results))
+	copy(sorted, results)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].TableName < sorted[j].TableName })
+	return sorted
+}
+
+func renderMarkdownReport(results []*SchemaValidationResult, generatedAt time.Time) string {
+	var report strings.Builder
+	report.WriteString("# Database Schema Validation Report\n\n")
+	report.WriteString(fmt.Sprintf("Generated at: %s\n\n", generatedAt.Format("2006-01-02 15:04:05")))
+
+	validCount := 0
+	for _, result := range results {
+		if result.IsValid {
+			validCount++
+		}
+	}
+
+	report.WriteString(fmt.Sprintf("## Summary\n"))
+	report.WriteString(fmt.Sprintf("- Total tables: %d\n", len(results)))
+	report.WriteString(fmt.Sprintf("- Valid tables: %d\n", validCount))
+	report.WriteString(fmt.Sprintf("- Invalid tables: %d\n\n", len(results)-validCount))
+
+	for _, result := range results {
+		status := "✅ VALID"
+		if !result.IsValid {
+			status = "❌ INVALID"
+		}
+
+		report.WriteString(fmt.Sprintf("## Table: %s %s\n\n", result.TableName, status))
+
+		if len(result.MissingColumns) > 0 {
+			report.WriteString("**Missing Columns:**\n")
+			for _, col := range result.MissingColumns {
+				report.WriteString(fmt.Sprintf("- %s\n", col))
+			}
+			report.WriteString("\n")
+		}
+
+		if len(result.TypeMismatches) > 0 {
+			report.WriteString("**Type Mismatches:**\n")
+			for _, mismatch := range result.TypeMismatches {
+				report.WriteString(fmt.Sprintf("- %s: expected %s, got %s\n",
+					mismatch.ColumnName, mismatch.ExpectedType, mismatch.ActualType))
+			}
+			report.WriteString("\n")
+		}
+
+		if len(result.EnumMismatches) > 0 || len(result.CheckMismatches) > 0 {
+			report.WriteString("**Allowed Value Mismatches:**\n")
+			for _, mismatch := range append(result.EnumMismatches, result.CheckMismatches...) {
+				report.WriteString(fmt.Sprintf("- %s (%s): missing [%s], extra [%s]\n",
+					mismatch.ColumnName, mismatch.Constraint,
+					strings.Join(mismatch.MissingValues, ", "), strings.Join(mismatch.ExtraValues, ", ")))
+			}
+			report.WriteString("\n")
+		}
+
+		if len(result.TriggerIssues) > 0 {
+			report.WriteString("**Trigger Issues:**\n")
+			for _, issue := range result.TriggerIssues {
+				report.WriteString(fmt.Sprintf("- %s (%s()): %s\n", issue.TriggerName, issue.Function, issue.Issue))
+			}
+			report.WriteString("\n")
+		}
+
+		if len(result.RecommendedActions) > 0 {
+			report.WriteString("**Recommended Actions:**\n")
+			for _, action := range result.RecommendedActions {
+				report.WriteString(fmt.Sprintf("- %s\n", action))
+			}
+			report.WriteString("\n")
+		}
+	}
+
+	return report.String()
+}
+
+// JUnit XML as read by CI test report collectors (Jenkins, GitLab, GitHub Actions)
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// renderJUnitReport reports each table as a test case that fails when the table is invalid
+// Findings of valid tables (extra columns, warnings) are kept in system-out
+func renderJUnitReport(results []*SchemaValidationResult, generatedAt time.Time) ([]byte, error) {
+	suite := junitTestSuite{
+		Name:      junitSuiteName,
+		Tests:     len(results),
+		Timestamp: generatedAt.UTC().Format("2006-01-02T15:04:05"),
+	}
+	for _, result := range results {
+		findings := validationFindings(result)
+		testCase := junitTestCase{Name: result.TableName, ClassName: junitSuiteName}
+		if result.IsValid {
+			testCase.SystemOut = strings.Join(findings, "\n")
+		} else {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("table %s does not match its model (%d findings)", result.TableName, len(findings)),
+				Type:    "SchemaDrift",
+				Body:    strings.Join(findings, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	doc := junitTestSuites{Name: junitSuiteName, Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode validation report: %w", err)
+	}
+	return append(append([]byte(xml.Header), body...), '\n'), nil
+}
+
+// validationFindings lists a result's issues and recommendations as one line each
+func validationFindings(result *SchemaValidationResult) []string {
+	var findings []string
+	for _, col := range result.MissingColumns {
+		findings = append(findings, "missing column: "+col)
+	}
+	for _, col := range result.ExtraColumns {
+		findings = append(findings, "extra column: "+col)
+	}
+	for _, mismatch := range result.TypeMismatches {
+		findings = append(findings, fmt.Sprintf("type mismatch: %s expected %s, got %s",
+			mismatch.ColumnName, mismatch.ExpectedType, mismatch.ActualType))
+	}
+	for _, index := range result.MissingIndexes {
+		findings = append(findings, "missing index: "+index)
+	}
+	for _, mismatch := range append(result.EnumMismatches, result.CheckMismatches...) {
+		findings = append(findings, fmt.Sprintf("allowed values of %s (%s): missing [%s], extra [%s]",
+			mismatch.ColumnName, mismatch.Constraint,
+			strings.Join(mismatch.MissingValues, ", "), strings.Join(mismatch.ExtraValues, ", ")))
+	}
+	for _, issue := range result.TriggerIssues {
+		findings = append(findings, fmt.Sprintf("trigger %s (%s()): %s", issue.TriggerName, issue.Function, issue.Issue))
+	}
+	for _, issue := range result.ConstraintIssues {
+		findings = append(findings, fmt.Sprintf("%s: %s: %s", issue.Severity, issue.ConstraintName, issue.Issue))
+	}
+	for _, action := range result.RecommendedActions {
+		findings = append(findings, "recommendation: "+action)
+	}
+	return findings
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator produces primary keys for new records
+// Implementations must be safe for concurrent use
+type IDGenerator interface {
+	NewID() uuid.UUID
+}
+
+// RandomIDGenerator generates random (version 4) UUIDs - the production default
+type RandomIDGenerator struct{}
+
+// NewID returns a new random UUID
+func (RandomIDGenerator) NewID() uuid.UUID {
+	return uuid.New()
+}
+
+// SequentialIDGenerator generates deterministic, monotonically increasing UUIDs
+// Intended for tests and fixtures where stable IDs make assertions and ordering predictable
+type SequentialIDGenerator struct {
+	mu      sync.Mutex
+	prefix  uint32
+	counter uint64
+}
+
+// NewSequentialIDGenerator creates a sequential generator
+// The prefix occupies the first 4 bytes of every ID so parallel suites (or tenants) don't collide
+func NewSequentialIDGenerator(prefix uint32) *SequentialIDGenerator {
+	return &SequentialIDGenerator{prefix: prefix}
+}
+
+// NewID returns the next UUID in the sequence (e.g. 00000001-0000-4000-8000-000000000001)
+func (g *SequentialIDGenerator) NewID() uuid.UUID {
+	g.mu.Lock()
+	g.counter++
+	n := g.counter
+	g.mu.Unlock()
+
+	var id uuid.UUID
+	binary.BigEndian.PutUint32(id[0:4], g.prefix)
+	binary.BigEndian.PutUint64(id[8:16], n)
+	// Keep the value a well-formed RFC 4122 version 4 UUID so PostgreSQL and validators accept it
+	id[6] = 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return id
+}
+
+// Reset restarts the sequence from the beginning
+func (g *SequentialIDGenerator) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.counter = 0
+}
+
+var (
+	idGeneratorMu sync.RWMutex
+	idGenerator   IDGenerator = RandomIDGenerator{}
+)
+
+// SetIDGenerator replaces the generator used by model hooks, repositories and services
+// Passing nil restores the random default. Returns the previously installed generator
+func SetIDGenerator(gen IDGenerator) IDGenerator {
+	idGeneratorMu.Lock()
+	defer idGeneratorMu.Unlock()
+
+	previous := idGenerator
+	if gen == nil {
+		gen = RandomIDGenerator{}
+	}
+	idGenerator = gen
+	return previous
+}
+
+// NewID returns a new identifier from the configured generator
+// Use this instead of calling uuid.New() directly
+func NewID() uuid.UUID {
+	idGeneratorMu.RLock()
+	gen := idGenerator
+	idGeneratorMu.RUnlock()
+	return gen.NewID()
+}
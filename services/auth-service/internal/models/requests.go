@@ -6,19 +6,42 @@ import "time"
 type RegisterRequest struct {
 	Email       string `json:"email" binding:"required,email"`
 	Username    string `json:"username" binding:"required,min=3,max=30"`
-	Password    string `json:"password" binding:"required,min=8"`
+	Password    string `json:"password" binding:"required"` // Checked against the security.password_* policy
 	FirstName   string `json:"first_name,omitempty"`
 	LastName    string `json:"last_name,omitempty"`
 	PhoneNumber string `json:"phone_number,omitempty"`
+
+	// IgnoreEmailSuggestion registers the address as typed after a did-you-mean hint for its domain
+	IgnoreEmailSuggestion bool `json:"ignore_email_suggestion,omitempty"`
+
+	// CaptchaToken is the solved challenge, required once logins from the address keep failing
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	ClientID string `json:"client_id,omitempty" binding:"max=100"` // Registered client; selects its security policy
+
+	// RememberMe asks for the long jwt.remember_me_expiry refresh token lifetime instead of refresh_expiry
+	RememberMe bool `json:"remember_me,omitempty"`
+
+	// CaptchaToken is the solved challenge, required once logins from the address or for the email keep failing
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
+
+	// Browser is set by the handler when the token came from the refresh cookie; the request must
+	// then come from an origin allowed for the client the token was issued through
+	Browser *BrowserContext `json:"-"`
+}
+
+// BrowserContext carries the fetch metadata headers of a browser request
+type BrowserContext struct {
+	Origin    string // Origin header
+	FetchSite string // Sec-Fetch-Site header; empty in browsers that don't send it
 }
 
 type ForgotPasswordRequest struct {
@@ -27,12 +50,19 @@ type ForgotPasswordRequest struct {
 
 type ResetPasswordRequest struct {
 	Token    string `json:"token" binding:"required"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"` // Checked against the security.password_* policy
+}
+
+// AcceptInvitationRequest activates an invited account with the token from the invitation email
+type AcceptInvitationRequest struct {
+	Token           string `json:"token" binding:"required"`
+	Password        string `json:"password" binding:"required"` // Checked against the security.password_* policy
+	EnableTwoFactor bool   `json:"enable_two_factor"`
 }
 
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=8"`
+	NewPassword     string `json:"new_password" binding:"required"` // Checked against the security.password_* policy
 }
 
 type UpdateProfileRequest struct {
@@ -87,10 +117,23 @@ type VerifyTokenRequest struct {
 // Response DTOs
 type AuthResponse struct {
 	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"` // Empty when delivered in the refresh cookie
 	TokenType    string    `json:"token_type"`
 	ExpiresIn    int64     `json:"expires_in"`
 	User         UserInfo  `json:"user"`
+	// TwoFactorEnrollment is set when the user's role requires two-factor authentication they haven't enabled yet
+	TwoFactorEnrollment *TwoFactorEnrollment `json:"two_factor_enrollment,omitempty"`
+	// ApprovalStatus is "pending" when the account awaits admin approval; no tokens are issued then
+	ApprovalStatus string `json:"approval_status,omitempty"`
+	// Warnings flag accepted input the user should reconsider, like a password found in known breaches
+	Warnings []ValidationHint `json:"warnings,omitempty"`
+}
+
+// TwoFactorEnrollment tells a user by when they must enable two-factor authentication
+type TwoFactorEnrollment struct {
+	Required bool      `json:"required"`
+	Deadline time.Time `json:"deadline"`
+	Overdue  bool      `json:"overdue"`
 }
 
 type RefreshResponse struct {
@@ -117,17 +160,496 @@ type UserInfo struct {
 	CreatedAt     time.Time  `json:"created_at"`
 }
 
+// BootstrapResponse is what a dashboard needs on page load, fetched in one request
+type BootstrapResponse struct {
+	Profile             *UserInfo       `json:"profile"`
+	Preferences         *UserPreference `json:"preferences"` // null until the user saves preferences
+	UnreadNotifications int64           `json:"unread_notifications"`
+}
+
 type VerifyTokenResponse struct {
-	Valid  bool     `json:"valid"`
-	UserID string   `json:"user_id,omitempty"`
-	Role   UserRole `json:"role,omitempty"`
-	Email  string   `json:"email,omitempty"`
+	Valid    bool     `json:"valid"`
+	UserID   string   `json:"user_id,omitempty"`
+	Role     UserRole `json:"role,omitempty"`
+	Roles    []string `json:"roles,omitempty"` // Extra roles from active role grants and assigned roles
+	Email    string   `json:"email,omitempty"`
+
+	// Permissions are the token's perms claim; empty unless jwt.permissions_claim is on
+	Permissions []string `json:"permissions,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`    // Set for personal access tokens and client tokens
+	ClientID string   `json:"client_id,omitempty"` // Registered client the token was issued to
+
+	// Claims holds the allowlisted custom claims of the token for downstream services
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
+
+// ClientInfo identifies the client and request behind a service call for auditing
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	RequestID string
+	TraceID   string // W3C trace ID of the request, for exemplars linking latency metrics to traces
+	JA3       string // TLS client fingerprints forwarded by the terminating proxy; empty when not forwarded
+	JA4       string
+}
+
+// TLSFingerprint returns the most stable fingerprint available, prefixed with its kind ("ja4:..." or "ja3:...")
+func (c ClientInfo) TLSFingerprint() string {
+	if c.JA4 != "" {
+		return "ja4:" + c.JA4
+	}
+	if c.JA3 != "" {
+		return "ja3:" + c.JA3
+	}
+	return ""
+}
+
+// DeviceInfo is the device metadata stored as JSON in Session.DeviceInfo
+type DeviceInfo struct {
+	JA3 string `json:"ja3,omitempty"`
+	JA4 string `json:"ja4,omitempty"`
 }
 
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-	Code    int    `json:"code,omitempty"`
+	Error     string           `json:"error"`
+	Message   string           `json:"message,omitempty"`
+	Code      int              `json:"code,omitempty"`
+	RequestID string           `json:"request_id,omitempty"` // Matches the X-Request-ID response header
+	Hints     []ValidationHint `json:"hints,omitempty"`      // Why input was refused and how to fix it
+}
+
+// ValidationHint explains a refused input value, with a corrected value when one is likely
+type ValidationHint struct {
+	Field      string `json:"field"`
+	Code       string `json:"code"` // e.g. disposable_email_domain, email_domain_typo, breached_password
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// LoginHistoryRequest pages through the user's own login attempts, newest first
+type LoginHistoryRequest struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+type LoginHistoryResponse struct {
+	Attempts []LoginAttempt `json:"attempts"`
+	Total    int64          `json:"total"`
+	Limit    int            `json:"limit"`
+	Offset   int            `json:"offset"`
+}
+
+// SessionFilterRequest carries admin session filters as query parameters (search) or JSON (revoke)
+// Timestamps are RFC 3339; IP accepts a single address or a CIDR range
+type SessionFilterRequest struct {
+	UserID        string `form:"user_id" json:"user_id,omitempty"`
+	IP            string `form:"ip" json:"ip,omitempty"`
+	UserAgent     string `form:"user_agent" json:"user_agent,omitempty"`
+	CreatedAfter  string `form:"created_after" json:"created_after,omitempty"`
+	CreatedBefore string `form:"created_before" json:"created_before,omitempty"`
+}
+
+type AdminSessionSearchRequest struct {
+	SessionFilterRequest
+	IncludeRevoked bool `form:"include_revoked"`
+	Limit          int  `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset         int  `form:"offset" binding:"omitempty,min=0"`
+}
+
+type AdminSessionSearchResponse struct {
+	Sessions []Session `json:"sessions"`
+	Total    int64     `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
+
+type AdminRevokeSessionsRequest struct {
+	SessionFilterRequest
+	Reason string `json:"reason" binding:"required,max=255"`
+	DryRun bool   `json:"dry_run"` // Count matching sessions without revoking them
+}
+
+type AdminRevokeSessionsResponse struct {
+	Matched int64 `json:"matched"`
+	Revoked int64 `json:"revoked"`
+	DryRun  bool  `json:"dry_run"`
+}
+
+// AdminInviteUserRequest creates an inactive account without a password and emails an activation link
+type AdminInviteUserRequest struct {
+	Email     string   `json:"email" binding:"required,email"`
+	Username  string   `json:"username" binding:"required,min=3,max=30"`
+	Role      UserRole `json:"role" binding:"omitempty,oneof=user admin moderator"`
+	FirstName string   `json:"first_name,omitempty"`
+	LastName  string   `json:"last_name,omitempty"`
+}
+
+type AdminInvitationResponse struct {
+	User      UserInfo  `json:"user"`
+	ExpiresAt time.Time `json:"expires_at"`
+	EmailSent bool      `json:"email_sent"` // False when delivery failed; resend the invitation
+}
+
+// AdminRegistrationQueueRequest pages through sign-ups awaiting approval, oldest first
+type AdminRegistrationQueueRequest struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+type AdminRegistrationQueueResponse struct {
+	Registrations []UserInfo `json:"registrations"`
+	Total         int64      `json:"total"`
+	Limit         int        `json:"limit"`
+	Offset        int        `json:"offset"`
+}
+
+type AdminRejectRegistrationRequest struct {
+	Reason string `json:"reason" binding:"max=500"` // Optional; included in the email to the applicant
+}
+
+// AdminRegistrationReviewResponse reports an approved or rejected sign-up
+type AdminRegistrationReviewResponse struct {
+	User      UserInfo `json:"user"`
+	Status    string   `json:"status"`     // approved or rejected
+	EmailSent bool     `json:"email_sent"` // False when the applicant couldn't be notified
+}
+
+// AdminGrantRoleRequest gives a user an extra role for a bounded time, e.g. admin for an on-call shift
+type AdminGrantRoleRequest struct {
+	Role     UserRole `json:"role" binding:"required,oneof=admin moderator"`
+	Duration string   `json:"duration" binding:"required"` // Go duration such as "4h", at most role_grants.max_duration
+	Reason   string   `json:"reason" binding:"required,max=500"`
+}
+
+type AdminRevokeRoleGrantRequest struct {
+	Reason string `json:"reason" binding:"max=500"` // Optional; recorded in the audit trail
+}
+
+// AdminRoleGrantListRequest pages through role grants, newest first
+type AdminRoleGrantListRequest struct {
+	UserID string `form:"user_id"`
+	Active bool   `form:"active"` // Only grants currently in effect
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type AdminRoleGrantListResponse struct {
+	Grants []RoleGrant `json:"grants"`
+	Total  int64       `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// AdminCreateRoleRequest creates a custom role, optionally with permissions by name (e.g. users:read)
+type AdminCreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	DisplayName string   `json:"display_name" binding:"max=100"`
+	Description string   `json:"description" binding:"max=500"`
+	Priority    int      `json:"priority" binding:"min=0,max=1000"`
+	Permissions []string `json:"permissions" binding:"omitempty,dive,required"`
+}
+
+// AdminUpdateRoleRequest changes a role's display name, description or priority; omitted fields are kept
+// Names can't change, as tokens and downstream services refer to roles by name
+type AdminUpdateRoleRequest struct {
+	DisplayName *string `json:"display_name,omitempty" binding:"omitempty,max=100"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=500"`
+	Priority    *int    `json:"priority,omitempty" binding:"omitempty,min=0,max=1000"`
+}
+
+// AdminSetRolePermissionsRequest replaces a role's permissions; an empty list removes them all
+type AdminSetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"dive,required"`
+}
+
+// AdminCreatePermissionRequest creates the permission resource:action
+type AdminCreatePermissionRequest struct {
+	Resource    string `json:"resource" binding:"required,max=50"`
+	Action      string `json:"action" binding:"required,max=50"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// AdminAssignRoleRequest gives a user a custom role by name
+type AdminAssignRoleRequest struct {
+	Role string `json:"role" binding:"required,max=50"`
+}
+
+// AdminUserRolesResponse lists a user's roles: the system role of users.role, roles from active grants,
+// assigned custom roles, and the permissions all of them give
+type AdminUserRolesResponse struct {
+	UserID       string               `json:"user_id"`
+	Role         UserRole             `json:"role"`
+	GrantedRoles []UserRole           `json:"granted_roles"`
+	Assignments  []UserRoleAssignment `json:"assignments"`
+	Permissions  []string             `json:"permissions"`
+}
+
+// AdminAuditLogRequest filters the audit log; format=ndjson streams every match oldest first instead of a page
+type AdminAuditLogRequest struct {
+	ActorID    string `form:"actor_id"`
+	TargetType string `form:"target_type"`
+	TargetID   string `form:"target_id"`
+	Action     string `form:"action"` // Exact, or a prefix ending in *
+	RequestID  string `form:"request_id"`
+	Since      string `form:"since"` // RFC 3339, inclusive
+	Until      string `form:"until"` // RFC 3339, exclusive
+	Format     string `form:"format" binding:"omitempty,oneof=json ndjson"`
+	Limit      int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset     int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+// AuditChange is a field's value before and after a change; nil where the field didn't exist
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AdminAuditEntry is an audit log entry with its changes and metadata decoded
+type AdminAuditEntry struct {
+	AuditLogEntry
+	Changes  map[string]AuditChange `json:"changes,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type AdminAuditLogResponse struct {
+	Entries []AdminAuditEntry `json:"entries"`
+	Total   int64             `json:"total"`
+	Limit   int               `json:"limit"`
+	Offset  int               `json:"offset"`
+}
+
+// AdminAuditVerifyResponse is the result of checking the audit log hash chain from its first entry
+type AdminAuditVerifyResponse struct {
+	Valid    bool   `json:"valid"`
+	Keyed    bool   `json:"keyed"`   // Hashes are HMACs with the audit log key
+	Checked  int64  `json:"checked"` // Entries checked
+	HeadSeq  int64  `json:"head_seq"`
+	HeadHash string `json:"head_hash,omitempty"` // Record it with head_seq elsewhere to detect a truncated log later
+	BrokenAt int64  `json:"broken_at,omitempty"` // Seq of the first entry that doesn't match the chain
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AdminCreateHoneypotRequest plants a honeypot account (by email) or generates a canary API key
+type AdminCreateHoneypotRequest struct {
+	Kind  string `json:"kind" binding:"required,oneof=account api_key"`
+	Email string `json:"email" binding:"required_if=Kind account,omitempty,email,max=255"` // Account honeypots only
+	Label string `json:"label" binding:"required,max=200"`                                 // Where it will be planted, e.g. "CI secrets of repo X"
+}
+
+// AdminHoneypotResponse returns a created honeypot; Key is the canary API key, shown only once
+type AdminHoneypotResponse struct {
+	Honeypot Honeypot `json:"honeypot"`
+	Key      string   `json:"key,omitempty"`
+}
+
+type AdminHoneypotListRequest struct {
+	IncludeDisabled bool `form:"include_disabled"`
+}
+
+// PasskeyCredential is the PublicKeyCredential returned by navigator.credentials.create or .get,
+// serialized with PublicKeyCredential.toJSON (binary fields as base64url)
+type PasskeyCredential struct {
+	ID       string                    `json:"id" binding:"required,max=1366"`
+	Type     string                    `json:"type" binding:"required,eq=public-key"`
+	Response PasskeyCredentialResponse `json:"response" binding:"required"`
+}
+
+// PasskeyCredentialResponse holds the authenticator's attestation (registration) or assertion (login)
+type PasskeyCredentialResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON" binding:"required"`
+	AttestationObject string   `json:"attestationObject,omitempty"` // Registration
+	Transports        []string `json:"transports,omitempty" binding:"max=8,dive,max=16"`
+	AuthenticatorData string   `json:"authenticatorData,omitempty"` // Login
+	Signature         string   `json:"signature,omitempty"`
+	UserHandle        string   `json:"userHandle,omitempty"`
+}
+
+// PasskeyRegistrationRequest completes a passkey registration started with /webauthn/register/begin
+type PasskeyRegistrationRequest struct {
+	Name       string            `json:"name" binding:"max=100"` // Defaults to "Passkey"
+	Credential PasskeyCredential `json:"credential" binding:"required"`
+}
+
+// PasskeyLoginBeginRequest starts a passkey login; without an email any discoverable passkey may be used
+type PasskeyLoginBeginRequest struct {
+	Email string `json:"email,omitempty" binding:"omitempty,email"`
+}
+
+// PasskeyLoginRequest completes a passkey login started with /webauthn/login/begin
+type PasskeyLoginRequest struct {
+	Credential PasskeyCredential `json:"credential" binding:"required"`
+	ClientID   string            `json:"client_id,omitempty" binding:"max=100"` // Registered client; selects its security policy
+}
+
+// RenamePasskeyRequest renames a registered passkey
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// PushSubscriptionRequest registers a browser for push notifications; it is PushSubscription.toJSON()
+type PushSubscriptionRequest struct {
+	Endpoint       string               `json:"endpoint" binding:"required,url,max=1000"`
+	ExpirationTime *int64               `json:"expirationTime,omitempty"` // Milliseconds since the epoch, as browsers report it
+	Keys           PushSubscriptionKeys `json:"keys" binding:"required"`
+}
+
+// PushSubscriptionKeys are the browser's keys for encrypting pushes, base64url encoded
+type PushSubscriptionKeys struct {
+	P256dh string `json:"p256dh" binding:"required,max=100"`
+	Auth   string `json:"auth" binding:"required,max=50"`
+}
+
+// CreatePersonalAccessTokenRequest mints a personal access token for a script or integration
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes,omitempty" binding:"omitempty,max=3,dive,oneof=read write admin"` // Defaults to read
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`                                                   // Defaults to personal_access_tokens.default_lifetime from now
+}
+
+// PersonalAccessTokenInfo describes a personal access token; the token itself is never returned again
+type PersonalAccessTokenInfo struct {
+	PersonalAccessToken
+	Scopes []string `json:"scopes"`
+}
+
+// PersonalAccessTokenResponse returns a created personal access token; Token is shown only once
+type PersonalAccessTokenResponse struct {
+	PersonalAccessToken PersonalAccessTokenInfo `json:"personal_access_token"`
+	Token               string                  `json:"token"`
+}
+
+// AdminCreateOAuthClientRequest registers a service for the client-credentials grant
+type AdminCreateOAuthClientRequest struct {
+	ClientID string   `json:"client_id" binding:"required,max=64"` // e.g. billing-worker
+	Name     string   `json:"name" binding:"required,max=100"`
+	Scopes   []string `json:"scopes" binding:"required,min=1,dive,required"` // From client_credentials.scopes
+}
+
+// AdminUpdateOAuthClientRequest renames a client or replaces its scopes; omitted fields are kept
+type AdminUpdateOAuthClientRequest struct {
+	Name   *string  `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Scopes []string `json:"scopes,omitempty" binding:"omitempty,min=1,dive,required"`
+}
+
+// OAuthClientInfo describes a registered client; the secret is never returned again
+type OAuthClientInfo struct {
+	OAuthClient
+	Scopes []string `json:"scopes"`
+}
+
+// AdminOAuthClientResponse returns a created client or rotated secret; ClientSecret is shown only once
+type AdminOAuthClientResponse struct {
+	Client       OAuthClientInfo `json:"client"`
+	ClientSecret string          `json:"client_secret"`
+}
+
+// ClientCredentialsTokenRequest is the form body of POST /oauth2/token (RFC 6749 section 4.4)
+// The client may authenticate with HTTP Basic instead of client_id and client_secret
+type ClientCredentialsTokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Scope        string `form:"scope"` // Space-separated; defaults to every scope of the client
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// ClientCredentialsTokenResponse is the RFC 6749 token response; there is no refresh token
+type ClientCredentialsTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// OAuth2ErrorResponse is the RFC 6749 error body that OAuth2 client libraries parse
+type OAuth2ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// AdminMergeUserRequest merges a duplicate account into the account in the path, which survives
+type AdminMergeUserRequest struct {
+	SourceUserID string `json:"source_user_id" binding:"required,uuid"` // The duplicate; it is soft-deleted
+	Reason       string `json:"reason" binding:"required,max=500"`      // Recorded in the merge audit entry
+}
+
+// AdminUserMergeResponse is a merge audit entry with the rows the merge moved
+type AdminUserMergeResponse struct {
+	Merge UserMerge       `json:"merge"`
+	Moved UserMergeCounts `json:"moved"`
+}
+
+// AdminMaintenanceRequest enters or leaves read-only maintenance mode
+type AdminMaintenanceRequest struct {
+	ReadOnly bool   `json:"read_only"`
+	Reason   string `json:"reason" binding:"max=500"` // Shown to clients refused a write
+}
+
+// AdminEmailDomainsRequest replaces the email domain lists on every replica
+type AdminEmailDomainsRequest struct {
+	DisposableDomains []string          `json:"disposable_domains" binding:"max=100000"`
+	PopularDomains    []string          `json:"popular_domains" binding:"max=1000"`
+	Typos             map[string]string `json:"typos" binding:"max=10000"` // Misspelled domain to correct domain
+}
+
+// AdminLogLevelRequest overrides logging.level on every replica for a bounded time
+type AdminLogLevelRequest struct {
+	Level    string `json:"level" binding:"required,oneof=debug info warn error"`
+	Duration string `json:"duration" binding:"required"` // Go duration such as "15m", at most logging.max_override_duration
+}
+
+// AdminDebugTargetRequest logs the requests of one user, under one path, or both in detail for a bounded time
+type AdminDebugTargetRequest struct {
+	UserID   string `json:"user_id" binding:"omitempty,uuid"`
+	Path     string `json:"path" binding:"max=200"` // Path prefix such as "/api/v1/auth/login"
+	Duration string `json:"duration" binding:"required"`
+}
+
+// AdminHoneypotTriggerListRequest pages through the uses of one honeypot, newest first
+type AdminHoneypotTriggerListRequest struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+type AdminHoneypotTriggerListResponse struct {
+	Triggers []HoneypotTrigger `json:"triggers"`
+	Total    int64             `json:"total"`
+	Limit    int               `json:"limit"`
+	Offset   int               `json:"offset"`
+}
+
+type AdminDisableTwoFactorRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// TwoFactorComplianceReport summarizes enrollment of the roles the two-factor policy applies to
+type TwoFactorComplianceReport struct {
+	Policy       TwoFactorPolicySummary                `json:"policy"`
+	GeneratedAt  time.Time                             `json:"generated_at"`
+	Roles        map[UserRole]*TwoFactorRoleCompliance `json:"roles"`
+	NonCompliant []TwoFactorNonCompliantUser           `json:"non_compliant"`
+}
+
+type TwoFactorPolicySummary struct {
+	RequiredRoles            []string   `json:"required_roles"`
+	GracePeriod              string     `json:"grace_period"`
+	EnforcedFrom             *time.Time `json:"enforced_from,omitempty"`
+	RequireApprovalToDisable bool       `json:"require_approval_to_disable"`
+}
+
+type TwoFactorRoleCompliance struct {
+	Total          int     `json:"total"`
+	Enrolled       int     `json:"enrolled"`
+	Pending        int     `json:"pending"` // Not enrolled, still within the grace period
+	Overdue        int     `json:"overdue"`
+	ComplianceRate float64 `json:"compliance_rate"`
+}
+
+type TwoFactorNonCompliantUser struct {
+	UserID   string    `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     UserRole  `json:"role"`
+	Deadline time.Time `json:"deadline"`
+	Overdue  bool      `json:"overdue"`
 }
 
 type SuccessResponse struct {
@@ -150,11 +672,18 @@ type JWTClaims struct {
 
 // OAuth2 User Info
 type OAuth2UserInfo struct {
-	ID       string `json:"id"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Avatar   string `json:"avatar"`
-	Provider string `json:"provider"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"` // The provider confirmed the user owns Email
+	Username      string `json:"username"`
+	Avatar        string `json:"avatar"`
+	Provider      string `json:"provider"`
+}
+
+// OAuthCodeExchangeRequest trades the one-time code an OAuth callback redirected with for tokens
+type OAuthCodeExchangeRequest struct {
+	Code     string `json:"code" binding:"required"`
+	ClientID string `json:"client_id,omitempty" binding:"max=100"` // Registered client; selects its security policy
 }
 
 // OAuthUser represents OAuth user data
@@ -174,4 +703,11 @@ type CreateNotificationRequest struct {
 	ActionURL  string     `json:"action_url,omitempty"`
 	ActionText string     `json:"action_text,omitempty"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// FeedTokenResponse returns a newly issued feed token with the feed URLs it unlocks
+// The token is only shown once; issuing a new one invalidates the previous URLs
+type FeedTokenResponse struct {
+	Token string            `json:"token"`
+	Feeds map[string]string `json:"feeds"` // Feed name (e.g. "activities.rss") to URL path
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,19 @@ const (
 	// RolePremium removed - not in database enum (user_role: 'user', 'admin', 'moderator')
 )
 
+// Role grant end reasons, matching check_valid_grant_end_reason
+const (
+	GrantEndExpired = "expired"
+	GrantEndRevoked = "revoked"
+)
+
+// Registration approval statuses, matching check_valid_approval_status
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
 // User represents the unified user entity matching 001_initial_schema.sql exactly
 // All fields correspond directly to database columns for schema consistency
 type User struct {
@@ -34,6 +48,7 @@ type User struct {
 	GoogleID             string         `json:"-" gorm:"type:varchar(255);column:google_id"`
 	GitHubID             string         `json:"-" gorm:"type:varchar(255);column:git_hub_id"` // Database uses git_hub_id
 	FacebookID           string         `json:"-" gorm:"type:varchar(255);column:facebook_id"`
+	AppleID              string         `json:"-" gorm:"type:varchar(255);column:apple_id"` // Apple's stable "sub"
 	
 	// Profile fields - integrated from UserProfile, matches database schema
 	FirstName    string         `json:"first_name" gorm:"type:varchar(100)"`
@@ -59,6 +74,25 @@ type User struct {
 	LastLoginIP          *string        `json:"-" gorm:"type:inet"` // Nullable INET to prevent empty string errors
 	FailedLoginAttempts  int            `json:"-" gorm:"default:0"`
 	LockedUntil          *time.Time     `json:"-"`
+	PasswordChangedAt    *time.Time     `json:"-"` // Set by password reset; refresh tokens from earlier logins are rejected
+	
+	// Invitation tracking - set when an admin creates the account without a password
+	InvitedAt            *time.Time     `json:"invited_at,omitempty"`                // Time of the latest (re)sent invitation
+	InvitedBy            *uuid.UUID     `json:"invited_by,omitempty" gorm:"type:uuid"` // FK to users(id) SET NULL
+	
+	// Registration approval - set for sign-ups made while registration.mode = "approval"
+	ApprovalStatus       *string        `json:"approval_status,omitempty" gorm:"type:varchar(20)"` // pending, approved or rejected
+	ApprovalReviewedBy   *uuid.UUID     `json:"approval_reviewed_by,omitempty" gorm:"type:uuid"`   // FK to users(id) SET NULL
+	ApprovalReviewedAt   *time.Time     `json:"approval_reviewed_at,omitempty"`
+	
+	// Feed subscriptions - SHA-256 of the personal token in RSS/Atom and iCal feed URLs
+	FeedTokenHash        *string        `json:"-" gorm:"type:varchar(64)"`
+	
+	// Token revocation - bumped when a role grant ends or the password is reset so earlier tokens stop working
+	TokenVersion         int            `json:"-" gorm:"not null;default:0"`
+	
+	// Retention - set when the account's personal data was cleared after its soft delete
+	PurgedAt             *time.Time     `json:"-"`
 	
 	// Timestamps - standard GORM fields matching database
 	CreatedAt            time.Time      `json:"created_at"`
@@ -69,10 +103,17 @@ type User struct {
 	IsVerified           bool           `json:"is_verified" gorm:"-"` 
 	VerifiedAt           *time.Time     `json:"verified_at,omitempty" gorm:"-"`
 	Avatar               string         `json:"avatar" gorm:"-"`
+	GrantedRoles         []UserRole     `json:"-" gorm:"-"` // Roles from active role grants, loaded before issuing tokens
+	GrantsExpireAt       *time.Time     `json:"-" gorm:"-"` // Earliest expiry of those grants; access tokens never outlive it
+	AssignedRoles        []string       `json:"-" gorm:"-"` // Custom roles from user_roles, loaded before issuing tokens
+	Permissions          []string       `json:"-" gorm:"-"` // Permissions of the user's role, grants and assigned roles
+	Policy               *SecurityPolicy `json:"-" gorm:"-"` // Tenant/client policy resolved at login; sets token lifetimes
+	TLSFingerprint       string         `json:"-" gorm:"-"` // TLS client fingerprint refresh tokens are bound to ("ja4:..." or "ja3:...")
 	
 	// Relations - Authentication
 	Sessions             []Session           `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 	PasswordResets       []PasswordReset     `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	OAuthIdentities      []OAuthIdentity     `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // OIDC providers; only set when creating or preloaded
 	
 	// Relations - Extended from User Service  
 	Profile              *UserProfile        `json:"profile,omitempty" gorm:"constraint:OnDelete:CASCADE"`
@@ -85,7 +126,7 @@ type User struct {
 // BeforeCreate hook to set UUID if not already set
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
-		u.ID = uuid.New()
+		u.ID = NewID()
 	}
 	return nil
 }
@@ -102,12 +143,17 @@ type Session struct {
 	AccessTokenHash  string         `json:"-" gorm:"size:255"`                        // VARCHAR(255) for hashed tokens
 	
 	// Session context - request metadata with proper PostgreSQL types
-	IPAddress        string         `json:"ip_address" gorm:"type:inet"`              // INET for IP addresses
+	IPAddress        *string        `json:"ip_address" gorm:"type:inet"`              // INET, full or truncated per privacy.ip_storage; NULL in hmac mode
+	IPHash           string         `json:"-" gorm:"size:64"`                         // VARCHAR(64) HMAC of the address in hmac mode
 	UserAgent        string         `json:"user_agent" gorm:"type:text"`              // TEXT for user agent strings
 	DeviceInfo       string         `json:"device_info" gorm:"type:jsonb"`            // JSONB for device metadata
+	CountryCode      string         `json:"country_code,omitempty" gorm:"size:2"`     // VARCHAR(2) ISO code located by geoip
+	City             string         `json:"city,omitempty" gorm:"size:100"`           // VARCHAR(100) located by geoip
 	
 	// Session lifecycle - status tracking with database defaults
 	IsActive         bool           `json:"is_active" gorm:"default:true"`            // BOOLEAN DEFAULT true
+	IsRevoked        bool           `json:"is_revoked" gorm:"not null;default:false"` // BOOLEAN NOT NULL DEFAULT false
+	RememberMe       bool           `json:"remember_me" gorm:"default:false"`         // BOOLEAN NOT NULL DEFAULT false; lives jwt.remember_me_expiry
 	
 	// Audit trail - timestamp tracking with triggers
 	CreatedAt        time.Time      `json:"created_at"`                               // TIMESTAMP DEFAULT NOW()
@@ -119,20 +165,70 @@ type Session struct {
 	User             User           `json:"-" gorm:"foreignKey:UserID"`
 }
 
+// SessionFilter selects sessions for administrative search and bulk revocation
+// Zero-valued fields do not filter; revoked sessions are excluded unless IncludeRevoked is set
+type SessionFilter struct {
+	UserID         *uuid.UUID
+	IPRange        string // Single address or CIDR range matched with the inet <<= operator
+	IPHash         string // Exact address match when IPs are stored as HMACs
+	UserAgent      string // Case-insensitive substring
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	ExcludeID      *uuid.UUID // A session to leave alone, e.g. the caller's own
+	IncludeRevoked bool
+}
+
+// IsEmpty reports whether the filter would match every active session
+func (f SessionFilter) IsEmpty() bool {
+	return f.UserID == nil && f.IPRange == "" && f.IPHash == "" && f.UserAgent == "" && f.CreatedAfter == nil && f.CreatedBefore == nil
+}
+
+// UserSessionInfo describes one of the caller's signed-in devices; Current marks the session making the request
+// IPAddress follows privacy.ip_storage and is omitted when addresses are stored as hashes
+type UserSessionInfo struct {
+	ID          uuid.UUID  `json:"id"`
+	IPAddress   *string    `json:"ip_address,omitempty"`
+	UserAgent   string     `json:"user_agent"`
+	CountryCode string     `json:"country_code,omitempty"` // Where the login came from, when geoip located it
+	City        string     `json:"city,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"` // Last token refresh; unset until the first one
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RememberMe  bool       `json:"remember_me"` // Long-lived login that asked to be remembered
+	Current     bool       `json:"current"`
+}
+
+// RevokedSessionsResponse reports how many sessions a sign-out of several devices ended
+type RevokedSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// TwoFactorStatus is a user's two-factor enrollment as seen by the compliance report
+type TwoFactorStatus struct {
+	UserID           uuid.UUID
+	Email            string
+	Role             UserRole
+	CreatedAt        time.Time
+	TwoFactorEnabled bool
+}
+
 // BeforeCreate hook to set UUID if not already set
 func (s *Session) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == uuid.Nil {
-		s.ID = uuid.New()
+		s.ID = NewID()
 	}
 	return nil
 }
 
+// PasswordReset records one requested password reset - matches 010_add_password_resets.sql
+// Only the SHA-256 hash of the emailed token is stored; a reset is usable once, before ExpiresAt
 type PasswordReset struct {
 	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
 	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
-	Token     string         `json:"-" gorm:"uniqueIndex;not null"`
+	TokenHash string         `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	RequestID string         `json:"request_id,omitempty" gorm:"size:128"` // X-Request-ID of the forgot-password request
 	ExpiresAt time.Time      `json:"expires_at" gorm:"not null"`
-	UsedAt    *time.Time     `json:"used_at"`
+	UsedAt    *time.Time     `json:"used_at"` // Also set on the user's other pending resets once one is used
 	CreatedAt time.Time      `json:"created_at"`
 	
 	// Relations
@@ -142,7 +238,7 @@ type PasswordReset struct {
 // BeforeCreate hook to set UUID if not already set
 func (p *PasswordReset) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
-		p.ID = uuid.New()
+		p.ID = NewID()
 	}
 	return nil
 }
@@ -163,8 +259,12 @@ type LoginAttempt struct {
 	FailureReason string     `json:"failure_reason,omitempty" gorm:"size:255"`    // VARCHAR(255) - error detail
 	
 	// Request context with PostgreSQL network types
-	IPAddress     string     `json:"ip_address" gorm:"type:inet;not null;index"`  // INET for IP tracking
+	IPAddress     *string    `json:"ip_address" gorm:"type:inet;index"`           // INET, full or truncated per privacy.ip_storage; NULL in hmac mode
+	IPHash        string     `json:"-" gorm:"size:64"`                            // VARCHAR(64) HMAC of the address in hmac mode
 	UserAgent     string     `json:"user_agent,omitempty" gorm:"type:text"`       // TEXT for browser info
+	RequestID     string     `json:"request_id,omitempty" gorm:"size:128;index"`  // VARCHAR(128) - X-Request-ID of the attempt
+	CountryCode   string     `json:"country_code,omitempty" gorm:"size:2"`        // VARCHAR(2) - ISO code located by geoip
+	City          string     `json:"city,omitempty" gorm:"size:100"`              // VARCHAR(100) - located by geoip
 	
 	// Audit timestamp
 	AttemptedAt   time.Time  `json:"attempted_at" gorm:"default:now()"`           // TIMESTAMP DEFAULT NOW()
@@ -176,7 +276,7 @@ type LoginAttempt struct {
 // BeforeCreate hook to set UUID if not already set
 func (l *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
 	if l.ID == uuid.Nil {
-		l.ID = uuid.New()
+		l.ID = NewID()
 	}
 	return nil
 }
@@ -201,6 +301,16 @@ func (LoginAttempt) TableName() string {
 	return "login_attempts"
 }
 
+// SignInDevice identifies where a successful login came from, for new sign-in alerts
+// Network and IPHash follow the IP storage mode: with addresses stored, logins from the same /24 (IPv4)
+// or /48 (IPv6) network count as one location; with hashes stored, only the same address does
+type SignInDevice struct {
+	UserAgent  string
+	Network    string // CIDR of the address's network; empty in hmac mode or without an address
+	IPHash     string // HMAC of the address in hmac mode
+	DeviceInfo string // Session device_info with JA3/JA4 fingerprints; "{}" without them
+}
+
 // IsLocked checks if user account is locked
 func (u *User) IsLocked() bool {
 	if u.LockedUntil == nil {
@@ -209,16 +319,67 @@ func (u *User) IsLocked() bool {
 	return time.Now().Before(*u.LockedUntil)
 }
 
+// IsPendingInvitation reports whether the user was invited and hasn't set a password yet
+func (u *User) IsPendingInvitation() bool {
+	return u.InvitedAt != nil && !u.IsActive && u.PasswordHash == ""
+}
+
 // CanAttemptLogin checks if user can attempt login
 func (u *User) CanAttemptLogin() bool {
 	return u.IsActive && !u.IsLocked()
 }
 
-// IncrementFailedAttempts increments failed login attempts
-func (u *User) IncrementFailedAttempts() {
+// OAuth providers whose IDs are stored on the user
+const (
+	OAuthProviderGoogle   = "google"
+	OAuthProviderGitHub   = "github"
+	OAuthProviderFacebook = "facebook"
+	OAuthProviderApple    = "apple"
+)
+
+// OAuthID returns the user's ID at the OAuth provider, or "" when the account isn't linked to it
+// IDs at OIDC providers are only known when OAuthIdentities is loaded
+func (u *User) OAuthID(provider string) string {
+	switch provider {
+	case OAuthProviderGoogle:
+		return u.GoogleID
+	case OAuthProviderGitHub:
+		return u.GitHubID
+	case OAuthProviderFacebook:
+		return u.FacebookID
+	case OAuthProviderApple:
+		return u.AppleID
+	}
+	for _, identity := range u.OAuthIdentities {
+		if identity.Provider == provider {
+			return identity.Subject
+		}
+	}
+	return ""
+}
+
+// SetOAuthID links the user to an ID at the OAuth provider; IDs at OIDC providers are added to
+// OAuthIdentities, which GORM saves with a new user
+func (u *User) SetOAuthID(provider, id string) {
+	switch provider {
+	case OAuthProviderGoogle:
+		u.GoogleID = id
+	case OAuthProviderGitHub:
+		u.GitHubID = id
+	case OAuthProviderFacebook:
+		u.FacebookID = id
+	case OAuthProviderApple:
+		u.AppleID = id
+	default:
+		u.OAuthIdentities = append(u.OAuthIdentities, OAuthIdentity{Provider: provider, Subject: id})
+	}
+}
+
+// IncrementFailedAttempts increments failed login attempts and locks the account once the policy's limit is reached
+func (u *User) IncrementFailedAttempts(policy *SecurityPolicy) {
 	u.FailedLoginAttempts++
-	if u.FailedLoginAttempts >= 5 {
-		lockUntil := time.Now().Add(15 * time.Minute)
+	if u.FailedLoginAttempts >= policy.MaxLoginAttempts {
+		lockUntil := time.Now().Add(policy.LockoutDuration)
 		u.LockedUntil = &lockUntil
 	}
 }
@@ -229,6 +390,34 @@ func (u *User) ResetFailedAttempts() {
 	u.LockedUntil = nil
 }
 
+// SecurityPolicy is the token lifetime and login security policy that applies to one login
+// It starts from the global settings and is tightened by the tenant and client policies that match
+type SecurityPolicy struct {
+	Name             string // "default", or the names of the matching policies joined with "+"
+	ClientID         string // Registered client the policy was resolved for; carried in tokens for refresh
+	AccessExpiry     time.Duration
+	RefreshExpiry    time.Duration
+	// RememberMeExpiry is the refresh token lifetime of logins that ask to be remembered; 0 turns it off
+	RememberMeExpiry time.Duration
+	RememberMe       bool // The login asked to be remembered, so RefreshExpiry is RememberMeExpiry
+	MaxSessions      int  // Older sessions are revoked beyond this; 0 means unlimited
+	RequireTwoFactor bool // Every user must enroll, regardless of role
+	MaxLoginAttempts int
+	LockoutDuration  time.Duration
+}
+
+// Remembered returns the policy of a login that asked to be remembered: a copy with the remember me
+// refresh token lifetime. Policies are shared, so p isn't changed; without remember me p is returned
+func (p *SecurityPolicy) Remembered() *SecurityPolicy {
+	if p.RememberMeExpiry <= 0 {
+		return p
+	}
+	remembered := *p
+	remembered.RefreshExpiry = max(p.RefreshExpiry, p.RememberMeExpiry)
+	remembered.RememberMe = true
+	return &remembered
+}
+
 // UserProfile contains extended user information
 type UserProfile struct {
 	ID            uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
@@ -252,7 +441,7 @@ type UserProfile struct {
 
 func (p *UserProfile) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
-		p.ID = uuid.New()
+		p.ID = NewID()
 	}
 	return nil
 }
@@ -286,7 +475,7 @@ type UserPreference struct {
 
 func (p *UserPreference) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
-		p.ID = uuid.New()
+		p.ID = NewID()
 	}
 	return nil
 }
@@ -297,15 +486,17 @@ type UserActivity struct {
 	UserID      uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`               // UUID FK to users(id)
 	Action      string    `gorm:"type:varchar(100);not null" json:"action"`              // VARCHAR(100) NOT NULL to match database
 	Description string    `gorm:"type:text" json:"description,omitempty"`                // TEXT for description
-	IPAddress   string    `gorm:"type:inet" json:"ip_address,omitempty"`                 // INET for IP addresses
+	IPAddress   *string   `gorm:"type:inet" json:"ip_address,omitempty"`                 // INET, full or truncated per privacy.ip_storage; NULL in hmac mode
+	IPHash      string    `gorm:"type:varchar(64)" json:"-"`                             // VARCHAR(64) HMAC of the address in hmac mode
 	UserAgent   string    `gorm:"type:text" json:"user_agent,omitempty"`                 // TEXT for user agent
+	RequestID   string    `gorm:"type:varchar(128);index" json:"request_id,omitempty"`   // VARCHAR(128) X-Request-ID of the request
 	Metadata    string    `gorm:"type:jsonb;default:'{}'" json:"metadata,omitempty"`     // JSONB for structured data
 	CreatedAt   time.Time `json:"created_at"`                                            // TIMESTAMP DEFAULT NOW()
 }
 
 func (a *UserActivity) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == uuid.Nil {
-		a.ID = uuid.New()
+		a.ID = NewID()
 	}
 	// Ensure Metadata is valid JSON
 	if a.Metadata == "" {
@@ -314,6 +505,316 @@ func (a *UserActivity) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// RoleGrant gives a user an extra role until it expires or is revoked (just-in-time elevated access)
+// Ended grants are kept as the audit trail - matches 009_add_role_grants.sql
+type RoleGrant struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"` // FK to users(id) CASCADE
+	Role      UserRole   `gorm:"type:user_role;not null" json:"role"`
+	Reason    string     `gorm:"type:text;not null" json:"reason"`
+	GrantedBy *uuid.UUID `gorm:"type:uuid" json:"granted_by,omitempty"` // FK to users(id) SET NULL
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`                           // Set when the grant expired or was revoked
+	EndedBy   *uuid.UUID `gorm:"type:uuid" json:"ended_by,omitempty"`          // Admin who revoked it; NULL when it expired
+	EndReason *string    `gorm:"type:varchar(20)" json:"end_reason,omitempty"` // expired or revoked
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for RoleGrant model
+func (RoleGrant) TableName() string {
+	return "role_grants"
+}
+
+func (g *RoleGrant) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = NewID()
+	}
+	return nil
+}
+
+// IsActive reports whether the grant is in effect at now
+func (g *RoleGrant) IsActive(now time.Time) bool {
+	return g.EndedAt == nil && now.Before(g.ExpiresAt)
+}
+
+// Honeypot kinds
+const (
+	HoneypotAccount = "account" // Login email no real user has
+	HoneypotAPIKey  = "api_key" // Canary bearer key no client is issued
+)
+
+// Honeypot is a planted credential whose use means someone is replaying leaked or stolen secrets
+// Disabled honeypots are kept with their triggers as the audit trail - matches 011_add_honeypots.sql
+type Honeypot struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Kind            string     `gorm:"type:varchar(20);not null" json:"kind"`        // account or api_key
+	Identifier      string     `gorm:"type:varchar(255);not null" json:"identifier"` // Lowercased email, or SHA-256 of the canary key
+	KeyPrefix       *string    `gorm:"type:varchar(20)" json:"key_prefix,omitempty"` // First characters of a canary key, to recognize it in listings
+	Label           string     `gorm:"type:varchar(200);not null" json:"label"`      // Where the credential was planted
+	CreatedBy       *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`        // FK to users(id) SET NULL
+	TriggerCount    int        `gorm:"not null;default:0" json:"trigger_count"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	DisabledAt      *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for Honeypot model
+func (Honeypot) TableName() string {
+	return "honeypots"
+}
+
+func (h *Honeypot) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = NewID()
+	}
+	return nil
+}
+
+// HoneypotTrigger records one use of a honeypot
+type HoneypotTrigger struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	HoneypotID uuid.UUID `gorm:"type:uuid;index;not null" json:"honeypot_id"` // FK to honeypots(id) CASCADE
+	IPAddress  *string   `gorm:"type:inet" json:"ip_address,omitempty"`       // Full or truncated per privacy.ip_storage; NULL in hmac mode
+	IPHash     string    `gorm:"type:varchar(64)" json:"-"`
+	UserAgent  string    `gorm:"type:text" json:"user_agent,omitempty"`
+	RequestID  string    `gorm:"type:varchar(128)" json:"request_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for HoneypotTrigger model
+func (HoneypotTrigger) TableName() string {
+	return "honeypot_triggers"
+}
+
+func (t *HoneypotTrigger) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = NewID()
+	}
+	return nil
+}
+
+// WebAuthnCredential is a passkey registered by a user for passwordless login
+type WebAuthnCredential struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`                      // FK to users(id) CASCADE
+	CredentialID   string     `gorm:"type:varchar(1366);uniqueIndex;not null" json:"credential_id"` // base64url, chosen by the authenticator
+	PublicKey      []byte     `gorm:"type:bytea;not null" json:"-"`                                 // COSE_Key
+	Algorithm      int        `gorm:"not null" json:"algorithm"`                                    // COSE algorithm, e.g. -7 for ES256
+	SignCount      int64      `gorm:"not null;default:0" json:"-"`
+	AAGUID         string     `gorm:"column:aaguid;type:varchar(36)" json:"aaguid,omitempty"` // Authenticator model
+	Transports     string     `gorm:"type:varchar(100)" json:"transports,omitempty"`          // Comma-separated hints, e.g. "internal,hybrid"
+	BackupEligible bool       `gorm:"not null;default:false" json:"backup_eligible"`          // Synced passkey
+	BackupState    bool       `gorm:"not null;default:false" json:"backup_state"`
+	Name           string     `gorm:"type:varchar(100);not null" json:"name"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for WebAuthnCredential model
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// TransportList returns the transport hints stored with the credential
+func (c *WebAuthnCredential) TransportList() []string {
+	if c.Transports == "" {
+		return nil
+	}
+	return strings.Split(c.Transports, ",")
+}
+
+func (c *WebAuthnCredential) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = NewID()
+	}
+	return nil
+}
+
+// OAuthIdentity links a user to their subject at an OIDC provider configured under oauth2.oidc
+// The built-in providers have a column on users instead
+type OAuthIdentity struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_oauth_identities_user_provider" json:"user_id"` // FK to users(id) CASCADE
+	Provider  string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_oauth_identities_user_provider;uniqueIndex:idx_user_oauth_identities_provider_subject" json:"provider"`
+	Subject   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_oauth_identities_provider_subject" json:"-"` // The ID token's "sub"
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for OAuthIdentity model
+func (OAuthIdentity) TableName() string {
+	return "user_oauth_identities"
+}
+
+func (i *OAuthIdentity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = NewID()
+	}
+	return nil
+}
+
+// PushSubscription is a browser subscribed to the user's notifications through the Push API
+// An endpoint belongs to one browser profile, so it is unique across users
+type PushSubscription struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`                 // FK to users(id) CASCADE
+	Endpoint  string     `gorm:"type:varchar(1000);uniqueIndex;not null" json:"endpoint"` // Push service URL of the browser
+	P256dh    string     `gorm:"column:p256dh;type:varchar(100);not null" json:"-"`       // base64url public key payloads are encrypted for
+	Auth      string     `gorm:"type:varchar(50);not null" json:"-"`                      // base64url authentication secret
+	UserAgent string     `gorm:"type:text" json:"user_agent,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Set when the browser reported an expiration time
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for PushSubscription model
+func (PushSubscription) TableName() string {
+	return "push_subscriptions"
+}
+
+func (s *PushSubscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = NewID()
+	}
+	return nil
+}
+
+// PushSubscriptionsResponse lists the user's push subscriptions with the key browsers subscribe with
+type PushSubscriptionsResponse struct {
+	VAPIDPublicKey string             `json:"vapid_public_key"` // applicationServerKey for pushManager.subscribe
+	Subscriptions  []PushSubscription `json:"subscriptions"`
+}
+
+// Personal access token scopes; a token only reaches the routes its scopes cover
+const (
+	TokenScopeRead  = "read"  // Read the user's own account data
+	TokenScopeWrite = "write" // Change the user's profile, preferences and notifications
+	TokenScopeAdmin = "admin" // Admin API, for admins only
+)
+
+// PersonalAccessToken is a long-lived bearer token a user minted for a script or integration
+// Only the SHA-256 of the token is stored; it is shown once, when created
+type PersonalAccessToken struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"` // FK to users(id) CASCADE
+	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
+	TokenHash   string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	TokenPrefix string     `gorm:"type:varchar(20);not null" json:"token_prefix"` // First characters, to recognize the token in listings
+	Scopes      string     `gorm:"type:varchar(100);not null" json:"-"`           // Comma-separated, e.g. "read,write"
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for PersonalAccessToken model
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// ScopeList returns the scopes granted to the token
+func (t *PersonalAccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// Active reports whether the token can still authenticate requests at now
+func (t *PersonalAccessToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+func (t *PersonalAccessToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = NewID()
+	}
+	return nil
+}
+
+// OAuthClient is an internal service registered for the client-credentials grant
+// Only the SHA-256 of its secret is stored; the secret is shown once, when created or rotated
+type OAuthClient struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	ClientID     string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"client_id"`
+	Name         string     `gorm:"type:varchar(100);not null" json:"name"`
+	SecretHash   string     `gorm:"type:varchar(64);not null" json:"-"`
+	SecretPrefix string     `gorm:"type:varchar(20);not null" json:"secret_prefix"` // First characters, to recognize the secret in listings
+	Scopes       string     `gorm:"type:text;not null" json:"-"`                    // Space-separated, e.g. "users:read notifications:send"
+	CreatedBy    *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`          // FK to users(id) SET NULL
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for OAuthClient model
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// ScopeList returns the scopes the client may request
+func (c *OAuthClient) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+func (c *OAuthClient) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = NewID()
+	}
+	return nil
+}
+
+// UserMerge records a duplicate account merged into the surviving one - matches 019_add_user_merges.sql
+// Rows can't be changed or deleted; the user IDs have no foreign keys so the record outlives both accounts
+type UserMerge struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	SourceUserID   uuid.UUID  `gorm:"type:uuid;uniqueIndex;not null" json:"source_user_id"` // The duplicate, soft-deleted by the merge
+	TargetUserID   uuid.UUID  `gorm:"type:uuid;index;not null" json:"target_user_id"`       // The surviving account
+	MergedBy       *uuid.UUID `gorm:"type:uuid" json:"merged_by,omitempty"`
+	SourceEmail    string     `gorm:"type:varchar(255);not null" json:"source_email"`
+	SourceUsername string     `gorm:"type:varchar(100);not null" json:"source_username"`
+	Reason         string     `gorm:"type:text;not null" json:"reason"`
+	Moved          string     `gorm:"type:jsonb;not null;default:'{}'" json:"-"` // UserMergeCounts as JSON
+	RequestID      string     `gorm:"type:varchar(128)" json:"request_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for UserMerge model
+func (UserMerge) TableName() string {
+	return "user_merges"
+}
+
+func (m *UserMerge) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = NewID()
+	}
+	return nil
+}
+
+// UserMergeCounts counts what a merge moved to the surviving account
+type UserMergeCounts struct {
+	Sessions              int64    `json:"sessions"` // Moved revoked; their tokens were issued to the duplicate
+	Activities            int64    `json:"activities"`
+	Notifications         int64    `json:"notifications"`
+	ArchivedNotifications int64    `json:"archived_notifications"`
+	ActivityArchives      int64    `json:"activity_archives"`      // Archived activity objects now listed for the surviving account
+	NotificationSummaries int64    `json:"notification_summaries"` // Months added to the surviving account's summaries
+	Passkeys              int64    `json:"passkeys"`
+	Preferences           bool     `json:"preferences"`           // False when the surviving account kept its own
+	Providers             []string `json:"providers,omitempty"`   // OAuth providers whose identity was moved
+	RevokedAccessTokens   int64    `json:"revoked_access_tokens"` // The duplicate's personal access tokens, revoked rather than moved
+}
+
+// UserPurgeCounts counts the rows deleted when a soft-deleted account was purged
+type UserPurgeCounts struct {
+	Sessions              int64 `json:"sessions"`
+	LoginAttempts         int64 `json:"login_attempts"` // Including failed attempts that only recorded the email
+	Activities            int64 `json:"activities"`
+	Notifications         int64 `json:"notifications"`
+	ArchivedNotifications int64 `json:"archived_notifications"`
+	ActivityArchives      int64 `json:"activity_archives"` // Archive objects, deleted from object storage first
+	Other                 int64 `json:"other"`             // Preferences, passkeys, tokens, identities, role grants...
+}
+
 // UserNotification represents system notifications to users - matches 001_initial_schema.sql exactly  
 type UserNotification struct {
 	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`                    // UUID PRIMARY KEY
@@ -331,17 +832,85 @@ type UserNotification struct {
 
 func (n *UserNotification) BeforeCreate(tx *gorm.DB) error {
 	if n.ID == uuid.Nil {
-		n.ID = uuid.New()
+		n.ID = NewID()
+	}
+	return nil
+}
+
+// NotificationSummary counts one user's notifications for a calendar month that the retention job removed
+type NotificationSummary struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID            uuid.UUID `gorm:"type:uuid;not null" json:"user_id"` // FK to users(id) CASCADE
+	Month             time.Time `gorm:"type:date;not null" json:"month"`   // First day of the month
+	NotificationCount int       `gorm:"not null;default:0" json:"notification_count"`
+	ReadCount         int       `gorm:"not null;default:0" json:"read_count"`
+	FirstCreatedAt    time.Time `gorm:"not null" json:"first_created_at"`
+	LastCreatedAt     time.Time `gorm:"not null" json:"last_created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for NotificationSummary model
+func (NotificationSummary) TableName() string {
+	return "user_notification_summaries"
+}
+
+// ArchivedNotification is a notification moved out of user_notifications by the retention job
+type ArchivedNotification struct {
+	UserNotification
+	ArchivedAt time.Time `gorm:"not null" json:"archived_at"`
+}
+
+// TableName returns the table name for ArchivedNotification model
+func (ArchivedNotification) TableName() string {
+	return "user_notifications_archive"
+}
+
+// UserActivityArchive lists an object in object storage holding a batch of one user's activities, moved
+// out of user_activities by the activity archive job - matches 023_add_user_activity_archives.sql
+type UserActivityArchive struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID         uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`                   // FK to users(id) CASCADE
+	ObjectKey      string    `gorm:"type:varchar(500);uniqueIndex;not null" json:"object_key"` // Without the storage prefix
+	ActivityCount  int       `gorm:"not null" json:"activity_count"`
+	SizeBytes      int64     `gorm:"not null" json:"size_bytes"` // Compressed
+	FirstCreatedAt time.Time `gorm:"not null" json:"first_created_at"`
+	LastCreatedAt  time.Time `gorm:"not null" json:"last_created_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for UserActivityArchive model
+func (UserActivityArchive) TableName() string {
+	return "user_activity_archives"
+}
+
+func (a *UserActivityArchive) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = NewID()
 	}
 	return nil
 }
 
-// Role represents user roles
+// UserDataKey is a user's key for data sealed in Redis, encrypted under the data master key - matches
+// 024_add_user_data_keys.sql. Deleting the row shreds everything the key sealed
+type UserDataKey struct {
+	UserID      uuid.UUID `gorm:"type:uuid;primary_key" json:"user_id"`     // FK to users(id) CASCADE
+	WrappedKey  []byte    `gorm:"type:bytea;not null" json:"-"`             // Nonce followed by the AES-GCM sealed key
+	MasterKeyID string    `gorm:"type:varchar(16);index;not null" json:"-"` // Fingerprint of the master key that wrapped it
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for UserDataKey model
+func (UserDataKey) TableName() string {
+	return "user_data_keys"
+}
+
+// Role represents user roles - matches 026_add_roles_and_permissions.sql
+// System roles mirror the user_role enum and follow users.role; custom roles are assigned in user_roles
 type Role struct {
 	ID          uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
-	Name        string         `gorm:"uniqueIndex;not null" json:"name"`
-	DisplayName string         `json:"display_name"`
-	Description string         `json:"description"`
+	Name        string         `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	DisplayName string         `gorm:"size:100" json:"display_name"`
+	Description string         `gorm:"type:text" json:"description"`
 	IsSystem    bool           `gorm:"default:false" json:"is_system"`
 	Priority    int            `gorm:"default:0" json:"priority"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -349,48 +918,129 @@ type Role struct {
 	
 	// Relations
 	Users       []User         `gorm:"many2many:user_roles" json:"-"`
-	Permissions []Permission   `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+	Permissions []Permission   `gorm:"many2many:role_permissions" json:"permissions"`
+}
+
+// TableName returns the table name for Role model
+func (Role) TableName() string {
+	return "roles"
 }
 
 func (r *Role) BeforeCreate(tx *gorm.DB) error {
 	if r.ID == uuid.Nil {
-		r.ID = uuid.New()
+		r.ID = NewID()
 	}
 	return nil
 }
 
-// Permission represents system permissions
+// Permission represents system permissions, named resource:action (e.g. users:read)
 type Permission struct {
 	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
-	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
-	Resource    string    `gorm:"index;not null" json:"resource"`
-	Action      string    `gorm:"not null" json:"action"`
-	Description string    `json:"description"`
+	Name        string    `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Resource    string    `gorm:"size:50;index;not null" json:"resource"`
+	Action      string    `gorm:"size:50;not null" json:"action"`
+	Description string    `gorm:"type:text" json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
 	
 	// Relations
 	Roles []Role `gorm:"many2many:role_permissions" json:"-"`
 }
 
+// TableName returns the table name for Permission model
+func (Permission) TableName() string {
+	return "permissions"
+}
+
 func (p *Permission) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
-		p.ID = uuid.New()
+		p.ID = NewID()
 	}
 	return nil
 }
 
 // UserRoleAssignment represents the many-to-many relationship between users and roles
 type UserRoleAssignment struct {
-	UserID     uuid.UUID `gorm:"type:uuid;primary_key" json:"user_id"`
-	RoleID     uuid.UUID `gorm:"type:uuid;primary_key" json:"role_id"`
-	AssignedAt time.Time `json:"assigned_at"`
-	AssignedBy uuid.UUID `gorm:"type:uuid" json:"assigned_by"`
+	UserID     uuid.UUID  `gorm:"type:uuid;primary_key" json:"user_id"`     // FK to users(id) CASCADE
+	RoleID     uuid.UUID  `gorm:"type:uuid;primary_key" json:"role_id"`     // FK to roles(id) CASCADE
+	AssignedAt time.Time  `json:"assigned_at"`
+	AssignedBy *uuid.UUID `gorm:"type:uuid" json:"assigned_by,omitempty"` // FK to users(id) SET NULL
+	
+	Role *Role `gorm:"foreignKey:RoleID" json:"role,omitempty"`
+}
+
+// TableName returns the table name for UserRoleAssignment model
+func (UserRoleAssignment) TableName() string {
+	return "user_roles"
 }
 
 // RolePermission represents the many-to-many relationship between roles and permissions
 type RolePermission struct {
-	RoleID       uuid.UUID `gorm:"type:uuid;primary_key" json:"role_id"`
-	PermissionID uuid.UUID `gorm:"type:uuid;primary_key" json:"permission_id"`
-	GrantedAt    time.Time `json:"granted_at"`
-	GrantedBy    uuid.UUID `gorm:"type:uuid" json:"granted_by"`
+	RoleID       uuid.UUID  `gorm:"type:uuid;primary_key" json:"role_id"`       // FK to roles(id) CASCADE
+	PermissionID uuid.UUID  `gorm:"type:uuid;primary_key" json:"permission_id"` // FK to permissions(id) CASCADE
+	GrantedAt    time.Time  `json:"granted_at"`
+	GrantedBy    *uuid.UUID `gorm:"type:uuid" json:"granted_by,omitempty"` // FK to users(id) SET NULL
+}
+
+// TableName returns the table name for RolePermission model
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
+
+// Actor types of audit log entries
+const (
+	AuditActorAdmin  = "admin"  // An administrator's request through the admin API
+	AuditActorUser   = "user"   // The user the event happened to, e.g. a reset password
+	AuditActorSystem = "system" // The service itself, e.g. refusing a login; the client may be unknown
+)
+
+// AuditLogEntry is one link of the audit log hash chain - matches 027_add_audit_log.sql
+// Rows can't be changed or deleted; Hash covers every other column and PrevHash, the Hash of the entry at Seq-1
+type AuditLogEntry struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Seq        int64      `gorm:"uniqueIndex;not null" json:"seq"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index" json:"actor_id,omitempty"` // No foreign key; entries outlive accounts
+	ActorType  string     `gorm:"type:varchar(20);not null" json:"actor_type"`
+	Action     string     `gorm:"type:varchar(150);not null" json:"action"`
+	TargetType string     `gorm:"type:varchar(50)" json:"target_type,omitempty"`
+	TargetID   string     `gorm:"type:varchar(255)" json:"target_id,omitempty"`
+	Status     int        `json:"status,omitempty"`                                 // HTTP status of an admin request
+	Changes    string     `gorm:"type:jsonb;not null;default:'{}'" json:"-"`         // Field → AuditChange as JSON
+	Metadata   string     `gorm:"type:jsonb;not null;default:'{}'" json:"-"`
+	RequestID  string     `gorm:"type:varchar(128);index" json:"request_id,omitempty"`
+	IPAddress  *string    `gorm:"type:varchar(45)" json:"ip_address,omitempty"` // Full or truncated per privacy.ip_storage; NULL in hmac mode
+	IPHash     string     `gorm:"type:varchar(64)" json:"-"`
+	UserAgent  string     `gorm:"type:text" json:"user_agent,omitempty"`
+	PrevHash   string     `gorm:"type:varchar(64);not null" json:"prev_hash"`
+	Hash       string     `gorm:"type:varchar(64);not null" json:"hash"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for AuditLogEntry model
+func (AuditLogEntry) TableName() string {
+	return "audit_log"
+}
+
+// AuditLogFilter narrows audit log queries; zero fields match everything
+type AuditLogFilter struct {
+	ActorID    *uuid.UUID
+	TargetType string
+	TargetID   string
+	Action     string // Exact action, or a prefix ending in * (e.g. "POST /api/v1/admin/roles*")
+	RequestID  string
+	Since      *time.Time // Inclusive
+	Until      *time.Time // Exclusive
+}
+
+// AuditEvent is something to record in the audit log; the audit log chains it into an AuditLogEntry
+type AuditEvent struct {
+	ActorID    *uuid.UUID
+	ActorType  string
+	Action     string
+	TargetType string
+	TargetID   string
+	Status     int
+	Before     interface{} // Diffed with After into the entry's changes; nil for creations
+	After      interface{} // nil for deletions
+	Metadata   map[string]interface{}
+	Client     ClientInfo
 }
\ No newline at end of file
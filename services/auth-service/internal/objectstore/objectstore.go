@@ -0,0 +1,118 @@
+// Package objectstore keeps immutable objects, such as activity archives, in a directory or an
+// S3-compatible bucket. Objects are written once and only ever deleted
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"auth-service/internal/config"
+)
+
+// ErrNotFound is returned by Get for keys that have no object
+var ErrNotFound = errors.New("object not found")
+
+// Store writes, reads and deletes whole objects by key. Keys are slash-separated paths without "." or ".."
+// segments; the configured prefix is prepended to each
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the object at key; deleting a missing object succeeds
+	Delete(ctx context.Context, key string) error
+}
+
+// New opens the configured backend; a nil client uses one with storage.timeout for s3
+func New(cfg config.ObjectStorageConfig, client *http.Client) (Store, error) {
+	switch cfg.Backend {
+	case config.ObjectStorageFilesystem:
+		if err := os.MkdirAll(cfg.Directory, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create object storage directory: %w", err)
+		}
+		return &fileStore{root: cfg.Directory, prefix: cfg.Prefix}, nil
+	case config.ObjectStorageS3:
+		if client == nil {
+			client = &http.Client{Timeout: cfg.Timeout}
+		}
+		return &s3Store{client: client, config: cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown object storage backend: %s", cfg.Backend)
+}
+
+// validKey rejects keys that could leave the prefix
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid object key %q", key)
+		}
+	}
+	return nil
+}
+
+// fileStore keeps each object in a file under root
+type fileStore struct {
+	root   string
+	prefix string
+}
+
+func (s *fileStore) path(key string) (string, error) {
+	if err := validKey(s.prefix + key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(s.prefix+key)), nil
+}
+
+// Put writes the object to a temporary file and renames it, so readers never see a partial object
+func (s *fileStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *fileStore) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *fileStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
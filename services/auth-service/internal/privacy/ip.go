@@ -0,0 +1,87 @@
+// Package privacy applies the configured IP storage mode before client addresses are persisted
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+
+	"auth-service/internal/config"
+)
+
+// Truncation prefixes for the truncate storage mode
+const (
+	truncateIPv4Bits = 24
+	truncateIPv6Bits = 48
+)
+
+// IPAnonymizer turns a client address into the values stored in ip_address and ip_hash columns
+// Anything derived from the address (e.g. geo enrichment) must use the raw ClientInfo address before
+// it reaches the anonymizer. A nil IPAnonymizer stores addresses in full
+type IPAnonymizer struct {
+	mode string
+	key  []byte
+}
+
+// NewIPAnonymizer builds an anonymizer for the validated privacy configuration
+func NewIPAnonymizer(cfg config.PrivacyConfig) *IPAnonymizer {
+	return &IPAnonymizer{mode: cfg.IPStorage, key: []byte(cfg.IPHashKey)}
+}
+
+// Mode returns the IP storage mode
+func (a *IPAnonymizer) Mode() string {
+	if a == nil {
+		return config.IPStorageFull
+	}
+	return a.mode
+}
+
+// Address returns the value for an inet column: the full or truncated address
+// It is nil in hmac mode and for empty or unparsable addresses, which would be rejected by the column
+func (a *IPAnonymizer) Address(ip string) *string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil
+	}
+
+	switch a.Mode() {
+	case config.IPStorageHMAC:
+		return nil
+	case config.IPStorageTruncate:
+		truncated := Truncate(parsed).String()
+		return &truncated
+	default:
+		address := parsed.String()
+		return &address
+	}
+}
+
+// Hash returns the hex HMAC-SHA256 of the normalized address in hmac mode, and "" otherwise
+// Equal addresses hash equally, so hashed rows can still be grouped and searched by exact address
+func (a *IPAnonymizer) Hash(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || a.Mode() != config.IPStorageHMAC {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(parsed.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Truncate zeroes the host part of ip: IPv4 addresses keep their /24 network, IPv6 addresses their /48
+func Truncate(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(truncateIPv4Bits, 32))
+	}
+	return ip.Mask(net.CIDRMask(truncateIPv6Bits, 128))
+}
+
+// Network returns the network Truncate keeps of ip, e.g. 203.0.113.0/24
+func Network(ip net.IP) *net.IPNet {
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: Truncate(v4), Mask: net.CIDRMask(truncateIPv4Bits, 32)}
+	}
+	return &net.IPNet{IP: Truncate(ip), Mask: net.CIDRMask(truncateIPv6Bits, 128)}
+}
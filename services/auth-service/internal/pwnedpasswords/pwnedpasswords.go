@@ -0,0 +1,143 @@
+// Package pwnedpasswords looks passwords up in the Have I Been Pwned range API without disclosing them:
+// only the first 5 hex characters of the SHA-1 hash are sent, and the API returns the suffixes of every
+// breached hash sharing that prefix (k-anonymity). Ranges are cached in memory to limit outbound calls
+package pwnedpasswords
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+)
+
+// maxRangeSize bounds range responses; padded ranges are around 40 KB
+const maxRangeSize = 1 << 20
+
+// prefixLength is how many hex characters of the hash are sent
+const prefixLength = 5
+
+// Checker counts how often a password appears in known breaches
+type Checker struct {
+	client *http.Client
+	config config.BreachedPasswordsConfig
+
+	mu     sync.Mutex
+	ranges map[string]*hashRange
+}
+
+// hashRange is a cached API response: breach counts by hash suffix
+type hashRange struct {
+	counts    map[string]int
+	fetchedAt time.Time
+}
+
+// NewChecker creates the checker; a nil client uses one with breached_passwords.timeout
+func NewChecker(cfg config.BreachedPasswordsConfig, client *http.Client) *Checker {
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+	return &Checker{client: client, config: cfg, ranges: make(map[string]*hashRange)}
+}
+
+// Mode is breached_passwords.mode; a nil checker is off
+func (c *Checker) Mode() string {
+	if c == nil {
+		return config.BreachedPasswordsOff
+	}
+	return c.config.Mode
+}
+
+// Count returns how many times password appears in known breaches, 0 when it doesn't
+func (c *Checker) Count(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	counts, err := c.rangeOf(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	return counts[suffix], nil
+}
+
+// rangeOf returns the range of prefix from the cache or the API
+func (c *Checker) rangeOf(ctx context.Context, prefix string) (map[string]int, error) {
+	c.mu.Lock()
+	cached, ok := c.ranges[prefix]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < c.config.CacheTTL {
+		return cached.counts, nil
+	}
+
+	counts, err := c.fetch(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	c.store(prefix, &hashRange{counts: counts, fetchedAt: time.Now()})
+	return counts, nil
+}
+
+// store caches a range, evicting the oldest one when the cache is full
+func (c *Checker) store(prefix string, r *hashRange) {
+	if c.config.CacheSize <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.ranges[prefix]; !ok && len(c.ranges) >= c.config.CacheSize {
+		var oldest string
+		for cachedPrefix, cached := range c.ranges {
+			if oldest == "" || cached.fetchedAt.Before(c.ranges[oldest].fetchedAt) {
+				oldest = cachedPrefix
+			}
+		}
+		delete(c.ranges, oldest)
+	}
+	c.ranges[prefix] = r
+}
+
+// fetch requests the range of prefix. Add-Padding makes every response about the same size, so the
+// response length doesn't hint at the prefix either; padding entries have a count of 0 and are dropped
+func (c *Checker) fetch(ctx context.Context, prefix string) (map[string]int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.APIURL+prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "auth-service")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("breached password range request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("breached password range request returned %s", resp.Status)
+	}
+
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxRangeSize))
+	for scanner.Scan() {
+		suffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(count); err == nil && n > 0 {
+			counts[strings.ToUpper(suffix)] = n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password range: %w", err)
+	}
+	return counts, nil
+}
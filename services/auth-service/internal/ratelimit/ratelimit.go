@@ -0,0 +1,234 @@
+// Package ratelimit applies the Traefik gateway's rate limits inside the service, for runs without the
+// gateway such as local development. The limits are read from the gateway's own dynamic configuration,
+// so both enforce the same definitions on the same paths
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix prefixes the Redis keys holding each client's next allowed request time
+// (rate_limit:<router>:<middleware>:<client address>)
+const keyPrefix = "rate_limit:"
+
+// Limit is one Traefik rateLimit middleware: Average requests per Period on average, up to Burst at once
+type Limit struct {
+	Name    string
+	Average int64
+	Burst   int64
+	Period  time.Duration
+}
+
+// Rule is a Traefik router to this service: requests matching its paths pass its limits in order
+type Rule struct {
+	Router   string
+	Priority int
+	Paths    []string // Exact paths (Path matcher)
+	Prefixes []string // Path prefixes (PathPrefix matcher)
+	Limits   []Limit
+}
+
+// matches reports whether the router would take a request for path
+func (r Rule) matches(path string) bool {
+	for _, p := range r.Paths {
+		if path == p {
+			return true
+		}
+	}
+	for _, prefix := range r.Prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dynamicConfig is the part of a Traefik dynamic configuration file the rules are read from
+type dynamicConfig struct {
+	HTTP struct {
+		Middlewares map[string]struct {
+			RateLimit *struct {
+				Average int64  `toml:"average"`
+				Burst   int64  `toml:"burst"`
+				Period  string `toml:"period"`
+			} `toml:"rateLimit"`
+		} `toml:"middlewares"`
+		Routers map[string]struct {
+			Rule        string   `toml:"rule"`
+			Middlewares []string `toml:"middlewares"`
+			Priority    int      `toml:"priority"`
+		} `toml:"routers"`
+	} `toml:"http"`
+}
+
+// LoadRules reads the named routers and the rateLimit middlewares they use from a Traefik dynamic
+// configuration file. Router rules may only combine Host, Path and PathPrefix matchers with &&
+func LoadRules(path string, routers []string) ([]Rule, error) {
+	var dynamic dynamicConfig
+	if _, err := toml.DecodeFile(path, &dynamic); err != nil {
+		return nil, fmt.Errorf("failed to read gateway configuration %s: %w", path, err)
+	}
+
+	rules := make([]Rule, 0, len(routers))
+	for _, name := range routers {
+		router, ok := dynamic.HTTP.Routers[name]
+		if !ok {
+			return nil, fmt.Errorf("router %s is not defined in %s", name, path)
+		}
+		rule := Rule{Router: name, Priority: router.Priority}
+		if rule.Priority == 0 {
+			rule.Priority = len(router.Rule) // Traefik's default: longer rules first
+		}
+		if err := parseMatchers(router.Rule, &rule); err != nil {
+			return nil, fmt.Errorf("router %s: %w", name, err)
+		}
+
+		for _, middleware := range router.Middlewares {
+			definition, ok := dynamic.HTTP.Middlewares[middleware]
+			if !ok || definition.RateLimit == nil || definition.RateLimit.Average <= 0 {
+				continue // Not a rate limit, or one without a limit
+			}
+			limit := Limit{
+				Name:    middleware,
+				Average: definition.RateLimit.Average,
+				Burst:   max(definition.RateLimit.Burst, 1),
+				Period:  time.Second,
+			}
+			if definition.RateLimit.Period != "" {
+				period, err := time.ParseDuration(definition.RateLimit.Period)
+				if err != nil || period <= 0 {
+					return nil, fmt.Errorf("middleware %s: invalid period %q", middleware, definition.RateLimit.Period)
+				}
+				limit.Period = period
+			}
+			rule.Limits = append(rule.Limits, limit)
+		}
+		rules = append(rules, rule)
+	}
+
+	// A request is taken by the matching router of highest priority, like Traefik does
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority > rules[j].Priority })
+	return rules, nil
+}
+
+// parseMatchers adds the Path and PathPrefix matchers of a router rule to rule; Host matchers are
+// ignored since every request reaching the service is for it
+func parseMatchers(expression string, rule *Rule) error {
+	for _, term := range strings.Split(expression, "&&") {
+		term = strings.TrimSpace(term)
+		name, arg, ok := strings.Cut(term, "(")
+		if !ok || !strings.HasSuffix(arg, ")") {
+			return fmt.Errorf("unsupported rule %q", expression)
+		}
+		value := strings.Trim(strings.TrimSuffix(arg, ")"), "`\"")
+		switch name {
+		case "Host":
+		case "Path":
+			rule.Paths = append(rule.Paths, value)
+		case "PathPrefix":
+			rule.Prefixes = append(rule.Prefixes, value)
+		default:
+			return fmt.Errorf("unsupported matcher %s in rule %q", name, expression)
+		}
+	}
+	if len(rule.Paths) == 0 && len(rule.Prefixes) == 0 {
+		return fmt.Errorf("rule %q has no Path or PathPrefix matcher", expression)
+	}
+	return nil
+}
+
+// allowScript is a generic cell rate algorithm, equivalent to a token bucket of burst tokens refilled
+// every interval: it stores the time the client's bucket is full again and refuses requests that would
+// push it beyond burst intervals ahead. Redis time keeps replicas in agreement
+// KEYS[1] = bucket, ARGV[1] = interval in microseconds, ARGV[2] = burst
+// Returns {microseconds until the next request would be allowed (0 when allowed), requests left,
+// microseconds until the bucket is full}
+var allowScript = redis.NewScript(`
+local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000000 + tonumber(now[2])
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local excess = new_tat - now - interval * burst
+if excess > 0 then
+	return {excess, 0, tat - now}
+end
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil((new_tat - now) / 1000))
+return {0, math.floor((interval * burst - (new_tat - now)) / interval), new_tat - now}
+`)
+
+// Limiter enforces the rules per client address with buckets in Redis, shared by every replica
+type Limiter struct {
+	redis *redis.Client
+	rules []Rule
+}
+
+// NewLimiter creates a limiter for rules as returned by LoadRules
+func NewLimiter(client *redis.Client, rules []Rule) *Limiter {
+	return &Limiter{redis: client, rules: rules}
+}
+
+// Decision is the state of the limit closest to refusing a request, or of the one that refused it
+type Decision struct {
+	Limit      Limit
+	Allowed    bool
+	Remaining  int64         // Requests the client can still make at once
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next request would be allowed, when refused
+}
+
+// Policy describes the limit as a RateLimit-Policy header value: average per window, with the burst
+func (d *Decision) Policy() string {
+	return fmt.Sprintf("%d;w=%d;burst=%d", d.Limit.Average, d.Limit.WindowSeconds(), d.Limit.Burst)
+}
+
+// WindowSeconds is the limit's period in whole seconds, at least 1
+func (l Limit) WindowSeconds() int {
+	return max(1, int(math.Ceil(l.Period.Seconds())))
+}
+
+// Allow takes a request for path from client from the buckets of the matching rule's limits, in order,
+// stopping at the first that refuses it. The decision is nil when no rule limits the path
+func (l *Limiter) Allow(ctx context.Context, path, client string) (*Decision, error) {
+	for _, rule := range l.rules {
+		if !rule.matches(path) {
+			continue
+		}
+		var closest *Decision
+		for _, limit := range rule.Limits {
+			interval := limit.Period.Microseconds() / limit.Average
+			key := keyPrefix + rule.Router + ":" + limit.Name + ":" + client
+			result, err := allowScript.Run(ctx, l.redis, []string{key}, max(interval, 1), limit.Burst).Int64Slice()
+			if err != nil {
+				return nil, fmt.Errorf("rate limit %s: %w", limit.Name, err)
+			}
+			decision := &Decision{
+				Limit:      limit,
+				Allowed:    result[0] == 0,
+				Remaining:  result[1],
+				Reset:      time.Duration(result[2]) * time.Microsecond,
+				RetryAfter: time.Duration(result[0]) * time.Microsecond,
+			}
+			if !decision.Allowed {
+				return decision, nil
+			}
+			if closest == nil || decision.Remaining < closest.Remaining {
+				closest = decision
+			}
+		}
+		return closest, nil // Only the first matching router takes the request
+	}
+	return nil, nil
+}
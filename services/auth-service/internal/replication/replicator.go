@@ -0,0 +1,380 @@
+// Package replication keeps sessions in sync between the regions of an active-active deployment.
+// Session changes are published to a Redis stream every region shares; each region reads the stream
+// through its own consumer group and applies the other regions' changes to its database and Redis
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/repositories"
+
+	"github.com/redis/go-redis/v9"
+	"shared/health"
+)
+
+// queueSize bounds the events waiting to be published; beyond it events are dropped and counted
+const queueSize = 10000
+
+// retryBackoff bounds the wait after a failed read or apply
+const (
+	minRetryBackoff = time.Second
+	maxRetryBackoff = 30 * time.Second
+)
+
+// Apply outcomes reported by auth_service_session_replication_events_total
+const (
+	ResultApplied     = "applied"
+	ResultSuperseded  = "superseded"   // Revoked here first; revocation wins
+	ResultUserMissing = "user_missing" // The session's user isn't in this region's database
+	ResultOwnRegion   = "own_region"   // Published by this region
+	ResultMalformed   = "malformed"
+)
+
+// eventField is the stream entry field holding the JSON event
+const eventField = "event"
+
+// eventKey labels apply outcomes
+type eventKey struct {
+	eventType string
+	result    string
+}
+
+// Replicator publishes this region's session events and applies the other regions' ones
+type Replicator struct {
+	client   *redis.Client
+	applier  repositories.SessionEventApplier
+	config   config.SessionReplicationConfig
+	group    string
+	consumer string
+	queue    chan *repositories.SessionEvent
+
+	mu              sync.Mutex
+	published       map[string]uint64
+	publishFailures uint64
+	dropped         uint64
+	events          map[eventKey]uint64
+	lag             map[string]float64 // Seconds between publishing and applying the latest event, by source region
+	caughtUpAt      time.Time
+}
+
+// New creates a replicator for the cross-region stream in cfg; applier writes to this region's stores
+func New(cfg config.SessionReplicationConfig, applier repositories.SessionEventApplier) (*Replicator, error) {
+	options, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session_replication.redis_url: %w", err)
+	}
+	if cfg.RedisPassword != "" {
+		options.Password = cfg.RedisPassword
+	}
+
+	consumer, err := os.Hostname()
+	if err != nil || consumer == "" {
+		consumer = fmt.Sprintf("%s-%d", cfg.Region, os.Getpid())
+	}
+
+	return &Replicator{
+		client:    redis.NewClient(options),
+		applier:   applier,
+		config:    cfg,
+		group:     "region:" + cfg.Region,
+		consumer:  consumer,
+		queue:     make(chan *repositories.SessionEvent, queueSize),
+		published: make(map[string]uint64),
+		events:    make(map[eventKey]uint64),
+		lag:       make(map[string]float64),
+	}, nil
+}
+
+// PublishSessionEvent queues an event for the other regions without waiting on the cross-region Redis
+func (r *Replicator) PublishSessionEvent(event *repositories.SessionEvent) {
+	event.Region = r.config.Region
+	select {
+	case r.queue <- event:
+	default:
+		r.mu.Lock()
+		r.dropped++
+		r.mu.Unlock()
+		log.Printf("🚨 Session replication queue full, dropped %s event", event.Type)
+	}
+}
+
+// Start publishes queued events and applies other regions' events until ctx is cancelled
+func (r *Replicator) Start(ctx context.Context) {
+	log.Printf("🔧 Session replication enabled for region %s on stream %s", r.config.Region, r.config.Stream)
+	go r.publishLoop(ctx)
+	go r.consumeLoop(ctx)
+}
+
+// Close releases the cross-region Redis connection
+func (r *Replicator) Close() error {
+	return r.client.Close()
+}
+
+// publishLoop adds queued events to the stream, trimmed to about session_replication.max_len entries
+func (r *Replicator) publishLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-r.queue:
+			data, err := json.Marshal(event)
+			if err == nil {
+				err = r.client.XAdd(ctx, &redis.XAddArgs{
+					Stream: r.config.Stream,
+					MaxLen: r.config.MaxLen,
+					Approx: true,
+					Values: map[string]interface{}{eventField: data},
+				}).Err()
+			}
+
+			r.mu.Lock()
+			if err != nil {
+				r.publishFailures++
+			} else {
+				r.published[event.Type]++
+			}
+			r.mu.Unlock()
+			if err != nil && ctx.Err() == nil {
+				log.Printf("⚠️  Failed to publish %s session event: %v", event.Type, err)
+			}
+		}
+	}
+}
+
+// consumeLoop reads the stream through this region's consumer group. Unacknowledged events of this
+// consumer are retried first, so a failed apply is never skipped
+func (r *Replicator) consumeLoop(ctx context.Context) {
+	backoff := minRetryBackoff
+	retry := func(format string, args ...interface{}) bool {
+		log.Printf("⚠️  Session replication: "+format+"; retrying in %s", append(args, backoff)...)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+		return true
+	}
+
+	for ctx.Err() == nil {
+		err := r.client.XGroupCreateMkStream(ctx, r.config.Stream, r.group, "$").Err()
+		if err == nil || strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			break
+		}
+		if !retry("failed to create consumer group %s: %v", r.group, err) {
+			return
+		}
+	}
+
+	cursor := "0"
+	for ctx.Err() == nil {
+		streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    r.group,
+			Consumer: r.consumer,
+			Streams:  []string{r.config.Stream, cursor},
+			Count:    r.config.BatchSize,
+			Block:    r.config.BlockTimeout,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			r.markCaughtUp()
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil || !retry("failed to read %s: %v", r.config.Stream, err) {
+				return
+			}
+			continue
+		}
+
+		var messages []redis.XMessage
+		if len(streams) > 0 {
+			messages = streams[0].Messages
+		}
+		if cursor == "0" && len(messages) == 0 {
+			cursor = ">" // Pending events are done; read new ones
+			continue
+		}
+
+		for _, message := range messages {
+			if err := r.handle(ctx, message); err != nil {
+				cursor = "0"
+				if !retry("failed to apply event %s: %v", message.ID, err) {
+					return
+				}
+				break
+			}
+			if err := r.client.XAck(ctx, r.config.Stream, r.group, message.ID).Err(); err != nil {
+				log.Printf("⚠️  Failed to acknowledge session event %s: %v", message.ID, err)
+			}
+			backoff = minRetryBackoff
+		}
+	}
+}
+
+// handle applies one stream entry; an error leaves it unacknowledged to be retried
+func (r *Replicator) handle(ctx context.Context, message redis.XMessage) error {
+	raw, _ := message.Values[eventField].(string)
+	var event repositories.SessionEvent
+	if err := json.Unmarshal([]byte(raw), &event); err != nil || event.Type == "" {
+		log.Printf("⚠️  Skipping malformed session event %s", message.ID)
+		r.record(&event, ResultMalformed)
+		return nil
+	}
+	if event.Region == r.config.Region {
+		r.record(&event, ResultOwnRegion)
+		return nil
+	}
+
+	applied, err := r.applier.ApplySessionEvent(ctx, &event)
+	switch {
+	case errors.Is(err, repositories.ErrSessionUserMissing):
+		r.record(&event, ResultUserMissing)
+	case err != nil:
+		return err
+	case applied:
+		r.record(&event, ResultApplied)
+	default:
+		r.record(&event, ResultSuperseded)
+	}
+	return nil
+}
+
+// record counts an event and, for other regions' events, the lag since it was published
+func (r *Replicator) record(event *repositories.SessionEvent, result string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[eventKey{eventType: event.Type, result: result}]++
+	if result != ResultOwnRegion && result != ResultMalformed && !event.At.IsZero() {
+		r.lag[event.Region] = max(time.Since(event.At).Seconds(), 0)
+	}
+}
+
+// markCaughtUp clears the lag once the stream has no new events for this region
+func (r *Replicator) markCaughtUp() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for region := range r.lag {
+		r.lag[region] = 0
+	}
+	r.caughtUpAt = time.Now()
+}
+
+// Lag returns the replication lag by source region
+func (r *Replicator) Lag() map[string]time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lag := make(map[string]time.Duration, len(r.lag))
+	for region, seconds := range r.lag {
+		lag[region] = time.Duration(seconds * float64(time.Second))
+	}
+	return lag
+}
+
+// HealthCheck reports replication as degraded while any region lags more than session_replication.max_lag
+// or events are being dropped; logins keep working in this region either way
+func (r *Replicator) HealthCheck() health.Check {
+	return func(ctx context.Context) health.CheckResult {
+		lag := r.Lag()
+		r.mu.Lock()
+		dropped, caughtUpAt := r.dropped, r.caughtUpAt
+		r.mu.Unlock()
+
+		metadata := map[string]interface{}{"region": r.config.Region, "dropped_events": dropped}
+		var lagging []string
+		for region, behind := range lag {
+			metadata["lag_seconds_"+region] = behind.Seconds()
+			if behind > r.config.MaxLag {
+				lagging = append(lagging, region)
+			}
+		}
+		if !caughtUpAt.IsZero() {
+			metadata["caught_up_at"] = caughtUpAt
+		}
+
+		if len(lagging) > 0 {
+			sort.Strings(lagging)
+			return health.CheckResult{
+				Status:   health.StatusDegraded,
+				Message:  fmt.Sprintf("session replication from %s is behind", strings.Join(lagging, ", ")),
+				Metadata: metadata,
+			}
+		}
+		if dropped > 0 {
+			return health.CheckResult{
+				Status:   health.StatusDegraded,
+				Message:  "session events were dropped before reaching the other regions",
+				Metadata: metadata,
+			}
+		}
+		return health.CheckResult{Status: health.StatusHealthy, Metadata: metadata}
+	}
+}
+
+// WritePrometheus writes the replication metrics in the Prometheus text format
+func (r *Replicator) WritePrometheus(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	types := make([]string, 0, len(r.published))
+	for eventType := range r.published {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+
+	fmt.Fprintln(w, "# HELP auth_service_session_replication_published_total Session events published to the other regions")
+	fmt.Fprintln(w, "# TYPE auth_service_session_replication_published_total counter")
+	for _, eventType := range types {
+		fmt.Fprintf(w, "auth_service_session_replication_published_total{type=%q} %d\n", eventType, r.published[eventType])
+	}
+
+	fmt.Fprintln(w, "# HELP auth_service_session_replication_publish_failures_total Session events that failed to publish")
+	fmt.Fprintln(w, "# TYPE auth_service_session_replication_publish_failures_total counter")
+	fmt.Fprintf(w, "auth_service_session_replication_publish_failures_total %d\n", r.publishFailures)
+
+	fmt.Fprintln(w, "# HELP auth_service_session_replication_dropped_total Session events dropped because the publish queue was full")
+	fmt.Fprintln(w, "# TYPE auth_service_session_replication_dropped_total counter")
+	fmt.Fprintf(w, "auth_service_session_replication_dropped_total %d\n", r.dropped)
+
+	keys := make([]eventKey, 0, len(r.events))
+	for key := range r.events {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].eventType != keys[j].eventType {
+			return keys[i].eventType < keys[j].eventType
+		}
+		return keys[i].result < keys[j].result
+	})
+
+	fmt.Fprintln(w, "# HELP auth_service_session_replication_events_total Session events read from the stream by outcome")
+	fmt.Fprintln(w, "# TYPE auth_service_session_replication_events_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(w, "auth_service_session_replication_events_total{type=%q,result=%q} %d\n", key.eventType, key.result, r.events[key])
+	}
+
+	regions := make([]string, 0, len(r.lag))
+	for region := range r.lag {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	fmt.Fprintln(w, "# HELP auth_service_session_replication_lag_seconds Time between another region publishing a session event and this region applying it")
+	fmt.Fprintln(w, "# TYPE auth_service_session_replication_lag_seconds gauge")
+	for _, region := range regions {
+		fmt.Fprintf(w, "auth_service_session_replication_lag_seconds{source_region=%q} %g\n", region, r.lag[region])
+	}
+}
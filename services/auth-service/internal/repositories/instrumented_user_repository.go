@@ -0,0 +1,548 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"auth-service/internal/instrumentation"
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// instrumentedUserRepository decorates a UserRepository with per-query latency and error reporting
+type instrumentedUserRepository struct {
+	next     UserRepository
+	observer instrumentation.Observer
+}
+
+// NewInstrumentedUserRepository wraps UserRepository so every call reports latency and errors to observer
+func NewInstrumentedUserRepository(next UserRepository, observer instrumentation.Observer) UserRepository {
+	return &instrumentedUserRepository{next: next, observer: observer}
+}
+
+// observe reports a finished call; deferred with the named error result
+func (d *instrumentedUserRepository) observe(method string, start time.Time, err *error) {
+	var callErr error
+	if err != nil {
+		callErr = *err
+	}
+	d.observer.ObserveCall("UserRepository", method, time.Since(start), callErr)
+}
+
+func (d *instrumentedUserRepository) Create(user *models.User) (err error) {
+	defer d.observe("Create", time.Now(), &err)
+	return d.next.Create(user)
+}
+
+func (d *instrumentedUserRepository) GetByID(id uuid.UUID) (user *models.User, err error) {
+	defer d.observe("GetByID", time.Now(), &err)
+	return d.next.GetByID(id)
+}
+
+func (d *instrumentedUserRepository) GetByEmail(email string) (user *models.User, err error) {
+	defer d.observe("GetByEmail", time.Now(), &err)
+	return d.next.GetByEmail(email)
+}
+
+func (d *instrumentedUserRepository) GetByUsername(username string) (user *models.User, err error) {
+	defer d.observe("GetByUsername", time.Now(), &err)
+	return d.next.GetByUsername(username)
+}
+
+func (d *instrumentedUserRepository) GetByOAuthID(provider, oauthID string) (user *models.User, err error) {
+	defer d.observe("GetByOAuthID", time.Now(), &err)
+	return d.next.GetByOAuthID(provider, oauthID)
+}
+
+func (d *instrumentedUserRepository) LinkOAuthIdentity(userID uuid.UUID, provider, oauthID string) (secured bool, err error) {
+	defer d.observe("LinkOAuthIdentity", time.Now(), &err)
+	return d.next.LinkOAuthIdentity(userID, provider, oauthID)
+}
+
+func (d *instrumentedUserRepository) AttachOAuthIdentity(userID uuid.UUID, provider, oauthID string) (err error) {
+	defer d.observe("AttachOAuthIdentity", time.Now(), &err)
+	return d.next.AttachOAuthIdentity(userID, provider, oauthID)
+}
+
+func (d *instrumentedUserRepository) UnlinkOAuthIdentity(userID uuid.UUID, provider string) (err error) {
+	defer d.observe("UnlinkOAuthIdentity", time.Now(), &err)
+	return d.next.UnlinkOAuthIdentity(userID, provider)
+}
+
+func (d *instrumentedUserRepository) Update(user *models.User) (err error) {
+	defer d.observe("Update", time.Now(), &err)
+	return d.next.Update(user)
+}
+
+func (d *instrumentedUserRepository) Delete(userID uuid.UUID) (err error) {
+	defer d.observe("Delete", time.Now(), &err)
+	return d.next.Delete(userID)
+}
+
+func (d *instrumentedUserRepository) UpdateLastLogin(userID uuid.UUID, ipAddress string) (err error) {
+	defer d.observe("UpdateLastLogin", time.Now(), &err)
+	return d.next.UpdateLastLogin(userID, ipAddress)
+}
+
+func (d *instrumentedUserRepository) IncrementFailedAttempts(userID uuid.UUID) (err error) {
+	defer d.observe("IncrementFailedAttempts", time.Now(), &err)
+	return d.next.IncrementFailedAttempts(userID)
+}
+
+func (d *instrumentedUserRepository) ResetFailedAttempts(userID uuid.UUID) (err error) {
+	defer d.observe("ResetFailedAttempts", time.Now(), &err)
+	return d.next.ResetFailedAttempts(userID)
+}
+
+func (d *instrumentedUserRepository) CreateLoginAttempt(attempt *models.LoginAttempt) (err error) {
+	defer d.observe("CreateLoginAttempt", time.Now(), &err)
+	return d.next.CreateLoginAttempt(attempt)
+}
+
+func (d *instrumentedUserRepository) HasSignedInFrom(userID uuid.UUID, device models.SignInDevice, since time.Time) (seen bool, err error) {
+	defer d.observe("HasSignedInFrom", time.Now(), &err)
+	return d.next.HasSignedInFrom(userID, device, since)
+}
+
+func (d *instrumentedUserRepository) ListLoginAttempts(userID uuid.UUID, limit, offset int) (attempts []models.LoginAttempt, total int64, err error) {
+	defer d.observe("ListLoginAttempts", time.Now(), &err)
+	return d.next.ListLoginAttempts(userID, limit, offset)
+}
+
+func (d *instrumentedUserRepository) IsEmailTaken(email string) (taken bool, err error) {
+	defer d.observe("IsEmailTaken", time.Now(), &err)
+	return d.next.IsEmailTaken(email)
+}
+
+func (d *instrumentedUserRepository) IsUsernameTaken(username string) (taken bool, err error) {
+	defer d.observe("IsUsernameTaken", time.Now(), &err)
+	return d.next.IsUsernameTaken(username)
+}
+
+func (d *instrumentedUserRepository) GetUserPreferences(userID uuid.UUID) (prefs *models.UserPreference, err error) {
+	defer d.observe("GetUserPreferences", time.Now(), &err)
+	return d.next.GetUserPreferences(userID)
+}
+
+func (d *instrumentedUserRepository) CreateUserPreferences(prefs *models.UserPreference) (err error) {
+	defer d.observe("CreateUserPreferences", time.Now(), &err)
+	return d.next.CreateUserPreferences(prefs)
+}
+
+func (d *instrumentedUserRepository) UpdateUserPreferences(prefs *models.UserPreference) (err error) {
+	defer d.observe("UpdateUserPreferences", time.Now(), &err)
+	return d.next.UpdateUserPreferences(prefs)
+}
+
+func (d *instrumentedUserRepository) UpdateProfile(userID uuid.UUID, fields map[string]interface{}) (err error) {
+	defer d.observe("UpdateProfile", time.Now(), &err)
+	return d.next.UpdateProfile(userID, fields)
+}
+
+func (d *instrumentedUserRepository) CountUserActivities(userID uuid.UUID) (count int64, err error) {
+	defer d.observe("CountUserActivities", time.Now(), &err)
+	return d.next.CountUserActivities(userID)
+}
+
+func (d *instrumentedUserRepository) GetUserActivities(userID uuid.UUID, limit, offset int) (activities []models.UserActivity, err error) {
+	defer d.observe("GetUserActivities", time.Now(), &err)
+	return d.next.GetUserActivities(userID, limit, offset)
+}
+
+func (d *instrumentedUserRepository) CreateUserActivity(activity *models.UserActivity) (err error) {
+	defer d.observe("CreateUserActivity", time.Now(), &err)
+	return d.next.CreateUserActivity(activity)
+}
+
+func (d *instrumentedUserRepository) GetUserNotifications(userID uuid.UUID) (notifications []models.UserNotification, err error) {
+	defer d.observe("GetUserNotifications", time.Now(), &err)
+	return d.next.GetUserNotifications(userID)
+}
+
+func (d *instrumentedUserRepository) CountUnreadNotifications(userID uuid.UUID) (count int64, err error) {
+	defer d.observe("CountUnreadNotifications", time.Now(), &err)
+	return d.next.CountUnreadNotifications(userID)
+}
+
+func (d *instrumentedUserRepository) CreateUserNotification(notification *models.UserNotification) (err error) {
+	defer d.observe("CreateUserNotification", time.Now(), &err)
+	return d.next.CreateUserNotification(notification)
+}
+
+func (d *instrumentedUserRepository) MarkNotificationAsRead(userID, notificationID uuid.UUID) (err error) {
+	defer d.observe("MarkNotificationAsRead", time.Now(), &err)
+	return d.next.MarkNotificationAsRead(userID, notificationID)
+}
+
+func (d *instrumentedUserRepository) ListTwoFactorStatus(roles []models.UserRole) (statuses []models.TwoFactorStatus, err error) {
+	defer d.observe("ListTwoFactorStatus", time.Now(), &err)
+	return d.next.ListTwoFactorStatus(roles)
+}
+
+func (d *instrumentedUserRepository) CreateInvitedUser(user *models.User) (err error) {
+	defer d.observe("CreateInvitedUser", time.Now(), &err)
+	return d.next.CreateInvitedUser(user)
+}
+
+func (d *instrumentedUserRepository) GetPendingInvitation(userID uuid.UUID) (user *models.User, err error) {
+	defer d.observe("GetPendingInvitation", time.Now(), &err)
+	return d.next.GetPendingInvitation(userID)
+}
+
+func (d *instrumentedUserRepository) SetFeedTokenHash(userID uuid.UUID, hash *string) (err error) {
+	defer d.observe("SetFeedTokenHash", time.Now(), &err)
+	return d.next.SetFeedTokenHash(userID, hash)
+}
+
+func (d *instrumentedUserRepository) GetByFeedTokenHash(hash string) (user *models.User, err error) {
+	defer d.observe("GetByFeedTokenHash", time.Now(), &err)
+	return d.next.GetByFeedTokenHash(hash)
+}
+
+func (d *instrumentedUserRepository) CreatePendingRegistration(user *models.User) (err error) {
+	defer d.observe("CreatePendingRegistration", time.Now(), &err)
+	return d.next.CreatePendingRegistration(user)
+}
+
+func (d *instrumentedUserRepository) ListPendingRegistrations(limit, offset int) (users []models.User, total int64, err error) {
+	defer d.observe("ListPendingRegistrations", time.Now(), &err)
+	return d.next.ListPendingRegistrations(limit, offset)
+}
+
+func (d *instrumentedUserRepository) GetRegistrationByEmail(email string) (user *models.User, err error) {
+	defer d.observe("GetRegistrationByEmail", time.Now(), &err)
+	return d.next.GetRegistrationByEmail(email)
+}
+
+func (d *instrumentedUserRepository) ReviewRegistration(userID, adminID uuid.UUID, status string) (user *models.User, err error) {
+	defer d.observe("ReviewRegistration", time.Now(), &err)
+	return d.next.ReviewRegistration(userID, adminID, status)
+}
+
+func (d *instrumentedUserRepository) CreateRoleGrant(grant *models.RoleGrant) (err error) {
+	defer d.observe("CreateRoleGrant", time.Now(), &err)
+	return d.next.CreateRoleGrant(grant)
+}
+
+func (d *instrumentedUserRepository) GetActiveRoleGrants(userID uuid.UUID, now time.Time) (grants []models.RoleGrant, err error) {
+	defer d.observe("GetActiveRoleGrants", time.Now(), &err)
+	return d.next.GetActiveRoleGrants(userID, now)
+}
+
+func (d *instrumentedUserRepository) ListRoleGrants(userID uuid.UUID, activeOnly bool, limit, offset int) (grants []models.RoleGrant, total int64, err error) {
+	defer d.observe("ListRoleGrants", time.Now(), &err)
+	return d.next.ListRoleGrants(userID, activeOnly, limit, offset)
+}
+
+func (d *instrumentedUserRepository) EndRoleGrant(grantID uuid.UUID, endedBy *uuid.UUID, reason string) (grant *models.RoleGrant, err error) {
+	defer d.observe("EndRoleGrant", time.Now(), &err)
+	return d.next.EndRoleGrant(grantID, endedBy, reason)
+}
+
+func (d *instrumentedUserRepository) EndExpiredRoleGrants(now time.Time) (grants []models.RoleGrant, err error) {
+	defer d.observe("EndExpiredRoleGrants", time.Now(), &err)
+	return d.next.EndExpiredRoleGrants(now)
+}
+
+func (d *instrumentedUserRepository) GetTokenVersion(userID uuid.UUID) (version int, err error) {
+	defer d.observe("GetTokenVersion", time.Now(), &err)
+	return d.next.GetTokenVersion(userID)
+}
+
+func (d *instrumentedUserRepository) ListRoles() (roles []models.Role, err error) {
+	defer d.observe("ListRoles", time.Now(), &err)
+	return d.next.ListRoles()
+}
+
+func (d *instrumentedUserRepository) GetRole(roleID uuid.UUID) (role *models.Role, err error) {
+	defer d.observe("GetRole", time.Now(), &err)
+	return d.next.GetRole(roleID)
+}
+
+func (d *instrumentedUserRepository) GetRoleByName(name string) (role *models.Role, err error) {
+	defer d.observe("GetRoleByName", time.Now(), &err)
+	return d.next.GetRoleByName(name)
+}
+
+func (d *instrumentedUserRepository) CreateRole(role *models.Role, permissionIDs []uuid.UUID, grantedBy *uuid.UUID) (err error) {
+	defer d.observe("CreateRole", time.Now(), &err)
+	return d.next.CreateRole(role, permissionIDs, grantedBy)
+}
+
+func (d *instrumentedUserRepository) UpdateRole(roleID uuid.UUID, updates map[string]interface{}) (role *models.Role, err error) {
+	defer d.observe("UpdateRole", time.Now(), &err)
+	return d.next.UpdateRole(roleID, updates)
+}
+
+func (d *instrumentedUserRepository) DeleteRole(roleID uuid.UUID) (role *models.Role, err error) {
+	defer d.observe("DeleteRole", time.Now(), &err)
+	return d.next.DeleteRole(roleID)
+}
+
+func (d *instrumentedUserRepository) SetRolePermissions(roleID uuid.UUID, permissionIDs []uuid.UUID, grantedBy *uuid.UUID) (err error) {
+	defer d.observe("SetRolePermissions", time.Now(), &err)
+	return d.next.SetRolePermissions(roleID, permissionIDs, grantedBy)
+}
+
+func (d *instrumentedUserRepository) ListPermissions() (permissions []models.Permission, err error) {
+	defer d.observe("ListPermissions", time.Now(), &err)
+	return d.next.ListPermissions()
+}
+
+func (d *instrumentedUserRepository) GetPermissionsByName(names []string) (permissions []models.Permission, err error) {
+	defer d.observe("GetPermissionsByName", time.Now(), &err)
+	return d.next.GetPermissionsByName(names)
+}
+
+func (d *instrumentedUserRepository) CreatePermission(permission *models.Permission) (err error) {
+	defer d.observe("CreatePermission", time.Now(), &err)
+	return d.next.CreatePermission(permission)
+}
+
+func (d *instrumentedUserRepository) DeletePermission(permissionID uuid.UUID) (permission *models.Permission, err error) {
+	defer d.observe("DeletePermission", time.Now(), &err)
+	return d.next.DeletePermission(permissionID)
+}
+
+func (d *instrumentedUserRepository) ListUserRoles(userID uuid.UUID) (assignments []models.UserRoleAssignment, err error) {
+	defer d.observe("ListUserRoles", time.Now(), &err)
+	return d.next.ListUserRoles(userID)
+}
+
+func (d *instrumentedUserRepository) AssignRole(assignment *models.UserRoleAssignment) (err error) {
+	defer d.observe("AssignRole", time.Now(), &err)
+	return d.next.AssignRole(assignment)
+}
+
+func (d *instrumentedUserRepository) UnassignRole(userID, roleID uuid.UUID) (err error) {
+	defer d.observe("UnassignRole", time.Now(), &err)
+	return d.next.UnassignRole(userID, roleID)
+}
+
+func (d *instrumentedUserRepository) GetTokenRoles(userID uuid.UUID, systemRoles []string) (roles, permissions []string, err error) {
+	defer d.observe("GetTokenRoles", time.Now(), &err)
+	return d.next.GetTokenRoles(userID, systemRoles)
+}
+
+func (d *instrumentedUserRepository) CreatePasswordReset(reset *models.PasswordReset) (err error) {
+	defer d.observe("CreatePasswordReset", time.Now(), &err)
+	return d.next.CreatePasswordReset(reset)
+}
+
+func (d *instrumentedUserRepository) CompletePasswordReset(userID uuid.UUID, tokenHash, passwordHash string, now time.Time) (err error) {
+	defer d.observe("CompletePasswordReset", time.Now(), &err)
+	return d.next.CompletePasswordReset(userID, tokenHash, passwordHash, now)
+}
+
+func (d *instrumentedUserRepository) CreateHoneypot(honeypot *models.Honeypot) (err error) {
+	defer d.observe("CreateHoneypot", time.Now(), &err)
+	return d.next.CreateHoneypot(honeypot)
+}
+
+func (d *instrumentedUserRepository) ListHoneypots(includeDisabled bool) (honeypots []models.Honeypot, err error) {
+	defer d.observe("ListHoneypots", time.Now(), &err)
+	return d.next.ListHoneypots(includeDisabled)
+}
+
+func (d *instrumentedUserRepository) DisableHoneypot(honeypotID uuid.UUID) (honeypot *models.Honeypot, err error) {
+	defer d.observe("DisableHoneypot", time.Now(), &err)
+	return d.next.DisableHoneypot(honeypotID)
+}
+
+// TriggerHoneypot runs on every login; a miss is the expected outcome and isn't counted as an error
+func (d *instrumentedUserRepository) TriggerHoneypot(kind, identifier string, trigger *models.HoneypotTrigger) (*models.Honeypot, error) {
+	var err error
+	defer d.observe("TriggerHoneypot", time.Now(), &err)
+	honeypot, triggerErr := d.next.TriggerHoneypot(kind, identifier, trigger)
+	if !errors.Is(triggerErr, ErrHoneypotNotFound) {
+		err = triggerErr
+	}
+	return honeypot, triggerErr
+}
+
+func (d *instrumentedUserRepository) ListHoneypotTriggers(honeypotID uuid.UUID, limit, offset int) (triggers []models.HoneypotTrigger, total int64, err error) {
+	defer d.observe("ListHoneypotTriggers", time.Now(), &err)
+	return d.next.ListHoneypotTriggers(honeypotID, limit, offset)
+}
+
+func (d *instrumentedUserRepository) UpdatePasswordHash(userID uuid.UUID, oldHash, newHash string) (err error) {
+	defer d.observe("UpdatePasswordHash", time.Now(), &err)
+	return d.next.UpdatePasswordHash(userID, oldHash, newHash)
+}
+
+func (d *instrumentedUserRepository) CompactNotifications(olderThan, now time.Time, archive bool, limit int) (result NotificationCompaction, err error) {
+	defer d.observe("CompactNotifications", time.Now(), &err)
+	return d.next.CompactNotifications(olderThan, now, archive, limit)
+}
+
+func (d *instrumentedUserRepository) ArchiveActivities(olderThan time.Time, limit int, write ActivityArchiveWriter) (archived int64, err error) {
+	defer d.observe("ArchiveActivities", time.Now(), &err)
+	return d.next.ArchiveActivities(olderThan, limit, write)
+}
+
+func (d *instrumentedUserRepository) ListActivityArchives(userID uuid.UUID) (archives []models.UserActivityArchive, err error) {
+	defer d.observe("ListActivityArchives", time.Now(), &err)
+	return d.next.ListActivityArchives(userID)
+}
+
+func (d *instrumentedUserRepository) GetDataKey(userID uuid.UUID) (key *models.UserDataKey, err error) {
+	defer d.observe("GetDataKey", time.Now(), &err)
+	return d.next.GetDataKey(userID)
+}
+
+func (d *instrumentedUserRepository) CreateDataKey(key *models.UserDataKey) (stored *models.UserDataKey, err error) {
+	defer d.observe("CreateDataKey", time.Now(), &err)
+	return d.next.CreateDataKey(key)
+}
+
+func (d *instrumentedUserRepository) CreateWebAuthnCredential(credential *models.WebAuthnCredential) (err error) {
+	defer d.observe("CreateWebAuthnCredential", time.Now(), &err)
+	return d.next.CreateWebAuthnCredential(credential)
+}
+
+func (d *instrumentedUserRepository) ListWebAuthnCredentials(userID uuid.UUID) (credentials []models.WebAuthnCredential, err error) {
+	defer d.observe("ListWebAuthnCredentials", time.Now(), &err)
+	return d.next.ListWebAuthnCredentials(userID)
+}
+
+func (d *instrumentedUserRepository) GetWebAuthnCredential(credentialID string) (credential *models.WebAuthnCredential, err error) {
+	defer d.observe("GetWebAuthnCredential", time.Now(), &err)
+	return d.next.GetWebAuthnCredential(credentialID)
+}
+
+func (d *instrumentedUserRepository) RenameWebAuthnCredential(userID, id uuid.UUID, name string) (credential *models.WebAuthnCredential, err error) {
+	defer d.observe("RenameWebAuthnCredential", time.Now(), &err)
+	return d.next.RenameWebAuthnCredential(userID, id, name)
+}
+
+func (d *instrumentedUserRepository) DeleteWebAuthnCredential(userID, id uuid.UUID) (err error) {
+	defer d.observe("DeleteWebAuthnCredential", time.Now(), &err)
+	return d.next.DeleteWebAuthnCredential(userID, id)
+}
+
+func (d *instrumentedUserRepository) RecordWebAuthnCredentialUse(id uuid.UUID, signCount int64, backupState bool, usedAt time.Time) (err error) {
+	defer d.observe("RecordWebAuthnCredentialUse", time.Now(), &err)
+	return d.next.RecordWebAuthnCredentialUse(id, signCount, backupState, usedAt)
+}
+
+func (d *instrumentedUserRepository) SavePushSubscription(subscription *models.PushSubscription) (err error) {
+	defer d.observe("SavePushSubscription", time.Now(), &err)
+	return d.next.SavePushSubscription(subscription)
+}
+
+func (d *instrumentedUserRepository) ListPushSubscriptions(userID uuid.UUID) (subscriptions []models.PushSubscription, err error) {
+	defer d.observe("ListPushSubscriptions", time.Now(), &err)
+	return d.next.ListPushSubscriptions(userID)
+}
+
+func (d *instrumentedUserRepository) DeletePushSubscription(userID, id uuid.UUID) (err error) {
+	defer d.observe("DeletePushSubscription", time.Now(), &err)
+	return d.next.DeletePushSubscription(userID, id)
+}
+
+func (d *instrumentedUserRepository) DeletePushSubscriptionByEndpoint(endpoint string) (err error) {
+	defer d.observe("DeletePushSubscriptionByEndpoint", time.Now(), &err)
+	return d.next.DeletePushSubscriptionByEndpoint(endpoint)
+}
+
+func (d *instrumentedUserRepository) CreatePersonalAccessToken(token *models.PersonalAccessToken) (err error) {
+	defer d.observe("CreatePersonalAccessToken", time.Now(), &err)
+	return d.next.CreatePersonalAccessToken(token)
+}
+
+func (d *instrumentedUserRepository) ListPersonalAccessTokens(userID uuid.UUID) (tokens []models.PersonalAccessToken, err error) {
+	defer d.observe("ListPersonalAccessTokens", time.Now(), &err)
+	return d.next.ListPersonalAccessTokens(userID)
+}
+
+func (d *instrumentedUserRepository) GetPersonalAccessTokenByHash(tokenHash string) (token *models.PersonalAccessToken, err error) {
+	defer d.observe("GetPersonalAccessTokenByHash", time.Now(), &err)
+	return d.next.GetPersonalAccessTokenByHash(tokenHash)
+}
+
+func (d *instrumentedUserRepository) RevokePersonalAccessToken(userID, id uuid.UUID) (token *models.PersonalAccessToken, err error) {
+	defer d.observe("RevokePersonalAccessToken", time.Now(), &err)
+	return d.next.RevokePersonalAccessToken(userID, id)
+}
+
+func (d *instrumentedUserRepository) RevokeAllPersonalAccessTokens(userID uuid.UUID) (revoked int64, err error) {
+	defer d.observe("RevokeAllPersonalAccessTokens", time.Now(), &err)
+	return d.next.RevokeAllPersonalAccessTokens(userID)
+}
+
+func (d *instrumentedUserRepository) RecordPersonalAccessTokenUse(id uuid.UUID, usedAt time.Time, interval time.Duration) (err error) {
+	defer d.observe("RecordPersonalAccessTokenUse", time.Now(), &err)
+	return d.next.RecordPersonalAccessTokenUse(id, usedAt, interval)
+}
+
+func (d *instrumentedUserRepository) CreateOAuthClient(client *models.OAuthClient) (err error) {
+	defer d.observe("CreateOAuthClient", time.Now(), &err)
+	return d.next.CreateOAuthClient(client)
+}
+
+func (d *instrumentedUserRepository) ListOAuthClients() (clients []models.OAuthClient, err error) {
+	defer d.observe("ListOAuthClients", time.Now(), &err)
+	return d.next.ListOAuthClients()
+}
+
+// GetOAuthClient runs on every token request; an unknown client_id is the caller's error, not the database's
+func (d *instrumentedUserRepository) GetOAuthClient(clientID string) (*models.OAuthClient, error) {
+	var err error
+	defer d.observe("GetOAuthClient", time.Now(), &err)
+	client, getErr := d.next.GetOAuthClient(clientID)
+	if !errors.Is(getErr, ErrOAuthClientNotFound) {
+		err = getErr
+	}
+	return client, getErr
+}
+
+func (d *instrumentedUserRepository) UpdateOAuthClient(clientID string, updates map[string]interface{}) (client *models.OAuthClient, err error) {
+	defer d.observe("UpdateOAuthClient", time.Now(), &err)
+	return d.next.UpdateOAuthClient(clientID, updates)
+}
+
+func (d *instrumentedUserRepository) DeleteOAuthClient(clientID string) (client *models.OAuthClient, err error) {
+	defer d.observe("DeleteOAuthClient", time.Now(), &err)
+	return d.next.DeleteOAuthClient(clientID)
+}
+
+func (d *instrumentedUserRepository) RecordOAuthClientUse(id uuid.UUID, usedAt time.Time, interval time.Duration) (err error) {
+	defer d.observe("RecordOAuthClientUse", time.Now(), &err)
+	return d.next.RecordOAuthClientUse(id, usedAt, interval)
+}
+
+func (d *instrumentedUserRepository) MergeUsers(merge *models.UserMerge) (counts *models.UserMergeCounts, err error) {
+	defer d.observe("MergeUsers", time.Now(), &err)
+	return d.next.MergeUsers(merge)
+}
+
+func (d *instrumentedUserRepository) ListUserMerges(userID uuid.UUID) (merges []models.UserMerge, err error) {
+	defer d.observe("ListUserMerges", time.Now(), &err)
+	return d.next.ListUserMerges(userID)
+}
+
+func (d *instrumentedUserRepository) AppendAuditLog(entry *models.AuditLogEntry, seal func(entry *models.AuditLogEntry) error) (err error) {
+	defer d.observe("AppendAuditLog", time.Now(), &err)
+	return d.next.AppendAuditLog(entry, seal)
+}
+
+func (d *instrumentedUserRepository) ListAuditLog(filter models.AuditLogFilter, limit, offset int) (entries []models.AuditLogEntry, total int64, err error) {
+	defer d.observe("ListAuditLog", time.Now(), &err)
+	return d.next.ListAuditLog(filter, limit, offset)
+}
+
+func (d *instrumentedUserRepository) ListAuditLogAfter(filter models.AuditLogFilter, afterSeq int64, limit int) (entries []models.AuditLogEntry, err error) {
+	defer d.observe("ListAuditLogAfter", time.Now(), &err)
+	return d.next.ListAuditLogAfter(filter, afterSeq, limit)
+}
+
+func (d *instrumentedUserRepository) ListPurgeableUsers(deletedBefore time.Time, limit int) (users []models.User, err error) {
+	defer d.observe("ListPurgeableUsers", time.Now(), &err)
+	return d.next.ListPurgeableUsers(deletedBefore, limit)
+}
+
+func (d *instrumentedUserRepository) PurgeUser(userID uuid.UUID, deletedBefore time.Time, anonymize bool, removeArchives ActivityArchiveRemover) (counts *models.UserPurgeCounts, err error) {
+	defer d.observe("PurgeUser", time.Now(), &err)
+	return d.next.PurgeUser(userID, deletedBefore, anonymize, removeArchives)
+}
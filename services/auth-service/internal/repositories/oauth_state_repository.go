@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrOAuthStateNotFound = errors.New("OAuth state is invalid, expired or already used")
+
+// OAuthState is the Redis payload stored for an OAuth login between the redirect to the provider and
+// the callback. The PKCE code verifier never leaves the service; only its S256 challenge is sent
+type OAuthState struct {
+	Provider     string    `json:"provider"`
+	CodeVerifier string    `json:"code_verifier"`
+	NonceHash    string    `json:"nonce_hash"`             // Of the nonce cookie set in the browser that started the flow
+	LinkUserID   uuid.UUID `json:"link_user_id,omitempty"` // Set when a signed-in user links the provider instead of logging in
+	IssuedAt     time.Time `json:"issued_at"`
+}
+
+// OAuthStateRepository stores OAuth login states keyed by state hash
+type OAuthStateRepository interface {
+	Save(stateHash string, state *OAuthState, expiry time.Duration) error
+	// Consume atomically reads and deletes a state, so each login completes at most once
+	Consume(stateHash string) (*OAuthState, error)
+}
+
+type oauthStateRepository struct {
+	redis *redis.Client
+}
+
+func NewOAuthStateRepository(redisClient *redis.Client) OAuthStateRepository {
+	return &oauthStateRepository{redis: redisClient}
+}
+
+func (r *oauthStateRepository) Save(stateHash string, state *OAuthState, expiry time.Duration) error {
+	ctx := context.Background()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return r.redis.Set(ctx, oauthStateKey(stateHash), data, expiry).Err()
+}
+
+func (r *oauthStateRepository) Consume(stateHash string) (*OAuthState, error) {
+	ctx := context.Background()
+
+	data, err := r.redis.GetDel(ctx, oauthStateKey(stateHash)).Result()
+	if err == redis.Nil {
+		return nil, ErrOAuthStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state OAuthState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, fmt.Errorf("invalid OAuth state data: %w", err)
+	}
+	return &state, nil
+}
+
+func oauthStateKey(stateHash string) string {
+	return fmt.Sprintf("oauth_state:%s", stateHash)
+}
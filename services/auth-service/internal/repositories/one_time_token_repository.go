@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Token purposes keep reset, verification and invitation tokens in separate keyspaces
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeInvitation        = "invitation"
+)
+
+// consumedTokenRetention is how long a consumed marker is kept to recognise replays
+const consumedTokenRetention = 24 * time.Hour
+
+var (
+	ErrOneTimeTokenNotFound = errors.New("token is invalid or has expired")
+	ErrOneTimeTokenReplayed = errors.New("token has already been used")
+)
+
+// OneTimeToken is the Redis payload stored for a single-use token
+// Only hashes of the requesting IP address and user agent are kept; tokens issued on someone
+// else's behalf (invitations) have neither and aren't bound to a device
+type OneTimeToken struct {
+	UserID        uuid.UUID `json:"user_id"`
+	Purpose       string    `json:"purpose"`
+	IPHash        string    `json:"ip_hash"`
+	UserAgentHash string    `json:"user_agent_hash"`
+	IssuedAt      time.Time `json:"issued_at"`
+}
+
+// OneTimeTokenRepository stores single-use tokens keyed by token hash
+type OneTimeTokenRepository interface {
+	Issue(purpose, tokenHash string, token *OneTimeToken, expiry time.Duration) error
+	// Consume atomically claims a token. A second claim returns the original payload
+	// together with ErrOneTimeTokenReplayed so callers can alert on the replay
+	Consume(purpose, tokenHash string) (*OneTimeToken, error)
+}
+
+// consumeScript moves a token to its consumed marker in one step so concurrent claims cannot both succeed
+// Returns {1, payload} on first use, {2, payload} on replay and {0} when unknown
+var consumeScript = redis.NewScript(`
+local value = redis.call('GET', KEYS[1])
+if value then
+	redis.call('DEL', KEYS[1])
+	redis.call('SET', KEYS[2], value, 'PX', ARGV[1])
+	return {1, value}
+end
+local consumed = redis.call('GET', KEYS[2])
+if consumed then
+	return {2, consumed}
+end
+return {0}
+`)
+
+type oneTimeTokenRepository struct {
+	redis *redis.Client
+}
+
+func NewOneTimeTokenRepository(redisClient *redis.Client) OneTimeTokenRepository {
+	return &oneTimeTokenRepository{redis: redisClient}
+}
+
+func (r *oneTimeTokenRepository) Issue(purpose, tokenHash string, token *OneTimeToken, expiry time.Duration) error {
+	ctx := context.Background()
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	return r.redis.Set(ctx, oneTimeTokenKey(purpose, tokenHash), data, expiry).Err()
+}
+
+func (r *oneTimeTokenRepository) Consume(purpose, tokenHash string) (*OneTimeToken, error) {
+	ctx := context.Background()
+	keys := []string{oneTimeTokenKey(purpose, tokenHash), consumedTokenKey(purpose, tokenHash)}
+
+	result, err := consumeScript.Run(ctx, r.redis, keys, consumedTokenRetention.Milliseconds()).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	status, _ := result[0].(int64)
+	if status == 0 || len(result) < 2 {
+		return nil, ErrOneTimeTokenNotFound
+	}
+
+	payload, _ := result[1].(string)
+	var token OneTimeToken
+	if err := json.Unmarshal([]byte(payload), &token); err != nil {
+		return nil, fmt.Errorf("invalid token data: %w", err)
+	}
+
+	if status == 2 {
+		return &token, ErrOneTimeTokenReplayed
+	}
+	return &token, nil
+}
+
+func oneTimeTokenKey(purpose, tokenHash string) string {
+	return fmt.Sprintf("one_time_token:%s:%s", purpose, tokenHash)
+}
+
+func consumedTokenKey(purpose, tokenHash string) string {
+	return fmt.Sprintf("one_time_token_consumed:%s:%s", purpose, tokenHash)
+}
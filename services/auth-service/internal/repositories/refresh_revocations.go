@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys of the refresh token revocation log
+const (
+	revocationSequenceKey = "refresh_revocations:sequence" // Last sequence number handed out
+	revocationBySeqKey    = "refresh_revocations:by_seq"   // Revoked hashes scored by the sequence number they were recorded at
+	revocationExpiryKey   = "refresh_revocations:expiry"   // The same hashes scored by the Unix time they stop being listed
+)
+
+// RefreshRevocation is one revoked refresh token hash in the log
+type RefreshRevocation struct {
+	TokenHash string
+	Sequence  int64     // Increases with every recorded revocation; deltas return those after a sequence
+	ExpiresAt time.Time // The token can't be valid after this, so it is dropped from the log
+}
+
+// RefreshRevocationLog lists the hashes of revoked refresh tokens for the revocation snapshot edge
+// gateways poll. It is fed by session events: as the publisher of the session repository it sees every
+// revocation made in this region, and wrapped around the replication applier those of the other regions
+type RefreshRevocationLog struct {
+	redis     *redis.Client
+	retention time.Duration
+}
+
+// NewRefreshRevocationLog keeps each revoked hash for retention, at least the refresh token lifetime
+func NewRefreshRevocationLog(redisClient *redis.Client, retention time.Duration) *RefreshRevocationLog {
+	return &RefreshRevocationLog{redis: redisClient, retention: retention}
+}
+
+// Record lists hashes as revoked for the retention period, each under a new sequence number
+// A hash recorded again moves to the new sequence number and expiry
+func (l *RefreshRevocationLog) Record(ctx context.Context, hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	last, err := l.redis.IncrBy(ctx, revocationSequenceKey, int64(len(hashes))).Result()
+	if err != nil {
+		return err
+	}
+	first := last - int64(len(hashes)) + 1
+	expiresAt := float64(time.Now().Add(l.retention).Unix())
+
+	pipe := l.redis.TxPipeline()
+	for i, hash := range hashes {
+		pipe.ZAdd(ctx, revocationBySeqKey, redis.Z{Score: float64(first + int64(i)), Member: hash})
+		pipe.ZAdd(ctx, revocationExpiryKey, redis.Z{Score: expiresAt, Member: hash})
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// List drops expired entries and returns the rest sorted by token hash, with the last sequence number
+// handed out. Entries recorded while it runs may be newer than that sequence; they are listed again
+// in the next delta, which is harmless
+func (l *RefreshRevocationLog) List(ctx context.Context) ([]RefreshRevocation, int64, error) {
+	sequence, err := l.redis.Get(ctx, revocationSequenceKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, err
+	}
+
+	expired, err := l.redis.ZRangeByScore(ctx, revocationExpiryKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(expired) > 0 {
+		members := make([]interface{}, len(expired))
+		for i, hash := range expired {
+			members[i] = hash
+		}
+		pipe := l.redis.TxPipeline()
+		pipe.ZRem(ctx, revocationBySeqKey, members...)
+		pipe.ZRem(ctx, revocationExpiryKey, members...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	bySeq, err := l.redis.ZRangeWithScores(ctx, revocationBySeqKey, 0, -1).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	expiries, err := l.redis.ZRangeWithScores(ctx, revocationExpiryKey, 0, -1).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	expiresAt := make(map[string]int64, len(expiries))
+	for _, entry := range expiries {
+		expiresAt[entry.Member.(string)] = int64(entry.Score)
+	}
+
+	revocations := make([]RefreshRevocation, 0, len(bySeq))
+	for _, entry := range bySeq {
+		hash := entry.Member.(string)
+		expiry, ok := expiresAt[hash]
+		if !ok {
+			continue // Expired between the two reads
+		}
+		revocations = append(revocations, RefreshRevocation{
+			TokenHash: hash,
+			Sequence:  int64(entry.Score),
+			ExpiresAt: time.Unix(expiry, 0),
+		})
+	}
+	sort.Slice(revocations, func(i, j int) bool { return revocations[i].TokenHash < revocations[j].TokenHash })
+	return revocations, sequence, nil
+}
+
+// PublishSessionEvent records the refresh tokens a session event revokes, so the log can be the
+// publisher of a session repository; failures are logged since publishing must not fail the revocation
+func (l *RefreshRevocationLog) PublishSessionEvent(event *SessionEvent) {
+	if err := l.Record(context.Background(), revokedRefreshTokens(event)); err != nil {
+		log.Printf("⚠️  Failed to record revoked refresh tokens of %s event: %v", event.Type, err)
+	}
+}
+
+// Applier wraps the replication applier so revocations made in other regions are recorded here too
+func (l *RefreshRevocationLog) Applier(applier SessionEventApplier) SessionEventApplier {
+	return &recordingApplier{applier: applier, log: l}
+}
+
+type recordingApplier struct {
+	applier SessionEventApplier
+	log     *RefreshRevocationLog
+}
+
+func (a *recordingApplier) ApplySessionEvent(ctx context.Context, event *SessionEvent) (bool, error) {
+	applied, err := a.applier.ApplySessionEvent(ctx, event)
+	if err != nil {
+		return applied, err
+	}
+	return applied, a.log.Record(ctx, revokedRefreshTokens(event))
+}
+
+// revokedRefreshTokens returns the refresh token hashes an event revokes; rotated tokens aren't listed,
+// since the service has to see a rotated token replayed to end its family
+func revokedRefreshTokens(event *SessionEvent) []string {
+	switch event.Type {
+	case SessionEventRevoked, SessionEventFamilyRevoked:
+		return event.RefreshTokens
+	case SessionEventRefreshDeleted:
+		return []string{event.TokenHash}
+	}
+	return nil
+}
+
+// SessionEventPublishers publishes each event to every publisher in order
+type SessionEventPublishers []SessionEventPublisher
+
+func (p SessionEventPublishers) PublishSessionEvent(event *SessionEvent) {
+	for _, publisher := range p {
+		publisher.PublishSessionEvent(event)
+	}
+}
@@ -0,0 +1,313 @@
+package repositories
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Session events replicated between regions
+const (
+	SessionEventCreated           = "session_created"
+	SessionEventRevoked           = "sessions_revoked"
+	SessionEventUserRevoked       = "user_sessions_revoked"
+	SessionEventRefreshStored     = "refresh_token_stored"
+	SessionEventRefreshDeleted    = "refresh_token_deleted"
+	SessionEventAccessBlacklisted = "token_blacklisted"
+	SessionEventRefreshRotated    = "refresh_token_rotated"
+	SessionEventFamilyRevoked     = "refresh_family_revoked"
+)
+
+// SessionEvent is a session change made in one region, applied by the replicators of the others
+type SessionEvent struct {
+	Type   string    `json:"type"`
+	Region string    `json:"region"`
+	At     time.Time `json:"at"` // When the change was made in its region
+
+	UserID            uuid.UUID      `json:"user_id,omitempty"`
+	Session           *SessionRecord `json:"session,omitempty"`             // SessionEventCreated
+	SessionIDs        []uuid.UUID    `json:"session_ids,omitempty"`         // SessionEventRevoked
+	RefreshTokens     []string       `json:"refresh_tokens,omitempty"`      // Hashes to delete
+	AccessTokenHashes []string       `json:"access_token_hashes,omitempty"` // Hashes to blacklist
+	TokenHash         string         `json:"token_hash,omitempty"`          // Refresh or blacklisted access token hash
+	ExpiresAt         time.Time      `json:"expires_at,omitempty"`          // Of the refresh token or blacklist entry
+	FamilyID          string         `json:"family_id,omitempty"`           // Refresh token family rotated or revoked
+}
+
+// SessionRecord carries a session row across regions, including the token hashes models.Session keeps out of JSON
+type SessionRecord struct {
+	ID              uuid.UUID `json:"id"`
+	UserID          uuid.UUID `json:"user_id"`
+	RefreshToken    string    `json:"refresh_token"`
+	AccessTokenHash string    `json:"access_token_hash"`
+	IPAddress       *string   `json:"ip_address,omitempty"`
+	IPHash          string    `json:"ip_hash,omitempty"`
+	UserAgent       string    `json:"user_agent,omitempty"`
+	DeviceInfo      string    `json:"device_info,omitempty"`
+	CountryCode     string    `json:"country_code,omitempty"`
+	City            string    `json:"city,omitempty"`
+	RememberMe      bool      `json:"remember_me,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// SessionEventPublisher sends session events to the other regions; publishing must not fail the
+// local change, so implementations handle their own errors
+type SessionEventPublisher interface {
+	PublishSessionEvent(event *SessionEvent)
+}
+
+// SessionEventApplier applies events published by other regions to this region's sessions
+type SessionEventApplier interface {
+	// ApplySessionEvent reports false when the event was superseded by a revocation already applied here
+	ApplySessionEvent(ctx context.Context, event *SessionEvent) (bool, error)
+}
+
+// ErrSessionUserMissing means a replicated session belongs to a user this region's database doesn't have,
+// e.g. one deleted here; the session is dropped
+var ErrSessionUserMissing = errors.New("replicated session's user does not exist in this region")
+
+// Redis keys remembering revocations so events arriving out of order can't bring a session back
+const (
+	revokedSessionKey = "session_replication:revoked_session:%s" // Session revoked in any region
+	deletedRefreshKey = "session_replication:deleted_refresh:%s" // Refresh token hash deleted in any region
+	userRevokedKey    = "session_replication:user_revoked:%s"    // Unix nanoseconds of the user's latest revoke-all
+)
+
+// NewReplicatedSessionRepository is NewSessionRepository publishing every session change to publisher
+// revocationTTL is how long revocations are remembered, at least the refresh token lifetime
+func NewReplicatedSessionRepository(db *gorm.DB, redisClient *redis.Client, publisher SessionEventPublisher, revocationTTL time.Duration) SessionRepository {
+	return &sessionRepository{db: db, redis: redisClient, publisher: publisher, revocationTTL: revocationTTL}
+}
+
+// NewSessionEventApplier applies replicated events without publishing them again
+func NewSessionEventApplier(db *gorm.DB, redisClient *redis.Client, revocationTTL time.Duration) SessionEventApplier {
+	return &sessionRepository{db: db, redis: redisClient, revocationTTL: revocationTTL}
+}
+
+// publish stamps and sends an event when replication is enabled
+func (r *sessionRepository) publish(event *SessionEvent) {
+	if r.publisher == nil {
+		return
+	}
+	event.At = time.Now()
+	r.publisher.PublishSessionEvent(event)
+}
+
+// publishRevoked sends the revocation of sessions along with the tokens invalidated with them
+func (r *sessionRepository) publishRevoked(sessions []models.Session, blacklistFor time.Duration) {
+	if r.publisher == nil || len(sessions) == 0 {
+		return
+	}
+	event := &SessionEvent{Type: SessionEventRevoked, ExpiresAt: time.Now().Add(blacklistFor)}
+	for _, session := range sessions {
+		event.SessionIDs = append(event.SessionIDs, session.ID)
+		if session.RefreshToken != "" {
+			event.RefreshTokens = append(event.RefreshTokens, session.RefreshToken)
+		}
+		if session.AccessTokenHash != "" {
+			event.AccessTokenHashes = append(event.AccessTokenHashes, session.AccessTokenHash)
+		}
+	}
+	r.publish(event)
+}
+
+// ApplySessionEvent applies an event from another region. Revocation wins: a session or refresh token
+// revoked in any region stays revoked whatever order the events arrive in
+func (r *sessionRepository) ApplySessionEvent(ctx context.Context, event *SessionEvent) (bool, error) {
+	switch event.Type {
+	case SessionEventCreated:
+		return r.applySessionCreated(ctx, event)
+	case SessionEventRevoked:
+		return true, r.applySessionsRevoked(ctx, event)
+	case SessionEventUserRevoked:
+		return true, r.applyUserRevoked(ctx, event)
+	case SessionEventRefreshStored:
+		return r.applyRefreshStored(ctx, event)
+	case SessionEventRefreshDeleted:
+		pipe := r.redis.TxPipeline()
+		pipe.Del(ctx, fmt.Sprintf("refresh_token:%s", event.TokenHash))
+		pipe.Set(ctx, fmt.Sprintf(deletedRefreshKey, event.TokenHash), "1", r.revocationTTL)
+		_, err := pipe.Exec(ctx)
+		return true, err
+	case SessionEventAccessBlacklisted:
+		if ttl := time.Until(event.ExpiresAt); ttl > 0 {
+			return true, r.redis.Set(ctx, fmt.Sprintf("blacklist:%s", event.TokenHash), "1", ttl).Err()
+		}
+		return true, nil
+	case SessionEventRefreshRotated:
+		return true, r.applyRefreshRotated(ctx, event)
+	case SessionEventFamilyRevoked:
+		return true, r.applyFamilyRevoked(ctx, event)
+	}
+	return false, fmt.Errorf("unknown session event type %q", event.Type)
+}
+
+// applySessionCreated inserts the session unless it exists; a session revoked here first, or created before
+// its user's latest revoke-all, is inserted revoked
+func (r *sessionRepository) applySessionCreated(ctx context.Context, event *SessionEvent) (bool, error) {
+	record := event.Session
+	if record == nil {
+		return false, errors.New("session_created event without a session")
+	}
+
+	revoked, err := r.redis.Exists(ctx, fmt.Sprintf(revokedSessionKey, record.ID)).Result()
+	if err != nil {
+		return false, err
+	}
+	revokedAt, err := r.userRevokedAt(ctx, record.UserID)
+	if err != nil {
+		return false, err
+	}
+	superseded := revoked > 0 || !record.CreatedAt.After(revokedAt)
+
+	var users int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", record.UserID).Count(&users).Error; err != nil {
+		return false, err
+	}
+	if users == 0 {
+		return false, ErrSessionUserMissing
+	}
+
+	session := &models.Session{
+		ID:              record.ID,
+		UserID:          record.UserID,
+		RefreshToken:    record.RefreshToken,
+		AccessTokenHash: record.AccessTokenHash,
+		IPAddress:       record.IPAddress,
+		IPHash:          record.IPHash,
+		UserAgent:       record.UserAgent,
+		DeviceInfo:      record.DeviceInfo,
+		CountryCode:     record.CountryCode,
+		City:            record.City,
+		RememberMe:      record.RememberMe,
+		IsActive:        !superseded,
+		IsRevoked:       superseded,
+		CreatedAt:       record.CreatedAt,
+		ExpiresAt:       record.ExpiresAt,
+	}
+	// Select("*") writes is_active even when false, which GORM would otherwise replace with the column default
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Select("*").Omit("User").Create(session).Error; err != nil {
+		return false, err
+	}
+	return !superseded, nil
+}
+
+// applySessionsRevoked revokes the sessions, invalidates their tokens and remembers the revocation
+func (r *sessionRepository) applySessionsRevoked(ctx context.Context, event *SessionEvent) error {
+	if len(event.SessionIDs) > 0 {
+		if err := r.db.WithContext(ctx).Model(&models.Session{}).
+			Where("id IN ? AND is_revoked = ?", event.SessionIDs, false).
+			Updates(map[string]interface{}{"is_revoked": true, "is_active": false}).Error; err != nil {
+			return err
+		}
+	}
+
+	pipe := r.redis.TxPipeline()
+	for _, id := range event.SessionIDs {
+		pipe.Set(ctx, fmt.Sprintf(revokedSessionKey, id), "1", r.revocationTTL)
+	}
+	for _, hash := range event.RefreshTokens {
+		pipe.Del(ctx, fmt.Sprintf("refresh_token:%s", hash))
+		pipe.Set(ctx, fmt.Sprintf(deletedRefreshKey, hash), "1", r.revocationTTL)
+	}
+	if ttl := time.Until(event.ExpiresAt); ttl > 0 {
+		for _, hash := range event.AccessTokenHashes {
+			pipe.Set(ctx, fmt.Sprintf("blacklist:%s", hash), "1", ttl)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// applyUserRevoked revokes the user's sessions created up to the event; sessions created later, in
+// any region, are kept
+func (r *sessionRepository) applyUserRevoked(ctx context.Context, event *SessionEvent) error {
+	if err := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND is_revoked = ? AND created_at <= ?", event.UserID, false, event.At).
+		Updates(map[string]interface{}{"is_revoked": true, "is_active": false}).Error; err != nil {
+		return err
+	}
+
+	return r.rememberUserRevoked(ctx, event.UserID, event.At)
+}
+
+// rememberUserRevoked records a revoke-all of the user's sessions, keeping the latest
+func (r *sessionRepository) rememberUserRevoked(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	revokedAt, err := r.userRevokedAt(ctx, userID)
+	if err != nil || !at.After(revokedAt) {
+		return err
+	}
+	return r.redis.Set(ctx, fmt.Sprintf(userRevokedKey, userID), strconv.FormatInt(at.UnixNano(), 10), r.revocationTTL).Err()
+}
+
+// applyRefreshStored stores the refresh token unless it was deleted in any region or issued before its
+// user's latest revoke-all
+func (r *sessionRepository) applyRefreshStored(ctx context.Context, event *SessionEvent) (bool, error) {
+	ttl := time.Until(event.ExpiresAt)
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	deleted, err := r.redis.Exists(ctx, fmt.Sprintf(deletedRefreshKey, event.TokenHash)).Result()
+	if err != nil {
+		return false, err
+	}
+	revokedAt, err := r.userRevokedAt(ctx, event.UserID)
+	if err != nil {
+		return false, err
+	}
+	if deleted > 0 || !event.At.After(revokedAt) {
+		return false, nil
+	}
+
+	return true, r.storeRefreshToken(ctx, r.redis, event.UserID, event.TokenHash, event.At, ttl)
+}
+
+// applyRefreshRotated deletes the rotated token and remembers it, so replaying it here is detected as reuse
+func (r *sessionRepository) applyRefreshRotated(ctx context.Context, event *SessionEvent) error {
+	pipe := r.redis.TxPipeline()
+	pipe.Del(ctx, fmt.Sprintf("refresh_token:%s", event.TokenHash))
+	pipe.Set(ctx, fmt.Sprintf(deletedRefreshKey, event.TokenHash), "1", r.revocationTTL)
+	if ttl := time.Until(event.ExpiresAt); ttl > 0 {
+		pipe.Set(ctx, fmt.Sprintf(rotatedRefreshKey, event.TokenHash), event.FamilyID, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// applyFamilyRevoked deletes the family's tokens and remembers the family as revoked
+func (r *sessionRepository) applyFamilyRevoked(ctx context.Context, event *SessionEvent) error {
+	pipe := r.redis.TxPipeline()
+	for _, hash := range event.RefreshTokens {
+		pipe.Del(ctx, fmt.Sprintf("refresh_token:%s", hash))
+		pipe.Set(ctx, fmt.Sprintf(deletedRefreshKey, hash), "1", r.revocationTTL)
+	}
+	if ttl := time.Until(event.ExpiresAt); ttl > 0 {
+		pipe.Set(ctx, fmt.Sprintf(revokedFamilyKey, event.FamilyID), "1", ttl)
+	}
+	pipe.Del(ctx, fmt.Sprintf(refreshFamilyKey, event.FamilyID))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// userRevokedAt returns when the user's sessions were last revoked everywhere, or the zero time
+func (r *sessionRepository) userRevokedAt(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	value, err := r.redis.Get(ctx, fmt.Sprintf(userRevokedKey, userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, value), nil
+}
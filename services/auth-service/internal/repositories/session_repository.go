@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,19 @@ type SessionRepository interface {
 	RevokeSession(sessionID uuid.UUID) error
 	RevokeAllUserSessions(userID uuid.UUID) error
 	CleanupExpiredSessions() error
+
+	// Administrative search and bulk revocation across all users
+	SearchSessions(filter models.SessionFilter, limit, offset int) ([]models.Session, int64, error)
+	CountSessions(filter models.SessionFilter) (int64, error)
+	RevokeMatchingSessions(filter models.SessionFilter, blacklistFor time.Duration) (int64, error)
+
+	// RevokeExcessSessions enforces a per-user session limit, keeping the newest sessions
+	RevokeExcessSessions(userID uuid.UUID, keep int, blacklistFor time.Duration) (int64, error)
+
+	// Self-service session management: a user's own devices, and keeping them current as tokens rotate
+	ListActiveUserSessions(userID uuid.UUID) ([]models.Session, error)
+	RevokeUserSession(userID, sessionID uuid.UUID, blacklistFor time.Duration) error
+	RecordSessionRefresh(oldRefreshHash, newRefreshHash, accessTokenHash string, expiresAt time.Time) error
 	
 	// Redis-based token management
 	StoreRefreshToken(userID uuid.UUID, tokenHash string, expiry time.Duration) error
@@ -28,11 +42,32 @@ type SessionRepository interface {
 	DeleteRefreshToken(tokenHash string) error
 	BlacklistToken(tokenHash string, expiry time.Duration) error
 	IsTokenBlacklisted(tokenHash string) (bool, error)
+
+	// Refresh token families: a rotated token is remembered so that replaying it ends its whole family
+	RotateRefreshToken(userID uuid.UUID, familyID, oldHash, newHash string, expiry, rotatedFor time.Duration) error
+	GetRotatedRefreshTokenFamily(tokenHash string) (string, error)
+	IsRefreshFamilyRevoked(familyID string) (bool, error)
+	RevokeRefreshFamily(familyID string, revokeFor, blacklistFor time.Duration) (int, error)
+
+	// Blocked addresses, keyed by the SHA-256 of the IP so raw addresses aren't kept in Redis
+	BlockIP(ipHash string, duration time.Duration) error
+	IsIPBlocked(ipHash string) (bool, error)
 }
 
+// ErrSessionNotFound means the session doesn't exist, belongs to another user or was already revoked
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrRefreshTokenNotCurrent means the refresh token being rotated was already rotated or deleted, e.g. by
+// a concurrent refresh with the same token
+var ErrRefreshTokenNotCurrent = errors.New("refresh token is no longer current")
+
 type sessionRepository struct {
 	db    *gorm.DB
 	redis *redis.Client
+
+	// Session replication; publisher is nil unless session_replication is enabled
+	publisher     SessionEventPublisher
+	revocationTTL time.Duration
 }
 
 func NewSessionRepository(db *gorm.DB, redisClient *redis.Client) SessionRepository {
@@ -43,17 +78,35 @@ func NewSessionRepository(db *gorm.DB, redisClient *redis.Client) SessionReposit
 }
 
 func (r *sessionRepository) CreateSession(session *models.Session) error {
-	return r.db.Create(session).Error
+	if err := r.db.Create(session).Error; err != nil {
+		return err
+	}
+	r.publish(&SessionEvent{Type: SessionEventCreated, UserID: session.UserID, Session: &SessionRecord{
+		ID:              session.ID,
+		UserID:          session.UserID,
+		RefreshToken:    session.RefreshToken,
+		AccessTokenHash: session.AccessTokenHash,
+		IPAddress:       session.IPAddress,
+		IPHash:          session.IPHash,
+		UserAgent:       session.UserAgent,
+		DeviceInfo:      session.DeviceInfo,
+		CountryCode:     session.CountryCode,
+		City:            session.City,
+		RememberMe:      session.RememberMe,
+		CreatedAt:       session.CreatedAt,
+		ExpiresAt:       session.ExpiresAt,
+	}})
+	return nil
 }
 
 func (r *sessionRepository) GetSessionByToken(tokenHash string) (*models.Session, error) {
 	var session models.Session
-	err := r.db.Where("token_hash = ? AND is_revoked = ? AND expires_at > ?", 
+	err := r.db.Where("access_token_hash = ? AND is_revoked = ? AND expires_at > ?", 
 		tokenHash, false, time.Now()).First(&session).Error
 	
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("session not found")
+			return nil, ErrSessionNotFound
 		}
 		return nil, err
 	}
@@ -79,15 +132,30 @@ func (r *sessionRepository) UpdateSession(session *models.Session) error {
 }
 
 func (r *sessionRepository) RevokeSession(sessionID uuid.UUID) error {
-	return r.db.Model(&models.Session{}).
+	if err := r.db.Model(&models.Session{}).
 		Where("id = ?", sessionID).
-		Update("is_revoked", true).Error
+		Update("is_revoked", true).Error; err != nil {
+		return err
+	}
+	r.publish(&SessionEvent{Type: SessionEventRevoked, SessionIDs: []uuid.UUID{sessionID}})
+	return nil
 }
 
 func (r *sessionRepository) RevokeAllUserSessions(userID uuid.UUID) error {
-	return r.db.Model(&models.Session{}).
+	if err := r.db.Model(&models.Session{}).
 		Where("user_id = ?", userID).
-		Update("is_revoked", true).Error
+		Update("is_revoked", true).Error; err != nil {
+		return err
+	}
+	if r.publisher != nil {
+		// Sessions other regions created before now and haven't replicated yet arrive revoked
+		event := &SessionEvent{Type: SessionEventUserRevoked, UserID: userID}
+		r.publish(event)
+		if err := r.rememberUserRevoked(context.Background(), userID, event.At); err != nil {
+			return fmt.Errorf("sessions revoked but the revocation wasn't recorded for replication: %w", err)
+		}
+	}
+	return nil
 }
 
 func (r *sessionRepository) CleanupExpiredSessions() error {
@@ -95,22 +163,233 @@ func (r *sessionRepository) CleanupExpiredSessions() error {
 		Delete(&models.Session{}).Error
 }
 
+// revokeBatchSize bounds the IN list of each bulk revocation update
+const revokeBatchSize = 500
+
+// applySessionFilter narrows a sessions query; every condition is backed by an index on sessions
+func applySessionFilter(query *gorm.DB, filter models.SessionFilter) *gorm.DB {
+	if !filter.IncludeRevoked {
+		query = query.Where("is_revoked = ?", false)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.IPRange != "" {
+		query = query.Where("ip_address <<= ?::inet", filter.IPRange)
+	}
+	if filter.IPHash != "" {
+		query = query.Where("ip_hash = ?", filter.IPHash)
+	}
+	if filter.UserAgent != "" {
+		query = query.Where("user_agent ILIKE ?", "%"+escapeLike(filter.UserAgent)+"%")
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.ExcludeID != nil {
+		query = query.Where("id <> ?", *filter.ExcludeID)
+	}
+	return query
+}
+
+// escapeLike escapes LIKE wildcards so user agent filters match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *sessionRepository) SearchSessions(filter models.SessionFilter, limit, offset int) ([]models.Session, int64, error) {
+	query := applySessionFilter(r.db.Model(&models.Session{}), filter).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var sessions []models.Session
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&sessions).Error; err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+func (r *sessionRepository) CountSessions(filter models.SessionFilter) (int64, error) {
+	var total int64
+	err := applySessionFilter(r.db.Model(&models.Session{}), filter).Count(&total).Error
+	return total, err
+}
+
+// RevokeMatchingSessions revokes every active session matching filter and invalidates its tokens in Redis:
+// refresh tokens are deleted and access tokens are blacklisted for blacklistFor
+func (r *sessionRepository) RevokeMatchingSessions(filter models.SessionFilter, blacklistFor time.Duration) (int64, error) {
+	filter.IncludeRevoked = false
+
+	var sessions []models.Session
+	if err := applySessionFilter(r.db.Model(&models.Session{}), filter).
+		Select("id", "refresh_token", "access_token_hash").
+		Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+
+	var revoked int64
+	for start := 0; start < len(sessions); start += revokeBatchSize {
+		batch := sessions[start:min(start+revokeBatchSize, len(sessions))]
+
+		ids := make([]uuid.UUID, len(batch))
+		for i, session := range batch {
+			ids[i] = session.ID
+		}
+		result := r.db.Model(&models.Session{}).
+			Where("id IN ? AND is_revoked = ?", ids, false).
+			Updates(map[string]interface{}{"is_revoked": true, "is_active": false})
+		if result.Error != nil {
+			return revoked, result.Error
+		}
+		revoked += result.RowsAffected
+
+		r.publishRevoked(batch, blacklistFor)
+		if err := r.invalidateSessionTokens(batch, blacklistFor); err != nil {
+			return revoked, fmt.Errorf("sessions revoked but token invalidation failed: %w", err)
+		}
+	}
+
+	return revoked, nil
+}
+
+// RevokeExcessSessions revokes the user's active sessions beyond the newest keep and invalidates their tokens
+func (r *sessionRepository) RevokeExcessSessions(userID uuid.UUID, keep int, blacklistFor time.Duration) (int64, error) {
+	var sessions []models.Session
+	if err := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND is_revoked = ?", userID, false).
+		Order("created_at DESC").
+		Offset(keep).
+		Select("id", "refresh_token", "access_token_hash").
+		Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+	if len(sessions) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+	result := r.db.Model(&models.Session{}).
+		Where("id IN ? AND is_revoked = ?", ids, false).
+		Updates(map[string]interface{}{"is_revoked": true, "is_active": false})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	r.publishRevoked(sessions, blacklistFor)
+	if err := r.invalidateSessionTokens(sessions, blacklistFor); err != nil {
+		return result.RowsAffected, fmt.Errorf("sessions revoked but token invalidation failed: %w", err)
+	}
+	return result.RowsAffected, nil
+}
+
+// ListActiveUserSessions returns the user's sessions that are neither revoked nor expired, most recently used first
+func (r *sessionRepository) ListActiveUserSessions(userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND is_revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("COALESCE(last_used_at, created_at) DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeUserSession revokes one of the user's active sessions and invalidates its tokens
+// Sessions of other users are reported as ErrSessionNotFound, so IDs can't be probed
+func (r *sessionRepository) RevokeUserSession(userID, sessionID uuid.UUID, blacklistFor time.Duration) error {
+	var session models.Session
+	err := r.db.Where("id = ? AND user_id = ? AND is_revoked = ?", sessionID, userID, false).
+		Select("id", "refresh_token", "access_token_hash").
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND is_revoked = ?", session.ID, false).
+		Updates(map[string]interface{}{"is_revoked": true, "is_active": false})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound // Revoked concurrently
+	}
+
+	sessions := []models.Session{session}
+	r.publishRevoked(sessions, blacklistFor)
+	if err := r.invalidateSessionTokens(sessions, blacklistFor); err != nil {
+		return fmt.Errorf("session revoked but token invalidation failed: %w", err)
+	}
+	return nil
+}
+
+// RecordSessionRefresh moves the session holding oldRefreshHash onto the rotated token pair and marks it used,
+// so revoking the session invalidates the tokens its device holds now. Only the local row is updated:
+// other regions keep the hashes the session was created with, and revocations carry the current ones
+func (r *sessionRepository) RecordSessionRefresh(oldRefreshHash, newRefreshHash, accessTokenHash string, expiresAt time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("refresh_token = ? AND is_revoked = ?", oldRefreshHash, false).
+		Updates(map[string]interface{}{
+			"refresh_token":     newRefreshHash,
+			"access_token_hash": accessTokenHash,
+			"expires_at":        expiresAt,
+			"last_used_at":      time.Now(),
+		}).Error
+}
+
+// invalidateSessionTokens removes refresh tokens and blacklists access tokens for revoked sessions
+func (r *sessionRepository) invalidateSessionTokens(sessions []models.Session, blacklistFor time.Duration) error {
+	ctx := context.Background()
+	pipe := r.redis.Pipeline()
+	for _, session := range sessions {
+		if session.RefreshToken != "" {
+			pipe.Del(ctx, fmt.Sprintf("refresh_token:%s", session.RefreshToken))
+		}
+		if session.AccessTokenHash != "" {
+			pipe.Set(ctx, fmt.Sprintf("blacklist:%s", session.AccessTokenHash), "1", blacklistFor)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
 // Redis-based token management
 func (r *sessionRepository) StoreRefreshToken(userID uuid.UUID, tokenHash string, expiry time.Duration) error {
-	ctx := context.Background()
-	
-	tokenData := map[string]interface{}{
-		"user_id":    userID.String(),
-		"created_at": time.Now(),
+	now := time.Now()
+	if err := r.storeRefreshToken(context.Background(), r.redis, userID, tokenHash, now, expiry); err != nil {
+		return err
 	}
-	
-	data, err := json.Marshal(tokenData)
+	r.publish(&SessionEvent{Type: SessionEventRefreshStored, UserID: userID, TokenHash: tokenHash, ExpiresAt: now.Add(expiry)})
+	return nil
+}
+
+// storeRefreshToken writes the refresh token record, directly or in a pipeline; replicated tokens keep the
+// time they were created
+func (r *sessionRepository) storeRefreshToken(ctx context.Context, cmd redis.Cmdable, userID uuid.UUID, tokenHash string, createdAt time.Time, expiry time.Duration) error {
+	data, err := refreshTokenData(userID, createdAt)
 	if err != nil {
 		return err
 	}
 	
 	key := fmt.Sprintf("refresh_token:%s", tokenHash)
-	return r.redis.Set(ctx, key, data, expiry).Err()
+	return cmd.Set(ctx, key, data, expiry).Err()
+}
+
+// refreshTokenData encodes the record stored under a refresh token hash
+func refreshTokenData(userID uuid.UUID, createdAt time.Time) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"user_id":    userID.String(),
+		"created_at": createdAt,
+	})
 }
 
 func (r *sessionRepository) GetRefreshTokenData(tokenHash string) (string, error) {
@@ -141,13 +420,21 @@ func (r *sessionRepository) GetRefreshTokenData(tokenHash string) (string, error
 func (r *sessionRepository) DeleteRefreshToken(tokenHash string) error {
 	ctx := context.Background()
 	key := fmt.Sprintf("refresh_token:%s", tokenHash)
-	return r.redis.Del(ctx, key).Err()
+	if err := r.redis.Del(ctx, key).Err(); err != nil {
+		return err
+	}
+	r.publish(&SessionEvent{Type: SessionEventRefreshDeleted, TokenHash: tokenHash})
+	return nil
 }
 
 func (r *sessionRepository) BlacklistToken(tokenHash string, expiry time.Duration) error {
 	ctx := context.Background()
 	key := fmt.Sprintf("blacklist:%s", tokenHash)
-	return r.redis.Set(ctx, key, "1", expiry).Err()
+	if err := r.redis.Set(ctx, key, "1", expiry).Err(); err != nil {
+		return err
+	}
+	r.publish(&SessionEvent{Type: SessionEventAccessBlacklisted, TokenHash: tokenHash, ExpiresAt: time.Now().Add(expiry)})
+	return nil
 }
 
 func (r *sessionRepository) IsTokenBlacklisted(tokenHash string) (bool, error) {
@@ -163,4 +450,142 @@ func (r *sessionRepository) IsTokenBlacklisted(tokenHash string) (bool, error) {
 	}
 	
 	return true, nil
+}
+
+// Redis keys of refresh token families
+const (
+	rotatedRefreshKey = "refresh_rotated:%s"        // Family ID of a refresh token hash that was rotated
+	refreshFamilyKey  = "refresh_family:%s"         // Hashes of the refresh tokens issued in a family
+	revokedFamilyKey  = "refresh_family_revoked:%s" // Family ended because a rotated token was reused
+)
+
+// rotateRefreshScript claims the old refresh token by deleting it and only then stores its replacement,
+// so of two rotations of the same token exactly one succeeds. Returns 1 when rotated, 0 when the old
+// token was already gone
+var rotateRefreshScript = redis.NewScript(`
+if redis.call('DEL', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[2])
+redis.call('SET', KEYS[3], ARGV[3], 'PX', ARGV[4])
+redis.call('SADD', KEYS[4], ARGV[5], ARGV[6])
+redis.call('PEXPIRE', KEYS[4], ARGV[7])
+return 1
+`)
+
+// RotateRefreshToken replaces oldHash with newHash in family familyID in one atomic step
+// oldHash is remembered as rotated for rotatedFor, normally its remaining lifetime, so replaying it is
+// recognised as reuse instead of failing as an unknown token. ErrRefreshTokenNotCurrent when oldHash was
+// already rotated or deleted, in which case nothing is stored
+func (r *sessionRepository) RotateRefreshToken(userID uuid.UUID, familyID, oldHash, newHash string, expiry, rotatedFor time.Duration) error {
+	ctx := context.Background()
+	now := time.Now()
+	data, err := refreshTokenData(userID, now)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{
+		fmt.Sprintf("refresh_token:%s", oldHash),
+		fmt.Sprintf("refresh_token:%s", newHash),
+		fmt.Sprintf(rotatedRefreshKey, oldHash),
+		fmt.Sprintf(refreshFamilyKey, familyID),
+	}
+	rotated, err := rotateRefreshScript.Run(ctx, r.redis, keys,
+		data, expiry.Milliseconds(), familyID, rotatedFor.Milliseconds(),
+		oldHash, newHash, max(expiry, rotatedFor).Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if rotated == 0 {
+		return ErrRefreshTokenNotCurrent
+	}
+
+	r.publish(&SessionEvent{Type: SessionEventRefreshStored, UserID: userID, TokenHash: newHash, ExpiresAt: now.Add(expiry)})
+	r.publish(&SessionEvent{Type: SessionEventRefreshRotated, UserID: userID, TokenHash: oldHash, FamilyID: familyID, ExpiresAt: now.Add(rotatedFor)})
+	return nil
+}
+
+// GetRotatedRefreshTokenFamily returns the family of a refresh token that was already rotated, or ""
+func (r *sessionRepository) GetRotatedRefreshTokenFamily(tokenHash string) (string, error) {
+	familyID, err := r.redis.Get(context.Background(), fmt.Sprintf(rotatedRefreshKey, tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return familyID, err
+}
+
+func (r *sessionRepository) IsRefreshFamilyRevoked(familyID string) (bool, error) {
+	n, err := r.redis.Exists(context.Background(), fmt.Sprintf(revokedFamilyKey, familyID)).Result()
+	return n > 0, err
+}
+
+// RevokeRefreshFamily ends a refresh token family: its tokens are deleted and the family is remembered
+// as revoked for revokeFor, so tokens issued concurrently are refused too. The login session that started
+// the family is revoked and its access token blacklisted for blacklistFor
+// It returns how many of the family's tokens were known
+func (r *sessionRepository) RevokeRefreshFamily(familyID string, revokeFor, blacklistFor time.Duration) (int, error) {
+	ctx := context.Background()
+	familyKey := fmt.Sprintf(refreshFamilyKey, familyID)
+	hashes, err := r.redis.SMembers(ctx, familyKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	pipe := r.redis.TxPipeline()
+	for _, hash := range hashes {
+		pipe.Del(ctx, fmt.Sprintf("refresh_token:%s", hash))
+	}
+	pipe.Set(ctx, fmt.Sprintf(revokedFamilyKey, familyID), "1", revokeFor)
+	pipe.Del(ctx, familyKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	r.publish(&SessionEvent{Type: SessionEventFamilyRevoked, FamilyID: familyID, RefreshTokens: hashes, ExpiresAt: time.Now().Add(revokeFor)})
+
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+	var sessions []models.Session
+	if err := r.db.Model(&models.Session{}).
+		Where("refresh_token IN ? AND is_revoked = ?", hashes, false).
+		Select("id", "refresh_token", "access_token_hash").
+		Find(&sessions).Error; err != nil {
+		return len(hashes), fmt.Errorf("refresh token family revoked but its session wasn't: %w", err)
+	}
+	if len(sessions) == 0 {
+		return len(hashes), nil
+	}
+
+	ids := make([]uuid.UUID, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+	if err := r.db.Model(&models.Session{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"is_revoked": true, "is_active": false}).Error; err != nil {
+		return len(hashes), fmt.Errorf("refresh token family revoked but its session wasn't: %w", err)
+	}
+	r.publishRevoked(sessions, blacklistFor)
+	if err := r.invalidateSessionTokens(sessions, blacklistFor); err != nil {
+		return len(hashes), fmt.Errorf("session revoked but token invalidation failed: %w", err)
+	}
+	return len(hashes), nil
+}
+
+func (r *sessionRepository) BlockIP(ipHash string, duration time.Duration) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("blocked_ip:%s", ipHash)
+	return r.redis.Set(ctx, key, "1", duration).Err()
+}
+
+func (r *sessionRepository) IsIPBlocked(ipHash string) (bool, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("blocked_ip:%s", ipHash)
+	
+	n, err := r.redis.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
 }
\ No newline at end of file
@@ -2,15 +2,42 @@ package repositories
 
 import (
 	"auth-service/internal/models"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	ErrUserPreferencesNotFound = errors.New("user preferences not found")
+	ErrInvitationNotFound      = errors.New("no pending invitation for this user")
+	ErrFeedTokenNotFound       = errors.New("feed token not found")
+	ErrRegistrationNotFound    = errors.New("no pending registration for this user")
+	ErrRoleGrantNotFound       = errors.New("no active role grant with this ID")
+	ErrRoleNotFound            = errors.New("role not found")
+	ErrPermissionNotFound      = errors.New("permission not found")
+	ErrRoleAssignmentNotFound  = errors.New("user doesn't have this role")
+	ErrRoleAlreadyAssigned     = errors.New("user already has this role")
+	ErrPasswordResetNotFound   = errors.New("no pending password reset for this token")
+	ErrHoneypotNotFound        = errors.New("no active honeypot matches")
+	ErrPasskeyNotFound         = errors.New("passkey not found")
+	ErrAccessTokenNotFound     = errors.New("personal access token not found")
+	ErrOAuthClientNotFound     = errors.New("oauth client not found")
+	ErrOAuthIdentityNotFound   = errors.New("no account is linked to this provider identity")
+	ErrOAuthIdentityConflict   = errors.New("account is already linked to a different identity at this provider")
+	ErrOAuthIdentityInUse      = errors.New("this provider identity is linked to another account")
+	ErrOAuthNotLinked          = errors.New("account is not linked to this provider")
+	ErrLastLoginMethod         = errors.New("this is the account's only way to log in; set a password, add a passkey or link another provider first")
+	ErrMergeUserNotFound       = errors.New("both accounts must exist and not be deleted")
+	ErrMergeTargetInactive     = errors.New("the surviving account must be active")
+	ErrMergeIdentityConflict   = errors.New("both accounts are linked to different identities at the same provider")
+	ErrUserNotPurgeable        = errors.New("account is not deleted, not past its retention period or already purged")
 )
 
 // allowedProfileFields defines which fields can be updated via UpdateProfile
@@ -63,12 +90,17 @@ type UserRepository interface {
 	GetByEmail(email string) (*models.User, error)
 	GetByUsername(username string) (*models.User, error)
 	GetByOAuthID(provider, oauthID string) (*models.User, error)
+	LinkOAuthIdentity(userID uuid.UUID, provider, oauthID string) (bool, error)
+	AttachOAuthIdentity(userID uuid.UUID, provider, oauthID string) error
+	UnlinkOAuthIdentity(userID uuid.UUID, provider string) error
 	Update(user *models.User) error
 	Delete(userID uuid.UUID) error
 	UpdateLastLogin(userID uuid.UUID, ipAddress string) error
 	IncrementFailedAttempts(userID uuid.UUID) error
 	ResetFailedAttempts(userID uuid.UUID) error
 	CreateLoginAttempt(attempt *models.LoginAttempt) error
+	HasSignedInFrom(userID uuid.UUID, device models.SignInDevice, since time.Time) (bool, error)
+	ListLoginAttempts(userID uuid.UUID, limit, offset int) ([]models.LoginAttempt, int64, error)
 	IsEmailTaken(email string) (bool, error)
 	IsUsernameTaken(username string) (bool, error)
 	
@@ -82,14 +114,140 @@ type UserRepository interface {
 	
 	// Extended User Service functionality - User Activities
 	GetUserActivities(userID uuid.UUID, limit, offset int) ([]models.UserActivity, error)
+	CountUserActivities(userID uuid.UUID) (int64, error)
 	CreateUserActivity(activity *models.UserActivity) error
 	
 	// Extended User Service functionality - User Notifications
 	GetUserNotifications(userID uuid.UUID) ([]models.UserNotification, error)
+	CountUnreadNotifications(userID uuid.UUID) (int64, error)
 	CreateUserNotification(notification *models.UserNotification) error
 	MarkNotificationAsRead(userID, notificationID uuid.UUID) error
+
+	// Two-factor policy compliance
+	ListTwoFactorStatus(roles []models.UserRole) ([]models.TwoFactorStatus, error)
+
+	// Admin invitations - invited users stay inactive until they accept
+	CreateInvitedUser(user *models.User) error
+	GetPendingInvitation(userID uuid.UUID) (*models.User, error)
+
+	// Feed subscriptions - personal tokens are stored hashed
+	SetFeedTokenHash(userID uuid.UUID, hash *string) error
+	GetByFeedTokenHash(hash string) (*models.User, error)
+
+	// Registration approval - queued sign-ups stay inactive until an admin approves them
+	CreatePendingRegistration(user *models.User) error
+	ListPendingRegistrations(limit, offset int) ([]models.User, int64, error)
+	GetRegistrationByEmail(email string) (*models.User, error)
+	ReviewRegistration(userID, adminID uuid.UUID, status string) (*models.User, error)
+
+	// Role grants - time-boxed extra roles; ending a grant bumps the user's token version
+	CreateRoleGrant(grant *models.RoleGrant) error
+	GetActiveRoleGrants(userID uuid.UUID, now time.Time) ([]models.RoleGrant, error)
+	ListRoleGrants(userID uuid.UUID, activeOnly bool, limit, offset int) ([]models.RoleGrant, int64, error)
+	EndRoleGrant(grantID uuid.UUID, endedBy *uuid.UUID, reason string) (*models.RoleGrant, error)
+	EndExpiredRoleGrants(now time.Time) ([]models.RoleGrant, error)
+	GetTokenVersion(userID uuid.UUID) (int, error)
+
+	// Roles and permissions - system roles follow users.role, custom roles are assigned in user_roles
+	// Taking a role away from a user, or deleting it, bumps the token version of its holders
+	ListRoles() ([]models.Role, error)
+	GetRole(roleID uuid.UUID) (*models.Role, error)
+	GetRoleByName(name string) (*models.Role, error)
+	CreateRole(role *models.Role, permissionIDs []uuid.UUID, grantedBy *uuid.UUID) error
+	UpdateRole(roleID uuid.UUID, updates map[string]interface{}) (*models.Role, error)
+	DeleteRole(roleID uuid.UUID) (*models.Role, error)
+	SetRolePermissions(roleID uuid.UUID, permissionIDs []uuid.UUID, grantedBy *uuid.UUID) error
+	ListPermissions() ([]models.Permission, error)
+	GetPermissionsByName(names []string) ([]models.Permission, error)
+	CreatePermission(permission *models.Permission) error
+	DeletePermission(permissionID uuid.UUID) (*models.Permission, error)
+	ListUserRoles(userID uuid.UUID) ([]models.UserRoleAssignment, error)
+	AssignRole(assignment *models.UserRoleAssignment) error
+	UnassignRole(userID, roleID uuid.UUID) error
+	GetTokenRoles(userID uuid.UUID, systemRoles []string) (roles, permissions []string, err error)
+
+	// UpdatePasswordHash replaces the hash only while it is still oldHash, so a concurrent password change wins
+	UpdatePasswordHash(userID uuid.UUID, oldHash, newHash string) error
+
+	// Password resets - a reset is used once; using it changes the password and ends earlier tokens
+	CreatePasswordReset(reset *models.PasswordReset) error
+	CompletePasswordReset(userID uuid.UUID, tokenHash, passwordHash string, now time.Time) error
+
+	// Honeypots - planted credentials; triggering one counts the use and records it in the audit trail
+	CreateHoneypot(honeypot *models.Honeypot) error
+	ListHoneypots(includeDisabled bool) ([]models.Honeypot, error)
+	DisableHoneypot(honeypotID uuid.UUID) (*models.Honeypot, error)
+	TriggerHoneypot(kind, identifier string, trigger *models.HoneypotTrigger) (*models.Honeypot, error)
+	ListHoneypotTriggers(honeypotID uuid.UUID, limit, offset int) ([]models.HoneypotTrigger, int64, error)
+
+	// Notification retention - old read or expired notifications are summarized, then archived or deleted
+	CompactNotifications(olderThan, now time.Time, archive bool, limit int) (NotificationCompaction, error)
+
+	// Activity archive - old activities move to objects in object storage, listed per user in the manifest
+	ArchiveActivities(olderThan time.Time, limit int, write ActivityArchiveWriter) (int64, error)
+	ListActivityArchives(userID uuid.UUID) ([]models.UserActivityArchive, error)
+
+	// Data keys - one wrapped key per user, deleted with the account to shred the user's data sealed in Redis
+	GetDataKey(userID uuid.UUID) (*models.UserDataKey, error)
+	CreateDataKey(key *models.UserDataKey) (*models.UserDataKey, error)
+
+	// Passkeys - WebAuthn credentials, looked up by the credential ID the authenticator returns
+	CreateWebAuthnCredential(credential *models.WebAuthnCredential) error
+	ListWebAuthnCredentials(userID uuid.UUID) ([]models.WebAuthnCredential, error)
+	GetWebAuthnCredential(credentialID string) (*models.WebAuthnCredential, error)
+	RenameWebAuthnCredential(userID, id uuid.UUID, name string) (*models.WebAuthnCredential, error)
+	DeleteWebAuthnCredential(userID, id uuid.UUID) error
+	RecordWebAuthnCredentialUse(id uuid.UUID, signCount int64, backupState bool, usedAt time.Time) error
+
+	// Push subscriptions - one per browser endpoint; saving an endpoint again replaces its keys and owner
+	SavePushSubscription(subscription *models.PushSubscription) error
+	ListPushSubscriptions(userID uuid.UUID) ([]models.PushSubscription, error)
+	DeletePushSubscription(userID, id uuid.UUID) error
+	DeletePushSubscriptionByEndpoint(endpoint string) error
+
+	// Personal access tokens - looked up by the SHA-256 of the token; revoked tokens are kept
+	CreatePersonalAccessToken(token *models.PersonalAccessToken) error
+	ListPersonalAccessTokens(userID uuid.UUID) ([]models.PersonalAccessToken, error)
+	GetPersonalAccessTokenByHash(tokenHash string) (*models.PersonalAccessToken, error)
+	RevokePersonalAccessToken(userID, id uuid.UUID) (*models.PersonalAccessToken, error)
+	RevokeAllPersonalAccessTokens(userID uuid.UUID) (int64, error)
+	RecordPersonalAccessTokenUse(id uuid.UUID, usedAt time.Time, interval time.Duration) error
+
+	// OAuth clients - services using the client-credentials grant, looked up by client_id
+	CreateOAuthClient(client *models.OAuthClient) error
+	ListOAuthClients() ([]models.OAuthClient, error)
+	GetOAuthClient(clientID string) (*models.OAuthClient, error)
+	UpdateOAuthClient(clientID string, updates map[string]interface{}) (*models.OAuthClient, error)
+	DeleteOAuthClient(clientID string) (*models.OAuthClient, error)
+	RecordOAuthClientUse(id uuid.UUID, usedAt time.Time, interval time.Duration) error
+
+	// User merges - a duplicate's data moves to the surviving account; the audit entries can't be changed
+	MergeUsers(merge *models.UserMerge) (*models.UserMergeCounts, error)
+	ListUserMerges(userID uuid.UUID) ([]models.UserMerge, error)
+
+	// Audit log - an append-only hash chain; appends are serialized so each entry links to the one before
+	AppendAuditLog(entry *models.AuditLogEntry, seal func(entry *models.AuditLogEntry) error) error
+	ListAuditLog(filter models.AuditLogFilter, limit, offset int) ([]models.AuditLogEntry, int64, error)
+	ListAuditLogAfter(filter models.AuditLogFilter, afterSeq int64, limit int) ([]models.AuditLogEntry, error)
+
+	// Deleted user retention - accounts soft-deleted long enough ago lose their data, then their row or its personal data
+	ListPurgeableUsers(deletedBefore time.Time, limit int) ([]models.User, error)
+	PurgeUser(userID uuid.UUID, deletedBefore time.Time, anonymize bool, removeArchives ActivityArchiveRemover) (*models.UserPurgeCounts, error)
+}
+
+// NotificationCompaction counts what one CompactNotifications batch did
+type NotificationCompaction struct {
+	Removed   int64 // Notifications removed from user_notifications
+	Archived  int64 // Of those, copied to user_notifications_archive
+	Summaries int64 // Monthly summary rows created or updated
 }
 
+// ActivityArchiveRemover deletes the objects of a purged user's activity archives
+type ActivityArchiveRemover func(archives []models.UserActivityArchive) error
+
+// ActivityArchiveWriter stores one user's activities, newest first, as an object and returns its manifest row
+type ActivityArchiveWriter func(userID uuid.UUID, activities []models.UserActivity) (*models.UserActivityArchive, error)
+
 type userRepository struct {
 	db *gorm.DB
 }
@@ -138,46 +296,217 @@ func (r *userRepository) GetByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
+// oauthColumns maps the built-in OAuth providers to the users column holding the provider's ID
+// OIDC providers configured under oauth2.oidc keep theirs in user_oauth_identities
+var oauthColumns = map[string]string{
+	models.OAuthProviderGoogle:   "google_id",
+	models.OAuthProviderGitHub:   "git_hub_id",
+	models.OAuthProviderFacebook: "facebook_id",
+	models.OAuthProviderApple:    "apple_id",
+}
+
+// GetByOAuthID returns the account linked to the provider identity, including inactive ones so the
+// caller can tell a deactivated or queued account from an unknown identity
 func (r *userRepository) GetByOAuthID(provider, oauthID string) (*models.User, error) {
-	var user models.User
-	var err error
+	if oauthID == "" {
+		return nil, ErrOAuthIdentityNotFound
+	}
 
-	switch provider {
-	case "google":
-		err = r.db.Where("google_id = ? AND is_active = ?", oauthID, true).First(&user).Error
-	case "github":
-		err = r.db.Where("git_hub_id = ? AND is_active = ?", oauthID, true).First(&user).Error
-	case "facebook":
-		err = r.db.Where("facebook_id = ? AND is_active = ?", oauthID, true).First(&user).Error
-	default:
-		return nil, errors.New("unsupported OAuth provider")
+	query := r.db
+	if column, ok := oauthColumns[provider]; ok {
+		query = query.Where(column+" = ?", oauthID)
+	} else {
+		owner := r.db.Model(&models.OAuthIdentity{}).Select("user_id").Where("provider = ? AND subject = ?", provider, oauthID)
+		query = query.Where("id IN (?)", owner)
 	}
 
-	if err != nil {
+	var user models.User
+	if err := query.First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrOAuthIdentityNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
+// LinkOAuthIdentity stores the provider identity on the account and marks its email verified, since the
+// provider vouched for the address. An account whose email was never verified also loses its password
+// and has its token version bumped: whoever set them never proved they own the address. It reports
+// whether that happened, so the caller can end the account's sessions
+func (r *userRepository) LinkOAuthIdentity(userID uuid.UUID, provider, oauthID string) (bool, error) {
+	secured := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		linked, err := linkedOAuthID(tx, &user, provider)
+		if err != nil {
+			return err
+		}
+		if linked != "" && linked != oauthID {
+			return ErrOAuthIdentityConflict
+		}
+		if linked == "" {
+			if err := setOAuthID(tx, userID, provider, oauthID); err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{"email_verified": true}
+		if !user.EmailVerified {
+			updates["password_hash"] = ""
+			secured = true
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if secured {
+			return bumpTokenVersion(tx, []uuid.UUID{userID})
+		}
+		return nil
+	})
+	return secured, err
+}
+
+// AttachOAuthIdentity links a signed-in user's account to the provider identity. Unlike LinkOAuthIdentity
+// the user proved they own the account, so the email and password are left alone
+func (r *userRepository) AttachOAuthIdentity(userID uuid.UUID, provider, oauthID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		linked, err := linkedOAuthID(tx, &user, provider)
+		if err != nil {
+			return err
+		}
+		if linked == oauthID {
+			return nil
+		} else if linked != "" {
+			return ErrOAuthIdentityConflict
+		}
+
+		taken, err := oauthIDTaken(tx, provider, oauthID, userID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrOAuthIdentityInUse
+		}
+		return setOAuthID(tx, userID, provider, oauthID)
+	})
+}
+
+// UnlinkOAuthIdentity removes the provider identity from the account, provided the user can still log in
+// with a password, a passkey or another provider afterwards
+func (r *userRepository) UnlinkOAuthIdentity(userID uuid.UUID, provider string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		linked, err := linkedOAuthID(tx, &user, provider)
+		if err != nil {
+			return err
+		}
+		if linked == "" {
+			return ErrOAuthNotLinked
+		}
+
+		remaining := user.PasswordHash != ""
+		for other := range oauthColumns {
+			if other != provider && user.OAuthID(other) != "" {
+				remaining = true
+			}
+		}
+		if !remaining {
+			var others int64
+			if err := tx.Model(&models.OAuthIdentity{}).Where("user_id = ? AND provider <> ?", userID, provider).Count(&others).Error; err != nil {
+				return err
+			}
+			var passkeys int64
+			if err := tx.Model(&models.WebAuthnCredential{}).Where("user_id = ?", userID).Count(&passkeys).Error; err != nil {
+				return err
+			}
+			if others == 0 && passkeys == 0 {
+				return ErrLastLoginMethod
+			}
+		}
+		return setOAuthID(tx, userID, provider, "")
+	})
+}
+
+// linkedOAuthID returns the user's ID at the provider, or "" when the account isn't linked to it
+func linkedOAuthID(tx *gorm.DB, user *models.User, provider string) (string, error) {
+	if _, ok := oauthColumns[provider]; ok {
+		return user.OAuthID(provider), nil
+	}
+	var identity models.OAuthIdentity
+	err := tx.Where("user_id = ? AND provider = ?", user.ID, provider).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return identity.Subject, err
+}
+
+// setOAuthID links the user to oauthID at the provider, or unlinks it when oauthID is ""
+func setOAuthID(tx *gorm.DB, userID uuid.UUID, provider, oauthID string) error {
+	if column, ok := oauthColumns[provider]; ok {
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update(column, oauthID).Error
+	}
+	if oauthID == "" {
+		return tx.Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.OAuthIdentity{}).Error
+	}
+	return tx.Create(&models.OAuthIdentity{UserID: userID, Provider: provider, Subject: oauthID}).Error
+}
+
+// oauthIDTaken reports whether another account is linked to oauthID at the provider
+func oauthIDTaken(tx *gorm.DB, provider, oauthID string, userID uuid.UUID) (bool, error) {
+	var owners int64
+	var err error
+	if column, ok := oauthColumns[provider]; ok {
+		err = tx.Model(&models.User{}).Where(column+" = ? AND id <> ?", oauthID, userID).Count(&owners).Error
+	} else {
+		err = tx.Model(&models.OAuthIdentity{}).Where("provider = ? AND subject = ? AND user_id <> ?", provider, oauthID, userID).Count(&owners).Error
+	}
+	return owners > 0, err
+}
+
 func (r *userRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+func (r *userRepository) UpdatePasswordHash(userID uuid.UUID, oldHash, newHash string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND password_hash = ?", userID, oldHash).
+		UpdateColumn("password_hash", newHash).Error
+}
+
 func (r *userRepository) Delete(userID uuid.UUID) error {
-	// Soft delete by setting deleted_at timestamp
-	return r.db.Delete(&models.User{}, userID).Error
+	// Soft delete by setting deleted_at timestamp; the data key goes with it, shredding the user's data
+	// sealed in Redis, since the soft delete doesn't cascade
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.User{}, userID).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserDataKey{}).Error
+	})
 }
 
+// UpdateLastLogin records the login time; an empty ipAddress (e.g. under hmac IP storage) is stored as NULL
 func (r *userRepository) UpdateLastLogin(userID uuid.UUID, ipAddress string) error {
 	now := time.Now()
+	var lastLoginIP interface{}
+	if ipAddress != "" {
+		lastLoginIP = ipAddress
+	}
 	return r.db.Model(&models.User{}).
 		Where("id = ?", userID).
 		Updates(map[string]interface{}{
 			"last_login_at": now,
-			"last_login_ip": ipAddress,
+			"last_login_ip": lastLoginIP,
 		}).Error
 }
 
@@ -200,6 +529,52 @@ func (r *userRepository) CreateLoginAttempt(attempt *models.LoginAttempt) error
 	return r.db.Create(attempt).Error
 }
 
+// HasSignedInFrom reports whether the user logged in successfully since from the device's network with
+// the same user agent, or started a session there with the same TLS fingerprints (browser updates change
+// the user agent but not the fingerprints)
+func (r *userRepository) HasSignedInFrom(userID uuid.UUID, device models.SignInDevice, since time.Time) (bool, error) {
+	fromNetwork := func(query *gorm.DB) *gorm.DB {
+		switch {
+		case device.Network != "":
+			return query.Where("ip_address <<= ?::inet", device.Network)
+		case device.IPHash != "":
+			return query.Where("ip_hash = ?", device.IPHash)
+		default:
+			return query
+		}
+	}
+
+	var attempts int64
+	err := fromNetwork(r.db.Model(&models.LoginAttempt{}).
+		Where("user_id = ? AND success = true AND attempted_at >= ? AND user_agent = ?", userID, since, device.UserAgent)).
+		Count(&attempts).Error
+	if err != nil || attempts > 0 || device.DeviceInfo == "" || device.DeviceInfo == "{}" {
+		return attempts > 0, err
+	}
+
+	var sessions int64
+	err = fromNetwork(r.db.Model(&models.Session{}).
+		Where("user_id = ? AND created_at >= ? AND device_info = ?::jsonb", userID, since, device.DeviceInfo)).
+		Count(&sessions).Error
+	return sessions > 0, err
+}
+
+// ListLoginAttempts returns a page of the user's login attempts, newest first, with the total
+func (r *userRepository) ListLoginAttempts(userID uuid.UUID, limit, offset int) ([]models.LoginAttempt, int64, error) {
+	query := r.db.Model(&models.LoginAttempt{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var attempts []models.LoginAttempt
+	if err := query.Order("attempted_at DESC").Limit(limit).Offset(offset).Find(&attempts).Error; err != nil {
+		return nil, 0, err
+	}
+	return attempts, total, nil
+}
+
 func (r *userRepository) IsEmailTaken(email string) (bool, error) {
 	var count int64
 	err := r.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error
@@ -308,8 +683,8 @@ func validateActivityPaginationParams(limit, offset int) error {
 	return nil
 }
 
-// normalizeActivityLimit normalizes the limit parameter to safe bounds
-func normalizeActivityLimit(limit int) int {
+// NormalizeActivityLimit normalizes the limit parameter to safe bounds
+func NormalizeActivityLimit(limit int) int {
 	// Default limit if zero or too large to prevent potential DoS
 	if limit == 0 || limit > MaxActivityLimit {
 		return DefaultActivityLimit
@@ -325,7 +700,7 @@ func (r *userRepository) GetUserActivities(userID uuid.UUID, limit, offset int)
 	}
 	
 	// Normalize limit to safe bounds
-	limit = normalizeActivityLimit(limit)
+	limit = NormalizeActivityLimit(limit)
 	
 	var activities []models.UserActivity
 	
@@ -343,6 +718,13 @@ func (r *userRepository) GetUserActivities(userID uuid.UUID, limit, offset int)
 	return activities, nil
 }
 
+// CountUserActivities counts the activities of a user still in user_activities
+func (r *userRepository) CountUserActivities(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserActivity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
 // validateUserActivity validates the input UserActivity
 func validateUserActivity(activity *models.UserActivity) error {
 	if activity == nil {
@@ -369,7 +751,7 @@ func validateUserActivity(activity *models.UserActivity) error {
 func setActivityDefaults(activity *models.UserActivity) {
 	// Set defaults if not provided
 	if activity.ID == uuid.Nil {
-		activity.ID = uuid.New()
+		activity.ID = models.NewID()
 	}
 	
 	if activity.CreatedAt.IsZero() {
@@ -426,6 +808,19 @@ func (r *userRepository) GetUserNotifications(userID uuid.UUID) ([]models.UserNo
 	return notifications, nil
 }
 
+// CountUnreadNotifications counts the user's unread notifications that haven't expired
+func (r *userRepository) CountUnreadNotifications(userID uuid.UUID) (int64, error) {
+	if err := validateUserID(userID); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err := r.db.Model(&models.UserNotification{}).
+		Where("user_id = ? AND is_read = ? AND (expires_at IS NULL OR expires_at > ?)", userID, false, time.Now().UTC()).
+		Count(&count).Error
+	return count, err
+}
+
 // Extended User Service functionality implementations - User Notifications Creation
 
 // validateUserNotification validates the input UserNotification
@@ -463,7 +858,7 @@ func validateUserNotification(notification *models.UserNotification) error {
 func setNotificationDefaults(notification *models.UserNotification) {
 	// Set defaults if not provided
 	if notification.ID == uuid.Nil {
-		notification.ID = uuid.New()
+		notification.ID = models.NewID()
 	}
 	
 	if notification.CreatedAt.IsZero() {
@@ -526,4 +921,1346 @@ func (r *userRepository) MarkNotificationAsRead(userID, notificationID uuid.UUID
 	}
 	
 	return nil
+}
+
+// ListTwoFactorStatus returns the two-factor enrollment of every active user in the given roles
+// Users without a preferences row have not enabled two-factor authentication
+func (r *userRepository) ListTwoFactorStatus(roles []models.UserRole) ([]models.TwoFactorStatus, error) {
+	var statuses []models.TwoFactorStatus
+	if len(roles) == 0 {
+		return statuses, nil
+	}
+
+	err := r.db.Table("users").
+		Select("users.id AS user_id, users.email, users.role, users.created_at, COALESCE(user_preferences.two_factor_enabled, false) AS two_factor_enabled").
+		Joins("LEFT JOIN user_preferences ON user_preferences.user_id = users.id").
+		Where("users.role IN ? AND users.is_active = ? AND users.deleted_at IS NULL", roles, true).
+		Order("users.created_at").
+		Scan(&statuses).Error
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// CreateInvitedUser inserts an inactive user awaiting invitation acceptance
+// GORM omits zero-valued fields that have a default, so is_active is cleared explicitly
+func (r *userRepository) CreateInvitedUser(user *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		user.IsActive = false
+		return tx.Model(user).Update("is_active", false).Error
+	})
+}
+
+// GetPendingInvitation returns an invited user who hasn't accepted the invitation yet
+func (r *userRepository) GetPendingInvitation(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("id = ? AND is_active = ? AND invited_at IS NOT NULL", userID, false).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetFeedTokenHash stores the hash of a user's feed token; nil revokes the token
+func (r *userRepository) SetFeedTokenHash(userID uuid.UUID, hash *string) error {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND is_active = ?", userID, true).
+		Update("feed_token_hash", hash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// GetByFeedTokenHash returns the active user a feed token belongs to
+func (r *userRepository) GetByFeedTokenHash(hash string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("feed_token_hash = ? AND is_active = ?", hash, true).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFeedTokenNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreatePendingRegistration inserts an inactive user awaiting registration approval
+// GORM omits zero-valued fields that have a default, so is_active is cleared explicitly
+func (r *userRepository) CreatePendingRegistration(user *models.User) error {
+	status := models.ApprovalPending
+	user.ApprovalStatus = &status
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		user.IsActive = false
+		return tx.Model(user).Update("is_active", false).Error
+	})
+}
+
+// ListPendingRegistrations returns a page of sign-ups awaiting approval, oldest first, with the total
+func (r *userRepository) ListPendingRegistrations(limit, offset int) ([]models.User, int64, error) {
+	query := r.db.Model(&models.User{}).Where("approval_status = ?", models.ApprovalPending)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := query.Order("created_at").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// GetRegistrationByEmail returns an inactive user whose sign-up is pending or was rejected
+func (r *userRepository) GetRegistrationByEmail(email string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("email = ? AND is_active = ? AND approval_status IN ?", email, false,
+		[]string{models.ApprovalPending, models.ApprovalRejected}).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRegistrationNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ReviewRegistration approves or rejects a pending sign-up; approval activates the account
+// The update only matches pending rows, so concurrent reviews of the same sign-up can't both succeed
+func (r *userRepository) ReviewRegistration(userID, adminID uuid.UUID, status string) (*models.User, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND approval_status = ?", userID, models.ApprovalPending).
+		Updates(map[string]interface{}{
+			"approval_status":      status,
+			"approval_reviewed_by": adminID,
+			"approval_reviewed_at": time.Now(),
+			"is_active":            status == models.ApprovalApproved,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRegistrationNotFound
+	}
+
+	var user models.User
+	if err := r.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateRoleGrant stores a new role grant
+func (r *userRepository) CreateRoleGrant(grant *models.RoleGrant) error {
+	return r.db.Create(grant).Error
+}
+
+// GetActiveRoleGrants returns the grants in effect for a user at now
+func (r *userRepository) GetActiveRoleGrants(userID uuid.UUID, now time.Time) ([]models.RoleGrant, error) {
+	var grants []models.RoleGrant
+	err := r.db.Where("user_id = ? AND ended_at IS NULL AND expires_at > ?", userID, now).
+		Order("expires_at").Find(&grants).Error
+	return grants, err
+}
+
+// ListRoleGrants returns a page of grants, newest first, with the total
+// uuid.Nil lists the grants of every user; activeOnly hides expired and revoked grants
+func (r *userRepository) ListRoleGrants(userID uuid.UUID, activeOnly bool, limit, offset int) ([]models.RoleGrant, int64, error) {
+	query := r.db.Model(&models.RoleGrant{})
+	if userID != uuid.Nil {
+		query = query.Where("user_id = ?", userID)
+	}
+	if activeOnly {
+		query = query.Where("ended_at IS NULL AND expires_at > ?", time.Now())
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var grants []models.RoleGrant
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&grants).Error; err != nil {
+		return nil, 0, err
+	}
+	return grants, total, nil
+}
+
+// EndRoleGrant ends an active grant early and bumps the user's token version in one transaction
+// endedBy is the revoking admin; the update only matches active grants, so a grant ends once
+func (r *userRepository) EndRoleGrant(grantID uuid.UUID, endedBy *uuid.UUID, reason string) (*models.RoleGrant, error) {
+	var grant models.RoleGrant
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RoleGrant{}).
+			Where("id = ? AND ended_at IS NULL", grantID).
+			Updates(map[string]interface{}{
+				"ended_at":   time.Now(),
+				"ended_by":   endedBy,
+				"end_reason": reason,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRoleGrantNotFound
+		}
+
+		if err := tx.Where("id = ?", grantID).First(&grant).Error; err != nil {
+			return err
+		}
+		return bumpTokenVersion(tx, []uuid.UUID{grant.UserID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// EndExpiredRoleGrants marks every grant that expired by now as ended and bumps the token
+// version of the affected users in one transaction; it returns the grants it ended
+// Rows are locked with SKIP LOCKED so replicas running the expiry job don't end a grant twice
+func (r *userRepository) EndExpiredRoleGrants(now time.Time) ([]models.RoleGrant, error) {
+	var grants []models.RoleGrant
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`SELECT * FROM role_grants WHERE ended_at IS NULL AND expires_at <= ? FOR UPDATE SKIP LOCKED`, now).
+			Scan(&grants).Error; err != nil {
+			return err
+		}
+		if len(grants) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(grants))
+		seen := make(map[uuid.UUID]bool)
+		var userIDs []uuid.UUID
+		for i := range grants {
+			ids = append(ids, grants[i].ID)
+			grants[i].EndedAt = &now
+			reason := models.GrantEndExpired
+			grants[i].EndReason = &reason
+			if !seen[grants[i].UserID] {
+				seen[grants[i].UserID] = true
+				userIDs = append(userIDs, grants[i].UserID)
+			}
+		}
+
+		if err := tx.Model(&models.RoleGrant{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"ended_at":   now,
+			"end_reason": models.GrantEndExpired,
+		}).Error; err != nil {
+			return err
+		}
+		return bumpTokenVersion(tx, userIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// GetTokenVersion returns the current token version of an active user
+func (r *userRepository) GetTokenVersion(userID uuid.UUID) (int, error) {
+	var user models.User
+	err := r.db.Select("token_version").Where("id = ? AND is_active = ?", userID, true).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("user not found")
+		}
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
+
+// ListRoles returns every role with its permissions, highest priority first
+func (r *userRepository) ListRoles() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("permissions.name")
+	}).Order("priority DESC, name").Find(&roles).Error
+	return roles, err
+}
+
+// GetRole returns a role with its permissions
+func (r *userRepository) GetRole(roleID uuid.UUID) (*models.Role, error) {
+	return r.findRole("id = ?", roleID)
+}
+
+// GetRoleByName returns a role with its permissions
+func (r *userRepository) GetRoleByName(name string) (*models.Role, error) {
+	return r.findRole("name = ?", name)
+}
+
+func (r *userRepository) findRole(query string, arg interface{}) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("permissions.name")
+	}).Where(query, arg).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// CreateRole stores a new role and grants it permissionIDs in one transaction
+func (r *userRepository) CreateRole(role *models.Role, permissionIDs []uuid.UUID, grantedBy *uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Users", "Permissions").Create(role).Error; err != nil {
+			return err
+		}
+		return grantRolePermissions(tx, role.ID, permissionIDs, grantedBy)
+	})
+}
+
+// UpdateRole changes a role's display name, description or priority and returns the updated role
+func (r *userRepository) UpdateRole(roleID uuid.UUID, updates map[string]interface{}) (*models.Role, error) {
+	result := r.db.Model(&models.Role{}).Where("id = ?", roleID).Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRoleNotFound
+	}
+	return r.GetRole(roleID)
+}
+
+// DeleteRole removes a custom role, its permissions and assignments, and bumps the token version of the
+// users who held it in one transaction; system roles are never deleted
+func (r *userRepository) DeleteRole(roleID uuid.UUID) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var holders []uuid.UUID
+		if err := tx.Model(&models.UserRoleAssignment{}).Where("role_id = ?", roleID).
+			Pluck("user_id", &holders).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.Returning{}).Where("id = ? AND is_system = ?", roleID, false).Delete(&role)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRoleNotFound
+		}
+		if len(holders) == 0 {
+			return nil
+		}
+		return bumpTokenVersion(tx, holders)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// SetRolePermissions replaces a role's permissions with permissionIDs in one transaction; permissions
+// the role keeps keep their granted_at and granted_by
+func (r *userRepository) SetRolePermissions(roleID uuid.UUID, permissionIDs []uuid.UUID, grantedBy *uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Role{}).Where("id = ?", roleID).UpdateColumn("updated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRoleNotFound
+		}
+
+		removed := tx.Where("role_id = ?", roleID)
+		if len(permissionIDs) > 0 {
+			removed = removed.Where("permission_id NOT IN ?", permissionIDs)
+		}
+		if err := removed.Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return grantRolePermissions(tx, roleID, permissionIDs, grantedBy)
+	})
+}
+
+// grantRolePermissions adds permissionIDs to a role, skipping the ones it already has
+func grantRolePermissions(tx *gorm.DB, roleID uuid.UUID, permissionIDs []uuid.UUID, grantedBy *uuid.UUID) error {
+	if len(permissionIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	grants := make([]models.RolePermission, 0, len(permissionIDs))
+	for _, permissionID := range permissionIDs {
+		grants = append(grants, models.RolePermission{RoleID: roleID, PermissionID: permissionID, GrantedAt: now, GrantedBy: grantedBy})
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&grants).Error
+}
+
+// ListPermissions returns every permission ordered by name
+func (r *userRepository) ListPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.db.Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+// GetPermissionsByName returns the permissions with the given names; unknown names are left out
+func (r *userRepository) GetPermissionsByName(names []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	err := r.db.Where("name IN ?", names).Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *userRepository) CreatePermission(permission *models.Permission) error {
+	return r.db.Omit("Roles").Create(permission).Error
+}
+
+// DeletePermission removes a permission; roles that had it lose it
+func (r *userRepository) DeletePermission(permissionID uuid.UUID) (*models.Permission, error) {
+	var permission models.Permission
+	result := r.db.Clauses(clause.Returning{}).Where("id = ?", permissionID).Delete(&permission)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrPermissionNotFound
+	}
+	return &permission, nil
+}
+
+// ListUserRoles returns the custom roles assigned to a user with their permissions, highest priority first
+func (r *userRepository) ListUserRoles(userID uuid.UUID) ([]models.UserRoleAssignment, error) {
+	var assignments []models.UserRoleAssignment
+	err := r.db.Preload("Role.Permissions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("permissions.name")
+	}).Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.priority DESC, roles.name").Find(&assignments).Error
+	return assignments, err
+}
+
+// AssignRole gives a user a role; ErrRoleAlreadyAssigned when the user already has it
+func (r *userRepository) AssignRole(assignment *models.UserRoleAssignment) error {
+	result := r.db.Omit("Role").Clauses(clause.OnConflict{DoNothing: true}).Create(assignment)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRoleAlreadyAssigned
+	}
+	return nil
+}
+
+// UnassignRole takes a role away from a user and bumps their token version in one transaction
+func (r *userRepository) UnassignRole(userID, roleID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&models.UserRoleAssignment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRoleAssignmentNotFound
+		}
+		return bumpTokenVersion(tx, []uuid.UUID{userID})
+	})
+}
+
+// GetTokenRoles returns the names of the custom roles assigned to a user, highest priority first, and the
+// permissions of those roles and of systemRoles (the user's role and granted roles), sorted by name
+func (r *userRepository) GetTokenRoles(userID uuid.UUID, systemRoles []string) ([]string, []string, error) {
+	var roles []string
+	err := r.db.Model(&models.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.priority DESC, roles.name").Pluck("roles.name", &roles).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var permissions []string
+	err = r.db.Model(&models.Permission{}).Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("(roles.is_system AND roles.name IN ?) OR roles.id IN (?)", systemRoles,
+			r.db.Model(&models.UserRoleAssignment{}).Select("role_id").Where("user_id = ?", userID)).
+		Order("permissions.name").Pluck("permissions.name", &permissions).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return roles, permissions, nil
+}
+
+// CreatePasswordReset stores a requested password reset
+func (r *userRepository) CreatePasswordReset(reset *models.PasswordReset) error {
+	return r.db.Create(reset).Error
+}
+
+// CompletePasswordReset uses the user's pending, unexpired reset with tokenHash and sets the new password in one transaction
+// It also closes the user's other pending resets, clears the lockout, records the change time and bumps the token version
+func (r *userRepository) CompletePasswordReset(userID uuid.UUID, tokenHash, passwordHash string, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PasswordReset{}).
+			Where("user_id = ? AND token_hash = ? AND used_at IS NULL AND expires_at > ?", userID, tokenHash, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPasswordResetNotFound
+		}
+
+		if err := tx.Model(&models.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"password_hash":         passwordHash,
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+			"password_changed_at":   now,
+		}).Error; err != nil {
+			return err
+		}
+		return bumpTokenVersion(tx, []uuid.UUID{userID})
+	})
+}
+
+// CreateHoneypot stores a new honeypot
+func (r *userRepository) CreateHoneypot(honeypot *models.Honeypot) error {
+	return r.db.Create(honeypot).Error
+}
+
+// ListHoneypots returns the honeypots, most recently triggered first
+func (r *userRepository) ListHoneypots(includeDisabled bool) ([]models.Honeypot, error) {
+	query := r.db.Model(&models.Honeypot{})
+	if !includeDisabled {
+		query = query.Where("disabled_at IS NULL")
+	}
+
+	var honeypots []models.Honeypot
+	err := query.Order("last_triggered_at DESC NULLS LAST, created_at DESC").Find(&honeypots).Error
+	return honeypots, err
+}
+
+// DisableHoneypot stops an active honeypot from alerting; it and its triggers are kept
+func (r *userRepository) DisableHoneypot(honeypotID uuid.UUID) (*models.Honeypot, error) {
+	var honeypot models.Honeypot
+	result := r.db.Model(&honeypot).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND disabled_at IS NULL", honeypotID).
+		Update("disabled_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrHoneypotNotFound
+	}
+	return &honeypot, nil
+}
+
+// TriggerHoneypot counts a use of the active honeypot matching kind and identifier and stores trigger
+// in one transaction; it returns ErrHoneypotNotFound for the (usual) case that nothing matches
+func (r *userRepository) TriggerHoneypot(kind, identifier string, trigger *models.HoneypotTrigger) (*models.Honeypot, error) {
+	var honeypot models.Honeypot
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&honeypot).
+			Clauses(clause.Returning{}).
+			Where("kind = ? AND identifier = ? AND disabled_at IS NULL", kind, identifier).
+			Updates(map[string]interface{}{
+				"trigger_count":     gorm.Expr("trigger_count + 1"),
+				"last_triggered_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrHoneypotNotFound
+		}
+
+		trigger.HoneypotID = honeypot.ID
+		return tx.Create(trigger).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &honeypot, nil
+}
+
+// ListHoneypotTriggers returns a page of a honeypot's uses, newest first, with the total
+func (r *userRepository) ListHoneypotTriggers(honeypotID uuid.UUID, limit, offset int) ([]models.HoneypotTrigger, int64, error) {
+	query := r.db.Model(&models.HoneypotTrigger{}).Where("honeypot_id = ?", honeypotID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var triggers []models.HoneypotTrigger
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&triggers).Error; err != nil {
+		return nil, 0, err
+	}
+	return triggers, total, nil
+}
+
+// bumpTokenVersion invalidates every token issued to the users so far
+func bumpTokenVersion(tx *gorm.DB, userIDs []uuid.UUID) error {
+	return tx.Model(&models.User{}).Where("id IN ?", userIDs).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+// CompactNotifications removes up to limit notifications created before olderThan that are read or expired
+// by now, oldest first. In one transaction it adds them to the per-user monthly summaries, copies them to
+// the archive when archive is set, and deletes them. Rows are locked with SKIP LOCKED so replicas running
+// the retention job never process a notification twice
+func (r *userRepository) CompactNotifications(olderThan, now time.Time, archive bool, limit int) (NotificationCompaction, error) {
+	var result NotificationCompaction
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		if err := tx.Raw(`SELECT id FROM user_notifications
+			WHERE created_at < ? AND (is_read = true OR expires_at < ?)
+			ORDER BY created_at LIMIT ? FOR UPDATE SKIP LOCKED`, olderThan, now, limit).
+			Scan(&ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		summaries := tx.Exec(`INSERT INTO user_notification_summaries
+			(user_id, month, notification_count, read_count, first_created_at, last_created_at, updated_at)
+			SELECT user_id, date_trunc('month', created_at)::date, COUNT(*), COUNT(*) FILTER (WHERE is_read),
+				MIN(created_at), MAX(created_at), ?
+			FROM user_notifications WHERE id IN ?
+			GROUP BY 1, 2
+			ON CONFLICT (user_id, month) DO UPDATE SET
+				notification_count = user_notification_summaries.notification_count + EXCLUDED.notification_count,
+				read_count = user_notification_summaries.read_count + EXCLUDED.read_count,
+				first_created_at = LEAST(user_notification_summaries.first_created_at, EXCLUDED.first_created_at),
+				last_created_at = GREATEST(user_notification_summaries.last_created_at, EXCLUDED.last_created_at),
+				updated_at = EXCLUDED.updated_at`, now, ids)
+		if summaries.Error != nil {
+			return summaries.Error
+		}
+		result.Summaries = summaries.RowsAffected
+
+		if archive {
+			archived := tx.Exec(`INSERT INTO user_notifications_archive
+				(id, user_id, type, title, message, is_read, read_at, action_url, action_text, expires_at, created_at, archived_at)
+				SELECT id, user_id, type, title, message, is_read, read_at, action_url, action_text, expires_at, created_at, ?
+				FROM user_notifications WHERE id IN ?
+				ON CONFLICT (id) DO NOTHING`, now, ids)
+			if archived.Error != nil {
+				return archived.Error
+			}
+			result.Archived = archived.RowsAffected
+		}
+
+		deleted := tx.Where("id IN ?", ids).Delete(&models.UserNotification{})
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		result.Removed = deleted.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return NotificationCompaction{}, err
+	}
+	return result, nil
+}
+
+// ArchiveActivities moves up to limit of the oldest activities created before olderThan, all of one user,
+// out of user_activities: write stores them and its manifest row is inserted as they are deleted, in one
+// transaction. Rows are locked with SKIP LOCKED so replicas running the archive job never archive an
+// activity twice; if the transaction fails after write, the object is left unlisted
+func (r *userRepository) ArchiveActivities(olderThan time.Time, limit int, write ActivityArchiveWriter) (int64, error) {
+	var archived int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var userIDs []uuid.UUID
+		if err := tx.Raw(`SELECT user_id FROM user_activities WHERE created_at < ?
+			ORDER BY created_at LIMIT 1 FOR UPDATE SKIP LOCKED`, olderThan).
+			Scan(&userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		// Oldest first, so what stays in user_activities is always newer than what was archived
+		var activities []models.UserActivity
+		if err := tx.Raw(`SELECT * FROM user_activities WHERE user_id = ? AND created_at < ?
+			ORDER BY created_at, id LIMIT ? FOR UPDATE SKIP LOCKED`, userIDs[0], olderThan, limit).
+			Scan(&activities).Error; err != nil {
+			return err
+		}
+		if len(activities) == 0 {
+			return nil
+		}
+		slices.Reverse(activities)
+
+		archive, err := write(userIDs[0], activities)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(archive).Error; err != nil {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(activities))
+		for i, activity := range activities {
+			ids[i] = activity.ID
+		}
+		deleted := tx.Where("id IN ?", ids).Delete(&models.UserActivity{})
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		archived = deleted.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return archived, nil
+}
+
+// ListActivityArchives returns a user's archive objects, newest activities first
+func (r *userRepository) ListActivityArchives(userID uuid.UUID) ([]models.UserActivityArchive, error) {
+	var archives []models.UserActivityArchive
+	err := r.db.Where("user_id = ?", userID).Order("last_created_at DESC").Find(&archives).Error
+	return archives, err
+}
+
+// ErrDataKeyNotFound means the user has no data key, or is deleted and can't be given one
+var ErrDataKeyNotFound = errors.New("data key not found")
+
+func (r *userRepository) GetDataKey(userID uuid.UUID) (*models.UserDataKey, error) {
+	var key models.UserDataKey
+	if err := r.db.Where("user_id = ?", userID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDataKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CreateDataKey stores key unless the user already has one, and returns the key the user ends up with,
+// so replicas creating one at the same time agree. Deleted users aren't given a key
+func (r *userRepository) CreateDataKey(key *models.UserDataKey) (*models.UserDataKey, error) {
+	err := r.db.Exec(`INSERT INTO user_data_keys (user_id, wrapped_key, master_key_id, created_at)
+		SELECT ?, ?, ?, NOW() WHERE EXISTS (SELECT 1 FROM users WHERE id = ? AND deleted_at IS NULL)
+		ON CONFLICT (user_id) DO NOTHING`, key.UserID, key.WrappedKey, key.MasterKeyID, key.UserID).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.GetDataKey(key.UserID)
+}
+
+func (r *userRepository) CreateWebAuthnCredential(credential *models.WebAuthnCredential) error {
+	return r.db.Create(credential).Error
+}
+
+// ListWebAuthnCredentials returns a user's passkeys, most recently used first
+func (r *userRepository) ListWebAuthnCredentials(userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	err := r.db.Where("user_id = ?", userID).
+		Order("last_used_at DESC NULLS LAST, created_at DESC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *userRepository) GetWebAuthnCredential(credentialID string) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	err := r.db.Where("credential_id = ?", credentialID).First(&credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPasskeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// RenameWebAuthnCredential renames one of the user's passkeys
+func (r *userRepository) RenameWebAuthnCredential(userID, id uuid.UUID, name string) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	result := r.db.Model(&credential).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("name", name)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrPasskeyNotFound
+	}
+	return &credential, nil
+}
+
+// DeleteWebAuthnCredential removes one of the user's passkeys
+func (r *userRepository) DeleteWebAuthnCredential(userID, id uuid.UUID) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// RecordWebAuthnCredentialUse stores the signature counter and backup state reported at login
+func (r *userRepository) RecordWebAuthnCredentialUse(id uuid.UUID, signCount int64, backupState bool, usedAt time.Time) error {
+	return r.db.Model(&models.WebAuthnCredential{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"sign_count":   signCount,
+		"backup_state": backupState,
+		"last_used_at": usedAt,
+	}).Error
+}
+
+// ErrPushSubscriptionNotFound means the user has no push subscription with the ID
+var ErrPushSubscriptionNotFound = errors.New("push subscription not found")
+
+// SavePushSubscription stores a subscription, or updates the one with its endpoint; subscription is
+// filled in with the stored row
+func (r *userRepository) SavePushSubscription(subscription *models.PushSubscription) error {
+	return r.db.Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "endpoint"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_id", "p256dh", "auth", "user_agent", "expires_at", "updated_at"}),
+		},
+		clause.Returning{},
+	).Create(subscription).Error
+}
+
+// ListPushSubscriptions returns a user's push subscriptions, newest first
+func (r *userRepository) ListPushSubscriptions(userID uuid.UUID) ([]models.PushSubscription, error) {
+	var subscriptions []models.PushSubscription
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// DeletePushSubscription removes one of the user's push subscriptions
+func (r *userRepository) DeletePushSubscription(userID, id uuid.UUID) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.PushSubscription{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPushSubscriptionNotFound
+	}
+	return nil
+}
+
+// DeletePushSubscriptionByEndpoint removes a subscription its push service no longer knows
+func (r *userRepository) DeletePushSubscriptionByEndpoint(endpoint string) error {
+	return r.db.Where("endpoint = ?", endpoint).Delete(&models.PushSubscription{}).Error
+}
+
+func (r *userRepository) CreatePersonalAccessToken(token *models.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+// ListPersonalAccessTokens returns a user's tokens that were not revoked, newest first
+func (r *userRepository) ListPersonalAccessTokens(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// GetPersonalAccessTokenByHash returns the token with the hash, whether or not it is still active
+func (r *userRepository) GetPersonalAccessTokenByHash(tokenHash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAccessTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokePersonalAccessToken revokes one of the user's tokens; it is kept for the audit trail
+func (r *userRepository) RevokePersonalAccessToken(userID, id uuid.UUID) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	result := r.db.Model(&token).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAccessTokenNotFound
+	}
+	return &token, nil
+}
+
+// RevokeAllPersonalAccessTokens revokes every active token of the user and returns how many there were
+func (r *userRepository) RevokeAllPersonalAccessTokens(userID uuid.UUID) (int64, error) {
+	result := r.db.Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// RecordPersonalAccessTokenUse sets last_used_at, at most once per interval so busy scripts
+// don't write on every request
+func (r *userRepository) RecordPersonalAccessTokenUse(id uuid.UUID, usedAt time.Time, interval time.Duration) error {
+	return r.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt.Add(-interval)).
+		Update("last_used_at", usedAt).Error
+}
+
+func (r *userRepository) CreateOAuthClient(client *models.OAuthClient) error {
+	return r.db.Create(client).Error
+}
+
+// ListOAuthClients returns the registered clients ordered by client_id
+func (r *userRepository) ListOAuthClients() ([]models.OAuthClient, error) {
+	var clients []models.OAuthClient
+	err := r.db.Order("client_id").Find(&clients).Error
+	return clients, err
+}
+
+func (r *userRepository) GetOAuthClient(clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	err := r.db.Where("client_id = ?", clientID).First(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// UpdateOAuthClient changes the client's name, scopes or secret and returns the updated client
+func (r *userRepository) UpdateOAuthClient(clientID string, updates map[string]interface{}) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	result := r.db.Model(&client).
+		Clauses(clause.Returning{}).
+		Where("client_id = ?", clientID).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrOAuthClientNotFound
+	}
+	return &client, nil
+}
+
+// DeleteOAuthClient removes the client; tokens already issued to it stay valid until they expire
+func (r *userRepository) DeleteOAuthClient(clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	result := r.db.Clauses(clause.Returning{}).Where("client_id = ?", clientID).Delete(&client)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrOAuthClientNotFound
+	}
+	return &client, nil
+}
+
+// RecordOAuthClientUse sets last_used_at, at most once per interval so busy services don't write on
+// every token request
+func (r *userRepository) RecordOAuthClientUse(id uuid.UUID, usedAt time.Time, interval time.Duration) error {
+	return r.db.Model(&models.OAuthClient{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt.Add(-interval)).
+		Update("last_used_at", usedAt).Error
+}
+
+// MergeUsers moves the duplicate's sessions, activities, notifications, passkeys, preferences and OAuth
+// identities to the surviving account, revokes its personal access tokens, soft-deletes it and stores
+// the audit entry, all in one transaction. Moved sessions are revoked: their tokens name the duplicate
+// The surviving account keeps its own preferences; identities at the same provider on both accounts
+// fail the merge with ErrMergeIdentityConflict
+func (r *userRepository) MergeUsers(merge *models.UserMerge) (*models.UserMergeCounts, error) {
+	counts := &models.UserMergeCounts{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Locked in ID order so concurrent merges of the same accounts can't deadlock
+		var users []models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uuid.UUID{merge.SourceUserID, merge.TargetUserID}).
+			Order("id").Find(&users).Error; err != nil {
+			return err
+		}
+		var source, target *models.User
+		for i := range users {
+			switch users[i].ID {
+			case merge.SourceUserID:
+				source = &users[i]
+			case merge.TargetUserID:
+				target = &users[i]
+			}
+		}
+		if source == nil || target == nil {
+			return ErrMergeUserNotFound
+		}
+		if !target.IsActive {
+			return ErrMergeTargetInactive
+		}
+
+		// Built-in providers: the IDs move to the target and the duplicate's columns become NULL, like an
+		// anonymized account's, so a lookup by provider ID finds only the surviving account
+		moved := make(map[string]interface{})
+		cleared := make(map[string]interface{})
+		for provider, column := range oauthColumns {
+			oauthID := source.OAuthID(provider)
+			if oauthID == "" {
+				continue
+			}
+			if target.OAuthID(provider) != "" {
+				return fmt.Errorf("%w: %s", ErrMergeIdentityConflict, provider)
+			}
+			moved[column] = oauthID
+			cleared[column] = nil
+			counts.Providers = append(counts.Providers, provider)
+		}
+
+		var identities []models.OAuthIdentity
+		if err := tx.Where("user_id IN ?", []uuid.UUID{source.ID, target.ID}).Find(&identities).Error; err != nil {
+			return err
+		}
+		targetProviders := make(map[string]bool)
+		for _, identity := range identities {
+			if identity.UserID == target.ID {
+				targetProviders[identity.Provider] = true
+			}
+		}
+		for _, identity := range identities {
+			if identity.UserID != source.ID {
+				continue
+			}
+			if targetProviders[identity.Provider] {
+				return fmt.Errorf("%w: %s", ErrMergeIdentityConflict, identity.Provider)
+			}
+			counts.Providers = append(counts.Providers, identity.Provider)
+		}
+		slices.Sort(counts.Providers)
+
+		if len(cleared) > 0 {
+			if err := tx.Model(&models.User{}).Where("id = ?", source.ID).Updates(cleared).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.User{}).Where("id = ?", target.ID).Updates(moved).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.OAuthIdentity{}).Where("user_id = ?", source.ID).
+			Update("user_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		reassign := func(model interface{}, updates map[string]interface{}) (int64, error) {
+			updates["user_id"] = target.ID
+			result := tx.Model(model).Where("user_id = ?", source.ID).Updates(updates)
+			return result.RowsAffected, result.Error
+		}
+		var err error
+		if counts.Sessions, err = reassign(&models.Session{}, map[string]interface{}{"is_revoked": true, "is_active": false}); err != nil {
+			return err
+		}
+		if counts.Activities, err = reassign(&models.UserActivity{}, map[string]interface{}{}); err != nil {
+			return err
+		}
+		if counts.Notifications, err = reassign(&models.UserNotification{}, map[string]interface{}{}); err != nil {
+			return err
+		}
+		if counts.ArchivedNotifications, err = reassign(&models.ArchivedNotification{}, map[string]interface{}{}); err != nil {
+			return err
+		}
+		if counts.ActivityArchives, err = reassign(&models.UserActivityArchive{}, map[string]interface{}{}); err != nil {
+			return err
+		}
+		if counts.Passkeys, err = reassign(&models.WebAuthnCredential{}, map[string]interface{}{}); err != nil {
+			return err
+		}
+
+		// Monthly summaries are unique per user and month, so the duplicate's are added to the target's
+		summaries := tx.Exec(`INSERT INTO user_notification_summaries
+			(user_id, month, notification_count, read_count, first_created_at, last_created_at, updated_at)
+			SELECT ?, month, notification_count, read_count, first_created_at, last_created_at, ?
+			FROM user_notification_summaries WHERE user_id = ?
+			ON CONFLICT (user_id, month) DO UPDATE SET
+				notification_count = user_notification_summaries.notification_count + EXCLUDED.notification_count,
+				read_count = user_notification_summaries.read_count + EXCLUDED.read_count,
+				first_created_at = LEAST(user_notification_summaries.first_created_at, EXCLUDED.first_created_at),
+				last_created_at = GREATEST(user_notification_summaries.last_created_at, EXCLUDED.last_created_at),
+				updated_at = EXCLUDED.updated_at`, target.ID, time.Now(), source.ID)
+		if summaries.Error != nil {
+			return summaries.Error
+		}
+		counts.NotificationSummaries = summaries.RowsAffected
+		if err := tx.Where("user_id = ?", source.ID).Delete(&models.NotificationSummary{}).Error; err != nil {
+			return err
+		}
+
+		var targetPreferences int64
+		if err := tx.Model(&models.UserPreference{}).Where("user_id = ?", target.ID).Count(&targetPreferences).Error; err != nil {
+			return err
+		}
+		if targetPreferences == 0 {
+			preferences, err := reassign(&models.UserPreference{}, map[string]interface{}{})
+			if err != nil {
+				return err
+			}
+			counts.Preferences = preferences > 0
+		}
+
+		revoked := tx.Model(&models.PersonalAccessToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", source.ID).
+			Update("revoked_at", time.Now())
+		if revoked.Error != nil {
+			return revoked.Error
+		}
+		counts.RevokedAccessTokens = revoked.RowsAffected
+
+		if err := tx.Model(&models.User{}).Where("id = ?", source.ID).Updates(map[string]interface{}{
+			"is_active":       false,
+			"feed_token_hash": nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := bumpTokenVersion(tx, []uuid.UUID{source.ID}); err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.User{}, source.ID).Error; err != nil {
+			return err
+		}
+		// The duplicate's data sealed in Redis isn't moved; shredding its key discards it
+		if err := tx.Where("user_id = ?", source.ID).Delete(&models.UserDataKey{}).Error; err != nil {
+			return err
+		}
+
+		movedJSON, err := json.Marshal(counts)
+		if err != nil {
+			return err
+		}
+		merge.SourceEmail = source.Email
+		merge.SourceUsername = source.Username
+		merge.Moved = string(movedJSON)
+		return tx.Create(merge).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ListUserMerges returns the merges into or out of the account, newest first
+func (r *userRepository) ListUserMerges(userID uuid.UUID) ([]models.UserMerge, error) {
+	var merges []models.UserMerge
+	err := r.db.Where("target_user_id = ? OR source_user_id = ?", userID, userID).
+		Order("created_at DESC").Find(&merges).Error
+	return merges, err
+}
+
+// auditLogLockKey is the advisory lock that serializes audit log appends
+const auditLogLockKey = 0x61756469 // "audi"
+
+// AppendAuditLog adds entry at the end of the audit log. seal is called with Seq and PrevHash set, under
+// the lock, and must set Hash
+func (r *userRepository) AppendAuditLog(entry *models.AuditLogEntry, seal func(entry *models.AuditLogEntry) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditLogLockKey).Error; err != nil {
+			return err
+		}
+		var head models.AuditLogEntry
+		if err := tx.Select("seq", "hash").Order("seq DESC").Limit(1).Find(&head).Error; err != nil {
+			return err
+		}
+		entry.Seq = head.Seq + 1
+		entry.PrevHash = head.Hash
+		if err := seal(entry); err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// ListAuditLog returns a page of the entries matching filter, newest first, and how many match
+func (r *userRepository) ListAuditLog(filter models.AuditLogFilter, limit, offset int) ([]models.AuditLogEntry, int64, error) {
+	query := applyAuditLogFilter(r.db.Model(&models.AuditLogEntry{}), filter).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLogEntry
+	if err := query.Order("seq DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// ListAuditLogAfter returns up to limit entries matching filter with a seq above afterSeq, in chain order
+func (r *userRepository) ListAuditLogAfter(filter models.AuditLogFilter, afterSeq int64, limit int) ([]models.AuditLogEntry, error) {
+	var entries []models.AuditLogEntry
+	err := applyAuditLogFilter(r.db.Model(&models.AuditLogEntry{}), filter).
+		Where("seq > ?", afterSeq).Order("seq").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func applyAuditLogFilter(query *gorm.DB, filter models.AuditLogFilter) *gorm.DB {
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if prefix, ok := strings.CutSuffix(filter.Action, "*"); ok {
+		query = query.Where("action LIKE ?", escapeLike(prefix)+"%")
+	} else if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	return query
+}
+
+// ListPurgeableUsers returns the IDs and deletion times of accounts soft-deleted before deletedBefore and not
+// purged yet, longest deleted first
+func (r *userRepository) ListPurgeableUsers(deletedBefore time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Unscoped().Select("id", "deleted_at").
+		Where("deleted_at < ? AND purged_at IS NULL", deletedBefore).
+		Order("deleted_at").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// PurgeUser deletes the per-user rows of an account soft-deleted before deletedBefore, then its row or, with
+// anonymize, the personal data in it. removeArchives is given the account's activity archives inside the
+// transaction, so a failure to delete the objects keeps their manifest rows for the next attempt
+func (r *userRepository) PurgeUser(userID uuid.UUID, deletedBefore time.Time, anonymize bool, removeArchives ActivityArchiveRemover) (*models.UserPurgeCounts, error) {
+	counts := &models.UserPurgeCounts{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Locked so replicas running the job at the same time purge each account once
+		var user models.User
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at < ? AND purged_at IS NULL", userID, deletedBefore).
+			First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotPurgeable
+		}
+		if err != nil {
+			return err
+		}
+
+		var archives []models.UserActivityArchive
+		if err := tx.Where("user_id = ?", userID).Find(&archives).Error; err != nil {
+			return err
+		}
+		if len(archives) > 0 {
+			if err := removeArchives(archives); err != nil {
+				return fmt.Errorf("failed to delete activity archives: %w", err)
+			}
+		}
+
+		remove := func(model interface{}, query string, args ...interface{}) (int64, error) {
+			result := tx.Unscoped().Where(query, args...).Delete(model)
+			return result.RowsAffected, result.Error
+		}
+		// Failed attempts against an account only record the email they were made with, in any case
+		if counts.LoginAttempts, err = remove(&models.LoginAttempt{}, "user_id = ? OR LOWER(email) = ?", userID, strings.ToLower(user.Email)); err != nil {
+			return err
+		}
+		for _, table := range []struct {
+			model interface{}
+			count *int64
+		}{
+			{&models.Session{}, &counts.Sessions},
+			{&models.UserActivity{}, &counts.Activities},
+			{&models.UserNotification{}, &counts.Notifications},
+			{&models.ArchivedNotification{}, &counts.ArchivedNotifications},
+			{&models.UserActivityArchive{}, &counts.ActivityArchives},
+			{&models.NotificationSummary{}, nil},
+			{&models.UserPreference{}, nil},
+			{&models.RoleGrant{}, nil},
+			{&models.UserRoleAssignment{}, nil},
+			{&models.PasswordReset{}, nil},
+			{&models.WebAuthnCredential{}, nil},
+			{&models.PersonalAccessToken{}, nil},
+			{&models.OAuthIdentity{}, nil},
+			{&models.PushSubscription{}, nil},
+			{&models.UserDataKey{}, nil},
+		} {
+			removed, err := remove(table.model, "user_id = ?", userID)
+			if err != nil {
+				return err
+			}
+			if table.count != nil {
+				*table.count = removed
+			} else {
+				counts.Other += removed
+			}
+		}
+
+		if !anonymize {
+			// Rows naming the account as the admin or inviter keep it as NULL
+			return tx.Unscoped().Delete(&models.User{}, userID).Error
+		}
+		// The ID, role, status and timestamps stay for the records that refer to the account
+		return tx.Unscoped().Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"email":                 userID.String() + "@deleted.invalid",
+			"username":              "deleted-" + userID.String(),
+			"password_hash":         "",
+			"is_active":             false,
+			"google_id":             nil,
+			"git_hub_id":            nil,
+			"facebook_id":           nil,
+			"apple_id":              nil,
+			"first_name":            nil,
+			"last_name":             nil,
+			"phone_number":          nil,
+			"bio":                   nil,
+			"avatar_url":            nil,
+			"date_of_birth":         nil,
+			"gender":                nil,
+			"country":               nil,
+			"city":                  nil,
+			"timezone":              nil,
+			"website":               nil,
+			"linkedin":              nil,
+			"twitter":               nil,
+			"github":                nil,
+			"last_login_ip":         nil,
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+			"feed_token_hash":       nil,
+			"purged_at":             time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
 }
\ No newline at end of file
@@ -0,0 +1,67 @@
+package routeguard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText prints the authorization matrix as an aligned table followed by any violations
+func WriteText(w io.Writer, result *Result) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tEXPECTED\tAUTH\tADMIN\tRATE LIMIT\tHANDLER")
+	for _, route := range result.Routes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", route.Method, route.Path, route.Expected,
+			mark(route.Authenticated), mark(route.AdminOnly), orDash(route.RateLimit), route.Handler)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	writeViolations(w, result, "❌ ")
+	if result.Violations() == 0 {
+		fmt.Fprintf(w, "✅ %d routes match the access policy\n", len(result.Routes))
+	}
+}
+
+// WriteMarkdown renders the authorization matrix as a Markdown report
+func WriteMarkdown(w io.Writer, result *Result) {
+	fmt.Fprintf(w, "# Authorization Matrix\n\n")
+	fmt.Fprintf(w, "Generated by `go run ./cmd/routeguard --format markdown`. Do not edit by hand.\n\n")
+	fmt.Fprintf(w, "| Method | Path | Expected | Auth | Admin | Rate limit | Handler |\n")
+	fmt.Fprintf(w, "|--------|------|----------|------|-------|------------|---------|\n")
+	for _, route := range result.Routes {
+		fmt.Fprintf(w, "| %s | `%s` | %s | %s | %s | %s | `%s` |\n", route.Method, route.Path, route.Expected,
+			mark(route.Authenticated), mark(route.AdminOnly), orDash(route.RateLimit), route.Handler)
+	}
+
+	if result.Violations() > 0 {
+		fmt.Fprintf(w, "\n## Violations\n\n")
+		writeViolations(w, result, "- ")
+	}
+}
+
+func writeViolations(w io.Writer, result *Result, prefix string) {
+	for _, route := range result.Routes {
+		if len(route.Violations) > 0 {
+			fmt.Fprintf(w, "%s%s %s: %s\n", prefix, route.Method, route.Path, strings.Join(route.Violations, "; "))
+		}
+	}
+	for _, key := range result.StaleEntries {
+		fmt.Fprintf(w, "%s%s: listed in Policy.Public but not registered\n", prefix, key)
+	}
+}
+
+func mark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "-"
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,250 @@
+// Package routeguard checks that every registered route carries the middleware its access level requires
+// cmd/routeguard runs it in CI so a new route can't ship without authentication by accident
+package routeguard
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Access is the protection level a route is expected to have
+type Access string
+
+const (
+	AccessPublic        Access = "public"
+	AccessAuthenticated Access = "authenticated"
+	AccessAdmin         Access = "admin"
+)
+
+// Rate limit sources reported for a route
+const (
+	RateLimitApp     = "app"
+	RateLimitGateway = "gateway"
+)
+
+// Guard middleware, matched by the function names Gin reports for a route's handler chain
+const (
+	authGuard      = "shared/middleware.(*JWTMiddleware).AuthRequired"
+	roleGuard      = "auth-service/internal/middleware.RequireRole"
+	rateLimitGuard = "auth-service/internal/middleware.RateLimit"
+)
+
+// Policy describes the access every route is expected to have
+// Routes that aren't public are expected to require authentication, so a new route is protected by default
+type Policy struct {
+	// Public lists "METHOD /path" routes reachable without a token
+	Public []string
+	// AdminPrefixes are path prefixes whose routes also require the admin role
+	AdminPrefixes []string
+	// RateLimitedPrefixes are path prefixes whose routes must be rate limited, in the app or at the gateway
+	RateLimitedPrefixes []string
+	// GatewayRateLimitedPrefixes are path prefixes the Traefik gateway rate limits
+	// (config/traefik/dynamic/dynamic.toml)
+	GatewayRateLimitedPrefixes []string
+}
+
+// DefaultPolicy is the auth service's route access policy
+var DefaultPolicy = Policy{
+	Public: []string{
+		"GET /health",
+		"GET /health/ready",
+		"GET /health/live",
+		"GET /status",
+		"GET /.well-known/jwks.json",      // Public keys only
+		"GET /revocations/refresh-tokens", // Hashes of revoked refresh tokens, signed for edge gateways
+		"GET /metrics",                    // Scraped by Prometheus from inside the cluster
+		"POST /api/v1/auth/register",
+		"POST /api/v1/auth/login",
+		"POST /api/v1/auth/refresh",
+		"POST /api/v1/auth/forgot-password",
+		"POST /api/v1/auth/reset-password",
+		"POST /api/v1/auth/invitations/accept",
+		"POST /api/v1/auth/webauthn/login/begin",
+		"POST /api/v1/auth/webauthn/login/finish",
+		"GET /api/v1/auth/oauth/:provider",
+		"GET /api/v1/auth/oauth/:provider/callback",
+		"POST /api/v1/auth/oauth/:provider/callback",
+		"POST /api/v1/auth/oauth/exchange",
+		// Feed readers can't send a bearer token; the personal token in the path authenticates them
+		"GET /api/v1/auth/feeds/:token/activities.rss",
+		"GET /api/v1/auth/feeds/:token/activities.atom",
+		"GET /api/v1/auth/feeds/:token/notifications.rss",
+		"GET /api/v1/auth/feeds/:token/notifications.atom",
+		"GET /api/v1/auth/feeds/:token/security.ics",
+		"POST /api/v1/verify", // ForwardAuth: validates the token it is given
+		"POST /oauth2/token",  // Client-credentials grant: the client secret authenticates the caller
+	},
+	AdminPrefixes:              []string{"/api/v1/admin/"},
+	RateLimitedPrefixes:        []string{"/api/v1/auth/"},
+	GatewayRateLimitedPrefixes: []string{"/api/v1/auth/"},
+}
+
+// Route is one registered route with the guards found in its handler chain
+type Route struct {
+	Method        string   `json:"method"`
+	Path          string   `json:"path"`
+	Handler       string   `json:"handler"`
+	Authenticated bool     `json:"authenticated"`
+	AdminOnly     bool     `json:"admin_only"`
+	RateLimit     string   `json:"rate_limit,omitempty"` // RateLimitApp, RateLimitGateway or empty
+	Expected      Access   `json:"expected"`
+	Violations    []string `json:"violations,omitempty"`
+
+	chain []string
+}
+
+// Result is the checked authorization matrix
+type Result struct {
+	Routes []Route `json:"routes"`
+	// StaleEntries are policy entries that match no registered route
+	StaleEntries []string `json:"stale_entries,omitempty"`
+}
+
+// Violations counts routes that break the policy plus stale policy entries
+func (r *Result) Violations() int {
+	count := len(r.StaleEntries)
+	for _, route := range r.Routes {
+		if len(route.Violations) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Inspect registers the routes on a fresh engine and records each route's handler chain
+// Gin only exposes the final handler of a route, so a recording middleware is installed first and
+// one request is sent per route; it aborts the chain, so no middleware or handler actually runs
+func Inspect(register func(router *gin.Engine)) ([]Route, error) {
+	var chain []string
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		chain = c.HandlerNames()[1:]
+		c.AbortWithStatus(http.StatusNoContent)
+	})
+	register(router)
+
+	var routes []Route
+	for _, info := range router.Routes() {
+		chain = nil
+		req := httptest.NewRequest(info.Method, samplePath(info.Path), nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		if chain == nil {
+			return nil, fmt.Errorf("route %s %s could not be reached for inspection", info.Method, info.Path)
+		}
+
+		routes = append(routes, Route{
+			Method:  info.Method,
+			Path:    info.Path,
+			Handler: shortName(info.Handler),
+			chain:   chain,
+		})
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, nil
+}
+
+// Check classifies the routes' guards and compares them against the policy
+func (p Policy) Check(routes []Route) *Result {
+	public := make(map[string]bool, len(p.Public))
+	for _, key := range p.Public {
+		public[key] = true
+	}
+
+	result := &Result{}
+	seen := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		seen[key] = true
+
+		authAt, roleAt := indexOf(route.chain, authGuard), indexOf(route.chain, roleGuard)
+		route.Authenticated = authAt >= 0
+		route.AdminOnly = roleAt >= 0
+		switch {
+		case indexOf(route.chain, rateLimitGuard) >= 0:
+			route.RateLimit = RateLimitApp
+		case hasPrefix(route.Path, p.GatewayRateLimitedPrefixes):
+			route.RateLimit = RateLimitGateway
+		}
+
+		switch {
+		case hasPrefix(route.Path, p.AdminPrefixes):
+			route.Expected = AccessAdmin
+		case public[key]:
+			route.Expected = AccessPublic
+		default:
+			route.Expected = AccessAuthenticated
+		}
+
+		if route.Expected != AccessPublic && !route.Authenticated {
+			route.Violations = append(route.Violations, "unprotected: no AuthRequired middleware (add it, or list the route in Policy.Public)")
+		}
+		if route.Expected == AccessAdmin && !route.AdminOnly {
+			route.Violations = append(route.Violations, "admin route without RequireRole middleware")
+		}
+		if route.AdminOnly && route.Authenticated && roleAt < authAt {
+			route.Violations = append(route.Violations, "RequireRole runs before AuthRequired and can never pass")
+		}
+		if route.RateLimit == "" && hasPrefix(route.Path, p.RateLimitedPrefixes) {
+			route.Violations = append(route.Violations, "not rate limited in the app or at the gateway")
+		}
+
+		result.Routes = append(result.Routes, route)
+	}
+
+	for _, key := range p.Public {
+		if !seen[key] {
+			result.StaleEntries = append(result.StaleEntries, key)
+		}
+	}
+	return result
+}
+
+// samplePath fills path parameters so the route matches a request
+func samplePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "routeguard"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// shortName drops the module path from a handler's function name
+func shortName(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// indexOf returns the position of the first handler created by guard, or -1
+func indexOf(chain []string, guard string) int {
+	for i, name := range chain {
+		if name == guard || strings.HasPrefix(name, guard+".") || strings.HasPrefix(name, guard+"-") {
+			return i
+		}
+	}
+	return -1
+}
+
+func hasPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
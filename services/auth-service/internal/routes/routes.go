@@ -0,0 +1,307 @@
+// Package routes registers the auth service HTTP API on a Gin engine
+// It is shared by the service binary and cmd/routeguard, which checks every route's guards
+package routes
+
+import (
+	"log"
+
+	"auth-service/internal/config"
+	"auth-service/internal/container"
+	"auth-service/internal/feeds"
+	"auth-service/internal/handlers"
+	"auth-service/internal/instrumentation"
+	localMiddleware "auth-service/internal/middleware"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	sharedMiddleware "shared/middleware"
+)
+
+// NewRouter configures HTTP router with comprehensive middleware and API route definitions
+func NewRouter(deps *container.Container, cfg *config.Config) *gin.Engine {
+	router := gin.New()
+	Register(router, deps, cfg)
+	return router
+}
+
+// Register adds the global middleware and every API route to router
+func Register(router *gin.Engine, deps *container.Container, cfg *config.Config) {
+	authHandler := deps.AuthHandler
+
+	// Initialize JWT middleware with the access token key set; it also accepts personal access tokens and
+	// trips canary API keys presented to any protected route
+	jwtMiddleware := sharedMiddleware.NewJWTMiddleware(cfg.JWT.AccessSecret).
+		WithKeyfunc(deps.SigningKeys.Keyfunc).
+		WithPersonalAccessTokens(cfg.AccessTokens.Prefix, deps.AuthService).
+		WithCanaryKeys(localMiddleware.CanaryKeys(deps.AuthService))
+
+	// Client addresses come from proxy headers only when a trusted proxy sent them
+	if deps.ClientIPs != nil {
+		if err := deps.ClientIPs.Configure(router); err != nil {
+			log.Printf("⚠️  Failed to apply trusted proxies to the router: %v", err)
+		}
+		router.Use(deps.ClientIPs.Middleware())
+	}
+
+	// Retiring endpoints answer with Deprecation and Sunset headers, even when a later middleware refuses
+	// the request; calls are counted per consumer for the admin report
+	if deps.Deprecations != nil {
+		router.Use(deps.Deprecations.Middleware())
+	}
+
+	// Apply global middleware for all routes
+	router.Use(sharedMiddleware.RequestID())                        // Request/trace ID for responses, logs and events
+	router.Use(localMiddleware.TLSFingerprint(&cfg.TLSFingerprint)) // JA3/JA4 fingerprints forwarded by the TLS proxy
+	router.Use(localMiddleware.CORS(&cfg.CORS))                     // Cross-origin request handling
+	router.Use(localMiddleware.Logger())                            // HTTP request logging for monitoring
+	router.Use(localMiddleware.DebugLogging(deps.LogLevels))        // Detailed lines at debug level or for admin debug targets
+	router.Use(localMiddleware.Recovery())                          // Panic recovery to prevent server crashes
+
+	// Without the gateway, the service applies the gateway's rate limits itself (rate_limiting.mode); it runs
+	// after the request ID, CORS and recovery so refusals carry them
+	if deps.RateLimiter != nil {
+		router.Use(localMiddleware.RateLimit(deps.RateLimiter))
+	}
+	router.Use(deps.Inject()) // Request-scoped access to the dependency container
+	if cfg.Honeypot.BlockDuration > 0 {
+		router.Use(localMiddleware.BlockedIPs(deps.AuthService)) // Refuse addresses that used a honeypot
+	}
+	router.Use(localMiddleware.ReadOnlyMode(deps.Maintenance)) // 503 for writes during read-only maintenance
+
+	// Health check endpoint for load balancers and monitoring systems
+	// Metadata carries the migrations this build expects that the database hadn't applied at startup
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"status":    "healthy",
+			"service":   "auth-service",
+			"timestamp": "2024-01-01T00:00:00Z",
+			"metadata": gin.H{
+				"pending_migrations": deps.SchemaStatus.PendingCount(),
+			},
+		})
+	})
+
+	// Kubernetes-style probes: readiness covers critical dependencies, liveness only the process
+	router.GET("/health/ready", deps.StatusPage.ReadinessHandler())
+	router.GET("/health/live", deps.StatusPage.LivenessHandler())
+
+	// Public status page with component health, uptime and incidents
+	router.GET("/status", deps.StatusHandler.GetStatus)
+
+	// Access token verification keys for services verifying tokens locally
+	router.GET("/.well-known/jwks.json", deps.JWKSHandler.GetJWKS)
+
+	// Signed hashes of revoked refresh tokens for edge gateways (revocation_snapshot.enabled)
+	router.GET("/revocations/refresh-tokens", deps.RevocationHandler.GetRefreshRevocations)
+
+	// OAuth2 client-credentials grant for service-to-service calls; clients authenticate with their secret
+	router.POST("/oauth2/token", authHandler.ClientCredentialsToken)
+
+	// Prometheus metrics endpoint for application monitoring
+	collectors := []instrumentation.Collector{deps.Metrics}
+	if deps.MigrationMetrics != nil {
+		collectors = append(collectors, deps.MigrationMetrics) // Only when migrations ran at startup
+	}
+	if deps.CacheWarmer != nil {
+		collectors = append(collectors, deps.CacheWarmer)
+	}
+	if deps.HoneypotAlerts != nil {
+		collectors = append(collectors, deps.HoneypotAlerts)
+	}
+	if deps.Maintenance != nil {
+		collectors = append(collectors, deps.Maintenance)
+	}
+	if cfg.NotificationRetention.Enabled && deps.NotificationRetention != nil {
+		collectors = append(collectors, deps.NotificationRetention)
+	}
+	if deps.ActivityArchive != nil {
+		collectors = append(collectors, deps.ActivityArchive)
+	}
+	if cfg.DeletedUserRetention.Enabled && deps.DeletedUserRetention != nil {
+		collectors = append(collectors, deps.DeletedUserRetention)
+	}
+	if deps.SessionReplicator != nil {
+		collectors = append(collectors, deps.SessionReplicator)
+	}
+	if deps.Deprecations != nil {
+		collectors = append(collectors, deps.Deprecations)
+	}
+	if deps.Captcha != nil {
+		collectors = append(collectors, deps.Captcha)
+	}
+	if deps.GeoIP != nil {
+		collectors = append(collectors, deps.GeoIP)
+	}
+	router.GET("/metrics", localMiddleware.PrometheusHandler(collectors...))
+
+	// API version 1 route group
+	v1 := router.Group("/api/v1")
+	{
+		// Authentication route group
+		auth := v1.Group("/auth")
+		{
+			// Public authentication endpoints (no JWT required)
+			auth.POST("/register", authHandler.Register)                   // User registration
+			auth.POST("/login", authHandler.Login)                         // User authentication
+			auth.POST("/refresh", authHandler.RefreshToken)                // Token refresh
+			auth.POST("/forgot-password", authHandler.ForgotPassword)      // Password reset request
+			auth.POST("/reset-password", authHandler.ResetPassword)        // Password reset execution
+			auth.POST("/invitations/accept", authHandler.AcceptInvitation) // Invited account activation
+
+			// Passwordless login with a registered passkey (WebAuthn)
+			auth.POST("/webauthn/login/begin", authHandler.BeginPasskeyLogin)
+			auth.POST("/webauthn/login/finish", authHandler.FinishPasskeyLogin)
+
+			// OAuth2 integration endpoints for external provider authentication
+			auth.GET("/oauth/:provider", authHandler.OAuthLogin)             // OAuth login initiation
+			auth.GET("/oauth/:provider/callback", authHandler.OAuthCallback) // OAuth callback handling
+			auth.POST("/oauth/exchange", authHandler.ExchangeOAuthCode)      // One-time code from a callback redirect
+
+			// Sign in with Apple posts its callback as a form
+			auth.POST("/oauth/:provider/callback", authHandler.OAuthCallback)
+
+			// Subscription feeds for readers and calendar apps, authenticated by the personal token in the URL
+			auth.GET("/feeds/:token/activities.rss", authHandler.Feed(feeds.KindActivities, handlers.FeedFormatRSS))
+			auth.GET("/feeds/:token/activities.atom", authHandler.Feed(feeds.KindActivities, handlers.FeedFormatAtom))
+			auth.GET("/feeds/:token/notifications.rss", authHandler.Feed(feeds.KindNotifications, handlers.FeedFormatRSS))
+			auth.GET("/feeds/:token/notifications.atom", authHandler.Feed(feeds.KindNotifications, handlers.FeedFormatAtom))
+			auth.GET("/feeds/:token/security.ics", authHandler.Feed(feeds.KindSecurity, handlers.FeedFormatICal))
+
+			// Protected endpoints requiring valid JWT authentication
+			protected := auth.Group("/")
+			protected.Use(jwtMiddleware.AuthRequired())        // JWT validation middleware
+			protected.Use(localMiddleware.RequireUserID())     // Parse user ID once for all handlers
+			protected.Use(localMiddleware.RequireTokenScope()) // Personal access tokens: read scope for GET, write otherwise
+			{
+				// Existing auth endpoints
+				protected.GET("/me", authHandler.GetMe) // Basic auth info only
+
+				// NEW: Unified User Service endpoints (Task 4.1 - API Integration)
+				// These endpoints moved from User Service (/api/v1/users/*) to Auth Service (/api/v1/auth/*)
+				protected.GET("/profile", authHandler.GetProfile)     // Previously /api/v1/users/profile
+				protected.PUT("/profile", authHandler.UpdateProfile)  // Previously /api/v1/users/profile
+				protected.GET("/bootstrap", authHandler.GetBootstrap) // Profile, preferences and unread count in one request
+
+				protected.GET("/preferences", authHandler.GetUserPreferences)     // Previously /api/v1/users/preferences
+				protected.POST("/preferences", authHandler.CreateUserPreferences) // Create new preferences
+				protected.PUT("/preferences", authHandler.UpdateUserPreferences)  // Previously /api/v1/users/preferences
+
+				protected.GET("/activities", authHandler.GetUserActivities) // Previously /api/v1/users/activities
+
+				protected.GET("/notifications", authHandler.GetUserNotifications)                        // Previously /api/v1/users/notifications
+				protected.PUT("/notifications/:notificationId/read", authHandler.MarkNotificationAsRead) // New unified endpoint
+
+				protected.GET("/push-subscriptions", authHandler.ListPushSubscriptions)              // Subscribed browsers and the VAPID key
+				protected.POST("/push-subscriptions", authHandler.SubscribePush)                     // Subscribe this browser to notifications
+				protected.DELETE("/push-subscriptions/:subscriptionId", authHandler.UnsubscribePush) // Stop pushing to a browser
+
+				protected.GET("/webauthn/credentials", authHandler.ListPasskeys) // Registered passkeys
+			}
+
+			// Credential management needs a login; a leaked personal access token can't take over the account
+			credentials := protected.Group("/")
+			credentials.Use(localMiddleware.RejectPersonalAccessTokens())
+			{
+				credentials.POST("/logout", authHandler.Logout)                  // End this session
+				credentials.POST("/logout-all", authHandler.LogoutAll)           // End every session of the user
+				credentials.POST("/change-password", authHandler.ChangePassword) // Password change
+				credentials.DELETE("/account", authHandler.DeleteAccount)        // Account deletion
+
+				credentials.GET("/sessions", authHandler.ListSessions)                       // Signed-in devices
+				credentials.DELETE("/sessions/:sessionId", authHandler.RevokeSession)        // Sign out one device
+				credentials.POST("/sessions/revoke-others", authHandler.RevokeOtherSessions) // Sign out every other device
+				credentials.GET("/login-history", authHandler.GetLoginHistory)               // Login attempts and where they came from
+
+				credentials.POST("/feeds/token", authHandler.CreateFeedToken)   // Issue or rotate the personal feed token
+				credentials.DELETE("/feeds/token", authHandler.RevokeFeedToken) // Invalidate all feed URLs
+
+				credentials.POST("/webauthn/register/begin", authHandler.BeginPasskeyRegistration)   // Options for navigator.credentials.create
+				credentials.POST("/webauthn/register/finish", authHandler.FinishPasskeyRegistration) // Store the new passkey
+				credentials.PATCH("/webauthn/credentials/:passkeyId", authHandler.RenamePasskey)     // Rename a passkey
+				credentials.DELETE("/webauthn/credentials/:passkeyId", authHandler.DeletePasskey)    // Remove a passkey
+
+				credentials.POST("/tokens", authHandler.CreatePersonalAccessToken)            // Mint a personal access token
+				credentials.GET("/tokens", authHandler.ListPersonalAccessTokens)              // Personal access tokens
+				credentials.DELETE("/tokens/:tokenId", authHandler.RevokePersonalAccessToken) // Revoke a personal access token
+
+				credentials.POST("/oauth/:provider/link", authHandler.LinkOAuth)       // Start linking a provider; the callback finishes it
+				credentials.DELETE("/oauth/:provider/unlink", authHandler.UnlinkOAuth) // Unlink a provider unless it's the last login method
+			}
+		}
+
+		// Token verification endpoint for API Gateway ForwardAuth integration
+		v1.POST("/verify", authHandler.VerifyToken)
+
+		// Administrative endpoints (admin role required)
+		admin := v1.Group("/admin")
+		admin.Use(jwtMiddleware.AuthRequired())
+		admin.Use(localMiddleware.RequireUserID())
+		admin.Use(localMiddleware.RequireCurrentToken(deps.AuthService)) // Reject tokens whose role grant ended
+		admin.Use(localMiddleware.RequireRole(string(models.RoleAdmin)))
+		admin.Use(sharedMiddleware.RequireScopes(models.TokenScopeAdmin)) // Personal access tokens need the admin scope
+		admin.Use(localMiddleware.AuditAdminRequests(deps.AuthService))   // Every change lands in the audit log
+		{
+			admin.POST("/status/incidents", deps.StatusHandler.CreateIncident)                       // Declare status page incident
+			admin.DELETE("/status/incidents/:incidentId", deps.StatusHandler.ResolveIncident)        // Resolve incident
+			admin.GET("/telemetry/login-funnel", deps.TelemetryHandler.GetLoginFunnel)               // Hourly login funnel drop-off
+			admin.GET("/sessions", deps.AdminHandler.SearchSessions)                                 // Search sessions across users
+			admin.POST("/sessions/revoke", deps.AdminHandler.RevokeSessions)                         // Bulk revoke matching sessions
+			admin.GET("/two-factor/compliance", deps.AdminHandler.TwoFactorCompliance)               // 2FA enrollment per required role
+			admin.POST("/users/:userId/two-factor/disable", deps.AdminHandler.DisableTwoFactor)      // Approve turning off a user's 2FA
+			admin.POST("/users/invite", deps.AdminHandler.InviteUser)                                // Create passwordless account and email activation link
+			admin.POST("/users/:userId/invite/resend", deps.AdminHandler.ResendInvitation)           // Replace an expired or lost invitation link
+			admin.POST("/users/:userId/merge", deps.AdminHandler.MergeUser)                          // Fold a duplicate account into this one (irreversible)
+			admin.GET("/users/:userId/merges", deps.AdminHandler.ListUserMerges)                     // Merge audit entries for an account
+			admin.GET("/schema/validate", deps.SchemaHandler.ValidateSchema)                         // Database schema drift against the models
+			admin.GET("/registrations", deps.AdminHandler.ListPendingRegistrations)                  // Sign-ups awaiting approval
+			admin.POST("/registrations/:userId/approve", deps.AdminHandler.ApproveRegistration)      // Activate a queued sign-up
+			admin.POST("/registrations/:userId/reject", deps.AdminHandler.RejectRegistration)        // Reject a queued sign-up
+			admin.POST("/users/:userId/role-grants", deps.AdminHandler.GrantRole)                    // Time-boxed extra role (just-in-time access)
+			admin.GET("/role-grants", deps.AdminHandler.ListRoleGrants)                              // Active and ended role grants
+			admin.POST("/role-grants/:grantId/revoke", deps.AdminHandler.RevokeRoleGrant)            // End a role grant early
+			admin.GET("/roles", deps.AdminHandler.ListRoles)                                         // Roles and their permissions
+			admin.POST("/roles", deps.AdminHandler.CreateRole)                                       // Custom role with named permissions
+			admin.GET("/roles/:roleId", deps.AdminHandler.GetRole)                                   // One role and its permissions
+			admin.PATCH("/roles/:roleId", deps.AdminHandler.UpdateRole)                              // Describe or reorder a role
+			admin.DELETE("/roles/:roleId", deps.AdminHandler.DeleteRole)                             // Delete a custom role and revoke its holders' tokens
+			admin.PUT("/roles/:roleId/permissions", deps.AdminHandler.SetRolePermissions)            // Replace a role's permissions
+			admin.GET("/permissions", deps.AdminHandler.ListPermissions)                             // Permissions roles can be given
+			admin.POST("/permissions", deps.AdminHandler.CreatePermission)                           // New resource:action permission
+			admin.DELETE("/permissions/:permissionId", deps.AdminHandler.DeletePermission)           // Remove a permission from every role
+			admin.GET("/users/:userId/roles", deps.AdminHandler.GetUserRoles)                        // A user's roles and effective permissions
+			admin.POST("/users/:userId/roles", deps.AdminHandler.AssignRole)                         // Assign a custom role
+			admin.DELETE("/users/:userId/roles/:roleId", deps.AdminHandler.UnassignRole)             // Take a custom role away
+			admin.POST("/honeypots", deps.AdminHandler.CreateHoneypot)                               // Plant a honeypot account or canary API key
+			admin.GET("/honeypots", deps.AdminHandler.ListHoneypots)                                 // Honeypots and their trigger counts
+			admin.DELETE("/honeypots/:honeypotId", deps.AdminHandler.DisableHoneypot)                // Stop a honeypot from alerting
+			admin.GET("/honeypots/:honeypotId/triggers", deps.AdminHandler.ListHoneypotTriggers)     // Audit trail of a honeypot's uses
+			admin.POST("/oauth-clients", deps.AdminHandler.CreateOAuthClient)                        // Register a service for the client-credentials grant
+			admin.GET("/oauth-clients", deps.AdminHandler.ListOAuthClients)                          // Registered services and their scopes
+			admin.GET("/oauth-clients/:clientId", deps.AdminHandler.GetOAuthClient)                  // One registered service
+			admin.PATCH("/oauth-clients/:clientId", deps.AdminHandler.UpdateOAuthClient)             // Rename a service or replace its scopes
+			admin.DELETE("/oauth-clients/:clientId", deps.AdminHandler.DeleteOAuthClient)            // Remove a service and invalidate its tokens
+			admin.POST("/oauth-clients/:clientId/secret", deps.AdminHandler.RotateOAuthClientSecret) // Replace a service's secret
+			admin.GET("/maintenance", deps.MaintenanceHandler.GetMaintenance)                        // Read-only maintenance mode
+			admin.PUT("/maintenance", deps.MaintenanceHandler.SetMaintenance)                        // Enter or leave read-only maintenance mode
+			admin.GET("/email-domains", deps.EmailDomainsHandler.GetEmailDomains)                    // Disposable, popular and typo domain lists checked at registration
+			admin.PUT("/email-domains", deps.EmailDomainsHandler.SetEmailDomains)                    // Replace the lists on every replica
+			admin.DELETE("/email-domains", deps.EmailDomainsHandler.ResetEmailDomains)               // Return to the [email_domains] lists
+			admin.GET("/deprecations", deps.DeprecationHandler.GetDeprecations)                      // Deprecated endpoints and who still calls them
+			admin.GET("/export/:resource", deps.ExportHandler.Export)                                // NDJSON page of users, sessions or activities for analytics pipelines
+			admin.GET("/audit", deps.AdminHandler.ListAuditLog)                                      // Search or export admin operations and security events
+			admin.GET("/audit/verify", deps.AdminHandler.VerifyAuditLog)                             // Check the audit log hash chain
+
+			// Runtime logging changes on every replica; each reverts by itself
+			admin.GET("/logging", deps.LoggingHandler.GetLogging)                                   // Effective log level and debug targets
+			admin.PUT("/logging/level", deps.LoggingHandler.SetLogLevel)                            // Time-boxed log level override
+			admin.DELETE("/logging/level", deps.LoggingHandler.ResetLogLevel)                       // End the override early
+			admin.POST("/logging/debug-targets", deps.LoggingHandler.AddDebugTarget)                // Time-boxed debug logging for a user or path
+			admin.DELETE("/logging/debug-targets/:targetId", deps.LoggingHandler.RemoveDebugTarget) // End a debug target early
+		}
+	}
+
+	// Deprecations of routes that don't exist would never send their headers
+	for _, endpoint := range deps.Deprecations.Unregistered(router.Routes()) {
+		log.Printf("⚠️  Deprecated endpoint %s matches no route", endpoint)
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+	"shared/middleware"
+)
+
+// Personal access token errors; handlers map them to statuses with errors.Is
+var (
+	ErrAccessTokenLimit      = errors.New("personal access token limit reached; revoke an unused token first")
+	ErrAccessTokenAdminScope = errors.New("only admins can create tokens with the admin scope")
+	ErrAccessTokenExpiry     = errors.New("expires_at must be in the future and within personal_access_tokens.max_lifetime")
+	ErrInvalidAccessToken    = errors.New("invalid personal access token")
+	ErrAccessTokensDisabled  = errors.New("personal access tokens are not enabled")
+)
+
+// accessTokenBytes is the entropy of a personal access token, before hex encoding
+const accessTokenBytes = 32
+
+// accessTokenUseInterval limits how often last_used_at is written for a busy token
+const accessTokenUseInterval = time.Minute
+
+// accessTokenScopes lists the scopes in the order they are stored
+var accessTokenScopes = []string{models.TokenScopeRead, models.TokenScopeWrite, models.TokenScopeAdmin}
+
+// CreatePersonalAccessToken mints a token for the user's scripts and integrations
+// The token is returned once; only its hash is stored
+func (s *authService) CreatePersonalAccessToken(userID uuid.UUID, req *models.CreatePersonalAccessTokenRequest, client models.ClientInfo) (*models.PersonalAccessTokenResponse, error) {
+	if s.accessTokens.Prefix == "" {
+		return nil, ErrAccessTokensDisabled
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	requestedScopes := req.Scopes
+	if len(requestedScopes) == 0 {
+		requestedScopes = []string{models.TokenScopeRead}
+	}
+	scopes := make([]string, 0, len(accessTokenScopes))
+	for _, scope := range accessTokenScopes {
+		for _, requested := range requestedScopes {
+			if requested == scope {
+				scopes = append(scopes, scope)
+				break
+			}
+		}
+	}
+	for _, scope := range scopes {
+		if scope == models.TokenScopeAdmin && user.Role != models.RoleAdmin {
+			return nil, ErrAccessTokenAdminScope
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.accessTokens.DefaultLifetime)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	if !expiresAt.After(now) || (s.accessTokens.MaxLifetime > 0 && expiresAt.After(now.Add(s.accessTokens.MaxLifetime))) {
+		return nil, ErrAccessTokenExpiry
+	}
+
+	existing, err := s.userRepo.ListPersonalAccessTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+	active := 0
+	for i := range existing {
+		if existing[i].Active(now) {
+			active++
+		}
+	}
+	if s.accessTokens.MaxPerUser > 0 && active >= s.accessTokens.MaxPerUser {
+		return nil, ErrAccessTokenLimit
+	}
+
+	secret, err := generateRandomToken(accessTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	token := s.accessTokens.Prefix + secret
+	record := &models.PersonalAccessToken{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		TokenHash:   s.jwtService.HashToken(token),
+		TokenPrefix: token[:len(s.accessTokens.Prefix)+4],
+		Scopes:      strings.Join(scopes, ","),
+		ExpiresAt:   expiresAt,
+	}
+	if err := s.userRepo.CreatePersonalAccessToken(record); err != nil {
+		return nil, err
+	}
+
+	s.recordAccessTokenActivity(userID, "access_token_created", "Personal access token \""+record.Name+"\" created", record, client)
+	return &models.PersonalAccessTokenResponse{
+		PersonalAccessToken: models.PersonalAccessTokenInfo{PersonalAccessToken: *record, Scopes: scopes},
+		Token:               token,
+	}, nil
+}
+
+// ListPersonalAccessTokens returns the user's tokens that were not revoked, newest first
+func (s *authService) ListPersonalAccessTokens(userID uuid.UUID) ([]models.PersonalAccessTokenInfo, error) {
+	tokens, err := s.userRepo.ListPersonalAccessTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]models.PersonalAccessTokenInfo, 0, len(tokens))
+	for i := range tokens {
+		infos = append(infos, models.PersonalAccessTokenInfo{PersonalAccessToken: tokens[i], Scopes: tokens[i].ScopeList()})
+	}
+	return infos, nil
+}
+
+// RevokePersonalAccessToken revokes one of the user's tokens; requests made with it fail from then on
+func (s *authService) RevokePersonalAccessToken(userID, tokenID uuid.UUID, client models.ClientInfo) error {
+	record, err := s.userRepo.RevokePersonalAccessToken(userID, tokenID)
+	if err != nil {
+		return err
+	}
+	s.recordAccessTokenActivity(userID, "access_token_revoked", "Personal access token \""+record.Name+"\" revoked", record, client)
+	return nil
+}
+
+// ValidatePersonalAccessToken authenticates a request made with a personal access token and returns
+// claims for the shared JWT middleware, with the user's current role and the token's scopes
+// It implements middleware.PersonalAccessTokenValidator
+func (s *authService) ValidatePersonalAccessToken(token string) (*middleware.JWTClaims, error) {
+	if s.accessTokens.Prefix == "" || !strings.HasPrefix(token, s.accessTokens.Prefix) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	record, err := s.userRepo.GetPersonalAccessTokenByHash(s.jwtService.HashToken(token))
+	if err != nil {
+		if !errors.Is(err, repositories.ErrAccessTokenNotFound) {
+			log.Printf("❌ Failed to look up personal access token: %v", err)
+		}
+		return nil, ErrInvalidAccessToken
+	}
+	now := time.Now()
+	if !record.Active(now) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	user, err := s.userRepo.GetByID(record.UserID)
+	if err != nil || !user.IsActive {
+		return nil, ErrInvalidAccessToken
+	}
+
+	// Skipped while read-only; the update would fail against a replica
+	if !s.maintenance.ReadOnly() {
+		if err := s.userRepo.RecordPersonalAccessTokenUse(record.ID, now, accessTokenUseInterval); err != nil {
+			log.Printf("⚠️  Failed to record use of personal access token %s: %v", record.ID, err)
+		}
+	}
+
+	return &middleware.JWTClaims{
+		UserID:       user.ID.String(),
+		Email:        user.Email,
+		Username:     user.Username,
+		Role:         string(user.Role),
+		Type:         middleware.TokenTypePersonal,
+		Scopes:       record.ScopeList(),
+		TokenVersion: int64(user.TokenVersion),
+		Subject:      user.ID.String(),
+		IssuedAt:     record.CreatedAt.Unix(),
+		ExpiresAt:    record.ExpiresAt.Unix(),
+	}, nil
+}
+
+// recordAccessTokenActivity adds a personal access token change to the user's activity feed
+func (s *authService) recordAccessTokenActivity(userID uuid.UUID, action, description string, token *models.PersonalAccessToken, client models.ClientInfo) {
+	activity := &models.UserActivity{
+		ID:          models.NewID(),
+		UserID:      userID,
+		Action:      action,
+		Description: description,
+		IPAddress:   s.ipPrivacy.Address(client.IPAddress),
+		IPHash:      s.ipPrivacy.Hash(client.IPAddress),
+		UserAgent:   client.UserAgent,
+		RequestID:   client.RequestID,
+		Metadata:    fmt.Sprintf(`{"token_id":%q,"token_prefix":%q}`, token.ID, token.TokenPrefix),
+		CreatedAt:   time.Now(),
+	}
+	if err := s.userRepo.CreateUserActivity(activity); err != nil {
+		log.Printf("⚠️  Failed to record %s for user %s: %v", action, userID, err)
+	}
+}
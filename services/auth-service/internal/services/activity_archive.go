@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/maintenance"
+	"auth-service/internal/models"
+	"auth-service/internal/objectstore"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// activityArchiveContentType is gzipped JSON Lines, one activity per line, newest first
+const activityArchiveContentType = "application/x-ndjson"
+
+// ActivityArchive periodically moves user activities older than activity_archive.max_age from Postgres
+// to object storage, and reads them back for deep history pages
+type ActivityArchive struct {
+	userRepo    repositories.UserRepository
+	store       objectstore.Store
+	config      config.ActivityArchiveConfig
+	maintenance *maintenance.Mode
+
+	mu          sync.Mutex
+	archived    uint64
+	objects     uint64
+	bytes       uint64
+	batches     map[string]uint64
+	lastSuccess time.Time
+}
+
+// archivedActivity is an activity as stored in an archive object; unlike the API it keeps the IP hash
+type archivedActivity struct {
+	models.UserActivity
+	IPHash string `json:"ip_hash,omitempty"`
+}
+
+// NewActivityArchive creates the archive job; runs are skipped while mode is read-only
+func NewActivityArchive(userRepo repositories.UserRepository, store objectstore.Store, cfg config.ActivityArchiveConfig, mode *maintenance.Mode) *ActivityArchive {
+	return &ActivityArchive{
+		userRepo:    userRepo,
+		store:       store,
+		config:      cfg,
+		maintenance: mode,
+		batches:     map[string]uint64{"success": 0, "error": 0},
+	}
+}
+
+// Start runs the job immediately and then every activity_archive.interval until ctx is cancelled
+// It does nothing unless activity_archive.enabled is set
+func (a *ActivityArchive) Start(ctx context.Context) {
+	if a == nil || !a.config.Enabled {
+		return
+	}
+
+	go func() {
+		a.run(ctx)
+
+		ticker := time.NewTicker(a.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.run(ctx)
+			}
+		}
+	}()
+}
+
+// run archives batches until no activities older than max_age are left or ctx is cancelled
+func (a *ActivityArchive) run(ctx context.Context) {
+	if a.maintenance.ReadOnly() {
+		return
+	}
+
+	olderThan := time.Now().Add(-a.config.MaxAge)
+	var total int64
+	for ctx.Err() == nil {
+		var size int
+		archived, err := a.userRepo.ArchiveActivities(olderThan, a.config.BatchSize,
+			func(userID uuid.UUID, activities []models.UserActivity) (*models.UserActivityArchive, error) {
+				archive, err := a.write(ctx, userID, activities)
+				if archive != nil {
+					size = int(archive.SizeBytes)
+				}
+				return archive, err
+			})
+		a.record(archived, size, err)
+		if err != nil {
+			log.Printf("❌ Activity archive failed after archiving %d activities: %v", total, err)
+			return
+		}
+		total += archived
+		if archived == 0 {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("🗄️  Activity archive moved %d activities older than %s to object storage",
+			total, olderThan.UTC().Format(time.RFC3339))
+	}
+}
+
+// write stores activities, newest first, as one object and returns its manifest row
+func (a *ActivityArchive) write(ctx context.Context, userID uuid.UUID, activities []models.UserActivity) (*models.UserActivityArchive, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(zw)
+	for _, activity := range activities {
+		if err := encoder.Encode(archivedActivity{UserActivity: activity, IPHash: activity.IPHash}); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	archive := &models.UserActivityArchive{
+		ID:             models.NewID(),
+		UserID:         userID,
+		ActivityCount:  len(activities),
+		SizeBytes:      int64(buf.Len()),
+		FirstCreatedAt: activities[len(activities)-1].CreatedAt,
+		LastCreatedAt:  activities[0].CreatedAt,
+	}
+	archive.ObjectKey = fmt.Sprintf("user_activities/%s/%s-%s.jsonl.gz",
+		userID, archive.FirstCreatedAt.UTC().Format("20060102T150405Z"), archive.ID)
+	if err := a.store.Put(ctx, archive.ObjectKey, buf.Bytes(), activityArchiveContentType); err != nil {
+		return nil, fmt.Errorf("failed to store activity archive: %w", err)
+	}
+	return archive, nil
+}
+
+// Read returns the activities of an archive object, newest first. They are listed for the manifest's
+// user, which differs from the stored one after an account merge
+func (a *ActivityArchive) Read(ctx context.Context, archive models.UserActivityArchive) ([]models.UserActivity, error) {
+	data, err := a.store.Get(ctx, archive.ObjectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activity archive %s: %w", archive.ObjectKey, err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activity archive %s: %w", archive.ObjectKey, err)
+	}
+
+	activities := make([]models.UserActivity, 0, archive.ActivityCount)
+	decoder := json.NewDecoder(bufio.NewReader(zr))
+	for {
+		var stored archivedActivity
+		if err := decoder.Decode(&stored); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode activity archive %s: %w", archive.ObjectKey, err)
+		}
+		activity := stored.UserActivity
+		activity.IPHash = stored.IPHash
+		activity.UserID = archive.UserID
+		activities = append(activities, activity)
+	}
+	return activities, nil
+}
+
+// record adds one batch to the metrics
+func (a *ActivityArchive) record(archived int64, size int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err != nil {
+		a.batches["error"]++
+		return
+	}
+	a.batches["success"]++
+	if archived > 0 {
+		a.archived += uint64(archived)
+		a.objects++
+		a.bytes += uint64(size)
+	}
+	a.lastSuccess = time.Now()
+}
+
+// WritePrometheus writes the activities and objects archived by the job in the Prometheus text format
+func (a *ActivityArchive) WritePrometheus(w io.Writer) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP auth_service_activity_archive_activities_total Activities moved to object storage")
+	fmt.Fprintln(w, "# TYPE auth_service_activity_archive_activities_total counter")
+	fmt.Fprintf(w, "auth_service_activity_archive_activities_total %d\n", a.archived)
+	fmt.Fprintln(w, "# HELP auth_service_activity_archive_objects_total Archive objects written")
+	fmt.Fprintln(w, "# TYPE auth_service_activity_archive_objects_total counter")
+	fmt.Fprintf(w, "auth_service_activity_archive_objects_total %d\n", a.objects)
+	fmt.Fprintln(w, "# HELP auth_service_activity_archive_bytes_total Compressed bytes of the archive objects written")
+	fmt.Fprintln(w, "# TYPE auth_service_activity_archive_bytes_total counter")
+	fmt.Fprintf(w, "auth_service_activity_archive_bytes_total %d\n", a.bytes)
+	fmt.Fprintln(w, "# HELP auth_service_activity_archive_batches_total Activity archive batches by result")
+	fmt.Fprintln(w, "# TYPE auth_service_activity_archive_batches_total counter")
+	for _, result := range []string{"success", "error"} {
+		fmt.Fprintf(w, "auth_service_activity_archive_batches_total{result=%q} %d\n", result, a.batches[result])
+	}
+	if !a.lastSuccess.IsZero() {
+		fmt.Fprintln(w, "# HELP auth_service_activity_archive_last_success_timestamp_seconds When an archive batch last succeeded")
+		fmt.Fprintln(w, "# TYPE auth_service_activity_archive_last_success_timestamp_seconds gauge")
+		fmt.Fprintf(w, "auth_service_activity_archive_last_success_timestamp_seconds %d\n", a.lastSuccess.Unix())
+	}
+}
+
+// archivedActivities continues a history page past the activities still in Postgres: it skips the first
+// offset archived activities, newest first, and returns up to limit of the rest, reading only the objects
+// the page touches
+func (s *authService) archivedActivities(userID uuid.UUID, limit, offset int) ([]models.UserActivity, error) {
+	archives, err := s.userRepo.ListActivityArchives(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var page []models.UserActivity
+	for _, archive := range archives {
+		if len(page) >= limit {
+			break
+		}
+		if offset >= archive.ActivityCount {
+			offset -= archive.ActivityCount
+			continue
+		}
+		activities, err := s.activityArchive.Read(context.Background(), archive)
+		if err != nil {
+			return nil, err
+		}
+		if offset < len(activities) {
+			end := min(len(activities), offset+limit-len(page))
+			page = append(page, activities[offset:end]...)
+		}
+		offset = 0
+	}
+	return page, nil
+}
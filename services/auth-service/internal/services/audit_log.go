@@ -0,0 +1,304 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"log"
+	"reflect"
+	"regexp"
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// auditLogBatchSize is how many entries exports and chain checks read per query
+const auditLogBatchSize = 500
+
+// auditRedactedValue replaces the values of secret fields in audit log changes and metadata
+const auditRedactedValue = "[redacted]"
+
+// auditSecretField matches the JSON names of fields whose values never reach the audit log, such as
+// a rotated client_secret or a token in a request body
+var auditSecretField = regexp.MustCompile(`(?i)(password|secret|private_?key|api_?key|(^|_)token$)`)
+
+// RecordAudit chains event onto the end of the audit log; secret fields are redacted
+func (s *authService) RecordAudit(event models.AuditEvent) error {
+	changes, err := json.Marshal(auditDiff(event.Before, event.After))
+	if err != nil {
+		return fmt.Errorf("audit changes: %w", err)
+	}
+	metadata := []byte("{}")
+	if len(event.Metadata) > 0 {
+		if metadata, err = json.Marshal(redactAuditValue(auditFields(event.Metadata))); err != nil {
+			return fmt.Errorf("audit metadata: %w", err)
+		}
+	}
+
+	entry := &models.AuditLogEntry{
+		ID:         models.NewID(),
+		ActorID:    event.ActorID,
+		ActorType:  event.ActorType,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Status:     event.Status,
+		Changes:    string(changes),
+		Metadata:   string(metadata),
+		RequestID:  event.Client.RequestID,
+		IPAddress:  s.ipPrivacy.Address(event.Client.IPAddress),
+		IPHash:     s.ipPrivacy.Hash(event.Client.IPAddress),
+		UserAgent:  event.Client.UserAgent,
+		// Postgres keeps microseconds; the hash has to cover the time as it reads back
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	return s.userRepo.AppendAuditLog(entry, func(entry *models.AuditLogEntry) error {
+		var err error
+		entry.Hash, err = s.auditHash(entry)
+		return err
+	})
+}
+
+// auditSecurityEvent records a security event the service detected itself; userID is the account it
+// concerns, nil when there is none
+func (s *authService) auditSecurityEvent(action string, userID *uuid.UUID, client models.ClientInfo, metadata map[string]interface{}) {
+	event := models.AuditEvent{
+		ActorType: models.AuditActorSystem,
+		Action:    "security." + action,
+		Metadata:  metadata,
+		Client:    client,
+	}
+	if userID != nil {
+		event.TargetType, event.TargetID = "user", userID.String()
+	}
+	if err := s.RecordAudit(event); err != nil {
+		log.Printf("⚠️  Failed to record %s in the audit log: %v", event.Action, err)
+	}
+}
+
+// ListAuditLog returns a page of the audit log entries matching filter, newest first
+func (s *authService) ListAuditLog(filter models.AuditLogFilter, limit, offset int) (*models.AdminAuditLogResponse, error) {
+	entries, total, err := s.userRepo.ListAuditLog(filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.AdminAuditLogResponse{
+		Entries: make([]models.AdminAuditEntry, 0, len(entries)),
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	}
+	for i := range entries {
+		response.Entries = append(response.Entries, decodeAuditEntry(entries[i]))
+	}
+	return response, nil
+}
+
+// ExportAuditLog passes every entry matching filter to write in chain order, stopping at the first error
+func (s *authService) ExportAuditLog(filter models.AuditLogFilter, write func(entry *models.AdminAuditEntry) error) error {
+	var afterSeq int64
+	for {
+		entries, err := s.userRepo.ListAuditLogAfter(filter, afterSeq, auditLogBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range entries {
+			entry := decodeAuditEntry(entries[i])
+			if err := write(&entry); err != nil {
+				return err
+			}
+			afterSeq = entries[i].Seq
+		}
+		if len(entries) < auditLogBatchSize {
+			return nil
+		}
+	}
+}
+
+// VerifyAuditLog walks the audit log from its first entry and reports the first one that was changed,
+// removed or inserted. Entries removed from the end go unnoticed unless the head is compared with a
+// head_seq and head_hash recorded earlier
+func (s *authService) VerifyAuditLog() (*models.AdminAuditVerifyResponse, error) {
+	result := &models.AdminAuditVerifyResponse{Valid: true, Keyed: s.auditLog.Key != ""}
+	var afterSeq int64
+	prevHash := ""
+	for {
+		entries, err := s.userRepo.ListAuditLogAfter(models.AuditLogFilter{}, afterSeq, auditLogBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			entry := &entries[i]
+			if reason := s.auditChainBreak(entry, afterSeq, prevHash); reason != "" {
+				result.Valid, result.BrokenAt, result.Reason = false, entry.Seq, reason
+				return result, nil
+			}
+			result.Checked++
+			result.HeadSeq, result.HeadHash = entry.Seq, entry.Hash
+			afterSeq, prevHash = entry.Seq, entry.Hash
+		}
+		if len(entries) < auditLogBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// auditChainBreak says why entry can't follow the entry with prevSeq and prevHash; empty when it can
+func (s *authService) auditChainBreak(entry *models.AuditLogEntry, prevSeq int64, prevHash string) string {
+	if entry.Seq != prevSeq+1 {
+		return fmt.Sprintf("entries %d to %d are missing", prevSeq+1, entry.Seq-1)
+	}
+	if entry.PrevHash != prevHash {
+		return "prev_hash doesn't match the hash of the previous entry"
+	}
+	expected, err := s.auditHash(entry)
+	if err != nil {
+		return "unreadable entry: " + err.Error()
+	}
+	if !hmac.Equal([]byte(expected), []byte(entry.Hash)) {
+		return "the entry doesn't match its hash"
+	}
+	return ""
+}
+
+// auditHash is the hex HMAC-SHA256 (SHA-256 without a key) of entry's columns and PrevHash. JSON columns
+// are hashed in canonical form, since Postgres doesn't keep the key order and spacing they were written in
+func (s *authService) auditHash(entry *models.AuditLogEntry) (string, error) {
+	changes, err := canonicalJSON(entry.Changes)
+	if err != nil {
+		return "", err
+	}
+	metadata, err := canonicalJSON(entry.Metadata)
+	if err != nil {
+		return "", err
+	}
+	var actorID, ipAddress string
+	if entry.ActorID != nil {
+		actorID = entry.ActorID.String()
+	}
+	if entry.IPAddress != nil {
+		ipAddress = *entry.IPAddress
+	}
+
+	payload, err := json.Marshal([]interface{}{
+		entry.Seq, entry.ID.String(), actorID, entry.ActorType, entry.Action, entry.TargetType, entry.TargetID,
+		entry.Status, changes, metadata, entry.RequestID, ipAddress, entry.IPHash, entry.UserAgent,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano), entry.PrevHash,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var mac hash.Hash
+	if s.auditLog.Key != "" {
+		mac = hmac.New(sha256.New, []byte(s.auditLog.Key))
+	} else {
+		mac = sha256.New()
+	}
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// canonicalJSON re-encodes a JSON document with sorted object keys and no spacing
+func canonicalJSON(document string) (json.RawMessage, error) {
+	if document == "" {
+		document = "{}"
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// auditDiff returns the fields, by JSON name, whose values differ between before and after with secret
+// fields redacted. Values that aren't JSON objects are compared whole as the field "value"
+func auditDiff(before, after interface{}) map[string]models.AuditChange {
+	beforeFields, afterFields := auditFields(before), auditFields(after)
+	changes := make(map[string]models.AuditChange)
+	for name, value := range beforeFields {
+		if other, ok := afterFields[name]; !ok || !reflect.DeepEqual(value, other) {
+			changes[name] = models.AuditChange{Before: value, After: other}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = models.AuditChange{After: value}
+		}
+	}
+	for name, change := range changes {
+		if auditSecretField.MatchString(name) {
+			changes[name] = models.AuditChange{Before: redactedIfSet(change.Before), After: redactedIfSet(change.After)}
+		} else {
+			changes[name] = models.AuditChange{Before: redactAuditValue(change.Before), After: redactAuditValue(change.After)}
+		}
+	}
+	return changes
+}
+
+// auditFields decodes value's JSON encoding into its fields; nil values have none
+func auditFields(value interface{}) map[string]interface{} {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return map[string]interface{}{"value": fmt.Sprint(value)}
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded == nil {
+		return nil
+	}
+	if fields, ok := decoded.(map[string]interface{}); ok {
+		return fields
+	}
+	return map[string]interface{}{"value": decoded}
+}
+
+// redactAuditValue replaces the values of secret fields anywhere within a decoded JSON value
+func redactAuditValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for name, field := range v {
+			if auditSecretField.MatchString(name) {
+				redacted[name] = redactedIfSet(field)
+			} else {
+				redacted[name] = redactAuditValue(field)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redactAuditValue(item)
+		}
+		return redacted
+	}
+	return value
+}
+
+// redactedIfSet hides a secret value but keeps whether there was one
+func redactedIfSet(value interface{}) interface{} {
+	if value == nil || value == "" {
+		return value
+	}
+	return auditRedactedValue
+}
+
+// decodeAuditEntry decodes the JSON columns of an audit log entry for the admin API
+func decodeAuditEntry(entry models.AuditLogEntry) models.AdminAuditEntry {
+	decoded := models.AdminAuditEntry{AuditLogEntry: entry}
+	if err := json.Unmarshal([]byte(entry.Changes), &decoded.Changes); err != nil {
+		log.Printf("⚠️  Unreadable changes in audit log entry %d: %v", entry.Seq, err)
+	}
+	if err := json.Unmarshal([]byte(entry.Metadata), &decoded.Metadata); err != nil {
+		log.Printf("⚠️  Unreadable metadata in audit log entry %d: %v", entry.Seq, err)
+	}
+	return decoded
+}
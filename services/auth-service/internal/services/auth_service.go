@@ -1,33 +1,59 @@
 package services
 
 import (
+	"auth-service/internal/captcha"
 	"auth-service/internal/config"
+	"auth-service/internal/datakeys"
+	"auth-service/internal/emaildomains"
+	"auth-service/internal/feeds"
+	"auth-service/internal/geoip"
+	"auth-service/internal/mail"
+	"auth-service/internal/maintenance"
 	"auth-service/internal/models"
+	"auth-service/internal/privacy"
+	"auth-service/internal/pwnedpasswords"
 	"auth-service/internal/repositories"
+	"auth-service/internal/telemetry"
+	"auth-service/internal/webauthn"
+	"auth-service/internal/webpush"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"shared/events"
+	"shared/middleware"
 )
 
 type AuthService interface {
 	// Existing Auth functionality
-	Register(req *models.RegisterRequest) (*models.AuthResponse, error)
-	Login(req *models.LoginRequest, ipAddress, userAgent string) (*models.AuthResponse, error)
-	RefreshToken(req *models.RefreshTokenRequest) (*models.RefreshResponse, error)
-	VerifyToken(token string) (*models.VerifyTokenResponse, error)
+	Register(req *models.RegisterRequest, client models.ClientInfo) (*models.AuthResponse, error)
+	Login(req *models.LoginRequest, client models.ClientInfo) (*models.AuthResponse, error)
+	RefreshToken(req *models.RefreshTokenRequest, client models.ClientInfo) (*models.RefreshResponse, error)
+	// VerifyToken takes the client only so the call can be linked to the request's trace
+	VerifyToken(token string, client models.ClientInfo) (*models.VerifyTokenResponse, error)
+	// VerifyAPIKey checks an X-API-Key value, which can only be a personal access token, never a JWT
+	VerifyAPIKey(key string, client models.ClientInfo) (*models.VerifyTokenResponse, error)
 	Logout(userID uuid.UUID, token string) error
-	ChangePassword(userID uuid.UUID, req *models.ChangePasswordRequest) error
+	LogoutAll(userID uuid.UUID, token string) (int64, error)
+	// ChangePassword returns warnings about the new password, like breached password hints in warn mode
+	ChangePassword(userID uuid.UUID, req *models.ChangePasswordRequest) ([]models.ValidationHint, error)
 	DeleteAccount(userID uuid.UUID) error
 	GetProfile(userID uuid.UUID) (*models.UserInfo, error)
+	// GetBootstrap returns the profile, preferences and unread notification count in one call
+	GetBootstrap(userID uuid.UUID) (*models.BootstrapResponse, error)
 	UpdateProfile(userID uuid.UUID, req *models.UpdateProfileRequest) (*models.UserInfo, error)
-	ForgotPassword(req *models.ForgotPasswordRequest) error
-	ResetPassword(req *models.ResetPasswordRequest) error
+	ForgotPassword(req *models.ForgotPasswordRequest, client models.ClientInfo) error
+	ResetPassword(req *models.ResetPasswordRequest, client models.ClientInfo) error
 	
 	// Extended User Service functionality (from refactoring plan Task 1.2)
 	GetUserPreferences(userID uuid.UUID) (*models.UserPreference, error)
@@ -37,10 +63,115 @@ type AuthService interface {
 	// Activity and notification management
 	LogUserActivity(userID uuid.UUID, action, description string, metadata map[string]interface{}) error
 	GetUserActivities(userID uuid.UUID, limit, offset int) ([]models.UserActivity, error)
+	GetLoginHistory(userID uuid.UUID, limit, offset int) (*models.LoginHistoryResponse, error)
 	
 	GetUserNotifications(userID uuid.UUID) ([]models.UserNotification, error)
 	MarkNotificationAsRead(userID, notificationID uuid.UUID) error
 	CreateNotification(userID uuid.UUID, req *CreateNotificationRequest) error
+
+	// Self-service session management: the user's own signed-in devices
+	ListUserSessions(userID uuid.UUID, accessToken string) ([]models.UserSessionInfo, error)
+	RevokeUserSession(userID, sessionID uuid.UUID, client models.ClientInfo) error
+	RevokeOtherSessions(userID uuid.UUID, accessToken string, client models.ClientInfo) (int64, error)
+
+	// Administrative session management across all users (incident response)
+	SearchSessions(filter models.SessionFilter, limit, offset int) ([]models.Session, int64, error)
+	RevokeSessions(adminID uuid.UUID, filter models.SessionFilter, reason string, dryRun bool) (*models.AdminRevokeSessionsResponse, error)
+
+	// Two-factor enforcement policy
+	TwoFactorCompliance() (*models.TwoFactorComplianceReport, error)
+	DisableTwoFactor(adminID, userID uuid.UUID, reason string) error
+
+	// Admin invitations for accounts created without a password
+	InviteUser(adminID uuid.UUID, req *models.AdminInviteUserRequest) (*models.AdminInvitationResponse, error)
+	ResendInvitation(adminID, userID uuid.UUID) (*models.AdminInvitationResponse, error)
+	AcceptInvitation(req *models.AcceptInvitationRequest, client models.ClientInfo) (*models.UserInfo, error)
+
+	// Registration review queue for registration.mode = "approval"
+	ListPendingRegistrations(limit, offset int) (*models.AdminRegistrationQueueResponse, error)
+	ApproveRegistration(adminID, userID uuid.UUID) (*models.AdminRegistrationReviewResponse, error)
+	RejectRegistration(adminID, userID uuid.UUID, reason string) (*models.AdminRegistrationReviewResponse, error)
+
+	// Time-boxed role grants (just-in-time elevated access)
+	GrantRole(adminID, userID uuid.UUID, req *models.AdminGrantRoleRequest) (*models.RoleGrant, error)
+	RevokeRoleGrant(adminID, grantID uuid.UUID, reason string) (*models.RoleGrant, error)
+	ListRoleGrants(userID uuid.UUID, activeOnly bool, limit, offset int) (*models.AdminRoleGrantListResponse, error)
+	ExpireRoleGrants() (int, error)
+
+	// Roles and permissions - custom roles with named permissions, assigned to users next to users.role
+	ListRoles() ([]models.Role, error)
+	GetRole(roleID uuid.UUID) (*models.Role, error)
+	CreateRole(adminID uuid.UUID, req *models.AdminCreateRoleRequest) (*models.Role, error)
+	UpdateRole(adminID, roleID uuid.UUID, req *models.AdminUpdateRoleRequest) (*models.Role, error)
+	DeleteRole(adminID, roleID uuid.UUID) error
+	SetRolePermissions(adminID, roleID uuid.UUID, req *models.AdminSetRolePermissionsRequest) (*models.Role, error)
+	ListPermissions() ([]models.Permission, error)
+	CreatePermission(adminID uuid.UUID, req *models.AdminCreatePermissionRequest) (*models.Permission, error)
+	DeletePermission(adminID, permissionID uuid.UUID) error
+	GetUserRoles(userID uuid.UUID) (*models.AdminUserRolesResponse, error)
+	AssignRole(adminID, userID uuid.UUID, req *models.AdminAssignRoleRequest) (*models.UserRoleAssignment, error)
+	UnassignRole(adminID, userID, roleID uuid.UUID) error
+
+	// Honeypots - planted credentials whose use raises an alert and blocks the caller
+	CreateHoneypot(adminID uuid.UUID, req *models.AdminCreateHoneypotRequest) (*models.AdminHoneypotResponse, error)
+	ListHoneypots(includeDisabled bool) ([]models.Honeypot, error)
+	DisableHoneypot(adminID, honeypotID uuid.UUID) (*models.Honeypot, error)
+	ListHoneypotTriggers(honeypotID uuid.UUID, limit, offset int) (*models.AdminHoneypotTriggerListResponse, error)
+	CheckCanaryKey(key string, client models.ClientInfo) bool
+	IsIPBlocked(ipAddress string) (bool, error)
+	TokenVersion(userID uuid.UUID) (int, error)
+
+	// Passkeys - WebAuthn registration, management and passwordless login
+	BeginPasskeyRegistration(userID uuid.UUID) (*webauthn.CreationOptions, error)
+	FinishPasskeyRegistration(userID uuid.UUID, req *models.PasskeyRegistrationRequest, client models.ClientInfo) (*models.WebAuthnCredential, error)
+	ListPasskeys(userID uuid.UUID) ([]models.WebAuthnCredential, error)
+	RenamePasskey(userID, passkeyID uuid.UUID, name string) (*models.WebAuthnCredential, error)
+	DeletePasskey(userID, passkeyID uuid.UUID, client models.ClientInfo) error
+	BeginPasskeyLogin(req *models.PasskeyLoginBeginRequest) (*webauthn.RequestOptions, error)
+	FinishPasskeyLogin(req *models.PasskeyLoginRequest, client models.ClientInfo) (*models.AuthResponse, error)
+
+	// Push subscriptions - browsers registered for Web Push notifications
+	ListPushSubscriptions(userID uuid.UUID) (*models.PushSubscriptionsResponse, error)
+	SubscribePush(userID uuid.UUID, req *models.PushSubscriptionRequest, client models.ClientInfo) (*models.PushSubscription, error)
+	UnsubscribePush(userID, subscriptionID uuid.UUID) error
+
+	// OAuth login - find, link or create the account for a provider identity and start a session;
+	// signed-in users also link and unlink providers themselves
+	OAuthLogin(info *models.OAuth2UserInfo, client models.ClientInfo) (*models.AuthResponse, error)
+	IssueOAuthLoginCode(info *models.OAuth2UserInfo, client models.ClientInfo) (string, error)
+	ExchangeOAuthLoginCode(req *models.OAuthCodeExchangeRequest, client models.ClientInfo) (*models.AuthResponse, error)
+	LinkOAuthAccount(userID uuid.UUID, info *models.OAuth2UserInfo, client models.ClientInfo) error
+	UnlinkOAuthAccount(userID uuid.UUID, provider string, client models.ClientInfo) error
+
+	// Personal access tokens for scripts and integrations, accepted by the shared JWT middleware
+	CreatePersonalAccessToken(userID uuid.UUID, req *models.CreatePersonalAccessTokenRequest, client models.ClientInfo) (*models.PersonalAccessTokenResponse, error)
+	ListPersonalAccessTokens(userID uuid.UUID) ([]models.PersonalAccessTokenInfo, error)
+	RevokePersonalAccessToken(userID, tokenID uuid.UUID, client models.ClientInfo) error
+	ValidatePersonalAccessToken(token string) (*middleware.JWTClaims, error)
+
+	// OAuth2 client-credentials grant: a registry of internal services and their short-lived tokens
+	CreateOAuthClient(adminID uuid.UUID, req *models.AdminCreateOAuthClientRequest) (*models.AdminOAuthClientResponse, error)
+	ListOAuthClients() ([]models.OAuthClientInfo, error)
+	GetOAuthClient(clientID string) (*models.OAuthClientInfo, error)
+	UpdateOAuthClient(adminID uuid.UUID, clientID string, req *models.AdminUpdateOAuthClientRequest) (*models.OAuthClientInfo, error)
+	RotateOAuthClientSecret(adminID uuid.UUID, clientID string) (*models.AdminOAuthClientResponse, error)
+	DeleteOAuthClient(adminID uuid.UUID, clientID string) error
+	IssueClientCredentialsToken(req *models.ClientCredentialsTokenRequest, client models.ClientInfo) (*models.ClientCredentialsTokenResponse, error)
+
+	// Account merges - support folds a duplicate account into the one the person keeps
+	MergeUsers(adminID, targetID uuid.UUID, req *models.AdminMergeUserRequest, client models.ClientInfo) (*models.AdminUserMergeResponse, error)
+	ListUserMerges(userID uuid.UUID) ([]models.AdminUserMergeResponse, error)
+
+	// Audit log - tamper-evident record of admin operations and security events
+	RecordAudit(event models.AuditEvent) error
+	ListAuditLog(filter models.AuditLogFilter, limit, offset int) (*models.AdminAuditLogResponse, error)
+	ExportAuditLog(filter models.AuditLogFilter, write func(entry *models.AdminAuditEntry) error) error
+	VerifyAuditLog() (*models.AdminAuditVerifyResponse, error)
+
+	// RSS/Atom and iCal feeds unlocked by a personal token in the URL
+	CreateFeedToken(userID uuid.UUID) (*models.FeedTokenResponse, error)
+	RevokeFeedToken(userID uuid.UUID) error
+	GetFeed(token, kind string) (*feeds.Feed, error)
 }
 
 // Request types for extended User Service functionality
@@ -63,20 +194,154 @@ type CreateNotificationRequest struct {
 }
 
 type authService struct {
-	userRepo    repositories.UserRepository
-	sessionRepo repositories.SessionRepository
-	jwtService  JWTService
+	userRepo          repositories.UserRepository
+	sessionRepo       repositories.SessionRepository
+	tokenRepo         repositories.OneTimeTokenRepository
+	jwtService        JWTService
+	security          config.SecurityConfig
+	passwordPolicy    PasswordPolicy
+	passwordHasher    PasswordHasher
+	twoFactor         config.TwoFactorPolicyConfig
+	funnel            *telemetry.LoginFunnel
+	mailer            mail.Mailer
+	linkBaseURL       string
+	ipPrivacy         *privacy.IPAnonymizer
+	registration      config.RegistrationConfig
+	emailDomains      *emaildomains.Checker // nil leaves registering addresses unchecked
+	breachedPasswords *pwnedpasswords.Checker // nil skips breached password screening
+	activityArchive   *ActivityArchive        // nil when activity_archive is disabled; history ends at Postgres
+	captcha           *captcha.Guard          // nil when captcha.provider is off; nobody is challenged
+	loginAlerts       config.LoginAlertsConfig
+	geoIP             *geoip.Locator          // nil when geoip.provider is off; nothing is located
+	auditLog          config.AuditLogConfig
+	dataKeys          *datakeys.Keyring       // nil when data_encryption is disabled
+	roleGrants        config.RoleGrantConfig
+	policies          *SecurityPolicyResolver
+	tlsFingerprint    config.TLSFingerprintConfig
+	honeypot          config.HoneypotConfig
+	honeypotAlerts    *HoneypotAlerts
+	passkeys          *webauthn.RelyingParty // nil while passkeys are disabled
+	webauthnConfig    config.WebAuthnConfig
+	webPush           *webpush.Pusher // nil while push notifications are disabled
+	webPushConfig     config.WebPushConfig
+	accessTokens      config.PersonalAccessTokenConfig
+	maintenance       *maintenance.Mode
+	oauth             config.OAuth2Config
+	refreshCookie     config.RefreshCookieConfig
+	clientCredentials config.ClientCredentialsConfig
+	events            *events.EventBus
+	bootstraps        flightGroup[*models.BootstrapResponse]
+}
+
+// AuthServiceDeps lists the collaborators of the auth service
+// TokenRepo is optional; without it password reset and invitations are unavailable
+type AuthServiceDeps struct {
+	UserRepo          repositories.UserRepository
+	SessionRepo       repositories.SessionRepository
+	TokenRepo         repositories.OneTimeTokenRepository
+	JWTService        JWTService
+	Security          config.SecurityConfig
+	Funnel            *telemetry.LoginFunnel           // Optional login funnel analytics
+	TwoFactor         config.TwoFactorPolicyConfig     // Zero value requires two-factor authentication for no role
+	Mailer            mail.Mailer                      // Optional; invitations are created but not emailed without it
+	LinkBaseURL       string                           // Frontend origin that emailed links point to
+	IPPrivacy         *privacy.IPAnonymizer            // Optional; IPs are stored in full without it
+	Registration      config.RegistrationConfig        // Zero value activates sign-ups immediately
+	EmailDomains      *emaildomains.Checker            // Optional; disposable and mistyped registering addresses are accepted without it
+	BreachedPasswords *pwnedpasswords.Checker          // Optional; new passwords aren't screened against known breaches without it
+	ActivityArchive   *ActivityArchive                 // Optional; activity history only covers user_activities without it
+	DataKeys          *datakeys.Keyring                // Optional; evicts the keys of deleted accounts from its cache
+	Captcha           *captcha.Guard                   // Optional; logins and sign-ups are never challenged without it
+	LoginAlerts       config.LoginAlertsConfig         // Zero value sends no new sign-in alerts
+	GeoIP             *geoip.Locator                   // Optional; logins aren't located and country rules don't apply without it
+	AuditLog          config.AuditLogConfig            // Zero value chains audit log entries with unkeyed SHA-256
+	RoleGrants        config.RoleGrantConfig           // Zero MaxDuration rejects every role grant
+	Policies          *SecurityPolicyResolver          // Tenant and client overrides of token lifetimes and login security
+	TLSFingerprint    config.TLSFingerprintConfig      // Zero value records fingerprint changes on refresh without rejecting them
+	Honeypot          config.HoneypotConfig            // Zero BlockDuration alerts on honeypot use without blocking the caller
+	HoneypotAlerts    *HoneypotAlerts                  // Optional honeypot trigger metrics
+	WebAuthn          config.WebAuthnConfig            // Zero value (no rp_id) disables passkeys
+	WebPush           *webpush.Pusher                  // Optional; push subscriptions are refused and notifications aren't pushed without it
+	WebPushConfig     config.WebPushConfig             // Subscriptions a user may register
+	AccessTokens      config.PersonalAccessTokenConfig // Zero value (no prefix) disables personal access tokens
+	Maintenance       *maintenance.Mode                // Optional; token last-used times aren't written while read-only
+	OAuth2            config.OAuth2Config              // Lifetime of the one-time codes OAuth callbacks redirect with
+	RefreshCookie     config.RefreshCookieConfig       // First-party origins allowed to refresh from the refresh cookie
+	ClientCredentials config.ClientCredentialsConfig   // Zero value disables the client-credentials grant
+	Events            *events.EventBus                 // Optional; security events such as TokenRevoked aren't published without it
 }
 
 func NewAuthService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository, jwtConfig config.JWTConfig) AuthService {
+	security := config.SecurityConfig{
+		TokenBinding:     config.TokenBindingUserAgent,
+		ResetTokenTTL:    time.Hour,
+		MaxLoginAttempts: 5,
+		LockoutDuration:  15 * time.Minute,
+	}
+	return NewAuthServiceWithDeps(AuthServiceDeps{
+		UserRepo:    userRepo,
+		SessionRepo: sessionRepo,
+		JWTService:  NewJWTService(jwtConfig),
+		Security:    security,
+		Policies:    NewSecurityPolicyResolver(&config.Config{JWT: jwtConfig, Security: security}),
+	})
+}
+
+// NewAuthServiceWithDeps creates an auth service from explicit dependencies (e.g. instrumented ones)
+func NewAuthServiceWithDeps(deps AuthServiceDeps) AuthService {
 	return &authService{
-		userRepo:    userRepo,
-		sessionRepo: sessionRepo,
-		jwtService:  NewJWTService(jwtConfig),
+		userRepo:          deps.UserRepo,
+		sessionRepo:       deps.SessionRepo,
+		tokenRepo:         deps.TokenRepo,
+		funnel:            deps.Funnel,
+		jwtService:        deps.JWTService,
+		security:          deps.Security,
+		passwordPolicy:    NewPasswordPolicy(deps.Security),
+		passwordHasher:    NewPasswordHasher(deps.Security),
+		twoFactor:         deps.TwoFactor,
+		mailer:            deps.Mailer,
+		linkBaseURL:       deps.LinkBaseURL,
+		ipPrivacy:         deps.IPPrivacy,
+		registration:      deps.Registration,
+		emailDomains:      deps.EmailDomains,
+		breachedPasswords: deps.BreachedPasswords,
+		activityArchive:   deps.ActivityArchive,
+		dataKeys:          deps.DataKeys,
+		captcha:           deps.Captcha,
+		loginAlerts:       deps.LoginAlerts,
+		geoIP:             deps.GeoIP,
+		auditLog:          deps.AuditLog,
+		roleGrants:        deps.RoleGrants,
+		policies:          deps.Policies,
+		tlsFingerprint:    deps.TLSFingerprint,
+		honeypot:          deps.Honeypot,
+		honeypotAlerts:    deps.HoneypotAlerts,
+		passkeys:          webauthn.New(deps.WebAuthn),
+		webauthnConfig:    deps.WebAuthn,
+		webPush:           deps.WebPush,
+		webPushConfig:     deps.WebPushConfig,
+		accessTokens:      deps.AccessTokens,
+		maintenance:       deps.Maintenance,
+		oauth:             deps.OAuth2,
+		refreshCookie:     deps.RefreshCookie,
+		clientCredentials: deps.ClientCredentials,
+		events:            deps.Events,
 	}
 }
 
-func (s *authService) Register(req *models.RegisterRequest) (*models.AuthResponse, error) {
+func (s *authService) Register(req *models.RegisterRequest, client models.ClientInfo) (*models.AuthResponse, error) {
+	// Blocked countries are refused, and challenged ones and addresses whose logins keep failing solve a
+	// CAPTCHA, before anything about the sign-up is checked
+	if _, err := s.screenClient(client, req.Email, req.CaptchaToken); err != nil {
+		return nil, err
+	}
+	if err := s.passwordPolicy.Validate(req.Password); err != nil {
+		return nil, err
+	}
+	if err := s.checkEmailDomain(req.Email, req.IgnoreEmailSuggestion); err != nil {
+		return nil, err
+	}
+
 	// Check if email is already taken
 	emailTaken, err := s.userRepo.IsEmailTaken(req.Email)
 	if err != nil {
@@ -95,6 +360,12 @@ func (s *authService) Register(req *models.RegisterRequest) (*models.AuthRespons
 		return nil, errors.New("username already exists")
 	}
 
+	// Screen last, so refused registrations don't cost a breach lookup
+	warnings, err := s.screenBreachedPassword(req.Password, "password")
+	if err != nil {
+		return nil, err
+	}
+
 	// Hash password
 	hashedPassword, err := s.hashPassword(req.Password)
 	if err != nil {
@@ -110,46 +381,138 @@ func (s *authService) Register(req *models.RegisterRequest) (*models.AuthRespons
 		IsActive:     true,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	var response *models.AuthResponse
+	if s.requiresApproval() {
+		response, err = s.registerPending(user)
+	} else {
+		if err := s.userRepo.Create(user); err != nil {
+			return nil, err
+		}
+
+		// Generate tokens under the tenant's policy; sign-ups carry no client ID, so resolving can't fail
+		user.Policy, _ = s.policies.Resolve(user.Email, "")
+		if err := s.loadRoleGrants(user); err != nil {
+			return nil, err
+		}
+		response, err = s.jwtService.GenerateTokenPair(user)
+	}
+	if err != nil {
 		return nil, err
 	}
-
-	// Generate tokens
-	return s.jwtService.GenerateTokenPair(user)
+	response.Warnings = warnings
+	return response, nil
 }
 
-func (s *authService) Login(req *models.LoginRequest, ipAddress, userAgent string) (*models.AuthResponse, error) {
+func (s *authService) Login(req *models.LoginRequest, client models.ClientInfo) (resp *models.AuthResponse, err error) {
+	// Honeypot accounts fail like an unknown email, so the caller can't tell it was detected
+	if s.tripHoneypot(models.HoneypotAccount, strings.ToLower(req.Email), client) {
+		return nil, errors.New("invalid credentials")
+	}
+
+	// Tenant (email domain) and client overrides decide token lifetimes, session limit, 2FA and lockout
+	policy, err := s.policies.Resolve(req.Email, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	funnel := s.funnel.Start()
+	defer func() {
+		if err != nil {
+			funnel.Fail(telemetry.ReasonInternal) // No-op when a specific reason was already recorded
+		}
+	}()
+
+	// Blocked countries are refused; from challenged countries, and after repeated failures from the address
+	// or for the email, only a solved CAPTCHA gets a password checked
+	location, screenErr := s.screenClient(client, req.Email, req.CaptchaToken)
+
 	// Record login attempt
 	loginAttempt := &models.LoginAttempt{
-		Email:     req.Email,
-		IPAddress: ipAddress,
-		UserAgent: userAgent,
-		Success:   false,
+		Email:       req.Email,
+		IPAddress:   s.ipPrivacy.Address(client.IPAddress),
+		IPHash:      s.ipPrivacy.Hash(client.IPAddress),
+		UserAgent:   client.UserAgent,
+		RequestID:   client.RequestID,
+		CountryCode: location.CountryCode,
+		City:        location.City,
+		Success:     false,
+	}
+	if errors.Is(screenErr, ErrCountryBlocked) {
+		loginAttempt.FailureReason = screenErr.Error()
+		s.userRepo.CreateLoginAttempt(loginAttempt)
+		funnel.Fail(telemetry.ReasonCountryBlocked)
+		return nil, screenErr
+	}
+	if screenErr != nil {
+		funnel.Fail(telemetry.ReasonCaptcha)
+		return nil, screenErr
 	}
 
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(strings.ToLower(req.Email))
 	if err != nil {
 		s.userRepo.CreateLoginAttempt(loginAttempt)
+		if queuedErr := s.queuedRegistrationError(strings.ToLower(req.Email), req.Password); queuedErr != nil {
+			funnel.Fail(telemetry.ReasonInactive)
+			return nil, queuedErr
+		}
+		s.captcha.RecordFailure(context.Background(), client.IPAddress, req.Email)
+		funnel.Fail(telemetry.ReasonUnknownUser)
 		return nil, errors.New("invalid credentials")
 	}
+	user.Policy = policy
+	loginAttempt.UserID = &user.ID // Failed attempts on the account show in its login history
 
 	// Check if user can attempt login
 	if !user.CanAttemptLogin() {
 		s.userRepo.CreateLoginAttempt(loginAttempt)
 		if user.IsLocked() {
+			funnel.Fail(telemetry.ReasonLocked)
 			return nil, errors.New("account is temporarily locked")
 		}
+		funnel.Fail(telemetry.ReasonInactive)
 		return nil, errors.New("account is inactive")
 	}
 
 	// Verify password
-	if !s.verifyPassword(req.Password, user.PasswordHash) {
-		user.IncrementFailedAttempts()
+	passwordOK, needsRehash := s.passwordHasher.Verify(req.Password, user.PasswordHash)
+	if !passwordOK {
+		user.IncrementFailedAttempts(policy)
 		s.userRepo.Update(user)
 		s.userRepo.CreateLoginAttempt(loginAttempt)
+		if user.IsLocked() {
+			s.auditSecurityEvent("account_locked", &user.ID, client, map[string]interface{}{
+				"failed_attempts": user.FailedLoginAttempts,
+				"locked_until":    user.LockedUntil.UTC().Format(time.RFC3339),
+			})
+		}
+		s.captcha.RecordFailure(context.Background(), client.IPAddress, req.Email)
+		funnel.Fail(telemetry.ReasonInvalidPassword)
 		return nil, errors.New("invalid credentials")
 	}
+	funnel.Reach(telemetry.StagePasswordOK)
+	s.captcha.Reset(context.Background(), req.Email)
+	if needsRehash {
+		s.rehashPassword(user, req.Password)
+	}
+
+	// Users with two-factor enabled count as challenged; only sampled attempts pay for the lookup
+	if funnel != nil {
+		if prefs, err := s.userRepo.GetUserPreferences(user.ID); err == nil && prefs.TwoFactorEnabled {
+			funnel.Reach(telemetry.StageTwoFactorChallenge)
+		}
+	}
+
+	// Roles that require two-factor authentication may log in without it only during the grace period
+	enrollment, err := s.twoFactorEnrollment(user)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment != nil && enrollment.Overdue {
+		s.userRepo.CreateLoginAttempt(loginAttempt)
+		funnel.Fail(telemetry.ReasonTwoFactorOverdue)
+		return nil, ErrTwoFactorEnrollmentOverdue
+	}
 
 	// Reset failed attempts on successful login
 	if user.FailedLoginAttempts > 0 {
@@ -157,14 +520,51 @@ func (s *authService) Login(req *models.LoginRequest, ipAddress, userAgent strin
 		s.userRepo.Update(user)
 	}
 
+	// A remembered login gets the long refresh token lifetime, when the policy offers one
+	if req.RememberMe {
+		user.Policy = policy.Remembered()
+	}
+
+	authResponse, err := s.startSession(user, client, loginAttempt)
+	if err != nil {
+		return nil, err
+	}
+
+	authResponse.TwoFactorEnrollment = enrollment
+	funnel.Reach(telemetry.StageSuccess)
+	return authResponse, nil
+}
+
+// startSession records a successful login of an authenticated user and issues a token pair bound to a
+// new session, using the user's resolved policy for token lifetimes and the session limit
+func (s *authService) startSession(user *models.User, client models.ClientInfo, loginAttempt *models.LoginAttempt) (*models.AuthResponse, error) {
+	policy := user.Policy
+	loginAttempt.UserID = &user.ID // Makes the attempt part of the user's login history and known devices
+
+	// OAuth and passkey logins are located, and refused from blocked countries, once the user is known
+	if err := s.locateLogin(loginAttempt, client); err != nil {
+		return nil, err
+	}
+
+	// Decided before this login is recorded, so it doesn't make its own device known
+	newDevice := s.isNewSignInDevice(user, client)
+
 	// Update last login
-	s.userRepo.UpdateLastLogin(user.ID, ipAddress)
+	lastLoginIP := ""
+	if address := s.ipPrivacy.Address(client.IPAddress); address != nil {
+		lastLoginIP = *address
+	}
+	s.userRepo.UpdateLastLogin(user.ID, lastLoginIP)
 
 	// Record successful login attempt
 	loginAttempt.Success = true
 	s.userRepo.CreateLoginAttempt(loginAttempt)
 
-	// Generate tokens
+	// Generate tokens, carrying the roles of active grants; the refresh token is bound to the TLS client
+	user.TLSFingerprint = client.TLSFingerprint()
+	if err := s.loadRoleGrants(user); err != nil {
+		return nil, err
+	}
 	authResponse, err := s.jwtService.GenerateTokenPair(user)
 	if err != nil {
 		return nil, err
@@ -172,36 +572,50 @@ func (s *authService) Login(req *models.LoginRequest, ipAddress, userAgent strin
 
 	// Store refresh token in Redis
 	refreshTokenHash := s.jwtService.HashToken(authResponse.RefreshToken)
-	if err := s.sessionRepo.StoreRefreshToken(user.ID, refreshTokenHash, 7*24*time.Hour); err != nil {
+	if err := s.sessionRepo.StoreRefreshToken(user.ID, refreshTokenHash, policy.RefreshExpiry); err != nil {
 		return nil, err
 	}
 
-	// Create session record
+	// Create session record; it lives as long as its refresh token, and refreshes move it onto the new tokens
 	session := &models.Session{
 		UserID:          user.ID,
 		AccessTokenHash: s.jwtService.HashToken(authResponse.AccessToken),
 		RefreshToken:    refreshTokenHash,
-		ExpiresAt:       time.Now().Add(15 * time.Minute),
-		IPAddress:       ipAddress,
-		UserAgent:       userAgent,
-		DeviceInfo:      `{}`, // Set empty JSON object for JSONB column
+		ExpiresAt:       time.Now().Add(policy.RefreshExpiry),
+		IPAddress:       s.ipPrivacy.Address(client.IPAddress),
+		IPHash:          s.ipPrivacy.Hash(client.IPAddress),
+		UserAgent:       client.UserAgent,
+		DeviceInfo:      sessionDeviceInfo(client), // JA3/JA4 fingerprints when the proxy forwards them
+		CountryCode:     loginAttempt.CountryCode,
+		City:            loginAttempt.City,
 		IsActive:        true,
+		RememberMe:      policy.RememberMe,
 	}
 
 	if err := s.sessionRepo.CreateSession(session); err != nil {
 		return nil, err
 	}
-
+	s.enforceSessionLimit(user.ID, policy)
+	if newDevice {
+		s.alertNewSignIn(user, client, geoip.Location{CountryCode: loginAttempt.CountryCode, City: loginAttempt.City})
+	}
 	return authResponse, nil
 }
 
-func (s *authService) RefreshToken(req *models.RefreshTokenRequest) (*models.RefreshResponse, error) {
+func (s *authService) RefreshToken(req *models.RefreshTokenRequest, client models.ClientInfo) (*models.RefreshResponse, error) {
 	// Validate refresh token
 	claims, err := s.jwtService.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
 
+	// The browser attaches the refresh cookie to any request; only the client's own pages may use it
+	if req.Browser != nil && !s.refreshOriginAllowed(claims.ClientID, req.Browser) {
+		log.Printf("🚨 Refresh from the cookie of user %s refused: origin %q, Sec-Fetch-Site %q",
+			claims.UserID, req.Browser.Origin, req.Browser.FetchSite)
+		return nil, ErrRefreshOriginNotAllowed
+	}
+
 	// Check if refresh token is blacklisted
 	tokenHash := s.jwtService.HashToken(req.RefreshToken)
 	isBlacklisted, err := s.sessionRepo.IsTokenBlacklisted(tokenHash)
@@ -212,6 +626,11 @@ func (s *authService) RefreshToken(req *models.RefreshTokenRequest) (*models.Ref
 		return nil, errors.New("refresh token is blacklisted")
 	}
 
+	// A revoked family, or a rotated token used again, ends here
+	if err := s.checkRefreshFamily(tokenHash, claims, client); err != nil {
+		return nil, err
+	}
+
 	// Verify refresh token in Redis
 	userIDStr, err := s.sessionRepo.GetRefreshTokenData(tokenHash)
 	if err != nil {
@@ -238,39 +657,110 @@ func (s *authService) RefreshToken(req *models.RefreshTokenRequest) (*models.Ref
 		return nil, errors.New("user account is inactive")
 	}
 
-	// Generate new access token
+	// A password reset ends every login that happened before it
+	if user.PasswordChangedAt != nil && claims.FamilyIssuedAt < user.PasswordChangedAt.Unix() {
+		s.sessionRepo.DeleteRefreshToken(tokenHash)
+		return nil, errors.New("refresh token was issued before the password was reset")
+	}
+
+	// Keep the policy of the client the user logged in through; a deregistered client has to log in again
+	if user.Policy, err = s.policies.Resolve(user.Email, claims.ClientID); err != nil {
+		return nil, err
+	}
+	if claims.RememberMe {
+		user.Policy = user.Policy.Remembered()
+	}
+
+	if err := s.checkTLSFingerprint(user, claims.TLSFingerprint, client); err != nil {
+		return nil, err
+	}
+
+	// Generate new access token; grants that ended since the last refresh are dropped
+	if err := s.loadRoleGrants(user); err != nil {
+		return nil, err
+	}
 	newAccessToken, err := s.jwtService.GenerateAccessToken(user)
 	if err != nil {
 		return nil, err
 	}
 
-	// Optionally generate new refresh token (rotation)
-	newRefreshToken, err := s.jwtService.GenerateRefreshToken(user)
+	// Rotate the refresh token within its family; absolute mode ends the family at its max lifetime
+	// Tokens issued before families had IDs start one here
+	familyID := claims.FamilyID
+	if familyID == "" {
+		familyID = models.NewID().String()
+	}
+	newRefreshToken, err := s.jwtService.RotateRefreshToken(user, familyID, time.Unix(claims.FamilyIssuedAt, 0))
 	if err != nil {
+		if errors.Is(err, ErrRefreshFamilyExpired) {
+			s.sessionRepo.DeleteRefreshToken(tokenHash)
+		}
 		return nil, err
 	}
 
-	// Store new refresh token in Redis
+	// Replace the old refresh token with the new one; the old one is remembered until it would have
+	// expired, so presenting it again revokes the family
 	newRefreshTokenHash := s.jwtService.HashToken(newRefreshToken)
-	if err := s.sessionRepo.StoreRefreshToken(user.ID, newRefreshTokenHash, 7*24*time.Hour); err != nil {
+	rotatedFor := max(time.Until(time.Unix(claims.ExpiresAt, 0)), time.Minute)
+	err = s.sessionRepo.RotateRefreshToken(user.ID, familyID, tokenHash, newRefreshTokenHash, user.Policy.RefreshExpiry, rotatedFor)
+	if errors.Is(err, repositories.ErrRefreshTokenNotCurrent) {
+		// Another refresh with this token got there first, so two parties hold it
+		if rotatedFamily, _ := s.sessionRepo.GetRotatedRefreshTokenFamily(tokenHash); rotatedFamily != "" {
+			familyID = rotatedFamily
+		}
+		s.revokeReusedFamily(familyID, claims, client)
+		return nil, ErrRefreshTokenReused
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	// Invalidate old refresh token
-	s.sessionRepo.DeleteRefreshToken(tokenHash)
+	// The tokens are already rotated; a session row left behind only shows stale details
+	if err := s.sessionRepo.RecordSessionRefresh(tokenHash, newRefreshTokenHash, s.jwtService.HashToken(newAccessToken),
+		time.Now().Add(user.Policy.RefreshExpiry)); err != nil {
+		log.Printf("⚠️  Failed to update the session of user %s after a token refresh: %v", user.ID, err)
+	}
 
 	return &models.RefreshResponse{
 		AccessToken:  newAccessToken,
 		RefreshToken: newRefreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    int64(15 * time.Minute.Seconds()),
+		ExpiresIn:    int64(user.Policy.AccessExpiry.Seconds()),
 	}, nil
 }
 
-func (s *authService) VerifyToken(token string) (*models.VerifyTokenResponse, error) {
+func (s *authService) VerifyAPIKey(key string, _ models.ClientInfo) (*models.VerifyTokenResponse, error) {
+	return s.verifyPersonalAccessToken(key), nil
+}
+
+// verifyPersonalAccessToken reports whether token is a live personal access token and whose it is
+func (s *authService) verifyPersonalAccessToken(token string) *models.VerifyTokenResponse {
+	claims, err := s.ValidatePersonalAccessToken(token)
+	if err != nil {
+		return &models.VerifyTokenResponse{Valid: false}
+	}
+	return &models.VerifyTokenResponse{
+		Valid:  true,
+		UserID: claims.UserID,
+		Role:   models.UserRole(claims.Role),
+		Email:  claims.Email,
+		Scopes: claims.Scopes,
+	}
+}
+
+func (s *authService) VerifyToken(token string, _ models.ClientInfo) (*models.VerifyTokenResponse, error) {
+	// Personal access tokens are looked up rather than parsed; their scopes are passed downstream
+	if s.accessTokens.Prefix != "" && strings.HasPrefix(token, s.accessTokens.Prefix) {
+		return s.verifyPersonalAccessToken(token), nil
+	}
+
 	// Validate token
 	claims, err := s.jwtService.ValidateToken(token)
 	if err != nil {
+		// Services' client-credentials tokens name a client instead of a user
+		if response, ok := s.verifyClientToken(token); ok {
+			return response, nil
+		}
 		return &models.VerifyTokenResponse{Valid: false}, nil
 	}
 
@@ -298,45 +788,91 @@ func (s *authService) VerifyToken(token string) (*models.VerifyTokenResponse, er
 		return &models.VerifyTokenResponse{Valid: false}, nil
 	}
 
+	// Ending a role grant bumps the token version, revoking tokens that carry the role
+	if claims.TokenVersion < int64(user.TokenVersion) {
+		return &models.VerifyTokenResponse{Valid: false}, nil
+	}
+
 	return &models.VerifyTokenResponse{
-		Valid:  true,
-		UserID: claims.UserID,
-		Role:   models.UserRole(claims.Role),
-		Email:  claims.Email,
+		Valid:       true,
+		UserID:      claims.UserID,
+		Role:        models.UserRole(claims.Role),
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Email:       claims.Email,
+		ClientID:    claims.ClientID,
+		Claims:      claims.Custom,
 	}, nil
 }
 
+// Logout ends the session the access token belongs to; the user's other devices stay signed in
 func (s *authService) Logout(userID uuid.UUID, token string) error {
 	// Blacklist the access token
 	tokenHash := s.jwtService.HashToken(token)
-	if err := s.sessionRepo.BlacklistToken(tokenHash, 15*time.Minute); err != nil {
+	if err := s.sessionRepo.BlacklistToken(tokenHash, sessionBlacklistFor); err != nil {
+		return err
+	}
+
+	// Revoke its session, deleting the refresh token; a token issued outside a login has none
+	session, err := s.sessionRepo.GetSessionByToken(tokenHash)
+	if errors.Is(err, repositories.ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return nil
+	}
+	if err := s.sessionRepo.RevokeUserSession(userID, session.ID, sessionBlacklistFor); err != nil && !errors.Is(err, repositories.ErrSessionNotFound) {
 		return err
 	}
+	return nil
+}
+
+// LogoutAll ends every session of the user, this one included, and returns how many were revoked
+func (s *authService) LogoutAll(userID uuid.UUID, token string) (int64, error) {
+	if err := s.sessionRepo.BlacklistToken(s.jwtService.HashToken(token), sessionBlacklistFor); err != nil {
+		return 0, err
+	}
 
-	// Revoke all sessions for the user
-	return s.sessionRepo.RevokeAllUserSessions(userID)
+	revoked, err := s.sessionRepo.RevokeMatchingSessions(models.SessionFilter{UserID: &userID}, sessionBlacklistFor)
+	if err != nil {
+		return revoked, err
+	}
+	// Sessions other regions created that haven't replicated here yet arrive revoked
+	return revoked, s.sessionRepo.RevokeAllUserSessions(userID)
 }
 
-func (s *authService) ChangePassword(userID uuid.UUID, req *models.ChangePasswordRequest) error {
+func (s *authService) ChangePassword(userID uuid.UUID, req *models.ChangePasswordRequest) ([]models.ValidationHint, error) {
+	if err := s.passwordPolicy.Validate(req.NewPassword); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
-		return errors.New("user not found")
+		return nil, errors.New("user not found")
 	}
 
 	// Verify current password
 	if !s.verifyPassword(req.CurrentPassword, user.PasswordHash) {
-		return errors.New("invalid current password")
+		return nil, errors.New("invalid current password")
+	}
+
+	warnings, err := s.screenBreachedPassword(req.NewPassword, "new_password")
+	if err != nil {
+		return nil, err
 	}
 
 	// Hash new password
 	newPasswordHash, err := s.hashPassword(req.NewPassword)
 	if err != nil {
-		return errors.New("failed to hash new password")
+		return nil, errors.New("failed to hash new password")
 	}
 
 	// Update password
 	user.PasswordHash = newPasswordHash
-	return s.userRepo.Update(user)
+	return warnings, s.userRepo.Update(user)
 }
 
 func (s *authService) DeleteAccount(userID uuid.UUID) error {
@@ -345,8 +881,14 @@ func (s *authService) DeleteAccount(userID uuid.UUID) error {
 		return errors.New("user not found")
 	}
 
-	// Soft delete the user account by setting deleted_at timestamp
-	return s.userRepo.Delete(user.ID)
+	// Soft delete the user account by setting deleted_at timestamp; its data key is deleted with it
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return err
+	}
+	if s.dataKeys != nil {
+		s.dataKeys.Evict(user.ID)
+	}
+	return nil
 }
 
 func (s *authService) GetProfile(userID uuid.UUID) (*models.UserInfo, error) {
@@ -406,11 +948,13 @@ func (s *authService) UpdateProfile(userID uuid.UUID, req *models.UpdateProfileR
 	}, nil
 }
 
-func (s *authService) ForgotPassword(req *models.ForgotPasswordRequest) error {
-	// This is a simplified implementation
-	// In production, you would send an email with a reset link
+func (s *authService) ForgotPassword(req *models.ForgotPasswordRequest, client models.ClientInfo) error {
+	if s.tokenRepo == nil {
+		return errors.New("password reset is not configured")
+	}
+
 	user, err := s.userRepo.GetByEmail(strings.ToLower(req.Email))
-	if err != nil {
+	if err != nil || !user.IsActive {
 		// Don't reveal if email exists or not
 		return nil
 	}
@@ -420,31 +964,286 @@ func (s *authService) ForgotPassword(req *models.ForgotPasswordRequest) error {
 	if err != nil {
 		return err
 	}
+	tokenHash := s.jwtService.HashToken(resetToken)
+	issuedAt := time.Now()
+
+	// The database record enforces expiry and single use; only the hash is stored
+	reset := &models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		RequestID: client.RequestID,
+		ExpiresAt: issuedAt.Add(s.security.ResetTokenTTL),
+	}
+	if err := s.userRepo.CreatePasswordReset(reset); err != nil {
+		return err
+	}
+
+	// The Redis record binds the token to the requesting device and detects replays
+	record := &repositories.OneTimeToken{
+		UserID:        user.ID,
+		Purpose:       repositories.TokenPurposePasswordReset,
+		IPHash:        hashDeviceAttribute(client.IPAddress),
+		UserAgentHash: hashDeviceAttribute(client.UserAgent),
+		IssuedAt:      issuedAt,
+	}
+	if err := s.tokenRepo.Issue(repositories.TokenPurposePasswordReset, tokenHash, record, s.security.ResetTokenTTL); err != nil {
+		return err
+	}
+
+	// Delivery failures are only logged so the response never reveals whether the account exists
+	if s.mailer == nil {
+		log.Printf("⚠️  No mailer configured, password reset email for %s was not sent", user.Email)
+		return nil
+	}
+	if err := s.mailer.Send(s.passwordResetEmail(user, resetToken, reset.ExpiresAt)); err != nil {
+		log.Printf("⚠️  Failed to send password reset email to %s: %v", user.Email, err)
+		return nil
+	}
+
+	if err := s.LogUserActivity(user.ID, "password_reset_requested", "Password reset email sent", map[string]interface{}{
+		"expires_at": reset.ExpiresAt.Format(time.RFC3339),
+	}); err != nil {
+		log.Printf("⚠️  Failed to record password reset activity for user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+func (s *authService) ResetPassword(req *models.ResetPasswordRequest, client models.ClientInfo) error {
+	if s.tokenRepo == nil {
+		return errors.New("password reset is not configured")
+	}
+	// Checked before the token is spent so the user can retry with a stronger password
+	if err := s.passwordPolicy.Validate(req.Password); err != nil {
+		return err
+	}
+
+	record, err := s.consumeOneTimeToken(repositories.TokenPurposePasswordReset, req.Token, client)
+	if err != nil {
+		return err
+	}
 
-	// Create password reset record (simplified - not implemented in this example)
-	_ = user
-	_ = resetToken
+	newPasswordHash, err := s.hashPassword(req.Password)
+	if err != nil {
+		return errors.New("failed to hash new password")
+	}
+
+	// Rejects resets that expired, were used, or were superseded by another completed reset
+	err = s.userRepo.CompletePasswordReset(record.UserID, s.jwtService.HashToken(req.Token), newPasswordHash, time.Now())
+	if errors.Is(err, repositories.ErrPasswordResetNotFound) {
+		return errors.New("invalid or expired reset token")
+	}
+	if err != nil {
+		return err
+	}
 
-	// TODO: Send email with reset link
+	// Existing sessions may belong to whoever triggered the reset; the token version bump above
+	// rejects their access tokens and RefreshToken rejects refresh tokens from before the change
+	userID := record.UserID
+	if _, err := s.sessionRepo.RevokeMatchingSessions(models.SessionFilter{UserID: &userID}, 15*time.Minute); err != nil {
+		log.Printf("⚠️  Failed to revoke sessions of user %s after password reset: %v", userID, err)
+	}
+	if _, err := s.userRepo.RevokeAllPersonalAccessTokens(userID); err != nil {
+		log.Printf("⚠️  Failed to revoke personal access tokens of user %s after password reset: %v", userID, err)
+	}
+
+	if err := s.LogUserActivity(userID, "password_reset", "Password reset; all sessions and personal access tokens ended", map[string]interface{}{
+		"request_id": client.RequestID,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record password reset activity for user %s: %v", userID, err)
+	}
+	if err := s.RecordAudit(models.AuditEvent{
+		ActorID:    &userID,
+		ActorType:  models.AuditActorUser,
+		Action:     "security.password_reset",
+		TargetType: "user",
+		TargetID:   userID.String(),
+		Client:     client,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record password reset of user %s in the audit log: %v", userID, err)
+	}
 	return nil
 }
 
-func (s *authService) ResetPassword(req *models.ResetPasswordRequest) error {
-	// This is a simplified implementation
-	// In production, you would verify the reset token and update the password
-	_ = req
-	return errors.New("not implemented")
+func (s *authService) passwordResetEmail(user *models.User, token string, expiresAt time.Time) mail.Message {
+	link := strings.TrimRight(s.linkBaseURL, "/") + "/reset-password?token=" + url.QueryEscape(token)
+
+	name := user.FirstName
+	if name == "" {
+		name = user.Username
+	}
+
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to reset your password. Open the link below to choose a new one:\n\n"+
+			"%s\n\n"+
+			"The link can be used once and expires on %s. Resetting your password signs you out on every device.\n\n"+
+			"If you didn't request this, you can ignore this email; your password stays the same.\n",
+			name, link, expiresAt.UTC().Format("2006-01-02 15:04 MST")),
+	}
+}
+
+func (s *authService) SearchSessions(filter models.SessionFilter, limit, offset int) ([]models.Session, int64, error) {
+	filter, err := s.storedSessionFilter(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.sessionRepo.SearchSessions(filter, limit, offset)
+}
+
+// RevokeSessions revokes every active session matching filter across all users
+// An empty filter is rejected so a malformed request cannot sign out the whole user base
+func (s *authService) RevokeSessions(adminID uuid.UUID, filter models.SessionFilter, reason string, dryRun bool) (*models.AdminRevokeSessionsResponse, error) {
+	if filter.IsEmpty() {
+		return nil, errors.New("at least one session filter is required")
+	}
+	filter.IncludeRevoked = false
+
+	filter, err := s.storedSessionFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	matched, err := s.sessionRepo.CountSessions(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.AdminRevokeSessionsResponse{Matched: matched, DryRun: dryRun}
+	if dryRun || matched == 0 {
+		return resp, nil
+	}
+
+	// Access tokens stay blacklisted until they would have expired anyway
+	resp.Revoked, err = s.sessionRepo.RevokeMatchingSessions(filter, 15*time.Minute)
+	log.Printf("🚨 Admin %s revoked %d sessions (reason: %s)", adminID, resp.Revoked, reason)
+	if err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// ErrIPRangeUnavailable is returned for CIDR session filters when only IP hashes are stored
+var ErrIPRangeUnavailable = errors.New("IP range filters are unavailable when IPs are stored as hashes; filter by a single address")
+
+// storedSessionFilter rewrites a session IP filter to match how addresses were stored
+// Truncated storage matches a single address by its network; hashed storage matches it by HMAC
+func (s *authService) storedSessionFilter(filter models.SessionFilter) (models.SessionFilter, error) {
+	if filter.IPRange == "" {
+		return filter, nil
+	}
+
+	ip := net.ParseIP(filter.IPRange)
+	switch s.ipPrivacy.Mode() {
+	case config.IPStorageHMAC:
+		if ip == nil {
+			return filter, ErrIPRangeUnavailable
+		}
+		filter.IPHash = s.ipPrivacy.Hash(filter.IPRange)
+		filter.IPRange = ""
+	case config.IPStorageTruncate:
+		if ip != nil {
+			filter.IPRange = privacy.Truncate(ip).String()
+		}
+	}
+	return filter, nil
+}
+
+// consumeOneTimeToken claims a single-use token and enforces the configured device binding
+// Replays and binding mismatches are recorded on the owner's activity log as security alerts
+func (s *authService) consumeOneTimeToken(purpose, token string, client models.ClientInfo) (*repositories.OneTimeToken, error) {
+	record, err := s.tokenRepo.Consume(purpose, s.jwtService.HashToken(token))
+	if errors.Is(err, repositories.ErrOneTimeTokenReplayed) {
+		s.alertTokenMisuse(record, "security.token_replayed", "A used "+purpose+" token was presented again", client)
+		return nil, errors.New("invalid or expired token")
+	}
+	if err != nil {
+		if errors.Is(err, repositories.ErrOneTimeTokenNotFound) {
+			return nil, errors.New("invalid or expired token")
+		}
+		return nil, err
+	}
+
+	if !s.deviceMatches(record, client.IPAddress, client.UserAgent) {
+		// The token is already burned, so a stolen link cannot be retried from the right device
+		s.alertTokenMisuse(record, "security.token_device_mismatch", "A "+purpose+" token was used from a different device", client)
+		return nil, errors.New("token was issued to a different device")
+	}
+
+	return record, nil
+}
+
+// deviceMatches applies the configured token binding mode
+func (s *authService) deviceMatches(record *repositories.OneTimeToken, ipAddress, userAgent string) bool {
+	if record.IPHash == "" && record.UserAgentHash == "" {
+		return true // Unbound token, e.g. an invitation issued by an admin
+	}
+
+	ipMatches := record.IPHash == hashDeviceAttribute(ipAddress)
+	userAgentMatches := record.UserAgentHash == hashDeviceAttribute(userAgent)
+
+	switch s.security.TokenBinding {
+	case config.TokenBindingNone:
+		return true
+	case config.TokenBindingIP:
+		return ipMatches
+	case config.TokenBindingStrict:
+		return ipMatches && userAgentMatches
+	default:
+		return userAgentMatches
+	}
+}
+
+// alertTokenMisuse logs a security alert and records it in the user's activity feed
+func (s *authService) alertTokenMisuse(record *repositories.OneTimeToken, action, description string, client models.ClientInfo) {
+	log.Printf("🚨 %s for user %s from %s (request_id=%s)", description, record.UserID, client.IPAddress, client.RequestID)
+
+	activity := &models.UserActivity{
+		ID:          models.NewID(),
+		UserID:      record.UserID,
+		Action:      action,
+		Description: description,
+		IPAddress:   s.ipPrivacy.Address(client.IPAddress),
+		IPHash:      s.ipPrivacy.Hash(client.IPAddress),
+		UserAgent:   client.UserAgent,
+		RequestID:   client.RequestID,
+		Metadata:    fmt.Sprintf(`{"purpose":%q,"issued_at":%q}`, record.Purpose, record.IssuedAt.Format(time.RFC3339)),
+		CreatedAt:   time.Now(),
+	}
+	if err := s.userRepo.CreateUserActivity(activity); err != nil {
+		log.Printf("Failed to record security alert for user %s: %v", record.UserID, err)
+	}
 }
 
 // Helper functions
 func (s *authService) hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return s.passwordHasher.Hash(password)
 }
 
 func (s *authService) verifyPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	ok, _ := s.passwordHasher.Verify(password, hash)
+	return ok
+}
+
+// rehashPassword replaces a stored hash made with another algorithm or other cost parameters, after the
+// password verified against it. Failures are logged; the old hash keeps working until the next login
+func (s *authService) rehashPassword(user *models.User, password string) {
+	hash, err := s.hashPassword(password)
+	if err == nil {
+		err = s.userRepo.UpdatePasswordHash(user.ID, user.PasswordHash, hash)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to rehash the password of user %s: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
+// hashDeviceAttribute hashes an IP address or user agent so raw values are never stored with tokens
+func hashDeviceAttribute(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
 }
 
 func generateRandomToken(length int) (string, error) {
@@ -498,6 +1297,9 @@ func (s *authService) UpdateUserPreferences(userID uuid.UUID, req *UpdatePrefere
 		prefs.PushNotifications = *req.PushNotifications
 	}
 	if req.TwoFactorEnabled != nil {
+		if prefs.TwoFactorEnabled && !*req.TwoFactorEnabled && s.twoFactor.RequireApprovalToDisable {
+			return nil, ErrTwoFactorDisableNeedsApproval
+		}
 		prefs.TwoFactorEnabled = *req.TwoFactorEnabled
 	}
 	if req.Theme != "" {
@@ -540,7 +1342,7 @@ func (s *authService) CreateUserPreferences(userID uuid.UUID, req *models.Create
 	
 	// Create new preferences with default values, then override with request values
 	prefs := &models.UserPreference{
-		ID:                 uuid.New(),
+		ID:                 models.NewID(),
 		UserID:             userID,
 		EmailNotifications: true,  // Default value
 		PushNotifications:  true,  // Default value
@@ -596,7 +1398,7 @@ func (s *authService) LogUserActivity(userID uuid.UUID, action, description stri
 	
 	// Create user activity record
 	activity := &models.UserActivity{
-		ID:          uuid.New(),
+		ID:          models.NewID(),
 		UserID:      userID,
 		Action:      action,
 		Description: description,
@@ -610,7 +1412,27 @@ func (s *authService) LogUserActivity(userID uuid.UUID, action, description stri
 
 func (s *authService) GetUserActivities(userID uuid.UUID, limit, offset int) ([]models.UserActivity, error) {
 	// Get user activities from repository
-	return s.userRepo.GetUserActivities(userID, limit, offset)
+	activities, err := s.userRepo.GetUserActivities(userID, limit, offset)
+	if err != nil || s.activityArchive == nil {
+		return activities, err
+	}
+
+	// A short page reached the end of user_activities; archived activities are all older, so they follow
+	limit = repositories.NormalizeActivityLimit(limit)
+	if len(activities) >= limit {
+		return activities, nil
+	}
+	hot := int64(offset + len(activities))
+	if len(activities) == 0 {
+		if hot, err = s.userRepo.CountUserActivities(userID); err != nil {
+			return nil, err
+		}
+	}
+	archived, err := s.archivedActivities(userID, limit-len(activities), max(0, offset-int(hot)))
+	if err != nil {
+		return nil, err
+	}
+	return append(activities, archived...), nil
 }
 
 func (s *authService) GetUserNotifications(userID uuid.UUID) ([]models.UserNotification, error) {
@@ -626,7 +1448,7 @@ func (s *authService) MarkNotificationAsRead(userID, notificationID uuid.UUID) e
 func (s *authService) CreateNotification(userID uuid.UUID, req *CreateNotificationRequest) error {
 	// Create notification record
 	notification := &models.UserNotification{
-		ID:        uuid.New(),
+		ID:        models.NewID(),
 		UserID:    userID,
 		Type:      req.Type,
 		Title:     req.Title,
@@ -648,5 +1470,9 @@ func (s *authService) CreateNotification(userID uuid.UUID, req *CreateNotificati
 	}
 	
 	// Save to repository
-	return s.userRepo.CreateUserNotification(notification)
+	if err := s.userRepo.CreateUserNotification(notification); err != nil {
+		return err
+	}
+	s.pushNotification(notification)
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// ErrProfileNotFound means the user behind the token no longer exists
+var ErrProfileNotFound = errors.New("user not found")
+
+// GetBootstrap reads the profile, preferences and unread notification count concurrently
+// Concurrent calls for the same user (a dashboard's widgets loading at once) share one set of reads
+func (s *authService) GetBootstrap(userID uuid.UUID) (*models.BootstrapResponse, error) {
+	return s.bootstraps.do(userID.String(), func() (*models.BootstrapResponse, error) {
+		var (
+			wg                                    sync.WaitGroup
+			resp                                  models.BootstrapResponse
+			profileErr, preferencesErr, unreadErr error
+		)
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			resp.Profile, profileErr = s.GetProfile(userID)
+		}()
+		go func() {
+			defer wg.Done()
+			resp.Preferences, preferencesErr = s.userRepo.GetUserPreferences(userID)
+			if errors.Is(preferencesErr, repositories.ErrUserPreferencesNotFound) {
+				preferencesErr = nil
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			resp.UnreadNotifications, unreadErr = s.userRepo.CountUnreadNotifications(userID)
+		}()
+		wg.Wait()
+
+		if profileErr != nil {
+			return nil, ErrProfileNotFound
+		}
+		if preferencesErr != nil {
+			return nil, fmt.Errorf("failed to load preferences: %w", preferencesErr)
+		}
+		if unreadErr != nil {
+			return nil, fmt.Errorf("failed to count unread notifications: %w", unreadErr)
+		}
+		return &resp, nil
+	})
+}
+
+// flightGroup coalesces concurrent calls with the same key: the first caller runs fn and the others
+// wait for and share its result. Nothing is kept once the call returns, so results are never stale
+type flightGroup[T any] struct {
+	mu      sync.Mutex
+	flights map[string]*flight[T]
+}
+
+// errFlightAborted is returned to the callers waiting on a call that panicked
+var errFlightAborted = errors.New("coalesced call aborted")
+
+type flight[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func (g *flightGroup[T]) do(key string, fn func() (T, error)) (T, error) {
+	g.mu.Lock()
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		<-f.done
+		return f.value, f.err
+	}
+	if g.flights == nil {
+		g.flights = make(map[string]*flight[T])
+	}
+	f := &flight[T]{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	completed := false
+	defer func() {
+		if !completed {
+			f.err = errFlightAborted
+		}
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.value, f.err = fn()
+	completed = true
+	return f.value, f.err
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+)
+
+// HintBreachedPassword is the validation hint code of passwords found in known breaches in warn mode
+const HintBreachedPassword = "breached_password"
+
+// screenBreachedPassword looks a new password up in known breaches. In block mode a breached password
+// is a *PasswordPolicyError; in warn mode it is accepted and the returned hint, for field, tells the
+// user to pick another. Lookup failures are logged and the password accepted
+func (s *authService) screenBreachedPassword(password, field string) ([]models.ValidationHint, error) {
+	mode := s.breachedPasswords.Mode()
+	if mode == config.BreachedPasswordsOff {
+		return nil, nil
+	}
+
+	count, err := s.breachedPasswords.Count(context.Background(), password)
+	if err != nil {
+		log.Printf("⚠️  Breached password screening skipped: %v", err)
+		return nil, nil
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	message := fmt.Sprintf("has appeared %d times in known data breaches", count)
+	if mode == config.BreachedPasswordsBlock {
+		return nil, &PasswordPolicyError{Violations: []PasswordViolation{{Rule: PasswordRuleBreached, Message: message}}}
+	}
+	return []models.ValidationHint{{
+		Field:   field,
+		Code:    HintBreachedPassword,
+		Message: "this password " + message + "; consider changing it",
+	}}, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+)
+
+// ClaimsEnricher adds deployment-specific claims (plan tier, feature flags, org ID) to access tokens
+// Register implementations with container.WithClaimsEnricher instead of patching JWTService.
+// Only claim names listed in [jwt.custom_claims] allowed are kept.
+type ClaimsEnricher interface {
+	EnrichClaims(user *models.User) (map[string]interface{}, error)
+}
+
+// ClaimsEnricherFunc adapts a function to ClaimsEnricher
+type ClaimsEnricherFunc func(user *models.User) (map[string]interface{}, error)
+
+func (f ClaimsEnricherFunc) EnrichClaims(user *models.User) (map[string]interface{}, error) {
+	return f(user)
+}
+
+// customClaims collects the allowlisted claims of every enricher for user
+// Enrichment never blocks token issuance: a failing enricher is skipped, and when the claims together
+// exceed the size limit none are added
+func customClaims(cfg config.CustomClaimsConfig, enrichers []ClaimsEnricher, user *models.User) map[string]interface{} {
+	if len(enrichers) == 0 || len(cfg.Allowed) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(cfg.Allowed))
+	for _, name := range cfg.Allowed {
+		allowed[name] = true
+	}
+
+	claims := make(map[string]interface{})
+	for _, enricher := range enrichers {
+		extra, err := enricher.EnrichClaims(user)
+		if err != nil {
+			log.Printf("⚠️  Claims enricher failed for user %s, skipping its claims: %v", user.ID, err)
+			continue
+		}
+		for name, value := range extra {
+			if !allowed[name] || config.IsReservedClaim(name) {
+				log.Printf("⚠️  Dropping custom claim %q: not in jwt.custom_claims.allowed", name)
+				continue
+			}
+			claims[name] = value
+		}
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(claims)
+	if err != nil {
+		log.Printf("⚠️  Dropping custom claims for user %s: %v", user.ID, err)
+		return nil
+	}
+	if len(encoded) > cfg.MaxBytes {
+		log.Printf("⚠️  Dropping custom claims for user %s: %d bytes exceeds jwt.custom_claims.max_bytes (%d)", user.ID, len(encoded), cfg.MaxBytes)
+		return nil
+	}
+	return claims
+}
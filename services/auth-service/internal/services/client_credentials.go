@@ -0,0 +1,280 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// Client-credentials errors; handlers map them to statuses and OAuth2 error codes with errors.Is
+var (
+	ErrClientCredentialsDisabled = errors.New("the client credentials grant is not enabled")
+	ErrUnsupportedGrantType      = errors.New("only the client_credentials grant type is supported")
+	ErrInvalidClient             = errors.New("client authentication failed")
+	ErrInvalidScope              = errors.New("a requested scope is not granted to the client")
+	ErrOAuthClientExists         = errors.New("a client with this client_id already exists")
+	ErrOAuthClientID             = errors.New("client_id must be 3-64 lowercase letters, digits and hyphens")
+	ErrOAuthClientScope          = errors.New("scopes must be listed in client_credentials.scopes")
+)
+
+// GrantTypeClientCredentials is the only grant_type POST /oauth2/token accepts
+const GrantTypeClientCredentials = "client_credentials"
+
+// clientSecretBytes is the entropy of a client secret, before hex encoding
+const clientSecretBytes = 32
+
+// clientSecretPrefixLength is how much of a secret is kept to recognize it in listings
+const clientSecretPrefixLength = 8
+
+// oauthClientUseInterval limits how often last_used_at is written for a busy client
+const oauthClientUseInterval = time.Minute
+
+// CreateOAuthClient registers a service for the client-credentials grant
+// The secret is returned once; only its hash is stored
+func (s *authService) CreateOAuthClient(adminID uuid.UUID, req *models.AdminCreateOAuthClientRequest) (*models.AdminOAuthClientResponse, error) {
+	if !s.clientCredentials.Enabled {
+		return nil, ErrClientCredentialsDisabled
+	}
+	if !config.OAuthClientID.MatchString(req.ClientID) {
+		return nil, ErrOAuthClientID
+	}
+	scopes, err := s.grantableScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetOAuthClient(req.ClientID); err == nil {
+		return nil, ErrOAuthClientExists
+	} else if !errors.Is(err, repositories.ErrOAuthClientNotFound) {
+		return nil, err
+	}
+
+	secret, err := generateRandomToken(clientSecretBytes)
+	if err != nil {
+		return nil, err
+	}
+	client := &models.OAuthClient{
+		ClientID:     req.ClientID,
+		Name:         strings.TrimSpace(req.Name),
+		SecretHash:   s.jwtService.HashToken(secret),
+		SecretPrefix: secret[:clientSecretPrefixLength],
+		Scopes:       strings.Join(scopes, " "),
+		CreatedBy:    &adminID,
+	}
+	if err := s.userRepo.CreateOAuthClient(client); err != nil {
+		return nil, err
+	}
+
+	s.recordOAuthClientChange(adminID, "oauth_client_created", "registered", client)
+	return &models.AdminOAuthClientResponse{Client: oauthClientInfo(client), ClientSecret: secret}, nil
+}
+
+// ListOAuthClients returns the registered clients ordered by client_id
+func (s *authService) ListOAuthClients() ([]models.OAuthClientInfo, error) {
+	clients, err := s.userRepo.ListOAuthClients()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]models.OAuthClientInfo, 0, len(clients))
+	for i := range clients {
+		infos = append(infos, oauthClientInfo(&clients[i]))
+	}
+	return infos, nil
+}
+
+// GetOAuthClient returns one registered client
+func (s *authService) GetOAuthClient(clientID string) (*models.OAuthClientInfo, error) {
+	client, err := s.userRepo.GetOAuthClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+	info := oauthClientInfo(client)
+	return &info, nil
+}
+
+// UpdateOAuthClient renames a client or replaces its scopes; tokens already issued keep their scopes
+// until they expire
+func (s *authService) UpdateOAuthClient(adminID uuid.UUID, clientID string, req *models.AdminUpdateOAuthClientRequest) (*models.OAuthClientInfo, error) {
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Scopes != nil {
+		scopes, err := s.grantableScopes(req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		updates["scopes"] = strings.Join(scopes, " ")
+	}
+	if len(updates) == 0 {
+		return s.GetOAuthClient(clientID)
+	}
+
+	client, err := s.userRepo.UpdateOAuthClient(clientID, updates)
+	if err != nil {
+		return nil, err
+	}
+	s.recordOAuthClientChange(adminID, "oauth_client_updated", "updated", client)
+	info := oauthClientInfo(client)
+	return &info, nil
+}
+
+// RotateOAuthClientSecret replaces a client's secret; the old one stops working immediately
+func (s *authService) RotateOAuthClientSecret(adminID uuid.UUID, clientID string) (*models.AdminOAuthClientResponse, error) {
+	secret, err := generateRandomToken(clientSecretBytes)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.userRepo.UpdateOAuthClient(clientID, map[string]interface{}{
+		"secret_hash":   s.jwtService.HashToken(secret),
+		"secret_prefix": secret[:clientSecretPrefixLength],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordOAuthClientChange(adminID, "oauth_client_secret_rotated", "secret rotated", client)
+	return &models.AdminOAuthClientResponse{Client: oauthClientInfo(client), ClientSecret: secret}, nil
+}
+
+// DeleteOAuthClient removes a client: it gets no new tokens and /api/v1/verify rejects the ones it holds
+func (s *authService) DeleteOAuthClient(adminID uuid.UUID, clientID string) error {
+	client, err := s.userRepo.DeleteOAuthClient(clientID)
+	if err != nil {
+		return err
+	}
+	s.recordOAuthClientChange(adminID, "oauth_client_deleted", "deleted", client)
+	return nil
+}
+
+// IssueClientCredentialsToken authenticates a client by its secret and issues a short-lived token with
+// the requested scopes, or all of the client's scopes when none are requested
+func (s *authService) IssueClientCredentialsToken(req *models.ClientCredentialsTokenRequest, client models.ClientInfo) (*models.ClientCredentialsTokenResponse, error) {
+	if !s.clientCredentials.Enabled {
+		return nil, ErrClientCredentialsDisabled
+	}
+	if req.GrantType != GrantTypeClientCredentials {
+		return nil, ErrUnsupportedGrantType
+	}
+
+	// Hashed before the lookup so an unknown client_id takes as long as a wrong secret
+	secretHash := s.jwtService.HashToken(req.ClientSecret)
+	registered, err := s.userRepo.GetOAuthClient(req.ClientID)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrOAuthClientNotFound) {
+			return nil, err
+		}
+		log.Printf("⚠️  Token request for unknown client %q from %s (request_id=%s)", req.ClientID, client.IPAddress, client.RequestID)
+		return nil, ErrInvalidClient
+	}
+	if req.ClientSecret == "" || subtle.ConstantTimeCompare([]byte(secretHash), []byte(registered.SecretHash)) != 1 {
+		log.Printf("⚠️  Wrong secret for client %s from %s (request_id=%s)", registered.ClientID, client.IPAddress, client.RequestID)
+		return nil, ErrInvalidClient
+	}
+
+	granted := s.clientScopes(registered)
+	scopes := granted
+	if requested := strings.Fields(req.Scope); len(requested) > 0 {
+		scopes = make([]string, 0, len(requested))
+		for _, scope := range requested {
+			if !slices.Contains(granted, scope) {
+				return nil, ErrInvalidScope
+			}
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+
+	token, err := s.jwtService.GenerateClientToken(registered.ClientID, scopes, s.clientCredentials.TokenLifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skipped while read-only; the update would fail against a replica
+	if !s.maintenance.ReadOnly() {
+		if err := s.userRepo.RecordOAuthClientUse(registered.ID, time.Now(), oauthClientUseInterval); err != nil {
+			log.Printf("⚠️  Failed to record use of client %s: %v", registered.ClientID, err)
+		}
+	}
+
+	return &models.ClientCredentialsTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.clientCredentials.TokenLifetime.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// verifyClientToken verifies a client token for /api/v1/verify; the client must still be registered
+func (s *authService) verifyClientToken(token string) (*models.VerifyTokenResponse, bool) {
+	claims, err := s.jwtService.ValidateClientToken(token)
+	if err != nil {
+		return nil, false
+	}
+	if _, err := s.userRepo.GetOAuthClient(claims.ClientID); err != nil {
+		return &models.VerifyTokenResponse{Valid: false}, true
+	}
+	return &models.VerifyTokenResponse{
+		Valid:    true,
+		ClientID: claims.ClientID,
+		Scopes:   claims.Scopes,
+	}, true
+}
+
+// grantableScopes checks requested scopes against client_credentials.scopes and returns them without
+// duplicates, in configuration order
+func (s *authService) grantableScopes(requested []string) ([]string, error) {
+	for _, scope := range requested {
+		if !slices.Contains(s.clientCredentials.Scopes, scope) {
+			return nil, ErrOAuthClientScope
+		}
+	}
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range s.clientCredentials.Scopes {
+		if slices.Contains(requested, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// clientScopes returns the client's scopes that are still in client_credentials.scopes, so removing a
+// scope from the configuration withdraws it from every client
+func (s *authService) clientScopes(client *models.OAuthClient) []string {
+	var scopes []string
+	for _, scope := range client.ScopeList() {
+		if slices.Contains(s.clientCredentials.Scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// recordOAuthClientChange logs a client registry change and adds it to the admin's activity feed
+func (s *authService) recordOAuthClientChange(adminID uuid.UUID, action, change string, client *models.OAuthClient) {
+	log.Printf("📝 Admin %s: OAuth client %s (%s) %s", adminID, client.ClientID, client.Name, change)
+	if err := s.LogUserActivity(adminID, action, "OAuth client "+client.ClientID+" "+change, map[string]interface{}{
+		"client_id":     client.ClientID,
+		"secret_prefix": client.SecretPrefix,
+		"scopes":        client.ScopeList(),
+	}); err != nil {
+		log.Printf("⚠️  Failed to record %s for admin %s: %v", action, adminID, err)
+	}
+}
+
+func oauthClientInfo(client *models.OAuthClient) models.OAuthClientInfo {
+	return models.OAuthClientInfo{OAuthClient: *client, Scopes: client.ScopeList()}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"auth-service/internal/models"
+)
+
+// ErrEmailDomainRefused matches every *EmailDomainError with errors.Is
+var ErrEmailDomainRefused = errors.New("email address was refused")
+
+// Validation hint codes for registering addresses
+const (
+	HintDisposableEmailDomain = "disposable_email_domain"
+	HintEmailDomainTypo       = "email_domain_typo"
+)
+
+// EmailDomainError refuses a registering address, with hints telling the client why
+type EmailDomainError struct {
+	Hints []models.ValidationHint
+}
+
+func (e *EmailDomainError) Error() string {
+	messages := make([]string, 0, len(e.Hints))
+	for _, hint := range e.Hints {
+		messages = append(messages, hint.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is makes errors.Is(err, ErrEmailDomainRefused) hold for email domain errors
+func (e *EmailDomainError) Is(target error) bool {
+	return target == ErrEmailDomainRefused
+}
+
+// checkEmailDomain refuses addresses at disposable mailbox services and, until the client confirms the
+// address as typed, likely typos of a popular provider
+func (s *authService) checkEmailDomain(email string, ignoreSuggestion bool) error {
+	result := s.emailDomains.Check(email)
+	switch {
+	case result.Disposable:
+		return &EmailDomainError{Hints: []models.ValidationHint{{
+			Field:   "email",
+			Code:    HintDisposableEmailDomain,
+			Message: "disposable email addresses can't be used to register",
+		}}}
+	case result.Suggestion != "" && !ignoreSuggestion:
+		return &EmailDomainError{Hints: []models.ValidationHint{{
+			Field:      "email",
+			Code:       HintEmailDomainTypo,
+			Message:    "did you mean " + result.Suggestion + "? Resubmit with ignore_email_suggestion to keep the address as typed",
+			Suggestion: result.Suggestion,
+		}}}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"auth-service/internal/feeds"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// Feed errors; handlers map them to statuses with errors.Is
+var (
+	ErrInvalidFeedToken = errors.New("invalid feed token")
+	ErrUnknownFeed      = errors.New("unknown feed")
+)
+
+const (
+	// feedTokenBytes is the entropy of a feed token; it is hex encoded in URLs
+	feedTokenBytes = 32
+	// feedItemLimit caps the entries of a feed; readers poll, so older entries were already seen
+	feedItemLimit = 50
+	// feedPathPrefix is where the feed routes are served
+	feedPathPrefix = "/api/v1/auth/feeds/"
+)
+
+// feedFiles lists the feed files served per token, in the order they are reported to the user
+var feedFiles = []string{"activities.rss", "activities.atom", "notifications.rss", "notifications.atom", "security.ics"}
+
+// CreateFeedToken issues a personal feed token, replacing any previous one
+func (s *authService) CreateFeedToken(userID uuid.UUID) (*models.FeedTokenResponse, error) {
+	token, err := generateRandomToken(feedTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := s.jwtService.HashToken(token)
+	if err := s.userRepo.SetFeedTokenHash(userID, &hash); err != nil {
+		return nil, err
+	}
+	log.Printf("📄 Feed token issued for user %s", userID)
+
+	response := &models.FeedTokenResponse{Token: token, Feeds: make(map[string]string, len(feedFiles))}
+	for _, file := range feedFiles {
+		response.Feeds[file] = feedPathPrefix + token + "/" + file
+	}
+	return response, nil
+}
+
+// RevokeFeedToken invalidates the user's feed URLs
+func (s *authService) RevokeFeedToken(userID uuid.UUID) error {
+	if err := s.userRepo.SetFeedTokenHash(userID, nil); err != nil {
+		return err
+	}
+	log.Printf("📄 Feed token revoked for user %s", userID)
+	return nil
+}
+
+// GetFeed resolves a feed token and builds the requested feed of its owner
+// Security events are the activities recorded for token misuse and security setting changes
+func (s *authService) GetFeed(token, kind string) (*feeds.Feed, error) {
+	if len(token) != 2*feedTokenBytes {
+		return nil, ErrInvalidFeedToken
+	}
+
+	user, err := s.userRepo.GetByFeedTokenHash(s.jwtService.HashToken(token))
+	if err != nil {
+		if errors.Is(err, repositories.ErrFeedTokenNotFound) {
+			return nil, ErrInvalidFeedToken
+		}
+		return nil, err
+	}
+
+	feed := &feeds.Feed{
+		ID:   fmt.Sprintf("urn:uuid:%s:%s", user.ID, kind),
+		Link: s.linkBaseURL,
+	}
+
+	switch kind {
+	case feeds.KindActivities, feeds.KindSecurity:
+		activities, err := s.userRepo.GetUserActivities(user.ID, repositories.MaxActivityLimit, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, activity := range activities {
+			if kind == feeds.KindSecurity && !isSecurityActivity(activity.Action) {
+				continue
+			}
+			feed.Items = append(feed.Items, activityFeedItem(activity))
+			if len(feed.Items) == feedItemLimit {
+				break
+			}
+		}
+		if kind == feeds.KindSecurity {
+			feed.Title = "Security events for " + user.Username
+			feed.Description = "Security alerts and security setting changes on your account"
+		} else {
+			feed.Title = "Account activity for " + user.Username
+			feed.Description = "Recent activity on your account"
+		}
+	case feeds.KindNotifications:
+		notifications, err := s.userRepo.GetUserNotifications(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, notification := range notifications {
+			feed.Items = append(feed.Items, feeds.Item{
+				ID:        "urn:uuid:" + notification.ID.String(),
+				Title:     notification.Title,
+				Summary:   notification.Message,
+				Link:      notification.ActionURL,
+				Category:  notification.Type,
+				Published: notification.CreatedAt,
+			})
+			if len(feed.Items) == feedItemLimit {
+				break
+			}
+		}
+		feed.Title = "Notifications for " + user.Username
+		feed.Description = "Notifications sent to your account"
+	default:
+		return nil, ErrUnknownFeed
+	}
+
+	feed.Updated = time.Now()
+	if len(feed.Items) > 0 {
+		feed.Updated = feed.Items[0].Published
+	}
+	return feed, nil
+}
+
+// isSecurityActivity reports whether an activity belongs in the security events feed
+func isSecurityActivity(action string) bool {
+	return strings.HasPrefix(action, "security.") || action == "two_factor_disabled"
+}
+
+func activityFeedItem(activity models.UserActivity) feeds.Item {
+	title := activity.Description
+	if title == "" {
+		title = activity.Action
+	}
+	return feeds.Item{
+		ID:        "urn:uuid:" + activity.ID.String(),
+		Title:     title,
+		Summary:   activity.Description,
+		Category:  activity.Action,
+		Published: activity.CreatedAt,
+	}
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// Honeypot errors; handlers map them to statuses with errors.Is
+var (
+	ErrHoneypotEmailInUse = errors.New("a real account already uses this email")
+	ErrHoneypotExists     = errors.New("a honeypot with this email already exists")
+)
+
+// canaryKeyBytes is the entropy of a generated canary key, before hex encoding
+const canaryKeyBytes = 24
+
+// HoneypotAlerts counts honeypot triggers by kind so alerting can page on any increase
+type HoneypotAlerts struct {
+	mu       sync.Mutex
+	triggers map[string]uint64
+}
+
+// NewHoneypotAlerts creates a trigger counter for every honeypot kind
+func NewHoneypotAlerts() *HoneypotAlerts {
+	return &HoneypotAlerts{triggers: map[string]uint64{models.HoneypotAccount: 0, models.HoneypotAPIKey: 0}}
+}
+
+// record counts one trigger; a nil counter records nothing
+func (a *HoneypotAlerts) record(kind string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.triggers[kind]++
+}
+
+// WritePrometheus writes the trigger counts in the Prometheus text format
+func (a *HoneypotAlerts) WritePrometheus(w io.Writer) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP auth_service_honeypot_triggers_total Uses of honeypot accounts and canary API keys; any increase is an incident")
+	fmt.Fprintln(w, "# TYPE auth_service_honeypot_triggers_total counter")
+	for _, kind := range []string{models.HoneypotAccount, models.HoneypotAPIKey} {
+		fmt.Fprintf(w, "auth_service_honeypot_triggers_total{kind=%q} %d\n", kind, a.triggers[kind])
+	}
+}
+
+// CreateHoneypot plants a honeypot account or generates a canary API key
+// The canary key is returned once; only its hash is stored
+func (s *authService) CreateHoneypot(adminID uuid.UUID, req *models.AdminCreateHoneypotRequest) (*models.AdminHoneypotResponse, error) {
+	honeypot := &models.Honeypot{
+		Kind:      req.Kind,
+		Label:     req.Label,
+		CreatedBy: &adminID,
+	}
+	resp := &models.AdminHoneypotResponse{}
+
+	switch req.Kind {
+	case models.HoneypotAccount:
+		email := strings.ToLower(req.Email)
+		taken, err := s.userRepo.IsEmailTaken(email)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrHoneypotEmailInUse
+		}
+		existing, err := s.userRepo.ListHoneypots(true)
+		if err != nil {
+			return nil, err
+		}
+		for i := range existing {
+			if existing[i].Kind == models.HoneypotAccount && existing[i].Identifier == email {
+				return nil, ErrHoneypotExists
+			}
+		}
+		honeypot.Identifier = email
+	case models.HoneypotAPIKey:
+		secret, err := generateRandomToken(canaryKeyBytes)
+		if err != nil {
+			return nil, err
+		}
+		resp.Key = s.honeypot.KeyPrefix + secret
+		prefix := resp.Key[:len(s.honeypot.KeyPrefix)+4]
+		honeypot.Identifier = s.jwtService.HashToken(resp.Key)
+		honeypot.KeyPrefix = &prefix
+	default:
+		return nil, fmt.Errorf("unknown honeypot kind %q", req.Kind)
+	}
+
+	if err := s.userRepo.CreateHoneypot(honeypot); err != nil {
+		return nil, err
+	}
+	resp.Honeypot = *honeypot
+
+	log.Printf("📝 Admin %s created %s honeypot %s (%s)", adminID, honeypot.Kind, honeypot.ID, honeypot.Label)
+	if err := s.LogUserActivity(adminID, "honeypot_created", "Honeypot "+honeypot.Kind+" created", map[string]interface{}{
+		"honeypot_id": honeypot.ID.String(),
+		"label":       honeypot.Label,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record honeypot creation for admin %s: %v", adminID, err)
+	}
+	return resp, nil
+}
+
+// ListHoneypots returns the honeypots, most recently triggered first
+func (s *authService) ListHoneypots(includeDisabled bool) ([]models.Honeypot, error) {
+	honeypots, err := s.userRepo.ListHoneypots(includeDisabled)
+	if err != nil {
+		return nil, err
+	}
+	if honeypots == nil {
+		honeypots = []models.Honeypot{}
+	}
+	return honeypots, nil
+}
+
+// DisableHoneypot stops a honeypot from alerting, e.g. once the planted credential is removed
+func (s *authService) DisableHoneypot(adminID, honeypotID uuid.UUID) (*models.Honeypot, error) {
+	honeypot, err := s.userRepo.DisableHoneypot(honeypotID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📝 Admin %s disabled %s honeypot %s (%s)", adminID, honeypot.Kind, honeypot.ID, honeypot.Label)
+	if err := s.LogUserActivity(adminID, "honeypot_disabled", "Honeypot "+honeypot.Kind+" disabled", map[string]interface{}{
+		"honeypot_id": honeypot.ID.String(),
+		"label":       honeypot.Label,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record honeypot removal for admin %s: %v", adminID, err)
+	}
+	return honeypot, nil
+}
+
+// ListHoneypotTriggers returns a page of a honeypot's uses, newest first
+func (s *authService) ListHoneypotTriggers(honeypotID uuid.UUID, limit, offset int) (*models.AdminHoneypotTriggerListResponse, error) {
+	triggers, total, err := s.userRepo.ListHoneypotTriggers(honeypotID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if triggers == nil {
+		triggers = []models.HoneypotTrigger{}
+	}
+	return &models.AdminHoneypotTriggerListResponse{Triggers: triggers, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// CheckCanaryKey reports whether key is a canary API key, raising the alert when it is
+// Callers reject the request exactly as they would an unknown key
+func (s *authService) CheckCanaryKey(key string, client models.ClientInfo) bool {
+	if !strings.HasPrefix(key, s.honeypot.KeyPrefix) {
+		return false
+	}
+	return s.tripHoneypot(models.HoneypotAPIKey, s.jwtService.HashToken(key), client)
+}
+
+// IsIPBlocked reports whether the address used a honeypot within honeypot.block_duration
+func (s *authService) IsIPBlocked(ipAddress string) (bool, error) {
+	return s.sessionRepo.IsIPBlocked(hashDeviceAttribute(ipAddress))
+}
+
+// tripHoneypot triggers the active honeypot matching kind and identifier, if any: it records the use,
+// raises a high-severity alert and blocks the caller's address. It reports whether a honeypot matched
+func (s *authService) tripHoneypot(kind, identifier string, client models.ClientInfo) bool {
+	honeypot, err := s.userRepo.TriggerHoneypot(kind, identifier, &models.HoneypotTrigger{
+		IPAddress: s.ipPrivacy.Address(client.IPAddress),
+		IPHash:    s.ipPrivacy.Hash(client.IPAddress),
+		UserAgent: client.UserAgent,
+		RequestID: client.RequestID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		if !errors.Is(err, repositories.ErrHoneypotNotFound) {
+			log.Printf("❌ Failed to check honeypots: %v", err)
+		}
+		return false
+	}
+
+	s.honeypotAlerts.record(kind)
+	s.auditSecurityEvent("honeypot_triggered", nil, client, map[string]interface{}{
+		"honeypot_id":   honeypot.ID.String(),
+		"kind":          honeypot.Kind,
+		"label":         honeypot.Label,
+		"trigger_count": honeypot.TriggerCount,
+	})
+	log.Printf("🚨 [HIGH] Honeypot %s %s (%s) used from %s, trigger #%d (request_id=%s)",
+		honeypot.Kind, honeypot.ID, honeypot.Label, client.IPAddress, honeypot.TriggerCount, client.RequestID)
+
+	if s.honeypot.BlockDuration > 0 {
+		if err := s.sessionRepo.BlockIP(hashDeviceAttribute(client.IPAddress), s.honeypot.BlockDuration); err != nil {
+			log.Printf("❌ Failed to block %s after honeypot use: %v", client.IPAddress, err)
+		}
+	}
+	return true
+}
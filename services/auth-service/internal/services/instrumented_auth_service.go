@@ -0,0 +1,514 @@
+package services
+
+import (
+	"time"
+
+	"auth-service/internal/feeds"
+	"auth-service/internal/instrumentation"
+	"auth-service/internal/models"
+	"auth-service/internal/webauthn"
+
+	"github.com/google/uuid"
+	"shared/middleware"
+)
+
+// instrumentedAuthService decorates an AuthService with per-method latency and error reporting
+// Business logic stays in authService; this type only measures and delegates
+type instrumentedAuthService struct {
+	next     AuthService
+	observer instrumentation.Observer
+}
+
+// NewInstrumentedAuthService wraps AuthService so every call reports latency and errors to observer
+func NewInstrumentedAuthService(next AuthService, observer instrumentation.Observer) AuthService {
+	return &instrumentedAuthService{next: next, observer: observer}
+}
+
+// observe reports a finished call; deferred with the named error result
+func (d *instrumentedAuthService) observe(method string, start time.Time, err *error) {
+	var callErr error
+	if err != nil {
+		callErr = *err
+	}
+	d.observer.ObserveCall("AuthService", method, time.Since(start), callErr)
+}
+
+// observeTraced reports a finished call with the request's trace ID, so its latency can carry an exemplar
+// Used for the calls whose latency operators watch: login, refresh and verify
+func (d *instrumentedAuthService) observeTraced(method string, start time.Time, traceID string, err *error) {
+	var callErr error
+	if err != nil {
+		callErr = *err
+	}
+	instrumentation.ObserveTraced(d.observer, "AuthService", method, time.Since(start), callErr, traceID)
+}
+
+func (d *instrumentedAuthService) Register(req *models.RegisterRequest, client models.ClientInfo) (resp *models.AuthResponse, err error) {
+	defer d.observeTraced("Register", time.Now(), client.TraceID, &err)
+	return d.next.Register(req, client)
+}
+
+func (d *instrumentedAuthService) Login(req *models.LoginRequest, client models.ClientInfo) (resp *models.AuthResponse, err error) {
+	defer d.observeTraced("Login", time.Now(), client.TraceID, &err)
+	return d.next.Login(req, client)
+}
+
+func (d *instrumentedAuthService) RefreshToken(req *models.RefreshTokenRequest, client models.ClientInfo) (resp *models.RefreshResponse, err error) {
+	defer d.observeTraced("RefreshToken", time.Now(), client.TraceID, &err)
+	return d.next.RefreshToken(req, client)
+}
+
+func (d *instrumentedAuthService) VerifyToken(token string, client models.ClientInfo) (resp *models.VerifyTokenResponse, err error) {
+	defer d.observeTraced("VerifyToken", time.Now(), client.TraceID, &err)
+	return d.next.VerifyToken(token, client)
+}
+
+func (d *instrumentedAuthService) VerifyAPIKey(key string, client models.ClientInfo) (resp *models.VerifyTokenResponse, err error) {
+	defer d.observeTraced("VerifyAPIKey", time.Now(), client.TraceID, &err)
+	return d.next.VerifyAPIKey(key, client)
+}
+
+func (d *instrumentedAuthService) Logout(userID uuid.UUID, token string) (err error) {
+	defer d.observe("Logout", time.Now(), &err)
+	return d.next.Logout(userID, token)
+}
+
+func (d *instrumentedAuthService) LogoutAll(userID uuid.UUID, token string) (revoked int64, err error) {
+	defer d.observe("LogoutAll", time.Now(), &err)
+	return d.next.LogoutAll(userID, token)
+}
+
+func (d *instrumentedAuthService) ChangePassword(userID uuid.UUID, req *models.ChangePasswordRequest) (warnings []models.ValidationHint, err error) {
+	defer d.observe("ChangePassword", time.Now(), &err)
+	return d.next.ChangePassword(userID, req)
+}
+
+func (d *instrumentedAuthService) DeleteAccount(userID uuid.UUID) (err error) {
+	defer d.observe("DeleteAccount", time.Now(), &err)
+	return d.next.DeleteAccount(userID)
+}
+
+func (d *instrumentedAuthService) GetProfile(userID uuid.UUID) (info *models.UserInfo, err error) {
+	defer d.observe("GetProfile", time.Now(), &err)
+	return d.next.GetProfile(userID)
+}
+
+func (d *instrumentedAuthService) GetBootstrap(userID uuid.UUID) (resp *models.BootstrapResponse, err error) {
+	defer d.observe("GetBootstrap", time.Now(), &err)
+	return d.next.GetBootstrap(userID)
+}
+
+func (d *instrumentedAuthService) UpdateProfile(userID uuid.UUID, req *models.UpdateProfileRequest) (info *models.UserInfo, err error) {
+	defer d.observe("UpdateProfile", time.Now(), &err)
+	return d.next.UpdateProfile(userID, req)
+}
+
+func (d *instrumentedAuthService) ForgotPassword(req *models.ForgotPasswordRequest, client models.ClientInfo) (err error) {
+	defer d.observe("ForgotPassword", time.Now(), &err)
+	return d.next.ForgotPassword(req, client)
+}
+
+func (d *instrumentedAuthService) ResetPassword(req *models.ResetPasswordRequest, client models.ClientInfo) (err error) {
+	defer d.observe("ResetPassword", time.Now(), &err)
+	return d.next.ResetPassword(req, client)
+}
+
+func (d *instrumentedAuthService) GetUserPreferences(userID uuid.UUID) (prefs *models.UserPreference, err error) {
+	defer d.observe("GetUserPreferences", time.Now(), &err)
+	return d.next.GetUserPreferences(userID)
+}
+
+func (d *instrumentedAuthService) CreateUserPreferences(userID uuid.UUID, req *models.CreatePreferencesRequest) (prefs *models.UserPreference, err error) {
+	defer d.observe("CreateUserPreferences", time.Now(), &err)
+	return d.next.CreateUserPreferences(userID, req)
+}
+
+func (d *instrumentedAuthService) UpdateUserPreferences(userID uuid.UUID, req *UpdatePreferencesRequest) (prefs *models.UserPreference, err error) {
+	defer d.observe("UpdateUserPreferences", time.Now(), &err)
+	return d.next.UpdateUserPreferences(userID, req)
+}
+
+func (d *instrumentedAuthService) LogUserActivity(userID uuid.UUID, action, description string, metadata map[string]interface{}) (err error) {
+	defer d.observe("LogUserActivity", time.Now(), &err)
+	return d.next.LogUserActivity(userID, action, description, metadata)
+}
+
+func (d *instrumentedAuthService) GetUserActivities(userID uuid.UUID, limit, offset int) (activities []models.UserActivity, err error) {
+	defer d.observe("GetUserActivities", time.Now(), &err)
+	return d.next.GetUserActivities(userID, limit, offset)
+}
+
+func (d *instrumentedAuthService) GetLoginHistory(userID uuid.UUID, limit, offset int) (resp *models.LoginHistoryResponse, err error) {
+	defer d.observe("GetLoginHistory", time.Now(), &err)
+	return d.next.GetLoginHistory(userID, limit, offset)
+}
+
+func (d *instrumentedAuthService) GetUserNotifications(userID uuid.UUID) (notifications []models.UserNotification, err error) {
+	defer d.observe("GetUserNotifications", time.Now(), &err)
+	return d.next.GetUserNotifications(userID)
+}
+
+func (d *instrumentedAuthService) MarkNotificationAsRead(userID, notificationID uuid.UUID) (err error) {
+	defer d.observe("MarkNotificationAsRead", time.Now(), &err)
+	return d.next.MarkNotificationAsRead(userID, notificationID)
+}
+
+func (d *instrumentedAuthService) CreateNotification(userID uuid.UUID, req *CreateNotificationRequest) (err error) {
+	defer d.observe("CreateNotification", time.Now(), &err)
+	return d.next.CreateNotification(userID, req)
+}
+
+func (d *instrumentedAuthService) ListUserSessions(userID uuid.UUID, accessToken string) (sessions []models.UserSessionInfo, err error) {
+	defer d.observe("ListUserSessions", time.Now(), &err)
+	return d.next.ListUserSessions(userID, accessToken)
+}
+
+func (d *instrumentedAuthService) RevokeUserSession(userID, sessionID uuid.UUID, client models.ClientInfo) (err error) {
+	defer d.observe("RevokeUserSession", time.Now(), &err)
+	return d.next.RevokeUserSession(userID, sessionID, client)
+}
+
+func (d *instrumentedAuthService) RevokeOtherSessions(userID uuid.UUID, accessToken string, client models.ClientInfo) (revoked int64, err error) {
+	defer d.observe("RevokeOtherSessions", time.Now(), &err)
+	return d.next.RevokeOtherSessions(userID, accessToken, client)
+}
+
+func (d *instrumentedAuthService) SearchSessions(filter models.SessionFilter, limit, offset int) (sessions []models.Session, total int64, err error) {
+	defer d.observe("SearchSessions", time.Now(), &err)
+	return d.next.SearchSessions(filter, limit, offset)
+}
+
+func (d *instrumentedAuthService) RevokeSessions(adminID uuid.UUID, filter models.SessionFilter, reason string, dryRun bool) (resp *models.AdminRevokeSessionsResponse, err error) {
+	defer d.observe("RevokeSessions", time.Now(), &err)
+	return d.next.RevokeSessions(adminID, filter, reason, dryRun)
+}
+
+func (d *instrumentedAuthService) TwoFactorCompliance() (report *models.TwoFactorComplianceReport, err error) {
+	defer d.observe("TwoFactorCompliance", time.Now(), &err)
+	return d.next.TwoFactorCompliance()
+}
+
+func (d *instrumentedAuthService) DisableTwoFactor(adminID, userID uuid.UUID, reason string) (err error) {
+	defer d.observe("DisableTwoFactor", time.Now(), &err)
+	return d.next.DisableTwoFactor(adminID, userID, reason)
+}
+
+func (d *instrumentedAuthService) InviteUser(adminID uuid.UUID, req *models.AdminInviteUserRequest) (resp *models.AdminInvitationResponse, err error) {
+	defer d.observe("InviteUser", time.Now(), &err)
+	return d.next.InviteUser(adminID, req)
+}
+
+func (d *instrumentedAuthService) ResendInvitation(adminID, userID uuid.UUID) (resp *models.AdminInvitationResponse, err error) {
+	defer d.observe("ResendInvitation", time.Now(), &err)
+	return d.next.ResendInvitation(adminID, userID)
+}
+
+func (d *instrumentedAuthService) AcceptInvitation(req *models.AcceptInvitationRequest, client models.ClientInfo) (info *models.UserInfo, err error) {
+	defer d.observe("AcceptInvitation", time.Now(), &err)
+	return d.next.AcceptInvitation(req, client)
+}
+
+func (d *instrumentedAuthService) GrantRole(adminID, userID uuid.UUID, req *models.AdminGrantRoleRequest) (grant *models.RoleGrant, err error) {
+	defer d.observe("GrantRole", time.Now(), &err)
+	return d.next.GrantRole(adminID, userID, req)
+}
+
+func (d *instrumentedAuthService) RevokeRoleGrant(adminID, grantID uuid.UUID, reason string) (grant *models.RoleGrant, err error) {
+	defer d.observe("RevokeRoleGrant", time.Now(), &err)
+	return d.next.RevokeRoleGrant(adminID, grantID, reason)
+}
+
+func (d *instrumentedAuthService) ListRoleGrants(userID uuid.UUID, activeOnly bool, limit, offset int) (resp *models.AdminRoleGrantListResponse, err error) {
+	defer d.observe("ListRoleGrants", time.Now(), &err)
+	return d.next.ListRoleGrants(userID, activeOnly, limit, offset)
+}
+
+func (d *instrumentedAuthService) ExpireRoleGrants() (expired int, err error) {
+	defer d.observe("ExpireRoleGrants", time.Now(), &err)
+	return d.next.ExpireRoleGrants()
+}
+
+func (d *instrumentedAuthService) ListRoles() (roles []models.Role, err error) {
+	defer d.observe("ListRoles", time.Now(), &err)
+	return d.next.ListRoles()
+}
+
+func (d *instrumentedAuthService) GetRole(roleID uuid.UUID) (role *models.Role, err error) {
+	defer d.observe("GetRole", time.Now(), &err)
+	return d.next.GetRole(roleID)
+}
+
+func (d *instrumentedAuthService) CreateRole(adminID uuid.UUID, req *models.AdminCreateRoleRequest) (role *models.Role, err error) {
+	defer d.observe("CreateRole", time.Now(), &err)
+	return d.next.CreateRole(adminID, req)
+}
+
+func (d *instrumentedAuthService) UpdateRole(adminID, roleID uuid.UUID, req *models.AdminUpdateRoleRequest) (role *models.Role, err error) {
+	defer d.observe("UpdateRole", time.Now(), &err)
+	return d.next.UpdateRole(adminID, roleID, req)
+}
+
+func (d *instrumentedAuthService) DeleteRole(adminID, roleID uuid.UUID) (err error) {
+	defer d.observe("DeleteRole", time.Now(), &err)
+	return d.next.DeleteRole(adminID, roleID)
+}
+
+func (d *instrumentedAuthService) SetRolePermissions(adminID, roleID uuid.UUID, req *models.AdminSetRolePermissionsRequest) (role *models.Role, err error) {
+	defer d.observe("SetRolePermissions", time.Now(), &err)
+	return d.next.SetRolePermissions(adminID, roleID, req)
+}
+
+func (d *instrumentedAuthService) ListPermissions() (permissions []models.Permission, err error) {
+	defer d.observe("ListPermissions", time.Now(), &err)
+	return d.next.ListPermissions()
+}
+
+func (d *instrumentedAuthService) CreatePermission(adminID uuid.UUID, req *models.AdminCreatePermissionRequest) (permission *models.Permission, err error) {
+	defer d.observe("CreatePermission", time.Now(), &err)
+	return d.next.CreatePermission(adminID, req)
+}
+
+func (d *instrumentedAuthService) DeletePermission(adminID, permissionID uuid.UUID) (err error) {
+	defer d.observe("DeletePermission", time.Now(), &err)
+	return d.next.DeletePermission(adminID, permissionID)
+}
+
+func (d *instrumentedAuthService) GetUserRoles(userID uuid.UUID) (resp *models.AdminUserRolesResponse, err error) {
+	defer d.observe("GetUserRoles", time.Now(), &err)
+	return d.next.GetUserRoles(userID)
+}
+
+func (d *instrumentedAuthService) AssignRole(adminID, userID uuid.UUID, req *models.AdminAssignRoleRequest) (assignment *models.UserRoleAssignment, err error) {
+	defer d.observe("AssignRole", time.Now(), &err)
+	return d.next.AssignRole(adminID, userID, req)
+}
+
+func (d *instrumentedAuthService) UnassignRole(adminID, userID, roleID uuid.UUID) (err error) {
+	defer d.observe("UnassignRole", time.Now(), &err)
+	return d.next.UnassignRole(adminID, userID, roleID)
+}
+
+func (d *instrumentedAuthService) CreateHoneypot(adminID uuid.UUID, req *models.AdminCreateHoneypotRequest) (resp *models.AdminHoneypotResponse, err error) {
+	defer d.observe("CreateHoneypot", time.Now(), &err)
+	return d.next.CreateHoneypot(adminID, req)
+}
+
+func (d *instrumentedAuthService) ListHoneypots(includeDisabled bool) (honeypots []models.Honeypot, err error) {
+	defer d.observe("ListHoneypots", time.Now(), &err)
+	return d.next.ListHoneypots(includeDisabled)
+}
+
+func (d *instrumentedAuthService) DisableHoneypot(adminID, honeypotID uuid.UUID) (honeypot *models.Honeypot, err error) {
+	defer d.observe("DisableHoneypot", time.Now(), &err)
+	return d.next.DisableHoneypot(adminID, honeypotID)
+}
+
+func (d *instrumentedAuthService) ListHoneypotTriggers(honeypotID uuid.UUID, limit, offset int) (resp *models.AdminHoneypotTriggerListResponse, err error) {
+	defer d.observe("ListHoneypotTriggers", time.Now(), &err)
+	return d.next.ListHoneypotTriggers(honeypotID, limit, offset)
+}
+
+func (d *instrumentedAuthService) CheckCanaryKey(key string, client models.ClientInfo) (canary bool) {
+	defer d.observe("CheckCanaryKey", time.Now(), nil)
+	return d.next.CheckCanaryKey(key, client)
+}
+
+func (d *instrumentedAuthService) IsIPBlocked(ipAddress string) (blocked bool, err error) {
+	defer d.observe("IsIPBlocked", time.Now(), &err)
+	return d.next.IsIPBlocked(ipAddress)
+}
+
+func (d *instrumentedAuthService) TokenVersion(userID uuid.UUID) (version int, err error) {
+	defer d.observe("TokenVersion", time.Now(), &err)
+	return d.next.TokenVersion(userID)
+}
+
+func (d *instrumentedAuthService) ListPendingRegistrations(limit, offset int) (resp *models.AdminRegistrationQueueResponse, err error) {
+	defer d.observe("ListPendingRegistrations", time.Now(), &err)
+	return d.next.ListPendingRegistrations(limit, offset)
+}
+
+func (d *instrumentedAuthService) ApproveRegistration(adminID, userID uuid.UUID) (resp *models.AdminRegistrationReviewResponse, err error) {
+	defer d.observe("ApproveRegistration", time.Now(), &err)
+	return d.next.ApproveRegistration(adminID, userID)
+}
+
+func (d *instrumentedAuthService) RejectRegistration(adminID, userID uuid.UUID, reason string) (resp *models.AdminRegistrationReviewResponse, err error) {
+	defer d.observe("RejectRegistration", time.Now(), &err)
+	return d.next.RejectRegistration(adminID, userID, reason)
+}
+
+func (d *instrumentedAuthService) BeginPasskeyRegistration(userID uuid.UUID) (options *webauthn.CreationOptions, err error) {
+	defer d.observe("BeginPasskeyRegistration", time.Now(), &err)
+	return d.next.BeginPasskeyRegistration(userID)
+}
+
+func (d *instrumentedAuthService) FinishPasskeyRegistration(userID uuid.UUID, req *models.PasskeyRegistrationRequest, client models.ClientInfo) (credential *models.WebAuthnCredential, err error) {
+	defer d.observe("FinishPasskeyRegistration", time.Now(), &err)
+	return d.next.FinishPasskeyRegistration(userID, req, client)
+}
+
+func (d *instrumentedAuthService) ListPasskeys(userID uuid.UUID) (credentials []models.WebAuthnCredential, err error) {
+	defer d.observe("ListPasskeys", time.Now(), &err)
+	return d.next.ListPasskeys(userID)
+}
+
+func (d *instrumentedAuthService) RenamePasskey(userID, passkeyID uuid.UUID, name string) (credential *models.WebAuthnCredential, err error) {
+	defer d.observe("RenamePasskey", time.Now(), &err)
+	return d.next.RenamePasskey(userID, passkeyID, name)
+}
+
+func (d *instrumentedAuthService) DeletePasskey(userID, passkeyID uuid.UUID, client models.ClientInfo) (err error) {
+	defer d.observe("DeletePasskey", time.Now(), &err)
+	return d.next.DeletePasskey(userID, passkeyID, client)
+}
+
+func (d *instrumentedAuthService) BeginPasskeyLogin(req *models.PasskeyLoginBeginRequest) (options *webauthn.RequestOptions, err error) {
+	defer d.observe("BeginPasskeyLogin", time.Now(), &err)
+	return d.next.BeginPasskeyLogin(req)
+}
+
+func (d *instrumentedAuthService) FinishPasskeyLogin(req *models.PasskeyLoginRequest, client models.ClientInfo) (response *models.AuthResponse, err error) {
+	defer d.observe("FinishPasskeyLogin", time.Now(), &err)
+	return d.next.FinishPasskeyLogin(req, client)
+}
+
+func (d *instrumentedAuthService) ListPushSubscriptions(userID uuid.UUID) (subscriptions *models.PushSubscriptionsResponse, err error) {
+	defer d.observe("ListPushSubscriptions", time.Now(), &err)
+	return d.next.ListPushSubscriptions(userID)
+}
+
+func (d *instrumentedAuthService) SubscribePush(userID uuid.UUID, req *models.PushSubscriptionRequest, client models.ClientInfo) (subscription *models.PushSubscription, err error) {
+	defer d.observe("SubscribePush", time.Now(), &err)
+	return d.next.SubscribePush(userID, req, client)
+}
+
+func (d *instrumentedAuthService) UnsubscribePush(userID, subscriptionID uuid.UUID) (err error) {
+	defer d.observe("UnsubscribePush", time.Now(), &err)
+	return d.next.UnsubscribePush(userID, subscriptionID)
+}
+
+func (d *instrumentedAuthService) CreatePersonalAccessToken(userID uuid.UUID, req *models.CreatePersonalAccessTokenRequest, client models.ClientInfo) (response *models.PersonalAccessTokenResponse, err error) {
+	defer d.observe("CreatePersonalAccessToken", time.Now(), &err)
+	return d.next.CreatePersonalAccessToken(userID, req, client)
+}
+
+func (d *instrumentedAuthService) ListPersonalAccessTokens(userID uuid.UUID) (tokens []models.PersonalAccessTokenInfo, err error) {
+	defer d.observe("ListPersonalAccessTokens", time.Now(), &err)
+	return d.next.ListPersonalAccessTokens(userID)
+}
+
+func (d *instrumentedAuthService) RevokePersonalAccessToken(userID, tokenID uuid.UUID, client models.ClientInfo) (err error) {
+	defer d.observe("RevokePersonalAccessToken", time.Now(), &err)
+	return d.next.RevokePersonalAccessToken(userID, tokenID, client)
+}
+
+func (d *instrumentedAuthService) ValidatePersonalAccessToken(token string) (claims *middleware.JWTClaims, err error) {
+	defer d.observe("ValidatePersonalAccessToken", time.Now(), &err)
+	return d.next.ValidatePersonalAccessToken(token)
+}
+
+func (d *instrumentedAuthService) OAuthLogin(info *models.OAuth2UserInfo, client models.ClientInfo) (response *models.AuthResponse, err error) {
+	defer d.observe("OAuthLogin", time.Now(), &err)
+	return d.next.OAuthLogin(info, client)
+}
+
+func (d *instrumentedAuthService) IssueOAuthLoginCode(info *models.OAuth2UserInfo, client models.ClientInfo) (code string, err error) {
+	defer d.observe("IssueOAuthLoginCode", time.Now(), &err)
+	return d.next.IssueOAuthLoginCode(info, client)
+}
+
+func (d *instrumentedAuthService) ExchangeOAuthLoginCode(req *models.OAuthCodeExchangeRequest, client models.ClientInfo) (response *models.AuthResponse, err error) {
+	defer d.observe("ExchangeOAuthLoginCode", time.Now(), &err)
+	return d.next.ExchangeOAuthLoginCode(req, client)
+}
+
+func (d *instrumentedAuthService) LinkOAuthAccount(userID uuid.UUID, info *models.OAuth2UserInfo, client models.ClientInfo) (err error) {
+	defer d.observe("LinkOAuthAccount", time.Now(), &err)
+	return d.next.LinkOAuthAccount(userID, info, client)
+}
+
+func (d *instrumentedAuthService) UnlinkOAuthAccount(userID uuid.UUID, provider string, client models.ClientInfo) (err error) {
+	defer d.observe("UnlinkOAuthAccount", time.Now(), &err)
+	return d.next.UnlinkOAuthAccount(userID, provider, client)
+}
+
+func (d *instrumentedAuthService) CreateFeedToken(userID uuid.UUID) (response *models.FeedTokenResponse, err error) {
+	defer d.observe("CreateFeedToken", time.Now(), &err)
+	return d.next.CreateFeedToken(userID)
+}
+
+func (d *instrumentedAuthService) RevokeFeedToken(userID uuid.UUID) (err error) {
+	defer d.observe("RevokeFeedToken", time.Now(), &err)
+	return d.next.RevokeFeedToken(userID)
+}
+
+func (d *instrumentedAuthService) GetFeed(token, kind string) (feed *feeds.Feed, err error) {
+	defer d.observe("GetFeed", time.Now(), &err)
+	return d.next.GetFeed(token, kind)
+}
+
+func (d *instrumentedAuthService) CreateOAuthClient(adminID uuid.UUID, req *models.AdminCreateOAuthClientRequest) (resp *models.AdminOAuthClientResponse, err error) {
+	defer d.observe("CreateOAuthClient", time.Now(), &err)
+	return d.next.CreateOAuthClient(adminID, req)
+}
+
+func (d *instrumentedAuthService) ListOAuthClients() (clients []models.OAuthClientInfo, err error) {
+	defer d.observe("ListOAuthClients", time.Now(), &err)
+	return d.next.ListOAuthClients()
+}
+
+func (d *instrumentedAuthService) GetOAuthClient(clientID string) (client *models.OAuthClientInfo, err error) {
+	defer d.observe("GetOAuthClient", time.Now(), &err)
+	return d.next.GetOAuthClient(clientID)
+}
+
+func (d *instrumentedAuthService) UpdateOAuthClient(adminID uuid.UUID, clientID string, req *models.AdminUpdateOAuthClientRequest) (client *models.OAuthClientInfo, err error) {
+	defer d.observe("UpdateOAuthClient", time.Now(), &err)
+	return d.next.UpdateOAuthClient(adminID, clientID, req)
+}
+
+func (d *instrumentedAuthService) RotateOAuthClientSecret(adminID uuid.UUID, clientID string) (resp *models.AdminOAuthClientResponse, err error) {
+	defer d.observe("RotateOAuthClientSecret", time.Now(), &err)
+	return d.next.RotateOAuthClientSecret(adminID, clientID)
+}
+
+func (d *instrumentedAuthService) DeleteOAuthClient(adminID uuid.UUID, clientID string) (err error) {
+	defer d.observe("DeleteOAuthClient", time.Now(), &err)
+	return d.next.DeleteOAuthClient(adminID, clientID)
+}
+
+func (d *instrumentedAuthService) IssueClientCredentialsToken(req *models.ClientCredentialsTokenRequest, client models.ClientInfo) (resp *models.ClientCredentialsTokenResponse, err error) {
+	defer d.observeTraced("IssueClientCredentialsToken", time.Now(), client.TraceID, &err)
+	return d.next.IssueClientCredentialsToken(req, client)
+}
+
+func (d *instrumentedAuthService) MergeUsers(adminID, targetID uuid.UUID, req *models.AdminMergeUserRequest, client models.ClientInfo) (resp *models.AdminUserMergeResponse, err error) {
+	defer d.observeTraced("MergeUsers", time.Now(), client.TraceID, &err)
+	return d.next.MergeUsers(adminID, targetID, req, client)
+}
+
+func (d *instrumentedAuthService) ListUserMerges(userID uuid.UUID) (merges []models.AdminUserMergeResponse, err error) {
+	defer d.observe("ListUserMerges", time.Now(), &err)
+	return d.next.ListUserMerges(userID)
+}
+
+func (d *instrumentedAuthService) RecordAudit(event models.AuditEvent) (err error) {
+	defer d.observe("RecordAudit", time.Now(), &err)
+	return d.next.RecordAudit(event)
+}
+
+func (d *instrumentedAuthService) ListAuditLog(filter models.AuditLogFilter, limit, offset int) (resp *models.AdminAuditLogResponse, err error) {
+	defer d.observe("ListAuditLog", time.Now(), &err)
+	return d.next.ListAuditLog(filter, limit, offset)
+}
+
+func (d *instrumentedAuthService) ExportAuditLog(filter models.AuditLogFilter, write func(entry *models.AdminAuditEntry) error) (err error) {
+	defer d.observe("ExportAuditLog", time.Now(), &err)
+	return d.next.ExportAuditLog(filter, write)
+}
+
+func (d *instrumentedAuthService) VerifyAuditLog() (resp *models.AdminAuditVerifyResponse, err error) {
+	defer d.observe("VerifyAuditLog", time.Now(), &err)
+	return d.next.VerifyAuditLog()
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"time"
+
+	"auth-service/internal/instrumentation"
+	"auth-service/internal/models"
+	"shared/middleware"
+)
+
+// instrumentedJWTService decorates a JWTService with per-method latency and error reporting
+type instrumentedJWTService struct {
+	next     JWTService
+	observer instrumentation.Observer
+}
+
+// NewInstrumentedJWTService wraps JWTService so every call reports latency and errors to observer
+func NewInstrumentedJWTService(next JWTService, observer instrumentation.Observer) JWTService {
+	return &instrumentedJWTService{next: next, observer: observer}
+}
+
+// observe reports a finished call; deferred with the named error result
+func (d *instrumentedJWTService) observe(method string, start time.Time, err *error) {
+	var callErr error
+	if err != nil {
+		callErr = *err
+	}
+	d.observer.ObserveCall("JWTService", method, time.Since(start), callErr)
+}
+
+func (d *instrumentedJWTService) GenerateTokenPair(user *models.User) (resp *models.AuthResponse, err error) {
+	defer d.observe("GenerateTokenPair", time.Now(), &err)
+	return d.next.GenerateTokenPair(user)
+}
+
+func (d *instrumentedJWTService) GenerateAccessToken(user *models.User) (token string, err error) {
+	defer d.observe("GenerateAccessToken", time.Now(), &err)
+	return d.next.GenerateAccessToken(user)
+}
+
+func (d *instrumentedJWTService) GenerateRefreshToken(user *models.User) (token string, err error) {
+	defer d.observe("GenerateRefreshToken", time.Now(), &err)
+	return d.next.GenerateRefreshToken(user)
+}
+
+func (d *instrumentedJWTService) RotateRefreshToken(user *models.User, familyID string, familyIssuedAt time.Time) (token string, err error) {
+	defer d.observe("RotateRefreshToken", time.Now(), &err)
+	return d.next.RotateRefreshToken(user, familyID, familyIssuedAt)
+}
+
+func (d *instrumentedJWTService) ValidateToken(tokenString string) (claims *middleware.JWTClaims, err error) {
+	defer d.observe("ValidateToken", time.Now(), &err)
+	return d.next.ValidateToken(tokenString)
+}
+
+func (d *instrumentedJWTService) ValidateRefreshToken(tokenString string) (claims *middleware.JWTClaims, err error) {
+	defer d.observe("ValidateRefreshToken", time.Now(), &err)
+	return d.next.ValidateRefreshToken(tokenString)
+}
+
+func (d *instrumentedJWTService) GenerateClientToken(clientID string, scopes []string, lifetime time.Duration) (token string, err error) {
+	defer d.observe("GenerateClientToken", time.Now(), &err)
+	return d.next.GenerateClientToken(clientID, scopes, lifetime)
+}
+
+func (d *instrumentedJWTService) ValidateClientToken(tokenString string) (claims *middleware.JWTClaims, err error) {
+	defer d.observe("ValidateClientToken", time.Now(), &err)
+	return d.next.ValidateClientToken(tokenString)
+}
+
+func (d *instrumentedJWTService) HashToken(token string) (hash string) {
+	defer d.observe("HashToken", time.Now(), nil)
+	return d.next.HashToken(token)
+}
+
+func (d *instrumentedJWTService) GetTokenClaims(tokenString string) (claims *middleware.JWTClaims, err error) {
+	defer d.observe("GetTokenClaims", time.Now(), &err)
+	return d.next.GetTokenClaims(tokenString)
+}
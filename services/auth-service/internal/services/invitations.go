@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"auth-service/internal/mail"
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// Invitation errors; handlers map them to statuses by message
+var (
+	ErrInvitationsNotConfigured = errors.New("invitations are not configured")
+	ErrInvitationSuperseded     = errors.New("invitation was superseded by a newer one")
+)
+
+// invitationClockSkew tolerates the precision lost when invited_at round-trips through the database
+const invitationClockSkew = time.Second
+
+// InviteUser creates an inactive account without a password and emails the invitee an activation link
+// A failed delivery doesn't undo the invitation; the response reports it so the admin can resend
+func (s *authService) InviteUser(adminID uuid.UUID, req *models.AdminInviteUserRequest) (*models.AdminInvitationResponse, error) {
+	if s.tokenRepo == nil {
+		return nil, ErrInvitationsNotConfigured
+	}
+
+	email := strings.ToLower(req.Email)
+	emailTaken, err := s.userRepo.IsEmailTaken(email)
+	if err != nil {
+		return nil, err
+	}
+	if emailTaken {
+		return nil, errors.New("email already exists")
+	}
+
+	usernameTaken, err := s.userRepo.IsUsernameTaken(req.Username)
+	if err != nil {
+		return nil, err
+	}
+	if usernameTaken {
+		return nil, errors.New("username already exists")
+	}
+
+	role := req.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Email:     email,
+		Username:  req.Username,
+		Role:      role,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		InvitedAt: &now,
+		InvitedBy: &adminID,
+	}
+	if err := s.userRepo.CreateInvitedUser(user); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📧 Admin %s invited %s as %s", adminID, user.Email, user.Role)
+	return s.sendInvitation(adminID, user, now)
+}
+
+// ResendInvitation issues a fresh activation link; links sent earlier stop working
+func (s *authService) ResendInvitation(adminID, userID uuid.UUID) (*models.AdminInvitationResponse, error) {
+	if s.tokenRepo == nil {
+		return nil, ErrInvitationsNotConfigured
+	}
+
+	user, err := s.userRepo.GetPendingInvitation(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.InvitedAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📧 Admin %s resent the invitation for %s", adminID, user.Email)
+	return s.sendInvitation(adminID, user, now)
+}
+
+// AcceptInvitation sets the invitee's password, optionally enables two-factor authentication and
+// activates the account
+func (s *authService) AcceptInvitation(req *models.AcceptInvitationRequest, client models.ClientInfo) (*models.UserInfo, error) {
+	if s.tokenRepo == nil {
+		return nil, ErrInvitationsNotConfigured
+	}
+	// Checked before the token is spent so the invitee can retry with a stronger password
+	if err := s.passwordPolicy.Validate(req.Password); err != nil {
+		return nil, err
+	}
+
+	record, err := s.consumeOneTimeToken(repositories.TokenPurposeInvitation, req.Token, client)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetPendingInvitation(record.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvitationNotFound) {
+			return nil, errors.New("invalid or expired token")
+		}
+		return nil, err
+	}
+	if record.IssuedAt.Before(user.InvitedAt.Add(-invitationClockSkew)) {
+		return nil, ErrInvitationSuperseded
+	}
+
+	passwordHash, err := s.hashPassword(req.Password)
+	if err != nil {
+		return nil, errors.New("failed to hash password")
+	}
+
+	// Following the emailed link proves ownership of the address
+	user.PasswordHash = passwordHash
+	user.IsActive = true
+	user.EmailVerified = true
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	if req.EnableTwoFactor {
+		enabled := true
+		if _, err := s.UpdateUserPreferences(user.ID, &UpdatePreferencesRequest{TwoFactorEnabled: &enabled}); err != nil {
+			log.Printf("⚠️  Failed to enable two-factor authentication for invited user %s: %v", user.ID, err)
+		}
+	}
+
+	if err := s.LogUserActivity(user.ID, "invitation_accepted", "Account activated from an invitation", map[string]interface{}{
+		"two_factor_enabled": req.EnableTwoFactor,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record invitation acceptance for user %s: %v", user.ID, err)
+	}
+
+	log.Printf("✅ Invited user %s activated their account", user.Email)
+	return &models.UserInfo{
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		Username:      user.Username,
+		Role:          user.Role,
+		IsActive:      user.IsActive,
+		EmailVerified: user.EmailVerified,
+		Avatar:        user.Avatar,
+		LastLoginAt:   user.LastLoginAt,
+		CreatedAt:     user.CreatedAt,
+	}, nil
+}
+
+// sendInvitation stores an unbound invitation token issued at invitedAt and emails the activation link
+func (s *authService) sendInvitation(adminID uuid.UUID, user *models.User, invitedAt time.Time) (*models.AdminInvitationResponse, error) {
+	token, err := generateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &repositories.OneTimeToken{
+		UserID:   user.ID,
+		Purpose:  repositories.TokenPurposeInvitation,
+		IssuedAt: invitedAt,
+	}
+	if err := s.tokenRepo.Issue(repositories.TokenPurposeInvitation, s.jwtService.HashToken(token), record, s.security.InvitationTTL); err != nil {
+		return nil, err
+	}
+
+	resp := &models.AdminInvitationResponse{
+		User: models.UserInfo{
+			ID:            user.ID.String(),
+			Email:         user.Email,
+			Username:      user.Username,
+			Role:          user.Role,
+			IsActive:      user.IsActive,
+			EmailVerified: user.EmailVerified,
+			CreatedAt:     user.CreatedAt,
+		},
+		ExpiresAt: invitedAt.Add(s.security.InvitationTTL),
+	}
+
+	if s.mailer == nil {
+		log.Printf("⚠️  No mailer configured, invitation email for %s was not sent", user.Email)
+		return resp, nil
+	}
+	if err := s.mailer.Send(s.invitationEmail(user, token, resp.ExpiresAt)); err != nil {
+		log.Printf("⚠️  Failed to send invitation email to %s: %v", user.Email, err)
+		return resp, nil
+	}
+	resp.EmailSent = true
+
+	if err := s.LogUserActivity(user.ID, "invitation_sent", "Invitation email sent", map[string]interface{}{
+		"admin_id":   adminID.String(),
+		"expires_at": resp.ExpiresAt.Format(time.RFC3339),
+	}); err != nil {
+		log.Printf("⚠️  Failed to record invitation activity for user %s: %v", user.ID, err)
+	}
+	return resp, nil
+}
+
+func (s *authService) invitationEmail(user *models.User, token string, expiresAt time.Time) mail.Message {
+	link := strings.TrimRight(s.linkBaseURL, "/") + "/activate-account?token=" + url.QueryEscape(token)
+
+	name := user.FirstName
+	if name == "" {
+		name = user.Username
+	}
+
+	return mail.Message{
+		To:      user.Email,
+		Subject: "You've been invited to create your account",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"An administrator created an account for you. Open the link below to set your password and activate it:\n\n"+
+			"%s\n\n"+
+			"The link can be used once and expires on %s. Ask your administrator to resend the invitation if it has expired.\n",
+			name, link, expiresAt.UTC().Format("2006-01-02 15:04 MST")),
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -23,22 +24,41 @@ func parseDuration(durationStr string) time.Duration {
 	return duration
 }
 
+// ErrRefreshFamilyExpired means the refresh token family outlived its absolute lifetime
+var ErrRefreshFamilyExpired = errors.New("refresh token family expired: please log in again")
+
 type JWTService interface {
 	GenerateTokenPair(user *models.User) (*models.AuthResponse, error)
 	GenerateAccessToken(user *models.User) (string, error)
 	GenerateRefreshToken(user *models.User) (string, error)
+	// RotateRefreshToken issues the next refresh token of family familyID, started at familyIssuedAt
+	RotateRefreshToken(user *models.User, familyID string, familyIssuedAt time.Time) (string, error)
 	ValidateToken(tokenString string) (*middleware.JWTClaims, error)
 	ValidateRefreshToken(tokenString string) (*middleware.JWTClaims, error)
+	// GenerateClientToken issues a client-credentials token to a registered service; it names no user
+	GenerateClientToken(clientID string, scopes []string, lifetime time.Duration) (string, error)
+	ValidateClientToken(tokenString string) (*middleware.JWTClaims, error)
 	HashToken(token string) string
 	GetTokenClaims(tokenString string) (*middleware.JWTClaims, error)
 }
 
 type jwtService struct {
-	config config.JWTConfig
+	config    config.JWTConfig
+	keys      *SigningKeys
+	keysErr   error // Invalid jwt.keys; config.Load rejects them, so only hand-built configurations get here
+	enrichers []ClaimsEnricher
+}
+
+// NewJWTService creates the token service; enrichers add allowlisted custom claims to access tokens
+func NewJWTService(cfg config.JWTConfig, enrichers ...ClaimsEnricher) JWTService {
+	keys, err := NewSigningKeys(cfg)
+	return &jwtService{config: cfg, keys: keys, keysErr: err, enrichers: enrichers}
 }
 
-func NewJWTService(cfg config.JWTConfig) JWTService {
-	return &jwtService{config: cfg}
+// NewJWTServiceWithKeys creates the token service on a key set shared with the JWT middleware and the
+// JWKS endpoint
+func NewJWTServiceWithKeys(cfg config.JWTConfig, keys *SigningKeys, enrichers ...ClaimsEnricher) JWTService {
+	return &jwtService{config: cfg, keys: keys, enrichers: enrichers}
 }
 
 func (s *jwtService) GenerateTokenPair(user *models.User) (*models.AuthResponse, error) {
@@ -58,7 +78,7 @@ func (s *jwtService) GenerateTokenPair(user *models.User) (*models.AuthResponse,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    int64(parseDuration(s.config.AccessExpiry).Seconds()),
+		ExpiresIn:    int64(s.accessExpiry(user).Seconds()),
 		User: models.UserInfo{
 			ID:            user.ID.String(),
 			Email:         user.Email,
@@ -75,19 +95,26 @@ func (s *jwtService) GenerateTokenPair(user *models.User) (*models.AuthResponse,
 
 func (s *jwtService) GenerateAccessToken(user *models.User) (string, error) {
 	now := time.Now()
+	expiresAt := now.Add(s.accessExpiry(user))
+	// Tokens carrying granted roles never outlive the grants
+	if user.GrantsExpireAt != nil && user.GrantsExpireAt.Before(expiresAt) {
+		expiresAt = *user.GrantsExpireAt
+	}
+
 	claims := &middleware.JWTClaims{
-		UserID:    user.ID.String(),
-		Email:     user.Email,
-		Username:  user.Username,
-		Role:      string(user.Role),
-		Type:      "access",
-		Issuer:    s.config.Issuer,
-		Subject:   user.ID.String(),
-		IssuedAt:  now.Unix(),
-		ExpiresAt: now.Add(parseDuration(s.config.AccessExpiry)).Unix(),
+		UserID:       user.ID.String(),
+		Email:        user.Email,
+		Username:     user.Username,
+		Role:         string(user.Role),
+		Type:         "access",
+		Issuer:       s.config.Issuer,
+		Subject:      user.ID.String(),
+		IssuedAt:     now.Unix(),
+		ExpiresAt:    expiresAt.Unix(),
+		TokenVersion: int64(user.TokenVersion),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	mapClaims := jwt.MapClaims{
 		"user_id":  claims.UserID,
 		"email":    claims.Email,
 		"username": claims.Username,
@@ -97,13 +124,53 @@ func (s *jwtService) GenerateAccessToken(user *models.User) (string, error) {
 		"sub":      claims.Subject,
 		"iat":      claims.IssuedAt,
 		"exp":      claims.ExpiresAt,
-	})
+		"tv":       claims.TokenVersion,
+	}
+	if user.Policy != nil && user.Policy.ClientID != "" {
+		mapClaims["client_id"] = user.Policy.ClientID
+	}
+	// roles lists what the user holds beyond role: granted system roles, then assigned custom roles
+	if len(user.GrantedRoles) > 0 || len(user.AssignedRoles) > 0 {
+		roles := make([]string, 0, len(user.GrantedRoles)+len(user.AssignedRoles))
+		for _, role := range user.GrantedRoles {
+			roles = append(roles, string(role))
+		}
+		roles = append(roles, user.AssignedRoles...)
+		mapClaims["roles"] = roles
+	}
+	if s.config.PermissionsClaim && len(user.Permissions) > 0 {
+		mapClaims["perms"] = strings.Join(user.Permissions, " ")
+	}
+	for name, value := range customClaims(s.config.CustomClaims, s.enrichers, user) {
+		mapClaims[name] = value
+	}
 
-	return token.SignedString([]byte(s.config.AccessSecret))
+	if s.keysErr != nil {
+		return "", s.keysErr
+	}
+	return s.keys.Sign(mapClaims)
 }
 
+// GenerateRefreshToken starts a new refresh token family (a fresh login)
 func (s *jwtService) GenerateRefreshToken(user *models.User) (string, error) {
+	return s.RotateRefreshToken(user, models.NewID().String(), time.Now())
+}
+
+// RotateRefreshToken keeps the family ID and issued-at of the token being rotated
+// In absolute mode the new token never expires later than the family's maximum lifetime
+func (s *jwtService) RotateRefreshToken(user *models.User, familyID string, familyIssuedAt time.Time) (string, error) {
 	now := time.Now()
+	expiresAt := now.Add(s.refreshExpiry(user))
+	if s.config.RefreshMode == config.RefreshModeAbsolute {
+		familyExpiresAt := familyIssuedAt.Add(parseDuration(s.config.RefreshMaxLifetime))
+		if !now.Before(familyExpiresAt) {
+			return "", ErrRefreshFamilyExpired
+		}
+		if familyExpiresAt.Before(expiresAt) {
+			expiresAt = familyExpiresAt
+		}
+	}
+
 	claims := &middleware.JWTClaims{
 		UserID:    user.ID.String(),
 		Email:     user.Email,
@@ -113,31 +180,48 @@ func (s *jwtService) GenerateRefreshToken(user *models.User) (string, error) {
 		Issuer:    s.config.Issuer,
 		Subject:   user.ID.String(),
 		IssuedAt:  now.Unix(),
-		ExpiresAt: now.Add(parseDuration(s.config.RefreshExpiry)).Unix(),
+		ExpiresAt: expiresAt.Unix(),
+
+		FamilyIssuedAt: familyIssuedAt.Unix(),
+		FamilyID:       familyID,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":  claims.UserID,
-		"email":    claims.Email,
-		"username": claims.Username,
-		"role":     claims.Role,
-		"type":     claims.Type,
-		"iss":      claims.Issuer,
-		"sub":      claims.Subject,
-		"iat":      claims.IssuedAt,
-		"exp":      claims.ExpiresAt,
-	})
+	mapClaims := jwt.MapClaims{
+		"user_id":    claims.UserID,
+		"email":      claims.Email,
+		"username":   claims.Username,
+		"role":       claims.Role,
+		"type":       claims.Type,
+		"iss":        claims.Issuer,
+		"sub":        claims.Subject,
+		"iat":        claims.IssuedAt,
+		"exp":        claims.ExpiresAt,
+		"family_iat": claims.FamilyIssuedAt,
+		"fid":        claims.FamilyID,
+	}
+	// The client keeps its security policy when the token is refreshed
+	if user.Policy != nil && user.Policy.ClientID != "" {
+		mapClaims["client_id"] = user.Policy.ClientID
+	}
+	// Bind the token to the TLS client it is issued to
+	if user.TLSFingerprint != "" {
+		mapClaims["tlsfp"] = user.TLSFingerprint
+	}
+	// A remembered login keeps its long lifetime when the token is refreshed
+	if user.Policy != nil && user.Policy.RememberMe {
+		mapClaims["rm"] = true
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims)
 
 	return token.SignedString([]byte(s.config.RefreshSecret))
 }
 
 func (s *jwtService) ValidateToken(tokenString string) (*middleware.JWTClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(s.config.AccessSecret), nil
-	})
+	if s.keysErr != nil {
+		return nil, s.keysErr
+	}
+	token, err := jwt.Parse(tokenString, s.keys.Keyfunc)
 
 	if err != nil {
 		return nil, err
@@ -191,6 +275,71 @@ func (s *jwtService) ValidateRefreshToken(tokenString string) (*middleware.JWTCl
 	return s.mapClaimsToJWTClaims(claims)
 }
 
+// GenerateClientToken signs a client token with the access token keys, so services verify it like an
+// access token, e.g. against /.well-known/jwks.json
+func (s *jwtService) GenerateClientToken(clientID string, scopes []string, lifetime time.Duration) (string, error) {
+	if s.keysErr != nil {
+		return "", s.keysErr
+	}
+	now := time.Now()
+	return s.keys.Sign(jwt.MapClaims{
+		"type":      middleware.TokenTypeClient,
+		"client_id": clientID,
+		"scopes":    scopes,
+		"iss":       s.config.Issuer,
+		"sub":       clientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(lifetime).Unix(),
+	})
+}
+
+// ValidateClientToken verifies a token from GenerateClientToken; access tokens are rejected
+func (s *jwtService) ValidateClientToken(tokenString string) (*middleware.JWTClaims, error) {
+	if s.keysErr != nil {
+		return nil, s.keysErr
+	}
+	token, err := jwt.Parse(tokenString, s.keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	if tokenType, _ := mapClaims["type"].(string); tokenType != middleware.TokenTypeClient {
+		return nil, errors.New("invalid token type")
+	}
+
+	claims := &middleware.JWTClaims{}
+	if err := claims.FromMap(mapClaims); err != nil {
+		return nil, err
+	}
+	if claims.ClientID == "" {
+		return nil, errors.New("invalid client_id claim")
+	}
+	return claims, nil
+}
+
+// accessExpiry is the access token lifetime for user; the resolved security policy may shorten it
+func (s *jwtService) accessExpiry(user *models.User) time.Duration {
+	if user.Policy != nil {
+		return user.Policy.AccessExpiry
+	}
+	return parseDuration(s.config.AccessExpiry)
+}
+
+// refreshExpiry is the refresh token lifetime for user; the resolved security policy may shorten it
+func (s *jwtService) refreshExpiry(user *models.User) time.Duration {
+	if user.Policy != nil {
+		return user.Policy.RefreshExpiry
+	}
+	return parseDuration(s.config.RefreshExpiry)
+}
+
 func (s *jwtService) HashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
 	return hex.EncodeToString(hash[:])
@@ -256,7 +405,7 @@ func (s *jwtService) mapClaimsToJWTClaims(claims jwt.MapClaims) (*middleware.JWT
 		return nil, errors.New("invalid expires at claim")
 	}
 
-	return &middleware.JWTClaims{
+	result := &middleware.JWTClaims{
 		UserID:    userID,
 		Email:     email,
 		Username:  username,
@@ -266,5 +415,47 @@ func (s *jwtService) mapClaimsToJWTClaims(claims jwt.MapClaims) (*middleware.JWT
 		Subject:   subject,
 		IssuedAt:  int64(issuedAt),
 		ExpiresAt: int64(expiresAt),
-	}, nil
+	}
+	if roles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			if str, ok := role.(string); ok {
+				result.Roles = append(result.Roles, str)
+			}
+		}
+	}
+	if permissions, ok := claims["perms"].(string); ok {
+		result.Permissions = strings.Fields(permissions)
+	}
+	if tokenVersion, ok := claims["tv"].(float64); ok {
+		result.TokenVersion = int64(tokenVersion)
+	}
+	if clientID, ok := claims["client_id"].(string); ok {
+		result.ClientID = clientID
+	}
+	if fingerprint, ok := claims["tlsfp"].(string); ok {
+		result.TLSFingerprint = fingerprint
+	}
+	for _, name := range s.config.CustomClaims.Allowed {
+		if value, ok := claims[name]; ok {
+			if result.Custom == nil {
+				result.Custom = make(map[string]interface{})
+			}
+			result.Custom[name] = value
+		}
+	}
+	if tokenType == "refresh" {
+		// Refresh tokens issued before families were tracked start their family at their own issued-at
+		result.FamilyIssuedAt = result.IssuedAt
+		if familyIssuedAt, ok := claims["family_iat"].(float64); ok {
+			result.FamilyIssuedAt = int64(familyIssuedAt)
+		}
+		// Tokens issued before family IDs were tracked get one when they are next rotated
+		if familyID, ok := claims["fid"].(string); ok {
+			result.FamilyID = familyID
+		}
+		if rememberMe, ok := claims["rm"].(bool); ok {
+			result.RememberMe = rememberMe
+		}
+	}
+	return result, nil
 }
\ No newline at end of file
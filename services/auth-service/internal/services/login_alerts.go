@@ -0,0 +1,138 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"auth-service/internal/geoip"
+	"auth-service/internal/mail"
+	"auth-service/internal/models"
+	"auth-service/internal/privacy"
+)
+
+// NotificationTypeNewSignIn is the notification type of new sign-in alerts
+const NotificationTypeNewSignIn = "new_sign_in"
+
+// isNewSignInDevice reports whether a login of user from client should raise a new sign-in alert: none
+// of the user's logins within login_alerts.lookback came from its device and network. An account's first
+// login, and logins when the history can't be read, raise none
+func (s *authService) isNewSignInDevice(user *models.User, client models.ClientInfo) bool {
+	if !s.loginAlerts.Enabled || user.LastLoginAt == nil {
+		return false
+	}
+	device := models.SignInDevice{
+		UserAgent:  client.UserAgent,
+		IPHash:     s.ipPrivacy.Hash(client.IPAddress),
+		DeviceInfo: sessionDeviceInfo(client),
+	}
+	if ip := net.ParseIP(client.IPAddress); ip != nil && device.IPHash == "" {
+		device.Network = privacy.Network(ip).String()
+	}
+
+	seen, err := s.userRepo.HasSignedInFrom(user.ID, device, time.Now().Add(-s.loginAlerts.Lookback))
+	if err != nil {
+		log.Printf("⚠️  Failed to check sign-in history of user %s: %v", user.ID, err)
+		return false
+	}
+	return !seen
+}
+
+// alertNewSignIn tells the user about a login from a new device and network with a notification, pushed
+// to subscribed browsers, and with an email when login_alerts.email is set. The address is followed by its
+// location when geoip found one
+func (s *authService) alertNewSignIn(user *models.User, client models.ClientInfo, location geoip.Location) {
+	device, address := describeDevice(client.UserAgent), client.IPAddress
+	if address == "" {
+		address = "an unknown address"
+	}
+	if where := location.String(); where != "" {
+		address += " (" + where + ")"
+	}
+	notification := &models.UserNotification{
+		ID:        models.NewID(),
+		UserID:    user.ID,
+		Type:      NotificationTypeNewSignIn,
+		Title:     "New sign-in to your account",
+		Message:   fmt.Sprintf("Your account was signed in to from %s at %s. If this wasn't you, reset your password.", device, address),
+		CreatedAt: time.Now(),
+	}
+	if err := s.userRepo.CreateUserNotification(notification); err != nil {
+		log.Printf("⚠️  Failed to create new sign-in notification for user %s: %v", user.ID, err)
+	} else {
+		s.pushNotification(notification)
+	}
+
+	if !s.loginAlerts.Email {
+		return
+	}
+	if s.mailer == nil {
+		log.Printf("⚠️  No mailer configured, new sign-in email for %s was not sent", user.Email)
+		return
+	}
+	go func() {
+		if err := s.mailer.Send(s.newSignInEmail(user, device, address, notification.CreatedAt)); err != nil {
+			log.Printf("⚠️  Failed to send new sign-in email to %s: %v", user.Email, err)
+		}
+	}()
+}
+
+func (s *authService) newSignInEmail(user *models.User, device, address string, at time.Time) mail.Message {
+	link := strings.TrimRight(s.linkBaseURL, "/") + "/forgot-password"
+
+	name := user.FirstName
+	if name == "" {
+		name = user.Username
+	}
+
+	return mail.Message{
+		To:      user.Email,
+		Subject: "New sign-in to your account",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Your account was just signed in to from a device or location you haven't used recently:\n\n"+
+			"  Time:    %s\n"+
+			"  Device:  %s\n"+
+			"  Address: %s\n\n"+
+			"If this was you, there's nothing to do.\n\n"+
+			"If it wasn't, reset your password right away; resetting it signs you out on every device:\n\n"+
+			"%s\n",
+			name, at.UTC().Format("2006-01-02 15:04 MST"), device, address, link),
+	}
+}
+
+// describeDevice names the browser and operating system of a user agent for people to recognize, falling
+// back to the user agent itself
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "an unknown device"
+	}
+	browser := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+	system := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Windows", "Windows"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			system = candidate.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "" || system != "":
+		return browser + system
+	default:
+		return userAgent
+	}
+}
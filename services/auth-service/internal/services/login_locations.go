@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"auth-service/internal/geoip"
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrCountryBlocked refuses logins and sign-ups from a country in geoip.blocked_countries
+var ErrCountryBlocked = errors.New("sign-ins from your location are not allowed")
+
+// screenClient applies the country rules and the CAPTCHA before credentials or sign-up details are
+// checked: callers from geoip.blocked_countries are refused, those from challenge_countries always solve
+// the CAPTCHA, and everyone else once logins keep failing. It returns where the caller is
+func (s *authService) screenClient(client models.ClientInfo, email, captchaToken string) (geoip.Location, error) {
+	location := s.geoIP.Locate(context.Background(), client.IPAddress)
+	if s.geoIP.Blocked(location) {
+		log.Printf("🚫 Login or sign-up for %s refused from blocked country %s (request %s)", email, location.CountryCode, client.RequestID)
+		s.auditSecurityEvent("country_blocked", nil, client, map[string]interface{}{
+			"email":        email,
+			"country_code": location.CountryCode,
+		})
+		return location, ErrCountryBlocked
+	}
+	if s.geoIP.Challenged(location) {
+		return location, s.captcha.Challenge(context.Background(), client.IPAddress, captchaToken)
+	}
+	return location, s.captcha.Check(context.Background(), client.IPAddress, email, captchaToken)
+}
+
+// locateLogin records where a login attempt came from unless screenClient already did, as for password
+// logins; OAuth and passkey logins are located here and refused from geoip.blocked_countries
+func (s *authService) locateLogin(attempt *models.LoginAttempt, client models.ClientInfo) error {
+	if s.geoIP == nil || attempt.CountryCode != "" {
+		return nil
+	}
+	location := s.geoIP.Locate(context.Background(), client.IPAddress)
+	attempt.CountryCode, attempt.City = location.CountryCode, location.City
+	if s.geoIP.Blocked(location) {
+		attempt.FailureReason = ErrCountryBlocked.Error()
+		s.userRepo.CreateLoginAttempt(attempt)
+		s.auditSecurityEvent("country_blocked", attempt.UserID, client, map[string]interface{}{
+			"email":        attempt.Email,
+			"country_code": location.CountryCode,
+		})
+		return ErrCountryBlocked
+	}
+	return nil
+}
+
+// GetLoginHistory returns a page of the user's login attempts, newest first, with where they came from
+func (s *authService) GetLoginHistory(userID uuid.UUID, limit, offset int) (*models.LoginHistoryResponse, error) {
+	attempts, total, err := s.userRepo.ListLoginAttempts(userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if attempts == nil {
+		attempts = []models.LoginAttempt{}
+	}
+	return &models.LoginHistoryResponse{Attempts: attempts, Total: total, Limit: limit, Offset: offset}, nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/maintenance"
+	"auth-service/internal/repositories"
+)
+
+// NotificationRetention periodically removes read or expired notifications older than
+// notification_retention.max_age, counting each into a monthly summary row per user first
+type NotificationRetention struct {
+	userRepo    repositories.UserRepository
+	config      config.NotificationRetentionConfig
+	maintenance *maintenance.Mode
+
+	mu          sync.Mutex
+	removed     uint64
+	archived    uint64
+	summaries   uint64
+	batches     map[string]uint64
+	lastSuccess time.Time
+}
+
+// NewNotificationRetention creates the retention job; runs are skipped while mode is read-only
+func NewNotificationRetention(userRepo repositories.UserRepository, cfg config.NotificationRetentionConfig, mode *maintenance.Mode) *NotificationRetention {
+	return &NotificationRetention{
+		userRepo:    userRepo,
+		config:      cfg,
+		maintenance: mode,
+		batches:     map[string]uint64{"success": 0, "error": 0},
+	}
+}
+
+// Start runs the job immediately and then every notification_retention.interval until ctx is cancelled
+// It does nothing unless notification_retention.enabled is set
+func (j *NotificationRetention) Start(ctx context.Context) {
+	if !j.config.Enabled {
+		return
+	}
+
+	go func() {
+		j.run(ctx)
+
+		ticker := time.NewTicker(j.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.run(ctx)
+			}
+		}
+	}()
+}
+
+// run compacts batches until no eligible notifications are left or ctx is cancelled
+func (j *NotificationRetention) run(ctx context.Context) {
+	if j.maintenance.ReadOnly() {
+		return
+	}
+
+	now := time.Now()
+	olderThan := now.Add(-j.config.MaxAge)
+	archive := j.config.Mode == config.NotificationRetentionArchive
+
+	var total repositories.NotificationCompaction
+	for ctx.Err() == nil {
+		batch, err := j.userRepo.CompactNotifications(olderThan, now, archive, j.config.BatchSize)
+		j.record(batch, err)
+		if err != nil {
+			log.Printf("❌ Notification retention failed after removing %d notifications: %v", total.Removed, err)
+			return
+		}
+		total.Removed += batch.Removed
+		total.Archived += batch.Archived
+		if batch.Removed < int64(j.config.BatchSize) {
+			break
+		}
+	}
+
+	if total.Removed > 0 {
+		log.Printf("🧹 Notification retention removed %d notifications older than %s (%d archived)",
+			total.Removed, olderThan.UTC().Format(time.RFC3339), total.Archived)
+	}
+}
+
+// record adds one batch to the metrics
+func (j *NotificationRetention) record(batch repositories.NotificationCompaction, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err != nil {
+		j.batches["error"]++
+		return
+	}
+	j.batches["success"]++
+	j.removed += uint64(batch.Removed)
+	j.archived += uint64(batch.Archived)
+	j.summaries += uint64(batch.Summaries)
+	j.lastSuccess = time.Now()
+}
+
+// WritePrometheus writes the rows processed by the job in the Prometheus text format
+func (j *NotificationRetention) WritePrometheus(w io.Writer) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP auth_service_notification_retention_rows_total Notifications processed by the retention job")
+	fmt.Fprintln(w, "# TYPE auth_service_notification_retention_rows_total counter")
+	fmt.Fprintf(w, "auth_service_notification_retention_rows_total{action=\"removed\"} %d\n", j.removed)
+	fmt.Fprintf(w, "auth_service_notification_retention_rows_total{action=\"archived\"} %d\n", j.archived)
+	fmt.Fprintln(w, "# HELP auth_service_notification_retention_summaries_total Monthly notification summary rows created or updated")
+	fmt.Fprintln(w, "# TYPE auth_service_notification_retention_summaries_total counter")
+	fmt.Fprintf(w, "auth_service_notification_retention_summaries_total %d\n", j.summaries)
+	fmt.Fprintln(w, "# HELP auth_service_notification_retention_batches_total Retention job batches by result")
+	fmt.Fprintln(w, "# TYPE auth_service_notification_retention_batches_total counter")
+	for _, result := range []string{"success", "error"} {
+		fmt.Fprintf(w, "auth_service_notification_retention_batches_total{result=%q} %d\n", result, j.batches[result])
+	}
+	if !j.lastSuccess.IsZero() {
+		fmt.Fprintln(w, "# HELP auth_service_notification_retention_last_success_timestamp_seconds When a retention batch last succeeded")
+		fmt.Fprintln(w, "# TYPE auth_service_notification_retention_last_success_timestamp_seconds gauge")
+		fmt.Fprintf(w, "auth_service_notification_retention_last_success_timestamp_seconds %d\n", j.lastSuccess.Unix())
+	}
+}
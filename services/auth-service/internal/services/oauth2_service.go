@@ -2,30 +2,54 @@ package services
 
 import (
 	"auth-service/internal/config"
+	"auth-service/internal/discovery"
 	"auth-service/internal/models"
+	"auth-service/internal/repositories"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/facebook"
 	"golang.org/x/oauth2/github"
 	"golang.org/x/oauth2/google"
 )
 
+// ErrUnsupportedOAuthProvider means the provider isn't configured or enabled
+var ErrUnsupportedOAuthProvider = errors.New("unsupported OAuth provider")
+
+// ErrOAuthState means the callback's state was never issued, has expired, was already used or belongs
+// to another provider or to a browser without the flow's nonce cookie; the login has to start over
+var ErrOAuthState = errors.New("OAuth state is invalid or has expired")
+
 type OAuth2Service interface {
-	GetAuthURL(provider, state string) (string, error)
-	HandleCallback(provider, code, state string) (*models.OAuth2UserInfo, error)
+	// GetAuthURL starts a login, or linking the provider to linkUserID's account when it isn't uuid.Nil:
+	// it stores a new single-use state, PKCE verifier and the hash of nonce, the value of the starting
+	// browser's nonce cookie, for oauth2.state_ttl and returns the provider's authorization URL and the state
+	GetAuthURL(provider string, linkUserID uuid.UUID, nonce string, client models.ClientInfo) (authURL, state string, err error)
+	// HandleCallback consumes the state, checks nonce is the one it was issued with, then exchanges the code
+	// with the stored PKCE verifier; linkUserID is the account the flow was started to link, or uuid.Nil for a login
+	HandleCallback(provider, code, state, nonce string, client models.ClientInfo) (userInfo *models.OAuth2UserInfo, linkUserID uuid.UUID, err error)
 	GetProviderConfig(provider string) (*oauth2.Config, error)
+	FrontendRedirectURL() string // Where callbacks redirect with a one-time code; "" to respond with tokens
 }
 
 type oauth2Service struct {
-	configs map[string]*oauth2.Config
+	configs             map[string]*oauth2.Config
+	apple               *appleProvider
+	oidc                map[string]*oidcProvider // Configured by issuer; their endpoints can change, so no static config
+	frontendRedirectURL string
+	states              repositories.OAuthStateRepository
+	stateTTL            time.Duration
 }
 
-func NewOAuth2Service(cfg config.OAuth2Config) OAuth2Service {
+func NewOAuth2Service(cfg config.OAuth2Config, states repositories.OAuthStateRepository, fetcher *discovery.Fetcher) OAuth2Service {
 	configs := make(map[string]*oauth2.Config)
 
 	// Google OAuth2
@@ -61,49 +85,145 @@ func NewOAuth2Service(cfg config.OAuth2Config) OAuth2Service {
 		}
 	}
 
-	return &oauth2Service{configs: configs}
+	// Sign in with Apple; the client secret is signed for each exchange
+	var apple *appleProvider
+	if cfg.Apple.Enabled {
+		provider, err := newAppleProvider(cfg.Apple)
+		if err != nil {
+			log.Printf("🚨 Sign in with Apple disabled: invalid private key: %v", err)
+		} else {
+			apple = provider
+			configs[models.OAuthProviderApple] = &oauth2.Config{
+				ClientID:    cfg.Apple.ClientID,
+				RedirectURL: cfg.Apple.RedirectURL,
+				Scopes:      []string{"name", "email"},
+				Endpoint:    appleEndpoint,
+			}
+		}
+	}
+
+	// OpenID Connect providers configured by issuer; discovery fetches their documents in the background
+	oidc := make(map[string]*oidcProvider)
+	for name, provider := range cfg.OIDC {
+		if provider.Enabled {
+			oidc[name] = newOIDCProvider(name, provider, fetcher)
+		}
+	}
+
+	return &oauth2Service{
+		configs:             configs,
+		apple:               apple,
+		oidc:                oidc,
+		frontendRedirectURL: cfg.FrontendRedirectURL,
+		states:              states,
+		stateTTL:            cfg.StateTTL,
+	}
 }
 
-func (s *oauth2Service) GetAuthURL(provider, state string) (string, error) {
-	config, exists := s.configs[provider]
-	if !exists {
-		return "", errors.New("unsupported OAuth provider")
+func (s *oauth2Service) FrontendRedirectURL() string {
+	return s.frontendRedirectURL
+}
+
+func (s *oauth2Service) GetAuthURL(provider string, linkUserID uuid.UUID, nonce string, _ models.ClientInfo) (string, string, error) {
+	config, err := s.GetProviderConfig(provider)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The state ties the callback to this login and the nonce to this browser (CSRF protection); PKCE
+	// ties the code to this service
+	state, err := generateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+	record := &repositories.OAuthState{
+		Provider:     provider,
+		CodeVerifier: verifier,
+		NonceHash:    hashDeviceAttribute(nonce),
+		LinkUserID:   linkUserID,
+		IssuedAt:     time.Now(),
+	}
+	if err := s.states.Save(hashDeviceAttribute(state), record, s.stateTTL); err != nil {
+		return "", "", fmt.Errorf("failed to store OAuth state: %w", err)
 	}
 
-	return config.AuthCodeURL(state, oauth2.AccessTypeOffline), nil
+	// Apple posts the callback as a form when the name or email is requested, and doesn't support PKCE;
+	// the state still ties the callback to this login
+	if provider == models.OAuthProviderApple {
+		return config.AuthCodeURL(state, oauth2.SetAuthURLParam("response_mode", "form_post")), state, nil
+	}
+	return config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)), state, nil
 }
 
-func (s *oauth2Service) HandleCallback(provider, code, state string) (*models.OAuth2UserInfo, error) {
-	config, exists := s.configs[provider]
-	if !exists {
-		return nil, errors.New("unsupported OAuth provider")
+func (s *oauth2Service) HandleCallback(provider, code, state, nonce string, client models.ClientInfo) (*models.OAuth2UserInfo, uuid.UUID, error) {
+	config, err := s.GetProviderConfig(provider)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	// Consumed before anything else, so a state works once even if the exchange below fails
+	record, err := s.states.Consume(hashDeviceAttribute(state))
+	if errors.Is(err, repositories.ErrOAuthStateNotFound) {
+		return nil, uuid.Nil, ErrOAuthState
+	}
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	// A callback URL sent to someone else arrives without the nonce cookie of the browser that started it
+	if record.Provider != provider || nonce == "" ||
+		subtle.ConstantTimeCompare([]byte(record.NonceHash), []byte(hashDeviceAttribute(nonce))) != 1 {
+		log.Printf("🚨 OAuth state for %s presented to the %s callback from a different browser (request_id=%s)",
+			record.Provider, provider, client.RequestID)
+		return nil, uuid.Nil, ErrOAuthState
 	}
 
 	// Exchange code for token
-	token, err := config.Exchange(context.Background(), code)
+	token, err := s.exchange(provider, config, code, record.CodeVerifier)
 	if err != nil {
-		return nil, fmt.Errorf("failed to exchange code for token: %w", err)
+		return nil, uuid.Nil, fmt.Errorf("failed to exchange code for token: %w", err)
 	}
 
 	// Get user info from provider
 	userInfo, err := s.getUserInfo(provider, config, token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user info: %w", err)
+		return nil, uuid.Nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
 	userInfo.Provider = provider
-	return userInfo, nil
+	return userInfo, record.LinkUserID, nil
+}
+
+// exchange trades the code for a token; for Apple with a freshly signed client secret
+func (s *oauth2Service) exchange(provider string, config *oauth2.Config, code, verifier string) (*oauth2.Token, error) {
+	if provider != models.OAuthProviderApple {
+		return config.Exchange(context.Background(), code, oauth2.VerifierOption(verifier))
+	}
+
+	secret, err := s.apple.clientSecret(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign Apple client secret: %w", err)
+	}
+	appleConfig := *config
+	appleConfig.ClientSecret = secret
+	return appleConfig.Exchange(context.Background(), code)
 }
 
 func (s *oauth2Service) GetProviderConfig(provider string) (*oauth2.Config, error) {
+	if oidc, exists := s.oidc[provider]; exists {
+		return oidc.oauthConfig()
+	}
 	config, exists := s.configs[provider]
 	if !exists {
-		return nil, errors.New("unsupported OAuth provider")
+		return nil, ErrUnsupportedOAuthProvider
 	}
 	return config, nil
 }
 
 func (s *oauth2Service) getUserInfo(provider string, config *oauth2.Config, token *oauth2.Token) (*models.OAuth2UserInfo, error) {
+	if oidc, exists := s.oidc[provider]; exists {
+		return oidc.userInfo(config, token)
+	}
 	client := config.Client(context.Background(), token)
 
 	switch provider {
@@ -113,6 +233,8 @@ func (s *oauth2Service) getUserInfo(provider string, config *oauth2.Config, toke
 		return s.getGitHubUserInfo(client)
 	case "facebook":
 		return s.getFacebookUserInfo(client)
+	case "apple":
+		return s.apple.userInfo(token)
 	default:
 		return nil, errors.New("unsupported provider")
 	}
@@ -142,10 +264,11 @@ func (s *oauth2Service) getGoogleUserInfo(client *http.Client) (*models.OAuth2Us
 	}
 
 	return &models.OAuth2UserInfo{
-		ID:       googleUser.ID,
-		Email:    googleUser.Email,
-		Username: googleUser.Name,
-		Avatar:   googleUser.Picture,
+		ID:            googleUser.ID,
+		Email:         googleUser.Email,
+		EmailVerified: googleUser.VerifiedEmail,
+		Username:      googleUser.Name,
+		Avatar:        googleUser.Picture,
 	}, nil
 }
 
@@ -173,12 +296,10 @@ func (s *oauth2Service) getGitHubUserInfo(client *http.Client) (*models.OAuth2Us
 		return nil, err
 	}
 
-	// If email is not public, get it from emails endpoint
-	if githubUser.Email == "" {
-		email, err := s.getGitHubUserEmail(client)
-		if err == nil {
-			githubUser.Email = email
-		}
+	// The profile's public email carries no verification status; the emails endpoint does
+	verified := false
+	if email, isVerified, err := s.getGitHubUserEmail(client); err == nil {
+		githubUser.Email, verified = email, isVerified
 	}
 
 	username := githubUser.Name
@@ -187,44 +308,47 @@ func (s *oauth2Service) getGitHubUserInfo(client *http.Client) (*models.OAuth2Us
 	}
 
 	return &models.OAuth2UserInfo{
-		ID:       fmt.Sprintf("%d", githubUser.ID),
-		Email:    githubUser.Email,
-		Username: username,
-		Avatar:   githubUser.AvatarURL,
+		ID:            fmt.Sprintf("%d", githubUser.ID),
+		Email:         githubUser.Email,
+		EmailVerified: verified,
+		Username:      username,
+		Avatar:        githubUser.AvatarURL,
 	}, nil
 }
 
-func (s *oauth2Service) getGitHubUserEmail(client *http.Client) (string, error) {
+// getGitHubUserEmail returns the user's primary email and whether GitHub verified it
+func (s *oauth2Service) getGitHubUserEmail(client *http.Client) (string, bool, error) {
 	resp, err := client.Get("https://api.github.com/user/emails")
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", errors.New("failed to get user emails from GitHub")
+		return "", false, errors.New("failed to get user emails from GitHub")
 	}
 
 	var emails []struct {
-		Email   string `json:"email"`
-		Primary bool   `json:"primary"`
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&emails); err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	for _, email := range emails {
 		if email.Primary {
-			return email.Email, nil
+			return email.Email, email.Verified, nil
 		}
 	}
 
 	if len(emails) > 0 {
-		return emails[0].Email, nil
+		return emails[0].Email, emails[0].Verified, nil
 	}
 
-	return "", errors.New("no email found")
+	return "", false, errors.New("no email found")
 }
 
 func (s *oauth2Service) getFacebookUserInfo(client *http.Client) (*models.OAuth2UserInfo, error) {
@@ -253,10 +377,12 @@ func (s *oauth2Service) getFacebookUserInfo(client *http.Client) (*models.OAuth2
 		return nil, err
 	}
 
+	// The Graph API only returns addresses the user has confirmed with Facebook
 	return &models.OAuth2UserInfo{
-		ID:       facebookUser.ID,
-		Email:    facebookUser.Email,
-		Username: facebookUser.Name,
-		Avatar:   facebookUser.Picture.Data.URL,
+		ID:            facebookUser.ID,
+		Email:         facebookUser.Email,
+		EmailVerified: facebookUser.Email != "",
+		Username:      facebookUser.Name,
+		Avatar:        facebookUser.Picture.Data.URL,
 	}, nil
 }
\ No newline at end of file
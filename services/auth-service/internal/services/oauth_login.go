@@ -0,0 +1,347 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// OAuth login errors; handlers map them to statuses with errors.Is
+var (
+	ErrOAuthEmailUnverified = errors.New("the provider did not share a verified email address")
+	ErrOAuthLoginFailed     = errors.New("OAuth login failed")
+	ErrOAuthLoginCode       = errors.New("login code is invalid or has expired")
+	ErrOAuthCodesDisabled   = errors.New("OAuth login codes are not configured")
+)
+
+// tokenPurposeOAuthLogin is the Redis purpose of the one-time codes OAuth callbacks redirect with
+const tokenPurposeOAuthLogin = "oauth_login_code"
+
+// oauthUsernameBaseLength leaves room in the 30 character username for a "_" and a 6 character suffix
+const oauthUsernameBaseLength = 23
+
+// maxAvatarURLLength matches the users.avatar_url column; longer provider URLs are dropped
+const maxAvatarURLLength = 500
+
+// OAuthLogin logs in with the provider identity from an OAuth callback, linking or creating the account
+// first when needed, and returns the same token pair as a password login
+func (s *authService) OAuthLogin(info *models.OAuth2UserInfo, client models.ClientInfo) (*models.AuthResponse, error) {
+	user, err := s.resolveOAuthUser(info, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.startOAuthSession(user, "", client)
+}
+
+// IssueOAuthLoginCode resolves the provider identity like OAuthLogin but returns a one-time code bound
+// to the caller's device instead of tokens, so tokens never appear in a redirect URL. The frontend
+// trades the code for tokens with ExchangeOAuthLoginCode within oauth2.code_ttl
+func (s *authService) IssueOAuthLoginCode(info *models.OAuth2UserInfo, client models.ClientInfo) (string, error) {
+	if s.tokenRepo == nil {
+		return "", ErrOAuthCodesDisabled
+	}
+
+	user, err := s.resolveOAuthUser(info, client)
+	if err != nil {
+		return "", err
+	}
+	// Refuse now rather than at the exchange, so the frontend shows the reason straight away
+	if err := s.checkOAuthLoginAllowed(user, client); err != nil {
+		return "", err
+	}
+
+	code, err := generateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	record := &repositories.OneTimeToken{
+		UserID:        user.ID,
+		Purpose:       tokenPurposeOAuthLogin,
+		IPHash:        hashDeviceAttribute(client.IPAddress),
+		UserAgentHash: hashDeviceAttribute(client.UserAgent),
+		IssuedAt:      time.Now(),
+	}
+	if err := s.tokenRepo.Issue(tokenPurposeOAuthLogin, s.jwtService.HashToken(code), record, s.oauth.CodeTTL); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// ExchangeOAuthLoginCode redeems a code from IssueOAuthLoginCode, once and from the device it was
+// issued to, and starts the session
+func (s *authService) ExchangeOAuthLoginCode(req *models.OAuthCodeExchangeRequest, client models.ClientInfo) (*models.AuthResponse, error) {
+	if s.tokenRepo == nil {
+		return nil, ErrOAuthCodesDisabled
+	}
+
+	record, err := s.consumeOneTimeToken(tokenPurposeOAuthLogin, req.Code, client)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOAuthLoginCode, err)
+	}
+
+	// The account may have been deactivated since the code was issued; GetByID only finds active ones
+	user, err := s.userRepo.GetByID(record.UserID)
+	if err != nil {
+		return nil, errors.New("account is inactive")
+	}
+	return s.startOAuthSession(user, req.ClientID, client)
+}
+
+// LinkOAuthAccount links the provider identity from a linking callback to the signed-in user who
+// started it. The user proved they own the account, so unlike linking by email nothing else changes
+func (s *authService) LinkOAuthAccount(userID uuid.UUID, info *models.OAuth2UserInfo, client models.ClientInfo) error {
+	if info.ID == "" {
+		return ErrOAuthLoginFailed
+	}
+	// GetByID only finds active accounts
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("account is inactive")
+	}
+
+	if err := s.userRepo.AttachOAuthIdentity(user.ID, info.Provider, info.ID); err != nil {
+		return err
+	}
+	s.recordOAuthActivity(user, "oauth_linked", "Linked "+info.Provider+" login", info, client)
+	return nil
+}
+
+// UnlinkOAuthAccount removes the provider identity from the user's account, unless it is the only way
+// left to log in
+func (s *authService) UnlinkOAuthAccount(userID uuid.UUID, provider string, client models.ClientInfo) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("account is inactive")
+	}
+
+	if err := s.userRepo.UnlinkOAuthIdentity(user.ID, provider); err != nil {
+		return err
+	}
+	s.recordOAuthActivity(user, "oauth_unlinked", "Unlinked "+provider+" login", &models.OAuth2UserInfo{Provider: provider}, client)
+	return nil
+}
+
+// resolveOAuthUser returns the account for a provider identity: the one already linked to it, else the
+// active account with the same (provider-verified) email, which gets linked, else a new account
+func (s *authService) resolveOAuthUser(info *models.OAuth2UserInfo, client models.ClientInfo) (*models.User, error) {
+	if info.ID == "" {
+		return nil, ErrOAuthLoginFailed
+	}
+
+	user, err := s.userRepo.GetByOAuthID(info.Provider, info.ID)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repositories.ErrOAuthIdentityNotFound) {
+		return nil, err
+	}
+
+	// Linking or creating by email trusts the provider's word that the caller owns the address
+	email := strings.ToLower(strings.TrimSpace(info.Email))
+	if email == "" || !info.EmailVerified {
+		return nil, ErrOAuthEmailUnverified
+	}
+
+	// Honeypot accounts fail like any refused login, so the caller can't tell it was detected
+	if s.tripHoneypot(models.HoneypotAccount, email, client) {
+		return nil, ErrOAuthLoginFailed
+	}
+
+	if user, err := s.userRepo.GetByEmail(email); err == nil {
+		return s.linkOAuthIdentity(user, info, client)
+	}
+	if registration, err := s.userRepo.GetRegistrationByEmail(email); err == nil {
+		return nil, registrationStatusError(registration)
+	}
+	taken, err := s.userRepo.IsEmailTaken(email)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, errors.New("account is inactive")
+	}
+	return s.createOAuthUser(info, email, client)
+}
+
+// linkOAuthIdentity links the provider identity to an existing account with the same email
+// If the account's email was never verified, its password, sessions and personal access tokens are
+// dropped: they may belong to whoever signed up with an address they don't own
+func (s *authService) linkOAuthIdentity(user *models.User, info *models.OAuth2UserInfo, client models.ClientInfo) (*models.User, error) {
+	secured, err := s.userRepo.LinkOAuthIdentity(user.ID, info.Provider, info.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := "Linked " + info.Provider + " login"
+	if secured {
+		userID := user.ID
+		if _, err := s.sessionRepo.RevokeMatchingSessions(models.SessionFilter{UserID: &userID}, 15*time.Minute); err != nil {
+			log.Printf("⚠️  Failed to revoke sessions of user %s after securing the account: %v", userID, err)
+		}
+		if _, err := s.userRepo.RevokeAllPersonalAccessTokens(userID); err != nil {
+			log.Printf("⚠️  Failed to revoke personal access tokens of user %s after securing the account: %v", userID, err)
+		}
+		description += "; the unverified email's password, sessions and personal access tokens were removed"
+	}
+	s.recordOAuthActivity(user, "oauth_linked", description, info, client)
+
+	// Reload for the verified email and, when secured, the new token version
+	return s.userRepo.GetByID(user.ID)
+}
+
+// createOAuthUser signs up a new account for the provider identity; it has no password until the
+// user sets one through a password reset. In approval mode the sign-up is queued instead
+func (s *authService) createOAuthUser(info *models.OAuth2UserInfo, email string, client models.ClientInfo) (*models.User, error) {
+	username, err := s.oauthUsername(info.Username, email)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:         email,
+		Username:      username,
+		Role:          models.RoleUser,
+		IsActive:      true,
+		EmailVerified: true,
+	}
+	if len(info.Avatar) <= maxAvatarURLLength {
+		user.AvatarURL = info.Avatar
+	}
+	user.SetOAuthID(info.Provider, info.ID)
+
+	if s.requiresApproval() {
+		if _, err := s.registerPending(user); err != nil {
+			return nil, err
+		}
+		return nil, ErrRegistrationPendingApproval
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	s.recordOAuthActivity(user, "oauth_account_created", "Account created with "+info.Provider+" login", info, client)
+	return user, nil
+}
+
+// checkOAuthLoginAllowed refuses accounts that are locked, deactivated or still awaiting approval,
+// recording the failed login attempt
+func (s *authService) checkOAuthLoginAllowed(user *models.User, client models.ClientInfo) error {
+	if user.CanAttemptLogin() {
+		return nil
+	}
+
+	s.userRepo.CreateLoginAttempt(s.oauthLoginAttempt(user, client))
+	if err := registrationStatusError(user); err != nil {
+		return err
+	}
+	if user.IsLocked() {
+		return errors.New("account is temporarily locked")
+	}
+	return errors.New("account is inactive")
+}
+
+// startOAuthSession applies the login checks a password login does, minus the password, and starts the session
+func (s *authService) startOAuthSession(user *models.User, clientID string, client models.ClientInfo) (*models.AuthResponse, error) {
+	if err := s.checkOAuthLoginAllowed(user, client); err != nil {
+		return nil, err
+	}
+	loginAttempt := s.oauthLoginAttempt(user, client)
+
+	policy, err := s.policies.Resolve(user.Email, clientID)
+	if err != nil {
+		return nil, err
+	}
+	user.Policy = policy
+
+	enrollment, err := s.twoFactorEnrollment(user)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment != nil && enrollment.Overdue {
+		s.userRepo.CreateLoginAttempt(loginAttempt)
+		return nil, ErrTwoFactorEnrollmentOverdue
+	}
+
+	authResponse, err := s.startSession(user, client, loginAttempt)
+	if err != nil {
+		return nil, err
+	}
+	authResponse.TwoFactorEnrollment = enrollment
+	return authResponse, nil
+}
+
+// oauthLoginAttempt starts the login attempt record for an OAuth login
+func (s *authService) oauthLoginAttempt(user *models.User, client models.ClientInfo) *models.LoginAttempt {
+	return &models.LoginAttempt{
+		Email:     user.Email,
+		IPAddress: s.ipPrivacy.Address(client.IPAddress),
+		IPHash:    s.ipPrivacy.Hash(client.IPAddress),
+		UserAgent: client.UserAgent,
+		RequestID: client.RequestID,
+		Success:   false,
+	}
+}
+
+// oauthUsername picks a free username from the provider's display name, or else the email's local part
+func (s *authService) oauthUsername(name, email string) (string, error) {
+	base := usernameFrom(name)
+	if len(base) < 3 {
+		local, _, _ := strings.Cut(email, "@")
+		base = usernameFrom(local)
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		taken, err := s.userRepo.IsUsernameTaken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		suffix, err := generateRandomToken(3)
+		if err != nil {
+			return "", err
+		}
+		candidate = base + "_" + suffix
+	}
+	return "", errors.New("failed to find a free username")
+}
+
+// usernameFrom lowercases a display name, turns spaces into underscores and keeps only ASCII letters,
+// digits and inner '.', '_' and '-', up to oauthUsernameBaseLength characters
+func usernameFrom(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if b.Len() == oauthUsernameBaseLength {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('_')
+		}
+	}
+	return strings.Trim(b.String(), "._-")
+}
+
+// recordOAuthActivity adds an OAuth account change to the user's activity feed
+func (s *authService) recordOAuthActivity(user *models.User, action, description string, info *models.OAuth2UserInfo, client models.ClientInfo) {
+	if err := s.LogUserActivity(user.ID, action, description, map[string]interface{}{
+		"provider":   info.Provider,
+		"request_id": client.RequestID,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record %s for user %s: %v", action, user.ID, err)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/discovery"
+	"auth-service/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// ErrOAuthProviderUnavailable means an OIDC provider's discovery document or keys haven't been fetched
+// yet or have gone stale; the login can be retried once discovery catches up
+var ErrOAuthProviderUnavailable = errors.New("OAuth provider is temporarily unavailable")
+
+// oidcSigningMethods are the ID token algorithms accepted from OIDC providers; never HMAC or none
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384"}
+
+// oidcProvider is a provider configured by its issuer: the endpoints come from its discovery document
+// and ID tokens are verified against its JWKS, both kept fresh by the discovery fetcher
+type oidcProvider struct {
+	name      string
+	cfg       config.OIDCProvider
+	discovery *discovery.Fetcher
+}
+
+func newOIDCProvider(name string, cfg config.OIDCProvider, fetcher *discovery.Fetcher) *oidcProvider {
+	provider := &oidcProvider{name: name, cfg: cfg, discovery: fetcher}
+	fetcher.RegisterOIDC(provider.discoveryName(), cfg.Issuer)
+	return provider
+}
+
+func (p *oidcProvider) discoveryName() string { return "oidc:" + p.name }
+func (p *oidcProvider) jwksName() string      { return "oidc:" + p.name + ":jwks" }
+
+// document returns the cached discovery document, checking it is the configured issuer's (OpenID
+// Connect Discovery 4.3). Its JWKS is registered on first use, well before the first callback needs it
+func (p *oidcProvider) document() (*discovery.OIDCConfiguration, error) {
+	doc, err := p.discovery.OIDC(p.discoveryName())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s discovery: %v", ErrOAuthProviderUnavailable, p.name, err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(p.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("%w: %s discovery document is for issuer %s", ErrOAuthProviderUnavailable, p.name, doc.Issuer)
+	}
+	if doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: %s discovery document has no token endpoint or jwks_uri", ErrOAuthProviderUnavailable, p.name)
+	}
+	p.discovery.RegisterJWKS(p.jwksName(), doc.JWKSURI)
+	return doc, nil
+}
+
+// oauthConfig builds the client configuration from the current discovery document
+func (p *oidcProvider) oauthConfig() (*oauth2.Config, error) {
+	doc, err := p.document()
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       p.cfg.Scopes,
+		Endpoint:     oauth2.Endpoint{AuthURL: doc.AuthorizationEndpoint, TokenURL: doc.TokenEndpoint},
+	}, nil
+}
+
+// oidcIDClaims are the standard claims mapped to OAuth2UserInfo
+type oidcIDClaims struct {
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"`
+	Name              string      `json:"name"`
+	PreferredUsername string      `json:"preferred_username"`
+	Picture           string      `json:"picture"`
+	jwt.RegisteredClaims
+}
+
+// userInfo verifies the ID token's signature against the provider's JWKS and its iss, aud and exp, then
+// maps the standard claims; the userinfo endpoint fills in an email the ID token doesn't carry
+func (p *oidcProvider) userInfo(config *oauth2.Config, token *oauth2.Token) (*models.OAuth2UserInfo, error) {
+	raw, _ := token.Extra("id_token").(string)
+	if raw == "" {
+		return nil, fmt.Errorf("%s returned no ID token", p.name)
+	}
+	doc, err := p.document()
+	if err != nil {
+		return nil, err
+	}
+
+	var claims oidcIDClaims
+	_, err = jwt.ParseWithClaims(raw, &claims, p.verificationKey,
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s ID token: %w", p.name, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%s ID token has no subject", p.name)
+	}
+
+	if claims.Email == "" && doc.UserinfoEndpoint != "" {
+		if err := p.fetchUserinfo(config, token, doc.UserinfoEndpoint, &claims); err != nil {
+			return nil, err
+		}
+	}
+
+	username := claims.Name
+	if username == "" {
+		username = claims.PreferredUsername
+	}
+	return &models.OAuth2UserInfo{
+		ID:            claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claimBool(claims.EmailVerified),
+		Username:      username,
+		Avatar:        claims.Picture,
+	}, nil
+}
+
+// verificationKey looks up the token's kid in the provider's JWKS
+func (p *oidcProvider) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	jwks, err := p.discovery.JWKS(p.jwksName())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s keys: %v", ErrOAuthProviderUnavailable, p.name, err)
+	}
+	key, ok := jwks.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("no %s key with kid %q", p.name, kid)
+	}
+	return key.PublicKey()
+}
+
+// fetchUserinfo reads email claims from the userinfo endpoint, which must describe the ID token's
+// subject (OpenID Connect Core 5.3.2)
+func (p *oidcProvider) fetchUserinfo(config *oauth2.Config, token *oauth2.Token, endpoint string, claims *oidcIDClaims) error {
+	resp, err := config.Client(context.Background(), token).Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get user info from %s", p.name)
+	}
+
+	var info struct {
+		Subject       string      `json:"sub"`
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return err
+	}
+	if info.Subject != claims.Subject {
+		return fmt.Errorf("%s userinfo is for a different subject", p.name)
+	}
+	claims.Email, claims.EmailVerified = info.Email, info.EmailVerified
+	return nil
+}
+
+// claimBool reads a boolean claim some providers send as the string "true"
+func claimBool(value interface{}) bool {
+	return value == true || value == "true"
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"auth-service/internal/config"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// argon2Params are the cost parameters of an Argon2id hash
+type argon2Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// PasswordHasher hashes new passwords with the algorithm of [security] and verifies stored hashes of
+// either algorithm, reporting those that should be rehashed: another algorithm or other cost parameters
+type PasswordHasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     argon2Params
+}
+
+// NewPasswordHasher builds the hasher from the security settings; unset values, as in tests that build
+// the service without a loaded config, fall back to bcrypt at its default cost
+func NewPasswordHasher(security config.SecurityConfig) PasswordHasher {
+	algorithm := security.PasswordHash
+	if algorithm == "" {
+		algorithm = config.PasswordHashBcrypt
+	}
+	bcryptCost := security.BcryptCost
+	if bcryptCost == 0 {
+		bcryptCost = bcrypt.DefaultCost
+	}
+	return PasswordHasher{
+		algorithm:  algorithm,
+		bcryptCost: bcryptCost,
+		argon2: argon2Params{
+			Memory:      uint32(security.Argon2Memory),
+			Iterations:  uint32(security.Argon2Iterations),
+			Parallelism: uint8(security.Argon2Parallelism),
+		},
+	}
+}
+
+// Hash hashes password with the configured algorithm
+func (h PasswordHasher) Hash(password string) (string, error) {
+	if h.algorithm == config.PasswordHashArgon2id {
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		return encodeArgon2id(h.argon2, salt, argon2id(password, salt, h.argon2, argon2KeyLength)), nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+	return string(hash), err
+}
+
+// Verify reports whether password matches hash, and when it does whether hash should be replaced by
+// Hash(password) because it was made with another algorithm or other cost parameters
+func (h PasswordHasher) Verify(password, hash string) (ok, needsRehash bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false, false
+		}
+		computed := argon2id(password, salt, params, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false
+		}
+		return true, h.algorithm != config.PasswordHashArgon2id || params != h.argon2
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, h.algorithm != config.PasswordHashBcrypt || err != nil || cost != h.bcryptCost
+}
+
+func argon2id(password string, salt []byte, params argon2Params, keyLength uint32) []byte {
+	return argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, keyLength)
+}
+
+// encodeArgon2id formats the hash as a PHC string, as other Argon2 implementations read it:
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key> with unpadded base64
+func encodeArgon2id(params argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("malformed argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil ||
+		params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, fmt.Errorf("malformed argon2id parameters %q", parts[3])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("malformed argon2id key")
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"auth-service/internal/config"
+)
+
+// ErrPasswordPolicy matches every *PasswordPolicyError with errors.Is
+var ErrPasswordPolicy = errors.New("password does not meet the password policy")
+
+// Password policy rules a PasswordViolation can name
+const (
+	PasswordRuleMinLength = "min_length"
+	PasswordRuleMaxLength = "max_length"
+	PasswordRuleUppercase = "uppercase"
+	PasswordRuleNumber    = "number"
+	PasswordRuleSpecial   = "special"
+	PasswordRuleBreached  = "breached" // Found by the breached password screening in block mode
+)
+
+const (
+	// defaultPasswordMinLength applies when security.password_min_length is unset
+	defaultPasswordMinLength = 8
+	// passwordMaxBytes is the most bcrypt hashes; longer passwords are refused rather than truncated
+	passwordMaxBytes = 72
+)
+
+// PasswordViolation is one rule of the password policy that a password breaks
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a rejected password breaks, so clients can show them all at once
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return "password " + strings.Join(messages, ", ")
+}
+
+// Is makes errors.Is(err, ErrPasswordPolicy) hold for policy errors
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicy
+}
+
+// PasswordPolicy holds the password_* rules of [security] that new passwords must satisfy
+type PasswordPolicy struct {
+	MinLength        int // In characters
+	RequireUppercase bool
+	RequireNumber    bool
+	RequireSpecial   bool // Anything but letters, digits and spaces
+}
+
+// NewPasswordPolicy builds the policy from the security settings
+func NewPasswordPolicy(security config.SecurityConfig) PasswordPolicy {
+	minLength := security.PasswordMinLength
+	if minLength <= 0 {
+		minLength = defaultPasswordMinLength
+	}
+	return PasswordPolicy{
+		MinLength:        minLength,
+		RequireUppercase: security.PasswordRequireUppercase,
+		RequireNumber:    security.PasswordRequireNumber,
+		RequireSpecial:   security.PasswordRequireSpecial,
+	}
+}
+
+// Validate returns a *PasswordPolicyError listing every rule password breaks, or nil
+func (p PasswordPolicy) Validate(password string) error {
+	var hasUpper, hasNumber, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasNumber = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+
+	var violations []PasswordViolation
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMinLength,
+			Message: fmt.Sprintf("must be at least %d characters", p.MinLength),
+		})
+	}
+	if len(password) > passwordMaxBytes {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMaxLength,
+			Message: fmt.Sprintf("must be at most %d bytes", passwordMaxBytes),
+		})
+	}
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, PasswordViolation{Rule: PasswordRuleUppercase, Message: "must contain an uppercase letter"})
+	}
+	if p.RequireNumber && !hasNumber {
+		violations = append(violations, PasswordViolation{Rule: PasswordRuleNumber, Message: "must contain a number"})
+	}
+	if p.RequireSpecial && !hasSpecial {
+		violations = append(violations, PasswordViolation{Rule: PasswordRuleSpecial, Message: "must contain a special character"})
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"auth-service/internal/webpush"
+
+	"github.com/google/uuid"
+)
+
+// Push subscription errors; handlers map them to statuses with errors.Is
+var (
+	ErrWebPushDisabled          = errors.New("push notifications are not enabled")
+	ErrPushEndpointNotAllowed   = errors.New("push subscriptions must use a supported browser push service")
+	ErrTooManyPushSubscriptions = errors.New("too many devices are subscribed to push notifications; unsubscribe one first")
+)
+
+// pushDispatchTimeout bounds pushing one notification to all of a user's subscriptions
+const pushDispatchTimeout = time.Minute
+
+// ListPushSubscriptions returns the user's push subscriptions and the VAPID key browsers subscribe with
+func (s *authService) ListPushSubscriptions(userID uuid.UUID) (*models.PushSubscriptionsResponse, error) {
+	if s.webPush == nil {
+		return nil, ErrWebPushDisabled
+	}
+	subscriptions, err := s.userRepo.ListPushSubscriptions(userID)
+	if err != nil {
+		return nil, err
+	}
+	if subscriptions == nil {
+		subscriptions = []models.PushSubscription{}
+	}
+	return &models.PushSubscriptionsResponse{VAPIDPublicKey: s.webPush.PublicKey(), Subscriptions: subscriptions}, nil
+}
+
+// SubscribePush registers the browser of req for the user's notifications
+// A browser subscribing again, or after another user signed in on it, replaces its earlier subscription
+func (s *authService) SubscribePush(userID uuid.UUID, req *models.PushSubscriptionRequest, client models.ClientInfo) (*models.PushSubscription, error) {
+	if s.webPush == nil {
+		return nil, ErrWebPushDisabled
+	}
+	target := webpush.Subscription{Endpoint: req.Endpoint, P256dh: req.Keys.P256dh, Auth: req.Keys.Auth}
+	if err := target.Validate(); err != nil {
+		return nil, err
+	}
+	if !s.webPush.AllowsEndpoint(req.Endpoint) {
+		return nil, ErrPushEndpointNotAllowed
+	}
+
+	existing, err := s.userRepo.ListPushSubscriptions(userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.webPushConfig.MaxPerUser > 0 && len(existing) >= s.webPushConfig.MaxPerUser {
+		resubscribing := false
+		for _, subscription := range existing {
+			resubscribing = resubscribing || subscription.Endpoint == req.Endpoint
+		}
+		if !resubscribing {
+			return nil, ErrTooManyPushSubscriptions
+		}
+	}
+
+	subscription := &models.PushSubscription{
+		UserID:    userID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: client.UserAgent,
+	}
+	if req.ExpirationTime != nil {
+		expiresAt := time.UnixMilli(*req.ExpirationTime)
+		subscription.ExpiresAt = &expiresAt
+	}
+	if err := s.userRepo.SavePushSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// UnsubscribePush removes one of the user's push subscriptions
+func (s *authService) UnsubscribePush(userID, subscriptionID uuid.UUID) error {
+	return s.userRepo.DeletePushSubscription(userID, subscriptionID)
+}
+
+// pushPayload is the JSON a service worker receives in its push event
+type pushPayload struct {
+	ID         uuid.UUID `json:"id"`
+	Type       string    `json:"type"`
+	Title      string    `json:"title"`
+	Message    string    `json:"message,omitempty"`
+	ActionURL  string    `json:"action_url,omitempty"`
+	ActionText string    `json:"action_text,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// pushNotification pushes a new notification to every browser the user subscribed, in the background,
+// unless the user turned push notifications off. Subscriptions the push service reports gone, or that
+// have expired, are deleted
+func (s *authService) pushNotification(notification *models.UserNotification) {
+	if s.webPush == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), pushDispatchTimeout)
+		defer cancel()
+
+		prefs, err := s.userRepo.GetUserPreferences(notification.UserID)
+		if err != nil && !errors.Is(err, repositories.ErrUserPreferencesNotFound) {
+			log.Printf("⚠️  Failed to check push preferences of user %s: %v", notification.UserID, err)
+			return
+		}
+		if prefs != nil && !prefs.PushNotifications { // Push is on until preferences are saved
+			return
+		}
+		subscriptions, err := s.userRepo.ListPushSubscriptions(notification.UserID)
+		if err != nil {
+			log.Printf("⚠️  Failed to list push subscriptions of user %s: %v", notification.UserID, err)
+			return
+		}
+		if len(subscriptions) == 0 {
+			return
+		}
+
+		payload, err := encodePushPayload(notification)
+		if err != nil {
+			log.Printf("⚠️  Failed to encode push notification %s: %v", notification.ID, err)
+			return
+		}
+		for _, subscription := range subscriptions {
+			if subscription.ExpiresAt != nil && time.Now().After(*subscription.ExpiresAt) {
+				s.prunePushSubscription(subscription, "expired")
+				continue
+			}
+			err := s.webPush.Send(ctx, webpush.Subscription{
+				Endpoint: subscription.Endpoint,
+				P256dh:   subscription.P256dh,
+				Auth:     subscription.Auth,
+			}, payload)
+			switch {
+			case errors.Is(err, webpush.ErrSubscriptionGone), errors.Is(err, webpush.ErrInvalidSubscription):
+				s.prunePushSubscription(subscription, err.Error())
+			case err != nil:
+				log.Printf("⚠️  Failed to push notification %s to subscription %s: %v", notification.ID, subscription.ID, err)
+			}
+		}
+	}()
+}
+
+// encodePushPayload encodes the notification, leaving out the message when it doesn't fit in a push;
+// the service worker can fetch it from /notifications
+func encodePushPayload(notification *models.UserNotification) ([]byte, error) {
+	payload := pushPayload{
+		ID:         notification.ID,
+		Type:       notification.Type,
+		Title:      notification.Title,
+		Message:    notification.Message,
+		ActionURL:  notification.ActionURL,
+		ActionText: notification.ActionText,
+		CreatedAt:  notification.CreatedAt,
+	}
+	data, err := json.Marshal(payload)
+	if err != nil || len(data) <= webpush.MaxPayload {
+		return data, err
+	}
+	payload.Message = ""
+	data, err = json.Marshal(payload)
+	if err == nil && len(data) > webpush.MaxPayload {
+		return nil, webpush.ErrPayloadTooLarge
+	}
+	return data, err
+}
+
+func (s *authService) prunePushSubscription(subscription models.PushSubscription, reason string) {
+	if err := s.userRepo.DeletePushSubscriptionByEndpoint(subscription.Endpoint); err != nil {
+		log.Printf("⚠️  Failed to delete push subscription %s of user %s: %v", subscription.ID, subscription.UserID, err)
+		return
+	}
+	log.Printf("🧹 Deleted push subscription %s of user %s: %s", subscription.ID, subscription.UserID, reason)
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"auth-service/internal/models"
+)
+
+// ErrRefreshOriginNotAllowed means a refresh from the refresh cookie came from a page that may not use it
+var ErrRefreshOriginNotAllowed = errors.New("refresh from this origin is not allowed")
+
+// Sec-Fetch-Site values of requests made by a page on the auth service's own site
+const (
+	fetchSiteSameOrigin = "same-origin"
+	fetchSiteSameSite   = "same-site"
+	fetchSiteNone       = "none" // Typed URL, bookmark or other user-initiated navigation
+)
+
+// refreshOriginAllowed reports whether a browser request may refresh from the refresh cookie:
+// first-party apps in jwt.refresh_cookie.allowed_origins from the same site, or the web origins
+// registered for the token's client in its security policy from anywhere
+func (s *authService) refreshOriginAllowed(clientID string, browser *models.BrowserContext) bool {
+	if browser.Origin == "" || browser.FetchSite == fetchSiteNone {
+		return false
+	}
+
+	// Browsers that predate fetch metadata send no Sec-Fetch-Site; the Origin check still applies
+	sameSite := browser.FetchSite == "" || browser.FetchSite == fetchSiteSameOrigin || browser.FetchSite == fetchSiteSameSite
+	if sameSite {
+		for _, origin := range s.refreshCookie.AllowedOrigins {
+			if strings.EqualFold(origin, browser.Origin) {
+				return true
+			}
+		}
+	}
+	return clientID != "" && s.policies.AllowsOrigin(clientID, browser.Origin)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+	"shared/events"
+	"shared/middleware"
+)
+
+// ErrRefreshTokenReused means an already rotated refresh token was presented again; the token may have
+// been stolen, so its whole family has been revoked
+var ErrRefreshTokenReused = errors.New("refresh token was already used: this login has been ended, please log in again")
+
+// ErrRefreshFamilyRevoked means the refresh token belongs to a family ended by reuse detection
+var ErrRefreshFamilyRevoked = errors.New("refresh token family was revoked: please log in again")
+
+// Lifetimes used when the policy of a revoked family's login can no longer be resolved
+const (
+	fallbackRefreshExpiry = 30 * 24 * time.Hour
+	fallbackAccessExpiry  = 15 * time.Minute
+)
+
+// checkRefreshFamily rejects a refresh token whose family was revoked, or that was already rotated
+// A rotated token being replayed means two parties hold the family, so the family is revoked
+func (s *authService) checkRefreshFamily(tokenHash string, claims *middleware.JWTClaims, client models.ClientInfo) error {
+	if claims.FamilyID != "" {
+		revoked, err := s.sessionRepo.IsRefreshFamilyRevoked(claims.FamilyID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrRefreshFamilyRevoked
+		}
+	}
+
+	// Tokens without a family ID were rotated into a new family, which the rotated token points to
+	familyID, err := s.sessionRepo.GetRotatedRefreshTokenFamily(tokenHash)
+	if err != nil || familyID == "" {
+		return err
+	}
+	s.revokeReusedFamily(familyID, claims, client)
+	return ErrRefreshTokenReused
+}
+
+// revokeReusedFamily ends the family of a reused refresh token, records it in the user's activity and
+// publishes a TokenRevoked event. Failures are logged: the reused token is refused either way
+func (s *authService) revokeReusedFamily(familyID string, claims *middleware.JWTClaims, client models.ClientInfo) {
+	refreshExpiry, accessExpiry := fallbackRefreshExpiry, fallbackAccessExpiry
+	if policy, err := s.policies.Resolve(claims.Email, claims.ClientID); err == nil {
+		if claims.RememberMe {
+			policy = policy.Remembered()
+		}
+		refreshExpiry, accessExpiry = policy.RefreshExpiry, policy.AccessExpiry
+	}
+
+	revoked, err := s.sessionRepo.RevokeRefreshFamily(familyID, refreshExpiry, accessExpiry)
+	if err != nil {
+		log.Printf("❌ Failed to revoke refresh token family %s of user %s: %v", familyID, claims.UserID, err)
+	}
+	log.Printf("🚨 Reuse of a rotated refresh token of user %s; family %s revoked (%d tokens)",
+		claims.UserID, familyID, revoked)
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return
+	}
+	if err := s.LogUserActivity(userID, "refresh_token_reused", "A refresh token was used twice; the login it belonged to was ended", map[string]interface{}{
+		"family_id":  familyID,
+		"ip_address": s.ipPrivacy.Address(client.IPAddress),
+		"user_agent": client.UserAgent,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record refresh token reuse for user %s: %v", userID, err)
+	}
+	s.auditSecurityEvent("refresh_token_reused", &userID, client, map[string]interface{}{
+		"family_id":      familyID,
+		"revoked_tokens": revoked,
+	})
+
+	if s.events == nil {
+		return
+	}
+	event := events.NewAuthEvent(events.TokenRevoked, "auth-service", claims.UserID, "", map[string]interface{}{
+		"reason":     "refresh_token_reuse",
+		"family_id":  familyID,
+		"token_type": "refresh",
+	})
+	if err := s.events.Publish(context.Background(), event); err != nil {
+		log.Printf("⚠️  Failed to publish token revocation for user %s: %v", userID, err)
+	}
+}
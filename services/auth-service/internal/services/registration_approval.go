@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"auth-service/internal/config"
+	"auth-service/internal/mail"
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Registration approval errors; handlers map them to statuses with errors.Is
+// They are only returned to callers that proved the password, so they don't reveal queued addresses
+var (
+	ErrRegistrationPendingApproval = errors.New("account is pending approval")
+	ErrRegistrationRejected        = errors.New("registration was rejected")
+)
+
+// requiresApproval reports whether new sign-ups wait in the admin review queue
+func (s *authService) requiresApproval() bool {
+	return s.registration.Mode == config.RegistrationApproval
+}
+
+// registerPending queues a sign-up for admin approval instead of issuing tokens
+func (s *authService) registerPending(user *models.User) (*models.AuthResponse, error) {
+	if err := s.userRepo.CreatePendingRegistration(user); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📝 Registration of %s queued for approval", user.Email)
+	return &models.AuthResponse{
+		User:           registrationUserInfo(user),
+		ApprovalStatus: models.ApprovalPending,
+	}, nil
+}
+
+// queuedRegistrationError explains a failed login for a sign-up that is still queued or was rejected
+// It returns nil unless the email belongs to such a sign-up and the password matches
+func (s *authService) queuedRegistrationError(email, password string) error {
+	user, err := s.userRepo.GetRegistrationByEmail(email)
+	if err != nil || !s.verifyPassword(password, user.PasswordHash) {
+		return nil
+	}
+	return registrationStatusError(user)
+}
+
+// registrationStatusError returns the error for an account that is still queued for approval or was
+// rejected, and nil for any other account
+func registrationStatusError(user *models.User) error {
+	if user.IsActive || user.ApprovalStatus == nil {
+		return nil
+	}
+	switch *user.ApprovalStatus {
+	case models.ApprovalRejected:
+		return ErrRegistrationRejected
+	case models.ApprovalPending:
+		return ErrRegistrationPendingApproval
+	}
+	return nil
+}
+
+// ListPendingRegistrations returns the review queue, oldest sign-up first
+func (s *authService) ListPendingRegistrations(limit, offset int) (*models.AdminRegistrationQueueResponse, error) {
+	users, total, err := s.userRepo.ListPendingRegistrations(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.AdminRegistrationQueueResponse{
+		Registrations: make([]models.UserInfo, 0, len(users)),
+		Total:         total,
+		Limit:         limit,
+		Offset:        offset,
+	}
+	for i := range users {
+		resp.Registrations = append(resp.Registrations, registrationUserInfo(&users[i]))
+	}
+	return resp, nil
+}
+
+// ApproveRegistration activates a queued account and tells the applicant they can sign in
+func (s *authService) ApproveRegistration(adminID, userID uuid.UUID) (*models.AdminRegistrationReviewResponse, error) {
+	return s.reviewRegistration(adminID, userID, models.ApprovalApproved, "")
+}
+
+// RejectRegistration closes a queued sign-up; the account stays inactive and the applicant is notified
+func (s *authService) RejectRegistration(adminID, userID uuid.UUID, reason string) (*models.AdminRegistrationReviewResponse, error) {
+	return s.reviewRegistration(adminID, userID, models.ApprovalRejected, reason)
+}
+
+func (s *authService) reviewRegistration(adminID, userID uuid.UUID, status, reason string) (*models.AdminRegistrationReviewResponse, error) {
+	user, err := s.userRepo.ReviewRegistration(userID, adminID, status)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("📝 Admin %s %s the registration of %s", adminID, status, user.Email)
+
+	metadata := map[string]interface{}{"admin_id": adminID.String()}
+	if reason != "" {
+		metadata["reason"] = reason
+	}
+	if err := s.LogUserActivity(user.ID, "registration_"+status, "Registration "+status+" by an administrator", metadata); err != nil {
+		log.Printf("⚠️  Failed to record registration review for user %s: %v", user.ID, err)
+	}
+
+	resp := &models.AdminRegistrationReviewResponse{User: registrationUserInfo(user), Status: status}
+	if s.mailer == nil {
+		log.Printf("⚠️  No mailer configured, registration %s email for %s was not sent", status, user.Email)
+		return resp, nil
+	}
+	if err := s.mailer.Send(s.registrationReviewEmail(user, status, reason)); err != nil {
+		log.Printf("⚠️  Failed to send registration %s email to %s: %v", status, user.Email, err)
+		return resp, nil
+	}
+	resp.EmailSent = true
+	return resp, nil
+}
+
+func (s *authService) registrationReviewEmail(user *models.User, status, reason string) mail.Message {
+	name := user.FirstName
+	if name == "" {
+		name = user.Username
+	}
+
+	if status == models.ApprovalApproved {
+		link := strings.TrimRight(s.linkBaseURL, "/") + "/login"
+		return mail.Message{
+			To:      user.Email,
+			Subject: "Your account has been approved",
+			Body: fmt.Sprintf("Hi %s,\n\n"+
+				"Your registration was approved. You can now sign in with the email address and password you registered with:\n\n"+
+				"%s\n",
+				name, link),
+		}
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nThank you for your interest. Unfortunately your registration was not approved.\n", name)
+	if reason != "" {
+		body += fmt.Sprintf("\nReason: %s\n", reason)
+	}
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Your registration was not approved",
+		Body:    body,
+	}
+}
+
+// registrationUserInfo is the public view of a queued or reviewed sign-up
+func registrationUserInfo(user *models.User) models.UserInfo {
+	return models.UserInfo{
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		Username:      user.Username,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Role:          user.Role,
+		IsActive:      user.IsActive,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/repositories"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Revocation snapshot errors; handlers map them to statuses with errors.Is
+var (
+	ErrRevocationSnapshotDisabled = errors.New("refresh token revocation snapshots are not enabled")
+	ErrRevocationSnapshotNotReady = errors.New("no current revocation snapshot; it hasn't been built yet or the last rebuilds failed")
+)
+
+// RevocationSnapshotType is the type claim of revocation snapshots, which keeps them from being
+// accepted as access tokens although the same key signs both
+const RevocationSnapshotType = "refresh_revocations"
+
+// RevocationSnapshots periodically rebuilds the list of revoked refresh token hashes edge gateways poll
+// Snapshots are JWTs signed with the active access token key, verifiable with /.well-known/jwks.json:
+//
+//	{"type": "refresh_revocations", "ver": 42, "full": true, "revoked": [["<sha256 hex>", <exp>], ...]}
+//
+// ver is the last revocation sequence number included; a delta (full false, with since) lists only the
+// revocations recorded after since. Entries are sorted by hash, and edges drop each one at its exp
+type RevocationSnapshots struct {
+	log    *repositories.RefreshRevocationLog
+	keys   *SigningKeys
+	config config.RevocationSnapshotConfig
+	issuer string
+
+	mu      sync.RWMutex
+	current *revocationSnapshot
+}
+
+// revocationSnapshot is one rebuild of the list
+type revocationSnapshot struct {
+	version     int64
+	builtAt     time.Time
+	revocations []repositories.RefreshRevocation // Sorted by token hash
+	signed      string                           // The full snapshot
+}
+
+// RevocationSnapshot is a signed snapshot or delta as served to edges
+type RevocationSnapshot struct {
+	Token      string // Compact JWS
+	Version    int64
+	Full       bool
+	FreshUntil time.Time // When the next rebuild is due
+}
+
+// NewRevocationSnapshots creates the snapshot builder; revocationLog is nil while revocation_snapshot is disabled
+func NewRevocationSnapshots(revocationLog *repositories.RefreshRevocationLog, keys *SigningKeys, cfg config.RevocationSnapshotConfig, issuer string) *RevocationSnapshots {
+	return &RevocationSnapshots{log: revocationLog, keys: keys, config: cfg, issuer: issuer}
+}
+
+// Start builds the snapshot immediately and then every revocation_snapshot.refresh_interval until ctx is
+// cancelled. It does nothing unless revocation_snapshot.enabled is set
+func (s *RevocationSnapshots) Start(ctx context.Context) {
+	if !s.config.Enabled || s.log == nil {
+		return
+	}
+
+	go func() {
+		s.rebuild(ctx)
+
+		ticker := time.NewTicker(s.config.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.rebuild(ctx)
+			}
+		}
+	}()
+}
+
+// rebuild reads the revocation log and signs a new full snapshot; on failure the previous snapshot is
+// served until it expires
+func (s *RevocationSnapshots) rebuild(ctx context.Context) {
+	revocations, version, err := s.log.List(ctx)
+	if err != nil {
+		log.Printf("❌ Failed to rebuild the refresh token revocation snapshot: %v", err)
+		return
+	}
+	now := time.Now()
+	signed, err := s.sign(revocations, version, 0, now)
+	if err != nil {
+		log.Printf("❌ Failed to sign the refresh token revocation snapshot: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.current = &revocationSnapshot{version: version, builtAt: now, revocations: revocations, signed: signed}
+	s.mu.Unlock()
+}
+
+// Snapshot returns the latest full snapshot, or with since > 0 only the revocations recorded after
+// version since. A since newer than the latest version, as after the log was reset, returns the full
+// snapshot so the edge starts over
+func (s *RevocationSnapshots) Snapshot(since int64) (*RevocationSnapshot, error) {
+	if !s.config.Enabled || s.log == nil {
+		return nil, ErrRevocationSnapshotDisabled
+	}
+	s.mu.RLock()
+	current := s.current
+	s.mu.RUnlock()
+	if current == nil || time.Since(current.builtAt) >= s.config.MaxAge {
+		return nil, ErrRevocationSnapshotNotReady
+	}
+
+	freshUntil := current.builtAt.Add(s.config.RefreshInterval)
+	if since <= 0 || since > current.version {
+		return &RevocationSnapshot{Token: current.signed, Version: current.version, Full: true, FreshUntil: freshUntil}, nil
+	}
+	var delta []repositories.RefreshRevocation
+	for _, revocation := range current.revocations {
+		if revocation.Sequence > since {
+			delta = append(delta, revocation)
+		}
+	}
+	signed, err := s.sign(delta, current.version, since, current.builtAt)
+	if err != nil {
+		return nil, err
+	}
+	return &RevocationSnapshot{Token: signed, Version: current.version, FreshUntil: freshUntil}, nil
+}
+
+// sign encodes revocations as snapshot claims; since 0 makes a full snapshot
+// Snapshots expire max_age after their rebuild, so an edge whose polls fail stops trusting a stale list
+func (s *RevocationSnapshots) sign(revocations []repositories.RefreshRevocation, version, since int64, builtAt time.Time) (string, error) {
+	revoked := make([][]interface{}, len(revocations))
+	for i, revocation := range revocations {
+		revoked[i] = []interface{}{revocation.TokenHash, revocation.ExpiresAt.Unix()}
+	}
+	claims := jwt.MapClaims{
+		"iss":     s.issuer,
+		"iat":     builtAt.Unix(),
+		"exp":     builtAt.Add(s.config.MaxAge).Unix(),
+		"type":    RevocationSnapshotType,
+		"ver":     version,
+		"full":    since == 0,
+		"revoked": revoked,
+	}
+	if since > 0 {
+		claims["since"] = since
+	}
+	return s.keys.Sign(claims)
+}
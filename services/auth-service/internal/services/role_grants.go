@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Role grant errors; handlers map them to statuses with errors.Is
+var (
+	ErrInvalidGrantDuration = errors.New("grant duration must be positive and within role_grants.max_duration")
+	ErrSelfRoleGrant        = errors.New("administrators can't grant roles to themselves")
+	ErrRoleAlreadyHeld      = errors.New("user already has this role")
+)
+
+// GrantRole gives a user an extra role until the requested duration elapses
+// Tokens issued while the grant is active carry the role and never outlive the grant
+func (s *authService) GrantRole(adminID, userID uuid.UUID, req *models.AdminGrantRoleRequest) (*models.RoleGrant, error) {
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 || duration > s.roleGrants.MaxDuration {
+		return nil, ErrInvalidGrantDuration
+	}
+	if adminID == userID {
+		return nil, ErrSelfRoleGrant
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == req.Role {
+		return nil, ErrRoleAlreadyHeld
+	}
+
+	grant := &models.RoleGrant{
+		UserID:    userID,
+		Role:      req.Role,
+		Reason:    req.Reason,
+		GrantedBy: &adminID,
+		ExpiresAt: time.Now().Add(duration),
+	}
+	if err := s.userRepo.CreateRoleGrant(grant); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🚨 Admin %s granted role %s to user %s until %s (reason: %s)",
+		adminID, grant.Role, userID, grant.ExpiresAt.Format(time.RFC3339), grant.Reason)
+	s.auditRoleGrant(grant, "role_granted", fmt.Sprintf("Role %s granted until %s", grant.Role, grant.ExpiresAt.Format(time.RFC3339)),
+		map[string]interface{}{"granted_by": adminID.String(), "reason": grant.Reason})
+	return grant, nil
+}
+
+// RevokeRoleGrant ends a grant early; access tokens issued before the revocation stop working
+func (s *authService) RevokeRoleGrant(adminID, grantID uuid.UUID, reason string) (*models.RoleGrant, error) {
+	grant, err := s.userRepo.EndRoleGrant(grantID, &adminID, models.GrantEndRevoked)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🚨 Admin %s revoked role %s from user %s (grant %s, reason: %s)", adminID, grant.Role, grant.UserID, grant.ID, reason)
+	metadata := map[string]interface{}{"revoked_by": adminID.String()}
+	if reason != "" {
+		metadata["reason"] = reason
+	}
+	s.auditRoleGrant(grant, "role_grant_revoked", fmt.Sprintf("Role %s revoked by an administrator", grant.Role), metadata)
+	return grant, nil
+}
+
+// ListRoleGrants returns role grants, newest first; uuid.Nil lists the grants of every user
+func (s *authService) ListRoleGrants(userID uuid.UUID, activeOnly bool, limit, offset int) (*models.AdminRoleGrantListResponse, error) {
+	grants, total, err := s.userRepo.ListRoleGrants(userID, activeOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if grants == nil {
+		grants = []models.RoleGrant{}
+	}
+	return &models.AdminRoleGrantListResponse{Grants: grants, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// ExpireRoleGrants ends every grant past its expiry and revokes the tokens of their holders
+func (s *authService) ExpireRoleGrants() (int, error) {
+	grants, err := s.userRepo.EndExpiredRoleGrants(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range grants {
+		grant := &grants[i]
+		log.Printf("📝 Role %s of user %s expired (grant %s)", grant.Role, grant.UserID, grant.ID)
+		s.auditRoleGrant(grant, "role_grant_expired", fmt.Sprintf("Role %s expired", grant.Role), nil)
+	}
+	return len(grants), nil
+}
+
+// TokenVersion returns the user's current token version; access tokens with an older version are revoked
+func (s *authService) TokenVersion(userID uuid.UUID) (int, error) {
+	return s.userRepo.GetTokenVersion(userID)
+}
+
+// loadRoleGrants adds the user's active grants, assigned roles and permissions to user so the next tokens carry them
+func (s *authService) loadRoleGrants(user *models.User) error {
+	grants, err := s.userRepo.GetActiveRoleGrants(user.ID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	user.GrantedRoles = nil
+	user.GrantsExpireAt = nil
+	systemRoles := []string{string(user.Role)}
+	for i := range grants {
+		user.GrantedRoles = append(user.GrantedRoles, grants[i].Role)
+		systemRoles = append(systemRoles, string(grants[i].Role))
+		if user.GrantsExpireAt == nil || grants[i].ExpiresAt.Before(*user.GrantsExpireAt) {
+			user.GrantsExpireAt = &grants[i].ExpiresAt
+		}
+	}
+
+	// Assigned custom roles and the permissions of every role the user holds go into the token too
+	user.AssignedRoles, user.Permissions, err = s.userRepo.GetTokenRoles(user.ID, systemRoles)
+	return err
+}
+
+// auditRoleGrant records a grant event in the activity log of the user holding the grant
+func (s *authService) auditRoleGrant(grant *models.RoleGrant, action, description string, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["grant_id"] = grant.ID.String()
+	metadata["role"] = string(grant.Role)
+	metadata["expires_at"] = grant.ExpiresAt.UTC().Format(time.RFC3339)
+
+	if err := s.LogUserActivity(grant.UserID, action, description, metadata); err != nil {
+		log.Printf("⚠️  Failed to record %s for user %s: %v", action, grant.UserID, err)
+	}
+}
+
+// RoleGrantExpirer ends expired role grants in the background
+type RoleGrantExpirer struct {
+	service  AuthService
+	interval time.Duration
+}
+
+// NewRoleGrantExpirer creates an expirer that checks for expired grants every interval
+func NewRoleGrantExpirer(service AuthService, interval time.Duration) *RoleGrantExpirer {
+	return &RoleGrantExpirer{service: service, interval: interval}
+}
+
+// Start ends expired grants immediately and then every interval until ctx is cancelled
+// Replicas may all run it; each expired grant is ended by exactly one of them
+func (e *RoleGrantExpirer) Start(ctx context.Context) {
+	go func() {
+		e.expire()
+
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.expire()
+			}
+		}
+	}()
+}
+
+func (e *RoleGrantExpirer) expire() {
+	if _, err := e.service.ExpireRoleGrants(); err != nil {
+		log.Printf("❌ Failed to expire role grants: %v", err)
+	}
+}
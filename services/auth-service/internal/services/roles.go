@@ -0,0 +1,360 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// Role and permission errors; handlers map them to statuses with errors.Is
+var (
+	ErrRoleName          = errors.New("role names are 2-50 lowercase letters, digits, hyphens and underscores, starting with a letter")
+	ErrRoleExists        = errors.New("a role with this name already exists")
+	ErrSystemRole        = errors.New("system roles follow users.role; they can't be deleted or assigned")
+	ErrPermissionName    = errors.New("permission resources and actions are 1-50 lowercase letters, digits, hyphens and underscores, starting with a letter")
+	ErrPermissionExists  = errors.New("this permission already exists")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// roleName is the form of role names, e.g. support-agent
+var roleName = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// permissionPart is the form of the resource and action of a permission, e.g. users and read
+var permissionPart = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// ListRoles returns every role with its permissions, highest priority first
+func (s *authService) ListRoles() ([]models.Role, error) {
+	roles, err := s.userRepo.ListRoles()
+	if err != nil {
+		return nil, err
+	}
+	if roles == nil {
+		roles = []models.Role{}
+	}
+	return roles, nil
+}
+
+// GetRole returns a role with its permissions
+func (s *authService) GetRole(roleID uuid.UUID) (*models.Role, error) {
+	return s.userRepo.GetRole(roleID)
+}
+
+// CreateRole creates a custom role with the named permissions
+func (s *authService) CreateRole(adminID uuid.UUID, req *models.AdminCreateRoleRequest) (*models.Role, error) {
+	if !roleName.MatchString(req.Name) {
+		return nil, ErrRoleName
+	}
+	if _, err := s.userRepo.GetRoleByName(req.Name); err == nil {
+		return nil, ErrRoleExists
+	} else if !errors.Is(err, repositories.ErrRoleNotFound) {
+		return nil, err
+	}
+	permissionIDs, err := s.permissionIDs(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Description: strings.TrimSpace(req.Description),
+		Priority:    req.Priority,
+	}
+	if err := s.userRepo.CreateRole(role, permissionIDs, &adminID); err != nil {
+		return nil, err
+	}
+
+	created, err := s.userRepo.GetRole(role.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.recordRoleChange(adminID, "role_created", "Role "+created.Name+" created", created)
+	return created, nil
+}
+
+// UpdateRole changes a role's display name, description or priority; system roles included
+func (s *authService) UpdateRole(adminID, roleID uuid.UUID, req *models.AdminUpdateRoleRequest) (*models.Role, error) {
+	updates := make(map[string]interface{})
+	if req.DisplayName != nil {
+		updates["display_name"] = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Description != nil {
+		updates["description"] = strings.TrimSpace(*req.Description)
+	}
+	if req.Priority != nil {
+		updates["priority"] = *req.Priority
+	}
+	if len(updates) == 0 {
+		return s.userRepo.GetRole(roleID)
+	}
+
+	role, err := s.userRepo.UpdateRole(roleID, updates)
+	if err != nil {
+		return nil, err
+	}
+	s.recordRoleChange(adminID, "role_updated", "Role "+role.Name+" updated", role)
+	return role, nil
+}
+
+// DeleteRole deletes a custom role; the users who held it lose it and their tokens are revoked
+func (s *authService) DeleteRole(adminID, roleID uuid.UUID) error {
+	role, err := s.userRepo.GetRole(roleID)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrSystemRole
+	}
+	if _, err := s.userRepo.DeleteRole(roleID); err != nil {
+		return err
+	}
+
+	log.Printf("🚨 Admin %s deleted role %s", adminID, role.Name)
+	s.recordRoleChange(adminID, "role_deleted", "Role "+role.Name+" deleted", role)
+	return nil
+}
+
+// SetRolePermissions replaces a role's permissions with the named ones
+func (s *authService) SetRolePermissions(adminID, roleID uuid.UUID, req *models.AdminSetRolePermissionsRequest) (*models.Role, error) {
+	permissionIDs, err := s.permissionIDs(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetRolePermissions(roleID, permissionIDs, &adminID); err != nil {
+		return nil, err
+	}
+
+	role, err := s.userRepo.GetRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+	s.recordRoleChange(adminID, "role_permissions_updated", "Permissions of role "+role.Name+" replaced", role)
+	return role, nil
+}
+
+// ListPermissions returns every permission ordered by name
+func (s *authService) ListPermissions() ([]models.Permission, error) {
+	permissions, err := s.userRepo.ListPermissions()
+	if err != nil {
+		return nil, err
+	}
+	if permissions == nil {
+		permissions = []models.Permission{}
+	}
+	return permissions, nil
+}
+
+// CreatePermission creates the permission resource:action
+func (s *authService) CreatePermission(adminID uuid.UUID, req *models.AdminCreatePermissionRequest) (*models.Permission, error) {
+	if !permissionPart.MatchString(req.Resource) || !permissionPart.MatchString(req.Action) {
+		return nil, ErrPermissionName
+	}
+	name := req.Resource + ":" + req.Action
+	existing, err := s.userRepo.GetPermissionsByName([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, ErrPermissionExists
+	}
+
+	permission := &models.Permission{
+		Name:        name,
+		Resource:    req.Resource,
+		Action:      req.Action,
+		Description: strings.TrimSpace(req.Description),
+	}
+	if err := s.userRepo.CreatePermission(permission); err != nil {
+		return nil, err
+	}
+	s.recordPermissionChange(adminID, "permission_created", "created", permission)
+	return permission, nil
+}
+
+// DeletePermission deletes a permission; every role that had it loses it
+func (s *authService) DeletePermission(adminID, permissionID uuid.UUID) error {
+	permission, err := s.userRepo.DeletePermission(permissionID)
+	if err != nil {
+		return err
+	}
+	s.recordPermissionChange(adminID, "permission_deleted", "deleted", permission)
+	return nil
+}
+
+// GetUserRoles returns the user's system role, the roles of their active grants, their custom roles and
+// the permissions all of them give
+func (s *authService) GetUserRoles(userID uuid.UUID) (*models.AdminUserRolesResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadRoleGrants(user); err != nil {
+		return nil, err
+	}
+	assignments, err := s.userRepo.ListUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.userRepo.ListRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	granted := user.GrantedRoles
+	if granted == nil {
+		granted = []models.UserRole{}
+	}
+	if assignments == nil {
+		assignments = []models.UserRoleAssignment{}
+	}
+	return &models.AdminUserRolesResponse{
+		UserID:       userID.String(),
+		Role:         user.Role,
+		GrantedRoles: granted,
+		Assignments:  assignments,
+		Permissions:  effectivePermissions(roles, append([]models.UserRole{user.Role}, granted...), assignments),
+	}, nil
+}
+
+// AssignRole gives a user a custom role by name; the user's next login or token refresh carries it
+func (s *authService) AssignRole(adminID, userID uuid.UUID, req *models.AdminAssignRoleRequest) (*models.UserRoleAssignment, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, err
+	}
+	role, err := s.userRepo.GetRoleByName(req.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role.IsSystem {
+		return nil, ErrSystemRole
+	}
+
+	assignment := &models.UserRoleAssignment{
+		UserID:     userID,
+		RoleID:     role.ID,
+		AssignedAt: time.Now(),
+		AssignedBy: &adminID,
+	}
+	if err := s.userRepo.AssignRole(assignment); err != nil {
+		return nil, err
+	}
+	assignment.Role = role
+
+	log.Printf("🚨 Admin %s assigned role %s to user %s", adminID, role.Name, userID)
+	s.auditRoleAssignment(userID, role, "role_assigned", "Role "+role.Name+" assigned", map[string]interface{}{"assigned_by": adminID.String()})
+	return assignment, nil
+}
+
+// UnassignRole takes a custom role away from a user; tokens issued while they had it stop working
+func (s *authService) UnassignRole(adminID, userID, roleID uuid.UUID) error {
+	role, err := s.userRepo.GetRole(roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UnassignRole(userID, roleID); err != nil {
+		return err
+	}
+
+	log.Printf("🚨 Admin %s took role %s away from user %s", adminID, role.Name, userID)
+	s.auditRoleAssignment(userID, role, "role_unassigned", "Role "+role.Name+" taken away by an administrator", map[string]interface{}{"unassigned_by": adminID.String()})
+	return nil
+}
+
+// permissionIDs resolves permission names to IDs; any unknown name fails with ErrUnknownPermission
+func (s *authService) permissionIDs(names []string) ([]uuid.UUID, error) {
+	names = slices.Compact(slices.Sorted(slices.Values(names)))
+	permissions, err := s.userRepo.GetPermissionsByName(names)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]uuid.UUID, len(permissions))
+	for _, permission := range permissions {
+		found[permission.Name] = permission.ID
+	}
+	ids := make([]uuid.UUID, 0, len(names))
+	var unknown []string
+	for _, name := range names {
+		if id, ok := found[name]; ok {
+			ids = append(ids, id)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, strings.Join(unknown, ", "))
+	}
+	return ids, nil
+}
+
+// effectivePermissions returns the sorted names of the permissions of the system roles named in system
+// and of the assigned custom roles
+func effectivePermissions(roles []models.Role, system []models.UserRole, assignments []models.UserRoleAssignment) []string {
+	var names []string
+	for i := range roles {
+		if roles[i].IsSystem && slices.Contains(system, models.UserRole(roles[i].Name)) {
+			for _, permission := range roles[i].Permissions {
+				names = append(names, permission.Name)
+			}
+		}
+	}
+	for _, assignment := range assignments {
+		if assignment.Role == nil {
+			continue
+		}
+		for _, permission := range assignment.Role.Permissions {
+			names = append(names, permission.Name)
+		}
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	if names == nil {
+		names = []string{}
+	}
+	return names
+}
+
+// recordRoleChange records a change to a role in the activity log of the admin who made it
+func (s *authService) recordRoleChange(adminID uuid.UUID, action, description string, role *models.Role) {
+	log.Printf("📝 Admin %s: %s", adminID, description)
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+	if err := s.LogUserActivity(adminID, action, description, map[string]interface{}{
+		"role_id":     role.ID.String(),
+		"role":        role.Name,
+		"permissions": permissions,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record %s for admin %s: %v", action, adminID, err)
+	}
+}
+
+// recordPermissionChange records a created or deleted permission in the activity log of the admin
+func (s *authService) recordPermissionChange(adminID uuid.UUID, action, change string, permission *models.Permission) {
+	log.Printf("📝 Admin %s: permission %s %s", adminID, permission.Name, change)
+	if err := s.LogUserActivity(adminID, action, "Permission "+permission.Name+" "+change, map[string]interface{}{
+		"permission_id": permission.ID.String(),
+		"permission":    permission.Name,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record %s for admin %s: %v", action, adminID, err)
+	}
+}
+
+// auditRoleAssignment records a role assigned or taken away in the activity log of the user holding it
+func (s *authService) auditRoleAssignment(userID uuid.UUID, role *models.Role, action, description string, metadata map[string]interface{}) {
+	metadata["role_id"] = role.ID.String()
+	metadata["role"] = role.Name
+	if err := s.LogUserActivity(userID, action, description, metadata); err != nil {
+		log.Printf("⚠️  Failed to record %s for user %s: %v", action, userID, err)
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownClient means the login named a client_id no security policy registers
+var ErrUnknownClient = errors.New("unknown client_id")
+
+// DefaultPolicyName names the policy of logins no tenant or client override matches
+const DefaultPolicyName = "default"
+
+// policyKey identifies a resolved policy; only configured domains and clients become part of a key,
+// so the cache stays as small as the configuration
+type policyKey struct {
+	domain   string
+	clientID string
+}
+
+// SecurityPolicyResolver picks the security policy for a login from the tenant (email domain) and
+// registered client overrides, starting from the global settings
+// Resolved policies are cached; they only change with the configuration
+type SecurityPolicyResolver struct {
+	defaults models.SecurityPolicy
+	byDomain map[string][]config.SecurityPolicyConfig
+	byClient map[string][]config.SecurityPolicyConfig
+
+	mu    sync.RWMutex
+	cache map[policyKey]*models.SecurityPolicy
+}
+
+// NewSecurityPolicyResolver builds a resolver from the global settings and cfg.SecurityPolicies
+// The configuration must have passed config.Validate
+func NewSecurityPolicyResolver(cfg *config.Config) *SecurityPolicyResolver {
+	r := &SecurityPolicyResolver{
+		defaults: models.SecurityPolicy{
+			Name:             DefaultPolicyName,
+			AccessExpiry:     parseDuration(cfg.JWT.AccessExpiry),
+			RefreshExpiry:    parseDuration(cfg.JWT.RefreshExpiry),
+			RememberMeExpiry: parseDuration(cfg.JWT.RememberMeExpiry),
+			MaxSessions:      cfg.Security.MaxSessionsPerUser,
+			MaxLoginAttempts: cfg.Security.MaxLoginAttempts,
+			LockoutDuration:  cfg.Security.LockoutDuration,
+		},
+		byDomain: make(map[string][]config.SecurityPolicyConfig),
+		byClient: make(map[string][]config.SecurityPolicyConfig),
+		cache:    make(map[policyKey]*models.SecurityPolicy),
+	}
+	for _, policy := range cfg.SecurityPolicies {
+		for _, domain := range policy.EmailDomains {
+			domain = strings.ToLower(domain)
+			r.byDomain[domain] = append(r.byDomain[domain], policy)
+		}
+		for _, clientID := range policy.ClientIDs {
+			r.byClient[clientID] = append(r.byClient[clientID], policy)
+		}
+	}
+	if len(cfg.SecurityPolicies) > 0 {
+		log.Printf("✅ Loaded %d tenant/client security policies", len(cfg.SecurityPolicies))
+	}
+	return r
+}
+
+// Resolve returns the policy for a login by email through clientID ("" for first-party logins)
+// When several overrides match, the strictest value of each setting wins
+// The returned policy is shared and must not be modified
+func (r *SecurityPolicyResolver) Resolve(email, clientID string) (*models.SecurityPolicy, error) {
+	key := policyKey{clientID: clientID}
+	if clientID != "" && len(r.byClient[clientID]) == 0 {
+		return nil, ErrUnknownClient
+	}
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		if domain := strings.ToLower(email[at+1:]); len(r.byDomain[domain]) > 0 {
+			key.domain = domain
+		}
+	}
+
+	r.mu.RLock()
+	policy, ok := r.cache[key]
+	r.mu.RUnlock()
+	if ok {
+		return policy, nil
+	}
+
+	policy = r.resolve(key)
+	r.mu.Lock()
+	r.cache[key] = policy
+	r.mu.Unlock()
+	return policy, nil
+}
+
+// AllowsOrigin reports whether origin is listed in the origins of a policy registering clientID
+func (r *SecurityPolicyResolver) AllowsOrigin(clientID, origin string) bool {
+	for _, policy := range r.byClient[clientID] {
+		for _, allowed := range policy.Origins {
+			if strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolve merges the overrides matching key into the global settings
+func (r *SecurityPolicyResolver) resolve(key policyKey) *models.SecurityPolicy {
+	policy := r.defaults
+	policy.ClientID = key.clientID
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, override := range append(append([]config.SecurityPolicyConfig(nil), r.byDomain[key.domain]...), r.byClient[key.clientID]...) {
+		if seen[override.Name] {
+			continue
+		}
+		seen[override.Name] = true
+		names = append(names, override.Name)
+		tighten(&policy, override)
+	}
+	if len(names) > 0 {
+		policy.Name = strings.Join(names, "+")
+	}
+	return &policy
+}
+
+// enforceSessionLimit revokes the user's oldest sessions beyond the policy's limit after a login
+// Failures are logged rather than failing a login that already succeeded
+func (s *authService) enforceSessionLimit(userID uuid.UUID, policy *models.SecurityPolicy) {
+	if policy.MaxSessions <= 0 {
+		return
+	}
+	revoked, err := s.sessionRepo.RevokeExcessSessions(userID, policy.MaxSessions, policy.AccessExpiry)
+	if err != nil {
+		log.Printf("⚠️  Failed to enforce the session limit for user %s: %v", userID, err)
+		return
+	}
+	if revoked > 0 {
+		log.Printf("📝 Revoked %d oldest sessions of user %s (policy %s allows %d)", revoked, userID, policy.Name, policy.MaxSessions)
+	}
+}
+
+// tighten applies the settings of override that are stricter than policy's
+func tighten(policy *models.SecurityPolicy, override config.SecurityPolicyConfig) {
+	if expiry, err := time.ParseDuration(override.AccessExpiry); err == nil && expiry < policy.AccessExpiry {
+		policy.AccessExpiry = expiry
+	}
+	if expiry, err := time.ParseDuration(override.RefreshExpiry); err == nil && expiry < policy.RefreshExpiry {
+		policy.RefreshExpiry = expiry
+		policy.RememberMeExpiry = 0 // A shortened refresh lifetime isn't lengthened again by remember me
+	}
+	if override.MaxSessionsPerUser > 0 && (policy.MaxSessions == 0 || override.MaxSessionsPerUser < policy.MaxSessions) {
+		policy.MaxSessions = override.MaxSessionsPerUser
+	}
+	if override.MaxLoginAttempts > 0 && override.MaxLoginAttempts < policy.MaxLoginAttempts {
+		policy.MaxLoginAttempts = override.MaxLoginAttempts
+	}
+	if override.LockoutDuration > policy.LockoutDuration {
+		policy.LockoutDuration = override.LockoutDuration
+	}
+	policy.RequireTwoFactor = policy.RequireTwoFactor || override.RequireTwoFactor
+}
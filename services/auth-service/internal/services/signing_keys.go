@@ -0,0 +1,110 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"auth-service/internal/config"
+	"auth-service/internal/discovery"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is one key of the access token key set
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// SigningKeys signs access tokens with jwt.algorithm and verifies them by kid against every configured
+// key, so tokens signed before a rotation stay valid until they expire
+type SigningKeys struct {
+	algorithm string
+	secret    []byte // access_secret; with RS256 or EdDSA it only verifies HS256 tokens issued before the switch
+	active    *signingKey
+	keys      map[string]*signingKey
+	ordered   []*signingKey // Configuration order, for a stable JWKS
+}
+
+// NewSigningKeys builds the key set from the validated JWT configuration
+func NewSigningKeys(cfg config.JWTConfig) (*SigningKeys, error) {
+	keys := &SigningKeys{
+		algorithm: cfg.Algorithm,
+		secret:    []byte(cfg.AccessSecret),
+		keys:      make(map[string]*signingKey, len(cfg.Keys)),
+	}
+	if cfg.Algorithm == config.JWTAlgorithmHS256 {
+		return keys, nil
+	}
+
+	for _, keyConfig := range cfg.Keys {
+		private, err := keyConfig.ParsePrivateKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt.keys %s private key: %w", keyConfig.ID, err)
+		}
+		key := &signingKey{id: keyConfig.ID, method: jwt.GetSigningMethod(keyConfig.Algorithm), private: private}
+		keys.keys[key.id] = key
+		keys.ordered = append(keys.ordered, key)
+	}
+	if keys.active = keys.keys[cfg.SigningKeyID]; keys.active == nil {
+		return nil, fmt.Errorf("jwt.signing_key_id %q is not one of jwt.keys", cfg.SigningKeyID)
+	}
+	return keys, nil
+}
+
+// Sign signs access token claims with the active key, naming it in the kid header
+func (k *SigningKeys) Sign(claims jwt.MapClaims) (string, error) {
+	if k.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.secret)
+	}
+	token := jwt.NewWithClaims(k.active.method, claims)
+	token.Header["kid"] = k.active.id
+	return token.SignedString(k.active.private)
+}
+
+// Keyfunc returns the key verifying an access token: the key named by its kid, or access_secret for
+// HS256 tokens while one is configured
+func (k *SigningKeys) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(k.secret) == 0 {
+			return nil, errors.New("invalid signing method")
+		}
+		return k.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("invalid signing method")
+	}
+	return key.private.Public(), nil
+}
+
+// JWKS returns the public keys for /.well-known/jwks.json; empty while access tokens are HS256
+func (k *SigningKeys) JWKS() discovery.JWKS {
+	jwks := discovery.JWKS{Keys: make([]discovery.JSONWebKey, 0, len(k.ordered))}
+	for _, key := range k.ordered {
+		jwk := discovery.JSONWebKey{Kid: key.id, Alg: key.method.Alg(), Use: "sig"}
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
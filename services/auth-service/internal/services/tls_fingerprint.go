@@ -0,0 +1,84 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"auth-service/internal/models"
+)
+
+// ErrTLSFingerprintMismatch means a bound refresh token was presented by a different TLS client than it was issued to
+var ErrTLSFingerprintMismatch = errors.New("refresh token was issued to a different TLS client")
+
+// sessionDeviceInfo encodes the device metadata stored with a session; always a JSON object for the JSONB column
+func sessionDeviceInfo(client models.ClientInfo) string {
+	data, err := json.Marshal(models.DeviceInfo{JA3: client.JA3, JA4: client.JA4})
+	if err != nil {
+		return `{}`
+	}
+	return string(data)
+}
+
+// fingerprintOfKind returns the client's fingerprint of the same kind ("ja4:" or "ja3:") as bound
+// Fingerprints of different kinds can't be compared, so a proxy switching from JA3 to JA4 counts as absent
+func fingerprintOfKind(client models.ClientInfo, bound string) string {
+	kind, _, _ := strings.Cut(bound, ":")
+	switch {
+	case kind == "ja4" && client.JA4 != "":
+		return "ja4:" + client.JA4
+	case kind == "ja3" && client.JA3 != "":
+		return "ja3:" + client.JA3
+	}
+	return ""
+}
+
+// checkTLSFingerprint compares the fingerprint a refresh token is bound to with the refreshing client's and
+// sets the fingerprint the rotated token is bound to. A change is recorded as a risk signal and, with
+// tls_fingerprint.bind_refresh_tokens, rejected; a missing fingerprint on either side is never an error
+func (s *authService) checkTLSFingerprint(user *models.User, bound string, client models.ClientInfo) error {
+	if bound == "" {
+		// Tokens issued before fingerprints were forwarded bind to the first client that refreshes them
+		user.TLSFingerprint = client.TLSFingerprint()
+		return nil
+	}
+	// Keep the binding when the proxy didn't forward a fingerprint this time
+	user.TLSFingerprint = bound
+
+	current := fingerprintOfKind(client, bound)
+	if current == "" || current == bound {
+		return nil
+	}
+
+	rejected := s.tlsFingerprint.BindRefreshTokens
+	log.Printf("🚨 TLS fingerprint changed on refresh for user %s from %s (rejected=%t, request_id=%s)", user.ID, client.IPAddress, rejected, client.RequestID)
+	activity := &models.UserActivity{
+		ID:          models.NewID(),
+		UserID:      user.ID,
+		Action:      "tls_fingerprint_changed",
+		Description: "Refresh token used by a different TLS client than it was issued to",
+		IPAddress:   s.ipPrivacy.Address(client.IPAddress),
+		IPHash:      s.ipPrivacy.Hash(client.IPAddress),
+		UserAgent:   client.UserAgent,
+		RequestID:   client.RequestID,
+		Metadata:    fmt.Sprintf(`{"bound":%q,"presented":%q,"rejected":%t}`, bound, current, rejected),
+		CreatedAt:   time.Now(),
+	}
+	if err := s.userRepo.CreateUserActivity(activity); err != nil {
+		log.Printf("Failed to record TLS fingerprint change for user %s: %v", user.ID, err)
+	}
+	s.auditSecurityEvent("tls_fingerprint_changed", &user.ID, client, map[string]interface{}{
+		"bound":     bound,
+		"presented": current,
+		"rejected":  rejected,
+	})
+
+	if rejected {
+		return ErrTLSFingerprintMismatch
+	}
+	user.TLSFingerprint = current
+	return nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// Two-factor policy errors; handlers map them to 403 by message
+var (
+	ErrTwoFactorEnrollmentOverdue    = errors.New("two-factor enrollment overdue: your role requires two-factor authentication")
+	ErrTwoFactorDisableNeedsApproval = errors.New("disabling two-factor authentication requires administrator approval")
+)
+
+// requiresTwoFactor reports whether the policy makes two-factor authentication mandatory for role
+func (s *authService) requiresTwoFactor(role models.UserRole) bool {
+	for _, required := range s.twoFactor.RequiredRoles {
+		if models.UserRole(required) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// twoFactorDeadline is when a user created at createdAt must have enrolled
+func (s *authService) twoFactorDeadline(createdAt time.Time) time.Time {
+	start := createdAt
+	if s.twoFactor.EnforcedFrom.After(start) {
+		start = s.twoFactor.EnforcedFrom
+	}
+	return start.Add(s.twoFactor.GracePeriod)
+}
+
+// twoFactorEnrollment returns the enrollment notice for a user the policy applies to who hasn't enabled
+// two-factor authentication, or nil when no action is needed
+// A tenant or client security policy may require two-factor authentication for every role
+func (s *authService) twoFactorEnrollment(user *models.User) (*models.TwoFactorEnrollment, error) {
+	policyRequires := user.Policy != nil && user.Policy.RequireTwoFactor
+	if !s.requiresTwoFactor(user.Role) && !policyRequires {
+		return nil, nil
+	}
+
+	prefs, err := s.userRepo.GetUserPreferences(user.ID)
+	if err != nil && err != repositories.ErrUserPreferencesNotFound {
+		return nil, err
+	}
+	if prefs != nil && prefs.TwoFactorEnabled {
+		return nil, nil
+	}
+
+	deadline := s.twoFactorDeadline(user.CreatedAt)
+	return &models.TwoFactorEnrollment{
+		Required: true,
+		Deadline: deadline,
+		Overdue:  time.Now().After(deadline),
+	}, nil
+}
+
+// TwoFactorCompliance reports enrollment of every active user in a role the policy applies to
+func (s *authService) TwoFactorCompliance() (*models.TwoFactorComplianceReport, error) {
+	roles := make([]models.UserRole, 0, len(s.twoFactor.RequiredRoles))
+	for _, role := range s.twoFactor.RequiredRoles {
+		roles = append(roles, models.UserRole(role))
+	}
+
+	statuses, err := s.userRepo.ListTwoFactorStatus(roles)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &models.TwoFactorComplianceReport{
+		Policy: models.TwoFactorPolicySummary{
+			RequiredRoles:            s.twoFactor.RequiredRoles,
+			GracePeriod:              s.twoFactor.GracePeriod.String(),
+			RequireApprovalToDisable: s.twoFactor.RequireApprovalToDisable,
+		},
+		GeneratedAt:  now,
+		Roles:        make(map[models.UserRole]*models.TwoFactorRoleCompliance, len(roles)),
+		NonCompliant: []models.TwoFactorNonCompliantUser{},
+	}
+	if !s.twoFactor.EnforcedFrom.IsZero() {
+		report.Policy.EnforcedFrom = &s.twoFactor.EnforcedFrom
+	}
+	for _, role := range roles {
+		report.Roles[role] = &models.TwoFactorRoleCompliance{}
+	}
+
+	for _, status := range statuses {
+		compliance := report.Roles[status.Role]
+		if compliance == nil {
+			continue
+		}
+		compliance.Total++
+		if status.TwoFactorEnabled {
+			compliance.Enrolled++
+			continue
+		}
+
+		deadline := s.twoFactorDeadline(status.CreatedAt)
+		overdue := now.After(deadline)
+		if overdue {
+			compliance.Overdue++
+		} else {
+			compliance.Pending++
+		}
+		report.NonCompliant = append(report.NonCompliant, models.TwoFactorNonCompliantUser{
+			UserID:   status.UserID.String(),
+			Email:    status.Email,
+			Role:     status.Role,
+			Deadline: deadline,
+			Overdue:  overdue,
+		})
+	}
+
+	for _, compliance := range report.Roles {
+		compliance.ComplianceRate = 1
+		if compliance.Total > 0 {
+			compliance.ComplianceRate = float64(compliance.Enrolled) / float64(compliance.Total)
+		}
+	}
+
+	return report, nil
+}
+
+// DisableTwoFactor turns off two-factor authentication for a user on an administrator's approval
+func (s *authService) DisableTwoFactor(adminID, userID uuid.UUID, reason string) error {
+	prefs, err := s.userRepo.GetUserPreferences(userID)
+	if err != nil {
+		if err == repositories.ErrUserPreferencesNotFound {
+			return errors.New("two-factor authentication is not enabled for this user")
+		}
+		return err
+	}
+	if !prefs.TwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled for this user")
+	}
+
+	prefs.TwoFactorEnabled = false
+	if err := s.userRepo.UpdateUserPreferences(prefs); err != nil {
+		return err
+	}
+
+	log.Printf("🚨 Admin %s disabled two-factor authentication for user %s (reason: %s)", adminID, userID, reason)
+	if err := s.LogUserActivity(userID, "two_factor_disabled", "Two-factor authentication disabled by an administrator", map[string]interface{}{
+		"admin_id": adminID.String(),
+		"reason":   reason,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record two-factor disable activity for user %s: %v", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Account merge errors; handlers map them to statuses with errors.Is
+var (
+	ErrMergeSameUser   = errors.New("an account can't be merged into itself")
+	ErrMergeOwnAccount = errors.New("admins can't merge away their own account")
+)
+
+// MergeUsers folds the duplicate account req.SourceUserID into targetID, e.g. when one person signed up
+// with a password and again with Google. The duplicate's data moves to the target, the duplicate is
+// soft-deleted and the merge is recorded in user_merges; a merge can't be undone
+func (s *authService) MergeUsers(adminID, targetID uuid.UUID, req *models.AdminMergeUserRequest, client models.ClientInfo) (*models.AdminUserMergeResponse, error) {
+	sourceID, err := uuid.Parse(req.SourceUserID)
+	if err != nil {
+		return nil, err
+	}
+	if sourceID == targetID {
+		return nil, ErrMergeSameUser
+	}
+	if sourceID == adminID {
+		return nil, ErrMergeOwnAccount
+	}
+
+	merge := &models.UserMerge{
+		SourceUserID: sourceID,
+		TargetUserID: targetID,
+		MergedBy:     &adminID,
+		Reason:       req.Reason,
+		RequestID:    client.RequestID,
+	}
+	counts, err := s.userRepo.MergeUsers(merge)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("📝 Admin %s merged account %s (%s) into %s: %s", adminID, sourceID, merge.SourceEmail, targetID, req.Reason)
+	if s.dataKeys != nil {
+		s.dataKeys.Evict(sourceID) // Shredded with the merge
+	}
+
+	// The moved sessions were revoked with the merge; this tells other regions to revoke theirs too
+	if err := s.sessionRepo.RevokeAllUserSessions(sourceID); err != nil {
+		log.Printf("⚠️  Failed to revoke sessions of merged account %s: %v", sourceID, err)
+	}
+
+	if err := s.LogUserActivity(targetID, "account_merged", "Account "+merge.SourceEmail+" merged into this account by an administrator", map[string]interface{}{
+		"admin_id":       adminID.String(),
+		"merge_id":       merge.ID.String(),
+		"source_user_id": sourceID.String(),
+		"source_email":   merge.SourceEmail,
+		"reason":         req.Reason,
+	}); err != nil {
+		log.Printf("⚠️  Failed to record merge activity for user %s: %v", targetID, err)
+	}
+
+	return &models.AdminUserMergeResponse{Merge: *merge, Moved: *counts}, nil
+}
+
+// ListUserMerges returns the merges into or out of an account, newest first
+func (s *authService) ListUserMerges(userID uuid.UUID) ([]models.AdminUserMergeResponse, error) {
+	merges, err := s.userRepo.ListUserMerges(userID)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]models.AdminUserMergeResponse, 0, len(merges))
+	for _, merge := range merges {
+		response := models.AdminUserMergeResponse{Merge: merge}
+		if err := json.Unmarshal([]byte(merge.Moved), &response.Moved); err != nil {
+			log.Printf("⚠️  Unreadable counts in merge %s: %v", merge.ID, err)
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/maintenance"
+	"auth-service/internal/models"
+	"auth-service/internal/objectstore"
+	"auth-service/internal/repositories"
+)
+
+// errNoActivityStore fails purges of accounts with archived activities while activity_archive is disabled
+var errNoActivityStore = errors.New("the account has archived activities but activity_archive storage isn't configured")
+
+// DeletedUserRetention periodically purges accounts soft-deleted more than deleted_user_retention.retention_period
+// ago: their per-user rows and archived activities are deleted, then the user row or its personal data
+// Each purge is recorded in the audit log, the only place that still knows about it
+type DeletedUserRetention struct {
+	userRepo    repositories.UserRepository
+	store       objectstore.Store
+	service     AuthService
+	config      config.DeletedUserRetentionConfig
+	maintenance *maintenance.Mode
+
+	mu          sync.Mutex
+	accounts    map[string]uint64
+	rows        map[string]uint64
+	lastSuccess time.Time
+}
+
+// NewDeletedUserRetention creates the retention job; store holds the activity archives and is nil when
+// activity_archive is disabled. Purges are recorded in the audit log through service, and runs are
+// skipped while mode is read-only
+func NewDeletedUserRetention(userRepo repositories.UserRepository, store objectstore.Store, service AuthService, cfg config.DeletedUserRetentionConfig, mode *maintenance.Mode) *DeletedUserRetention {
+	return &DeletedUserRetention{
+		userRepo:    userRepo,
+		store:       store,
+		service:     service,
+		config:      cfg,
+		maintenance: mode,
+		accounts:    map[string]uint64{"purged": 0, "error": 0},
+		rows:        make(map[string]uint64),
+	}
+}
+
+// Start runs the job immediately and then every deleted_user_retention.interval until ctx is cancelled
+// It does nothing unless deleted_user_retention.enabled is set
+func (j *DeletedUserRetention) Start(ctx context.Context) {
+	if !j.config.Enabled {
+		return
+	}
+
+	go func() {
+		j.run(ctx)
+
+		ticker := time.NewTicker(j.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.run(ctx)
+			}
+		}
+	}()
+}
+
+// run purges batches of accounts until none past the retention period are left, one fails or ctx is cancelled
+func (j *DeletedUserRetention) run(ctx context.Context) {
+	if j.maintenance.ReadOnly() {
+		return
+	}
+
+	deletedBefore := time.Now().Add(-j.config.RetentionPeriod)
+	anonymize := j.config.Mode == config.DeletedUserRetentionAnonymize
+
+	var purged, failed int
+	for ctx.Err() == nil {
+		users, err := j.userRepo.ListPurgeableUsers(deletedBefore, j.config.BatchSize)
+		if err != nil {
+			log.Printf("❌ Deleted user retention failed after purging %d accounts: %v", purged, err)
+			return
+		}
+
+		batchFailed := 0
+		for i := range users {
+			if ctx.Err() != nil {
+				break
+			}
+			counts, err := j.userRepo.PurgeUser(users[i].ID, deletedBefore, anonymize, j.removeArchives(ctx))
+			switch {
+			case errors.Is(err, repositories.ErrUserNotPurgeable):
+				continue // Purged by another replica in the meantime
+			case err != nil:
+				log.Printf("❌ Failed to purge deleted user %s: %v", users[i].ID, err)
+				batchFailed++
+			default:
+				purged++
+				j.audit(&users[i], counts)
+			}
+			j.record(counts, err)
+		}
+
+		// Accounts that failed would be listed again, so they wait for the next run
+		failed += batchFailed
+		if len(users) < j.config.BatchSize || batchFailed > 0 {
+			break
+		}
+	}
+
+	if purged > 0 || failed > 0 {
+		log.Printf("🧹 Deleted user retention purged (%s) %d accounts deleted before %s; %d failed",
+			j.config.Mode, purged, deletedBefore.UTC().Format(time.RFC3339), failed)
+	}
+}
+
+// removeArchives deletes the objects of a purged account's activity archives
+func (j *DeletedUserRetention) removeArchives(ctx context.Context) repositories.ActivityArchiveRemover {
+	return func(archives []models.UserActivityArchive) error {
+		if j.store == nil {
+			return errNoActivityStore
+		}
+		for _, archive := range archives {
+			if err := j.store.Delete(ctx, archive.ObjectKey); err != nil {
+				return fmt.Errorf("%s: %w", archive.ObjectKey, err)
+			}
+		}
+		return nil
+	}
+}
+
+// audit records a purge in the audit log; user holds the account's ID and deletion time
+func (j *DeletedUserRetention) audit(user *models.User, counts *models.UserPurgeCounts) {
+	metadata := map[string]interface{}{
+		"mode":    j.config.Mode,
+		"deleted": counts,
+	}
+	if user.DeletedAt.Valid {
+		metadata["deleted_at"] = user.DeletedAt.Time.UTC().Format(time.RFC3339)
+	}
+	event := models.AuditEvent{
+		ActorType:  models.AuditActorSystem,
+		Action:     "retention.user_purged",
+		TargetType: "user",
+		TargetID:   user.ID.String(),
+		Metadata:   metadata,
+	}
+	if err := j.service.RecordAudit(event); err != nil {
+		log.Printf("⚠️  Failed to record the purge of deleted user %s in the audit log: %v", user.ID, err)
+	}
+}
+
+// record adds one account to the metrics
+func (j *DeletedUserRetention) record(counts *models.UserPurgeCounts, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err != nil {
+		j.accounts["error"]++
+		return
+	}
+	j.accounts["purged"]++
+	j.rows["sessions"] += uint64(counts.Sessions)
+	j.rows["login_attempts"] += uint64(counts.LoginAttempts)
+	j.rows["activities"] += uint64(counts.Activities)
+	j.rows["notifications"] += uint64(counts.Notifications)
+	j.rows["archived_notifications"] += uint64(counts.ArchivedNotifications)
+	j.rows["activity_archives"] += uint64(counts.ActivityArchives)
+	j.rows["other"] += uint64(counts.Other)
+	j.lastSuccess = time.Now()
+}
+
+// WritePrometheus writes the accounts and rows purged by the job in the Prometheus text format
+func (j *DeletedUserRetention) WritePrometheus(w io.Writer) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP auth_service_deleted_user_retention_accounts_total Deleted accounts processed by the retention job by result")
+	fmt.Fprintln(w, "# TYPE auth_service_deleted_user_retention_accounts_total counter")
+	for _, result := range []string{"purged", "error"} {
+		fmt.Fprintf(w, "auth_service_deleted_user_retention_accounts_total{result=%q} %d\n", result, j.accounts[result])
+	}
+	fmt.Fprintln(w, "# HELP auth_service_deleted_user_retention_rows_total Rows deleted with purged accounts")
+	fmt.Fprintln(w, "# TYPE auth_service_deleted_user_retention_rows_total counter")
+	for _, kind := range []string{"sessions", "login_attempts", "activities", "notifications", "archived_notifications", "activity_archives", "other"} {
+		fmt.Fprintf(w, "auth_service_deleted_user_retention_rows_total{kind=%q} %d\n", kind, j.rows[kind])
+	}
+	if !j.lastSuccess.IsZero() {
+		fmt.Fprintln(w, "# HELP auth_service_deleted_user_retention_last_success_timestamp_seconds When an account was last purged")
+		fmt.Fprintln(w, "# TYPE auth_service_deleted_user_retention_last_success_timestamp_seconds gauge")
+		fmt.Fprintf(w, "auth_service_deleted_user_retention_last_success_timestamp_seconds %d\n", j.lastSuccess.Unix())
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// sessionBlacklistFor keeps a revoked session's access token blacklisted until it would have expired anyway
+const sessionBlacklistFor = 15 * time.Minute
+
+// ListUserSessions returns the user's active sessions; the one authenticated by accessToken is marked current
+func (s *authService) ListUserSessions(userID uuid.UUID, accessToken string) ([]models.UserSessionInfo, error) {
+	sessions, err := s.sessionRepo.ListActiveUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentHash := s.jwtService.HashToken(accessToken)
+	infos := make([]models.UserSessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, models.UserSessionInfo{
+			ID:          session.ID,
+			IPAddress:   session.IPAddress,
+			UserAgent:   session.UserAgent,
+			CountryCode: session.CountryCode,
+			City:        session.City,
+			CreatedAt:   session.CreatedAt,
+			LastUsedAt:  session.LastUsedAt,
+			ExpiresAt:   session.ExpiresAt,
+			RememberMe:  session.RememberMe,
+			Current:     session.AccessTokenHash == currentHash,
+		})
+	}
+	return infos, nil
+}
+
+// RevokeUserSession signs out one of the user's devices: its refresh token is deleted and its access token
+// blacklisted. Revoking the current session works like a logout of this device only
+func (s *authService) RevokeUserSession(userID, sessionID uuid.UUID, client models.ClientInfo) error {
+	if err := s.sessionRepo.RevokeUserSession(userID, sessionID, sessionBlacklistFor); err != nil {
+		return err
+	}
+	s.recordSessionActivity(userID, "session_revoked", "Signed out a device", map[string]interface{}{
+		"session_id": sessionID,
+	}, client)
+	return nil
+}
+
+// RevokeOtherSessions signs out every device of the user except the session authenticated by accessToken
+func (s *authService) RevokeOtherSessions(userID uuid.UUID, accessToken string, client models.ClientInfo) (int64, error) {
+	sessions, err := s.sessionRepo.ListActiveUserSessions(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// A token that isn't a session's (issued at registration, say) leaves no session to keep
+	filter := models.SessionFilter{UserID: &userID}
+	currentHash := s.jwtService.HashToken(accessToken)
+	for i := range sessions {
+		if sessions[i].AccessTokenHash == currentHash {
+			filter.ExcludeID = &sessions[i].ID
+			break
+		}
+	}
+
+	revoked, err := s.sessionRepo.RevokeMatchingSessions(filter, sessionBlacklistFor)
+	if err != nil {
+		return revoked, err
+	}
+	s.recordSessionActivity(userID, "other_sessions_revoked", fmt.Sprintf("Signed out %d other devices", revoked), map[string]interface{}{
+		"revoked": revoked,
+	}, client)
+	return revoked, nil
+}
+
+// recordSessionActivity adds a session change to the user's activity log; failures are only logged
+func (s *authService) recordSessionActivity(userID uuid.UUID, action, description string, metadata map[string]interface{}, client models.ClientInfo) {
+	metadata["ip_address"] = s.ipPrivacy.Address(client.IPAddress)
+	metadata["user_agent"] = client.UserAgent
+	if err := s.LogUserActivity(userID, action, description, metadata); err != nil {
+		log.Printf("⚠️  Failed to record %s for user %s: %v", action, userID, err)
+	}
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repositories"
+	"auth-service/internal/webauthn"
+
+	"github.com/google/uuid"
+)
+
+// Passkey errors; handlers map them to statuses with errors.Is
+var (
+	ErrPasskeysDisabled       = errors.New("passkeys are not enabled")
+	ErrPasskeyChallenge       = errors.New("passkey challenge is invalid or has expired")
+	ErrPasskeyAlreadyEnrolled = errors.New("this passkey is already registered")
+	ErrPasskeyLoginFailed     = errors.New("passkey login failed")
+)
+
+// Redis purposes of passkey ceremony challenges
+const (
+	tokenPurposePasskeyRegistration = "webauthn_registration"
+	tokenPurposePasskeyLogin        = "webauthn_login"
+)
+
+// defaultPasskeyName names a passkey registered without a name
+const defaultPasskeyName = "Passkey"
+
+// BeginPasskeyRegistration starts registering a passkey for the user and returns the options for
+// navigator.credentials.create; the challenge is stored in Redis for webauthn.challenge_ttl
+func (s *authService) BeginPasskeyRegistration(userID uuid.UUID) (*webauthn.CreationOptions, error) {
+	if s.passkeys == nil {
+		return nil, ErrPasskeysDisabled
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.userRepo.ListWebAuthnCredentials(userID)
+	if err != nil {
+		return nil, err
+	}
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(existing))
+	for i := range existing {
+		exclude = append(exclude, webauthn.NewCredentialDescriptor(existing[i].CredentialID, existing[i].TransportList()))
+	}
+
+	challenge, err := s.issuePasskeyChallenge(tokenPurposePasskeyRegistration, userID)
+	if err != nil {
+		return nil, err
+	}
+	displayName := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if displayName == "" {
+		displayName = user.Username
+	}
+	return s.passkeys.CreationOptions(challenge, userID[:], user.Email, displayName, exclude), nil
+}
+
+// FinishPasskeyRegistration verifies the authenticator's response and stores the new passkey
+func (s *authService) FinishPasskeyRegistration(userID uuid.UUID, req *models.PasskeyRegistrationRequest, client models.ClientInfo) (*models.WebAuthnCredential, error) {
+	if s.passkeys == nil {
+		return nil, ErrPasskeysDisabled
+	}
+
+	clientDataJSON, err := webauthn.DecodeBase64URL(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, webauthn.ErrInvalidResponse
+	}
+	attestationObject, err := webauthn.DecodeBase64URL(req.Credential.Response.AttestationObject)
+	if err != nil || len(attestationObject) == 0 {
+		return nil, webauthn.ErrInvalidResponse
+	}
+
+	challenge, err := s.consumePasskeyChallenge(tokenPurposePasskeyRegistration, clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID != userID {
+		return nil, ErrPasskeyChallenge
+	}
+
+	verified, err := s.passkeys.VerifyRegistration(challenge.Challenge, clientDataJSON, attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.GetWebAuthnCredential(verified.ID); err == nil {
+		return nil, ErrPasskeyAlreadyEnrolled
+	} else if !errors.Is(err, repositories.ErrPasskeyNotFound) {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = defaultPasskeyName
+	}
+	credential := &models.WebAuthnCredential{
+		UserID:         userID,
+		CredentialID:   verified.ID,
+		PublicKey:      verified.PublicKey,
+		Algorithm:      int(verified.Algorithm),
+		SignCount:      int64(verified.SignCount),
+		AAGUID:         verified.AAGUID,
+		Transports:     strings.Join(req.Credential.Response.Transports, ","),
+		BackupEligible: verified.BackupEligible,
+		BackupState:    verified.BackupState,
+		Name:           name,
+	}
+	if err := s.userRepo.CreateWebAuthnCredential(credential); err != nil {
+		return nil, err
+	}
+
+	s.recordPasskeyActivity(userID, "passkey_registered", "Passkey \""+name+"\" registered", credential, client)
+	return credential, nil
+}
+
+// ListPasskeys returns the user's passkeys, most recently used first
+func (s *authService) ListPasskeys(userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	credentials, err := s.userRepo.ListWebAuthnCredentials(userID)
+	if err != nil {
+		return nil, err
+	}
+	if credentials == nil {
+		credentials = []models.WebAuthnCredential{}
+	}
+	return credentials, nil
+}
+
+// RenamePasskey renames one of the user's passkeys
+func (s *authService) RenamePasskey(userID, passkeyID uuid.UUID, name string) (*models.WebAuthnCredential, error) {
+	return s.userRepo.RenameWebAuthnCredential(userID, passkeyID, strings.TrimSpace(name))
+}
+
+// DeletePasskey removes one of the user's passkeys; it can no longer be used to log in
+func (s *authService) DeletePasskey(userID, passkeyID uuid.UUID, client models.ClientInfo) error {
+	if err := s.userRepo.DeleteWebAuthnCredential(userID, passkeyID); err != nil {
+		return err
+	}
+	s.recordPasskeyActivity(userID, "passkey_removed", "Passkey removed", &models.WebAuthnCredential{ID: passkeyID}, client)
+	return nil
+}
+
+// BeginPasskeyLogin returns the options for navigator.credentials.get
+// With an email, the user's passkeys are listed for the browser; an unknown email gets the same
+// response as no email at all, so the endpoint doesn't reveal which accounts exist
+func (s *authService) BeginPasskeyLogin(req *models.PasskeyLoginBeginRequest) (*webauthn.RequestOptions, error) {
+	if s.passkeys == nil {
+		return nil, ErrPasskeysDisabled
+	}
+
+	var allow []webauthn.CredentialDescriptor
+	if req.Email != "" {
+		if user, err := s.userRepo.GetByEmail(strings.ToLower(req.Email)); err == nil {
+			credentials, err := s.userRepo.ListWebAuthnCredentials(user.ID)
+			if err != nil {
+				return nil, err
+			}
+			for i := range credentials {
+				allow = append(allow, webauthn.NewCredentialDescriptor(credentials[i].CredentialID, credentials[i].TransportList()))
+			}
+		}
+	}
+
+	challenge, err := s.issuePasskeyChallenge(tokenPurposePasskeyLogin, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.passkeys.RequestOptions(challenge, allow), nil
+}
+
+// FinishPasskeyLogin verifies a passkey assertion and issues the same token pair as a password login
+func (s *authService) FinishPasskeyLogin(req *models.PasskeyLoginRequest, client models.ClientInfo) (*models.AuthResponse, error) {
+	if s.passkeys == nil {
+		return nil, ErrPasskeysDisabled
+	}
+
+	clientDataJSON, err := webauthn.DecodeBase64URL(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, webauthn.ErrInvalidResponse
+	}
+	authenticatorData, err := webauthn.DecodeBase64URL(req.Credential.Response.AuthenticatorData)
+	if err != nil {
+		return nil, webauthn.ErrInvalidResponse
+	}
+	signature, err := webauthn.DecodeBase64URL(req.Credential.Response.Signature)
+	if err != nil {
+		return nil, webauthn.ErrInvalidResponse
+	}
+
+	challenge, err := s.consumePasskeyChallenge(tokenPurposePasskeyLogin, clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.userRepo.GetWebAuthnCredential(strings.TrimRight(req.Credential.ID, "="))
+	if errors.Is(err, repositories.ErrPasskeyNotFound) {
+		return nil, ErrPasskeyLoginFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+	if handle := req.Credential.Response.UserHandle; handle != "" {
+		if decoded, err := webauthn.DecodeBase64URL(handle); err != nil || string(decoded) != string(credential.UserID[:]) {
+			return nil, ErrPasskeyLoginFailed
+		}
+	}
+
+	assertion, err := s.passkeys.VerifyAssertion(challenge.Challenge, credential.PublicKey, clientDataJSON, authenticatorData, signature)
+	if err != nil {
+		log.Printf("⚠️  Passkey %s failed verification for user %s: %v", credential.ID, credential.UserID, err)
+		return nil, ErrPasskeyLoginFailed
+	}
+
+	// Authenticators that keep a signature counter must increase it; a repeat suggests a cloned key
+	if (assertion.SignCount != 0 || credential.SignCount != 0) && int64(assertion.SignCount) <= credential.SignCount {
+		log.Printf("🚨 Passkey %s of user %s presented signature counter %d after %d; possible cloned authenticator",
+			credential.ID, credential.UserID, assertion.SignCount, credential.SignCount)
+		s.recordPasskeyActivity(credential.UserID, "passkey_counter_regressed", "Passkey used with a stale signature counter; login refused", credential, client)
+		return nil, ErrPasskeyLoginFailed
+	}
+
+	user, err := s.userRepo.GetByID(credential.UserID)
+	if err != nil {
+		return nil, ErrPasskeyLoginFailed
+	}
+	loginAttempt := &models.LoginAttempt{
+		Email:     user.Email,
+		IPAddress: s.ipPrivacy.Address(client.IPAddress),
+		IPHash:    s.ipPrivacy.Hash(client.IPAddress),
+		UserAgent: client.UserAgent,
+		RequestID: client.RequestID,
+		Success:   false,
+	}
+	if !user.CanAttemptLogin() {
+		s.userRepo.CreateLoginAttempt(loginAttempt)
+		if user.IsLocked() {
+			return nil, errors.New("account is temporarily locked")
+		}
+		return nil, errors.New("account is inactive")
+	}
+
+	policy, err := s.policies.Resolve(user.Email, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	user.Policy = policy
+
+	enrollment, err := s.twoFactorEnrollment(user)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment != nil && enrollment.Overdue {
+		s.userRepo.CreateLoginAttempt(loginAttempt)
+		return nil, ErrTwoFactorEnrollmentOverdue
+	}
+
+	if err := s.userRepo.RecordWebAuthnCredentialUse(credential.ID, int64(assertion.SignCount), assertion.BackupState, time.Now()); err != nil {
+		return nil, err
+	}
+	authResponse, err := s.startSession(user, client, loginAttempt)
+	if err != nil {
+		return nil, err
+	}
+	authResponse.TwoFactorEnrollment = enrollment
+	return authResponse, nil
+}
+
+// passkeyChallenge is a ceremony challenge read back from Redis
+type passkeyChallenge struct {
+	Challenge string
+	UserID    uuid.UUID // uuid.Nil for login
+}
+
+// issuePasskeyChallenge stores a new single-use challenge for a registration or login ceremony
+func (s *authService) issuePasskeyChallenge(purpose string, userID uuid.UUID) (string, error) {
+	if s.tokenRepo == nil {
+		return "", ErrPasskeysDisabled
+	}
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+	record := &repositories.OneTimeToken{UserID: userID, Purpose: purpose, IssuedAt: time.Now()}
+	if err := s.tokenRepo.Issue(purpose, s.jwtService.HashToken(challenge), record, s.webauthnConfig.ChallengeTTL); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumePasskeyChallenge claims the challenge a response answers, so each ceremony completes at most once
+func (s *authService) consumePasskeyChallenge(purpose string, clientDataJSON []byte) (*passkeyChallenge, error) {
+	if s.tokenRepo == nil {
+		return nil, ErrPasskeysDisabled
+	}
+	challenge, err := webauthn.ChallengeOf(clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	record, err := s.tokenRepo.Consume(purpose, s.jwtService.HashToken(challenge))
+	if errors.Is(err, repositories.ErrOneTimeTokenNotFound) || errors.Is(err, repositories.ErrOneTimeTokenReplayed) {
+		return nil, ErrPasskeyChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &passkeyChallenge{Challenge: challenge, UserID: record.UserID}, nil
+}
+
+// recordPasskeyActivity adds a passkey change or alert to the user's activity feed
+func (s *authService) recordPasskeyActivity(userID uuid.UUID, action, description string, credential *models.WebAuthnCredential, client models.ClientInfo) {
+	activity := &models.UserActivity{
+		ID:          models.NewID(),
+		UserID:      userID,
+		Action:      action,
+		Description: description,
+		IPAddress:   s.ipPrivacy.Address(client.IPAddress),
+		IPHash:      s.ipPrivacy.Hash(client.IPAddress),
+		UserAgent:   client.UserAgent,
+		RequestID:   client.RequestID,
+		Metadata:    fmt.Sprintf(`{"passkey_id":%q}`, credential.ID),
+		CreatedAt:   time.Now(),
+	}
+	if err := s.userRepo.CreateUserActivity(activity); err != nil {
+		log.Printf("Failed to record %s for user %s: %v", action, userID, err)
+	}
+}
@@ -0,0 +1,387 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"shared/health"
+)
+
+const (
+	incidentsKey     = "status:incidents"
+	uptimeKeyPrefix  = "status:uptime"
+	uptimeRetention  = 91 * 24 * time.Hour
+	uptimeDateLayout = "2006-01-02"
+	probeTimeout     = 5 * time.Second
+	historySize      = 60
+	historyTTL       = 24 * time.Hour
+)
+
+// uptimeWindows are the periods (in days) reported on the status page
+var uptimeWindows = []int{1, 7, 30, 90}
+
+// oauthProviders are probed for reachability; any HTTP answer below 500 counts as reachable
+var oauthProviders = map[string]string{
+	"oauth_google":   "https://accounts.google.com/.well-known/openid-configuration",
+	"oauth_github":   "https://github.com/login/oauth/authorize",
+	"oauth_facebook": "https://www.facebook.com/dialog/oauth",
+}
+
+var ErrIncidentNotFound = errors.New("incident not found")
+
+// Incident is an admin-declared notice shown on the status page
+type Incident struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title" binding:"required,max=200"`
+	Message   string    `json:"message,omitempty"`
+	Severity  string    `json:"severity" binding:"required,oneof=minor major critical"`
+	Component string    `json:"component,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Component is the public view of one health check
+type Component struct {
+	Name   string             `json:"name"`
+	Status health.Status      `json:"status"`
+	Uptime map[string]float64 `json:"uptime"` // window in calendar days ("1d", "7d", ...) -> percentage
+	Trend  *health.Trend      `json:"trend,omitempty"`
+}
+
+// Snapshot is the cached status page document
+type Snapshot struct {
+	Status      health.Status `json:"status"`
+	Components  []Component   `json:"components"`
+	Incidents   []Incident    `json:"incidents"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// Page runs health checks in the background, records uptime in Redis and serves a cached snapshot
+type Page struct {
+	checker  *health.HealthChecker
+	redis    *redis.Client
+	interval time.Duration
+
+	mu       sync.RWMutex
+	snapshot *Snapshot
+}
+
+// NewPage registers the status page checks: database, Redis, SMTP and OAuth provider reachability
+// Only the database and Redis are critical; third-party outages degrade the service without
+// taking it out of load balancer rotation
+func NewPage(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *Page {
+	checker := health.New("auth-service", cfg.Health.Timeout)
+	checker.AddCheck("database", health.DatabaseCheck(db))
+	checker.AddCheck("redis", health.RedisCheck(redisClient))
+	if cfg.Email.SMTPHost != "" {
+		checker.AddCheck("email", smtpCheck(cfg.Email.SMTPHost, cfg.Email.SMTPPort),
+			health.Informational(), health.WithCheckTimeout(probeTimeout))
+	}
+	for name, url := range oauthProviders {
+		checker.AddCheck(name, reachabilityCheck(url),
+			health.Informational(), health.WithCheckTimeout(probeTimeout))
+	}
+	// Flapping components are shown as degraded rather than bouncing between up and down
+	checker.EnableHistory(health.HistoryOptions{
+		Size:  historySize,
+		Store: health.NewRedisHistoryStore(redisClient, "auth-service", historySize, historyTTL),
+	})
+
+	interval := cfg.Health.CheckInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &Page{
+		checker:  checker,
+		redis:    redisClient,
+		interval: interval,
+	}
+}
+
+// AddCheck registers another component, e.g. one only known once the container is built
+func (p *Page) AddCheck(name string, check health.Check, opts ...health.CheckOption) {
+	p.checker.AddCheck(name, check, opts...)
+}
+
+// Start refreshes the snapshot every check interval until ctx is cancelled
+func (p *Page) Start(ctx context.Context) {
+	go func() {
+		if err := p.checker.LoadHistory(ctx); err != nil {
+			log.Printf("Failed to load health history: %v", err)
+		}
+		p.refresh(ctx)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.refresh(ctx)
+			}
+		}
+	}()
+}
+
+// refresh runs all checks, records their outcome and rebuilds the cached snapshot
+func (p *Page) refresh(ctx context.Context) {
+	overall := p.checker.RecordHealth(ctx)
+
+	names := make([]string, 0, len(overall.Checks))
+	for name, result := range overall.Checks {
+		names = append(names, name)
+		if err := p.recordUptime(ctx, name, result.Status != health.StatusUnhealthy); err != nil {
+			log.Printf("Failed to record uptime for %s: %v", name, err)
+		}
+	}
+	sort.Strings(names)
+
+	components := make([]Component, 0, len(names))
+	for _, name := range names {
+		uptime, err := p.uptime(ctx, name)
+		if err != nil {
+			log.Printf("Failed to read uptime for %s: %v", name, err)
+		}
+		component := Component{Name: name, Status: overall.Checks[name].Status, Uptime: uptime}
+		if trend, ok := overall.Trends[name]; ok {
+			component.Trend = &trend
+		}
+		components = append(components, component)
+	}
+
+	incidents, err := p.ListIncidents(ctx)
+	if err != nil {
+		log.Printf("Failed to load status incidents: %v", err)
+	}
+
+	snapshot := &Snapshot{
+		Status:      overall.Status,
+		Components:  components,
+		Incidents:   incidents,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	p.mu.Lock()
+	p.snapshot = snapshot
+	p.mu.Unlock()
+}
+
+// Snapshot returns the cached status, building it on first use
+func (p *Page) Snapshot(ctx context.Context) *Snapshot {
+	p.mu.RLock()
+	snapshot := p.snapshot
+	p.mu.RUnlock()
+
+	if snapshot == nil {
+		p.refresh(ctx)
+		p.mu.RLock()
+		snapshot = p.snapshot
+		p.mu.RUnlock()
+	}
+	return snapshot
+}
+
+// recordUptime increments today's sample counters for a component
+func (p *Page) recordUptime(ctx context.Context, component string, up bool) error {
+	key := uptimeKey(component, time.Now().UTC())
+
+	pipe := p.redis.TxPipeline()
+	pipe.HIncrBy(ctx, key, "total", 1)
+	if up {
+		pipe.HIncrBy(ctx, key, "up", 1)
+	}
+	pipe.Expire(ctx, key, uptimeRetention)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// uptime computes the percentage of successful samples per reporting window
+func (p *Page) uptime(ctx context.Context, component string) (map[string]float64, error) {
+	maxDays := uptimeWindows[len(uptimeWindows)-1]
+	today := time.Now().UTC()
+
+	pipe := p.redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, maxDays)
+	for day := 0; day < maxDays; day++ {
+		cmds[day] = pipe.HGetAll(ctx, uptimeKey(component, today.AddDate(0, 0, -day)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	result := make(map[string]float64, len(uptimeWindows))
+	var total, up int64
+	window := 0
+	for day := 0; day < maxDays; day++ {
+		counts := cmds[day].Val()
+		t, _ := strconv.ParseInt(counts["total"], 10, 64)
+		u, _ := strconv.ParseInt(counts["up"], 10, 64)
+		total += t
+		up += u
+
+		if day+1 == uptimeWindows[window] {
+			if total > 0 {
+				result[windowLabel(uptimeWindows[window])] = float64(up) * 100 / float64(total)
+			}
+			window++
+		}
+	}
+	return result, nil
+}
+
+// ListIncidents returns active incidents, newest first
+func (p *Page) ListIncidents(ctx context.Context) ([]Incident, error) {
+	values, err := p.redis.HGetAll(ctx, incidentsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := make([]Incident, 0, len(values))
+	for _, value := range values {
+		var incident Incident
+		if err := json.Unmarshal([]byte(value), &incident); err == nil {
+			incidents = append(incidents, incident)
+		}
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].CreatedAt.After(incidents[j].CreatedAt)
+	})
+	return incidents, nil
+}
+
+// CreateIncident stores an incident and publishes it on the cached snapshot
+func (p *Page) CreateIncident(ctx context.Context, incident *Incident) error {
+	incident.ID = models.NewID().String()
+	incident.CreatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(incident)
+	if err != nil {
+		return err
+	}
+	if err := p.redis.HSet(ctx, incidentsKey, incident.ID, data).Err(); err != nil {
+		return err
+	}
+
+	p.refreshIncidents(ctx)
+	return nil
+}
+
+// ResolveIncident removes an incident from storage and the cached snapshot
+func (p *Page) ResolveIncident(ctx context.Context, id string) error {
+	removed, err := p.redis.HDel(ctx, incidentsKey, id).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrIncidentNotFound
+	}
+
+	p.refreshIncidents(ctx)
+	return nil
+}
+
+// refreshIncidents updates the cached snapshot's incidents without re-running health checks
+func (p *Page) refreshIncidents(ctx context.Context) {
+	incidents, err := p.ListIncidents(ctx)
+	if err != nil {
+		log.Printf("Failed to load status incidents: %v", err)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.snapshot != nil {
+		updated := *p.snapshot
+		updated.Incidents = incidents
+		p.snapshot = &updated
+	}
+}
+
+// Handler serves the public status page; responses may be cached for one check interval
+func (p *Page) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		snapshot := p.Snapshot(c.Request.Context())
+
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(p.interval.Seconds())))
+		c.JSON(http.StatusOK, snapshot)
+	}
+}
+
+// OnTransition forwards component status transitions (e.g. to the EventBus) as they are detected
+func (p *Page) OnTransition(listener health.TransitionListener) {
+	p.checker.OnTransition(listener)
+}
+
+// ReadinessHandler reports whether the critical dependencies can serve traffic
+func (p *Page) ReadinessHandler() gin.HandlerFunc {
+	return p.checker.ReadinessHandler()
+}
+
+// LivenessHandler reports whether the process itself is alive
+func (p *Page) LivenessHandler() gin.HandlerFunc {
+	return p.checker.LivenessHandler()
+}
+
+// smtpCheck verifies the email provider accepts TCP connections
+func smtpCheck(host string, port int) health.Check {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	return health.CustomCheck("email", func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return fmt.Errorf("smtp server unreachable: %w", err)
+		}
+		return conn.Close()
+	})
+}
+
+// reachabilityCheck reports third-party endpoints as degraded (not unhealthy) when unreachable,
+// since an OAuth outage only affects social login
+func reachabilityCheck(url string) health.Check {
+	return func(ctx context.Context) health.CheckResult {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return health.CheckResult{Status: health.StatusDegraded, Error: err.Error()}
+		}
+
+		client := &http.Client{
+			// Provider login pages redirect; reaching them is enough
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return health.CheckResult{Status: health.StatusDegraded, Error: fmt.Sprintf("unreachable: %v", err)}
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 500 {
+			return health.CheckResult{Status: health.StatusDegraded, Error: fmt.Sprintf("provider returned status %d", resp.StatusCode)}
+		}
+		return health.CheckResult{Status: health.StatusHealthy, Message: "reachable"}
+	}
+}
+
+func uptimeKey(component string, day time.Time) string {
+	return fmt.Sprintf("%s:%s:%s", uptimeKeyPrefix, component, day.Format(uptimeDateLayout))
+}
+
+func windowLabel(days int) string {
+	return fmt.Sprintf("%dd", days)
+}
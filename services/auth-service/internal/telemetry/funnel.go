@@ -0,0 +1,259 @@
+// Package telemetry records anonymized product analytics such as the login funnel
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-service/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FunnelStage is a step of the login funnel
+type FunnelStage string
+
+// Login funnel stages in the order a successful attempt reaches them
+const (
+	StageStart              FunnelStage = "start"
+	StagePasswordOK         FunnelStage = "password_ok"
+	StageTwoFactorChallenge FunnelStage = "two_factor_challenge"
+	StageSuccess            FunnelStage = "success"
+	StageFailure            FunnelStage = "failure"
+)
+
+// Failure reasons recorded with StageFailure; they are low-cardinality and contain no user data
+const (
+	ReasonUnknownUser      = "unknown_user"
+	ReasonInvalidPassword  = "invalid_password"
+	ReasonLocked           = "locked"
+	ReasonInactive         = "inactive"
+	ReasonTwoFactorOverdue = "two_factor_enrollment_overdue"
+	ReasonCaptcha          = "captcha"
+	ReasonCountryBlocked   = "country_blocked"
+	ReasonInternal         = "internal_error"
+)
+
+const (
+	// funnelKeyPrefix prefixes the per-hour Redis hashes (telemetry:login_funnel:2006010215)
+	funnelKeyPrefix = "telemetry:login_funnel:"
+	// hourLayout formats the hour bucket in funnel keys
+	hourLayout = "2006010215"
+	// failurePrefix prefixes failure reason fields in a bucket
+	failurePrefix = "failure:"
+	// writeTimeout bounds each Redis write so telemetry never stalls a login
+	writeTimeout = 500 * time.Millisecond
+)
+
+// LoginFunnel samples login attempts and aggregates their stages into hourly Redis buckets
+// Only stage counts are stored: no user IDs, emails or addresses
+type LoginFunnel struct {
+	client     *redis.Client
+	sampleRate float64
+	retention  time.Duration
+	now        func() time.Time
+}
+
+// NewLoginFunnel creates a funnel recorder; it returns nil when telemetry is disabled
+func NewLoginFunnel(client *redis.Client, cfg config.TelemetryConfig) *LoginFunnel {
+	if !cfg.Enabled || client == nil {
+		return nil
+	}
+	return &LoginFunnel{
+		client:     client,
+		sampleRate: cfg.SampleRate,
+		retention:  cfg.Retention,
+		now:        time.Now,
+	}
+}
+
+// SampleRate returns the fraction of login attempts being recorded
+func (f *LoginFunnel) SampleRate() float64 {
+	if f == nil {
+		return 0
+	}
+	return f.sampleRate
+}
+
+// Start records the start of a login attempt
+// It returns nil when the attempt is not sampled; every FunnelAttempt method is safe to call on nil
+func (f *LoginFunnel) Start() *FunnelAttempt {
+	if f == nil || (f.sampleRate < 1 && rand.Float64() >= f.sampleRate) {
+		return nil
+	}
+
+	// The whole attempt is counted in the hour it started so stages of one attempt never straddle buckets
+	attempt := &FunnelAttempt{funnel: f, hour: f.now().UTC().Truncate(time.Hour)}
+	attempt.record(string(StageStart))
+	return attempt
+}
+
+// FunnelAttempt tracks the stages reached by one sampled login attempt
+type FunnelAttempt struct {
+	funnel *LoginFunnel
+	hour   time.Time
+	done   bool
+}
+
+// Reach records that the attempt progressed to stage
+func (a *FunnelAttempt) Reach(stage FunnelStage) {
+	if a == nil || a.done {
+		return
+	}
+	if stage == StageSuccess {
+		a.done = true
+	}
+	a.record(string(stage))
+}
+
+// Fail records the attempt as failed with one of the Reason constants
+func (a *FunnelAttempt) Fail(reason string) {
+	if a == nil || a.done {
+		return
+	}
+	a.done = true
+	a.record(string(StageFailure), failurePrefix+reason)
+}
+
+func (a *FunnelAttempt) record(fields ...string) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	key := funnelKeyPrefix + a.hour.Format(hourLayout)
+	pipe := a.funnel.client.Pipeline()
+	for _, field := range fields {
+		pipe.HIncrBy(ctx, key, field, 1)
+	}
+	pipe.Expire(ctx, key, a.funnel.retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️  Failed to record login funnel stage %s: %v", fields[0], err)
+	}
+}
+
+// FunnelBucket aggregates the sampled attempts started in one hour (or over the whole report)
+type FunnelBucket struct {
+	Hour     *time.Time            `json:"hour,omitempty"`
+	Stages   map[FunnelStage]int64 `json:"stages"`
+	Failures map[string]int64      `json:"failures"`
+	// Conversion is the share of attempts at each stage that reached the next one
+	Conversion map[string]float64 `json:"conversion"`
+	// DropOff counts attempts that stopped after a stage, keyed by that stage
+	DropOff map[FunnelStage]int64 `json:"drop_off"`
+	// EstimatedAttempts scales the sampled start count by the current sample rate
+	EstimatedAttempts int64 `json:"estimated_attempts"`
+}
+
+// FunnelReport is the hourly login funnel returned to administrators
+type FunnelReport struct {
+	SampleRate float64         `json:"sample_rate"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	Total      *FunnelBucket   `json:"total"`
+	Hours      []*FunnelBucket `json:"hours"`
+}
+
+// Report aggregates the last hours hourly buckets, including the current partial hour
+func (f *LoginFunnel) Report(ctx context.Context, hours int) (*FunnelReport, error) {
+	if f == nil {
+		return nil, fmt.Errorf("login telemetry is disabled")
+	}
+	if hours < 1 {
+		hours = 1
+	}
+	if maxHours := int(f.retention / time.Hour); maxHours > 0 && hours > maxHours {
+		hours = maxHours
+	}
+
+	to := f.now().UTC().Truncate(time.Hour)
+	from := to.Add(-time.Duration(hours-1) * time.Hour)
+
+	pipe := f.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, hours)
+	for i := 0; i < hours; i++ {
+		cmds[i] = pipe.HGetAll(ctx, funnelKeyPrefix+from.Add(time.Duration(i)*time.Hour).Format(hourLayout))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read login funnel: %w", err)
+	}
+
+	report := &FunnelReport{
+		SampleRate: f.sampleRate,
+		From:       from,
+		To:         to.Add(time.Hour),
+		Total:      newBucket(nil),
+		Hours:      make([]*FunnelBucket, 0, hours),
+	}
+	for i, cmd := range cmds {
+		hour := from.Add(time.Duration(i) * time.Hour)
+		bucket := newBucket(&hour)
+		for field, value := range cmd.Val() {
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			if reason, ok := strings.CutPrefix(field, failurePrefix); ok {
+				bucket.Failures[reason] += count
+				report.Total.Failures[reason] += count
+				continue
+			}
+			bucket.Stages[FunnelStage(field)] += count
+			report.Total.Stages[FunnelStage(field)] += count
+		}
+		bucket.finish(f.sampleRate)
+		report.Hours = append(report.Hours, bucket)
+	}
+	report.Total.finish(f.sampleRate)
+
+	return report, nil
+}
+
+func newBucket(hour *time.Time) *FunnelBucket {
+	return &FunnelBucket{
+		Hour:       hour,
+		Stages:     make(map[FunnelStage]int64),
+		Failures:   make(map[string]int64),
+		Conversion: make(map[string]float64),
+		DropOff:    make(map[FunnelStage]int64),
+	}
+}
+
+// finish derives conversion rates and drop-off from the raw stage counts
+// Two-factor challenges are optional, so password_ok converts straight to success
+func (b *FunnelBucket) finish(sampleRate float64) {
+	start := b.Stages[StageStart]
+	passwordOK := b.Stages[StagePasswordOK]
+	success := b.Stages[StageSuccess]
+
+	b.Conversion["start_to_password_ok"] = ratio(passwordOK, start)
+	b.Conversion["password_ok_to_success"] = ratio(success, passwordOK)
+	if challenged := b.Stages[StageTwoFactorChallenge]; challenged > 0 {
+		b.Conversion["password_ok_to_two_factor_challenge"] = ratio(challenged, passwordOK)
+	}
+	b.Conversion["start_to_success"] = ratio(success, start)
+
+	b.DropOff[StageStart] = nonNegative(start - passwordOK)
+	b.DropOff[StagePasswordOK] = nonNegative(passwordOK - success)
+
+	if sampleRate > 0 {
+		b.EstimatedAttempts = int64(float64(start) / sampleRate)
+	}
+}
+
+func ratio(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
+
+func nonNegative(n int64) int64 {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,116 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithms accepted for passkeys, in order of preference (RFC 9053)
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// supportedAlgorithms are offered in pubKeyCredParams
+var supportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters (RFC 9052 section 7)
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1 // Also the RSA modulus
+	coseX         = -2 // Also the RSA exponent
+	coseY         = -3
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// minRSABits rejects keys too weak to trust
+const minRSABits = 2048
+
+var errUnsupportedKey = errors.New("unsupported credential public key")
+
+// coseKey is a credential public key decoded from its COSE encoding
+type coseKey struct {
+	algorithm int64
+	key       crypto.PublicKey
+}
+
+// parseCOSEKey decodes a COSE_Key and returns the bytes that follow it
+func parseCOSEKey(data []byte) (*coseKey, []byte, error) {
+	item, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	params, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, nil, errUnsupportedKey
+	}
+
+	kty, _ := params[int64(coseKeyType)].(int64)
+	alg, _ := params[int64(coseAlgorithm)].(int64)
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256:
+		crv, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		y, _ := params[int64(coseY)].([]byte)
+		if crv != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, nil, errUnsupportedKey
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, nil, errors.New("credential public key is not on the P-256 curve")
+		}
+		return &coseKey{algorithm: alg, key: key}, rest, nil
+
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA:
+		crv, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		if crv != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, nil, errUnsupportedKey
+		}
+		return &coseKey{algorithm: alg, key: ed25519.PublicKey(x)}, rest, nil
+
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		n, _ := params[int64(coseCurve)].([]byte)
+		e, _ := params[int64(coseX)].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			return nil, nil, errUnsupportedKey
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSABits {
+			return nil, nil, fmt.Errorf("RSA credential keys must be at least %d bits", minRSABits)
+		}
+		return &coseKey{algorithm: alg, key: key}, rest, nil
+	}
+	return nil, nil, errUnsupportedKey
+}
+
+// verify checks signature over message with the key's algorithm
+func (k *coseKey) verify(message, signature []byte) bool {
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, message, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+// Package webauthn implements the relying party side of WebAuthn passkey ceremonies
+// Options are encoded like PublicKeyCredentialCreationOptionsJSON / RequestOptionsJSON (binary fields as
+// base64url), so browsers can pass them to PublicKeyCredential.parseCreationOptionsFromJSON. Attestation
+// is not requested ("none"), so attestation statements are never verified
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"auth-service/internal/config"
+)
+
+// Ceremony errors; callers treat them all as a failed registration or login
+var (
+	ErrChallengeMismatch = errors.New("passkey response does not answer this challenge")
+	ErrOriginNotAllowed  = errors.New("passkey response comes from an origin that is not allowed")
+	ErrInvalidResponse   = errors.New("passkey response is malformed")
+	ErrRPIDMismatch      = errors.New("passkey is scoped to a different site")
+	ErrUserNotPresent    = errors.New("authenticator did not confirm user presence")
+	ErrUserNotVerified   = errors.New("authenticator did not verify the user")
+	ErrBadSignature      = errors.New("passkey signature is invalid")
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackupState    = 0x10
+	flagAttestedData   = 0x40
+)
+
+// challengeBytes is the entropy of a ceremony challenge
+const challengeBytes = 32
+
+// maxCredentialIDLength is the largest credential ID the spec allows
+const maxCredentialIDLength = 1023
+
+// RelyingParty checks registration and login responses for one configured site
+type RelyingParty struct {
+	config config.WebAuthnConfig
+	rpHash [32]byte
+}
+
+// New creates the relying party for the configured rp_id; passkeys are disabled when it returns nil
+func New(cfg config.WebAuthnConfig) *RelyingParty {
+	if cfg.RPID == "" {
+		return nil
+	}
+	return &RelyingParty{config: cfg, rpHash: sha256.Sum256([]byte(cfg.RPID))}
+}
+
+// NewChallenge returns a random base64url challenge for one ceremony
+func NewChallenge() (string, error) {
+	buf := make([]byte, challengeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// DecodeBase64URL decodes a base64url field, with or without padding
+func DecodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// CredentialDescriptor identifies a registered credential to the browser
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"` // base64url credential ID
+	Transports []string `json:"transports,omitempty"`
+}
+
+// NewCredentialDescriptor describes a stored credential
+func NewCredentialDescriptor(credentialID string, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{Type: "public-key", ID: credentialID, Transports: transports}
+}
+
+// CreationOptions are passed to navigator.credentials.create
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     rpEntity               `json:"rp"`
+	User                   userEntity             `json:"user"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"` // Milliseconds
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+type rpEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	ID          string `json:"id"` // base64url user handle
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// RequestOptions are passed to navigator.credentials.get
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"` // Milliseconds
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// CreationOptions builds registration options for the user identified by userHandle
+// Credentials in exclude are already registered, so the authenticator won't create a second one
+func (rp *RelyingParty) CreationOptions(challenge string, userHandle []byte, name, displayName string, exclude []CredentialDescriptor) *CreationOptions {
+	params := make([]credentialParameter, 0, len(supportedAlgorithms))
+	for _, alg := range supportedAlgorithms {
+		params = append(params, credentialParameter{Type: "public-key", Alg: alg})
+	}
+	return &CreationOptions{
+		Challenge:          challenge,
+		RP:                 rpEntity{ID: rp.config.RPID, Name: rp.config.RPName},
+		User:               userEntity{ID: base64.RawURLEncoding.EncodeToString(userHandle), Name: name, DisplayName: displayName},
+		PubKeyCredParams:   params,
+		Timeout:            rp.config.ChallengeTTL.Milliseconds(),
+		ExcludeCredentials: exclude,
+		// Discoverable credentials allow login without typing an email first
+		AuthenticatorSelection: authenticatorSelection{ResidentKey: "preferred", UserVerification: rp.config.UserVerification},
+		Attestation:            "none",
+	}
+}
+
+// RequestOptions builds login options; an empty allow list lets the user pick any discoverable passkey
+func (rp *RelyingParty) RequestOptions(challenge string, allow []CredentialDescriptor) *RequestOptions {
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.config.ChallengeTTL.Milliseconds(),
+		RPID:             rp.config.RPID,
+		AllowCredentials: allow,
+		UserVerification: rp.config.UserVerification,
+	}
+}
+
+// Credential is a newly registered passkey
+type Credential struct {
+	ID             string // base64url credential ID
+	PublicKey      []byte // COSE_Key
+	Algorithm      int64
+	SignCount      uint32
+	AAGUID         string // Authenticator model, formatted as a UUID; all zeros when not disclosed
+	BackupEligible bool   // Synced passkey (e.g. iCloud Keychain, Google Password Manager)
+	BackupState    bool
+	UserVerified   bool
+}
+
+// Assertion is the outcome of a verified login
+type Assertion struct {
+	SignCount    uint32
+	BackupState  bool
+	UserVerified bool
+}
+
+// VerifyRegistration checks a navigator.credentials.create response against challenge
+// and returns the credential to store
+func (rp *RelyingParty) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	item, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	attestation, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+
+	data, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if data.flags&flagAttestedData == 0 || len(data.rest) < 18 {
+		return nil, fmt.Errorf("%w: no attested credential data", ErrInvalidResponse)
+	}
+
+	aaguid := data.rest[:16]
+	idLength := int(binary.BigEndian.Uint16(data.rest[16:18]))
+	body := data.rest[18:]
+	if idLength == 0 || idLength > maxCredentialIDLength || idLength > len(body) {
+		return nil, fmt.Errorf("%w: invalid credential ID", ErrInvalidResponse)
+	}
+	credentialID := body[:idLength]
+	publicKey := body[idLength:]
+
+	key, rest, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	return &Credential{
+		ID:             base64.RawURLEncoding.EncodeToString(credentialID),
+		PublicKey:      append([]byte(nil), publicKey[:len(publicKey)-len(rest)]...),
+		Algorithm:      key.algorithm,
+		SignCount:      data.signCount,
+		AAGUID:         formatAAGUID(aaguid),
+		BackupEligible: data.flags&flagBackupEligible != 0,
+		BackupState:    data.flags&flagBackupState != 0,
+		UserVerified:   data.flags&flagUserVerified != 0,
+	}, nil
+}
+
+// VerifyAssertion checks a navigator.credentials.get response against challenge and the stored public key
+func (rp *RelyingParty) VerifyAssertion(challenge string, publicKey, clientDataJSON, authenticatorData, signature []byte) (*Assertion, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return nil, err
+	}
+
+	data, err := rp.parseAuthenticatorData(authenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, _, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authenticatorData...), clientDataHash[:]...)
+	if !key.verify(signed, signature) {
+		return nil, ErrBadSignature
+	}
+
+	return &Assertion{
+		SignCount:    data.signCount,
+		BackupState:  data.flags&flagBackupState != 0,
+		UserVerified: data.flags&flagUserVerified != 0,
+	}, nil
+}
+
+// ChallengeOf returns the challenge a response answers, so the caller can look up the ceremony it started
+// The response is only trusted once VerifyRegistration or VerifyAssertion succeeds
+func ChallengeOf(clientDataJSON []byte) (string, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil || data.Challenge == "" {
+		return "", ErrInvalidResponse
+	}
+	return strings.TrimRight(data.Challenge, "="), nil
+}
+
+// clientData is the part of CollectedClientData the relying party checks
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if data.Type != ceremony {
+		return fmt.Errorf("%w: unexpected ceremony %q", ErrInvalidResponse, data.Type)
+	}
+	if strings.TrimRight(data.Challenge, "=") != challenge {
+		return ErrChallengeMismatch
+	}
+	if data.CrossOrigin {
+		return ErrOriginNotAllowed
+	}
+	for _, origin := range rp.config.Origins {
+		if data.Origin == origin {
+			return nil
+		}
+	}
+	return ErrOriginNotAllowed
+}
+
+// authenticatorData is the fixed-size header of authenticator data followed by the variable part
+type authenticatorData struct {
+	flags     byte
+	signCount uint32
+	rest      []byte
+}
+
+func (rp *RelyingParty) parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data too short", ErrInvalidResponse)
+	}
+	if !bytes.Equal(raw[:32], rp.rpHash[:]) {
+		return nil, ErrRPIDMismatch
+	}
+
+	data := &authenticatorData{flags: raw[32], signCount: binary.BigEndian.Uint32(raw[33:37]), rest: raw[37:]}
+	if data.flags&flagUserPresent == 0 {
+		return nil, ErrUserNotPresent
+	}
+	if rp.config.UserVerification == config.UserVerificationRequired && data.flags&flagUserVerified == 0 {
+		return nil, ErrUserNotVerified
+	}
+	return data, nil
+}
+
+// formatAAGUID renders an authenticator model ID as a UUID string
+func formatAAGUID(aaguid []byte) string {
+	h := hex.EncodeToString(aaguid)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
@@ -0,0 +1,96 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// aes128gcm content coding parameters (RFC 8188, RFC 8291)
+const (
+	authSecretBytes = 16
+	saltBytes       = 16
+	recordSize      = 4096 // One record; push services accept at most 4096 bytes of body
+	headerBytes     = saltBytes + 4 + 1 + 65
+	tagBytes        = 16
+
+	// MaxPayload is the largest payload Send accepts: the record less the header, tag and delimiter
+	MaxPayload = recordSize - headerBytes - tagBytes - 1
+)
+
+// encrypt encrypts payload for sub as a single aes128gcm record (RFC 8291 section 3.4)
+// A fresh ephemeral key and salt are used for every message
+func encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	if len(payload) > MaxPayload {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d", ErrPayloadTooLarge, len(payload), MaxPayload)
+	}
+	browserKey, authSecret, err := sub.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := serverKey.ECDH(browserKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+	}
+	salt := make([]byte, saltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	// IKM binds the shared secret to the browser's auth secret and both public keys
+	keyInfo := append([]byte("WebPush: info\x00"), browserKey.Bytes()...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm, err := expand(sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := expand(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := expand(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, key ID length and the key ID, which is the server's public key
+	body := make([]byte, 0, headerBytes+len(payload)+1+tagBytes)
+	body = append(body, salt...)
+	body = binary.BigEndian.AppendUint32(body, recordSize)
+	body = append(body, byte(len(serverPublic)))
+	body = append(body, serverPublic...)
+	// 0x02 delimits the last (and only) record; no padding follows
+	plaintext := append(append(make([]byte, 0, len(payload)+1), payload...), 0x02)
+	return gcm.Seal(body, nonce, plaintext, nil), nil
+}
+
+// expand derives length bytes with HKDF-SHA-256
+func expand(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,189 @@
+// Package webpush sends push messages to browser subscriptions (Push API, RFC 8030)
+// Payloads are encrypted for the subscription (aes128gcm, RFC 8291) and requests identify this server
+// with a VAPID JWT (RFC 8292) signed by the key browsers subscribed with
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-service/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrSubscriptionGone means the push service no longer knows the subscription (404 or 410): the
+	// browser unsubscribed or the subscription expired, so it should be deleted
+	ErrSubscriptionGone = errors.New("push subscription has expired or was unsubscribed")
+	// ErrInvalidSubscription means the endpoint or keys a browser sent can't be used
+	ErrInvalidSubscription = errors.New("invalid push subscription")
+	// ErrPayloadTooLarge means the message doesn't fit the 4096 bytes push services accept
+	ErrPayloadTooLarge = errors.New("push payload is too large")
+)
+
+// vapidTokenLifetime is how long a VAPID JWT is valid; push services reject more than 24 hours
+const vapidTokenLifetime = 12 * time.Hour
+
+// Subscription is what PushSubscription.toJSON() returns in the browser
+type Subscription struct {
+	Endpoint string
+	P256dh   string // base64url uncompressed P-256 public key of the browser
+	Auth     string // base64url 16-byte authentication secret
+}
+
+// Validate checks that the endpoint is an https URL and the keys can encrypt a payload
+func (s Subscription) Validate() error {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return fmt.Errorf("%w: endpoint must be an https URL", ErrInvalidSubscription)
+	}
+	if _, _, err := s.keys(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// keys decodes the browser's public key and authentication secret
+func (s Subscription) keys() (*ecdh.PublicKey, []byte, error) {
+	raw, err := decodeBase64URL(s.P256dh)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: p256dh is not base64url", ErrInvalidSubscription)
+	}
+	publicKey, err := ecdh.P256().NewPublicKey(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: p256dh is not a P-256 public key", ErrInvalidSubscription)
+	}
+	secret, err := decodeBase64URL(s.Auth)
+	if err != nil || len(secret) != authSecretBytes {
+		return nil, nil, fmt.Errorf("%w: auth must be a base64url %d-byte secret", ErrInvalidSubscription, authSecretBytes)
+	}
+	return publicKey, secret, nil
+}
+
+// Pusher sends encrypted, VAPID-signed push messages
+type Pusher struct {
+	config    config.WebPushConfig
+	key       *ecdsa.PrivateKey
+	publicKey string
+	client    *http.Client
+}
+
+// New creates the pusher for web_push; pushes are disabled when it returns nil
+// client is used for push service requests; nil uses one with web_push.timeout
+func New(cfg config.WebPushConfig, client *http.Client) (*Pusher, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	key, err := cfg.ParseVAPIDKey()
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID key: %w", err)
+	}
+	publicKey, err := key.ECDH()
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID key: %w", err)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+	return &Pusher{
+		config:    cfg,
+		key:       key,
+		publicKey: base64.RawURLEncoding.EncodeToString(publicKey.PublicKey().Bytes()),
+		client:    client,
+	}, nil
+}
+
+// PublicKey is the base64url VAPID public key browsers pass to pushManager.subscribe as applicationServerKey
+func (p *Pusher) PublicKey() string {
+	return p.publicKey
+}
+
+// AllowsEndpoint reports whether endpoint belongs to one of the push services of web_push.endpoint_hosts,
+// so users can't make this server send requests to arbitrary hosts
+func (p *Pusher) AllowsEndpoint(endpoint string) bool {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, allowed := range p.config.EndpointHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Send encrypts payload for sub and posts it to the subscription's push service
+// ErrSubscriptionGone reports a subscription the push service has dropped
+func (p *Pusher) Send(ctx context.Context, sub Subscription, payload []byte) error {
+	body, err := encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := p.vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(p.config.TTL.Seconds())))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	}
+	return fmt.Errorf("push service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+}
+
+// vapidAuthorization is the Authorization header for the push service of endpoint (RFC 8292 section 3)
+func (p *Pusher) vapidAuthorization(endpoint string) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": time.Now().Add(vapidTokenLifetime).Unix(),
+		"sub": p.config.Subject,
+	})
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, p.publicKey), nil
+}
+
+// decodeBase64URL accepts base64url with or without padding, as browsers and libraries differ
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
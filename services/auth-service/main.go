@@ -2,25 +2,25 @@ package main
 
 import (
 	"auth-service/internal/config"
-	"auth-service/internal/database"
-	"auth-service/internal/handlers"
-	localMiddleware "auth-service/internal/middleware"
-	"auth-service/internal/repositories"
-	"auth-service/internal/services"
+	"auth-service/internal/container"
+	"auth-service/internal/routes"
 	"context"
+	"embed"
 	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	sharedDB "shared/database"
-	sharedMiddleware "shared/middleware"
 )
 
+// migrationFiles embeds the SQL migrations so the binary can migrate its own schema at startup
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
 // main initializes and starts the Auth Service application with complete dependency setup
 func main() {
 	// Parse command line flags for environment selection
@@ -42,49 +42,76 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize PostgreSQL database connection with retry logic
-	ctx := context.Background()
-	dbConfig := sharedDB.ConnectionConfig{
-		Host:            cfg.Database.Host,
-		Port:            cfg.Database.Port,
-		Name:            cfg.Database.Name,
-		User:            cfg.Database.User,
-		Password:        cfg.Database.Password,
-		SSLMode:         cfg.Database.SSLMode,
-		MaxOpenConns:    cfg.Database.MaxOpenConns,
-		MaxIdleConns:    cfg.Database.MaxIdleConns,
-		ConnMaxLifetime: time.Duration(cfg.Database.ConnMaxLifetime) * time.Second,
-		Timezone:        "UTC",
-	}
-	retryConfig := sharedDB.DefaultRetryConfig()
-	db, err := sharedDB.ConnectWithRetry(ctx, dbConfig, retryConfig)
+	// Build the dependency graph: database, Redis, repositories, services and handlers
+	// OAuth2 stays disabled until the container is given an OAuth2Service
+	// Each step is logged with its timing and the config file used, including the steps a failure skipped
+	ctx, stopStartup := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	deps, err := container.New(ctx, cfg, container.WithMigrations(migrationFiles))
+	stopStartup()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatalf("Failed to initialize dependencies: %v", err)
 	}
+	ctx = context.Background()
 
-	// Auto-migrate database schema to ensure tables exist and are up to date
-	if err := database.Migrate(db); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Warm critical caches before (or, without cache_warming.block_startup, while) serving traffic
+	if err := deps.CacheWarmer.Start(ctx); err != nil {
+		log.Printf("⚠️  Starting with cold caches: %v", err)
 	}
 
-	// Initialize Redis client with retry logic for session management and token blacklisting
-	redisClient := database.ConnectRedis(cfg.Redis)
+	// Refresh the public status page in the background until shutdown
+	statusCtx, stopStatus := context.WithCancel(ctx)
+	defer stopStatus()
+	deps.StatusPage.Start(statusCtx)
+
+	// Keep provider JWKS/OIDC documents warm so token validation never waits on provider HTTP calls
+	deps.Discovery.Start(statusCtx)
+
+	// End expired role grants so the audit trail records them and their holders' tokens are revoked
+	deps.RoleGrantExpirer.Start(statusCtx)
+
+	// Summarize and archive old read or expired notifications (notification_retention.enabled)
+	deps.NotificationRetention.Start(statusCtx)
+
+	// Move old user activities to object storage (activity_archive.enabled)
+	deps.ActivityArchive.Start(statusCtx)
 
-	// Initialize data access layer repositories with database connections
-	userRepo := repositories.NewUserRepository(db)
-	sessionRepo := repositories.NewSessionRepository(db, redisClient)
+	// Purge accounts deleted more than the retention period ago (deleted_user_retention.enabled)
+	deps.DeletedUserRetention.Start(statusCtx)
 
-	// Initialize business logic services with repositories and configuration
-	authService := services.NewAuthService(userRepo, sessionRepo, cfg.JWT)
-	// oauth2Service := services.NewOAuth2Service(cfg.OAuth2) // Temporarily disabled
+	// Rebuild the signed refresh token revocation list edge gateways poll (revocation_snapshot.enabled)
+	deps.RevocationSnapshots.Start(statusCtx)
+
+	// Follow read-only maintenance mode toggled by admins on any replica
+	deps.Maintenance.Start(statusCtx)
+
+	// Follow disposable and typo email domain lists replaced by admins on any replica
+	deps.EmailDomains.Start(statusCtx)
+
+	// Follow log level overrides and debug targets set by admins on any replica
+	deps.LogControl.Start(statusCtx)
+
+	// Exchange session changes with the other regions (session_replication.enabled)
+	if deps.SessionReplicator != nil {
+		deps.SessionReplicator.Start(statusCtx)
+	}
 
-	// Initialize HTTP handlers with service dependencies
-	authHandler := handlers.NewAuthHandler(authService, nil) // Pass nil for OAuth2Service temporarily
+	// SIGUSR1 switches this replica to debug logging for logging.signal_debug_duration, or back
+	debugSignal := make(chan os.Signal, 1)
+	signal.Notify(debugSignal, syscall.SIGUSR1)
+	go func() {
+		for range debugSignal {
+			deps.LogLevels.ToggleSignalDebug()
+		}
+	}()
 
 	// Setup HTTP router with middleware and route definitions
-	router := setupRouter(authHandler, cfg)
+	router := routes.NewRouter(deps, cfg)
 	
-	log.Println("✅ Rate limiting handled by Traefik Gateway")
+	if deps.RateLimiter != nil {
+		log.Println("✅ Rate limiting applied by the service (no gateway)")
+	} else {
+		log.Println("✅ Rate limiting handled by Traefik Gateway")
+	}
 
 	// HTTP Server with proper configuration
 	srv := &http.Server{
@@ -117,89 +144,10 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
-	// Close database connection
-	if sqlDB, err := db.DB(); err == nil {
-		sqlDB.Close()
-	}
-
-	// Close Redis connection
-	if redisClient != nil {
-		redisClient.Close()
+	// Close database and Redis connections
+	if err := deps.Close(); err != nil {
+		log.Printf("Failed to release dependencies: %v", err)
 	}
 
 	log.Println("✅ Auth Service stopped")
-}
-
-// setupRouter configures HTTP router with comprehensive middleware and API route definitions
-func setupRouter(authHandler *handlers.AuthHandler, cfg *config.Config) *gin.Engine {
-	router := gin.Default()
-
-	// Initialize JWT middleware with secret from config
-	jwtMiddleware := sharedMiddleware.NewJWTMiddleware(cfg.JWT.AccessSecret)
-
-	// Apply global middleware for all routes
-	router.Use(localMiddleware.CORS(&cfg.CORS)) // Cross-origin request handling
-	router.Use(localMiddleware.Logger())       // HTTP request logging for monitoring
-	router.Use(localMiddleware.Recovery())     // Panic recovery to prevent server crashes
-
-	// Health check endpoint for load balancers and monitoring systems
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":    "healthy",
-			"service":   "auth-service",
-			"timestamp": "2024-01-01T00:00:00Z",
-		})
-	})
-
-	// Prometheus metrics endpoint for application monitoring
-	router.GET("/metrics", localMiddleware.PrometheusHandler())
-
-	// API version 1 route group
-	v1 := router.Group("/api/v1")
-	{
-		// Authentication route group
-		auth := v1.Group("/auth")
-		{
-			// Public authentication endpoints (no JWT required)
-			auth.POST("/register", authHandler.Register)           // User registration
-			auth.POST("/login", authHandler.Login)                 // User authentication
-			auth.POST("/refresh", authHandler.RefreshToken)        // Token refresh
-			auth.POST("/forgot-password", authHandler.ForgotPassword) // Password reset request
-			auth.POST("/reset-password", authHandler.ResetPassword)   // Password reset execution
-
-			// OAuth2 integration endpoints for external provider authentication
-			auth.GET("/oauth/:provider", authHandler.OAuthLogin)         // OAuth login initiation
-			auth.GET("/oauth/:provider/callback", authHandler.OAuthCallback) // OAuth callback handling
-
-			// Protected endpoints requiring valid JWT authentication
-			protected := auth.Group("/")
-			protected.Use(jwtMiddleware.AuthRequired()) // JWT validation middleware
-			{
-				// Existing auth endpoints
-				protected.GET("/me", authHandler.GetMe)                     // Basic auth info only
-				protected.POST("/logout", authHandler.Logout)               // Session termination
-				protected.POST("/change-password", authHandler.ChangePassword) // Password change
-				protected.DELETE("/account", authHandler.DeleteAccount)     // Account deletion
-
-				// NEW: Unified User Service endpoints (Task 4.1 - API Integration)
-				// These endpoints moved from User Service (/api/v1/users/*) to Auth Service (/api/v1/auth/*)
-				protected.GET("/profile", authHandler.GetProfile)                    // Previously /api/v1/users/profile
-				protected.PUT("/profile", authHandler.UpdateProfile)                // Previously /api/v1/users/profile
-
-				protected.GET("/preferences", authHandler.GetUserPreferences)       // Previously /api/v1/users/preferences  
-				protected.POST("/preferences", authHandler.CreateUserPreferences)   // Create new preferences
-				protected.PUT("/preferences", authHandler.UpdateUserPreferences)    // Previously /api/v1/users/preferences
-
-				protected.GET("/activities", authHandler.GetUserActivities)         // Previously /api/v1/users/activities
-
-				protected.GET("/notifications", authHandler.GetUserNotifications)   // Previously /api/v1/users/notifications
-				protected.PUT("/notifications/:notificationId/read", authHandler.MarkNotificationAsRead) // New unified endpoint
-			}
-		}
-
-		// Token verification endpoint for API Gateway ForwardAuth integration
-		v1.POST("/verify", authHandler.VerifyToken)
-	}
-
-	return router
 }
\ No newline at end of file
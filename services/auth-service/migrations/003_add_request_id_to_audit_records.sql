@@ -0,0 +1,35 @@
+-- ==========================================
+-- Migration: 003_add_request_id_to_audit_records.sql
+-- Purpose: Correlate login attempts and user activity with the originating request ID
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Request ID returned to the client in X-Request-ID (also stamped on published events)
+ALTER TABLE login_attempts
+ADD COLUMN IF NOT EXISTS request_id VARCHAR(128);
+
+ALTER TABLE user_activities
+ADD COLUMN IF NOT EXISTS request_id VARCHAR(128);
+
+-- Support looking up every audit record produced by a single request
+CREATE INDEX IF NOT EXISTS idx_login_attempts_request_id ON login_attempts(request_id);
+CREATE INDEX IF NOT EXISTS idx_user_activities_request_id ON user_activities(request_id);
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_user_activities_request_id;
+-- DROP INDEX IF EXISTS idx_login_attempts_request_id;
+-- ALTER TABLE user_activities DROP COLUMN IF EXISTS request_id;
+-- ALTER TABLE login_attempts DROP COLUMN IF EXISTS request_id;
+-- COMMIT;
\ No newline at end of file
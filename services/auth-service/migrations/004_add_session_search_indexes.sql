@@ -0,0 +1,32 @@
+-- ==========================================
+-- Migration: 004_add_session_search_indexes.sql
+-- Purpose: Index sessions for admin search and bulk revocation by IP range and user
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- GiST index so "ip_address <<= '203.0.113.0/24'" range lookups avoid a sequential scan
+CREATE INDEX IF NOT EXISTS idx_sessions_ip_address ON sessions USING gist (ip_address inet_ops);
+
+-- Per-user listing ordered by creation time
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id_created_at ON sessions(user_id, created_at DESC);
+
+-- Most searches only consider sessions that are still valid
+CREATE INDEX IF NOT EXISTS idx_sessions_active_created_at ON sessions(created_at DESC) WHERE is_revoked = false;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_sessions_active_created_at;
+-- DROP INDEX IF EXISTS idx_sessions_user_id_created_at;
+-- DROP INDEX IF EXISTS idx_sessions_ip_address;
+-- COMMIT;
\ No newline at end of file
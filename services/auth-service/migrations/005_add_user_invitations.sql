@@ -0,0 +1,31 @@
+-- ==========================================
+-- Migration: 005_add_user_invitations.sql
+-- Purpose: Track admin invitations for accounts created without a password
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Invited accounts stay inactive with an empty password hash until the invitee accepts
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS invited_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS invited_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Pending invitations are listed and expired by invitation time
+CREATE INDEX IF NOT EXISTS idx_users_invited_at ON users(invited_at) WHERE invited_at IS NOT NULL;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_users_invited_at;
+-- ALTER TABLE users DROP COLUMN IF EXISTS invited_by;
+-- ALTER TABLE users DROP COLUMN IF EXISTS invited_at;
+-- COMMIT;
\ No newline at end of file
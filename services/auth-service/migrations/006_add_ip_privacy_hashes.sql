@@ -0,0 +1,38 @@
+-- ==========================================
+-- Migration: 006_add_ip_privacy_hashes.sql
+-- Purpose: Allow client IPs to be stored as keyed hashes instead of addresses
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- privacy.ip_storage = "hmac" writes the hex HMAC-SHA256 of the address to ip_hash and leaves ip_address NULL
+ALTER TABLE login_attempts ADD COLUMN IF NOT EXISTS ip_hash VARCHAR(64);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip_hash VARCHAR(64);
+ALTER TABLE user_activities ADD COLUMN IF NOT EXISTS ip_hash VARCHAR(64);
+
+ALTER TABLE login_attempts ALTER COLUMN ip_address DROP NOT NULL;
+
+-- Hashed addresses are still looked up by exact match (admin session search, attempts per address)
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip_hash ON login_attempts(ip_hash) WHERE ip_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_sessions_ip_hash ON sessions(ip_hash) WHERE ip_hash IS NOT NULL;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_sessions_ip_hash;
+-- DROP INDEX IF EXISTS idx_login_attempts_ip_hash;
+-- DELETE FROM login_attempts WHERE ip_address IS NULL;
+-- ALTER TABLE login_attempts ALTER COLUMN ip_address SET NOT NULL;
+-- ALTER TABLE user_activities DROP COLUMN IF EXISTS ip_hash;
+-- ALTER TABLE sessions DROP COLUMN IF EXISTS ip_hash;
+-- ALTER TABLE login_attempts DROP COLUMN IF EXISTS ip_hash;
+-- COMMIT;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- ==========================================
+-- Migration: 007_add_feed_tokens.sql
+-- Purpose: Personal feed tokens for RSS/Atom and iCal subscriptions
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Feed readers can't send an Authorization header, so the feed URL carries a personal token
+-- Only its SHA-256 is stored; rotating or revoking the token replaces or clears the hash
+ALTER TABLE users ADD COLUMN IF NOT EXISTS feed_token_hash VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_feed_token_hash ON users(feed_token_hash) WHERE feed_token_hash IS NOT NULL;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_users_feed_token_hash;
+-- ALTER TABLE users DROP COLUMN IF EXISTS feed_token_hash;
+-- COMMIT;
\ No newline at end of file
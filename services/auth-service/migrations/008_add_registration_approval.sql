@@ -0,0 +1,38 @@
+-- ==========================================
+-- Migration: 008_add_registration_approval.sql
+-- Purpose: Registration review queue for registration.mode = "approval"
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- NULL for accounts that never needed approval; queued sign-ups stay inactive until approved
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS approval_status VARCHAR(20),
+ADD COLUMN IF NOT EXISTS approval_reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS approval_reviewed_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS check_valid_approval_status;
+ALTER TABLE users ADD CONSTRAINT check_valid_approval_status
+    CHECK (approval_status IN ('pending', 'approved', 'rejected'));
+
+-- The review queue lists pending sign-ups oldest first
+CREATE INDEX IF NOT EXISTS idx_users_pending_approval ON users(created_at) WHERE approval_status = 'pending';
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_users_pending_approval;
+-- ALTER TABLE users DROP CONSTRAINT IF EXISTS check_valid_approval_status;
+-- ALTER TABLE users DROP COLUMN IF EXISTS approval_reviewed_at;
+-- ALTER TABLE users DROP COLUMN IF EXISTS approval_reviewed_by;
+-- ALTER TABLE users DROP COLUMN IF EXISTS approval_status;
+-- COMMIT;
\ No newline at end of file
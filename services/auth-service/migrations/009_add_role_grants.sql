@@ -0,0 +1,47 @@
+-- ==========================================
+-- Migration: 009_add_role_grants.sql
+-- Purpose: Time-boxed role grants (just-in-time admin) and per-user token versions
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Bumped whenever a role grant ends; access tokens issued with an older version are rejected
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
+
+-- One row per grant; ended grants are kept as the audit trail of elevated access
+CREATE TABLE IF NOT EXISTS role_grants (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role user_role NOT NULL,
+    reason TEXT NOT NULL,
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    ended_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    end_reason VARCHAR(20),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT check_valid_grant_end_reason CHECK (end_reason IN ('expired', 'revoked'))
+);
+
+-- Token issuing looks up a user's active grants; the expiry job scans active grants by expiry
+CREATE INDEX IF NOT EXISTS idx_role_grants_user_id ON role_grants(user_id);
+CREATE INDEX IF NOT EXISTS idx_role_grants_active_expiry ON role_grants(expires_at) WHERE ended_at IS NULL;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_role_grants_active_expiry;
+-- DROP INDEX IF EXISTS idx_role_grants_user_id;
+-- DROP TABLE IF EXISTS role_grants;
+-- ALTER TABLE users DROP COLUMN IF EXISTS token_version;
+-- COMMIT;
\ No newline at end of file
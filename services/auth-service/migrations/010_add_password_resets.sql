@@ -0,0 +1,41 @@
+-- ==========================================
+-- Migration: 010_add_password_resets.sql
+-- Purpose: Persisted password reset requests and the password change time that ends earlier sessions
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Refresh tokens whose login predates the latest password reset are rejected
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP WITH TIME ZONE;
+
+-- One row per forgot-password request; only the SHA-256 hash of the emailed token is stored
+CREATE TABLE IF NOT EXISTS password_resets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    request_id VARCHAR(128),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- A successful reset closes the user's other pending resets
+CREATE INDEX IF NOT EXISTS idx_password_resets_user_pending ON password_resets(user_id) WHERE used_at IS NULL;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_password_resets_user_pending;
+-- DROP TABLE IF EXISTS password_resets;
+-- ALTER TABLE users DROP COLUMN IF EXISTS password_changed_at;
+-- COMMIT;
\ No newline at end of file
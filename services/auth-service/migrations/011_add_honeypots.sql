@@ -0,0 +1,56 @@
+-- ==========================================
+-- Migration: 011_add_honeypots.sql
+-- Purpose: Honeypot accounts and canary API keys, and the audit trail of their use
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Planted credentials no legitimate client uses; identifier is the lowercased email of an
+-- account honeypot or the SHA-256 of a canary API key
+CREATE TABLE IF NOT EXISTS honeypots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(20) NOT NULL,
+    identifier VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(20),
+    label VARCHAR(200) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    trigger_count INTEGER NOT NULL DEFAULT 0,
+    last_triggered_at TIMESTAMP WITH TIME ZONE,
+    disabled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT check_valid_honeypot_kind CHECK (kind IN ('account', 'api_key'))
+);
+
+-- Login and API key checks look honeypots up by kind and identifier
+CREATE UNIQUE INDEX IF NOT EXISTS idx_honeypots_kind_identifier ON honeypots(kind, identifier);
+
+-- One row per use of a honeypot, kept after the honeypot is disabled
+CREATE TABLE IF NOT EXISTS honeypot_triggers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    honeypot_id UUID NOT NULL REFERENCES honeypots(id) ON DELETE CASCADE,
+    ip_address INET,
+    ip_hash VARCHAR(64),
+    user_agent TEXT,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_honeypot_triggers_honeypot_id ON honeypot_triggers(honeypot_id, created_at DESC);
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_honeypot_triggers_honeypot_id;
+-- DROP TABLE IF EXISTS honeypot_triggers;
+-- DROP INDEX IF EXISTS idx_honeypots_kind_identifier;
+-- DROP TABLE IF EXISTS honeypots;
+-- COMMIT;
\ No newline at end of file
@@ -0,0 +1,62 @@
+-- ==========================================
+-- Migration: 012_add_notification_retention.sql
+-- Purpose: Monthly notification summaries and the archive filled by the notification retention job
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- One row per user and calendar month, counting the notifications the retention job removed
+CREATE TABLE IF NOT EXISTS user_notification_summaries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month DATE NOT NULL,
+    notification_count INTEGER NOT NULL DEFAULT 0,
+    read_count INTEGER NOT NULL DEFAULT 0,
+    first_created_at TIMESTAMP NOT NULL,
+    last_created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_summary_month_start CHECK (EXTRACT(DAY FROM month) = 1)
+);
+
+-- Compaction adds each batch to the existing row for the user and month
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_notification_summaries_user_month ON user_notification_summaries(user_id, month);
+
+-- Notifications moved out of user_notifications by the retention job in archive mode
+CREATE TABLE IF NOT EXISTS user_notifications_archive (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    message TEXT,
+    is_read BOOLEAN NOT NULL DEFAULT false,
+    read_at TIMESTAMP,
+    action_url VARCHAR(500),
+    action_text VARCHAR(100),
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_notifications_archive_user_id ON user_notifications_archive(user_id, created_at DESC);
+
+-- The retention job selects read or expired notifications by age
+CREATE INDEX IF NOT EXISTS idx_user_notifications_retention ON user_notifications(created_at) WHERE is_read = true OR expires_at IS NOT NULL;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_user_notifications_retention;
+-- DROP INDEX IF EXISTS idx_user_notifications_archive_user_id;
+-- DROP TABLE IF EXISTS user_notifications_archive;
+-- DROP INDEX IF EXISTS idx_user_notification_summaries_user_month;
+-- DROP TABLE IF EXISTS user_notification_summaries;
+-- COMMIT;
\ No newline at end of file
@@ -0,0 +1,45 @@
+-- ==========================================
+-- Migration: 013_add_webauthn_credentials.sql
+-- Purpose: Passkeys (WebAuthn credentials) for passwordless login
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- One row per registered passkey; credential_id is the base64url ID the authenticator chose and
+-- public_key its COSE encoding
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential_id VARCHAR(1366) NOT NULL,
+    public_key BYTEA NOT NULL,
+    algorithm INTEGER NOT NULL,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    aaguid VARCHAR(36),
+    transports VARCHAR(100),
+    backup_eligible BOOLEAN NOT NULL DEFAULT false,
+    backup_state BOOLEAN NOT NULL DEFAULT false,
+    name VARCHAR(100) NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Login looks credentials up by the ID the authenticator returns
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webauthn_credentials_credential_id ON webauthn_credentials(credential_id);
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_webauthn_credentials_user_id;
+-- DROP INDEX IF EXISTS idx_webauthn_credentials_credential_id;
+-- DROP TABLE IF EXISTS webauthn_credentials;
+-- COMMIT;
\ No newline at end of file
@@ -0,0 +1,27 @@
+-- ==========================================
+-- Migration: 015_add_apple_sign_in.sql
+-- Purpose: Sign in with Apple identities on users
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Apple's "sub" is stable per team, unlike the email, which may be a private relay address
+ALTER TABLE users ADD COLUMN IF NOT EXISTS apple_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_users_apple_id ON users(apple_id) WHERE apple_id IS NOT NULL;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_users_apple_id;
+-- ALTER TABLE users DROP COLUMN IF EXISTS apple_id;
+-- COMMIT;
\ No newline at end of file
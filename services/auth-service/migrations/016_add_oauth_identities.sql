@@ -0,0 +1,35 @@
+-- ==========================================
+-- Migration: 016_add_oauth_identities.sql
+-- Purpose: Identities at OIDC providers configured by issuer under oauth2.oidc
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- The built-in providers keep a column on users; configured OIDC providers get a row per linked account
+-- keyed by the ID token's "sub", which is only unique per provider
+CREATE TABLE IF NOT EXISTS user_oauth_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Login looks accounts up by provider and subject; an account links one identity per provider
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_oauth_identities_provider_subject ON user_oauth_identities(provider, subject);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_oauth_identities_user_provider ON user_oauth_identities(user_id, provider);
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP TABLE IF EXISTS user_oauth_identities;
+-- COMMIT;
\ No newline at end of file
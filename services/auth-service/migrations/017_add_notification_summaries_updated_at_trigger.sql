@@ -0,0 +1,27 @@
+-- ==========================================
+-- Migration: 017_add_notification_summaries_updated_at_trigger.sql
+-- Purpose: Keep user_notification_summaries.updated_at current like the tables of 001_initial_schema.sql
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Every table with an updated_at column has an update_<table>_updated_at trigger; the schema validator
+-- reports the ones that are missing. 012 created this table without it
+CREATE OR REPLACE TRIGGER update_user_notification_summaries_updated_at
+    BEFORE UPDATE ON user_notification_summaries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP TRIGGER IF EXISTS update_user_notification_summaries_updated_at ON user_notification_summaries;
+-- COMMIT;
\ No newline at end of file
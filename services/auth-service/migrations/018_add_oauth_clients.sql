@@ -0,0 +1,45 @@
+-- ==========================================
+-- Migration: 018_add_oauth_clients.sql
+-- Purpose: Registry of internal services using the OAuth2 client-credentials grant
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Only the SHA-256 of a client secret is stored; secret_prefix keeps its first characters so admins
+-- can tell which secret a service is configured with. scopes is space-separated, as in OAuth2
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    client_id VARCHAR(64) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    secret_hash VARCHAR(64) NOT NULL,
+    secret_prefix VARCHAR(20) NOT NULL,
+    scopes TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Every token request looks the client up by client_id
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oauth_clients_client_id ON oauth_clients(client_id);
+
+CREATE OR REPLACE TRIGGER update_oauth_clients_updated_at
+    BEFORE UPDATE ON oauth_clients
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP TRIGGER IF EXISTS update_oauth_clients_updated_at ON oauth_clients;
+-- DROP INDEX IF EXISTS idx_oauth_clients_client_id;
+-- DROP TABLE IF EXISTS oauth_clients;
+-- COMMIT;
\ No newline at end of file
@@ -0,0 +1,57 @@
+-- ==========================================
+-- Migration: 019_add_user_merges.sql
+-- Purpose: Audit trail of duplicate accounts merged into another by an administrator
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- One row per merge. The user IDs have no foreign keys so the record outlives both accounts; the
+-- duplicate's email and username are copied because its row may be purged later. moved counts the
+-- rows reassigned per table
+CREATE TABLE IF NOT EXISTS user_merges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    source_user_id UUID NOT NULL,
+    target_user_id UUID NOT NULL,
+    merged_by UUID,
+    source_email VARCHAR(255) NOT NULL,
+    source_username VARCHAR(100) NOT NULL,
+    reason TEXT NOT NULL,
+    moved JSONB NOT NULL DEFAULT '{}',
+    request_id VARCHAR(128),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- An account can only be merged away once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_merges_source_user_id ON user_merges(source_user_id);
+CREATE INDEX IF NOT EXISTS idx_user_merges_target_user_id ON user_merges(target_user_id, created_at DESC);
+
+-- Merges are irreversible, so their records can't be changed or deleted either
+CREATE OR REPLACE FUNCTION prevent_user_merge_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'user_merges records are immutable';
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE TRIGGER prevent_user_merges_changes
+    BEFORE UPDATE OR DELETE ON user_merges
+    FOR EACH ROW EXECUTE FUNCTION prevent_user_merge_changes();
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP TRIGGER IF EXISTS prevent_user_merges_changes ON user_merges;
+-- DROP FUNCTION IF EXISTS prevent_user_merge_changes();
+-- DROP INDEX IF EXISTS idx_user_merges_target_user_id;
+-- DROP INDEX IF EXISTS idx_user_merges_source_user_id;
+-- DROP TABLE IF EXISTS user_merges;
+-- COMMIT;
\ No newline at end of file
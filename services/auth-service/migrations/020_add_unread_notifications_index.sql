@@ -0,0 +1,24 @@
+-- ==========================================
+-- Migration: 020_add_unread_notifications_index.sql
+-- Purpose: Index unread notifications per user for the unread count in /auth/bootstrap
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Counting a user's unread notifications reads only this partial index, not the read backlog
+CREATE INDEX IF NOT EXISTS idx_user_notifications_unread ON user_notifications(user_id) WHERE is_read = false;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_user_notifications_unread;
+-- COMMIT;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- ==========================================
+-- Migration: 021_add_session_remember_me.sql
+-- Purpose: Mark sessions of logins that asked to be remembered, which get the long refresh token lifetime
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Existing sessions were started with jwt.refresh_expiry
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS remember_me BOOLEAN NOT NULL DEFAULT false;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- ALTER TABLE sessions DROP COLUMN IF EXISTS remember_me;
+-- COMMIT;
\ No newline at end of file
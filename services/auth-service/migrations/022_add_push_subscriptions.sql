@@ -0,0 +1,46 @@
+-- ==========================================
+-- Migration: 022_add_push_subscriptions.sql
+-- Purpose: Web Push subscriptions of the browsers users get notifications on
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- One row per subscribed browser; p256dh and auth are the base64url keys pushes are encrypted for.
+-- Subscriptions the push service reports gone (404/410) are deleted when a push fails
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint VARCHAR(1000) NOT NULL,
+    p256dh VARCHAR(100) NOT NULL,
+    auth VARCHAR(50) NOT NULL,
+    user_agent TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- A browser re-subscribing, or signing in as another user, replaces the row of its endpoint
+CREATE UNIQUE INDEX IF NOT EXISTS idx_push_subscriptions_endpoint ON push_subscriptions(endpoint);
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user_id ON push_subscriptions(user_id);
+
+CREATE OR REPLACE TRIGGER update_push_subscriptions_updated_at
+    BEFORE UPDATE ON push_subscriptions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP TRIGGER IF EXISTS update_push_subscriptions_updated_at ON push_subscriptions;
+-- DROP INDEX IF EXISTS idx_push_subscriptions_user_id;
+-- DROP INDEX IF EXISTS idx_push_subscriptions_endpoint;
+-- DROP TABLE IF EXISTS push_subscriptions;
+-- COMMIT;
\ No newline at end of file
@@ -0,0 +1,41 @@
+-- ==========================================
+-- Migration: 023_add_user_activity_archives.sql
+-- Purpose: Manifest of the user activity archive objects written by the activity archive job
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- One row per object in object storage holding a batch of one user's activities, newest first.
+-- The activities were deleted from user_activities in the same transaction the row was inserted in
+CREATE TABLE IF NOT EXISTS user_activity_archives (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    object_key VARCHAR(500) NOT NULL,
+    activity_count INTEGER NOT NULL CHECK (activity_count > 0),
+    size_bytes BIGINT NOT NULL,
+    first_created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_activity_archives_object_key ON user_activity_archives(object_key);
+
+-- Deep history reads walk a user's objects from the newest
+CREATE INDEX IF NOT EXISTS idx_user_activity_archives_user_id ON user_activity_archives(user_id, last_created_at DESC);
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_user_activity_archives_user_id;
+-- DROP INDEX IF EXISTS idx_user_activity_archives_object_key;
+-- DROP TABLE IF EXISTS user_activity_archives;
+-- COMMIT;
\ No newline at end of file
@@ -0,0 +1,34 @@
+-- ==========================================
+-- Migration: 024_add_user_data_keys.sql
+-- Purpose: Per-user keys sealing session Data and cached user blobs in Redis, wrapped by the data master key
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- One AES-256 key per user, encrypted with AES-GCM under the master key identified by master_key_id.
+-- Deleting an account deletes its row; whatever the key sealed in Redis can't be read from then on
+CREATE TABLE IF NOT EXISTS user_data_keys (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    wrapped_key BYTEA NOT NULL,
+    master_key_id VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Finds the keys still wrapped by a retired master key
+CREATE INDEX IF NOT EXISTS idx_user_data_keys_master_key_id ON user_data_keys(master_key_id);
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_user_data_keys_master_key_id;
+-- DROP TABLE IF EXISTS user_data_keys;
+-- COMMIT;
\ No newline at end of file
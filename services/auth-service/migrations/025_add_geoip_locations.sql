@@ -0,0 +1,37 @@
+-- ==========================================
+-- Migration: 025_add_geoip_locations.sql
+-- Purpose: Country and city of login attempts and sessions, located by geoip from the client address
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Located from the full address before privacy.ip_storage truncates or hashes it; empty when unknown
+ALTER TABLE login_attempts
+ADD COLUMN IF NOT EXISTS country_code VARCHAR(2),
+ADD COLUMN IF NOT EXISTS city VARCHAR(100);
+
+ALTER TABLE sessions
+ADD COLUMN IF NOT EXISTS country_code VARCHAR(2),
+ADD COLUMN IF NOT EXISTS city VARCHAR(100);
+
+-- Login history of a user, newest first
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id_attempted_at ON login_attempts(user_id, attempted_at DESC);
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_login_attempts_user_id_attempted_at;
+-- ALTER TABLE sessions DROP COLUMN IF EXISTS city;
+-- ALTER TABLE sessions DROP COLUMN IF EXISTS country_code;
+-- ALTER TABLE login_attempts DROP COLUMN IF EXISTS city;
+-- ALTER TABLE login_attempts DROP COLUMN IF EXISTS country_code;
+-- COMMIT;
\ No newline at end of file
@@ -0,0 +1,81 @@
+-- ==========================================
+-- Migration: 026_add_roles_and_permissions.sql
+-- Purpose: Roles, permissions and their assignments, with the system roles of the user_role enum seeded
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- System roles mirror users.role and can't be deleted; custom roles are assigned through user_roles
+CREATE TABLE IF NOT EXISTS roles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(50) NOT NULL UNIQUE,
+    display_name VARCHAR(100),
+    description TEXT,
+    is_system BOOLEAN NOT NULL DEFAULT false,
+    priority INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE OR REPLACE TRIGGER update_roles_updated_at
+    BEFORE UPDATE ON roles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Permissions are named resource:action, e.g. users:read
+CREATE TABLE IF NOT EXISTS permissions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    resource VARCHAR(50) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id UUID NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    granted_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    assigned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    PRIMARY KEY (user_id, role_id)
+);
+
+-- The primary keys look up by role and by user; these serve the reverse directions
+CREATE INDEX IF NOT EXISTS idx_permissions_resource ON permissions(resource);
+CREATE INDEX IF NOT EXISTS idx_role_permissions_permission_id ON role_permissions(permission_id);
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles(role_id);
+
+INSERT INTO roles (name, display_name, description, is_system, priority) VALUES
+    ('user', 'User', 'Accounts with users.role user', true, 0),
+    ('moderator', 'Moderator', 'Accounts with users.role moderator', true, 50),
+    ('admin', 'Administrator', 'Accounts with users.role admin', true, 100)
+ON CONFLICT (name) DO NOTHING;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_user_roles_role_id;
+-- DROP INDEX IF EXISTS idx_role_permissions_permission_id;
+-- DROP INDEX IF EXISTS idx_permissions_resource;
+-- DROP TABLE IF EXISTS user_roles;
+-- DROP TABLE IF EXISTS role_permissions;
+-- DROP TABLE IF EXISTS permissions;
+-- DROP TRIGGER IF EXISTS update_roles_updated_at ON roles;
+-- DROP TABLE IF EXISTS roles;
+-- COMMIT;
\ No newline at end of file
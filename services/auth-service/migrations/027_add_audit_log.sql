@@ -0,0 +1,71 @@
+-- ==========================================
+-- Migration: 027_add_audit_log.sql
+-- Purpose: Tamper-evident audit log of admin operations and security events
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Entries form a hash chain: hash covers the entry and prev_hash, the hash of the entry at seq - 1.
+-- Actor and target IDs have no foreign keys so entries outlive the accounts they name. The address is
+-- text rather than inet so it reads back exactly as it was hashed
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    seq BIGINT NOT NULL,
+    actor_id UUID,
+    actor_type VARCHAR(20) NOT NULL,
+    action VARCHAR(150) NOT NULL,
+    target_type VARCHAR(50),
+    target_id VARCHAR(255),
+    status INTEGER,
+    changes JSONB NOT NULL DEFAULT '{}',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    request_id VARCHAR(128),
+    ip_address VARCHAR(45),
+    ip_hash VARCHAR(64),
+    user_agent TEXT,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_log_seq ON audit_log(seq);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log(actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_request_id ON audit_log(request_id);
+
+-- Entries are append-only; a changed or missing entry would also break the chain
+CREATE OR REPLACE FUNCTION prevent_audit_log_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log entries are immutable';
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE TRIGGER prevent_audit_log_changes
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION prevent_audit_log_changes();
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP TRIGGER IF EXISTS prevent_audit_log_changes ON audit_log;
+-- DROP FUNCTION IF EXISTS prevent_audit_log_changes();
+-- DROP INDEX IF EXISTS idx_audit_log_request_id;
+-- DROP INDEX IF EXISTS idx_audit_log_action;
+-- DROP INDEX IF EXISTS idx_audit_log_target;
+-- DROP INDEX IF EXISTS idx_audit_log_actor_id;
+-- DROP INDEX IF EXISTS idx_audit_log_created_at;
+-- DROP INDEX IF EXISTS idx_audit_log_seq;
+-- DROP TABLE IF EXISTS audit_log;
+-- COMMIT;
\ No newline at end of file
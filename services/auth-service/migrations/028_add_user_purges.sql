@@ -0,0 +1,29 @@
+-- ==========================================
+-- Migration: 028_add_user_purges.sql
+-- Purpose: Mark soft-deleted accounts anonymized by the deleted user retention job
+-- Author: Migration Manager
+-- Date: 2026-10-16
+-- Environment: ALL
+-- ==========================================
+
+-- 🔄 FORWARD MIGRATION (UP)
+BEGIN;
+
+-- Set when the retention job cleared an account's personal data and kept its row (mode = "anonymize")
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS purged_at TIMESTAMP;
+
+-- Finds the soft-deleted accounts still waiting to be purged, oldest deletion first
+CREATE INDEX IF NOT EXISTS idx_users_pending_purge ON users(deleted_at) WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+
+COMMIT;
+
+-- ==========================================
+-- 🔙 DOWN MIGRATION (ROLLBACK)
+-- ==========================================
+-- To rollback this migration, run:
+-- 
+-- BEGIN;
+-- DROP INDEX IF EXISTS idx_users_pending_purge;
+-- ALTER TABLE users DROP COLUMN IF EXISTS purged_at;
+-- COMMIT;
\ No newline at end of file
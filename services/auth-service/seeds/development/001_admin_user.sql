@@ -0,0 +1,19 @@
+-- ==========================================
+-- Seed: 001_admin_user.sql
+-- Purpose: Local administrator account for development
+-- Environment: development
+-- Login: admin@localhost / DevAdmin123!
+-- ==========================================
+
+INSERT INTO users (email, username, password_hash, role, is_active, email_verified, first_name, last_name)
+VALUES (
+    'admin@localhost',
+    'admin',
+    '$2a$10$raFodVz9ORlAT1B7LAoTbuIt6vDTKwHuefP02rNBu54mb5ctISBqG',
+    'admin',
+    true,
+    true,
+    'Local',
+    'Admin'
+)
+ON CONFLICT (email) DO NOTHING;
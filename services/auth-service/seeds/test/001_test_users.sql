@@ -0,0 +1,13 @@
+-- ==========================================
+-- Seed: 001_test_users.sql
+-- Purpose: Fixed accounts for integration tests (one per role)
+-- Environment: test
+-- Password for all accounts: TestUser123!
+-- ==========================================
+
+INSERT INTO users (email, username, password_hash, role, is_active, email_verified)
+VALUES
+    ('admin@test.local', 'test_admin', '$2a$04$KIkDwfkX3UIh8GetYaqfwOx.UAOC9O7YlGeyZj6N4xwpEHvZbohiu', 'admin', true, true),
+    ('moderator@test.local', 'test_moderator', '$2a$04$KIkDwfkX3UIh8GetYaqfwOx.UAOC9O7YlGeyZj6N4xwpEHvZbohiu', 'moderator', true, true),
+    ('user@test.local', 'test_user', '$2a$04$KIkDwfkX3UIh8GetYaqfwOx.UAOC9O7YlGeyZj6N4xwpEHvZbohiu', 'user', true, true)
+ON CONFLICT (email) DO NOTHING;
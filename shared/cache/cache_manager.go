@@ -2,21 +2,36 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"shared/events"
+	"shared/health"
 	"shared/redis"
+	"shared/sealing"
 
 	redisClient "github.com/redis/go-redis/v9"
 )
 
+// ErrCacheDegraded is returned by reads while Redis is reported unhealthy
+var ErrCacheDegraded = errors.New("cache is in degraded mode")
+
 // CacheManager provides centralized caching with event-driven invalidation
 type CacheManager struct {
 	redis    *redis.RedisManager
 	eventBus *events.EventBus
 	config   Config
+	degraded atomic.Bool    // Set while Redis health transitions report it unavailable
+	sealer   sealing.Sealer // Optional; cached users are stored in the clear without it
+}
+
+// sealedEntry is how a cached user is stored when a sealer is set
+type sealedEntry struct {
+	Sealed []byte `json:"sealed"`
 }
 
 // Config contains cache configuration
@@ -53,8 +68,18 @@ func NewCacheManager(client *redisClient.Client, eventBus *events.EventBus, conf
 	return cm
 }
 
+// WithDataSealer encrypts cached users under their data key, so destroying the key makes every cached
+// copy unreadable; reading one whose key was destroyed is a cache miss
+func (cm *CacheManager) WithDataSealer(sealer sealing.Sealer) *CacheManager {
+	cm.sealer = sealer
+	return cm
+}
+
 // User-specific cache operations
 func (cm *CacheManager) SetUser(ctx context.Context, userID string, user interface{}) error {
+	if cm.IsDegraded() {
+		return nil
+	}
 	key := fmt.Sprintf("user:%s", userID)
 	ttl := cm.config.UserTTL
 	if ttl == 0 {
@@ -65,12 +90,46 @@ func (cm *CacheManager) SetUser(ctx context.Context, userID string, user interfa
 		log.Printf("🗃️ Caching user: %s", userID)
 	}
 	
-	return cm.redis.Set(ctx, key, user, ttl)
+	if cm.sealer == nil {
+		return cm.redis.Set(ctx, key, user, ttl)
+	}
+	plaintext, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user: %w", err)
+	}
+	sealed, err := cm.sealer.Seal(ctx, userID, plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to seal user: %w", err)
+	}
+	return cm.redis.Set(ctx, key, sealedEntry{Sealed: sealed}, ttl)
 }
 
 func (cm *CacheManager) GetUser(ctx context.Context, userID string, dest interface{}) error {
+	if cm.IsDegraded() {
+		return ErrCacheDegraded
+	}
 	key := fmt.Sprintf("user:%s", userID)
-	return cm.redis.Get(ctx, key, dest)
+	if cm.sealer == nil {
+		return cm.redis.Get(ctx, key, dest)
+	}
+	
+	var raw json.RawMessage
+	if err := cm.redis.Get(ctx, key, &raw); err != nil {
+		return err
+	}
+	var entry sealedEntry
+	if err := json.Unmarshal(raw, &entry); err != nil || len(entry.Sealed) == 0 {
+		return json.Unmarshal(raw, dest) // Cached before sealing was enabled
+	}
+	plaintext, err := cm.sealer.Open(ctx, userID, entry.Sealed)
+	if errors.Is(err, sealing.ErrShredded) {
+		cm.redis.Delete(ctx, key)
+		return redisClient.Nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open cached user: %w", err)
+	}
+	return json.Unmarshal(plaintext, dest)
 }
 
 func (cm *CacheManager) InvalidateUser(ctx context.Context, userID string) error {
@@ -103,6 +162,9 @@ func (cm *CacheManager) InvalidateUser(ctx context.Context, userID string) error
 
 // Session cache operations
 func (cm *CacheManager) SetSession(ctx context.Context, sessionID string, session interface{}) error {
+	if cm.IsDegraded() {
+		return nil
+	}
 	key := fmt.Sprintf("session:%s", sessionID)
 	ttl := cm.config.SessionTTL
 	if ttl == 0 {
@@ -113,6 +175,9 @@ func (cm *CacheManager) SetSession(ctx context.Context, sessionID string, sessio
 }
 
 func (cm *CacheManager) GetSession(ctx context.Context, sessionID string, dest interface{}) error {
+	if cm.IsDegraded() {
+		return ErrCacheDegraded
+	}
 	key := fmt.Sprintf("session:%s", sessionID)
 	return cm.redis.Get(ctx, key, dest)
 }
@@ -159,6 +224,9 @@ func (cm *CacheManager) IsTokenBlacklisted(ctx context.Context, tokenID string)
 
 // Generic cache operations
 func (cm *CacheManager) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if cm.IsDegraded() {
+		return nil
+	}
 	if ttl == 0 {
 		ttl = cm.config.DefaultTTL
 	}
@@ -167,6 +235,9 @@ func (cm *CacheManager) Set(ctx context.Context, key string, value interface{},
 }
 
 func (cm *CacheManager) Get(ctx context.Context, key string, dest interface{}) error {
+	if cm.IsDegraded() {
+		return ErrCacheDegraded
+	}
 	return cm.redis.Get(ctx, key, dest)
 }
 
@@ -184,6 +255,9 @@ func (cm *CacheManager) Exists(ctx context.Context, key string) (bool, error) {
 
 // List cache operations (for paginated results)
 func (cm *CacheManager) SetList(ctx context.Context, listKey string, items interface{}, ttl time.Duration) error {
+	if cm.IsDegraded() {
+		return nil
+	}
 	if ttl == 0 {
 		ttl = cm.config.DefaultTTL
 	}
@@ -192,6 +266,9 @@ func (cm *CacheManager) SetList(ctx context.Context, listKey string, items inter
 }
 
 func (cm *CacheManager) GetList(ctx context.Context, listKey string, dest interface{}) error {
+	if cm.IsDegraded() {
+		return ErrCacheDegraded
+	}
 	return cm.redis.Get(ctx, fmt.Sprintf("list:%s", listKey), dest)
 }
 
@@ -219,6 +296,10 @@ func (cm *CacheManager) InvalidateListPattern(ctx context.Context, pattern strin
 
 // Cache warming operations
 func (cm *CacheManager) WarmCache(ctx context.Context, key string, loader func() (interface{}, error), ttl time.Duration) error {
+	if cm.IsDegraded() {
+		return nil // Don't load data we can't store
+	}
+
 	// Check if cache exists
 	exists, err := cm.Exists(ctx, key)
 	if err != nil {
@@ -289,6 +370,9 @@ func (cm *CacheManager) registerEventHandlers() {
 	// Auth events
 	cm.eventBus.RegisterHandler(events.TokenRevoked, cm.handleTokenRevoked)
 	cm.eventBus.RegisterHandler(events.SessionExpired, cm.handleSessionExpired)
+
+	// System events
+	cm.eventBus.RegisterHandler(events.HealthChanged, cm.handleHealthChanged)
 	
 	log.Println("✅ Cache manager event handlers registered")
 }
@@ -338,6 +422,46 @@ func (cm *CacheManager) handleSessionExpired(ctx context.Context, event events.E
 	return nil
 }
 
+// handleHealthChanged switches to degraded mode while another service reports Redis unhealthy on the bus
+func (cm *CacheManager) handleHealthChanged(ctx context.Context, event events.Event) error {
+	data, ok := event.Data.(map[string]interface{})
+	if !ok || data["component"] != "redis" {
+		return nil
+	}
+
+	current, _ := data["current"].(string)
+	cm.setDegraded(current == string(health.StatusUnhealthy), event.Source)
+	return nil
+}
+
+// HealthListener returns a listener for the service's own health transitions, passed to
+// HealthChecker.OnTransition. Unlike bus events it is called in-process, so it still arrives when the
+// Redis carrying the bus is the component that failed
+func (cm *CacheManager) HealthListener() health.TransitionListener {
+	return func(ctx context.Context, transition health.Transition) {
+		if transition.Component == "redis" {
+			cm.setDegraded(transition.Current == health.StatusUnhealthy, transition.Service)
+		}
+	}
+}
+
+// setDegraded enters or leaves degraded mode: reads then miss immediately and writes are skipped
+// instead of waiting on connection timeouts
+func (cm *CacheManager) setDegraded(degraded bool, reportedBy string) {
+	if cm.degraded.Swap(degraded) != degraded {
+		if degraded {
+			log.Printf("⚠️ Cache manager entering degraded mode (redis reported by %s)", reportedBy)
+		} else {
+			log.Printf("✅ Cache manager leaving degraded mode")
+		}
+	}
+}
+
+// IsDegraded reports whether the cache is bypassed because Redis is unhealthy
+func (cm *CacheManager) IsDegraded() bool {
+	return cm.degraded.Load()
+}
+
 // Cache statistics (if metrics enabled)
 type CacheStats struct {
 	Hits         int64 `json:"hits"`
@@ -0,0 +1,76 @@
+package authclient
+
+import (
+	"sync"
+	"time"
+)
+
+// outcome is how a call went, as far as the auth service's health goes
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota // The service answered, whatever the status
+	outcomeFailure                // Unreachable, 429 or 5xx after every retry
+	outcomeUnknown                // The caller cancelled first
+)
+
+// breaker is a consecutive-failure circuit breaker
+// Closed lets every call through; open rejects calls until openUntil; then one trial call is let
+// through (half-open) and its outcome closes or reopens the circuit
+type breaker struct {
+	threshold int
+	openFor   time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool // A half-open trial call is in flight
+}
+
+// newBreaker returns nil, a breaker that never opens, for threshold 0
+func newBreaker(threshold int, openFor time.Duration) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &breaker{threshold: threshold, openFor: openFor}
+}
+
+// allow reports whether a call may be made; every allowed call must be followed by record
+func (b *breaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.trial || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *breaker) record(result outcome) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasTrial := b.trial
+	b.trial = false
+	switch result {
+	case outcomeSuccess:
+		b.failures = 0
+	case outcomeFailure:
+		b.failures++
+		if b.failures >= b.threshold || wasTrial {
+			b.failures = max(b.failures, b.threshold)
+			b.openUntil = time.Now().Add(b.openFor)
+		}
+	}
+	// An unknown outcome of a trial leaves the circuit open with its time up, so the next call is the trial
+}
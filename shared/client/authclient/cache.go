@@ -0,0 +1,74 @@
+package authclient
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// verificationCache keeps successful verifications for a short TTL so a service verifying the same
+// token on every request doesn't call the auth service each time. Tokens are kept only as SHA-256 hashes
+type verificationCache struct {
+	ttl  time.Duration
+	size int
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]cachedVerification
+}
+
+type cachedVerification struct {
+	verification Verification
+	expiresAt    time.Time
+}
+
+// newVerificationCache returns nil, which caches nothing, for ttl or size 0
+func newVerificationCache(ttl time.Duration, size int) *verificationCache {
+	if ttl <= 0 || size <= 0 {
+		return nil
+	}
+	return &verificationCache{ttl: ttl, size: size, entries: make(map[[sha256.Size]byte]cachedVerification)}
+}
+
+// get returns a copy of the cached verification, so callers can't change what others are served
+func (c *verificationCache) get(token string) (*Verification, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := sha256.Sum256([]byte(token))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	verification := entry.verification
+	return &verification, true
+}
+
+// put caches verification; when the cache is full of live entries the verification isn't cached
+func (c *verificationCache) put(token string, verification *Verification) {
+	if c == nil {
+		return
+	}
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.size {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.size {
+			return
+		}
+	}
+	c.entries[key] = cachedVerification{verification: *verification, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,265 @@
+// Package authclient is the Go client other services use to call the auth service: token verification,
+// the token's user and permission checks, with retries, a circuit breaker and a verification cache
+//
+// Consumers should depend on the API interface so tests can substitute Mock
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"shared/requestid"
+)
+
+// API is what consumers of the auth service call; *Client and *Mock implement it
+type API interface {
+	// VerifyToken checks an access token, personal access token or client token
+	// Rejected tokens return ErrInvalidToken
+	VerifyToken(ctx context.Context, token string) (*Verification, error)
+
+	// GetUser returns the profile of the user the token belongs to
+	GetUser(ctx context.Context, token string) (*User, error)
+
+	// CheckPermission verifies the token and reports whether it grants permission
+	// A rejected token is not an error: it is reported as not permitted
+	CheckPermission(ctx context.Context, token string, permission Permission) (bool, error)
+}
+
+// Defaults for New; each can be changed with an Option
+const (
+	DefaultTimeout          = 5 * time.Second
+	DefaultRetries          = 2
+	DefaultRetryBackoff     = 100 * time.Millisecond
+	DefaultFailureThreshold = 5
+	DefaultOpenDuration     = 30 * time.Second
+	DefaultCacheTTL         = 30 * time.Second
+	DefaultCacheSize        = 10000
+)
+
+const (
+	verifyPath  = "/api/v1/verify"
+	profilePath = "/api/v1/auth/profile"
+
+	maxResponseSize = 1 << 20
+)
+
+// Client calls the auth service over HTTP; it is safe for concurrent use
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	retries      int
+	retryBackoff time.Duration
+	userAgent    string
+
+	breaker *breaker
+	cache   *verificationCache // nil when caching is disabled
+}
+
+var _ API = (*Client)(nil)
+
+// Option customises a Client
+type Option func(*Client)
+
+// WithHTTPClient replaces the default client, e.g. to add TLS settings or instrumentation
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how often a failed call is retried and the wait before the first retry,
+// which doubles with every attempt. Only network errors, 429 and 5xx responses are retried
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = max(retries, 0)
+		c.retryBackoff = backoff
+	}
+}
+
+// WithCircuitBreaker opens the circuit after threshold consecutive failed calls; while open, calls fail
+// with ErrCircuitOpen without reaching the auth service. After openFor one trial call is let through
+func WithCircuitBreaker(threshold int, openFor time.Duration) Option {
+	return func(c *Client) {
+		c.breaker = newBreaker(threshold, openFor)
+	}
+}
+
+// WithVerificationCache caches successful verifications for ttl, keyed by a hash of the token
+// A token revoked meanwhile is still accepted from the cache until ttl passes; ttl 0 disables caching
+func WithVerificationCache(ttl time.Duration, size int) Option {
+	return func(c *Client) {
+		c.cache = newVerificationCache(ttl, size)
+	}
+}
+
+// WithUserAgent names the calling service in the auth service's logs and activity records
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New creates a client for the auth service at baseURL, e.g. http://auth-service:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		httpClient:   &http.Client{Timeout: DefaultTimeout},
+		retries:      DefaultRetries,
+		retryBackoff: DefaultRetryBackoff,
+		userAgent:    "authclient",
+		breaker:      newBreaker(DefaultFailureThreshold, DefaultOpenDuration),
+		cache:        newVerificationCache(DefaultCacheTTL, DefaultCacheSize),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Client) VerifyToken(ctx context.Context, token string) (*Verification, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+	if verification, ok := c.cache.get(token); ok {
+		return verification, nil
+	}
+
+	var verification Verification
+	if err := c.do(ctx, http.MethodPost, verifyPath, token, &verification); err != nil {
+		if isStatus(err, http.StatusUnauthorized) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		return nil, err
+	}
+	if !verification.Valid {
+		return nil, ErrInvalidToken
+	}
+	c.cache.put(token, &verification)
+	return &verification, nil
+}
+
+func (c *Client) GetUser(ctx context.Context, token string) (*User, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+
+	var user User
+	if err := c.do(ctx, http.MethodGet, profilePath, token, &user); err != nil {
+		switch {
+		case isStatus(err, http.StatusUnauthorized):
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		case isStatus(err, http.StatusNotFound):
+			return nil, fmt.Errorf("%w: %v", ErrUserNotFound, err)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) CheckPermission(ctx context.Context, token string, permission Permission) (bool, error) {
+	verification, err := c.VerifyToken(ctx, token)
+	if errors.Is(err, ErrInvalidToken) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return verification.Allows(permission), nil
+}
+
+// do sends one API call through the circuit breaker, retrying transient failures, and decodes a
+// successful response into out
+func (c *Client) do(ctx context.Context, method, path, token string, out interface{}) error {
+	if !c.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	backoff := c.retryBackoff
+	var (
+		transient bool
+		err       error
+	)
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			// Full jitter keeps callers that failed together from retrying together
+			wait := time.Duration(rand.Int63n(int64(backoff) + 1))
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
+			backoff *= 2
+		}
+		if ctx.Err() != nil {
+			// The caller gave up; that says nothing about the auth service
+			c.breaker.record(outcomeUnknown)
+			if err == nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%w (cancelled while retrying: %v)", err, ctx.Err())
+		}
+
+		transient, err = c.send(ctx, method, path, token, out)
+		if !transient {
+			break
+		}
+	}
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		c.breaker.record(outcomeUnknown)
+	case transient:
+		c.breaker.record(outcomeFailure)
+	default:
+		c.breaker.record(outcomeSuccess) // Including 4xx: the service answered
+	}
+	return err
+}
+
+// send makes a single request; transient reports a failure worth retrying: the auth service was
+// unreachable, overloaded or failed
+func (c *Client) send(ctx context.Context, method, path, token string, out interface{}) (transient bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if info, ok := requestid.FromContext(ctx); ok {
+		req.Header.Set(requestid.Header, info.RequestID)
+		if info.TraceID != "" {
+			req.Header.Set(requestid.TraceparentHeader, info.Traceparent())
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return true, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		transient = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return transient, newAPIError(resp, body)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return false, fmt.Errorf("authclient: decoding %s response: %w", path, err)
+	}
+	return false, nil
+}
+
+func isStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == status
+}
@@ -0,0 +1,52 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+)
+
+// Mock is an API for consumer tests: each method calls the matching func field, or fails with
+// ErrInvalidToken when it is nil
+//
+//	auth := &authclient.Mock{
+//		VerifyTokenFunc: func(ctx context.Context, token string) (*authclient.Verification, error) {
+//			return &authclient.Verification{Valid: true, UserID: "user-1", Role: "admin"}, nil
+//		},
+//	}
+type Mock struct {
+	VerifyTokenFunc     func(ctx context.Context, token string) (*Verification, error)
+	GetUserFunc         func(ctx context.Context, token string) (*User, error)
+	CheckPermissionFunc func(ctx context.Context, token string, permission Permission) (bool, error)
+}
+
+var _ API = (*Mock)(nil)
+
+func (m *Mock) VerifyToken(ctx context.Context, token string) (*Verification, error) {
+	if m.VerifyTokenFunc == nil {
+		return nil, ErrInvalidToken
+	}
+	return m.VerifyTokenFunc(ctx, token)
+}
+
+func (m *Mock) GetUser(ctx context.Context, token string) (*User, error) {
+	if m.GetUserFunc == nil {
+		return nil, ErrInvalidToken
+	}
+	return m.GetUserFunc(ctx, token)
+}
+
+// CheckPermission falls back to VerifyTokenFunc and Verification.Allows when CheckPermissionFunc is nil,
+// so tests can describe the token once
+func (m *Mock) CheckPermission(ctx context.Context, token string, permission Permission) (bool, error) {
+	if m.CheckPermissionFunc != nil {
+		return m.CheckPermissionFunc(ctx, token, permission)
+	}
+	verification, err := m.VerifyToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return false, nil
+		}
+		return false, err
+	}
+	return verification.Allows(permission), nil
+}
@@ -0,0 +1,160 @@
+package authclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"shared/requestid"
+)
+
+var (
+	// ErrInvalidToken means the auth service rejected the token: unknown, expired or revoked
+	ErrInvalidToken = errors.New("authclient: invalid token")
+	// ErrUserNotFound means the token is valid but its user no longer exists
+	ErrUserNotFound = errors.New("authclient: user not found")
+	// ErrCircuitOpen means recent calls failed and the client isn't calling the auth service for now
+	ErrCircuitOpen = errors.New("authclient: circuit open, auth service unavailable")
+)
+
+// Verification is the result of POST /api/v1/verify
+type Verification struct {
+	Valid    bool     `json:"valid"`
+	UserID   string   `json:"user_id,omitempty"` // Empty for client tokens, which act for a service
+	Email    string   `json:"email,omitempty"`
+	Role     string   `json:"role,omitempty"`
+	Roles    []string `json:"roles,omitempty"`     // Extra roles from active role grants
+	Scopes   []string `json:"scopes,omitempty"`    // Set only for personal access tokens and client tokens
+	ClientID string   `json:"client_id,omitempty"` // Registered client the token was issued to
+}
+
+// HasRole reports whether the token's user has role, as their role or through a role grant
+func (v *Verification) HasRole(role string) bool {
+	if role == "" {
+		return false
+	}
+	if v.Role == role {
+		return true
+	}
+	for _, r := range v.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScopes reports whether the token may be used for every scope
+// Only personal access tokens and client tokens are limited; tokens from a login cover all scopes
+func (v *Verification) HasScopes(scopes ...string) bool {
+	if len(v.Scopes) == 0 {
+		return true
+	}
+	for _, scope := range scopes {
+		granted := false
+		for _, s := range v.Scopes {
+			if s == scope {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
+}
+
+// Permission is what an operation requires of a token, checked like the auth service's own routes:
+// any one of Roles and every one of Scopes. Empty fields require nothing
+type Permission struct {
+	Roles  []string
+	Scopes []string
+}
+
+// Allows reports whether the verified token grants permission
+func (v *Verification) Allows(permission Permission) bool {
+	if len(permission.Roles) > 0 {
+		hasRole := false
+		for _, role := range permission.Roles {
+			if v.HasRole(role) {
+				hasRole = true
+				break
+			}
+		}
+		if !hasRole {
+			return false
+		}
+	}
+	return v.HasScopes(permission.Scopes...)
+}
+
+// User is the profile returned by GET /api/v1/auth/profile
+type User struct {
+	ID            string     `json:"id"`
+	Email         string     `json:"email"`
+	Username      string     `json:"username"`
+	FirstName     string     `json:"first_name,omitempty"`
+	LastName      string     `json:"last_name,omitempty"`
+	PhoneNumber   string     `json:"phone_number,omitempty"`
+	Role          string     `json:"role"`
+	IsActive      bool       `json:"is_active"`
+	EmailVerified bool       `json:"email_verified"`
+	IsVerified    bool       `json:"is_verified"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	Avatar        string     `json:"avatar,omitempty"`
+	LastLoginAt   *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// APIError is a non-200 response from the auth service
+type APIError struct {
+	Status    int
+	Title     string // "error" of a JSON error, "title" of a problem+json one
+	Detail    string // "message" or "detail"
+	RequestID string // For finding the call in the auth service's logs
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("authclient: auth service returned %d", e.Status)
+	if e.Title != "" {
+		msg += ": " + e.Title
+	}
+	if e.Detail != "" {
+		msg += " (" + e.Detail + ")"
+	}
+	if e.RequestID != "" {
+		msg += " [request " + e.RequestID + "]"
+	}
+	return msg
+}
+
+// newAPIError reads either error format the auth service writes
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{Status: resp.StatusCode, RequestID: resp.Header.Get(requestid.Header)}
+
+	var payload struct {
+		Error     string `json:"error"`
+		Message   string `json:"message"`
+		Title     string `json:"title"`
+		Detail    string `json:"detail"`
+		RequestID string `json:"request_id"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return apiErr
+	}
+	apiErr.Title = payload.Error
+	if apiErr.Title == "" {
+		apiErr.Title = payload.Title
+	}
+	apiErr.Detail = payload.Message
+	if apiErr.Detail == "" {
+		apiErr.Detail = payload.Detail
+	}
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = payload.RequestID
+	}
+	return apiErr
+}
@@ -23,6 +23,8 @@ type ConnectionConfig struct {
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	Timezone        string
+	Logger          logger.Interface // GORM logger; defaults to GORM's console logger at Info level
+	ReadOnly        bool             // Start every transaction read-only, e.g. when connected to a read replica
 }
 
 // RetryConfig contains retry logic configuration
@@ -74,6 +76,9 @@ func ConnectWithRetry(ctx context.Context, dbConfig ConnectionConfig, retryConfi
 		dbConfig.Host, dbConfig.Port, dbConfig.User, dbConfig.Password,
 		dbConfig.Name, dbConfig.SSLMode, timezone,
 	)
+	if dbConfig.ReadOnly {
+		dsn += " default_transaction_read_only=on"
+	}
 
 	var db *gorm.DB
 	var lastErr error
@@ -97,8 +102,12 @@ func ConnectWithRetry(ctx context.Context, dbConfig ConnectionConfig, retryConfi
 		log.Printf("Attempting database connection (attempt %d/%d)...", attempt+1, retryConfig.MaxRetries+1)
 		
 		// Attempt connection
+		gormLogger := dbConfig.Logger
+		if gormLogger == nil {
+			gormLogger = logger.Default.LogMode(logger.Info)
+		}
 		gormConfig := &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Info),
+			Logger: gormLogger,
 			NowFunc: func() time.Time {
 				return time.Now().UTC()
 			},
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"shared/requestid"
+)
+
+// redactedValue replaces secret parameter values in logged SQL
+const redactedValue = "[REDACTED]"
+
+// GormLoggerConfig configures the structured GORM logger
+type GormLoggerConfig struct {
+	// Level follows LoggingConfig.Level: "debug" logs every statement, "info" and "warn" log slow
+	// statements and errors, "error" logs errors only and "silent" disables SQL logging
+	Level string
+	// SlowThreshold marks statements slower than this as slow queries; zero disables slow query logging
+	SlowThreshold time.Duration
+	// IgnoreRecordNotFoundError keeps gorm.ErrRecordNotFound out of the error log
+	IgnoreRecordNotFoundError bool
+	// Logger receives the records; defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+// GormLogger adapts GORM's logger interface to log/slog
+// Parameters bound to secret-looking columns or holding secret-looking values are redacted before logging
+type GormLogger struct {
+	logger         *slog.Logger
+	level          logger.LogLevel
+	slowThreshold  time.Duration
+	ignoreNotFound bool
+}
+
+// NewGormLogger creates a GORM logger that writes structured records to cfg.Logger
+func NewGormLogger(cfg GormLoggerConfig) *GormLogger {
+	l := cfg.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+	return &GormLogger{
+		logger:         l.With("component", "gorm"),
+		level:          ParseGormLogLevel(cfg.Level),
+		slowThreshold:  cfg.SlowThreshold,
+		ignoreNotFound: cfg.IgnoreRecordNotFoundError,
+	}
+}
+
+// ParseGormLogLevel maps a LoggingConfig level to a GORM log level; unknown values behave like "info"
+func ParseGormLogLevel(level string) logger.LogLevel {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug", "trace":
+		return logger.Info
+	case "error":
+		return logger.Error
+	case "silent", "off", "none":
+		return logger.Silent
+	default:
+		return logger.Warn
+	}
+}
+
+// LogMode returns a copy of the logger at the given GORM level (used by db.Debug())
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info logs GORM informational messages
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, args...), l.contextAttrs(ctx)...)
+	}
+}
+
+// Warn logs GORM warnings
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, args...), l.contextAttrs(ctx)...)
+	}
+}
+
+// Error logs GORM errors
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...), l.contextAttrs(ctx)...)
+	}
+}
+
+// Trace logs a finished statement as an error, a slow query or (at debug level) a plain statement
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	attrs := func() []any {
+		sql, rows := fc()
+		attrs := append(l.contextAttrs(ctx),
+			slog.String("sql", sql),
+			slog.Float64("duration_ms", float64(elapsed.Nanoseconds())/1e6),
+		)
+		if rows >= 0 {
+			attrs = append(attrs, slog.Int64("rows", rows))
+		}
+		return attrs
+	}
+
+	switch {
+	case err != nil && l.level >= logger.Error && !(l.ignoreNotFound && errors.Is(err, gorm.ErrRecordNotFound)):
+		l.logger.ErrorContext(ctx, "sql error", append(attrs(), slog.String("error", err.Error()))...)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		l.logger.WarnContext(ctx, "slow query", append(attrs(), slog.Duration("threshold", l.slowThreshold))...)
+	case l.level >= logger.Info:
+		l.logger.DebugContext(ctx, "sql", attrs()...)
+	}
+}
+
+// contextAttrs correlates statements with the request that issued them (when queried WithContext)
+func (l *GormLogger) contextAttrs(ctx context.Context) []any {
+	if id := requestid.ID(ctx); id != "" {
+		return []any{slog.String("request_id", id)}
+	}
+	return nil
+}
+
+var (
+	// sensitiveColumn matches column names whose values must never be logged
+	sensitiveColumn = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|private_?key|credential|recovery_code|(^|_)otp($|_))`)
+	// comparedColumn matches `"column" = $1` in WHERE and SET clauses
+	comparedColumn = regexp.MustCompile(`"?([A-Za-z0-9_]+)"?\s*(?:=|<>|!=|IN\s*\()\s*\$(\d+)`)
+	// insertColumns captures the column list and VALUES list of an INSERT
+	insertColumns = regexp.MustCompile(`(?is)INSERT\s+INTO\s+\S+\s*\(([^)]*)\)\s*VALUES\s*(.*?)(?:\s+RETURNING|\s+ON\s+CONFLICT|$)`)
+	// placeholder matches a PostgreSQL bind variable
+	placeholder = regexp.MustCompile(`\$(\d+)`)
+	// secretValue matches password hashes and JWTs regardless of the column they are bound to
+	secretValue = regexp.MustCompile(`^(\$2[abxy]\$|\$argon2|eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.)`)
+)
+
+// ParamsFilter redacts secret parameter values before GORM renders them into the logged SQL
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if len(params) == 0 {
+		return sql, params
+	}
+
+	sensitive := sensitivePlaceholders(sql)
+	filtered := make([]interface{}, len(params))
+	for i, param := range params {
+		filtered[i] = param
+		if sensitive[i+1] || isSecretValue(param) {
+			filtered[i] = redactedValue
+		}
+	}
+	return sql, filtered
+}
+
+// sensitivePlaceholders returns the 1-based bind variable positions bound to sensitive columns
+func sensitivePlaceholders(sql string) map[int]bool {
+	positions := make(map[int]bool)
+
+	for _, match := range comparedColumn.FindAllStringSubmatch(sql, -1) {
+		if sensitiveColumn.MatchString(match[1]) {
+			if n, err := strconv.Atoi(match[2]); err == nil {
+				positions[n] = true
+			}
+		}
+	}
+
+	if match := insertColumns.FindStringSubmatch(sql); match != nil {
+		columns := strings.Split(match[1], ",")
+		// Every row of a multi-row INSERT repeats the column order
+		for _, row := range strings.Split(match[2], "),(") {
+			for i, bind := range placeholder.FindAllStringSubmatch(row, -1) {
+				if i < len(columns) && sensitiveColumn.MatchString(strings.Trim(strings.TrimSpace(columns[i]), `"`)) {
+					if n, err := strconv.Atoi(bind[1]); err == nil {
+						positions[n] = true
+					}
+				}
+			}
+		}
+	}
+
+	return positions
+}
+
+func isSecretValue(param interface{}) bool {
+	switch v := param.(type) {
+	case string:
+		return secretValue.MatchString(v)
+	case *string:
+		return v != nil && secretValue.MatchString(*v)
+	case []byte:
+		return secretValue.Match(v)
+	}
+	return false
+}
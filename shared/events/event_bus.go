@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"shared/requestid"
 )
 
 // Event represents a domain event
@@ -71,6 +72,16 @@ func (eb *EventBus) Publish(ctx context.Context, event Event) error {
 	if event.Version == "" {
 		event.Version = "1.0"
 	}
+	// Stamp the originating request so consumers can correlate the event with logs
+	if info, ok := requestid.FromContext(ctx); ok {
+		if event.Metadata == nil {
+			event.Metadata = make(map[string]interface{})
+		}
+		if _, exists := event.Metadata["request_id"]; !exists {
+			event.Metadata["request_id"] = info.RequestID
+			event.Metadata["trace_id"] = info.TraceID
+		}
+	}
 
 	data, err := json.Marshal(event)
 	if err != nil {
@@ -222,6 +233,7 @@ const (
 	ServiceStarted   = "system.service_started"
 	ServiceStopped   = "system.service_stopped"
 	HealthCheck      = "system.health_check"
+	HealthChanged    = "system.health_changed" // A dependency's health status transitioned
 	
 	// Cache Events
 	CacheInvalidated = "cache.invalidated"
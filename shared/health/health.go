@@ -24,6 +24,55 @@ const (
 // Check represents a health check function
 type Check func(ctx context.Context) CheckResult
 
+// Probe selects which checks contribute to a health evaluation
+type Probe string
+
+const (
+	ProbeAll       Probe = "all"
+	ProbeReadiness Probe = "readiness" // Should the instance receive traffic?
+	ProbeLiveness  Probe = "liveness"  // Should the instance be restarted?
+)
+
+// checkOptions controls how a check affects the overall status
+type checkOptions struct {
+	critical  bool
+	readiness bool
+	liveness  bool
+	timeout   time.Duration
+}
+
+// CheckOption customises a registered check
+type CheckOption func(*checkOptions)
+
+// Informational marks a check whose failure only degrades the service instead of making it unhealthy
+func Informational() CheckOption {
+	return func(o *checkOptions) {
+		o.critical = false
+	}
+}
+
+// LivenessOnly evaluates the check for liveness probes and leaves it out of readiness
+func LivenessOnly() CheckOption {
+	return func(o *checkOptions) {
+		o.liveness = true
+		o.readiness = false
+	}
+}
+
+// IncludeInLiveness evaluates the check for liveness probes in addition to readiness
+func IncludeInLiveness() CheckOption {
+	return func(o *checkOptions) {
+		o.liveness = true
+	}
+}
+
+// WithCheckTimeout overrides the checker-wide timeout for a single check
+func WithCheckTimeout(timeout time.Duration) CheckOption {
+	return func(o *checkOptions) {
+		o.timeout = timeout
+	}
+}
+
 // CheckResult contains the result of a health check
 type CheckResult struct {
 	Status    Status        `json:"status"`
@@ -31,6 +80,7 @@ type CheckResult struct {
 	Error     string        `json:"error,omitempty"`
 	Duration  time.Duration `json:"duration"`
 	Timestamp time.Time     `json:"timestamp"`
+	Critical  bool          `json:"critical"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -38,7 +88,11 @@ type CheckResult struct {
 type HealthChecker struct {
 	serviceName string
 	checks      map[string]Check
+	options     map[string]checkOptions
 	timeout     time.Duration
+	history     *history
+	lastStatus  map[string]Status
+	listeners   []TransitionListener
 	mu          sync.RWMutex
 }
 
@@ -51,15 +105,49 @@ func New(serviceName string, timeout time.Duration) *HealthChecker {
 	return &HealthChecker{
 		serviceName: serviceName,
 		checks:      make(map[string]Check),
+		options:     make(map[string]checkOptions),
+		lastStatus:  make(map[string]Status),
 		timeout:     timeout,
 	}
 }
 
 // AddCheck adds a health check
-func (h *HealthChecker) AddCheck(name string, check Check) {
+// By default a check is critical and counts towards readiness but not liveness
+func (h *HealthChecker) AddCheck(name string, check Check, opts ...CheckOption) {
+	options := checkOptions{critical: true, readiness: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.checks[name] = check
+	h.options[name] = options
+}
+
+// EnableHistory records every check result and turns on flap detection
+// A check that oscillates more than FlapThreshold times within FlapWindow results is reported
+// as degraded instead of toggling between healthy and unhealthy
+func (h *HealthChecker) EnableHistory(opts HistoryOptions) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.history = newHistory(opts)
+}
+
+// LoadHistory hydrates in-memory history from the configured HistoryStore (e.g. after a restart)
+func (h *HealthChecker) LoadHistory(ctx context.Context) error {
+	h.mu.RLock()
+	hist := h.history
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	h.mu.RUnlock()
+
+	if hist == nil {
+		return nil
+	}
+	return hist.load(ctx, names)
 }
 
 // RemoveCheck removes a health check
@@ -67,6 +155,8 @@ func (h *HealthChecker) RemoveCheck(name string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	delete(h.checks, name)
+	delete(h.options, name)
+	delete(h.lastStatus, name)
 }
 
 // OverallHealth contains the overall health status and individual check results
@@ -76,21 +166,61 @@ type OverallHealth struct {
 	Timestamp time.Time                `json:"timestamp"`
 	Duration  time.Duration            `json:"duration"`
 	Checks    map[string]CheckResult   `json:"checks"`
+	Trends    map[string]Trend         `json:"trends,omitempty"`
 	Metadata  map[string]interface{}   `json:"metadata,omitempty"`
 }
 
 // CheckHealth performs all health checks and returns the overall health
 func (h *HealthChecker) CheckHealth(ctx context.Context) OverallHealth {
+	return h.CheckProbe(ctx, ProbeAll)
+}
+
+// CheckProbe performs the checks that belong to the given probe and returns their overall health
+// Probes only read: they record no history and report no transitions, so a history store or event bus
+// that is down can't fail them, and probe traffic doesn't skew flap detection
+func (h *HealthChecker) CheckProbe(ctx context.Context, probe Probe) OverallHealth {
 	start := time.Now()
-	
+	results, hist := h.runChecks(ctx, probe)
+
+	var trends map[string]Trend
+	if hist != nil {
+		trends = hist.trends()
+	}
+	return h.overall(start, probe, results, trends)
+}
+
+// RecordHealth performs all health checks, records them in the history, downgrading flapping checks,
+// and notifies transition listeners. It is meant for a single periodic run, such as the status page's
+func (h *HealthChecker) RecordHealth(ctx context.Context) OverallHealth {
+	start := time.Now()
+	results, hist := h.runChecks(ctx, ProbeAll)
+
+	var trends map[string]Trend
+	if hist != nil {
+		h.applyHistory(ctx, hist, results)
+		trends = hist.trends()
+	}
+	h.detectTransitions(ctx, results)
+	return h.overall(start, ProbeAll, results, trends)
+}
+
+// runChecks runs the checks that belong to probe concurrently and returns their results and the history
+func (h *HealthChecker) runChecks(ctx context.Context, probe Probe) (map[string]CheckResult, *history) {
 	ctx, cancel := context.WithTimeout(ctx, h.timeout)
 	defer cancel()
 
 	h.mu.RLock()
 	checksToRun := make(map[string]Check, len(h.checks))
+	optionsToRun := make(map[string]checkOptions, len(h.checks))
 	for name, check := range h.checks {
+		options := h.options[name]
+		if (probe == ProbeReadiness && !options.readiness) || (probe == ProbeLiveness && !options.liveness) {
+			continue
+		}
 		checksToRun[name] = check
+		optionsToRun[name] = options
 	}
+	hist := h.history
 	h.mu.RUnlock()
 
 	results := make(map[string]CheckResult)
@@ -100,33 +230,66 @@ func (h *HealthChecker) CheckHealth(ctx context.Context) OverallHealth {
 	// Run all checks concurrently
 	for name, check := range checksToRun {
 		wg.Add(1)
-		go func(checkName string, checkFunc Check) {
+		go func(checkName string, checkFunc Check, options checkOptions) {
 			defer wg.Done()
-			result := h.runSingleCheck(ctx, checkFunc)
-			
+			result := h.runSingleCheck(ctx, checkFunc, options.timeout)
+			result.Critical = options.critical
+
 			mu.Lock()
 			results[checkName] = result
 			mu.Unlock()
-		}(name, check)
+		}(name, check, optionsToRun[name])
 	}
 
 	wg.Wait()
+	return results, hist
+}
 
-	// Determine overall status
-	overallStatus := h.calculateOverallStatus(results)
-
+// overall builds the overall health of an evaluation started at start
+func (h *HealthChecker) overall(start time.Time, probe Probe, results map[string]CheckResult, trends map[string]Trend) OverallHealth {
 	return OverallHealth{
 		Service:   h.serviceName,
-		Status:    overallStatus,
+		Status:    h.calculateOverallStatus(results),
 		Timestamp: start,
 		Duration:  time.Since(start),
 		Checks:    results,
+		Trends:    trends,
+		Metadata:  map[string]interface{}{"probe": probe},
+	}
+}
+
+// applyHistory records raw results and downgrades flapping checks to degraded
+func (h *HealthChecker) applyHistory(ctx context.Context, hist *history, results map[string]CheckResult) {
+	for name, result := range results {
+		flapping := hist.record(ctx, name, HistoryEntry{
+			Status:    result.Status,
+			Timestamp: result.Timestamp,
+			Duration:  result.Duration,
+			Error:     result.Error,
+		})
+		if !flapping {
+			continue
+		}
+
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		result.Metadata["flapping"] = true
+		result.Metadata["reported_status"] = result.Status
+		result.Status = StatusDegraded
+		results[name] = result
 	}
 }
 
 // runSingleCheck runs a single health check with timeout protection
-func (h *HealthChecker) runSingleCheck(ctx context.Context, check Check) CheckResult {
+func (h *HealthChecker) runSingleCheck(ctx context.Context, check Check, timeout time.Duration) CheckResult {
 	start := time.Now()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	
 	// Use a channel to capture the result or timeout
 	resultChan := make(chan CheckResult, 1)
@@ -163,6 +326,7 @@ func (h *HealthChecker) runSingleCheck(ctx context.Context, check Check) CheckRe
 }
 
 // calculateOverallStatus determines the overall health status based on individual check results
+// Failing informational checks only degrade the overall status
 func (h *HealthChecker) calculateOverallStatus(results map[string]CheckResult) Status {
 	if len(results) == 0 {
 		return StatusHealthy
@@ -174,7 +338,11 @@ func (h *HealthChecker) calculateOverallStatus(results map[string]CheckResult) S
 	for _, result := range results {
 		switch result.Status {
 		case StatusUnhealthy:
-			hasUnhealthy = true
+			if result.Critical {
+				hasUnhealthy = true
+			} else {
+				hasDegraded = true
+			}
 		case StatusDegraded:
 			hasDegraded = true
 		}
@@ -191,8 +359,23 @@ func (h *HealthChecker) calculateOverallStatus(results map[string]CheckResult) S
 
 // Handler returns a Gin handler for health checks
 func (h *HealthChecker) Handler() gin.HandlerFunc {
+	return h.ProbeHandler(ProbeAll)
+}
+
+// ReadinessHandler returns a Gin handler for load balancer readiness probes
+func (h *HealthChecker) ReadinessHandler() gin.HandlerFunc {
+	return h.ProbeHandler(ProbeReadiness)
+}
+
+// LivenessHandler returns a Gin handler for liveness probes
+func (h *HealthChecker) LivenessHandler() gin.HandlerFunc {
+	return h.ProbeHandler(ProbeLiveness)
+}
+
+// ProbeHandler returns a Gin handler that evaluates the checks of a single probe
+func (h *HealthChecker) ProbeHandler(probe Probe) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		health := h.CheckHealth(c.Request.Context())
+		health := h.CheckProbe(c.Request.Context(), probe)
 		
 		statusCode := http.StatusOK
 		if health.Status == StatusUnhealthy {
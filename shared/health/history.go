@@ -0,0 +1,253 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HistoryEntry is one recorded check outcome
+type HistoryEntry struct {
+	Status    Status        `json:"status"`
+	Timestamp time.Time     `json:"timestamp"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Trend summarises the recent history of a single check
+type Trend struct {
+	Samples       int           `json:"samples"`
+	HealthyRatio  float64       `json:"healthy_ratio"`
+	Transitions   int           `json:"transitions"`
+	Flapping      bool          `json:"flapping"`
+	AvgDuration   time.Duration `json:"avg_duration"`
+	LastChangedAt *time.Time    `json:"last_changed_at,omitempty"`
+	Recent        []Status      `json:"recent"`
+}
+
+// HistoryStore persists check history outside the process (e.g. across restarts and replicas)
+type HistoryStore interface {
+	Append(ctx context.Context, check string, entry HistoryEntry) error
+	Load(ctx context.Context, check string, limit int) ([]HistoryEntry, error)
+}
+
+// HistoryOptions configures history recording and flap detection
+type HistoryOptions struct {
+	Size          int          // Entries kept per check (default 60)
+	FlapWindow    int          // Most recent entries examined for flapping (default 10)
+	FlapThreshold int          // Status changes within the window that count as flapping (default 4)
+	Store         HistoryStore // Optional persistence
+}
+
+// ring is a fixed-size circular buffer of history entries
+type ring struct {
+	entries []HistoryEntry
+	next    int
+	full    bool
+}
+
+func newRing(size int) *ring {
+	return &ring{entries: make([]HistoryEntry, size)}
+}
+
+func (r *ring) add(entry HistoryEntry) {
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// ordered returns entries oldest first
+func (r *ring) ordered() []HistoryEntry {
+	if !r.full {
+		return append([]HistoryEntry(nil), r.entries[:r.next]...)
+	}
+	out := make([]HistoryEntry, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+// history holds the ring buffers of all checks
+type history struct {
+	opts  HistoryOptions
+	mu    sync.Mutex
+	rings map[string]*ring
+}
+
+func newHistory(opts HistoryOptions) *history {
+	if opts.Size <= 0 {
+		opts.Size = 60
+	}
+	if opts.FlapWindow <= 0 {
+		opts.FlapWindow = 10
+	}
+	if opts.FlapWindow > opts.Size {
+		opts.FlapWindow = opts.Size
+	}
+	if opts.FlapThreshold <= 0 {
+		opts.FlapThreshold = 4
+	}
+	return &history{opts: opts, rings: make(map[string]*ring)}
+}
+
+// record appends an entry and reports whether the check is now flapping
+func (h *history) record(ctx context.Context, check string, entry HistoryEntry) bool {
+	h.mu.Lock()
+	r, exists := h.rings[check]
+	if !exists {
+		r = newRing(h.opts.Size)
+		h.rings[check] = r
+	}
+	r.add(entry)
+	flapping := h.isFlapping(r.ordered())
+	h.mu.Unlock()
+
+	if h.opts.Store != nil {
+		// Persistence is best effort; in-memory history stays authoritative
+		_ = h.opts.Store.Append(ctx, check, entry)
+	}
+	return flapping
+}
+
+// isFlapping counts status changes within the flap window
+func (h *history) isFlapping(entries []HistoryEntry) bool {
+	if len(entries) > h.opts.FlapWindow {
+		entries = entries[len(entries)-h.opts.FlapWindow:]
+	}
+	return countTransitions(entries) >= h.opts.FlapThreshold
+}
+
+// trends summarises every check's history
+func (h *history) trends() map[string]Trend {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	trends := make(map[string]Trend, len(h.rings))
+	for check, r := range h.rings {
+		entries := r.ordered()
+		trend := Trend{
+			Samples:     len(entries),
+			Transitions: countTransitions(entries),
+			Flapping:    h.isFlapping(entries),
+			Recent:      make([]Status, 0, h.opts.FlapWindow),
+		}
+
+		var healthy int
+		var total time.Duration
+		for i, entry := range entries {
+			if entry.Status == StatusHealthy {
+				healthy++
+			}
+			total += entry.Duration
+			if i > 0 && entry.Status != entries[i-1].Status {
+				changedAt := entry.Timestamp
+				trend.LastChangedAt = &changedAt
+			}
+			if i >= len(entries)-h.opts.FlapWindow {
+				trend.Recent = append(trend.Recent, entry.Status)
+			}
+		}
+		if len(entries) > 0 {
+			trend.HealthyRatio = float64(healthy) / float64(len(entries))
+			trend.AvgDuration = total / time.Duration(len(entries))
+		}
+		trends[check] = trend
+	}
+	return trends
+}
+
+// load hydrates the ring buffers from the persistent store
+func (h *history) load(ctx context.Context, checks []string) error {
+	if h.opts.Store == nil {
+		return nil
+	}
+
+	for _, check := range checks {
+		entries, err := h.opts.Store.Load(ctx, check, h.opts.Size)
+		if err != nil {
+			return fmt.Errorf("failed to load history for %s: %w", check, err)
+		}
+
+		r := newRing(h.opts.Size)
+		for _, entry := range entries {
+			r.add(entry)
+		}
+
+		h.mu.Lock()
+		h.rings[check] = r
+		h.mu.Unlock()
+	}
+	return nil
+}
+
+func countTransitions(entries []HistoryEntry) int {
+	transitions := 0
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Status != entries[i-1].Status {
+			transitions++
+		}
+	}
+	return transitions
+}
+
+// RedisHistoryStore keeps the most recent entries of each check in a Redis list
+type RedisHistoryStore struct {
+	client  *redis.Client
+	prefix  string
+	maxSize int64
+	ttl     time.Duration
+}
+
+// NewRedisHistoryStore creates a Redis-backed history store keyed by service name
+func NewRedisHistoryStore(client *redis.Client, serviceName string, maxSize int, ttl time.Duration) *RedisHistoryStore {
+	return &RedisHistoryStore{
+		client:  client,
+		prefix:  fmt.Sprintf("health:history:%s", serviceName),
+		maxSize: int64(maxSize),
+		ttl:     ttl,
+	}
+}
+
+// Append pushes an entry and trims the list to its maximum size
+func (s *RedisHistoryStore) Append(ctx context.Context, check string, entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	key := s.key(check)
+	pipe := s.client.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, s.maxSize-1)
+	if s.ttl > 0 {
+		pipe.Expire(ctx, key, s.ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Load returns up to limit entries, oldest first
+func (s *RedisHistoryStore) Load(ctx context.Context, check string, limit int) ([]HistoryEntry, error) {
+	values, err := s.client.LRange(ctx, s.key(check), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, 0, len(values))
+	for i := len(values) - 1; i >= 0; i-- {
+		var entry HistoryEntry
+		if err := json.Unmarshal([]byte(values[i]), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (s *RedisHistoryStore) key(check string) string {
+	return fmt.Sprintf("%s:%s", s.prefix, check)
+}
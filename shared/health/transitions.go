@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"shared/events"
+)
+
+// Transition describes a check whose reported status changed between two evaluations
+type Transition struct {
+	Service   string                 `json:"service"`
+	Component string                 `json:"component"`
+	Previous  Status                 `json:"previous"` // Empty on the first evaluation
+	Current   Status                 `json:"current"`
+	Critical  bool                   `json:"critical"`
+	Error     string                 `json:"error,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// TransitionListener is notified of every status transition
+type TransitionListener func(ctx context.Context, transition Transition)
+
+// OnTransition registers a listener for status transitions
+// Listeners see the reported status, so flapping checks settle on degraded instead of emitting every swing
+func (h *HealthChecker) OnTransition(listener TransitionListener) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listeners = append(h.listeners, listener)
+}
+
+// detectTransitions compares results with the previously reported statuses and notifies listeners
+// A component that is already unhealthy or degraded on its first evaluation is reported too
+func (h *HealthChecker) detectTransitions(ctx context.Context, results map[string]CheckResult) {
+	h.mu.Lock()
+	if len(h.listeners) == 0 {
+		h.mu.Unlock()
+		return
+	}
+
+	var transitions []Transition
+	for name, result := range results {
+		previous, seen := h.lastStatus[name]
+		h.lastStatus[name] = result.Status
+		if previous == result.Status || (!seen && result.Status == StatusHealthy) {
+			continue
+		}
+
+		transitions = append(transitions, Transition{
+			Service:   h.serviceName,
+			Component: name,
+			Previous:  previous,
+			Current:   result.Status,
+			Critical:  result.Critical,
+			Error:     result.Error,
+			Metadata:  result.Metadata,
+			Timestamp: result.Timestamp,
+		})
+	}
+	listeners := append([]TransitionListener(nil), h.listeners...)
+	h.mu.Unlock()
+
+	for _, transition := range transitions {
+		for _, listener := range listeners {
+			listener(ctx, transition)
+		}
+	}
+}
+
+// EventPublisher returns a listener that publishes transitions as events.HealthChanged system events
+func EventPublisher(bus *events.EventBus) TransitionListener {
+	return func(ctx context.Context, transition Transition) {
+		event := events.NewSystemEvent(events.HealthChanged, transition.Service, map[string]interface{}{
+			"component": transition.Component,
+			"previous":  string(transition.Previous),
+			"current":   string(transition.Current),
+			"critical":  transition.Critical,
+			"error":     transition.Error,
+		})
+		event.Metadata = map[string]interface{}{
+			"component": transition.Component,
+			"status":    string(transition.Current),
+		}
+		if transition.Metadata != nil {
+			event.Metadata["details"] = transition.Metadata
+		}
+
+		// Publish on a fresh context: the probe request may already be finished
+		publishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if err := bus.Publish(publishCtx, event); err != nil {
+			log.Printf("❌ Failed to publish health transition for %s: %v", transition.Component, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIPKey is the gin context key holding the address resolved by ClientIPResolver.Middleware
+const clientIPKey = "client_ip"
+
+// ForwardedHeader is the RFC 7239 header; its for= parameters are read instead of a plain address list
+const ForwardedHeader = "Forwarded"
+
+// ClientIPResolver finds the address of the client behind trusted reverse proxies
+// Client IP headers are believed only when the connection comes from a trusted proxy. Address lists
+// (X-Forwarded-For, Forwarded) are read right to left, skipping trusted proxies, so entries a client
+// prepends itself are never used
+type ClientIPResolver struct {
+	proxies []string
+	trusted []*net.IPNet
+	headers []string
+}
+
+// NewClientIPResolver trusts the proxies at the given addresses or CIDR ranges and reads headers in order,
+// e.g. CF-Connecting-IP, X-Forwarded-For or Forwarded. No proxies means no header is believed
+func NewClientIPResolver(trustedProxies, headers []string) (*ClientIPResolver, error) {
+	r := &ClientIPResolver{proxies: trustedProxies}
+	for _, proxy := range trustedProxies {
+		network, err := parseNetwork(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", proxy, err)
+		}
+		r.trusted = append(r.trusted, network)
+	}
+	for _, header := range headers {
+		if header = strings.TrimSpace(header); header != "" {
+			r.headers = append(r.headers, http.CanonicalHeaderKey(header))
+		}
+	}
+	return r, nil
+}
+
+// parseNetwork accepts a CIDR range or a single address
+func parseNetwork(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		return network, err
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("not an IP address or CIDR range")
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// Configure applies the same trust to engine, so c.ClientIP() agrees with Resolve for every header
+// Gin can't read Forwarded; use ResolveClientIP where that header is configured
+func (r *ClientIPResolver) Configure(engine *gin.Engine) error {
+	var headers []string
+	for _, header := range r.headers {
+		if header != ForwardedHeader {
+			headers = append(headers, header)
+		}
+	}
+	engine.ForwardedByClientIP = len(headers) > 0
+	engine.RemoteIPHeaders = headers
+	return engine.SetTrustedProxies(r.proxies)
+}
+
+// Middleware resolves the client address once per request for ResolveClientIP
+func (r *ClientIPResolver) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(clientIPKey, r.Resolve(c.Request))
+		c.Next()
+	}
+}
+
+// Resolve returns the client address of req
+func (r *ClientIPResolver) Resolve(req *http.Request) string {
+	peer := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	peerIP := net.ParseIP(peer)
+	if peerIP == nil || !r.isTrusted(peerIP) {
+		return peer
+	}
+
+	for _, header := range r.headers {
+		var chain []string
+		if header == ForwardedHeader {
+			chain = forwardedFor(req.Header.Values(header))
+		} else {
+			chain = addressList(req.Header.Values(header))
+		}
+		if ip, ok := r.clientFromChain(chain); ok {
+			return ip
+		}
+	}
+	return peer
+}
+
+// clientFromChain walks a proxy chain from the nearest hop, returning the first untrusted address
+// An unparseable entry ends the walk: nothing before it can be attributed
+func (r *ClientIPResolver) clientFromChain(chain []string) (string, bool) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := parseHostIP(chain[i])
+		if ip == nil {
+			return "", false
+		}
+		if i == 0 || !r.isTrusted(ip) {
+			return ip.String(), true
+		}
+	}
+	return "", false
+}
+
+func (r *ClientIPResolver) isTrusted(ip net.IP) bool {
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// addressList splits comma-separated address headers; repeated headers are one list in order
+func addressList(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				chain = append(chain, entry)
+			}
+		}
+	}
+	return chain
+}
+
+// forwardedFor returns the for= node of every Forwarded element, e.g. for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"
+// Elements without for= are kept as empty entries so they stop the walk
+func forwardedFor(values []string) []string {
+	var chain []string
+	for _, element := range addressList(values) {
+		node := ""
+		for _, pair := range strings.Split(element, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if found && strings.EqualFold(name, "for") {
+				node = strings.Trim(value, `"`)
+				break
+			}
+		}
+		chain = append(chain, node)
+	}
+	return chain
+}
+
+// parseHostIP parses an address that may carry a port or IPv6 brackets; obfuscated or "unknown" nodes give nil
+func parseHostIP(value string) net.IP {
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	return net.ParseIP(strings.Trim(value, "[]"))
+}
+
+// ResolveClientIP returns the client address resolved by ClientIPResolver.Middleware, or c.ClientIP()
+// when the middleware isn't installed
+func ResolveClientIP(c *gin.Context) string {
+	if ip := c.GetString(clientIPKey); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
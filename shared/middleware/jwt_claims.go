@@ -3,6 +3,7 @@ package middleware
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,6 +19,34 @@ type JWTClaims struct {
 	Type      string   `json:"type"` // "access" or "refresh"
 	SessionID string   `json:"session_id,omitempty"`
 
+	// FamilyIssuedAt is when the login that started a refresh token's rotation chain happened
+	FamilyIssuedAt int64 `json:"family_iat,omitempty"`
+	// FamilyID identifies a refresh token's rotation chain, so reuse of a rotated token can end the chain
+	FamilyID string `json:"fid,omitempty"`
+	// RememberMe marks a refresh token of a login that asked to be remembered, so rotation keeps its lifetime
+	RememberMe bool `json:"rm,omitempty"`
+
+	// TokenVersion is the user's token version at issue time; the issuer rejects tokens with an older version
+	TokenVersion int64 `json:"tv,omitempty"`
+
+	// ClientID is the registered client the user logged in through, if any, or the service a client
+	// token was issued to
+	ClientID string `json:"client_id,omitempty"`
+
+	// TLSFingerprint is the JA3/JA4 fingerprint of the TLS client a refresh token was issued to, if known
+	TLSFingerprint string `json:"tlsfp,omitempty"`
+
+	// Permissions are the user's effective permissions ("resource:action"), carried as the space-separated
+	// perms claim when the issuer has permissions_claim on
+	Permissions []string `json:"perms,omitempty"`
+
+	// Scopes limits a personal access token or client token to part of the API; JWTs from a login carry
+	// none and are not limited
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Custom holds deployment-specific claims added by the issuer's claims enrichers
+	Custom map[string]interface{} `json:"custom,omitempty"`
+
 	// Standard JWT claims
 	Issuer    string `json:"iss,omitempty"`
 	Subject   string `json:"sub,omitempty"`
@@ -26,6 +55,13 @@ type JWTClaims struct {
 	NotBefore int64  `json:"nbf,omitempty"`
 }
 
+// TokenTypePersonal is the Type of claims authenticated from a personal access token rather than a JWT
+const TokenTypePersonal = "personal"
+
+// TokenTypeClient is the Type of tokens issued to services through the client-credentials grant
+// They carry a ClientID and Scopes but no user
+const TokenTypeClient = "client"
+
 // UserInfo represents basic user information extracted from JWT
 type UserInfo struct {
 	UserID   string   `json:"user_id"`
@@ -126,6 +162,16 @@ func (c JWTClaims) HasRole(role string) bool {
 	return false
 }
 
+// HasPermission checks if the token carries the permission
+func (c JWTClaims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 // HasAnyRole checks if the user has any of the specified roles
 func (c JWTClaims) HasAnyRole(roles ...string) bool {
 	if len(roles) == 0 {
@@ -151,6 +197,37 @@ func (c JWTClaims) IsRefreshToken() bool {
 	return c.Type == "refresh"
 }
 
+// IsPersonalAccessToken checks if the claims come from a personal access token
+func (c JWTClaims) IsPersonalAccessToken() bool {
+	return c.Type == TokenTypePersonal
+}
+
+// IsClientToken checks if the claims come from a token issued to a service rather than a user
+func (c JWTClaims) IsClientToken() bool {
+	return c.Type == TokenTypeClient
+}
+
+// HasScopes checks if the token may be used for every scope
+// Only personal access tokens and client tokens are limited; JWTs from a login cover all scopes
+func (c JWTClaims) HasScopes(scopes ...string) bool {
+	if !c.IsPersonalAccessToken() && !c.IsClientToken() {
+		return true
+	}
+	for _, scope := range scopes {
+		granted := false
+		for _, s := range c.Scopes {
+			if s == scope {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
+}
+
 // ToMap converts claims to jwt.MapClaims for token generation
 func (c JWTClaims) ToMap() jwt.MapClaims {
 	claims := jwt.MapClaims{
@@ -189,6 +266,44 @@ func (c JWTClaims) ToMap() jwt.MapClaims {
 		claims["nbf"] = c.NotBefore
 	}
 
+	if c.FamilyIssuedAt > 0 {
+		claims["family_iat"] = c.FamilyIssuedAt
+	}
+
+	if c.FamilyID != "" {
+		claims["fid"] = c.FamilyID
+	}
+
+	if c.RememberMe {
+		claims["rm"] = c.RememberMe
+	}
+
+	if c.TokenVersion > 0 {
+		claims["tv"] = c.TokenVersion
+	}
+
+	if c.ClientID != "" {
+		claims["client_id"] = c.ClientID
+	}
+
+	if c.TLSFingerprint != "" {
+		claims["tlsfp"] = c.TLSFingerprint
+	}
+
+	if len(c.Permissions) > 0 {
+		claims["perms"] = strings.Join(c.Permissions, " ")
+	}
+
+	if len(c.Scopes) > 0 {
+		claims["scopes"] = c.Scopes
+	}
+
+	for name, value := range c.Custom {
+		if _, exists := claims[name]; !exists {
+			claims[name] = value
+		}
+	}
+
 	return claims
 }
 
@@ -271,6 +386,59 @@ func (c *JWTClaims) FromMap(claims jwt.MapClaims) error {
 		}
 	}
 
+	if familyIAT, ok := claims["family_iat"]; ok {
+		if num, ok := familyIAT.(float64); ok {
+			c.FamilyIssuedAt = int64(num)
+		}
+	}
+
+	if familyID, ok := claims["fid"]; ok {
+		if str, ok := familyID.(string); ok {
+			c.FamilyID = str
+		}
+	}
+
+	if rememberMe, ok := claims["rm"]; ok {
+		if b, ok := rememberMe.(bool); ok {
+			c.RememberMe = b
+		}
+	}
+
+	if tokenVersion, ok := claims["tv"]; ok {
+		if num, ok := tokenVersion.(float64); ok {
+			c.TokenVersion = int64(num)
+		}
+	}
+
+	if clientID, ok := claims["client_id"]; ok {
+		if str, ok := clientID.(string); ok {
+			c.ClientID = str
+		}
+	}
+
+	if fingerprint, ok := claims["tlsfp"]; ok {
+		if str, ok := fingerprint.(string); ok {
+			c.TLSFingerprint = str
+		}
+	}
+
+	if permissions, ok := claims["perms"]; ok {
+		if str, ok := permissions.(string); ok {
+			c.Permissions = strings.Fields(str)
+		}
+	}
+
+	if scopes, ok := claims["scopes"]; ok {
+		if scopeSlice, ok := scopes.([]interface{}); ok {
+			c.Scopes = make([]string, 0, len(scopeSlice))
+			for _, scope := range scopeSlice {
+				if str, ok := scope.(string); ok {
+					c.Scopes = append(c.Scopes, str)
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -6,13 +6,36 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"shared/requestid"
 )
 
 // JWTMiddleware handles JWT authentication
 type JWTMiddleware struct {
-	secret string
+	secret  string
+	keyfunc jwt.Keyfunc // Replaces the HMAC secret when set, e.g. to verify asymmetric tokens by kid
+
+	// Bearer tokens starting with patPrefix are personal access tokens, checked by patValidator
+	patPrefix    string
+	patValidator PersonalAccessTokenValidator
+
+	// Sees every credential before it is validated, to raise the alert on planted canary keys
+	canaryCheck CanaryKeyChecker
+}
+
+// CanaryKeyChecker reports whether a presented credential is a planted canary key, raising the alert
+// and blocking the caller when it is; c is the request the credential came with
+type CanaryKeyChecker func(c *gin.Context, credential string) bool
+
+// PersonalAccessTokenValidator checks an opaque personal access token, typically by looking up its hash,
+// and returns the claims to authenticate the request with (Type TokenTypePersonal, with Scopes)
+type PersonalAccessTokenValidator interface {
+	ValidatePersonalAccessToken(token string) (*JWTClaims, error)
 }
 
+// APIKeyHeader carries a personal access token for clients that can't send a bearer token, e.g. API
+// gateways and webhook senders that only support a fixed header
+const APIKeyHeader = "X-API-Key"
+
 // NewJWTMiddleware creates a new JWT middleware instance
 func NewJWTMiddleware(secret string) *JWTMiddleware {
 	return &JWTMiddleware{
@@ -20,31 +43,55 @@ func NewJWTMiddleware(secret string) *JWTMiddleware {
 	}
 }
 
+// WithKeyfunc verifies JWTs with the keys keyfunc returns instead of the HMAC secret
+// keyfunc must check the token's signing method against the key it returns
+func (m *JWTMiddleware) WithKeyfunc(keyfunc jwt.Keyfunc) *JWTMiddleware {
+	m.keyfunc = keyfunc
+	return m
+}
+
+// WithPersonalAccessTokens makes the middleware accept personal access tokens alongside JWTs
+// Bearer tokens starting with prefix and X-API-Key headers are passed to validator instead of being
+// parsed as JWTs
+func (m *JWTMiddleware) WithPersonalAccessTokens(prefix string, validator PersonalAccessTokenValidator) *JWTMiddleware {
+	m.patPrefix = prefix
+	m.patValidator = validator
+	return m
+}
+
+// WithCanaryKeys passes bearer tokens and API keys to check before validating them. A canary key is never a valid
+// credential, so it is then rejected like any other unknown token
+func (m *JWTMiddleware) WithCanaryKeys(check CanaryKeyChecker) *JWTMiddleware {
+	m.canaryCheck = check
+	return m
+}
+
 // AuthRequired is middleware that requires valid JWT authentication
 // Returns 401 if token is missing or invalid
 func (m *JWTMiddleware) AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := extractToken(c)
+		token, apiKey := m.extractCredential(c)
 		if token == "" {
-			c.AbortWithStatusJSON(401, gin.H{
+			c.AbortWithStatusJSON(401, withRequestID(c, gin.H{
 				"error":   "Unauthorized",
 				"message": "No token provided",
-			})
+			}))
 			return
 		}
 
-		claims, err := m.validateToken(token)
+		claims, err := m.authenticate(c, token, apiKey)
 		if err != nil {
-			c.AbortWithStatusJSON(401, gin.H{
+			c.AbortWithStatusJSON(401, withRequestID(c, gin.H{
 				"error":   "Unauthorized",
 				"message": "Invalid token",
 				"details": err.Error(),
-			})
+			}))
 			return
 		}
 
 		// Set user information in context
 		m.setUserContext(c, claims)
+		c.Set("token", token) // Raw token for handlers that revoke it (e.g. logout)
 		c.Next()
 	}
 }
@@ -53,13 +100,13 @@ func (m *JWTMiddleware) AuthRequired() gin.HandlerFunc {
 // Continues processing even if token is missing or invalid
 func (m *JWTMiddleware) OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := extractToken(c)
+		token, apiKey := m.extractCredential(c)
 		if token == "" {
 			c.Next()
 			return
 		}
 
-		claims, err := m.validateToken(token)
+		claims, err := m.authenticate(c, token, apiKey)
 		if err != nil {
 			// Log error but continue processing
 			// Could add logging here
@@ -73,6 +120,14 @@ func (m *JWTMiddleware) OptionalAuth() gin.HandlerFunc {
 	}
 }
 
+// withRequestID adds the request ID set by the RequestID middleware to an error body
+func withRequestID(c *gin.Context, body gin.H) gin.H {
+	if id := c.GetString(requestid.ContextKey); id != "" {
+		body["request_id"] = id
+	}
+	return body
+}
+
 // extractToken extracts JWT token from Authorization header
 func extractToken(c *gin.Context) string {
 	bearer := c.GetHeader("Authorization")
@@ -88,16 +143,48 @@ func extractToken(c *gin.Context) string {
 	return token
 }
 
+// extractCredential returns the bearer token, or else the X-API-Key header when personal access tokens
+// are accepted; apiKey reports the latter
+func (m *JWTMiddleware) extractCredential(c *gin.Context) (token string, apiKey bool) {
+	if token := extractToken(c); token != "" {
+		return token, false
+	}
+	if m.patValidator == nil {
+		return "", false
+	}
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key, true
+	}
+	return "", false
+}
+
+// authenticate validates a credential: a personal access token when it came in X-API-Key or carries the
+// configured prefix, otherwise a JWT. API keys are never parsed as JWTs
+func (m *JWTMiddleware) authenticate(c *gin.Context, token string, apiKey bool) (*JWTClaims, error) {
+	if m.canaryCheck != nil {
+		m.canaryCheck(c, token) // A canary fails validation below, indistinguishable from a mistyped token
+	}
+	if apiKey || (m.patValidator != nil && m.patPrefix != "" && strings.HasPrefix(token, m.patPrefix)) {
+		return m.patValidator.ValidatePersonalAccessToken(token)
+	}
+	return m.validateToken(token)
+}
+
 // validateToken validates JWT token and returns claims
 func (m *JWTMiddleware) validateToken(tokenString string) (*JWTClaims, error) {
-	// Parse token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	keyfunc := m.keyfunc
+	if keyfunc == nil {
+		keyfunc = func(token *jwt.Token) (interface{}, error) {
+			// Validate signing method
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(m.secret), nil
 		}
-		return []byte(m.secret), nil
-	})
+	}
+
+	// Parse token
+	token, err := jwt.Parse(tokenString, keyfunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -137,6 +224,56 @@ func (m *JWTMiddleware) setUserContext(c *gin.Context, claims *JWTClaims) {
 	c.Set("claims", claims)
 }
 
+// RequireScopes allows the request only if the token covers every scope; JWTs from a login cover all
+// scopes, personal access tokens only those they were created with. Must run after AuthRequired()
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaimsFromContext(c)
+		if claims == nil || !claims.HasScopes(scopes...) {
+			c.AbortWithStatusJSON(403, withRequestID(c, gin.H{
+				"error":   "Insufficient scope",
+				"message": "This endpoint requires a token with the scopes: " + strings.Join(scopes, ", "),
+			}))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireClientScopes allows only client tokens covering every scope, for endpoints that services call
+// on their own behalf rather than a user's. Must run after AuthRequired()
+func RequireClientScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaimsFromContext(c)
+		if claims == nil || !claims.IsClientToken() || !claims.HasScopes(scopes...) {
+			c.AbortWithStatusJSON(403, withRequestID(c, gin.H{
+				"error":   "Insufficient scope",
+				"message": "This endpoint requires a client token with the scopes: " + strings.Join(scopes, ", "),
+			}))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermissions allows the request only if the token carries every permission. Tokens carry
+// permissions only when the issuer has permissions_claim on. Must run after AuthRequired()
+func RequirePermissions(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaimsFromContext(c)
+		for _, permission := range permissions {
+			if claims == nil || !claims.HasPermission(permission) {
+				c.AbortWithStatusJSON(403, withRequestID(c, gin.H{
+					"error":   "Insufficient permissions",
+					"message": "This endpoint requires the permissions: " + strings.Join(permissions, ", "),
+				}))
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
 // GetUserFromContext extracts user information from Gin context
 // Returns nil if no user is authenticated
 func GetUserFromContext(c *gin.Context) *UserInfo {
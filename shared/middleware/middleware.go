@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"shared/config"
+	"shared/requestid"
 )
 
 // CORSConfig contains CORS middleware configuration
@@ -76,7 +80,7 @@ func DefaultCORS() gin.HandlerFunc {
 	return CORS(CORSConfig{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
+		AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Requested-With"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           86400, // 24 hours
@@ -113,15 +117,17 @@ func Recovery() gin.HandlerFunc {
 }
 
 // RequestID adds a unique request ID to each request
+// Inbound X-Request-ID and W3C traceparent headers are honoured; the ID is returned in X-Request-ID,
+// stored in the gin context ("request_id", "trace_id") and in the request context for logs and events
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			requestID = fmt.Sprintf("%d", time.Now().UnixNano())
-		}
-		
-		c.Header("X-Request-ID", requestID)
-		c.Set("request_id", requestID)
+		info := requestid.FromHeaders(c.GetHeader(requestid.Header), c.GetHeader(requestid.TraceparentHeader))
+
+		c.Header(requestid.Header, info.RequestID)
+		c.Header(requestid.TraceparentHeader, info.Traceparent())
+		c.Set(requestid.ContextKey, info.RequestID)
+		c.Set(requestid.TraceContextKey, info.TraceID)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), info))
 		c.Next()
 	}
 }
@@ -268,42 +274,71 @@ func (j *JWTAuth) AdminRequired() gin.HandlerFunc {
 	}
 }
 
+// RateLimitOptions configures the in-memory rate limiter
+type RateLimitOptions struct {
+	Requests         int           // Requests allowed per window
+	Window           time.Duration // Window length
+	DocumentationURL string        // Problem type URI pointing clients to backoff guidance
+}
+
 // RateLimit provides basic in-memory rate limiting
 func RateLimit(requests int, duration time.Duration) gin.HandlerFunc {
+	return RateLimitWithOptions(RateLimitOptions{Requests: requests, Window: duration})
+}
+
+// RateLimitWithOptions provides in-memory rate limiting per client IP
+// Every response carries the draft IETF RateLimit-* headers so clients can pace themselves;
+// rejected requests get Retry-After and an application/problem+json body
+func RateLimitWithOptions(opts RateLimitOptions) gin.HandlerFunc {
 	type client struct {
-		requests int
+		requests  int
 		resetTime time.Time
 	}
-	
+
+	var mu sync.Mutex
 	clients := make(map[string]*client)
-	
+	policy := fmt.Sprintf("%d;w=%d", opts.Requests, int(opts.Window.Seconds()))
+
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
+		clientIP := ResolveClientIP(c)
 		now := time.Now()
-		
-		if clientData, exists := clients[clientIP]; exists {
-			if now.Before(clientData.resetTime) {
-				if clientData.requests >= requests {
-					c.JSON(http.StatusTooManyRequests, gin.H{
-						"error":     "Rate limit exceeded",
-						"timestamp": time.Now().UTC().Format(time.RFC3339),
-						"retry_after": clientData.resetTime.Sub(now).Seconds(),
-					})
-					c.Abort()
-					return
-				}
-				clientData.requests++
-			} else {
-				clientData.requests = 1
-				clientData.resetTime = now.Add(duration)
-			}
-		} else {
-			clients[clientIP] = &client{
-				requests: 1,
-				resetTime: now.Add(duration),
-			}
+
+		mu.Lock()
+		clientData, exists := clients[clientIP]
+		if !exists || !now.Before(clientData.resetTime) {
+			clientData = &client{resetTime: now.Add(opts.Window)}
+			clients[clientIP] = clientData
 		}
-		
+
+		allowed := clientData.requests < opts.Requests
+		if allowed {
+			clientData.requests++
+		}
+		remaining := opts.Requests - clientData.requests
+		resetSeconds := int(math.Ceil(clientData.resetTime.Sub(now).Seconds()))
+		mu.Unlock()
+
+		c.Header("RateLimit-Limit", strconv.Itoa(opts.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+		c.Header("RateLimit-Policy", policy)
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(resetSeconds))
+			AbortWithProblem(c, &ProblemDetails{
+				Type:   opts.DocumentationURL,
+				Title:  "Rate limit exceeded",
+				Status: http.StatusTooManyRequests,
+				Detail: fmt.Sprintf("Too many requests, retry after %d seconds", resetSeconds),
+				Extensions: map[string]interface{}{
+					"retry_after": resetSeconds,
+					"limit":       opts.Requests,
+					"window":      int(opts.Window.Seconds()),
+				},
+			})
+			return
+		}
+
 		c.Next()
 	}
 }
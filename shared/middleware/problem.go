@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the RFC 7807 media type for error responses
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is an RFC 7807 error body
+// Extensions carries additional members (e.g. retry_after) serialized alongside the standard ones
+type ProblemDetails struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Status     int                    `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Instance   string                 `json:"instance,omitempty"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// body flattens the problem and its extensions into a single JSON object
+func (p *ProblemDetails) body() map[string]interface{} {
+	body := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		body[key] = value
+	}
+
+	body["type"] = p.Type
+	body["title"] = p.Title
+	body["status"] = p.Status
+	if p.Detail != "" {
+		body["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		body["instance"] = p.Instance
+	}
+	return body
+}
+
+// AbortWithProblem writes an application/problem+json response and stops the handler chain
+// The instance defaults to the request ID set by the RequestID middleware
+func AbortWithProblem(c *gin.Context, problem *ProblemDetails) {
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Instance == "" {
+		if requestID, exists := c.Get("request_id"); exists {
+			problem.Instance, _ = requestID.(string)
+		}
+	}
+
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem.body())
+}
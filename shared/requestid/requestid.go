@@ -0,0 +1,94 @@
+// Package requestid carries the request/trace identifier through contexts, logs and events
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Header is the request ID header accepted from clients and returned on every response
+	Header = "X-Request-ID"
+	// TraceparentHeader is the W3C Trace Context header
+	TraceparentHeader = "traceparent"
+	// ContextKey is the gin context key holding the request ID
+	ContextKey = "request_id"
+	// TraceContextKey is the gin context key holding the W3C trace ID
+	TraceContextKey = "trace_id"
+	// maxLength bounds client-supplied request IDs
+	maxLength = 128
+)
+
+type contextKey struct{}
+
+// Info identifies a request and the distributed trace it belongs to
+type Info struct {
+	RequestID string
+	TraceID   string // 32 hex characters, shared by every service in the trace
+	SpanID    string // 16 hex characters, this service's span
+	Flags     string // Trace flags, "01" when sampled
+}
+
+var (
+	validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:\-]+$`)
+	traceparentRe  = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+)
+
+// FromHeaders resolves request identity from inbound headers, generating whatever is missing
+// A valid traceparent keeps the caller's trace ID; without X-Request-ID the trace ID doubles as request ID
+func FromHeaders(requestID, traceparent string) Info {
+	info := Info{SpanID: randomHex(8), Flags: "01"}
+
+	if match := traceparentRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(traceparent))); match != nil &&
+		match[1] != "ff" && strings.Trim(match[2], "0") != "" {
+		info.TraceID = match[2]
+		info.Flags = match[4]
+	} else {
+		info.TraceID = randomHex(16)
+	}
+
+	requestID = strings.TrimSpace(requestID)
+	if requestID != "" && len(requestID) <= maxLength && validRequestID.MatchString(requestID) {
+		info.RequestID = requestID
+	} else {
+		info.RequestID = info.TraceID
+	}
+	return info
+}
+
+// Traceparent renders the W3C traceparent header for this service's span
+func (i Info) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%s", i.TraceID, i.SpanID, i.Flags)
+}
+
+// NewContext returns a context carrying the request identity
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns the request identity stored in ctx
+func FromContext(ctx context.Context) (Info, bool) {
+	if ctx == nil {
+		return Info{}, false
+	}
+	info, ok := ctx.Value(contextKey{}).(Info)
+	return info, ok
+}
+
+// ID returns the request ID stored in ctx, or an empty string
+func ID(ctx context.Context) string {
+	info, _ := FromContext(ctx)
+	return info.RequestID
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("requestid: failed to read random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
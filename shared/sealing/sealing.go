@@ -0,0 +1,19 @@
+// Package sealing lets the session and cache managers encrypt user data under a key of the user it
+// belongs to, so destroying that one key makes every copy in Redis unreadable without finding them
+package sealing
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrShredded is returned by Open when the user's key was destroyed; the data can never be read again
+var ErrShredded = errors.New("user data key was shredded")
+
+// Sealer encrypts and decrypts data under a key of the user it belongs to
+type Sealer interface {
+	// Seal encrypts plaintext under userID's key, creating the key on first use
+	Seal(ctx context.Context, userID string, plaintext []byte) ([]byte, error)
+	// Open decrypts what Seal returned for userID; it returns ErrShredded once the key is destroyed
+	Open(ctx context.Context, userID string, ciphertext []byte) ([]byte, error)
+}
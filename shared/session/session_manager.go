@@ -3,12 +3,14 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"shared/events"
 	"shared/redis"
+	"shared/sealing"
 
 	redisClient "github.com/redis/go-redis/v9"
 )
@@ -27,6 +29,9 @@ type Session struct {
 	IPAddress string                 `json:"ip_address,omitempty"`
 	UserAgent string                 `json:"user_agent,omitempty"`
 	Active    bool                   `json:"active"`
+
+	// SealedData holds Data encrypted under the user's data key when a sealer is set; Data is not stored then
+	SealedData []byte `json:"sealed_data,omitempty"`
 }
 
 // SessionManager provides distributed session management
@@ -34,6 +39,7 @@ type SessionManager struct {
 	redis    *redis.RedisManager
 	eventBus *events.EventBus
 	config   Config
+	sealer   sealing.Sealer // Optional; Data is stored in the clear without it
 }
 
 // Config contains session configuration
@@ -65,6 +71,13 @@ func NewSessionManager(client *redisClient.Client, eventBus *events.EventBus, co
 	return sm
 }
 
+// WithDataSealer encrypts session Data under the user's data key, so destroying the key makes the Data
+// of every stored session unreadable; sessions whose key was destroyed are treated as not found
+func (sm *SessionManager) WithDataSealer(sealer sealing.Sealer) *SessionManager {
+	sm.sealer = sealer
+	return sm
+}
+
 // CreateSession creates a new session
 func (sm *SessionManager) CreateSession(ctx context.Context, session Session) error {
 	// Set session metadata
@@ -86,7 +99,7 @@ func (sm *SessionManager) CreateSession(ctx context.Context, session Session) er
 	sessionKey := sm.sessionKey(session.ID)
 	ttl := time.Until(session.ExpiresAt)
 	
-	if err := sm.redis.Set(ctx, sessionKey, session, ttl); err != nil {
+	if err := sm.save(ctx, sessionKey, session, ttl); err != nil {
 		return fmt.Errorf("failed to create session: %w", err)
 	}
 	
@@ -132,6 +145,14 @@ func (sm *SessionManager) GetSession(ctx context.Context, sessionID string) (*Se
 		}
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
+	if err := sm.openData(ctx, &session); err != nil {
+		if errors.Is(err, sealing.ErrShredded) {
+			// The user was deleted; what is left of the session can't be read by anyone
+			sm.redis.Delete(ctx, sessionKey)
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to open session data: %w", err)
+	}
 	
 	// Check if session is expired
 	if time.Now().UTC().After(session.ExpiresAt) {
@@ -175,7 +196,7 @@ func (sm *SessionManager) UpdateSession(ctx context.Context, sessionID string, u
 	sessionKey := sm.sessionKey(sessionID)
 	ttl := time.Until(session.ExpiresAt)
 	
-	return sm.redis.Set(ctx, sessionKey, session, ttl)
+	return sm.save(ctx, sessionKey, *session, ttl)
 }
 
 // RefreshSession extends session TTL
@@ -193,7 +214,7 @@ func (sm *SessionManager) RefreshSession(ctx context.Context, sessionID string)
 	sessionKey := sm.sessionKey(sessionID)
 	ttl := time.Until(session.ExpiresAt)
 	
-	return sm.redis.Set(ctx, sessionKey, session, ttl)
+	return sm.save(ctx, sessionKey, *session, ttl)
 }
 
 // DeleteSession removes a session
@@ -318,6 +339,39 @@ func (sm *SessionManager) enforceSessionLimit(ctx context.Context, userID string
 	return nil
 }
 
+// save stores session, with its Data sealed when a sealer is set
+func (sm *SessionManager) save(ctx context.Context, key string, session Session, ttl time.Duration) error {
+	if sm.sealer != nil && len(session.Data) > 0 {
+		plaintext, err := json.Marshal(session.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal session data: %w", err)
+		}
+		sealed, err := sm.sealer.Seal(ctx, session.UserID, plaintext)
+		if err != nil {
+			return fmt.Errorf("failed to seal session data: %w", err)
+		}
+		session.Data = nil
+		session.SealedData = sealed
+	}
+	return sm.redis.Set(ctx, key, session, ttl)
+}
+
+// openData decrypts SealedData back into Data
+func (sm *SessionManager) openData(ctx context.Context, session *Session) error {
+	if len(session.SealedData) == 0 {
+		return nil
+	}
+	if sm.sealer == nil {
+		return fmt.Errorf("session data is sealed but no sealer is set")
+	}
+	plaintext, err := sm.sealer.Open(ctx, session.UserID, session.SealedData)
+	if err != nil {
+		return err
+	}
+	session.SealedData = nil
+	return json.Unmarshal(plaintext, &session.Data)
+}
+
 // Key generation helpers
 func (sm *SessionManager) sessionKey(sessionID string) string {
 	return fmt.Sprintf("%s:%s", sm.config.SessionKeyPrefix, sessionID)
@@ -338,9 +392,6 @@ func (sm *SessionManager) startCleanupRoutine() {
 }
 
 func (sm *SessionManager) cleanupExpiredSessions() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	
 	// This would scan for expired sessions and remove them
 	// Implementation depends on Redis scanning strategy
 	log.Println("🧹 Running session cleanup routine")